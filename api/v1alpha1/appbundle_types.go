@@ -17,28 +17,1870 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// PlacementPolicy selects the clusters of the bundles without placement label
+// +kubebuilder:validation:Enum=AllClusters
+type PlacementPolicy string
+
+const (
+	// PlacementAllClusters selects all the clusters of the cluster sets bound to the
+	// namespace of the bundle
+	PlacementAllClusters PlacementPolicy = "AllClusters"
+)
+
 // AppBundleSpec defines the desired state of AppBundle
 type AppBundleSpec struct {
-	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	workapiv1.ManifestWorkSpec `json:",inline"`
+
+	// WorkloadRefs references ConfigMaps or Secrets in the bundle namespace holding
+	// YAML documents, which are distributed together with the inline workload manifests.
+	// +optional
+	WorkloadRefs []WorkloadReference `json:"workloadRefs,omitempty"`
+
+	// Components split the workload into named parts, each rendered from its own
+	// manifests and workload references into its own target namespace, and distributed
+	// after the inline workload manifests
+	// +optional
+	Components []Component `json:"components,omitempty"`
+
+	// Flux ships Flux objects, reconciled by Flux on the managed clusters, together with
+	// the workload manifests, instead of rendering the workload on the hub
+	// +optional
+	Flux *FluxSource `json:"flux,omitempty"`
+
+	// Analysis runs metric queries against the clusters the bundle is distributed to,
+	// using the metrics endpoint configured in KealmConfig
+	// +optional
+	Analysis *Analysis `json:"analysis,omitempty"`
+
+	// Monitoring labels the Services of the bundle with the bundle name and generation
+	// and generates ServiceMonitors scraping them
+	// +optional
+	Monitoring *Monitoring `json:"monitoring,omitempty"`
+
+	// ClusterSelection narrows the clusters of the placement decision down to the
+	// cheapest or best scored ones
+	// +optional
+	ClusterSelection *ClusterSelection `json:"clusterSelection,omitempty"`
+
+	// Spread constrains how the clusters the bundle is distributed to spread across
+	// the topology domains defined by ManagedCluster labels
+	// +optional
+	Spread []SpreadConstraint `json:"spread,omitempty"`
+
+	// Priority of the bundle. When reconciles are throttled, for example while the hub
+	// recovers, bundles with a higher priority are reconciled first. Defaults to 0.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// Prune deletes from the managed clusters the resources removed from the bundle,
+	// otherwise they are orphaned. Resources annotated with
+	// cluster.open-cluster-management.io/prune override it. Defaults to true.
+	// +optional
+	Prune *bool `json:"prune,omitempty"`
+
+	// RetainedKinds lists the kinds, as Kind or Kind.group, whose resources are never
+	// deleted from the managed clusters, in addition to the ones listed in KealmConfig
+	// +optional
+	RetainedKinds []string `json:"retainedKinds,omitempty"`
+
+	// Instance makes the bundle an instance of a workload deployed several times on the
+	// same clusters, e.g. a preview environment created by CI with generateName
+	// +optional
+	Instance *Instance `json:"instance,omitempty"`
+
+	// PlacementPolicy AllClusters distributes the bundle to all the clusters of the
+	// ManagedClusterSets bound to its namespace, through a Placement managed by kealm,
+	// instead of the Placement of the cluster.open-cluster-management.io/placement label
+	// +optional
+	PlacementPolicy PlacementPolicy `json:"placementPolicy,omitempty"`
+
+	// FallbackPlacement is the name of a Placement of the namespace whose decision the
+	// bundle is distributed to while the decision of its placement has no registered
+	// cluster, e.g. cloud clusters for a bundle preferring on-prem ones. The bundle
+	// switches back as soon as its placement decides a cluster again.
+	// +optional
+	FallbackPlacement string `json:"fallbackPlacement,omitempty"`
+
+	// TargetNamespace moves the namespaced resources of the workload manifests to the
+	// namespace. Cluster-scoped resources, e.g. Namespaces, ClusterRoles and
+	// CustomResourceDefinitions, are never moved, so it is invalid when the manifests
+	// only define cluster-scoped resources.
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// Scaling adjusts the replicas of the Deployments and StatefulSets of the workload
+	// manifests to the clusters, e.g. one replica on small edge clusters
+	// +optional
+	Scaling *Scaling `json:"scaling,omitempty"`
+
+	// AutoscaledWorkloads names the Deployments and StatefulSets autoscaled on the
+	// managed clusters by HorizontalPodAutoscalers not defined in the bundle. Their
+	// replicas, like the replicas of the targets of the autoscalers of the bundle, are
+	// left to the autoscalers.
+	// +optional
+	AutoscaledWorkloads []string `json:"autoscaledWorkloads,omitempty"`
+
+	// Architectures restricts the bundle to the clusters of the CPU architectures it
+	// supports, and selects the variants of its images for the architecture of each
+	// cluster
+	// +optional
+	Architectures *Architectures `json:"architectures,omitempty"`
+
+	// OpenShift adjusts the manifests distributed to the OpenShift clusters, so that
+	// a single bundle is distributed to OpenShift and other Kubernetes clusters
+	// +optional
+	OpenShift *OpenShiftProfile `json:"openShift,omitempty"`
+
+	// WriteBack records the state resolved for the clusters of the bundle in a Git
+	// repository, committed each time it changes
+	// +optional
+	WriteBack *WriteBack `json:"writeBack,omitempty"`
+
+	// SecretValues are looked up in the external secret backends of the KealmConfig
+	// when the manifests are rendered for each cluster, and referenced in the templates
+	// of the bundles with cluster templating as {{ .Secrets.NAME }}
+	// +optional
+	SecretValues []SecretValue `json:"secretValues,omitempty"`
+
+	// Bandwidth reduces the writes and the size of the works of the bundle, for
+	// clusters behind constrained links
+	// +optional
+	Bandwidth *Bandwidth `json:"bandwidth,omitempty"`
+
+	// AvailabilityPolicy tolerates clusters temporarily not available, e.g. edge sites
+	// occasionally connected to the hub
+	// +optional
+	AvailabilityPolicy *AvailabilityPolicy `json:"availabilityPolicy,omitempty"`
+
+	// Drain drains the clusters the bundle is removed from by a placement change before
+	// deleting its works, to avoid an abrupt loss of traffic
+	// +optional
+	Drain *Drain `json:"drain,omitempty"`
+
+	// Requires lists the bundles, e.g. platform bundles installing an ingress
+	// controller, which must be Available on a cluster before the bundle is distributed
+	// to it
+	// +optional
+	Requires []BundleRequirement `json:"requires,omitempty"`
+
+	// AntiAffinity lists the bundles, e.g. the other stack of a blue/green pair, which
+	// must not be distributed to the same clusters as the bundle. A cluster decided for
+	// both stays with the bundle already distributed to it, else with the older bundle.
+	// +optional
+	AntiAffinity []BundleAntiAffinity `json:"antiAffinity,omitempty"`
+
+	// BlueGreen deploys each new generation of the bundle next to the previous one on
+	// the same clusters, switches the traffic to it once it is Available on all the
+	// clusters, then removes the previous one
+	// +optional
+	BlueGreen *BlueGreen `json:"blueGreen,omitempty"`
+
+	// Rollout distributes each new content of the bundle to its clusters in ordered
+	// waves, a wave starting once the gates of the previous one passed
+	// +optional
+	Rollout *Rollout `json:"rollout,omitempty"`
+
+	// RolloutOverrides pause waves of the rollout plan and move clusters to later waves
+	// +optional
+	RolloutOverrides *RolloutOverrides `json:"rolloutOverrides,omitempty"`
+
+	// Certificates requests TLS certificates from cert-manager on each cluster, with DNS
+	// names specific to the cluster
+	// +optional
+	Certificates []BundleCertificate `json:"certificates,omitempty"`
+
+	// GlobalDNS publishes the clusters the bundle is Available on behind a global DNS
+	// record, with an ExternalDNS DNSEndpoint in the bundle namespace on the hub
+	// +optional
+	GlobalDNS *GlobalDNS `json:"globalDNS,omitempty"`
+
+	// HubAccess provisions the workloads of the bundle with a kubeconfig to call back
+	// to the hub, scoped by a Role of the bundle namespace
+	// +optional
+	HubAccess *HubAccess `json:"hubAccess,omitempty"`
+
+	// VersionSkew blocks the rollouts of agents talking back to a hub component whose
+	// version, the app.kubernetes.io/version label of the bundle, is not compatible
+	// with the version of the hub component
+	// +optional
+	VersionSkew *VersionSkewPolicy `json:"versionSkew,omitempty"`
+
+	// Template distributes the workload of a version of an AppBundleTemplate, before
+	// the workload manifests of the bundle
+	// +optional
+	Template *TemplateReference `json:"template,omitempty"`
+
+	// ClusterTemplating executes the templates in the manifests with the context of
+	// each cluster, e.g. {{ .Region }} or {{ index .Claims "id.k8s.io" }}, and passes it
+	// to the Helm releases as the clusterContext value
+	// +optional
+	ClusterTemplating bool `json:"clusterTemplating,omitempty"`
+
+	// ImageUpdates update the tags of images in the inline manifests to the latest tags
+	// of their registry matching a policy
+	// +optional
+	ImageUpdates []ImageUpdatePolicy `json:"imageUpdates,omitempty"`
+
+	// SBOMs reference the software bills of materials of the images, recorded in the
+	// image inventory of the bundle
+	// +optional
+	SBOMs []SBOMReference `json:"sboms,omitempty"`
+}
+
+// SBOMReference references the software bill of materials of an image
+type SBOMReference struct {
+	// Image is the image reference, or its repository for all its tags
+	Image string `json:"image"`
+
+	// URL of the SBOM document
+	URL string `json:"url"`
+}
+
+// ImageUpdatePolicy selects the tag of an image among the tags of its registry. The
+// latest tag in semantic version order is selected when SemVer is set, otherwise the
+// latest in lexical order.
+type ImageUpdatePolicy struct {
+	// Image is the image repository, without tag, e.g. ghcr.io/acme/web
+	Image string `json:"image"`
+
+	// SemVer is the range of the semantic versions selected, as space separated
+	// comparisons, e.g. ">=1.2.0 <2.0.0"
+	// +optional
+	SemVer string `json:"semver,omitempty"`
+
+	// Pattern is a regular expression the selected tags must match
+	// +optional
+	Pattern string `json:"pattern,omitempty"`
+
+	// Interval between two checks of the registry, defaults to 5m. Registry webhooks
+	// received by the webhook receiver trigger a check immediately.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// Architecture is a CPU architecture of the nodes of the clusters
+// +kubebuilder:validation:Enum=amd64;arm64;s390x;ppc64le
+type Architecture string
+
+// Architectures describes the CPU architectures of the clusters a bundle runs on. The
+// architecture of a cluster is its architecture.open-cluster-management.io claim, or
+// its kubernetes.io/arch label.
+type Architectures struct {
+	// Supported lists the architectures the bundle runs on, the clusters of other
+	// architectures being skipped. The clusters of unknown architecture are never
+	// skipped. All the architectures are supported when empty.
+	// +optional
+	Supported []Architecture `json:"supported,omitempty"`
+
+	// Images map images of the manifests to their variants per architecture, for the
+	// images not published as multi-architecture indexes
+	// +optional
+	Images []ArchitectureImage `json:"images,omitempty"`
+}
+
+// ArchitectureImage maps an image to its variants per architecture
+type ArchitectureImage struct {
+	// Image is the repository of the image in the manifests, e.g. quay.io/acme/web
+	Image string `json:"image"`
+
+	// Variants map architectures to the image run on their clusters: a repository,
+	// keeping the tag of the manifests, e.g. quay.io/acme/web-arm64, or a reference
+	// with a tag or digest
+	Variants map[Architecture]string `json:"variants"`
+}
+
+// OpenShiftProfile lists the adjustments of the manifests distributed to the
+// OpenShift clusters, whose product.open-cluster-management.io claim is OpenShift or
+// one of its managed offerings
+type OpenShiftProfile struct {
+	// SecurityContext removes the user, group and fsGroup IDs set in the security
+	// contexts of the pods, which the restricted security context constraint rejects
+	// unless in the range of the namespace, and disallows the privilege escalation of
+	// the unprivileged containers
+	// +optional
+	SecurityContext bool `json:"securityContext,omitempty"`
+
+	// Routes replaces the Ingresses with Routes to their backends, with edge TLS
+	// termination for the hosts of their TLS sections, serving the default certificate
+	// of the router
+	// +optional
+	Routes bool `json:"routes,omitempty"`
+}
+
+// SecretValue is a value looked up in an external secret backend
+type SecretValue struct {
+	// Name of the value in the templates
+	Name string `json:"name"`
+
+	// ValueFrom is the secret the value is looked up in
+	ValueFrom SecretValueSource `json:"valueFrom"`
+}
+
+// SecretValueSource references a secret of one of the external secret backends. Its
+// path is a template executed with the cluster context, e.g. secret/data/{{ .Name }}/db
+// for a secret per cluster.
+type SecretValueSource struct {
+	// Vault reads a key of a secret of Vault
+	// +optional
+	Vault *VaultSecretReference `json:"vault,omitempty"`
+
+	// AWSSecretsManager reads a secret of AWS Secrets Manager
+	// +optional
+	AWSSecretsManager *AWSSecretReference `json:"awsSecretsManager,omitempty"`
+}
+
+// VaultSecretReference references a key of a secret of Vault
+type VaultSecretReference struct {
+	// Path of the secret, e.g. secret/data/shop/db for the KV version 2 engine
+	Path string `json:"path"`
+
+	// Key of the value in the secret
+	Key string `json:"key"`
+}
+
+// AWSSecretReference references a secret of AWS Secrets Manager
+type AWSSecretReference struct {
+	// SecretID is the name or ARN of the secret
+	SecretID string `json:"secretId"`
+
+	// Key of the value in the secret string parsed as a JSON object, the whole secret
+	// string when empty
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// WriteBackContent is the content recorded in Git for the clusters of a bundle
+// +kubebuilder:validation:Enum=Digests;Manifests
+type WriteBackContent string
+
+const (
+	// WriteBackDigests records the digest of the manifests and the images of each
+	// cluster
+	WriteBackDigests WriteBackContent = "Digests"
+	// WriteBackManifests also records the manifests rendered for each cluster
+	WriteBackManifests WriteBackContent = "Manifests"
+)
+
+// WriteBack configures the Git repository the resolved state of a bundle is written to,
+// through the API of its GitHub, GitLab or Gitea host
+type WriteBack struct {
+	// URL of the repository, e.g. https://github.com/acme/fleet-state
+	URL string `json:"url"`
+
+	// Branch the state is committed to
+	// +kubebuilder:default=main
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Path of the directory of the bundle in the repository, defaults to
+	// NAMESPACE/NAME
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// CredentialsSecret is the Secret of the bundle namespace holding the token
+	// allowed to commit to the branch in its token key
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// Content recorded for each cluster
+	// +kubebuilder:default=Digests
+	// +optional
+	Content WriteBackContent `json:"content,omitempty"`
+}
+
+// Instance suffixes the names of the namespaces and of the other cluster-scoped
+// resources defined by the bundle, moving the resources of the namespaces to the
+// suffixed ones, so that the instances do not collide on the managed clusters
+type Instance struct {
+	// Suffix appended to the names. Defaults to the suffix generated for the bundle name
+	// when created with generateName, otherwise to the first characters of its UID.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=16
+	// +optional
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// Scaling selects the replicas of the workloads per cluster
+type Scaling struct {
+	// Rules are matched in order against the labels of each cluster, the first
+	// matching rule applies. The replicas of the manifests are kept on the clusters no
+	// rule matches.
+	Rules []ScalingRule `json:"rules"`
+}
+
+// ScalingRule sets the replicas of the workloads on the clusters it selects. Either
+// Replicas or Multiplier must be set.
+type ScalingRule struct {
+	// ClusterSelector selects the clusters by label, an empty selector matching all
+	// the clusters
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+
+	// Workloads restricts the rule to the Deployments and StatefulSets of these names,
+	// defaults to all of them
+	// +optional
+	Workloads []string `json:"workloads,omitempty"`
+
+	// Replicas replaces the replicas of the workloads
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Multiplier scales the replicas of the workloads, rounded up, e.g. 0.5 or 2
+	// +optional
+	Multiplier *resource.Quantity `json:"multiplier,omitempty"`
+}
+
+// Bandwidth skips the writes of the works whose content, labels and annotations are
+// unchanged. Works edited on the hub are then only restored when
+// the bundle changes.
+type Bandwidth struct {
+	// CompressConfigMapsOver packs the ConfigMaps whose data exceeds the size gzip
+	// compressed, unpacked on the managed clusters by a Job
+	// +optional
+	CompressConfigMapsOver *resource.Quantity `json:"compressConfigMapsOver,omitempty"`
+
+	// UnpackImage is the image of the unpacking Jobs, providing sh, gunzip and kubectl,
+	// defaults to bitnami/kubectl:1.22
+	// +optional
+	UnpackImage string `json:"unpackImage,omitempty"`
+}
+
+// AvailabilityPolicy controls how the clusters not available affect the bundle
+type AvailabilityPolicy struct {
+	// Toleration is how long the clusters may be not available before the bundle is
+	// Degraded, defaults to 0
+	// +optional
+	Toleration *metav1.Duration `json:"toleration,omitempty"`
+
+	// ProceedPastUnreachable leaves the clusters not available beyond the toleration
+	// out of the analysis and of the rollout SLO, so that they do not hold the rollout
+	// +optional
+	ProceedPastUnreachable bool `json:"proceedPastUnreachable,omitempty"`
+}
+
+// Drain updates the works of the clusters left by the bundle with their workloads
+// scaled to zero and a hook Job, and deletes them once the agent applied the update and
+// the grace period elapsed, or the timeout expired
+type Drain struct {
+	// ScaleToZero scales the Deployments and StatefulSets to zero replicas
+	// +optional
+	ScaleToZero bool `json:"scaleToZero,omitempty"`
+
+	// Hook is a Job run on the clusters before the works are deleted, e.g. to
+	// deregister the cluster from a global load balancer
+	// +optional
+	Hook *workapiv1.Manifest `json:"hook,omitempty"`
+
+	// GracePeriod is waited after the drained works are applied, defaults to 1m
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+
+	// Timeout deletes the works not drained within it, e.g. of unreachable clusters,
+	// defaults to 10m
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// TemplateReference references a version of an AppBundleTemplate
+type TemplateReference struct {
+	// Name of the template
+	Name string `json:"name"`
+
+	// Namespace of the template, defaults to the namespace of the bundle. The templates
+	// of another namespace must be synced from a Catalog the bundle namespace is a
+	// tenant of.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Version of the template, defaults to its latest version
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Parameters given to the template
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// BundleRequirement references required bundles, by name or by label. Either Name or
+// Selector must be set.
+type BundleRequirement struct {
+	// Name of the required bundle
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Selector selects the required bundles by label
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Namespace of the required bundles, defaults to the namespace of the bundle
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// BundleAntiAffinity references the bundles a bundle must not share clusters with, by
+// name or by label. Either Name or Selector must be set.
+type BundleAntiAffinity struct {
+	// Name of the bundle
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Selector selects the bundles by label
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Namespace of the bundles, defaults to the namespace of the bundle
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// VersionSkewPolicy declares the versions of the agents of a bundle compatible with the
+// versions of a hub component
+type VersionSkewPolicy struct {
+	// HubComponent is the hub Deployment the agents talk to. Its version is its
+	// app.kubernetes.io/version label, else the tag of the image of its first container.
+	HubComponent HubComponentReference `json:"hubComponent"`
+
+	// Compatibility lists the ranges of agent versions compatible with ranges of hub
+	// versions. The agent version must be in the agent range of one of the entries
+	// whose hub range has the hub version.
+	// +kubebuilder:validation:MinItems=1
+	Compatibility []VersionCompatibility `json:"compatibility"`
+}
+
+// HubComponentReference references a Deployment of the hub
+type HubComponentReference struct {
+	// Namespace of the Deployment
+	Namespace string `json:"namespace"`
+
+	// Name of the Deployment
+	Name string `json:"name"`
+}
+
+// VersionCompatibility declares the agent versions compatible with hub versions. The
+// ranges are space separated lists of comparisons, e.g. ">=1.4.0 <1.5.0".
+type VersionCompatibility struct {
+	// Hub is the range of hub versions
+	Hub string `json:"hub"`
+
+	// Agent is the range of agent versions compatible with them
+	Agent string `json:"agent"`
+}
+
+// The colors of the blue/green bundles
+const (
+	ColorBlue  = "blue"
+	ColorGreen = "green"
+)
+
+// ComponentType is the way the manifests of a component are dispatched
+// +kubebuilder:validation:Enum=Workload;Crossplane
+type ComponentType string
+
+const (
+	// ComponentWorkload components are distributed to the managed clusters
+	ComponentWorkload ComponentType = "Workload"
+	// ComponentCrossplane components are Crossplane claims, or other resources
+	// provisioning infrastructure, applied on the hub in the namespace of the bundle
+	ComponentCrossplane ComponentType = "Crossplane"
+)
+
+// Component is a named part of the workload of a bundle
+type Component struct {
+	// Name of the component, unique in the bundle
+	Name string `json:"name"`
+
+	// Type of the component. The manifests of Crossplane components are applied on the
+	// hub instead of being distributed, and the other manifests are distributed once
+	// their outputs are available.
+	// +kubebuilder:default=Workload
+	// +optional
+	Type ComponentType `json:"type,omitempty"`
+
+	// Outputs of the claims of a Crossplane component, referenced in the templates of
+	// the bundles with cluster templating as {{ .Outputs.COMPONENT.OUTPUT }}
+	// +optional
+	Outputs []ComponentOutput `json:"outputs,omitempty"`
+
+	// Manifests of the component
+	// +optional
+	Manifests []workapiv1.Manifest `json:"manifests,omitempty"`
+
+	// WorkloadRefs references ConfigMaps or Secrets in the bundle namespace holding the
+	// manifests of the component
+	// +optional
+	WorkloadRefs []WorkloadReference `json:"workloadRefs,omitempty"`
+
+	// TargetNamespace moves the namespaced resources of the component to the
+	// namespace, defaults to the target namespace of the bundle
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// DependsOn gates the changes of the component on a cluster: they are applied once
+	// the manifests of the listed components are Available there, until then the
+	// component keeps its previous manifests on the cluster
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// OS constrains the pods of the component to the nodes of an operating system, and
+	// the component to the clusters advertising nodes of that operating system
+	// +optional
+	OS *OSConstraint `json:"os,omitempty"`
+}
+
+// ComponentOutput is a value read from a claim of a Crossplane component, either one
+// of its fields or a key of its connection Secret
+type ComponentOutput struct {
+	// Name of the output
+	Name string `json:"name"`
+
+	// Claim is the name of the claim of the component the output is read from
+	Claim string `json:"claim"`
+
+	// FieldPath is the dot separated path of a field of the claim, e.g. status.address
+	// +optional
+	FieldPath string `json:"fieldPath,omitempty"`
+
+	// ConnectionSecretKey is a key of the Secret the claim writes its connection
+	// details to, e.g. endpoint
+	// +optional
+	ConnectionSecretKey string `json:"connectionSecretKey,omitempty"`
+}
+
+// OperatingSystem is the operating system of the nodes of a cluster
+// +kubebuilder:validation:Enum=linux;windows
+type OperatingSystem string
+
+// OSConstraint constrains a component to an operating system. The operating systems
+// of a cluster are listed by its os.open-cluster-management.io claim, as comma
+// separated values, e.g. linux,windows. The clusters without the claim only run linux.
+type OSConstraint struct {
+	// Name of the operating system, set as the kubernetes.io/os node selector of the
+	// pods of the component
+	Name OperatingSystem `json:"name"`
+
+	// Tolerations added to the pods of the component, for the taints of the node pools
+	// of the operating system. The windows pods tolerate the os=windows and
+	// node.kubernetes.io/os=windows NoSchedule taints when not set.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// ComponentStatus reports the distribution of a component of the bundle
+type ComponentStatus struct {
+	// Name of the component
+	Name string `json:"name"`
+
+	// Digest of the rendered manifests of the component
+	Digest string `json:"digest,omitempty"`
+
+	// Manifests is the number of rendered manifests of the component
+	Manifests int32 `json:"manifests"`
+
+	// AvailableClusters is the number of clusters where all the manifests of the
+	// component are Available
+	AvailableClusters int32 `json:"availableClusters"`
+
+	// Waiting lists the clusters where the changes of the component wait for the
+	// components it depends on
+	// +optional
+	Waiting []string `json:"waiting,omitempty"`
+
+	// Unsupported lists the clusters the component is not distributed to, as they do not
+	// advertise nodes of its operating system
+	// +optional
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// BlueGreen configures the blue/green deployment of a bundle. The manifests of each
+// color are suffixed with it, like the instance bundles, and must all be in the
+// namespaces the bundle defines.
+type BlueGreen struct {
+	// Switch is the manifest routing the traffic to the active color, e.g. a Service
+	// of type ExternalName or a VirtualService outside the namespaces of the bundle.
+	// The templates in its string values are executed with the .Color it routes to,
+	// the .Inactive color and the .Weight percentage of the traffic shifted to the
+	// inactive color by the mesh steps. Either Switch or Mesh must be set.
+	// +optional
+	Switch workapiv1.Manifest `json:"switch,omitempty"`
+
+	// Mesh generates an Istio VirtualService as the switch, shifting the traffic to
+	// the preview color in steps before switching it
+	// +optional
+	Mesh *MeshTraffic `json:"mesh,omitempty"`
+
+	// PreviewDuration is how long the new color must be Available on all the clusters
+	// before the traffic is switched to it
+	// +optional
+	PreviewDuration *metav1.Duration `json:"previewDuration,omitempty"`
+}
+
+// Rollout lists the waves of the rollouts of the bundle
+type Rollout struct {
+	// Waves are rolled out in order, each cluster belonging to the first wave selecting
+	// it. The clusters no wave selects form a last wave named remaining.
+	Waves []RolloutWave `json:"waves"`
+}
+
+// RolloutOverrides are the changes of the operators to the rollout plans
+type RolloutOverrides struct {
+	// PausedWaves are not started, or do not complete once started, until removed from
+	// the list
+	// +optional
+	PausedWaves []string `json:"pausedWaves,omitempty"`
+
+	// Deferrals move clusters to a later wave than the one selecting them
+	// +optional
+	Deferrals []ClusterDeferral `json:"deferrals,omitempty"`
+}
+
+// ClusterDeferral moves a cluster to a later wave
+type ClusterDeferral struct {
+	// Cluster is the name of the managed cluster
+	Cluster string `json:"cluster"`
+
+	// Wave is the name of the wave the cluster is moved to
+	Wave string `json:"wave"`
+}
+
+// RolloutWave selects clusters rolled out together
+type RolloutWave struct {
+	// Name of the wave
+	Name string `json:"name"`
+
+	// ClusterSelector selects the clusters of the wave by their labels, all the clusters
+	// left by the previous waves if not set
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// SoakTime is how long the wave must be Available before the next wave starts
+	// +optional
+	SoakTime *metav1.Duration `json:"soakTime,omitempty"`
+}
+
+// BundleCertificate describes a cert-manager Certificate distributed to each cluster
+type BundleCertificate struct {
+	// Name of the Certificate
+	Name string `json:"name"`
+
+	// Namespace of the Certificate, defaults to the target namespace of the bundle
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SecretName is the Secret the certificate is stored in, defaults to the name of
+	// the Certificate
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// DNSNames are executed as templates with the context of each cluster, e.g.
+	// web.{{ .Name }}.example.com
+	// +kubebuilder:validation:MinItems=1
+	DNSNames []string `json:"dnsNames"`
+
+	// IssuerRef is the issuer of the certificate on the clusters
+	IssuerRef CertificateIssuerReference `json:"issuerRef"`
+
+	// Duration of the certificate, defaults to the one of cert-manager
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// CertificateIssuerReference references a cert-manager issuer
+type CertificateIssuerReference struct {
+	// Name of the issuer
+	Name string `json:"name"`
+
+	// Kind of the issuer
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +kubebuilder:default=Issuer
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Group of the issuer, defaults to cert-manager.io
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// CertificateStatus reports the distribution of a certificate of the bundle
+type CertificateStatus struct {
+	// Name of the Certificate
+	Name string `json:"name"`
+
+	// AvailableClusters is the number of clusters where the work agent reports the
+	// Certificate Available
+	AvailableClusters int32 `json:"availableClusters"`
+
+	// Pending lists the clusters where the Certificate is not Available yet
+	// +optional
+	Pending []string `json:"pending,omitempty"`
+}
+
+// GlobalDNS describes the global record routing the traffic to the clusters of a bundle
+type GlobalDNS struct {
+	// Hostname of the global record
+	Hostname string `json:"hostname"`
+
+	// Target is the endpoint of a cluster in the record, executed as a template with
+	// the context of the cluster, e.g. web.{{ .Name }}.example.com or an address
+	// held in a label of the cluster
+	Target string `json:"target"`
+
+	// RecordType of the global record
+	// +kubebuilder:validation:Enum=A;AAAA;CNAME
+	// +kubebuilder:default=A
+	// +optional
+	RecordType string `json:"recordType,omitempty"`
+
+	// TTL of the global record in seconds
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// GlobalDNSStatus reports the clusters behind the global record of a bundle
+type GlobalDNSStatus struct {
+	// Clusters routed to by the global record
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// HubAccess describes the kubeconfig provisioned on each cluster for the workloads of
+// a bundle. The controller creates a ServiceAccount per cluster in the bundle namespace
+// on the hub, binds it to the Role and distributes a kubeconfig with its token in a
+// Secret of the work. The ServiceAccount is deleted, revoking the token, when the
+// cluster leaves the bundle.
+type HubAccess struct {
+	// Role is the name of the Role of the bundle namespace granting the permissions
+	// of the workloads on the hub
+	Role string `json:"role"`
+
+	// SecretName is the name of the Secret holding the kubeconfig on the clusters,
+	// defaults to hub-kubeconfig
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// Namespace of the Secret on the clusters, defaults to the target namespace of the
+	// bundle
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// MeshTraffic describes the Istio VirtualService routing the traffic of a blue/green
+// bundle to the Service of its colors
+type MeshTraffic struct {
+	// Service of the bundle the traffic is routed to, as namespace/name in the
+	// manifests of the bundle. The namespace is suffixed with the color like the other
+	// manifests.
+	Service string `json:"service"`
+
+	// Port of the Service, when it exposes several
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Namespace of the VirtualService, outside the namespaces of the bundle
+	Namespace string `json:"namespace"`
+
+	// Hosts routed by the VirtualService, e.g. web.example.com
+	// +kubebuilder:validation:MinItems=1
+	Hosts []string `json:"hosts"`
+
+	// Gateways of the VirtualService, defaults to the sidecars of the mesh
+	// +optional
+	Gateways []string `json:"gateways,omitempty"`
+
+	// Steps are the increasing percentages of the traffic shifted in turn to the
+	// preview color once it is Available and previewed, e.g. [10, 50], before
+	// switching all the traffic to it
+	// +optional
+	Steps []int32 `json:"steps,omitempty"`
+
+	// StepDuration is how long each step lasts, defaults to 1m
+	// +optional
+	StepDuration *metav1.Duration `json:"stepDuration,omitempty"`
+}
+
+// FluxSource describes the Flux objects distributed to the managed clusters. Either
+// Kustomization or HelmRelease must be set.
+type FluxSource struct {
+	// Namespace of the Flux objects on the managed clusters, defaults to flux-system
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Interval between the reconciles of the Flux objects, defaults to 5m
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// GitRepository the managed clusters pull from. Either GitRepository or
+	// HelmRepository must be set.
+	// +optional
+	GitRepository *FluxGitRepository `json:"gitRepository,omitempty"`
+
+	// HelmRepository the managed clusters pull the chart of the HelmRelease from
+	// +optional
+	HelmRepository *FluxHelmRepository `json:"helmRepository,omitempty"`
+
+	// Kustomization applies a path of the repository
+	// +optional
+	Kustomization *FluxKustomization `json:"kustomization,omitempty"`
+
+	// HelmRelease installs a chart of the repository
+	// +optional
+	HelmRelease *FluxHelmRelease `json:"helmRelease,omitempty"`
+
+	// Patches are applied to the objects rendered by the Kustomization or the Helm
+	// release, e.g. to fix a chart without forking it
+	// +optional
+	Patches []FluxPatch `json:"patches,omitempty"`
+}
+
+// FluxPatch is a strategic merge or JSON 6902 patch applied to the rendered objects
+type FluxPatch struct {
+	// Patch is a strategic merge patch, or a JSON 6902 patch as a list of operations
+	Patch string `json:"patch"`
+
+	// Target selects the patched objects, required for JSON 6902 patches. Strategic
+	// merge patches default to the object named in the patch.
+	// +optional
+	Target *FluxPatchTarget `json:"target,omitempty"`
+}
+
+// FluxPatchTarget selects the objects a patch applies to
+type FluxPatchTarget struct {
+	// +optional
+	Group string `json:"group,omitempty"`
+	// +optional
+	Version string `json:"version,omitempty"`
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// +optional
+	LabelSelector string `json:"labelSelector,omitempty"`
+	// +optional
+	AnnotationSelector string `json:"annotationSelector,omitempty"`
+}
+
+// FluxGitRepository is a Git repository pulled by Flux
+type FluxGitRepository struct {
+	// URL of the repository
+	URL string `json:"url"`
+
+	// Branch to check out, defaults to master when no other reference is set
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Tag to check out
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// SemVer range of the tags to check out
+	// +optional
+	SemVer string `json:"semver,omitempty"`
+
+	// Commit SHA to check out
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// SecretName is the name of the Secret holding the credentials of the repository
+	// in the Flux namespace of the managed clusters
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// FluxHelmRepository is a Helm repository or an OCI registry of charts pulled by Flux
+type FluxHelmRepository struct {
+	// URL of the repository, oci:// URLs are OCI registries
+	URL string `json:"url"`
+
+	// Interval between the refreshes of the repository index and the chart, defaults
+	// to the interval of the source. Flux caches the charts on the managed clusters
+	// and verifies their digests.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// CredentialsSecret is the name of the Secret of the bundle namespace holding the
+	// credentials of the repository, as username and password keys or as a docker
+	// config for OCI registries. It is distributed with the Flux objects.
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// FluxKustomization applies a path of the repository with kustomize
+type FluxKustomization struct {
+	// Path of the kustomization in the repository, defaults to the root
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Prune deletes the objects removed from the repository
+	// +optional
+	Prune bool `json:"prune,omitempty"`
+
+	// TargetNamespace overrides the namespace of the applied objects
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+}
+
+// FluxHelmRelease installs a Helm chart stored in the repository
+type FluxHelmRelease struct {
+	// Chart is the path of the chart in the Git repository, or its name in the Helm
+	// repository
+	Chart string `json:"chart"`
+
+	// Version is the semantic version range of the chart in the Helm repository,
+	// defaults to the latest version
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// ReleaseName defaults to the name of the bundle
+	// +optional
+	ReleaseName string `json:"releaseName,omitempty"`
+
+	// TargetNamespace is the namespace of the release
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// Values of the release, for all clusters
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Values *runtime.RawExtension `json:"values,omitempty"`
+
+	// ClusterSetValues override the values for the clusters of cluster sets
+	// +optional
+	ClusterSetValues []HelmClusterSetValues `json:"clusterSetValues,omitempty"`
+
+	// ClusterValuesConfigMap is the name of the ConfigMaps of the cluster namespaces
+	// holding values for their cluster in the values.yaml key. They override the values
+	// of the cluster sets.
+	// +optional
+	ClusterValuesConfigMap string `json:"clusterValuesConfigMap,omitempty"`
+}
+
+// HelmClusterSetValues are the values of the release on the clusters of a cluster set
+type HelmClusterSetValues struct {
+	// ClusterSet is the name of the cluster set
+	ClusterSet string `json:"clusterSet"`
+
+	// Values override the values of the release
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Values *runtime.RawExtension `json:"values"`
+}
+
+// Analysis lists the metrics checked on every cluster the bundle is distributed to
+type Analysis struct {
+	// Interval between the evaluations of the metrics, defaults to 1m
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Metrics to evaluate
+	// +kubebuilder:validation:MinItems=1
+	Metrics []AnalysisMetric `json:"metrics"`
+}
+
+// AnalysisMetric is a PromQL query evaluated for every cluster, whose result must be
+// within the given bounds
+type AnalysisMetric struct {
+	// Name of the metric
+	Name string `json:"name"`
+
+	// Query is a PromQL query template returning a single sample. The template is
+	// given .Cluster, .Namespace, .Bundle and .Generation, e.g.
+	// sum(rate(http_errors_total{cluster="{{ .Cluster }}"}[5m]))
+	Query string `json:"query"`
+
+	// Min is the lowest accepted value
+	// +optional
+	Min *resource.Quantity `json:"min,omitempty"`
+
+	// Max is the highest accepted value
+	// +optional
+	Max *resource.Quantity `json:"max,omitempty"`
+}
+
+// Monitoring controls the monitoring manifests injected in the bundle
+type Monitoring struct {
+	// Port is the name of the Service ports scraped, defaults to metrics. A
+	// ServiceMonitor is generated for every Service exposing a port with this name.
+	// +optional
+	Port string `json:"port,omitempty"`
+
+	// Interval between the scrapes, defaults to the interval of Prometheus
+	// +optional
+	Interval string `json:"interval,omitempty"`
+}
+
+// SelectionStrategy selects how the clusters are ranked
+// +kubebuilder:validation:Enum=LowestCost;HighestScore
+type SelectionStrategy string
+
+const (
+	// SelectLowestCost selects the clusters with the lowest cost annotation
+	SelectLowestCost SelectionStrategy = "LowestCost"
+	// SelectHighestScore selects the clusters with the highest score annotation
+	SelectHighestScore SelectionStrategy = "HighestScore"
+)
+
+// ClusterSelection selects a subset of the clusters of the placement decision
+type ClusterSelection struct {
+	// Clusters is the number of clusters to select
+	// +kubebuilder:validation:Minimum=1
+	Clusters int32 `json:"clusters"`
+
+	// Strategy ranking the clusters, defaults to LowestCost
+	// +optional
+	Strategy SelectionStrategy `json:"strategy,omitempty"`
+
+	// Annotation of the ManagedClusters holding their cost or score, defaults to
+	// cluster.open-cluster-management.io/cost or cluster.open-cluster-management.io/score.
+	// Clusters without the annotation are ranked last.
+	// +optional
+	Annotation string `json:"annotation,omitempty"`
+}
+
+// SpreadConstraint bounds the number of clusters per topology domain, and requires a
+// minimum number of domains
+type SpreadConstraint struct {
+	// TopologyKey is the ManagedCluster label whose values are the topology domains,
+	// e.g. region or zone. Clusters without the label are not constrained.
+	TopologyKey string `json:"topologyKey"`
+
+	// MaxClustersPerDomain is the maximum number of clusters selected in a domain
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxClustersPerDomain *int32 `json:"maxClustersPerDomain,omitempty"`
+
+	// MinDomains is the minimum number of domains the selected clusters span
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinDomains *int32 `json:"minDomains,omitempty"`
+}
+
+// WorkloadReference references a ConfigMap or Secret holding YAML manifests
+type WorkloadReference struct {
+	// Kind of the referenced object, either ConfigMap or Secret
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
 
-	// Foo is an example field of AppBundle. Edit appbundle_types.go to remove/update
-	Foo string `json:"foo,omitempty"`
+	// Name of the referenced object in the bundle namespace
+	Name string `json:"name"`
+
+	// Keys lists the data keys to read manifests from. All keys are read, in
+	// lexical order, when empty.
+	// +optional
+	Keys []string `json:"keys,omitempty"`
 }
 
+const (
+	// WorkloadRefKindConfigMap references a ConfigMap
+	WorkloadRefKindConfigMap = "ConfigMap"
+	// WorkloadRefKindSecret references a Secret
+	WorkloadRefKindSecret = "Secret"
+)
+
 // AppBundleStatus defines the observed state of AppBundle
 type AppBundleStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	workapiv1.ManifestWorkStatus `json:",inline"`
+
+	// Clusters lists the managed clusters the bundle is currently distributed to.
+	// +optional
+	Clusters []ClusterStatus `json:"clusters,omitempty"`
+
+	// Provenance records the content distributed for the latest generation of the bundle.
+	// +optional
+	Provenance *Provenance `json:"provenance,omitempty"`
+
+	// Pruned lists the resources, as group/kind/namespace/name, deleted from the managed
+	// clusters by the latest update of the bundle works
+	// +optional
+	Pruned []string `json:"pruned,omitempty"`
+
+	// Orphaned lists the resources removed from the bundle by the latest update of its
+	// works but left on the managed clusters
+	// +optional
+	Orphaned []string `json:"orphaned,omitempty"`
+
+	// TemplateVersion is the version of the template distributed
+	// +optional
+	TemplateVersion string `json:"templateVersion,omitempty"`
+
+	// InstanceSuffix is the suffix of the resources of an instance bundle
+	// +optional
+	InstanceSuffix string `json:"instanceSuffix,omitempty"`
+
+	// ManifestBytes is the size of the rendered manifests, counted in the quota of
+	// the KealmTenant of the namespace
+	// +optional
+	ManifestBytes int64 `json:"manifestBytes,omitempty"`
+
+	// BlueGreen reports the colors of a blue/green bundle
+	// +optional
+	BlueGreen *BlueGreenStatus `json:"blueGreen,omitempty"`
+
+	// Components reports the distribution of each component of the bundle
+	// +optional
+	Components []ComponentStatus `json:"components,omitempty"`
+
+	// Certificates reports the distribution of each certificate of the bundle
+	// +optional
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
+
+	// GlobalDNS reports the clusters behind the global record of the bundle
+	// +optional
+	GlobalDNS *GlobalDNSStatus `json:"globalDNS,omitempty"`
+
+	// Claims reports the claims of the Crossplane components applied on the hub
+	// +optional
+	Claims []ClaimStatus `json:"claims,omitempty"`
+
+	// WriteBack reports the state last written to the Git repository of the bundle
+	// +optional
+	WriteBack *WriteBackStatus `json:"writeBack,omitempty"`
+
+	// SecretRotation reports the distribution of the latest rotation of the secret
+	// values of the bundle
+	// +optional
+	SecretRotation *SecretRotationStatus `json:"secretRotation,omitempty"`
+
+	// Plan is the plan of the rollout of the latest content of the bundle, with its
+	// progress
+	// +optional
+	Plan *RolloutPlan `json:"plan,omitempty"`
+
+	// Images reports the tags selected by the image update policies
+	// +optional
+	Images []ImageStatus `json:"images,omitempty"`
+}
+
+// ClaimStatus reports a claim of a Crossplane component
+type ClaimStatus struct {
+	// Component the claim belongs to
+	Component  string `json:"component"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+
+	// Ready is true when the claim reports a true Ready condition
+	Ready bool `json:"ready"`
+}
+
+// WriteBackStatus reports the state last written to Git
+type WriteBackStatus struct {
+	// Generation of the bundle written
+	Generation int64 `json:"generation"`
+
+	// Digest of the written files
+	Digest string `json:"digest"`
+
+	// Time the files were written
+	Time metav1.Time `json:"time"`
+}
+
+// RolloutPlan is the ordered waves a content is rolled out in
+type RolloutPlan struct {
+	// Digest of the content rolled out
+	Digest string `json:"digest"`
+
+	// ComputedAt is when the plan was computed, before the rollout started
+	ComputedAt metav1.Time `json:"computedAt"`
+
+	// Waves of the rollout, in order
+	Waves []WavePlan `json:"waves"`
+
+	// CurrentWave is the index of the wave being rolled out, the number of waves once
+	// the rollout completed
+	CurrentWave int32 `json:"currentWave"`
+}
+
+// WavePhase is the progress of a wave
+// +kubebuilder:validation:Enum=Pending;Progressing;Paused;Completed
+type WavePhase string
+
+const (
+	// WavePending is the phase of the waves waiting for the previous ones
+	WavePending WavePhase = "Pending"
+	// WaveProgressing is the phase of the wave being rolled out
+	WaveProgressing WavePhase = "Progressing"
+	// WavePaused is the phase of the current wave while it is paused by the overrides
+	WavePaused WavePhase = "Paused"
+	// WaveCompleted is the phase of the waves whose gates passed
+	WaveCompleted WavePhase = "Completed"
+)
+
+// RolloutGateType is a condition for a wave to complete
+// +kubebuilder:validation:Enum=Available;Soak;Analysis
+type RolloutGateType string
+
+const (
+	// GateAvailable passes when the works of all the clusters of the wave are applied
+	// and Available
+	GateAvailable RolloutGateType = "Available"
+	// GateSoak passes when the soak time of the wave elapsed since it became Available
+	GateSoak RolloutGateType = "Soak"
+	// GateAnalysis passes when the analysis of the bundle passes
+	GateAnalysis RolloutGateType = "Analysis"
+)
+
+// RolloutGate is a gate of a wave, passed in order
+type RolloutGate struct {
+	// Type of the gate
+	Type RolloutGateType `json:"type"`
+
+	// Duration of the Soak gates
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// PassedAt is when the gate passed
+	// +optional
+	PassedAt *metav1.Time `json:"passedAt,omitempty"`
+}
+
+// WavePlan is a wave of a rollout plan, with its progress
+type WavePlan struct {
+	// Name of the wave
+	Name string `json:"name"`
+
+	// Clusters of the wave
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+
+	// Gates the wave must pass to complete
+	Gates []RolloutGate `json:"gates"`
+
+	// Phase of the wave
+	Phase WavePhase `json:"phase"`
+
+	// Updated lists the clusters of the wave whose work has the content rolled out
+	// +optional
+	Updated []string `json:"updated,omitempty"`
+
+	// StartedAt is when the wave started
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is when the gates of the wave passed
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// SecretRotationStatus reports the clusters where rotated secret values are applied
+type SecretRotationStatus struct {
+	// DetectedAt is when the rotation was first distributed
+	DetectedAt metav1.Time `json:"detectedAt"`
+
+	// Pending lists the clusters whose work agent has not yet applied the rotated
+	// values
+	// +optional
+	Pending []string `json:"pending,omitempty"`
+
+	// Completed lists the clusters where the rotated values are applied
+	// +optional
+	Completed []string `json:"completed,omitempty"`
+
+	// CompletedAt is when the rotated values were applied on all the clusters
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// ImageStatus reports the tag selected for an image
+type ImageStatus struct {
+	// Image is the image repository
+	Image string `json:"image"`
+
+	// Tag is the latest tag selected
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// LastChecked is when the registry was last checked
+	// +optional
+	LastChecked *metav1.Time `json:"lastChecked,omitempty"`
+
+	// Message reports the error of the last check, if any
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Provenance records what was distributed for a generation of the bundle and by whom
+type Provenance struct {
+	// Generation is the bundle generation the content was rendered from
+	Generation int64 `json:"generation"`
+
+	// Digest is the sha256 digest of the canonical JSON encoding of the distributed manifests
+	Digest string `json:"digest"`
+
+	// Signature is the base64 encoded signature of the content, set when a signing key
+	// is configured on the hub
+	// +optional
+	Signature string `json:"signature,omitempty"`
+
+	// SignedBy is the identity of the controller which distributed the content
+	// +optional
+	SignedBy string `json:"signedBy,omitempty"`
+
+	// ModifiedBy is the user who last changed the bundle spec, as recorded by the
+	// admission webhook, or its latest field manager without the webhook
+	// +optional
+	ModifiedBy string `json:"modifiedBy,omitempty"`
+}
+
+// BlueGreenStatus reports the colors of a blue/green bundle
+type BlueGreenStatus struct {
+	// ActiveColor is the color the traffic is routed to
+	// +optional
+	ActiveColor string `json:"activeColor,omitempty"`
+
+	// ActiveDigest is the digest of the content of the active color
+	// +optional
+	ActiveDigest string `json:"activeDigest,omitempty"`
+
+	// PreviewColor is the color the new content is deployed to, until the traffic
+	// is switched to it
+	// +optional
+	PreviewColor string `json:"previewColor,omitempty"`
+
+	// PreviewDigest is the digest of the content of the preview color
+	// +optional
+	PreviewDigest string `json:"previewDigest,omitempty"`
+
+	// PreviewAvailableSince is when the preview color became Available on all the
+	// clusters
+	// +optional
+	PreviewAvailableSince *metav1.Time `json:"previewAvailableSince,omitempty"`
+
+	// PreviewWeight is the percentage of the traffic shifted to the preview color by
+	// the mesh steps
+	// +optional
+	PreviewWeight int32 `json:"previewWeight,omitempty"`
+
+	// WeightShiftedAt is when the traffic was last shifted to the preview color
+	// +optional
+	WeightShiftedAt *metav1.Time `json:"weightShiftedAt,omitempty"`
+
+	// RetiringColor is the color the traffic was switched from, removed once the
+	// switch is applied on all the clusters
+	// +optional
+	RetiringColor string `json:"retiringColor,omitempty"`
+}
+
+// ClusterStatus reports the distribution state of the bundle on a single managed cluster
+type ClusterStatus struct {
+	// ClusterName is the name of the managed cluster
+	ClusterName string `json:"clusterName"`
+
+	// WorkName is the name of the ManifestWork generated in the cluster namespace
+	// +optional
+	WorkName string `json:"workName,omitempty"`
+
+	// Digest is the content digest of the manifests shipped to the cluster
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// Conditions of the ManifestWork in the cluster, as last observed
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Incompatible lists the resources not distributed to the cluster, as their API
+	// version is removed on its Kubernetes version
+	// +optional
+	Incompatible []string `json:"incompatible,omitempty"`
+
+	// UnavailableSince is when the managed cluster became not available, unset while
+	// it is available
+	// +optional
+	UnavailableSince *metav1.Time `json:"unavailableSince,omitempty"`
+
+	// Waiting lists the required bundles, as namespace/name, not Available on the
+	// cluster, the bundle not being distributed to it until they are
+	// +optional
+	Waiting []string `json:"waiting,omitempty"`
+
+	// Requests are the resources requested by the manifests distributed to the
+	// cluster, claimed on the cluster when the resource-aware gating is enabled
+	// +optional
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+
+	// Insufficient lists the resources the cluster cannot fit, the bundle not being
+	// distributed to it until it does
+	// +optional
+	Insufficient []string `json:"insufficient,omitempty"`
+
+	// Stalled is true when the work is not applied within the timeout of the
+	// stalledWorks of the KealmConfig
+	// +optional
+	Stalled bool `json:"stalled,omitempty"`
+
+	// Health of the agents of the cluster, to tell a broken agent from a bad manifest
+	// when the work is not applied
+	// +optional
+	Health *ClusterHealth `json:"health,omitempty"`
+
+	// SecretsHash is a truncated hash of the secret values distributed to the cluster,
+	// changed when they rotate
+	// +optional
+	SecretsHash string `json:"secretsHash,omitempty"`
+}
+
+// ClusterHealthStatus summarizes the health of the agents of a cluster
+// +kubebuilder:validation:Enum=Healthy;Degraded;Unavailable;Unknown
+type ClusterHealthStatus string
+
+const (
+	// ClusterHealthy is the status of the clusters whose klusterlet and add-ons are
+	// available
+	ClusterHealthy ClusterHealthStatus = "Healthy"
+	// ClusterDegraded is the status of the clusters with add-ons not available
+	ClusterDegraded ClusterHealthStatus = "Degraded"
+	// ClusterUnavailable is the status of the clusters whose klusterlet does not renew
+	// its lease, its agents applying no work
+	ClusterUnavailable ClusterHealthStatus = "Unavailable"
+	// ClusterHealthUnknown is the status of the clusters not registered
+	ClusterHealthUnknown ClusterHealthStatus = "Unknown"
+)
+
+// ClusterHealth is the health of the klusterlet and of the add-ons of a cluster
+type ClusterHealth struct {
+	// Status summarizes the health of the agents
+	Status ClusterHealthStatus `json:"status"`
+
+	// Message explains the status
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// DegradedAddOns lists the ManagedClusterAddOns of the cluster not Available or
+	// Degraded
+	// +optional
+	DegradedAddOns []string `json:"degradedAddOns,omitempty"`
 }
 
+const (
+	// ConditionPlacementResolved reports whether the placement decision for the
+	// placement referenced by the bundle could be found
+	ConditionPlacementResolved = "PlacementResolved"
+
+	// ReasonPlacementDecisionFound is set when the placement decision has been found
+	ReasonPlacementDecisionFound = "PlacementDecisionFound"
+	// ReasonPlacementDecisionNotFound is set when no placement decision exists yet
+	// for the placement referenced by the bundle
+	ReasonPlacementDecisionNotFound = "PlacementDecisionNotFound"
+
+	// ConditionPlacementSatisfied reports whether the placement referenced by the
+	// bundle exists and its requirements are satisfied
+	ConditionPlacementSatisfied = "PlacementSatisfied"
+
+	// ReasonPlacementSatisfied is set when the placement requirements are satisfied
+	ReasonPlacementSatisfied = "PlacementSatisfied"
+	// ReasonPlacementNotFound is set when the referenced placement does not exist
+	ReasonPlacementNotFound = "PlacementNotFound"
+	// ReasonPlacementUnsatisfied is set when the placement cannot select enough clusters
+	ReasonPlacementUnsatisfied = "PlacementUnsatisfied"
+
+	// ConditionWorkloadResolved reports whether the workload references of the bundle
+	// could be resolved and parsed
+	ConditionWorkloadResolved = "WorkloadResolved"
+
+	// ReasonWorkloadResolved is set when all workload references have been resolved
+	ReasonWorkloadResolved = "WorkloadResolved"
+	// ReasonWorkloadRefFailed is set when a workload reference is missing or invalid
+	ReasonWorkloadRefFailed = "WorkloadRefFailed"
+
+	// ConditionGuardrailsPassed reports whether the rendered manifests comply with the
+	// guardrails configured in KealmConfig
+	ConditionGuardrailsPassed = "GuardrailsPassed"
+
+	// ReasonGuardrailsPassed is set when no guardrail is violated
+	ReasonGuardrailsPassed = "GuardrailsPassed"
+	// ReasonGuardrailViolated is set when a guardrail is violated
+	ReasonGuardrailViolated = "GuardrailViolated"
+
+	// ConditionVersionSkewAllowed reports whether the version of the agents of the
+	// bundle is compatible with the version of the hub component they talk to
+	ConditionVersionSkewAllowed = "VersionSkewAllowed"
+
+	// ReasonVersionSkewAllowed is the reason when the versions are compatible
+	ReasonVersionSkewAllowed = "VersionSkewAllowed"
+	// ReasonVersionSkewViolated is the reason, and the event reason, when the versions
+	// are not compatible, or cannot be determined
+	ReasonVersionSkewViolated = "VersionSkewViolated"
+
+	// ConditionWithinQuota reports whether the bundle fits in the quota of the
+	// KealmTenant of its namespace
+	ConditionWithinQuota = "WithinQuota"
+
+	// ReasonWithinQuota is set when the quota is not exceeded
+	ReasonWithinQuota = "WithinQuota"
+	// ReasonQuotaExceeded is set when the bundle exceeds the quota, it is not
+	// distributed
+	ReasonQuotaExceeded = "QuotaExceeded"
+
+	// ConditionClustersSelected reports whether the cluster selection of the bundle
+	// found enough clusters in the placement decision
+	ConditionClustersSelected = "ClustersSelected"
+
+	// ReasonClustersSelected is set when enough clusters are selected
+	ReasonClustersSelected = "ClustersSelected"
+	// ReasonNotEnoughClusters is set when the decision has fewer clusters than required
+	ReasonNotEnoughClusters = "NotEnoughClusters"
+
+	// ConditionSpreadSatisfied reports whether the clusters satisfy the spread constraints
+	ConditionSpreadSatisfied = "SpreadSatisfied"
+
+	// ReasonSpreadSatisfied is set when all the spread constraints are satisfied
+	ReasonSpreadSatisfied = "SpreadSatisfied"
+	// ReasonSpreadUnsatisfiable is set when a spread constraint cannot be satisfied
+	ReasonSpreadUnsatisfiable = "SpreadUnsatisfiable"
+
+	// ConditionArchitectureSupported reports whether the bundle supports the
+	// architectures of all its decided clusters
+	ConditionArchitectureSupported = "ArchitectureSupported"
+
+	// ReasonArchitectureSupported is set when the bundle supports the architectures of
+	// all its decided clusters
+	ReasonArchitectureSupported = "ArchitectureSupported"
+	// ReasonUnsupportedArchitecture is set when decided clusters are skipped as the
+	// bundle does not support their architecture
+	ReasonUnsupportedArchitecture = "UnsupportedArchitecture"
+
+	// ReasonSecretLookupFailed is set when a secret value cannot be looked up
+	ReasonSecretLookupFailed = "SecretLookupFailed"
+	// ReasonSecretRotated is set when rotated secret values are distributed
+	ReasonSecretRotated = "SecretRotated"
+	// ReasonSecretRotationCompleted is set when rotated secret values are applied on
+	// all the clusters
+	ReasonSecretRotationCompleted = "SecretRotationCompleted"
+
+	// ReasonWaveStarted is set when a wave of a rollout starts
+	ReasonWaveStarted = "WaveStarted"
+	// ReasonRolloutCompleted is set when the last wave of a rollout completes
+	ReasonRolloutCompleted = "RolloutCompleted"
+
+	// ConditionRolloutOverridesHonored reports whether the rollout overrides of the
+	// bundle are honored
+	ConditionRolloutOverridesHonored = "RolloutOverridesHonored"
+	// ReasonOverridesHonored is set when all the rollout overrides are honored
+	ReasonOverridesHonored = "OverridesHonored"
+	// ReasonOverridesIgnored is set when rollout overrides cannot be honored, e.g. a
+	// cluster moved to an earlier wave
+	ReasonOverridesIgnored = "OverridesIgnored"
+
+	// ConditionClaimsReady reports whether the outputs of the claims of the Crossplane
+	// components are available
+	ConditionClaimsReady = "ClaimsReady"
+
+	// ReasonClaimsReady is set when the outputs of the claims are available
+	ReasonClaimsReady = "ClaimsReady"
+	// ReasonClaimsPending is set when the distribution waits for outputs of the claims
+	ReasonClaimsPending = "ClaimsPending"
+	// ReasonClaimFailed is set when a claim cannot be applied
+	ReasonClaimFailed = "ClaimFailed"
+
+	// ConditionWrittenBack reports whether the resolved state of the bundle is written
+	// to its Git repository
+	ConditionWrittenBack = "WrittenBack"
+
+	// ReasonWrittenBack is set when the resolved state is written to Git
+	ReasonWrittenBack = "WrittenBack"
+	// ReasonWriteBackFailed is set when the resolved state cannot be written to Git
+	ReasonWriteBackFailed = "WriteBackFailed"
+
+	// ConditionBlocked reports whether changes to some clusters of the bundle are
+	// blocked by a ClusterLock or the read-only mode
+	ConditionBlocked = "Blocked"
+
+	// ReasonClusterLocked is set when clusters of the bundle are locked
+	ReasonClusterLocked = "ClusterLocked"
+	// ReasonReadOnly is set when the KealmConfig puts the controller in read-only mode
+	ReasonReadOnly = "ReadOnly"
+
+	// ConditionWithinChangeBudget reports whether the bundle could change all its
+	// clusters within their change budget
+	ConditionWithinChangeBudget = "WithinChangeBudget"
+
+	// ReasonWithinChangeBudget is set when no cluster change was deferred
+	ReasonWithinChangeBudget = "WithinChangeBudget"
+	// ReasonChangeBudgetExhausted is set when cluster changes are deferred
+	ReasonChangeBudgetExhausted = "ChangeBudgetExhausted"
+
+	// ConditionHubMigration reports the progress of the hub migration of the bundle
+	ConditionHubMigration = "HubMigration"
+
+	// ReasonWorksReleasing is set while the works of the bundle are being orphaned
+	ReasonWorksReleasing = "WorksReleasing"
+	// ReasonWorksReleased is set once all the works of the bundle are orphaned
+	ReasonWorksReleased = "WorksReleased"
+	// ReasonAdoptOnly is set while the bundle waits for the handover of its works
+	ReasonAdoptOnly = "AdoptOnly"
+
+	// ReasonImageUpdated is set on the events recorded when an image tag is updated
+	ReasonImageUpdated = "ImageUpdated"
+
+	// ConditionSynced is the condition type reporting whether the latest reconcile of
+	// the bundle succeeded, with the reason of the fault otherwise
+	ConditionSynced = "Synced"
+
+	// ReasonSynced is the reason when the bundle is distributed
+	ReasonSynced = "Synced"
+	// ReasonPlacementMissing is the fault when the placement has no decision, a user error
+	ReasonPlacementMissing = "PlacementMissing"
+	// ReasonRenderFailed is the fault when the manifests cannot be rendered, a user error
+	ReasonRenderFailed = "RenderFailed"
+	// ReasonPayloadTooLarge is the fault when a ManifestWork exceeds the size limit, a
+	// user error
+	ReasonPayloadTooLarge = "PayloadTooLarge"
+	// ReasonWorkCreateForbidden is the fault when the controller may not write the
+	// ManifestWorks, a system fault
+	ReasonWorkCreateForbidden = "WorkCreateForbidden"
+	// ReasonClusterUnavailable is the fault when a cluster or its namespace is missing,
+	// a system fault
+	ReasonClusterUnavailable = "ClusterUnavailable"
+	// ReasonHubAccessForbidden is the fault when the author of the bundle may not bind
+	// the Role of its hub access, a user error
+	ReasonHubAccessForbidden = "HubAccessForbidden"
+	// ReasonInternalError is the fault of the other errors, a system fault
+	ReasonInternalError = "InternalError"
+
+	// ConditionPluginsPassed is the condition type reporting the validation of the
+	// manifests distributed to every cluster by the distribution plugins
+	ConditionPluginsPassed = "PluginsPassed"
+
+	// ReasonPluginsPassed is the reason when the plugins allow the manifests of all clusters
+	ReasonPluginsPassed = "PluginsPassed"
+	// ReasonPluginDenied is the reason when a plugin denies the manifests of clusters
+	ReasonPluginDenied = "PluginDenied"
+
+	// ReasonDistributed is the reason of the events reporting the distribution of a
+	// generation of the bundle, with the user who changed it
+	ReasonDistributed = "Distributed"
+
+	// ReasonDraining is the reason of the events reporting the drain of the clusters
+	// left by the bundle
+	ReasonDraining = "Draining"
+
+	// ReasonIncompatibleAPI is the reason of the events reporting resources not
+	// distributed to clusters whose Kubernetes version removed their API version
+	ReasonIncompatibleAPI = "IncompatibleAPI"
+
+	// ConditionSecurityGatePassed is the condition type reporting the review of the
+	// images of the bundle by the security gate
+	ConditionSecurityGatePassed = "SecurityGatePassed"
+
+	// ReasonSecurityGatePassed is the reason when the scanner allows the images
+	ReasonSecurityGatePassed = "SecurityGatePassed"
+	// ReasonSecurityGateFailed is the reason when the scanner denies the images
+	ReasonSecurityGateFailed = "SecurityGateFailed"
+	// ReasonSecurityGateOverridden is the reason when denied images are distributed
+	// as the bundle overrides the security gate
+	ReasonSecurityGateOverridden = "SecurityGateOverridden"
+	// ReasonSecurityGateError is the reason when the scanner cannot be reached
+	ReasonSecurityGateError = "SecurityGateError"
+
+	// ConditionRequirementsMet reports whether the bundles required by the bundle are
+	// Available on its clusters
+	ConditionRequirementsMet = "RequirementsMet"
+
+	// ReasonRequirementsMet is the reason when the required bundles are Available
+	ReasonRequirementsMet = "RequirementsMet"
+	// ReasonRequirementsMissing is the reason when required bundles are not Available
+	// on clusters
+	ReasonRequirementsMissing = "RequirementsMissing"
+
+	// ConditionResourcesFit reports whether the clusters of the bundle fit its
+	// resource requests, when the resource-aware gating is enabled
+	ConditionResourcesFit = "ResourcesFit"
+
+	// ReasonResourcesFit is the reason when the clusters fit the requests
+	ReasonResourcesFit = "ResourcesFit"
+	// ReasonResourcesInsufficient is the reason when clusters cannot fit the requests,
+	// or the bundle is preempted from clusters
+	ReasonResourcesInsufficient = "ResourcesInsufficient"
+	// ReasonPreempted is the event reason when the bundle is preempted from a cluster
+	ReasonPreempted = "Preempted"
+	// ReasonPreempting is the event reason when the bundle preempts bundles from a
+	// cluster
+	ReasonPreempting = "Preempting"
+
+	// ConditionAntiAffinitySatisfied reports whether the bundle is distributed to all
+	// the clusters decided for it, none being taken by the bundles it is anti-affine with
+	ConditionAntiAffinitySatisfied = "AntiAffinitySatisfied"
+
+	// ReasonAntiAffinitySatisfied is the reason when no decided cluster is taken
+	ReasonAntiAffinitySatisfied = "AntiAffinitySatisfied"
+	// ReasonAntiAffinityConflict is the reason, and the event reason, when decided
+	// clusters are taken by bundles the bundle is anti-affine with
+	ReasonAntiAffinityConflict = "AntiAffinityConflict"
+
+	// ReasonBlueGreenPreview is the event reason when a new generation of a blue/green
+	// bundle is deployed to the preview color
+	ReasonBlueGreenPreview = "BlueGreenPreview"
+	// ReasonBlueGreenSwitched is the event reason when the traffic of a blue/green
+	// bundle is switched to the preview color
+	ReasonBlueGreenSwitched = "BlueGreenSwitched"
+	// ReasonTrafficShifted is the event reason when the mesh steps of a blue/green
+	// bundle shift the traffic to or back from the preview color
+	ReasonTrafficShifted = "TrafficShifted"
+
+	// ReasonGlobalDNSUpdated is the event reason when the clusters behind the global
+	// record of a bundle change
+	ReasonGlobalDNSUpdated = "GlobalDNSUpdated"
+
+	// ConditionPlacementFallback reports whether the bundle is distributed to the
+	// clusters of its fallback placement
+	ConditionPlacementFallback = "PlacementFallback"
+
+	// ReasonPrimaryPlacement is the reason when the placement of the bundle decides
+	// clusters
+	ReasonPrimaryPlacement = "PrimaryPlacement"
+	// ReasonFallbackPlacement is the reason, and the event reason, when the placement
+	// decides no cluster and the bundle falls back to its fallback placement
+	ReasonFallbackPlacement = "FallbackPlacement"
+
+	// ConditionDegraded reports whether clusters of the bundle are not available beyond
+	// the toleration of its availability policy
+	ConditionDegraded = "Degraded"
+
+	// ReasonClustersAvailable is the reason when the clusters are available or within
+	// the toleration
+	ReasonClustersAvailable = "ClustersAvailable"
+	// ReasonClustersUnreachable is the reason when clusters are not available beyond
+	// the toleration
+	ReasonClustersUnreachable = "ClustersUnreachable"
+
+	// ConditionSLOViolated reports whether the rollout of the latest content of the
+	// bundle misses the rollout SLO configured in KealmConfig
+	ConditionSLOViolated = "SLOViolated"
+
+	// ReasonObjectiveMet is the reason when the rollout is within the error budget
+	ReasonObjectiveMet = "ObjectiveMet"
+	// ReasonObjectiveMissed is the reason when too many clusters missed the window
+	ReasonObjectiveMissed = "ObjectiveMissed"
+
+	// ReasonWorkStalled is the reason of the events of the works not applied within
+	// the stalled works timeout
+	ReasonWorkStalled = "WorkStalled"
+	// ReasonWorkRecreated is the reason of the events of the stalled works deleted to
+	// be created again
+	ReasonWorkRecreated = "WorkRecreated"
+
+	// ConditionAnalysisPassed reports whether the analysis metrics of the bundle are
+	// within bounds on all its clusters
+	ConditionAnalysisPassed = "AnalysisPassed"
+
+	// ReasonAnalysisPassed is set when all the metrics are within bounds
+	ReasonAnalysisPassed = "AnalysisPassed"
+	// ReasonAnalysisFailed is set when a metric is out of bounds
+	ReasonAnalysisFailed = "AnalysisFailed"
+	// ReasonAnalysisError is set when a metric cannot be evaluated
+	ReasonAnalysisError = "AnalysisError"
+)
+
+//+genclient
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
@@ -48,11 +1890,11 @@ type AppBundle struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	// Spec represents a desired configuration of work to be deployed on the managed cluster.
-	Spec workapiv1.ManifestWorkSpec `json:"spec"`
+	Spec AppBundleSpec `json:"spec"`
 
 	// Status represents the current status of work.
 	// +optional
-	Status workapiv1.ManifestWorkStatus `json:"status,omitempty"`
+	Status AppBundleStatus `json:"status,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,131 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AppBundleAuditSpec records a distribution action performed for an AppBundle
+type AppBundleAuditSpec struct {
+	// BundleName is the name of the audited AppBundle
+	BundleName string `json:"bundleName"`
+
+	// BundleUID is the UID of the audited AppBundle
+	BundleUID string `json:"bundleUID"`
+
+	// Generation is the bundle generation which was distributed
+	Generation int64 `json:"generation"`
+
+	// Digest is the content digest of the distributed manifests
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// ChangedBy lists who last changed the bundle spec, as recorded in its managed fields
+	// +optional
+	ChangedBy []ChangeAuthor `json:"changedBy,omitempty"`
+
+	// ModifiedBy is the user who last changed the bundle spec, as recorded by the
+	// admission webhook, or its latest field manager without the webhook
+	// +optional
+	ModifiedBy string `json:"modifiedBy,omitempty"`
+
+	// Diff summarizes the changes computed against the previously distributed manifests
+	// +optional
+	Diff ManifestDiff `json:"diff,omitempty"`
+
+	// Clusters lists the clusters touched by the distribution action
+	// +optional
+	Clusters []ClusterAction `json:"clusters,omitempty"`
+
+	// Time is when the distribution action was performed
+	Time metav1.Time `json:"time"`
+}
+
+// ChangeAuthor identifies who changed a bundle
+type ChangeAuthor struct {
+	// Manager is the field manager, or the user when known, which performed the change
+	Manager string `json:"manager"`
+
+	// Operation is the type of operation which performed the change
+	// +optional
+	Operation string `json:"operation,omitempty"`
+
+	// Time is when the change was performed
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
+}
+
+// ManifestDiff lists the resources added, removed or changed by a distribution action.
+// Resources are identified as group/kind/namespace/name.
+type ManifestDiff struct {
+	// +optional
+	Added []string `json:"added,omitempty"`
+
+	// +optional
+	Removed []string `json:"removed,omitempty"`
+
+	// +optional
+	Changed []string `json:"changed,omitempty"`
+}
+
+// ClusterAction records the action performed on a single cluster
+type ClusterAction struct {
+	// ClusterName is the name of the managed cluster
+	ClusterName string `json:"clusterName"`
+
+	// Action is the action performed on the cluster ManifestWork
+	// +kubebuilder:validation:Enum=Created;Updated;Deleted
+	Action string `json:"action"`
+}
+
+const (
+	// ClusterActionCreated is recorded when a ManifestWork is created
+	ClusterActionCreated = "Created"
+	// ClusterActionUpdated is recorded when a ManifestWork is updated
+	ClusterActionUpdated = "Updated"
+	// ClusterActionDeleted is recorded when a ManifestWork is deleted
+	ClusterActionDeleted = "Deleted"
+)
+
+//+genclient
+//+genclient:noStatus
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="Bundle",type=string,JSONPath=`.spec.bundleName`
+//+kubebuilder:printcolumn:name="Generation",type=integer,JSONPath=`.spec.generation`
+//+kubebuilder:printcolumn:name="Time",type=date,JSONPath=`.spec.time`
+
+// AppBundleAudit is an append-only record of a distribution action performed for an AppBundle
+type AppBundleAudit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AppBundleAuditSpec `json:"spec"`
+}
+
+//+kubebuilder:object:root=true
+
+// AppBundleAuditList contains a list of AppBundleAudit
+type AppBundleAuditList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AppBundleAudit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AppBundleAudit{}, &AppBundleAuditList{})
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AppBundleSetSpec describes the AppBundles generated from a template for each set of
+// parameters and each cluster of the generators
+type AppBundleSetSpec struct {
+	// Generators produce the parameters of the bundles. The parameter sets of the list
+	// and gitDirectories generators are crossed with the clusters of the clusters
+	// generator, e.g. a list of two tenants and three clusters generate six bundles.
+	// +kubebuilder:validation:MinItems=1
+	Generators []BundleSetGenerator `json:"generators"`
+
+	// Template of the generated bundles. {{name}} in its strings is substituted with the
+	// value of the parameter name, e.g. {{cluster.name}} or {{path.basename}}.
+	Template BundleSetTemplate `json:"template"`
+}
+
+// BundleSetGenerator produces parameters. Exactly one of its generators must be set.
+type BundleSetGenerator struct {
+	// Clusters generates a bundle per ManagedCluster selected, distributed to that
+	// cluster only, with the parameters cluster.name, cluster.region, cluster.cloud,
+	// cluster.platform and cluster.labels.<key>
+	// +optional
+	Clusters *ClusterGenerator `json:"clusters,omitempty"`
+
+	// List generates a parameter set per element
+	// +optional
+	List *ListGenerator `json:"list,omitempty"`
+
+	// GitDirectories generates a parameter set per directory of a Git repository, with
+	// the parameters path and path.basename
+	// +optional
+	GitDirectories *GitDirectoryGenerator `json:"gitDirectories,omitempty"`
+}
+
+// ClusterGenerator selects ManagedClusters
+type ClusterGenerator struct {
+	// Selector of the ManagedClusters by label, all the clusters when empty
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// ListGenerator lists parameter sets
+type ListGenerator struct {
+	// Elements are the parameter sets
+	// +kubebuilder:validation:MinItems=1
+	Elements []map[string]string `json:"elements"`
+}
+
+// GitDirectoryGenerator lists the directories of a path of a Git repository on GitHub,
+// GitLab or Gitea, read from the API of the Git host
+type GitDirectoryGenerator struct {
+	// URL of the repository, e.g. https://github.com/acme/apps
+	URL string `json:"url"`
+
+	// Ref is the branch, tag or commit read
+	// +kubebuilder:default=main
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// Path whose directories are listed, the root of the repository when empty
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Interval between two listings of the directories
+	// +kubebuilder:default="3m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// BundleSetTemplate is the template of the generated bundles
+type BundleSetTemplate struct {
+	// Name of the generated bundles, which must be unique per parameter set. Defaults to
+	// the name of the set suffixed with a hash of the parameters.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Labels of the generated bundles, e.g. the placement label
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations of the generated bundles
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Spec of the generated bundles
+	Spec AppBundleSpec `json:"spec"`
+}
+
+// AppBundleSetStatus defines the observed state of AppBundleSet
+type AppBundleSetStatus struct {
+	// Bundles is the number of bundles generated
+	// +optional
+	Bundles int32 `json:"bundles,omitempty"`
+
+	// Conditions describe the state of the set
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ConditionBundlesGenerated reports whether the bundles of the set are generated
+	ConditionBundlesGenerated = "Generated"
+
+	// ReasonBundlesGenerated is set once the bundles of the set are generated
+	ReasonBundlesGenerated = "BundlesGenerated"
+	// ReasonGeneratorFailed is set when a generator or the template fails
+	ReasonGeneratorFailed = "GeneratorFailed"
+)
+
+//+genclient
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Bundles",type=integer,JSONPath=`.status.bundles`
+//+kubebuilder:printcolumn:name="Generated",type=string,JSONPath=`.status.conditions[?(@.type=="Generated")].status`
+
+// AppBundleSet generates AppBundles from a template for the parameter sets and the
+// clusters of its generators, for the workloads parameterized per cluster beyond the
+// cluster templating of the manifests. The generated bundles are deleted with the set
+// or once no longer generated.
+type AppBundleSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AppBundleSetSpec   `json:"spec"`
+	Status AppBundleSetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// AppBundleSetList contains a list of AppBundleSet
+type AppBundleSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AppBundleSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AppBundleSet{}, &AppBundleSetList{})
+}
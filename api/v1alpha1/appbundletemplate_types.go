@@ -0,0 +1,93 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AppBundleTemplateSpec lists the versions of a template
+type AppBundleTemplateSpec struct {
+	// Description of the template, e.g. shown in catalogs
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Versions of the template, the last one being the latest
+	// +kubebuilder:validation:MinItems=1
+	Versions []AppBundleTemplateVersion `json:"versions"`
+}
+
+// AppBundleTemplateVersion is a version of the workload of a template
+type AppBundleTemplateVersion struct {
+	// Version of the template, e.g. 1.2.0
+	Version string `json:"version"`
+
+	// Parameters accepted by the version
+	// +optional
+	Parameters []TemplateParameter `json:"parameters,omitempty"`
+
+	// Manifests are YAML documents distributed with the workload of the bundles, a Go
+	// template given the parameters as .Params, e.g. replicas: {{ .Params.replicas }}
+	Manifests string `json:"manifests"`
+}
+
+// TemplateParameter is a parameter of a template version
+type TemplateParameter struct {
+	// Name of the parameter
+	Name string `json:"name"`
+
+	// Description of the parameter
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Default value of the parameter
+	// +optional
+	Default string `json:"default,omitempty"`
+
+	// Required parameters must be set by the bundles
+	// +optional
+	Required bool `json:"required,omitempty"`
+}
+
+//+genclient
+//+genclient:noStatus
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="Description",type=string,JSONPath=`.spec.description`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// AppBundleTemplate is a versioned and parameterized workload, which AppBundles
+// reference to distribute it. The bundles following the latest version of the template
+// roll out its new versions.
+type AppBundleTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AppBundleTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// AppBundleTemplateList contains a list of AppBundleTemplate
+type AppBundleTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AppBundleTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AppBundleTemplate{}, &AppBundleTemplateList{})
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CatalogSpec describes an index of AppBundleTemplates synced in the namespace of the
+// catalog
+type CatalogSpec struct {
+	// Git is a file of the index in a Git repository
+	// +optional
+	Git *CatalogGitSource `json:"git,omitempty"`
+
+	// OCI is an artifact of the index in an OCI registry
+	// +optional
+	OCI *CatalogOCISource `json:"oci,omitempty"`
+
+	// Interval between two syncs of the index
+	// +kubebuilder:default="10m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Tenants selects the namespaces whose bundles may reference the templates of the
+	// catalog. The bundles of the namespace of the catalog always may.
+	// +optional
+	Tenants *metav1.LabelSelector `json:"tenants,omitempty"`
+
+	// Viewers are granted read access to the templates of the catalog
+	// +optional
+	Viewers []rbacv1.Subject `json:"viewers,omitempty"`
+}
+
+// CatalogGitSource is a YAML file of AppBundleTemplates in a Git repository, read over
+// HTTPS from the raw file endpoint of the Git host
+type CatalogGitSource struct {
+	// URL of the repository, e.g. https://github.com/acme/catalog
+	URL string `json:"url"`
+
+	// Ref is the branch, tag or commit read
+	// +kubebuilder:default=main
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// Path of the index file in the repository
+	// +kubebuilder:default=catalog.yaml
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// CatalogOCISource is an artifact whose first layer is a YAML file of
+// AppBundleTemplates, e.g. pushed with oras push
+type CatalogOCISource struct {
+	// Image is the repository of the artifact, e.g. ghcr.io/acme/catalog
+	Image string `json:"image"`
+
+	// Tag of the artifact
+	// +kubebuilder:default=latest
+	// +optional
+	Tag string `json:"tag,omitempty"`
+}
+
+// CatalogStatus defines the observed state of Catalog
+type CatalogStatus struct {
+	// Revision is the digest of the index last synced
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// LastSyncTime is when the index was last synced
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Templates are the names of the AppBundleTemplates of the catalog
+	// +optional
+	Templates []string `json:"templates,omitempty"`
+
+	// Conditions describe the state of the catalog
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ConditionCatalogSynced reports whether the index of the catalog is synced
+	ConditionCatalogSynced = "Synced"
+
+	// ReasonCatalogSynced is set once the templates of the index are synced
+	ReasonCatalogSynced = "CatalogSynced"
+	// ReasonCatalogSyncFailed is set when the index cannot be read or applied
+	ReasonCatalogSyncFailed = "CatalogSyncFailed"
+)
+
+//+genclient
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Revision",type=string,JSONPath=`.status.revision`
+//+kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+//+kubebuilder:printcolumn:name="Last Sync",type=date,JSONPath=`.status.lastSyncTime`
+
+// Catalog syncs the AppBundleTemplates of an index in a Git repository or an OCI
+// registry on an interval, and makes them available to the bundles of tenant
+// namespaces
+type Catalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CatalogSpec   `json:"spec"`
+	Status CatalogStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CatalogList contains a list of Catalog
+type CatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Catalog `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Catalog{}, &CatalogList{})
+}
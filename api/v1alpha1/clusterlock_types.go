@@ -0,0 +1,62 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterLockSpec describes why a cluster is locked
+type ClusterLockSpec struct {
+	// Reason explains why the cluster is locked, e.g. the incident being handled
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// LockedBy identifies who locked the cluster
+	// +optional
+	LockedBy string `json:"lockedBy,omitempty"`
+}
+
+//+genclient
+//+genclient:noStatus
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.spec.reason`
+//+kubebuilder:printcolumn:name="Locked By",type=string,JSONPath=`.spec.lockedBy`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterLock freezes the changes to a managed cluster: while a ClusterLock exists in
+// the cluster namespace, the ManifestWorks of that namespace are not created, updated
+// or deleted. The existing ManifestWorks are left untouched.
+type ClusterLock struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterLockSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterLockList contains a list of ClusterLock
+type ClusterLockList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterLock `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterLock{}, &ClusterLockList{})
+}
@@ -33,4 +33,13 @@ var (
 
 	// AddToScheme adds the types in this group-version to the given scheme.
 	AddToScheme = SchemeBuilder.AddToScheme
+
+	// SchemeGroupVersion is the group version of the generated clientset, listers and
+	// informers
+	SchemeGroupVersion = GroupVersion
 )
+
+// Resource takes an unqualified resource and returns a group qualified GroupResource
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
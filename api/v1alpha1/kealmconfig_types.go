@@ -0,0 +1,492 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// KealmConfigName is the name of the KealmConfig singleton read by the controller
+const KealmConfigName = "default"
+
+// KealmConfigSpec defines the controller-wide defaults
+type KealmConfigSpec struct {
+	// DeleteOption is applied to the ManifestWorks generated for bundles not setting one.
+	// +optional
+	DeleteOption *workapiv1.DeleteOption `json:"deleteOption,omitempty"`
+
+	// LabelPropagation controls which labels and annotations of the bundles are
+	// propagated to the generated ManifestWorks. All are propagated when not set.
+	// +optional
+	LabelPropagation *PropagationPolicy `json:"labelPropagation,omitempty"`
+
+	// MaxConcurrentReconciles limits how many bundles are reconciled concurrently, within
+	// the bound set by the --max-concurrent-reconciles flag of the controller.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrentReconciles *int32 `json:"maxConcurrentReconciles,omitempty"`
+
+	// NotificationSinks receive the warning events recorded for bundles.
+	// +optional
+	NotificationSinks []NotificationSink `json:"notificationSinks,omitempty"`
+
+	// Guardrails are checked against the rendered manifests of every bundle. Bundles
+	// violating a guardrail are not distributed.
+	// +optional
+	Guardrails []Guardrail `json:"guardrails,omitempty"`
+
+	// WebhookTriggers map the payloads received by the webhook receiver on
+	// /triggers/<name> to actions on bundles
+	// +optional
+	WebhookTriggers []WebhookTrigger `json:"webhookTriggers,omitempty"`
+
+	// RetainedKinds lists the kinds, as Kind or Kind.group, whose resources are never
+	// deleted from the managed clusters: neither when removed from a bundle nor when the
+	// bundle is deleted, e.g. PersistentVolumeClaim to protect stateful data
+	// +optional
+	RetainedKinds []string `json:"retainedKinds,omitempty"`
+
+	// MaxConcurrentChangesPerCluster limits how many bundles change a cluster at the
+	// same time. A bundle changes a cluster until its ManifestWork is applied, fails to
+	// apply, or for 10m at most, bundles exceeding the budget are deferred. The budget
+	// is best effort, the bundles reconciled concurrently may exceed it. Unlimited when
+	// not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrentChangesPerCluster *int32 `json:"maxConcurrentChangesPerCluster,omitempty"`
+
+	// ResyncInterval between the reconciles of every bundle, so that the bundles
+	// converge even when a watch event is missed. A jitter of up to 20% is added.
+	// Defaults to 10m, 0 disables the resync.
+	// +optional
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+
+	// MetricsEndpoint is the URL of the Prometheus compatible API, e.g. a Thanos
+	// querier or a federating Prometheus, the analysis queries of the bundles run against
+	// +optional
+	MetricsEndpoint string `json:"metricsEndpoint,omitempty"`
+
+	// ImageInventory records the images distributed by each bundle, and the clusters
+	// it is distributed to, in a <bundle>-image-inventory ConfigMap of its namespace
+	// +optional
+	ImageInventory bool `json:"imageInventory,omitempty"`
+
+	// WorkHistory records the ManifestWork spec applied to each cluster for the latest
+	// generations of each bundle, in a <bundle>-<cluster>-work-history ConfigMap of its
+	// namespace, to explain what changed on a cluster with kealm history diff
+	// +optional
+	WorkHistory *WorkHistory `json:"workHistory,omitempty"`
+
+	// NamespaceLabels stamps the Namespaces distributed by the bundles with labels, so
+	// the policy engines of the managed clusters treat them consistently
+	// +optional
+	NamespaceLabels *NamespaceLabels `json:"namespaceLabels,omitempty"`
+
+	// ReadOnly stops the controller from creating, updating or deleting ManifestWorks,
+	// as if every cluster was locked, while the status of the bundles is still
+	// aggregated, e.g. during an upgrade of the hub or a migration of OCM
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// HubServer is the URL of the hub API server written in the kubeconfigs of the
+	// bundles with hub access, as reachable from the managed clusters
+	// +optional
+	HubServer string `json:"hubServer,omitempty"`
+
+	// SecurityGate reviews the images of the bundles with an external scanner before
+	// distributing them
+	// +optional
+	SecurityGate *SecurityGate `json:"securityGate,omitempty"`
+
+	// DistributionPlugins validate and mutate the manifests distributed to every
+	// cluster, in order. The mutations of all plugins run before their validations.
+	// +optional
+	DistributionPlugins []DistributionPlugin `json:"distributionPlugins,omitempty"`
+
+	// LintRules override the severity of the lint rules, which the admission webhook
+	// and kealm lint check the inline manifests of the bundles against. The rules not
+	// listed warn.
+	// +optional
+	LintRules []LintRule `json:"lintRules,omitempty"`
+
+	// RolloutSLO is the objective of the rollouts of the bundles. When set, the bundles
+	// report the SLOViolated condition and the rollout SLI and burn rate metrics.
+	// +optional
+	RolloutSLO *RolloutSLO `json:"rolloutSLO,omitempty"`
+
+	// StalledWorks detects the works the work agents of the clusters do not apply. When
+	// set, the stalled clusters of the bundles are reported in their status and by
+	// WorkStalled events.
+	// +optional
+	StalledWorks *StalledWorks `json:"stalledWorks,omitempty"`
+
+	// ResourceGating enables the resource-aware gating: the bundles are not distributed
+	// to the clusters whose allocatable resources, less the requests of the bundles
+	// already distributed to them, cannot fit their requests
+	// +optional
+	ResourceGating *ResourceGating `json:"resourceGating,omitempty"`
+
+	// SecretProviders are the external secret backends the secret values of the
+	// bundles are looked up in
+	// +optional
+	SecretProviders *SecretProviders `json:"secretProviders,omitempty"`
+
+	// ClaimKinds lists the kinds, as Kind.group, the Crossplane components of the bundles
+	// may apply on the hub, e.g. PostgreSQLInstance.database.acme.com. The components
+	// apply none when not set.
+	// +optional
+	ClaimKinds []string `json:"claimKinds,omitempty"`
+
+	// GitHosts lists the hosts, e.g. github.com or git.acme.com:8443, of the repositories
+	// the gitDirectories generators of the AppBundleSets list over HTTPS. The generators
+	// fail when not set.
+	// +optional
+	GitHosts []string `json:"gitHosts,omitempty"`
+}
+
+// SecretProviders configures the external secret backends
+type SecretProviders struct {
+	// Vault is a HashiCorp Vault server, authenticated with the VAULT_TOKEN environment
+	// variable of the controller
+	// +optional
+	Vault *VaultProvider `json:"vault,omitempty"`
+
+	// AWSSecretsManager is the AWS Secrets Manager of a region, authenticated with the
+	// default credential chain of the AWS SDK, e.g. the IAM role of the ServiceAccount
+	// or of the instance of the controller
+	// +optional
+	AWSSecretsManager *AWSSecretsManagerProvider `json:"awsSecretsManager,omitempty"`
+
+	// Namespaces are the secrets the bundles of each namespace may look up. The bundles
+	// of the namespaces not listed look up none.
+	// +optional
+	Namespaces []SecretNamespace `json:"namespaces,omitempty"`
+
+	// RefreshInterval between two lookups of a secret value, after which its rotation
+	// is distributed. Defaults to 5m.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// SecretNamespace are the secrets the bundles of a namespace may look up
+type SecretNamespace struct {
+	// Name of the namespace
+	Name string `json:"name"`
+
+	// PathPrefixes of the secrets, the Vault paths or the AWS Secrets Manager secret
+	// IDs under one of them, matched on whole segments, e.g. secret/data/shop/
+	PathPrefixes []string `json:"pathPrefixes"`
+}
+
+// VaultProvider configures a Vault server
+type VaultProvider struct {
+	// Address of the server, e.g. https://vault.acme.com:8200
+	Address string `json:"address"`
+
+	// Namespace of Vault Enterprise the secrets are read in
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// AWSSecretsManagerProvider configures the AWS Secrets Manager of a region
+type AWSSecretsManagerProvider struct {
+	// Region of the secrets, e.g. eu-west-1
+	Region string `json:"region"`
+
+	// Endpoint overrides the endpoint of the region, e.g. for a VPC endpoint
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// WorkHistory configures the recording of the works applied to each cluster
+type WorkHistory struct {
+	// Generations is the number of generations of a bundle recorded per cluster
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Generations int32 `json:"generations,omitempty"`
+}
+
+// NamespaceLabels configures the labels set on the distributed Namespaces. They override
+// the labels of the manifests.
+type NamespaceLabels struct {
+	// PodSecurity is the pod-security.kubernetes.io/enforce level
+	// +kubebuilder:validation:Enum=privileged;baseline;restricted
+	// +optional
+	PodSecurity string `json:"podSecurity,omitempty"`
+
+	// IstioInjection is the value of the istio-injection label
+	// +kubebuilder:validation:Enum=enabled;disabled
+	// +optional
+	IstioInjection string `json:"istioInjection,omitempty"`
+
+	// TenantLabel is the key of the label set to the namespace of the bundle on the hub
+	// +optional
+	TenantLabel string `json:"tenantLabel,omitempty"`
+
+	// Labels are set as is
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ResourceGating configures the resource-aware gating of the bundles
+type ResourceGating struct {
+	// Preemption lets the bundles which do not fit on a cluster preempt the bundles
+	// of a lower priority distributed to it, which are removed from the cluster until
+	// it fits them again
+	// +optional
+	Preemption bool `json:"preemption,omitempty"`
+}
+
+// RolloutSLO requires a percentage of the clusters of a bundle to be Available within
+// a window of each change of the bundle
+type RolloutSLO struct {
+	// Objective is the percentage of the clusters whose work must become Available,
+	// defaults to 95
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	// +optional
+	Objective int32 `json:"objective,omitempty"`
+
+	// Window after the write of the content of a work on the hub, defaults to 15m
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+}
+
+// StalledWorks marks the works stalled when they are not Applied, or still Progressing,
+// within a timeout of the write of their content
+type StalledWorks struct {
+	// Timeout after the write of the content of a work on the hub, defaults to 15m
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Recreate deletes the stalled works, for the controller to create them again,
+	// e.g. to recover from a work agent which missed their events
+	// +optional
+	Recreate bool `json:"recreate,omitempty"`
+}
+
+// LintSeverity is the severity of the findings of a lint rule
+// +kubebuilder:validation:Enum=Warn;Deny;Off
+type LintSeverity string
+
+const (
+	// LintWarn admits the bundles with a warning
+	LintWarn LintSeverity = "Warn"
+	// LintDeny denies the bundles
+	LintDeny LintSeverity = "Deny"
+	// LintOff disables the rule
+	LintOff LintSeverity = "Off"
+)
+
+// LintRule sets the severity of a lint rule
+type LintRule struct {
+	// Name of the rule: resource-requests, latest-tag, host-path or deprecated-api
+	// +kubebuilder:validation:Enum=resource-requests;latest-tag;host-path;deprecated-api
+	Name string `json:"name"`
+
+	// Severity of the findings of the rule
+	Severity LintSeverity `json:"severity"`
+}
+
+// DistributionPlugin is a built-in plugin, cluster-label or require-resource-limits,
+// or an experimental WebAssembly plugin
+type DistributionPlugin struct {
+	// Name of the built-in plugin, or of the WebAssembly plugin
+	Name string `json:"name"`
+
+	// Module is the path of the WebAssembly module of the plugin in the directory set by
+	// --wasm-plugin-dir, run with the runtime set by --wasm-runtime
+	// +optional
+	Module string `json:"module,omitempty"`
+}
+
+// SecurityGateFailurePolicy selects how scanner errors are handled
+// +kubebuilder:validation:Enum=Fail;Ignore
+type SecurityGateFailurePolicy string
+
+const (
+	// SecurityGateFail blocks the bundles when the scanner fails
+	SecurityGateFail SecurityGateFailurePolicy = "Fail"
+	// SecurityGateIgnore distributes the bundles when the scanner fails
+	SecurityGateIgnore SecurityGateFailurePolicy = "Ignore"
+)
+
+// SecurityGate is a scanner webhook reviewing the images of the bundles. The webhook
+// receives the bundle and its images as JSON, and answers whether the images are
+// allowed, e.g. denying images with vulnerabilities above the severity threshold.
+type SecurityGate struct {
+	// URL of the scanner webhook
+	URL string `json:"url"`
+
+	// SeverityThreshold is passed to the scanner, which denies the images with
+	// vulnerabilities of this severity or higher
+	// +kubebuilder:validation:Enum=Critical;High;Medium;Low
+	// +optional
+	SeverityThreshold string `json:"severityThreshold,omitempty"`
+
+	// RecheckInterval between the reviews of unchanged images, as new vulnerabilities
+	// are published. Defaults to 1h.
+	// +optional
+	RecheckInterval *metav1.Duration `json:"recheckInterval,omitempty"`
+
+	// FailurePolicy when the scanner fails, defaults to Fail
+	// +optional
+	FailurePolicy SecurityGateFailurePolicy `json:"failurePolicy,omitempty"`
+
+	// OverrideGroups lists the groups of the users allowed to set the
+	// cluster.open-cluster-management.io/security-gate-override annotation, which
+	// distributes a denied bundle. Requires the admission webhooks.
+	// +optional
+	OverrideGroups []string `json:"overrideGroups,omitempty"`
+}
+
+// PropagationMode selects which labels and annotations are propagated
+// +kubebuilder:validation:Enum=All;None;Selected
+type PropagationMode string
+
+const (
+	// PropagateAll propagates all labels and annotations
+	PropagateAll PropagationMode = "All"
+	// PropagateNone propagates no labels and annotations
+	PropagateNone PropagationMode = "None"
+	// PropagateSelected propagates the labels and annotations matching the listed prefixes
+	PropagateSelected PropagationMode = "Selected"
+)
+
+// PropagationPolicy controls which labels and annotations are propagated
+type PropagationPolicy struct {
+	// Mode selects which labels and annotations are propagated
+	Mode PropagationMode `json:"mode"`
+
+	// Labels lists the prefixes of the label keys propagated in Selected mode
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+
+	// Annotations lists the prefixes of the annotation keys propagated in Selected mode
+	// +optional
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// NotificationSink is a webhook receiving notifications as JSON POST requests
+type NotificationSink struct {
+	// Name of the sink
+	Name string `json:"name"`
+
+	// URL of the webhook
+	URL string `json:"url"`
+}
+
+// Guardrail denies the distribution of bundles containing matching manifests
+type Guardrail struct {
+	// Name of the guardrail
+	Name string `json:"name"`
+
+	// DeniedKinds lists the denied kinds, as Kind or Kind.group
+	// +optional
+	DeniedKinds []string `json:"deniedKinds,omitempty"`
+
+	// DeniedNamespaces lists the namespaces manifests must not target
+	// +optional
+	DeniedNamespaces []string `json:"deniedNamespaces,omitempty"`
+
+	// Message is reported when the guardrail is violated
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// TriggerAction is the action of a webhook trigger
+// +kubebuilder:validation:Enum=ImageTag;Resync;Promote
+type TriggerAction string
+
+const (
+	// TriggerActionImageTag sets the tag of the image in the bundle manifests
+	TriggerActionImageTag TriggerAction = "ImageTag"
+	// TriggerActionResync reconciles the bundle again
+	TriggerActionResync TriggerAction = "Resync"
+	// TriggerActionPromote copies the workload of the bundle to another bundle
+	TriggerActionPromote TriggerAction = "Promote"
+)
+
+// WebhookTrigger maps the payloads received on /triggers/<name> to an action on a bundle
+type WebhookTrigger struct {
+	// Name of the trigger, in the path of the receiver
+	Name string `json:"name"`
+
+	// Event restricts the trigger to the payloads of this event type, as sent in the
+	// X-GitHub-Event header
+	// +optional
+	Event string `json:"event,omitempty"`
+
+	// BundleNamespace and BundleName reference the bundle acted on
+	BundleNamespace string `json:"bundleNamespace"`
+	BundleName      string `json:"bundleName"`
+
+	// Action performed on the bundle
+	Action TriggerAction `json:"action"`
+
+	// Image is the image, without tag, whose tag is set by the ImageTag action
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// TagPath is the JSONPath of the tag in the payload for the ImageTag action,
+	// e.g. {.release.tag_name}
+	// +optional
+	TagPath string `json:"tagPath,omitempty"`
+
+	// PromoteTo is the name of the bundle, in the same namespace, the workload is
+	// copied to by the Promote action
+	// +optional
+	PromoteTo string `json:"promoteTo,omitempty"`
+}
+
+// KealmConfigStatus defines the observed state of KealmConfig
+type KealmConfigStatus struct {
+	// ObservedGeneration is the generation of the configuration loaded by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+genclient
+//+genclient:nonNamespaced
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// KealmConfig configures controller-wide defaults. The controller reads the instance
+// named "default" and reloads it when it changes.
+type KealmConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KealmConfigSpec   `json:"spec,omitempty"`
+	Status KealmConfigStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KealmConfigList contains a list of KealmConfig
+type KealmConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KealmConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KealmConfig{}, &KealmConfigList{})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KealmTenantSpec describes the identity of the bundles of a tenant namespace
+type KealmTenantSpec struct {
+	// ServiceAccountName is the ServiceAccount of the namespace impersonated to write
+	// the ManifestWorks of its bundles. The controller writes them with its own
+	// privileges when empty.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Quota bounds the bundles of the namespace
+	// +optional
+	Quota *TenantQuota `json:"quota,omitempty"`
+}
+
+// TenantQuota bounds the load the bundles of a namespace put on the hub
+type TenantQuota struct {
+	// MaxBundles is the maximum number of bundles of the namespace
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxBundles *int32 `json:"maxBundles,omitempty"`
+
+	// MaxClusters is the maximum number of distinct clusters targeted by the bundles
+	// of the namespace
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxClusters *int32 `json:"maxClusters,omitempty"`
+
+	// MaxManifestBytes is the maximum total size of the rendered manifests of the
+	// bundles of the namespace
+	// +optional
+	MaxManifestBytes *resource.Quantity `json:"maxManifestBytes,omitempty"`
+}
+
+// TenantUsage is the usage of the quota of a namespace
+type TenantUsage struct {
+	// Bundles is the number of bundles
+	Bundles int32 `json:"bundles"`
+
+	// Clusters is the number of distinct clusters targeted
+	Clusters int32 `json:"clusters"`
+
+	// ManifestBytes is the total size of the rendered manifests
+	ManifestBytes int64 `json:"manifestBytes"`
+}
+
+// KealmTenantStatus defines the observed state of KealmTenant
+type KealmTenantStatus struct {
+	// Usage of the quota by the bundles of the namespace, as last reconciled
+	// +optional
+	Usage TenantUsage `json:"usage,omitempty"`
+
+	// Conditions describe the state of the tenant
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+genclient
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Service Account",type=string,JSONPath=`.spec.serviceAccountName`
+//+kubebuilder:printcolumn:name="Bundles",type=integer,JSONPath=`.status.usage.bundles`
+//+kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.usage.clusters`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// KealmTenant makes a namespace a tenant: the ManifestWorks of its bundles are
+// created, updated and deleted impersonating a ServiceAccount of the namespace, so
+// that the hub RBAC of that ServiceAccount bounds the clusters the tenant distributes
+// to, and its bundles are bounded by a quota. A namespace has at most one KealmTenant.
+type KealmTenant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KealmTenantSpec   `json:"spec"`
+	Status KealmTenantStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KealmTenantList contains a list of KealmTenant
+type KealmTenantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KealmTenant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KealmTenant{}, &KealmTenantList{})
+}
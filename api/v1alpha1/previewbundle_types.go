@@ -0,0 +1,108 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PreviewBundleSpec describes a short-lived preview of a pull request
+type PreviewBundleSpec struct {
+	// BaseBundle is the name of the AppBundle in the same namespace the preview is
+	// stamped out from
+	BaseBundle string `json:"baseBundle"`
+
+	// PullRequest identifies the pull request previewed
+	PullRequest PullRequest `json:"pullRequest"`
+
+	// Placement is the name of the placement of the preview bundle
+	// +kubebuilder:default=preview
+	// +optional
+	Placement string `json:"placement,omitempty"`
+
+	// TTL is how long the preview lives after its creation
+	// +kubebuilder:default="72h"
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+// PullRequest identifies a pull request
+type PullRequest struct {
+	// Repository is the full name of the repository, e.g. owner/name
+	Repository string `json:"repository"`
+
+	// Number of the pull request
+	// +kubebuilder:validation:Minimum=1
+	Number int32 `json:"number"`
+}
+
+// PreviewBundleStatus defines the observed state of PreviewBundle
+type PreviewBundleStatus struct {
+	// BundleName is the name of the preview AppBundle
+	// +optional
+	BundleName string `json:"bundleName,omitempty"`
+
+	// ExpiresAt is when the preview is torn down
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// Conditions describe the state of the preview
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ConditionPreviewReady reports whether the preview bundle is stamped out
+	ConditionPreviewReady = "Ready"
+
+	// ReasonPreviewCreated is set once the preview bundle exists
+	ReasonPreviewCreated = "PreviewCreated"
+	// ReasonBaseBundleNotFound is set when the base bundle does not exist
+	ReasonBaseBundleNotFound = "BaseBundleNotFound"
+)
+
+//+genclient
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Base",type=string,JSONPath=`.spec.baseBundle`
+//+kubebuilder:printcolumn:name="Repository",type=string,JSONPath=`.spec.pullRequest.repository`
+//+kubebuilder:printcolumn:name="PR",type=integer,JSONPath=`.spec.pullRequest.number`
+//+kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.status.expiresAt`
+
+// PreviewBundle stamps out a short-lived AppBundle from a base one, targeting a preview
+// placement, for a pull request. The preview is deleted on expiry or when the pull
+// request is closed.
+type PreviewBundle struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PreviewBundleSpec   `json:"spec"`
+	Status PreviewBundleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PreviewBundleList contains a list of PreviewBundle
+type PreviewBundleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PreviewBundle `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PreviewBundle{}, &PreviewBundleList{})
+}
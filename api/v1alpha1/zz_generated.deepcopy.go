@@ -22,9 +22,95 @@ limitations under the License.
 package v1alpha1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	workv1 "open-cluster-management.io/api/work/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSSecretReference) DeepCopyInto(out *AWSSecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSSecretReference.
+func (in *AWSSecretReference) DeepCopy() *AWSSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSSecretsManagerProvider) DeepCopyInto(out *AWSSecretsManagerProvider) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSSecretsManagerProvider.
+func (in *AWSSecretsManagerProvider) DeepCopy() *AWSSecretsManagerProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSSecretsManagerProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Analysis) DeepCopyInto(out *Analysis) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]AnalysisMetric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Analysis.
+func (in *Analysis) DeepCopy() *Analysis {
+	if in == nil {
+		return nil
+	}
+	out := new(Analysis)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnalysisMetric) DeepCopyInto(out *AnalysisMetric) {
+	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnalysisMetric.
+func (in *AnalysisMetric) DeepCopy() *AnalysisMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AppBundle) DeepCopyInto(out *AppBundle) {
 	*out = *in
@@ -52,6 +138,93 @@ func (in *AppBundle) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleAudit) DeepCopyInto(out *AppBundleAudit) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleAudit.
+func (in *AppBundleAudit) DeepCopy() *AppBundleAudit {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleAudit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppBundleAudit) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleAuditList) DeepCopyInto(out *AppBundleAuditList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AppBundleAudit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleAuditList.
+func (in *AppBundleAuditList) DeepCopy() *AppBundleAuditList {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleAuditList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppBundleAuditList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleAuditSpec) DeepCopyInto(out *AppBundleAuditSpec) {
+	*out = *in
+	if in.ChangedBy != nil {
+		in, out := &in.ChangedBy, &out.ChangedBy
+		*out = make([]ChangeAuthor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Diff.DeepCopyInto(&out.Diff)
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterAction, len(*in))
+		copy(*out, *in)
+	}
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleAuditSpec.
+func (in *AppBundleAuditSpec) DeepCopy() *AppBundleAuditSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleAuditSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AppBundleList) DeepCopyInto(out *AppBundleList) {
 	*out = *in
@@ -84,9 +257,285 @@ func (in *AppBundleList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleSet) DeepCopyInto(out *AppBundleSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleSet.
+func (in *AppBundleSet) DeepCopy() *AppBundleSet {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppBundleSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleSetList) DeepCopyInto(out *AppBundleSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AppBundleSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleSetList.
+func (in *AppBundleSetList) DeepCopy() *AppBundleSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppBundleSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleSetSpec) DeepCopyInto(out *AppBundleSetSpec) {
+	*out = *in
+	if in.Generators != nil {
+		in, out := &in.Generators, &out.Generators
+		*out = make([]BundleSetGenerator, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleSetSpec.
+func (in *AppBundleSetSpec) DeepCopy() *AppBundleSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleSetStatus) DeepCopyInto(out *AppBundleSetStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleSetStatus.
+func (in *AppBundleSetStatus) DeepCopy() *AppBundleSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AppBundleSpec) DeepCopyInto(out *AppBundleSpec) {
 	*out = *in
+	in.ManifestWorkSpec.DeepCopyInto(&out.ManifestWorkSpec)
+	if in.WorkloadRefs != nil {
+		in, out := &in.WorkloadRefs, &out.WorkloadRefs
+		*out = make([]WorkloadReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]Component, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Flux != nil {
+		in, out := &in.Flux, &out.Flux
+		*out = new(FluxSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Analysis != nil {
+		in, out := &in.Analysis, &out.Analysis
+		*out = new(Analysis)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(Monitoring)
+		**out = **in
+	}
+	if in.ClusterSelection != nil {
+		in, out := &in.ClusterSelection, &out.ClusterSelection
+		*out = new(ClusterSelection)
+		**out = **in
+	}
+	if in.Spread != nil {
+		in, out := &in.Spread, &out.Spread
+		*out = make([]SpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Prune != nil {
+		in, out := &in.Prune, &out.Prune
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RetainedKinds != nil {
+		in, out := &in.RetainedKinds, &out.RetainedKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Instance != nil {
+		in, out := &in.Instance, &out.Instance
+		*out = new(Instance)
+		**out = **in
+	}
+	if in.Scaling != nil {
+		in, out := &in.Scaling, &out.Scaling
+		*out = new(Scaling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoscaledWorkloads != nil {
+		in, out := &in.AutoscaledWorkloads, &out.AutoscaledWorkloads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = new(Architectures)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OpenShift != nil {
+		in, out := &in.OpenShift, &out.OpenShift
+		*out = new(OpenShiftProfile)
+		**out = **in
+	}
+	if in.WriteBack != nil {
+		in, out := &in.WriteBack, &out.WriteBack
+		*out = new(WriteBack)
+		**out = **in
+	}
+	if in.SecretValues != nil {
+		in, out := &in.SecretValues, &out.SecretValues
+		*out = make([]SecretValue, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Bandwidth != nil {
+		in, out := &in.Bandwidth, &out.Bandwidth
+		*out = new(Bandwidth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AvailabilityPolicy != nil {
+		in, out := &in.AvailabilityPolicy, &out.AvailabilityPolicy
+		*out = new(AvailabilityPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(Drain)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Requires != nil {
+		in, out := &in.Requires, &out.Requires
+		*out = make([]BundleRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AntiAffinity != nil {
+		in, out := &in.AntiAffinity, &out.AntiAffinity
+		*out = make([]BundleAntiAffinity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreen)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(Rollout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RolloutOverrides != nil {
+		in, out := &in.RolloutOverrides, &out.RolloutOverrides
+		*out = new(RolloutOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]BundleCertificate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GlobalDNS != nil {
+		in, out := &in.GlobalDNS, &out.GlobalDNS
+		*out = new(GlobalDNS)
+		**out = **in
+	}
+	if in.HubAccess != nil {
+		in, out := &in.HubAccess, &out.HubAccess
+		*out = new(HubAccess)
+		**out = **in
+	}
+	if in.VersionSkew != nil {
+		in, out := &in.VersionSkew, &out.VersionSkew
+		*out = new(VersionSkewPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(TemplateReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageUpdates != nil {
+		in, out := &in.ImageUpdates, &out.ImageUpdates
+		*out = make([]ImageUpdatePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SBOMs != nil {
+		in, out := &in.SBOMs, &out.SBOMs
+		*out = make([]SBOMReference, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleSpec.
@@ -102,14 +551,2704 @@ func (in *AppBundleSpec) DeepCopy() *AppBundleSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AppBundleStatus) DeepCopyInto(out *AppBundleStatus) {
 	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleStatus.
-func (in *AppBundleStatus) DeepCopy() *AppBundleStatus {
-	if in == nil {
-		return nil
+	in.ManifestWorkStatus.DeepCopyInto(&out.ManifestWorkStatus)
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Provenance != nil {
+		in, out := &in.Provenance, &out.Provenance
+		*out = new(Provenance)
+		**out = **in
+	}
+	if in.Pruned != nil {
+		in, out := &in.Pruned, &out.Pruned
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Orphaned != nil {
+		in, out := &in.Orphaned, &out.Orphaned
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]ComponentStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]CertificateStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GlobalDNS != nil {
+		in, out := &in.GlobalDNS, &out.GlobalDNS
+		*out = new(GlobalDNSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Claims != nil {
+		in, out := &in.Claims, &out.Claims
+		*out = make([]ClaimStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.WriteBack != nil {
+		in, out := &in.WriteBack, &out.WriteBack
+		*out = new(WriteBackStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretRotation != nil {
+		in, out := &in.SecretRotation, &out.SecretRotation
+		*out = new(SecretRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(RolloutPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ImageStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleStatus.
+func (in *AppBundleStatus) DeepCopy() *AppBundleStatus {
+	if in == nil {
+		return nil
 	}
 	out := new(AppBundleStatus)
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleTemplate) DeepCopyInto(out *AppBundleTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleTemplate.
+func (in *AppBundleTemplate) DeepCopy() *AppBundleTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppBundleTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleTemplateList) DeepCopyInto(out *AppBundleTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AppBundleTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleTemplateList.
+func (in *AppBundleTemplateList) DeepCopy() *AppBundleTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppBundleTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleTemplateSpec) DeepCopyInto(out *AppBundleTemplateSpec) {
+	*out = *in
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]AppBundleTemplateVersion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleTemplateSpec.
+func (in *AppBundleTemplateSpec) DeepCopy() *AppBundleTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppBundleTemplateVersion) DeepCopyInto(out *AppBundleTemplateVersion) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]TemplateParameter, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppBundleTemplateVersion.
+func (in *AppBundleTemplateVersion) DeepCopy() *AppBundleTemplateVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(AppBundleTemplateVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchitectureImage) DeepCopyInto(out *ArchitectureImage) {
+	*out = *in
+	if in.Variants != nil {
+		in, out := &in.Variants, &out.Variants
+		*out = make(map[Architecture]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchitectureImage.
+func (in *ArchitectureImage) DeepCopy() *ArchitectureImage {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchitectureImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Architectures) DeepCopyInto(out *Architectures) {
+	*out = *in
+	if in.Supported != nil {
+		in, out := &in.Supported, &out.Supported
+		*out = make([]Architecture, len(*in))
+		copy(*out, *in)
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ArchitectureImage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Architectures.
+func (in *Architectures) DeepCopy() *Architectures {
+	if in == nil {
+		return nil
+	}
+	out := new(Architectures)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AvailabilityPolicy) DeepCopyInto(out *AvailabilityPolicy) {
+	*out = *in
+	if in.Toleration != nil {
+		in, out := &in.Toleration, &out.Toleration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AvailabilityPolicy.
+func (in *AvailabilityPolicy) DeepCopy() *AvailabilityPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AvailabilityPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Bandwidth) DeepCopyInto(out *Bandwidth) {
+	*out = *in
+	if in.CompressConfigMapsOver != nil {
+		in, out := &in.CompressConfigMapsOver, &out.CompressConfigMapsOver
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Bandwidth.
+func (in *Bandwidth) DeepCopy() *Bandwidth {
+	if in == nil {
+		return nil
+	}
+	out := new(Bandwidth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreen) DeepCopyInto(out *BlueGreen) {
+	*out = *in
+	in.Switch.DeepCopyInto(&out.Switch)
+	if in.Mesh != nil {
+		in, out := &in.Mesh, &out.Mesh
+		*out = new(MeshTraffic)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreviewDuration != nil {
+		in, out := &in.PreviewDuration, &out.PreviewDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreen.
+func (in *BlueGreen) DeepCopy() *BlueGreen {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreen)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenStatus) DeepCopyInto(out *BlueGreenStatus) {
+	*out = *in
+	if in.PreviewAvailableSince != nil {
+		in, out := &in.PreviewAvailableSince, &out.PreviewAvailableSince
+		*out = (*in).DeepCopy()
+	}
+	if in.WeightShiftedAt != nil {
+		in, out := &in.WeightShiftedAt, &out.WeightShiftedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenStatus.
+func (in *BlueGreenStatus) DeepCopy() *BlueGreenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleAntiAffinity) DeepCopyInto(out *BundleAntiAffinity) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleAntiAffinity.
+func (in *BundleAntiAffinity) DeepCopy() *BundleAntiAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleAntiAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleCertificate) DeepCopyInto(out *BundleCertificate) {
+	*out = *in
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.IssuerRef = in.IssuerRef
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleCertificate.
+func (in *BundleCertificate) DeepCopy() *BundleCertificate {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleCertificate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleRequirement) DeepCopyInto(out *BundleRequirement) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleRequirement.
+func (in *BundleRequirement) DeepCopy() *BundleRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleSetGenerator) DeepCopyInto(out *BundleSetGenerator) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = new(ClusterGenerator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.List != nil {
+		in, out := &in.List, &out.List
+		*out = new(ListGenerator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GitDirectories != nil {
+		in, out := &in.GitDirectories, &out.GitDirectories
+		*out = new(GitDirectoryGenerator)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleSetGenerator.
+func (in *BundleSetGenerator) DeepCopy() *BundleSetGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleSetGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleSetTemplate) DeepCopyInto(out *BundleSetTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleSetTemplate.
+func (in *BundleSetTemplate) DeepCopy() *BundleSetTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleSetTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Catalog) DeepCopyInto(out *Catalog) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Catalog.
+func (in *Catalog) DeepCopy() *Catalog {
+	if in == nil {
+		return nil
+	}
+	out := new(Catalog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Catalog) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogGitSource) DeepCopyInto(out *CatalogGitSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogGitSource.
+func (in *CatalogGitSource) DeepCopy() *CatalogGitSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogGitSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogList) DeepCopyInto(out *CatalogList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Catalog, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogList.
+func (in *CatalogList) DeepCopy() *CatalogList {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogOCISource) DeepCopyInto(out *CatalogOCISource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogOCISource.
+func (in *CatalogOCISource) DeepCopy() *CatalogOCISource {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogOCISource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogSpec) DeepCopyInto(out *CatalogSpec) {
+	*out = *in
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(CatalogGitSource)
+		**out = **in
+	}
+	if in.OCI != nil {
+		in, out := &in.OCI, &out.OCI
+		*out = new(CatalogOCISource)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Viewers != nil {
+		in, out := &in.Viewers, &out.Viewers
+		*out = make([]rbacv1.Subject, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogSpec.
+func (in *CatalogSpec) DeepCopy() *CatalogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogStatus) DeepCopyInto(out *CatalogStatus) {
+	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogStatus.
+func (in *CatalogStatus) DeepCopy() *CatalogStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateIssuerReference) DeepCopyInto(out *CertificateIssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateIssuerReference.
+func (in *CertificateIssuerReference) DeepCopy() *CertificateIssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateIssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateStatus) DeepCopyInto(out *CertificateStatus) {
+	*out = *in
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateStatus.
+func (in *CertificateStatus) DeepCopy() *CertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChangeAuthor) DeepCopyInto(out *ChangeAuthor) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeAuthor.
+func (in *ChangeAuthor) DeepCopy() *ChangeAuthor {
+	if in == nil {
+		return nil
+	}
+	out := new(ChangeAuthor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClaimStatus) DeepCopyInto(out *ClaimStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClaimStatus.
+func (in *ClaimStatus) DeepCopy() *ClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAction) DeepCopyInto(out *ClusterAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAction.
+func (in *ClusterAction) DeepCopy() *ClusterAction {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDeferral) DeepCopyInto(out *ClusterDeferral) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDeferral.
+func (in *ClusterDeferral) DeepCopy() *ClusterDeferral {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDeferral)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGenerator) DeepCopyInto(out *ClusterGenerator) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGenerator.
+func (in *ClusterGenerator) DeepCopy() *ClusterGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterHealth) DeepCopyInto(out *ClusterHealth) {
+	*out = *in
+	if in.DegradedAddOns != nil {
+		in, out := &in.DegradedAddOns, &out.DegradedAddOns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterHealth.
+func (in *ClusterHealth) DeepCopy() *ClusterHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterLock) DeepCopyInto(out *ClusterLock) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterLock.
+func (in *ClusterLock) DeepCopy() *ClusterLock {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterLock)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterLock) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterLockList) DeepCopyInto(out *ClusterLockList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterLock, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterLockList.
+func (in *ClusterLockList) DeepCopy() *ClusterLockList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterLockList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterLockList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterLockSpec) DeepCopyInto(out *ClusterLockSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterLockSpec.
+func (in *ClusterLockSpec) DeepCopy() *ClusterLockSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterLockSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSelection) DeepCopyInto(out *ClusterSelection) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSelection.
+func (in *ClusterSelection) DeepCopy() *ClusterSelection {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSelection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Incompatible != nil {
+		in, out := &in.Incompatible, &out.Incompatible
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnavailableSince != nil {
+		in, out := &in.UnavailableSince, &out.UnavailableSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Waiting != nil {
+		in, out := &in.Waiting, &out.Waiting
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Insufficient != nil {
+		in, out := &in.Insufficient, &out.Insufficient
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(ClusterHealth)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
+func (in *ClusterStatus) DeepCopy() *ClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Component) DeepCopyInto(out *Component) {
+	*out = *in
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]ComponentOutput, len(*in))
+		copy(*out, *in)
+	}
+	if in.Manifests != nil {
+		in, out := &in.Manifests, &out.Manifests
+		*out = make([]workv1.Manifest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.WorkloadRefs != nil {
+		in, out := &in.WorkloadRefs, &out.WorkloadRefs
+		*out = make([]WorkloadReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OS != nil {
+		in, out := &in.OS, &out.OS
+		*out = new(OSConstraint)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Component.
+func (in *Component) DeepCopy() *Component {
+	if in == nil {
+		return nil
+	}
+	out := new(Component)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentOutput) DeepCopyInto(out *ComponentOutput) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentOutput.
+func (in *ComponentOutput) DeepCopy() *ComponentOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentStatus) DeepCopyInto(out *ComponentStatus) {
+	*out = *in
+	if in.Waiting != nil {
+		in, out := &in.Waiting, &out.Waiting
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unsupported != nil {
+		in, out := &in.Unsupported, &out.Unsupported
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentStatus.
+func (in *ComponentStatus) DeepCopy() *ComponentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DistributionPlugin) DeepCopyInto(out *DistributionPlugin) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DistributionPlugin.
+func (in *DistributionPlugin) DeepCopy() *DistributionPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(DistributionPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Drain) DeepCopyInto(out *Drain) {
+	*out = *in
+	if in.Hook != nil {
+		in, out := &in.Hook, &out.Hook
+		*out = new(workv1.Manifest)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Drain.
+func (in *Drain) DeepCopy() *Drain {
+	if in == nil {
+		return nil
+	}
+	out := new(Drain)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FluxGitRepository) DeepCopyInto(out *FluxGitRepository) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FluxGitRepository.
+func (in *FluxGitRepository) DeepCopy() *FluxGitRepository {
+	if in == nil {
+		return nil
+	}
+	out := new(FluxGitRepository)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FluxHelmRelease) DeepCopyInto(out *FluxHelmRelease) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterSetValues != nil {
+		in, out := &in.ClusterSetValues, &out.ClusterSetValues
+		*out = make([]HelmClusterSetValues, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FluxHelmRelease.
+func (in *FluxHelmRelease) DeepCopy() *FluxHelmRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(FluxHelmRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FluxHelmRepository) DeepCopyInto(out *FluxHelmRepository) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FluxHelmRepository.
+func (in *FluxHelmRepository) DeepCopy() *FluxHelmRepository {
+	if in == nil {
+		return nil
+	}
+	out := new(FluxHelmRepository)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FluxKustomization) DeepCopyInto(out *FluxKustomization) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FluxKustomization.
+func (in *FluxKustomization) DeepCopy() *FluxKustomization {
+	if in == nil {
+		return nil
+	}
+	out := new(FluxKustomization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FluxPatch) DeepCopyInto(out *FluxPatch) {
+	*out = *in
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(FluxPatchTarget)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FluxPatch.
+func (in *FluxPatch) DeepCopy() *FluxPatch {
+	if in == nil {
+		return nil
+	}
+	out := new(FluxPatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FluxPatchTarget) DeepCopyInto(out *FluxPatchTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FluxPatchTarget.
+func (in *FluxPatchTarget) DeepCopy() *FluxPatchTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(FluxPatchTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FluxSource) DeepCopyInto(out *FluxSource) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GitRepository != nil {
+		in, out := &in.GitRepository, &out.GitRepository
+		*out = new(FluxGitRepository)
+		**out = **in
+	}
+	if in.HelmRepository != nil {
+		in, out := &in.HelmRepository, &out.HelmRepository
+		*out = new(FluxHelmRepository)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kustomization != nil {
+		in, out := &in.Kustomization, &out.Kustomization
+		*out = new(FluxKustomization)
+		**out = **in
+	}
+	if in.HelmRelease != nil {
+		in, out := &in.HelmRelease, &out.HelmRelease
+		*out = new(FluxHelmRelease)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]FluxPatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FluxSource.
+func (in *FluxSource) DeepCopy() *FluxSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FluxSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitDirectoryGenerator) DeepCopyInto(out *GitDirectoryGenerator) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitDirectoryGenerator.
+func (in *GitDirectoryGenerator) DeepCopy() *GitDirectoryGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(GitDirectoryGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalDNS) DeepCopyInto(out *GlobalDNS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalDNS.
+func (in *GlobalDNS) DeepCopy() *GlobalDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalDNSStatus) DeepCopyInto(out *GlobalDNSStatus) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalDNSStatus.
+func (in *GlobalDNSStatus) DeepCopy() *GlobalDNSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalDNSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Guardrail) DeepCopyInto(out *Guardrail) {
+	*out = *in
+	if in.DeniedKinds != nil {
+		in, out := &in.DeniedKinds, &out.DeniedKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedNamespaces != nil {
+		in, out := &in.DeniedNamespaces, &out.DeniedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Guardrail.
+func (in *Guardrail) DeepCopy() *Guardrail {
+	if in == nil {
+		return nil
+	}
+	out := new(Guardrail)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmClusterSetValues) DeepCopyInto(out *HelmClusterSetValues) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmClusterSetValues.
+func (in *HelmClusterSetValues) DeepCopy() *HelmClusterSetValues {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmClusterSetValues)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HubAccess) DeepCopyInto(out *HubAccess) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HubAccess.
+func (in *HubAccess) DeepCopy() *HubAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(HubAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HubComponentReference) DeepCopyInto(out *HubComponentReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HubComponentReference.
+func (in *HubComponentReference) DeepCopy() *HubComponentReference {
+	if in == nil {
+		return nil
+	}
+	out := new(HubComponentReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageStatus) DeepCopyInto(out *ImageStatus) {
+	*out = *in
+	if in.LastChecked != nil {
+		in, out := &in.LastChecked, &out.LastChecked
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageStatus.
+func (in *ImageStatus) DeepCopy() *ImageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageUpdatePolicy) DeepCopyInto(out *ImageUpdatePolicy) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageUpdatePolicy.
+func (in *ImageUpdatePolicy) DeepCopy() *ImageUpdatePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageUpdatePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Instance) DeepCopyInto(out *Instance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Instance.
+func (in *Instance) DeepCopy() *Instance {
+	if in == nil {
+		return nil
+	}
+	out := new(Instance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KealmConfig) DeepCopyInto(out *KealmConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KealmConfig.
+func (in *KealmConfig) DeepCopy() *KealmConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(KealmConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KealmConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KealmConfigList) DeepCopyInto(out *KealmConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KealmConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KealmConfigList.
+func (in *KealmConfigList) DeepCopy() *KealmConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(KealmConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KealmConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KealmConfigSpec) DeepCopyInto(out *KealmConfigSpec) {
+	*out = *in
+	if in.DeleteOption != nil {
+		in, out := &in.DeleteOption, &out.DeleteOption
+		*out = new(workv1.DeleteOption)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LabelPropagation != nil {
+		in, out := &in.LabelPropagation, &out.LabelPropagation
+		*out = new(PropagationPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxConcurrentReconciles != nil {
+		in, out := &in.MaxConcurrentReconciles, &out.MaxConcurrentReconciles
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NotificationSinks != nil {
+		in, out := &in.NotificationSinks, &out.NotificationSinks
+		*out = make([]NotificationSink, len(*in))
+		copy(*out, *in)
+	}
+	if in.Guardrails != nil {
+		in, out := &in.Guardrails, &out.Guardrails
+		*out = make([]Guardrail, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.WebhookTriggers != nil {
+		in, out := &in.WebhookTriggers, &out.WebhookTriggers
+		*out = make([]WebhookTrigger, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetainedKinds != nil {
+		in, out := &in.RetainedKinds, &out.RetainedKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxConcurrentChangesPerCluster != nil {
+		in, out := &in.MaxConcurrentChangesPerCluster, &out.MaxConcurrentChangesPerCluster
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.WorkHistory != nil {
+		in, out := &in.WorkHistory, &out.WorkHistory
+		*out = new(WorkHistory)
+		**out = **in
+	}
+	if in.NamespaceLabels != nil {
+		in, out := &in.NamespaceLabels, &out.NamespaceLabels
+		*out = new(NamespaceLabels)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecurityGate != nil {
+		in, out := &in.SecurityGate, &out.SecurityGate
+		*out = new(SecurityGate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DistributionPlugins != nil {
+		in, out := &in.DistributionPlugins, &out.DistributionPlugins
+		*out = make([]DistributionPlugin, len(*in))
+		copy(*out, *in)
+	}
+	if in.LintRules != nil {
+		in, out := &in.LintRules, &out.LintRules
+		*out = make([]LintRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.RolloutSLO != nil {
+		in, out := &in.RolloutSLO, &out.RolloutSLO
+		*out = new(RolloutSLO)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StalledWorks != nil {
+		in, out := &in.StalledWorks, &out.StalledWorks
+		*out = new(StalledWorks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceGating != nil {
+		in, out := &in.ResourceGating, &out.ResourceGating
+		*out = new(ResourceGating)
+		**out = **in
+	}
+	if in.SecretProviders != nil {
+		in, out := &in.SecretProviders, &out.SecretProviders
+		*out = new(SecretProviders)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClaimKinds != nil {
+		in, out := &in.ClaimKinds, &out.ClaimKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GitHosts != nil {
+		in, out := &in.GitHosts, &out.GitHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KealmConfigSpec.
+func (in *KealmConfigSpec) DeepCopy() *KealmConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KealmConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KealmConfigStatus) DeepCopyInto(out *KealmConfigStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KealmConfigStatus.
+func (in *KealmConfigStatus) DeepCopy() *KealmConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KealmConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KealmTenant) DeepCopyInto(out *KealmTenant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KealmTenant.
+func (in *KealmTenant) DeepCopy() *KealmTenant {
+	if in == nil {
+		return nil
+	}
+	out := new(KealmTenant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KealmTenant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KealmTenantList) DeepCopyInto(out *KealmTenantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KealmTenant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KealmTenantList.
+func (in *KealmTenantList) DeepCopy() *KealmTenantList {
+	if in == nil {
+		return nil
+	}
+	out := new(KealmTenantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KealmTenantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KealmTenantSpec) DeepCopyInto(out *KealmTenantSpec) {
+	*out = *in
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(TenantQuota)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KealmTenantSpec.
+func (in *KealmTenantSpec) DeepCopy() *KealmTenantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KealmTenantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KealmTenantStatus) DeepCopyInto(out *KealmTenantStatus) {
+	*out = *in
+	out.Usage = in.Usage
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KealmTenantStatus.
+func (in *KealmTenantStatus) DeepCopy() *KealmTenantStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KealmTenantStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LintRule) DeepCopyInto(out *LintRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LintRule.
+func (in *LintRule) DeepCopy() *LintRule {
+	if in == nil {
+		return nil
+	}
+	out := new(LintRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ListGenerator) DeepCopyInto(out *ListGenerator) {
+	*out = *in
+	if in.Elements != nil {
+		in, out := &in.Elements, &out.Elements
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ListGenerator.
+func (in *ListGenerator) DeepCopy() *ListGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(ListGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManifestDiff) DeepCopyInto(out *ManifestDiff) {
+	*out = *in
+	if in.Added != nil {
+		in, out := &in.Added, &out.Added
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changed != nil {
+		in, out := &in.Changed, &out.Changed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManifestDiff.
+func (in *ManifestDiff) DeepCopy() *ManifestDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(ManifestDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MeshTraffic) DeepCopyInto(out *MeshTraffic) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.StepDuration != nil {
+		in, out := &in.StepDuration, &out.StepDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeshTraffic.
+func (in *MeshTraffic) DeepCopy() *MeshTraffic {
+	if in == nil {
+		return nil
+	}
+	out := new(MeshTraffic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Monitoring) DeepCopyInto(out *Monitoring) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Monitoring.
+func (in *Monitoring) DeepCopy() *Monitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(Monitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceLabels) DeepCopyInto(out *NamespaceLabels) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceLabels.
+func (in *NamespaceLabels) DeepCopy() *NamespaceLabels {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceLabels)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationSink) DeepCopyInto(out *NotificationSink) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationSink.
+func (in *NotificationSink) DeepCopy() *NotificationSink {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSConstraint) DeepCopyInto(out *OSConstraint) {
+	*out = *in
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSConstraint.
+func (in *OSConstraint) DeepCopy() *OSConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(OSConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenShiftProfile) DeepCopyInto(out *OpenShiftProfile) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenShiftProfile.
+func (in *OpenShiftProfile) DeepCopy() *OpenShiftProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenShiftProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviewBundle) DeepCopyInto(out *PreviewBundle) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviewBundle.
+func (in *PreviewBundle) DeepCopy() *PreviewBundle {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviewBundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PreviewBundle) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviewBundleList) DeepCopyInto(out *PreviewBundleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PreviewBundle, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviewBundleList.
+func (in *PreviewBundleList) DeepCopy() *PreviewBundleList {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviewBundleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PreviewBundleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviewBundleSpec) DeepCopyInto(out *PreviewBundleSpec) {
+	*out = *in
+	out.PullRequest = in.PullRequest
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviewBundleSpec.
+func (in *PreviewBundleSpec) DeepCopy() *PreviewBundleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviewBundleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviewBundleStatus) DeepCopyInto(out *PreviewBundleStatus) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviewBundleStatus.
+func (in *PreviewBundleStatus) DeepCopy() *PreviewBundleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviewBundleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropagationPolicy) DeepCopyInto(out *PropagationPolicy) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropagationPolicy.
+func (in *PropagationPolicy) DeepCopy() *PropagationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PropagationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Provenance) DeepCopyInto(out *Provenance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Provenance.
+func (in *Provenance) DeepCopy() *Provenance {
+	if in == nil {
+		return nil
+	}
+	out := new(Provenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PullRequest) DeepCopyInto(out *PullRequest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullRequest.
+func (in *PullRequest) DeepCopy() *PullRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(PullRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceGating) DeepCopyInto(out *ResourceGating) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceGating.
+func (in *ResourceGating) DeepCopy() *ResourceGating {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceGating)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Rollout) DeepCopyInto(out *Rollout) {
+	*out = *in
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([]RolloutWave, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rollout.
+func (in *Rollout) DeepCopy() *Rollout {
+	if in == nil {
+		return nil
+	}
+	out := new(Rollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutGate) DeepCopyInto(out *RolloutGate) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PassedAt != nil {
+		in, out := &in.PassedAt, &out.PassedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutGate.
+func (in *RolloutGate) DeepCopy() *RolloutGate {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutOverrides) DeepCopyInto(out *RolloutOverrides) {
+	*out = *in
+	if in.PausedWaves != nil {
+		in, out := &in.PausedWaves, &out.PausedWaves
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deferrals != nil {
+		in, out := &in.Deferrals, &out.Deferrals
+		*out = make([]ClusterDeferral, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutOverrides.
+func (in *RolloutOverrides) DeepCopy() *RolloutOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutPlan) DeepCopyInto(out *RolloutPlan) {
+	*out = *in
+	in.ComputedAt.DeepCopyInto(&out.ComputedAt)
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([]WavePlan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutPlan.
+func (in *RolloutPlan) DeepCopy() *RolloutPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutSLO) DeepCopyInto(out *RolloutSLO) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutSLO.
+func (in *RolloutSLO) DeepCopy() *RolloutSLO {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutSLO)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutWave) DeepCopyInto(out *RolloutWave) {
+	*out = *in
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SoakTime != nil {
+		in, out := &in.SoakTime, &out.SoakTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutWave.
+func (in *RolloutWave) DeepCopy() *RolloutWave {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutWave)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SBOMReference) DeepCopyInto(out *SBOMReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SBOMReference.
+func (in *SBOMReference) DeepCopy() *SBOMReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SBOMReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Scaling) DeepCopyInto(out *Scaling) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]ScalingRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Scaling.
+func (in *Scaling) DeepCopy() *Scaling {
+	if in == nil {
+		return nil
+	}
+	out := new(Scaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingRule) DeepCopyInto(out *ScalingRule) {
+	*out = *in
+	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Multiplier != nil {
+		in, out := &in.Multiplier, &out.Multiplier
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingRule.
+func (in *ScalingRule) DeepCopy() *ScalingRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretNamespace) DeepCopyInto(out *SecretNamespace) {
+	*out = *in
+	if in.PathPrefixes != nil {
+		in, out := &in.PathPrefixes, &out.PathPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretNamespace.
+func (in *SecretNamespace) DeepCopy() *SecretNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretProviders) DeepCopyInto(out *SecretProviders) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultProvider)
+		**out = **in
+	}
+	if in.AWSSecretsManager != nil {
+		in, out := &in.AWSSecretsManager, &out.AWSSecretsManager
+		*out = new(AWSSecretsManagerProvider)
+		**out = **in
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]SecretNamespace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretProviders.
+func (in *SecretProviders) DeepCopy() *SecretProviders {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretProviders)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretRotationStatus) DeepCopyInto(out *SecretRotationStatus) {
+	*out = *in
+	in.DetectedAt.DeepCopyInto(&out.DetectedAt)
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Completed != nil {
+		in, out := &in.Completed, &out.Completed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretRotationStatus.
+func (in *SecretRotationStatus) DeepCopy() *SecretRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretValue) DeepCopyInto(out *SecretValue) {
+	*out = *in
+	in.ValueFrom.DeepCopyInto(&out.ValueFrom)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretValue.
+func (in *SecretValue) DeepCopy() *SecretValue {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretValue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretValueSource) DeepCopyInto(out *SecretValueSource) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultSecretReference)
+		**out = **in
+	}
+	if in.AWSSecretsManager != nil {
+		in, out := &in.AWSSecretsManager, &out.AWSSecretsManager
+		*out = new(AWSSecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretValueSource.
+func (in *SecretValueSource) DeepCopy() *SecretValueSource {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretValueSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityGate) DeepCopyInto(out *SecurityGate) {
+	*out = *in
+	if in.RecheckInterval != nil {
+		in, out := &in.RecheckInterval, &out.RecheckInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.OverrideGroups != nil {
+		in, out := &in.OverrideGroups, &out.OverrideGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityGate.
+func (in *SecurityGate) DeepCopy() *SecurityGate {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpreadConstraint) DeepCopyInto(out *SpreadConstraint) {
+	*out = *in
+	if in.MaxClustersPerDomain != nil {
+		in, out := &in.MaxClustersPerDomain, &out.MaxClustersPerDomain
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinDomains != nil {
+		in, out := &in.MinDomains, &out.MinDomains
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpreadConstraint.
+func (in *SpreadConstraint) DeepCopy() *SpreadConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(SpreadConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StalledWorks) DeepCopyInto(out *StalledWorks) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StalledWorks.
+func (in *StalledWorks) DeepCopy() *StalledWorks {
+	if in == nil {
+		return nil
+	}
+	out := new(StalledWorks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateParameter) DeepCopyInto(out *TemplateParameter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateParameter.
+func (in *TemplateParameter) DeepCopy() *TemplateParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateReference) DeepCopyInto(out *TemplateReference) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateReference.
+func (in *TemplateReference) DeepCopy() *TemplateReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantQuota) DeepCopyInto(out *TenantQuota) {
+	*out = *in
+	if in.MaxBundles != nil {
+		in, out := &in.MaxBundles, &out.MaxBundles
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxClusters != nil {
+		in, out := &in.MaxClusters, &out.MaxClusters
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxManifestBytes != nil {
+		in, out := &in.MaxManifestBytes, &out.MaxManifestBytes
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantQuota.
+func (in *TenantQuota) DeepCopy() *TenantQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantUsage) DeepCopyInto(out *TenantUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantUsage.
+func (in *TenantUsage) DeepCopy() *TenantUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultProvider) DeepCopyInto(out *VaultProvider) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultProvider.
+func (in *VaultProvider) DeepCopy() *VaultProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretReference) DeepCopyInto(out *VaultSecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretReference.
+func (in *VaultSecretReference) DeepCopy() *VaultSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionCompatibility) DeepCopyInto(out *VersionCompatibility) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionCompatibility.
+func (in *VersionCompatibility) DeepCopy() *VersionCompatibility {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionCompatibility)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionSkewPolicy) DeepCopyInto(out *VersionSkewPolicy) {
+	*out = *in
+	out.HubComponent = in.HubComponent
+	if in.Compatibility != nil {
+		in, out := &in.Compatibility, &out.Compatibility
+		*out = make([]VersionCompatibility, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionSkewPolicy.
+func (in *VersionSkewPolicy) DeepCopy() *VersionSkewPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionSkewPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WavePlan) DeepCopyInto(out *WavePlan) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gates != nil {
+		in, out := &in.Gates, &out.Gates
+		*out = make([]RolloutGate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Updated != nil {
+		in, out := &in.Updated, &out.Updated
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WavePlan.
+func (in *WavePlan) DeepCopy() *WavePlan {
+	if in == nil {
+		return nil
+	}
+	out := new(WavePlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTrigger) DeepCopyInto(out *WebhookTrigger) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTrigger.
+func (in *WebhookTrigger) DeepCopy() *WebhookTrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkHistory) DeepCopyInto(out *WorkHistory) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkHistory.
+func (in *WorkHistory) DeepCopy() *WorkHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadReference) DeepCopyInto(out *WorkloadReference) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadReference.
+func (in *WorkloadReference) DeepCopy() *WorkloadReference {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WriteBack) DeepCopyInto(out *WriteBack) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WriteBack.
+func (in *WriteBack) DeepCopy() *WriteBack {
+	if in == nil {
+		return nil
+	}
+	out := new(WriteBack)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WriteBackStatus) DeepCopyInto(out *WriteBackStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WriteBackStatus.
+func (in *WriteBackStatus) DeepCopy() *WriteBackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WriteBackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
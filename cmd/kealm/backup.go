@@ -0,0 +1,130 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/backup"
+)
+
+// newClient returns a client of the hub knowing the kealm and placement types
+func newClient(kubeconfig string) (client.WithWatch, error) {
+	cfg, err := loadConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme, appv1alpha1.AddToScheme, clusterapiv1.AddToScheme, clusterapiv1alpha1.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			return nil, err
+		}
+	}
+	return client.NewWithWatch(cfg, client.Options{Scheme: scheme})
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig of the hub, defaults to the standard loading rules.")
+	namespace := fs.String("namespace", "", "The namespace of the exported AppBundles, all namespaces when empty.")
+	output := fs.String("output", "", "The archive file, the standard output when empty.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	a, err := backup.Export(context.TODO(), c, *namespace)
+	if err != nil {
+		return err
+	}
+	data, err := a.Marshal()
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	// the archive may hold secrets
+	return ioutil.WriteFile(*output, data, 0600)
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig of the hub, defaults to the standard loading rules.")
+	input := fs.String("input", "", "The archive file written by kealm export.")
+	adopt := fs.Bool("adopt-works", true, "Relabel the ManifestWorks of the exported bundles as owned by the restored ones.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return fmt.Errorf("--input is required")
+	}
+
+	data, err := ioutil.ReadFile(*input)
+	if err != nil {
+		return err
+	}
+	a, err := backup.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	var works workclientset.Interface
+	if *adopt {
+		cfg, err := loadConfig(*kubeconfig)
+		if err != nil {
+			return err
+		}
+		if works, err = workclientset.NewForConfig(cfg); err != nil {
+			return err
+		}
+	}
+	report, err := backup.Import(context.TODO(), c, works, a)
+	if report != nil {
+		for _, o := range report.Created {
+			fmt.Printf("created %s\n", o)
+		}
+		for _, o := range report.Skipped {
+			fmt.Printf("skipped existing %s\n", o)
+		}
+		for _, w := range report.Adopted {
+			fmt.Printf("adopted manifestwork %s\n", w)
+		}
+	}
+	return err
+}
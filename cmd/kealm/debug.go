@@ -0,0 +1,82 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pdettori/kealm/pkg/diagnostics"
+)
+
+func runDebug(args []string) error {
+	if len(args) < 1 || args[0] != "dump" {
+		return fmt.Errorf("usage: kealm debug dump [--address URL] [--token TOKEN]")
+	}
+	fs := flag.NewFlagSet("debug dump", flag.ExitOnError)
+	address := fs.String("address", "http://127.0.0.1:8080",
+		"The address of the controller metrics endpoint, started with --enable-diagnostics.")
+	token := fs.String("token", "", "Bearer token used when the metrics endpoint is behind kube-rbac-proxy.")
+	timeout := fs.Duration("timeout", 10*time.Second, "The timeout of the request.")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	body, err := getDiagnostics(*address, diagnostics.StatePath, *token, *timeout)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, body, "", "  "); err != nil {
+		return err
+	}
+	_, err = out.WriteTo(os.Stdout)
+	return err
+}
+
+// getDiagnostics returns the body served on the path of the diagnostics endpoints of
+// the controller
+func getDiagnostics(address, path, token string, timeout time.Duration) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(address, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
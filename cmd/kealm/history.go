@@ -0,0 +1,91 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pdettori/kealm/pkg/history"
+)
+
+func runHistory(args []string) error {
+	if len(args) < 1 || args[0] != "diff" {
+		return fmt.Errorf("usage: kealm history diff --cluster CLUSTER [--namespace NAMESPACE] [--kubeconfig FILE] BUNDLE gen=N gen=M")
+	}
+	fs := flag.NewFlagSet("history diff", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig of the hub, defaults to the standard loading rules.")
+	namespace := fs.String("namespace", "default", "The namespace of the AppBundle.")
+	cluster := fs.String("cluster", "", "The managed cluster the works were applied to.")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 3 || *cluster == "" {
+		return fmt.Errorf("usage: kealm history diff --cluster CLUSTER [--namespace NAMESPACE] [--kubeconfig FILE] BUNDLE gen=N gen=M")
+	}
+	from, err := parseGeneration(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	to, err := parseGeneration(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: *namespace, Name: history.ConfigMapName(fs.Arg(0), *cluster)}
+	if err := c.Get(context.TODO(), key, cm); err != nil {
+		return fmt.Errorf("no work history of AppBundle %s on cluster %s, is workHistory set in the KealmConfig? %w", fs.Arg(0), *cluster, err)
+	}
+	old, err := history.Get(cm, from)
+	if err != nil {
+		return err
+	}
+	new, err := history.Get(cm, to)
+	if err != nil {
+		return err
+	}
+	diff, err := history.Diff(old, new)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		diff = "no change\n"
+	}
+	_, err = fmt.Fprintf(os.Stdout, "--- gen=%d\n+++ gen=%d\n%s", from, to, diff)
+	return err
+}
+
+// parseGeneration parses a gen=N argument, or a bare generation
+func parseGeneration(arg string) (int64, error) {
+	g, err := strconv.ParseInt(strings.TrimPrefix(arg, "gen="), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid generation %q, expected gen=N", arg)
+	}
+	return g, nil
+}
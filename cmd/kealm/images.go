@@ -0,0 +1,67 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pdettori/kealm/pkg/inventory"
+)
+
+func runImages(args []string) error {
+	fs := flag.NewFlagSet("images", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig of the hub, defaults to the standard loading rules.")
+	namespace := fs.String("namespace", "", "The namespace of the AppBundles, all namespaces when empty.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: kealm images IMAGE [--namespace NAMESPACE] [--kubeconfig FILE]")
+	}
+
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	var cms corev1.ConfigMapList
+	if err := c.List(context.TODO(), &cms, client.InNamespace(*namespace), client.HasLabels{inventory.Label}); err != nil {
+		return err
+	}
+	invs := []inventory.Inventory{}
+	for i := range cms.Items {
+		inv, err := inventory.FromConfigMap(&cms.Items[i])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: invalid inventory %s/%s: %v\n", cms.Items[i].Namespace, cms.Items[i].Name, err)
+			continue
+		}
+		invs = append(invs, *inv)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tBUNDLE\tGENERATION\tCLUSTERS")
+	for _, inv := range inventory.Running(invs, fs.Arg(0)) {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", inv.Namespace, inv.Bundle, inv.Generation, strings.Join(inv.Clusters, ","))
+	}
+	return w.Flush()
+}
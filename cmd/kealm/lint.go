@@ -0,0 +1,86 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/lint"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	file := fs.String("f", "", "The AppBundle file to lint.")
+	configFile := fs.String("config", "", "The KealmConfig file setting the severity of the rules, all rules warn when empty.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-f is required")
+	}
+
+	bundle := &appv1alpha1.AppBundle{}
+	if err := readYAML(*file, bundle); err != nil {
+		return err
+	}
+	var rules []appv1alpha1.LintRule
+	if *configFile != "" {
+		cfg := &appv1alpha1.KealmConfig{}
+		if err := readYAML(*configFile, cfg); err != nil {
+			return err
+		}
+		rules = cfg.Spec.LintRules
+	}
+	if len(bundle.Spec.WorkloadRefs) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: the manifests of the workload references are not linted\n")
+	}
+
+	ms, err := manifests.Normalize(bundle.Spec.Workload.Manifests)
+	if err != nil {
+		return err
+	}
+	findings, err := lint.Lint(ms, rules)
+	if err != nil {
+		return err
+	}
+	for _, f := range findings {
+		fmt.Printf("%s\t%s\n", f.Severity, f)
+	}
+	if denied, _ := lint.Split(findings); len(denied) > 0 {
+		return fmt.Errorf("%d denied findings", len(denied))
+	}
+	return nil
+}
+
+// readYAML reads the object of a YAML file
+func readYAML(file string, obj interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := yaml.UnmarshalStrict(data, obj); err != nil {
+		return fmt.Errorf("invalid %T in %s: %w", obj, file, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kealm is the command line client of the kealm controller
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{name: "debug", usage: "inspect the internal state of a running controller", run: runDebug},
+	{name: "migrate", usage: "generate AppBundles and Placements adopting existing ManifestWorks", run: runMigrate},
+	{name: "export", usage: "export the kealm state of a hub to an archive", run: runExport},
+	{name: "import", usage: "restore an archive, re-adopting the existing ManifestWorks", run: runImport},
+	{name: "images", usage: "list the bundles and clusters running an image", run: runImages},
+	{name: "pack", usage: "pin the images of an AppBundle to their digests for disconnected hubs", run: runPack},
+	{name: "lint", usage: "check an AppBundle for common problems", run: runLint},
+	{name: "render", usage: "print the ManifestWork an AppBundle is distributed with to a cluster", run: runRender},
+	{name: "history", usage: "show what changed on a cluster between two generations of an AppBundle", run: runHistory},
+	{name: "resync", usage: "apply a component of an AppBundle on a cluster again", run: runResync},
+	{name: "status", usage: "show the status of the clusters of an AppBundle and stream their transitions", run: runStatus},
+	{name: "simulate", usage: "report the works changed by hypothetical placement decisions or cluster labels", run: runSimulate},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: kealm <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.usage)
+	}
+}
+
+// loadConfig returns the config of the hub from the kubeconfig file, or from the
+// standard loading rules when empty
+func loadConfig(kubeconfig string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pdettori/kealm/pkg/migrate"
+)
+
+func runMigrate(args []string) error {
+	if len(args) < 1 || args[0] != "manifestworks" {
+		return fmt.Errorf("usage: kealm migrate manifestworks --namespace NAMESPACE [--selector SELECTOR] [--kubeconfig FILE]")
+	}
+	fs := flag.NewFlagSet("migrate manifestworks", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig of the hub, defaults to the standard loading rules.")
+	namespace := fs.String("namespace", "", "The namespace of the generated AppBundles and Placements.")
+	selector := fs.String("selector", "", "Label selector of the ManifestWorks to migrate.")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *namespace == "" {
+		return fmt.Errorf("--namespace is required")
+	}
+
+	cfg, err := loadConfig(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	client, err := workclientset.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	works, err := client.WorkV1().ManifestWorks("").List(context.TODO(), metav1.ListOptions{LabelSelector: *selector})
+	if err != nil {
+		return err
+	}
+
+	results, errs := migrate.FromManifestWorks(works.Items, *namespace)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	for _, r := range results {
+		fmt.Printf("# manifestwork %s in clusters %s\n", r.Bundle.Name, strings.Join(r.Clusters, ","))
+		fmt.Printf("# the placement selects the clusters by their %q label and requires a ManagedClusterSetBinding in %s\n",
+			migrate.ClusterNameLabel, *namespace)
+		for _, obj := range []interface{}{r.Placement, r.Bundle} {
+			out, err := yaml.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("---\n%s", out)
+		}
+	}
+	return nil
+}
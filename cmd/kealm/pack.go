@@ -0,0 +1,78 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/airgap"
+	"github.com/pdettori/kealm/pkg/registry"
+)
+
+func runPack(args []string) error {
+	fs := flag.NewFlagSet("pack", flag.ExitOnError)
+	file := fs.String("f", "", "The AppBundle file to pack.")
+	airGap := fs.Bool("airgap", false, "Reject the bundles pulling from remote sources, for disconnected hubs.")
+	reg := fs.String("registry", "", "The mirror registry, with an optional path prefix, the images are rewritten to.")
+	imagesFile := fs.String("images", "", "The file listing the source and packed reference of each image, to copy them to the mirror.")
+	output := fs.String("output", "", "The packed AppBundle file, the standard output when empty.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-f is required")
+	}
+
+	data, err := ioutil.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	bundle := &appv1alpha1.AppBundle{}
+	if err := yaml.UnmarshalStrict(data, bundle); err != nil {
+		return fmt.Errorf("invalid AppBundle in %s: %w", *file, err)
+	}
+	packed, images, err := airgap.Pack(context.TODO(), bundle, &registry.Client{}, airgap.Options{Registry: *reg, AirGap: *airGap})
+	if err != nil {
+		return err
+	}
+	if *imagesFile != "" {
+		var b bytes.Buffer
+		for _, image := range images {
+			fmt.Fprintf(&b, "%s %s\n", image.Source, image.Packed)
+		}
+		if err := ioutil.WriteFile(*imagesFile, b.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	out, err := yaml.Marshal(packed)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return ioutil.WriteFile(*output, out, 0644)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pdettori/kealm/pkg/diagnostics"
+)
+
+func runRender(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	address := fs.String("address", "http://127.0.0.1:8080",
+		"The address of the controller metrics endpoint, started with --enable-diagnostics.")
+	token := fs.String("token", "", "Bearer token used when the metrics endpoint is behind kube-rbac-proxy.")
+	timeout := fs.Duration("timeout", 30*time.Second, "The timeout of the request.")
+	namespace := fs.String("namespace", "default", "The namespace of the AppBundle.")
+	cluster := fs.String("cluster", "", "The managed cluster the work is rendered for.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *cluster == "" {
+		return fmt.Errorf("usage: kealm render --cluster CLUSTER [--namespace NAMESPACE] [--address URL] [--token TOKEN] BUNDLE")
+	}
+
+	query := url.Values{"namespace": {*namespace}, "bundle": {fs.Arg(0)}, "cluster": {*cluster}}
+	body, err := getDiagnostics(*address, diagnostics.RenderPath+"?"+query.Encode(), *token, *timeout)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(body)
+	return err
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/controllers"
+)
+
+func runResync(args []string) error {
+	fs := flag.NewFlagSet("resync", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig of the hub, defaults to the standard loading rules.")
+	namespace := fs.String("namespace", "default", "The namespace of the AppBundle.")
+	cluster := fs.String("cluster", "", "The managed cluster to apply the component on again.")
+	component := fs.String("component", "", "The component of the AppBundle to apply again.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *cluster == "" || *component == "" {
+		return fmt.Errorf("usage: kealm resync --cluster CLUSTER --component COMPONENT [--namespace NAMESPACE] [--kubeconfig FILE] BUNDLE")
+	}
+
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	ctx := context.TODO()
+	bundle := &appv1alpha1.AppBundle{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: *namespace, Name: fs.Arg(0)}, bundle); err != nil {
+		return err
+	}
+	found := false
+	for _, comp := range bundle.Spec.Components {
+		found = found || comp.Name == *component
+	}
+	if !found {
+		return fmt.Errorf("AppBundle %s has no component %s", fs.Arg(0), *component)
+	}
+	controllers.SetComponentResync(bundle, *cluster, *component, time.Now().UTC().Format(time.RFC3339))
+	if err := c.Update(ctx, bundle); err != nil {
+		return err
+	}
+	fmt.Printf("Requested the resync of component %s of AppBundle %s on cluster %s\n", *component, fs.Arg(0), *cluster)
+	return nil
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/controllers"
+	"github.com/pdettori/kealm/pkg/scheduler"
+	"github.com/pdettori/kealm/pkg/simulate"
+)
+
+// stringsFlag is a repeatable string flag
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig of the hub, defaults to the standard loading rules.")
+	namespace := fs.String("namespace", "", "The namespace of the AppBundles, all namespaces when empty.")
+	var decisions, labels stringsFlag
+	fs.Var(&decisions, "decision", "A hypothetical decision NAMESPACE/PLACEMENT=CLUSTER,... replacing the one of the placement, repeatable.")
+	fs.Var(&labels, "label", "A hypothetical label change CLUSTER:KEY=VALUE, or CLUSTER:KEY- to remove it, repeatable.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(decisions) == 0 && len(labels) == 0 {
+		return fmt.Errorf("usage: kealm simulate [--decision NAMESPACE/PLACEMENT=CLUSTER,...] [--label CLUSTER:KEY=VALUE|KEY-] [--namespace NAMESPACE] [--kubeconfig FILE]")
+	}
+	overrides, err := parseDecisions(decisions)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.TODO()
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	var mcs clusterapiv1.ManagedClusterList
+	if err := c.List(ctx, &mcs); err != nil {
+		return err
+	}
+	clusters := map[string]*clusterapiv1.ManagedCluster{}
+	for i := range mcs.Items {
+		clusters[mcs.Items[i].Name] = &mcs.Items[i]
+	}
+	changed, err := relabel(clusters, labels)
+	if err != nil {
+		return err
+	}
+	var bundles appv1alpha1.AppBundleList
+	if err := c.List(ctx, &bundles, client.InNamespace(*namespace)); err != nil {
+		return err
+	}
+
+	s := &simulation{client: c, clusters: clusters, changed: changed, decisions: overrides}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tBUNDLE\tWORK\tCREATED\tDELETED")
+	for i := range bundles.Items {
+		b := &bundles.Items[i]
+		placement, ok := controllers.PlacementName(b)
+		if !ok {
+			continue
+		}
+		next, err := s.clustersOf(ctx, b, placement)
+		if err != nil {
+			return err
+		}
+		current := []string{}
+		for _, cs := range b.Status.Clusters {
+			current = append(current, cs.ClusterName)
+		}
+		created, deleted := simulate.Diff(current, next)
+		if len(created) == 0 && len(deleted) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", b.Namespace, b.Name, controllers.WorkName(b),
+			strings.Join(created, ","), strings.Join(deleted, ","))
+	}
+	return w.Flush()
+}
+
+// simulation holds the hypothetical state of the hub: the relabeled clusters and
+// the decisions, evaluated once per placement
+type simulation struct {
+	client    client.Client
+	clusters  map[string]*clusterapiv1.ManagedCluster
+	changed   []*clusterapiv1.ManagedCluster
+	decisions map[string][]string
+}
+
+// clustersOf returns the clusters the bundle would be distributed to, applying its
+// cluster selection and spread constraints to the hypothetical decision of its placement,
+// or of its fallback placement when the decision has no registered cluster
+func (s *simulation) clustersOf(ctx context.Context, bundle *appv1alpha1.AppBundle, placement string) ([]string, error) {
+	decided, err := s.decision(ctx, bundle.Namespace, placement)
+	if err != nil {
+		return nil, err
+	}
+	managed := s.registered(decided)
+	if fallback := bundle.Spec.FallbackPlacement; len(managed) == 0 && fallback != "" {
+		if decided, err = s.decision(ctx, bundle.Namespace, fallback); err != nil {
+			return nil, err
+		}
+		managed = s.registered(decided)
+	}
+	sel, spread := bundle.Spec.ClusterSelection, bundle.Spec.Spread
+	if sel == nil && len(spread) == 0 {
+		return scheduler.Names(managed), nil
+	}
+	limit := 0
+	if sel != nil {
+		limit = int(sel.Clusters)
+	}
+	selected, _ := scheduler.Spread(scheduler.Rank(managed, sel), spread, limit)
+	return scheduler.Names(selected), nil
+}
+
+// registered returns the clusters of the names registered with the hub and not being
+// detached
+func (s *simulation) registered(names []string) []*clusterapiv1.ManagedCluster {
+	managed := []*clusterapiv1.ManagedCluster{}
+	for _, name := range names {
+		if c, ok := s.clusters[name]; ok && c.DeletionTimestamp.IsZero() && c.Spec.HubAcceptsClient {
+			managed = append(managed, c)
+		}
+	}
+	return managed
+}
+
+// decision returns the clusters decided for the placement: the hypothetical decision
+// given for it, else its current decision re-evaluated against the relabeled clusters
+func (s *simulation) decision(ctx context.Context, namespace, placement string) ([]string, error) {
+	key := namespace + "/" + placement
+	if d, ok := s.decisions[key]; ok {
+		return d, nil
+	}
+	var pds clusterapiv1alpha1.PlacementDecisionList
+	if err := s.client.List(ctx, &pds, client.InNamespace(namespace),
+		client.MatchingLabels{controllers.PlacementLabel: placement}); err != nil {
+		return nil, err
+	}
+	decided := []string{}
+	if len(pds.Items) > 0 {
+		for _, d := range pds.Items[0].Status.Decisions {
+			decided = append(decided, d.ClusterName)
+		}
+	}
+	if len(s.changed) > 0 {
+		p := &clusterapiv1alpha1.Placement{}
+		if err := s.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: placement}, p); err != nil {
+			return nil, fmt.Errorf("failed to get placement %s: %w", key, err)
+		}
+		var err error
+		if decided, err = simulate.Decide(p, decided, s.changed); err != nil {
+			return nil, err
+		}
+	}
+	s.decisions[key] = decided
+	return decided, nil
+}
+
+// parseDecisions parses the NAMESPACE/PLACEMENT=CLUSTER,... decision flags
+func parseDecisions(values []string) (map[string][]string, error) {
+	decisions := map[string][]string{}
+	for _, v := range values {
+		key, list := v, ""
+		if i := strings.Index(v, "="); i >= 0 {
+			key, list = v[:i], v[i+1:]
+		}
+		if parts := strings.Split(key, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" || !strings.Contains(v, "=") {
+			return nil, fmt.Errorf("invalid decision %q, expected NAMESPACE/PLACEMENT=CLUSTER,...", v)
+		}
+		clusters := []string{}
+		for _, c := range strings.Split(list, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				clusters = append(clusters, c)
+			}
+		}
+		decisions[key] = clusters
+	}
+	return decisions, nil
+}
+
+// relabel applies the CLUSTER:KEY=VALUE and CLUSTER:KEY- label flags to the clusters,
+// returning the changed ones
+func relabel(clusters map[string]*clusterapiv1.ManagedCluster, values []string) ([]*clusterapiv1.ManagedCluster, error) {
+	sets, removed := map[string]map[string]string{}, map[string][]string{}
+	order := []string{}
+	for _, v := range values {
+		i := strings.Index(v, ":")
+		if i <= 0 || i == len(v)-1 {
+			return nil, fmt.Errorf("invalid label %q, expected CLUSTER:KEY=VALUE or CLUSTER:KEY-", v)
+		}
+		name, label := v[:i], v[i+1:]
+		if _, ok := clusters[name]; !ok {
+			return nil, fmt.Errorf("cluster %s is not registered", name)
+		}
+		if _, ok := sets[name]; !ok {
+			sets[name] = map[string]string{}
+			order = append(order, name)
+		}
+		switch {
+		case strings.HasSuffix(label, "-"):
+			removed[name] = append(removed[name], strings.TrimSuffix(label, "-"))
+		case strings.Contains(label, "="):
+			kv := strings.SplitN(label, "=", 2)
+			sets[name][kv[0]] = kv[1]
+		default:
+			return nil, fmt.Errorf("invalid label %q, expected CLUSTER:KEY=VALUE or CLUSTER:KEY-", v)
+		}
+	}
+	changed := []*clusterapiv1.ManagedCluster{}
+	for _, name := range order {
+		c := simulate.Relabel(clusters[name], sets[name], removed[name])
+		clusters[name] = c
+		changed = append(changed, c)
+	}
+	return changed, nil
+}
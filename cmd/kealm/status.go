@@ -0,0 +1,112 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/tracker"
+)
+
+func runStatus(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig of the hub, defaults to the standard loading rules.")
+	namespace := fs.String("namespace", "default", "The namespace of the AppBundle.")
+	watch := fs.Bool("watch", false, "Stream the transitions of the clusters after the current status, until interrupted.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: kealm status [--watch] [--namespace NAMESPACE] [--kubeconfig FILE] BUNDLE")
+	}
+
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	bundle := &appv1alpha1.AppBundle{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: *namespace, Name: fs.Arg(0)}, bundle); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tAPPLIED\tAVAILABLE\tDEGRADED\tREASON")
+	for _, s := range bundle.Status.Clusters {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.ClusterName, conditionStatus(s, workapiv1.WorkApplied),
+			conditionStatus(s, workapiv1.WorkAvailable), conditionStatus(s, workapiv1.WorkDegraded), failureReason(s))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !*watch {
+		return nil
+	}
+
+	transitions, errs, err := tracker.Watch(ctx, c, bundle)
+	if err != nil {
+		return err
+	}
+	for t := range transitions {
+		if t.Removed {
+			fmt.Printf("%s  %s  removed\n", t.Time.Format(time.RFC3339), t.Cluster)
+			continue
+		}
+		previous := t.Previous
+		if previous == "" {
+			previous = "-"
+		}
+		fmt.Printf("%s  %s  %s %s -> %s  %s  %s\n", t.Time.Format(time.RFC3339), t.Cluster, t.Type, previous, t.Status, t.Reason, t.Message)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return <-errs
+}
+
+// conditionStatus returns the status of a condition of the work of a cluster, - when
+// not reported
+func conditionStatus(s appv1alpha1.ClusterStatus, conditionType string) string {
+	if c := meta.FindStatusCondition(s.Conditions, conditionType); c != nil {
+		return string(c.Status)
+	}
+	return "-"
+}
+
+// failureReason returns the reason of the first condition reporting a failure of the
+// work of a cluster
+func failureReason(s appv1alpha1.ClusterStatus) string {
+	for _, c := range s.Conditions {
+		if (tracker.Transition{Type: c.Type, Status: c.Status}).EventType() == corev1.EventTypeWarning {
+			return c.Reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/analysis"
+)
+
+// defaultAnalysisInterval is the interval between the evaluations of the analysis
+// metrics of the bundles not setting one
+const defaultAnalysisInterval = time.Minute
+
+// runAnalysis evaluates the analysis metrics of the bundle on its clusters and sets the
+// AnalysisPassed condition. It returns when to evaluate them again, 0 if the bundle
+// has no analysis.
+func (r *AppBundleReconciler) runAnalysis(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string, cfg *appv1alpha1.KealmConfigSpec) time.Duration {
+	a := bundle.Spec.Analysis
+	if a == nil {
+		removeCondition(bundle, appv1alpha1.ConditionAnalysisPassed)
+		return 0
+	}
+	if len(clusters) == 0 {
+		return 0
+	}
+	interval := defaultAnalysisInterval
+	if a.Interval != nil && a.Interval.Duration > 0 {
+		interval = a.Interval.Duration
+	}
+	if cfg.MetricsEndpoint == "" {
+		setCondition(bundle, appv1alpha1.ConditionAnalysisPassed, v1.ConditionUnknown, appv1alpha1.ReasonAnalysisError,
+			"No metrics endpoint configured in KealmConfig")
+		return interval
+	}
+
+	client := &analysis.Client{URL: cfg.MetricsEndpoint}
+	vars := analysis.Vars{Namespace: bundle.Namespace, Bundle: bundle.Name, Generation: bundle.Generation}
+	failed, errored := client.Evaluate(ctx, a, vars, clusters)
+	switch {
+	case len(failed) > 0:
+		message := "Metrics out of bounds: " + joinResults(failed)
+		r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonAnalysisFailed, message)
+		setCondition(bundle, appv1alpha1.ConditionAnalysisPassed, v1.ConditionFalse, appv1alpha1.ReasonAnalysisFailed, message)
+	case len(errored) > 0:
+		setCondition(bundle, appv1alpha1.ConditionAnalysisPassed, v1.ConditionUnknown, appv1alpha1.ReasonAnalysisError,
+			"Metrics not evaluated: "+joinResults(errored))
+	default:
+		setCondition(bundle, appv1alpha1.ConditionAnalysisPassed, v1.ConditionTrue, appv1alpha1.ReasonAnalysisPassed,
+			"All metrics within bounds")
+	}
+	return interval
+}
+
+func joinResults(results []analysis.Result) string {
+	messages := []string{}
+	for _, r := range results {
+		messages = append(messages, r.String())
+	}
+	return strings.Join(messages, "; ")
+}
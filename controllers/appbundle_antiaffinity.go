@@ -0,0 +1,164 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// antiAffineBundles returns the bundles the bundle must not share clusters with: the
+// ones of its anti-affinity, and the ones whose anti-affinity references it, or nil
+// when there is none
+func (r *AppBundleReconciler) antiAffineBundles(ctx context.Context, bundle *appv1alpha1.AppBundle) ([]appv1alpha1.AppBundle, error) {
+	var all appv1alpha1.AppBundleList
+	if err := r.List(ctx, &all); err != nil {
+		return nil, err
+	}
+	var result []appv1alpha1.AppBundle
+	for i := range all.Items {
+		other := &all.Items[i]
+		if other.UID == bundle.UID {
+			continue
+		}
+		if antiAffine(bundle, other) || antiAffine(other, bundle) {
+			result = append(result, *other)
+		}
+	}
+	return result, nil
+}
+
+// antiAffine returns true if the anti-affinity of the bundle references the object
+func antiAffine(bundle *appv1alpha1.AppBundle, obj client.Object) bool {
+	for _, a := range bundle.Spec.AntiAffinity {
+		namespace := a.Namespace
+		if namespace == "" {
+			namespace = bundle.Namespace
+		}
+		if namespace != obj.GetNamespace() {
+			continue
+		}
+		if a.Name == obj.GetName() {
+			return true
+		}
+		if a.Selector != nil {
+			if selector, err := v1.LabelSelectorAsSelector(a.Selector); err == nil && selector.Matches(labels.Set(obj.GetLabels())) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// antiAffinityConflicts returns, by cluster, the anti-affine bundles the clusters
+// decided for the bundle are taken by: the ones distributed to the cluster, unless
+// the bundle is distributed to it too and is older
+func antiAffinityConflicts(bundle *appv1alpha1.AppBundle, clusters []string, others []appv1alpha1.AppBundle) map[string][]string {
+	conflicts := map[string][]string{}
+	for _, c := range clusters {
+		for i := range others {
+			other := &others[i]
+			if !hasCluster(other, c) || (hasCluster(bundle, c) && createdBefore(bundle, other)) {
+				continue
+			}
+			conflicts[c] = append(conflicts[c], other.Namespace+"/"+other.Name)
+		}
+	}
+	return conflicts
+}
+
+// hasCluster returns true if the bundle is distributed to the cluster
+func hasCluster(bundle *appv1alpha1.AppBundle, cluster string) bool {
+	for _, c := range bundle.Status.Clusters {
+		if c.ClusterName == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAntiAffinity returns the decided clusters not taken by the bundles the bundle
+// is anti-affine with, and reports the conflicts in the AntiAffinitySatisfied condition
+func (r *AppBundleReconciler) applyAntiAffinity(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string) ([]string, error) {
+	others, err := r.antiAffineBundles(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	if len(bundle.Spec.AntiAffinity) == 0 && len(others) == 0 {
+		removeCondition(bundle, appv1alpha1.ConditionAntiAffinitySatisfied)
+		return clusters, nil
+	}
+	conflicts := antiAffinityConflicts(bundle, clusters, others)
+	if len(conflicts) == 0 {
+		setCondition(bundle, appv1alpha1.ConditionAntiAffinitySatisfied, v1.ConditionTrue,
+			appv1alpha1.ReasonAntiAffinitySatisfied, "No decided cluster is taken by an anti-affine bundle")
+		return clusters, nil
+	}
+	taken := []string{}
+	for c := range conflicts {
+		taken = append(taken, c)
+	}
+	sort.Strings(taken)
+	messages := []string{}
+	for _, c := range taken {
+		messages = append(messages, fmt.Sprintf("cluster %s is taken by %s", c, strings.Join(conflicts[c], ",")))
+	}
+	message := strings.Join(messages, "; ")
+	if c := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionAntiAffinitySatisfied); c == nil || c.Message != message {
+		r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonAntiAffinityConflict, message)
+	}
+	setCondition(bundle, appv1alpha1.ConditionAntiAffinitySatisfied, v1.ConditionFalse,
+		appv1alpha1.ReasonAntiAffinityConflict, message)
+	return withoutClusters(clusters, taken), nil
+}
+
+// bundlesForAntiAffinity maps a bundle to the bundles it is anti-affine with, in both
+// directions, so that they are reconciled again once the clusters it takes change
+func (r *AppBundleReconciler) bundlesForAntiAffinity(obj client.Object) []reconcile.Request {
+	changed, ok := obj.(*appv1alpha1.AppBundle)
+	if !ok {
+		return nil
+	}
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(context.TODO(), &bundles); err != nil {
+		klog.Errorf("Failed to list AppBundles for anti-affine bundle %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for i := range bundles.Items {
+		bundle := &bundles.Items[i]
+		if bundle.UID != changed.UID && (antiAffine(bundle, changed) || antiAffine(changed, bundle)) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+			})
+		}
+	}
+	return requests
+}
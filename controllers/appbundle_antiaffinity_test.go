@@ -0,0 +1,154 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestApplyAntiAffinity(t *testing.T) {
+	older := v1.NewTime(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	newer := v1.NewTime(older.Add(time.Hour))
+	// stack returns a bundle created at the time and distributed to the clusters
+	stack := func(name string, created v1.Time, clusters ...string) *appv1alpha1.AppBundle {
+		b := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "shop", UID: types.UID("uid-" + name),
+			CreationTimestamp: created, Labels: map[string]string{"stack": name}}}
+		for _, c := range clusters {
+			b.Status.Clusters = append(b.Status.Clusters, appv1alpha1.ClusterStatus{ClusterName: c})
+		}
+		return b
+	}
+	toGreen := []appv1alpha1.BundleAntiAffinity{{Name: "green"}}
+	toBlue := []appv1alpha1.BundleAntiAffinity{{Selector: &v1.LabelSelector{MatchLabels: map[string]string{"stack": "blue"}}}}
+
+	tests := []struct {
+		name       string
+		blue       *appv1alpha1.AppBundle
+		green      *appv1alpha1.AppBundle
+		blueRules  []appv1alpha1.BundleAntiAffinity
+		greenRules []appv1alpha1.BundleAntiAffinity
+		decided    []string
+		want       []string
+		status     v1.ConditionStatus
+		message    string
+	}{
+		{
+			name:    "no anti-affinity",
+			blue:    stack("blue", newer),
+			green:   stack("green", older, "cluster1"),
+			decided: []string{"cluster1", "cluster2"},
+			want:    []string{"cluster1", "cluster2"},
+		},
+		{
+			name:      "satisfied",
+			blue:      stack("blue", newer),
+			green:     stack("green", older, "cluster3"),
+			blueRules: toGreen,
+			decided:   []string{"cluster1", "cluster2"},
+			want:      []string{"cluster1", "cluster2"},
+			status:    v1.ConditionTrue,
+			message:   "No decided cluster is taken by an anti-affine bundle",
+		},
+		{
+			name:      "cluster taken",
+			blue:      stack("blue", newer),
+			green:     stack("green", older, "cluster1"),
+			blueRules: toGreen,
+			decided:   []string{"cluster1", "cluster2"},
+			want:      []string{"cluster2"},
+			status:    v1.ConditionFalse,
+			message:   "cluster cluster1 is taken by shop/green",
+		},
+		{
+			name:       "anti-affinity of the other bundle",
+			blue:       stack("blue", newer),
+			green:      stack("green", older, "cluster1"),
+			greenRules: toBlue,
+			decided:    []string{"cluster1", "cluster2"},
+			want:       []string{"cluster2"},
+			status:     v1.ConditionFalse,
+			message:    "cluster cluster1 is taken by shop/green",
+		},
+		{
+			name:      "both on the cluster, the bundle older",
+			blue:      stack("blue", older, "cluster1"),
+			green:     stack("green", newer, "cluster1"),
+			blueRules: toGreen,
+			decided:   []string{"cluster1"},
+			want:      []string{"cluster1"},
+			status:    v1.ConditionTrue,
+			message:   "No decided cluster is taken by an anti-affine bundle",
+		},
+		{
+			name:      "both on the cluster, the other older",
+			blue:      stack("blue", newer, "cluster1"),
+			green:     stack("green", older, "cluster1"),
+			blueRules: toGreen,
+			decided:   []string{"cluster1"},
+			want:      []string{},
+			status:    v1.ConditionFalse,
+			message:   "cluster cluster1 is taken by shop/green",
+		},
+		{
+			name:      "all clusters taken",
+			blue:      stack("blue", newer),
+			green:     stack("green", older, "cluster1", "cluster2"),
+			blueRules: toGreen,
+			decided:   []string{"cluster2", "cluster1"},
+			want:      []string{},
+			status:    v1.ConditionFalse,
+			message:   "cluster cluster1 is taken by shop/green; cluster cluster2 is taken by shop/green",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.blue.Spec.AntiAffinity, tt.green.Spec.AntiAffinity = tt.blueRules, tt.greenRules
+			f := newFixture(t, tt.blue.DeepCopy(), tt.green)
+			r := f.reconciler()
+
+			clusters, err := r.applyAntiAffinity(context.TODO(), tt.blue, tt.decided)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(clusters, tt.want) {
+				t.Errorf("expected the clusters %v, got %v", tt.want, clusters)
+			}
+			cond := meta.FindStatusCondition(tt.blue.Status.Conditions, appv1alpha1.ConditionAntiAffinitySatisfied)
+			if tt.status == "" {
+				if cond != nil {
+					t.Errorf("expected no AntiAffinitySatisfied condition, got %+v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.status || cond.Message != tt.message {
+				t.Errorf("expected the AntiAffinitySatisfied condition %s %q, got %+v", tt.status, tt.message, cond)
+			}
+			if events := len(f.recorder.Events); (tt.status == v1.ConditionFalse) != (events == 1) {
+				t.Errorf("expected the conflicts to be reported, got %d events", events)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// supportedClusters returns the decided clusters whose architecture the bundle
+// supports, and reports the skipped ones in the ArchitectureSupported condition
+func (r *AppBundleReconciler) supportedClusters(bundle *appv1alpha1.AppBundle, clusters []string) ([]string, error) {
+	a := bundle.Spec.Architectures
+	if a == nil || len(a.Supported) == 0 {
+		removeCondition(bundle, appv1alpha1.ConditionArchitectureSupported)
+		return clusters, nil
+	}
+	unsupported := map[string]string{}
+	for _, c := range clusters {
+		cluster, err := r.ManagedClusterLister.Get(c)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if arch := manifests.NewClusterContext(cluster).Architecture; !manifests.SupportsArchitecture(a, arch) {
+			unsupported[c] = arch
+		}
+	}
+	if len(unsupported) == 0 {
+		setCondition(bundle, appv1alpha1.ConditionArchitectureSupported, v1.ConditionTrue,
+			appv1alpha1.ReasonArchitectureSupported, "The architectures of all the decided clusters are supported")
+		return clusters, nil
+	}
+	skipped := []string{}
+	for c := range unsupported {
+		skipped = append(skipped, c)
+	}
+	sort.Strings(skipped)
+	messages := []string{}
+	for _, c := range skipped {
+		messages = append(messages, fmt.Sprintf("%s (%s)", c, unsupported[c]))
+	}
+	message := "Clusters of unsupported architectures skipped: " + strings.Join(messages, ", ")
+	if c := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionArchitectureSupported); c == nil || c.Message != message {
+		r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonUnsupportedArchitecture, message)
+	}
+	setCondition(bundle, appv1alpha1.ConditionArchitectureSupported, v1.ConditionFalse,
+		appv1alpha1.ReasonUnsupportedArchitecture, message)
+	return withoutClusters(clusters, skipped), nil
+}
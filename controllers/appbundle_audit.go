@@ -0,0 +1,66 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/audit"
+	"github.com/pdettori/kealm/pkg/manifests"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// recordAudit records the actions performed on the clusters of a bundle, if any
+func (r *AppBundleReconciler) recordAudit(ctx context.Context, bundle *appv1alpha1.AppBundle, digest string, diff appv1alpha1.ManifestDiff, actions []appv1alpha1.ClusterAction) error {
+	if r.AuditSink == nil || len(actions) == 0 {
+		return nil
+	}
+	klog.Infof("Recording audit for AppBundle %s: %d clusters touched", bundle.Name, len(actions))
+	return r.AuditSink.Record(ctx, audit.NewRecord(bundle, digest, diff, actions))
+}
+
+// diffAccumulator merges the manifest diffs computed for several clusters
+type diffAccumulator struct {
+	added, removed, changed sets.String
+}
+
+func newDiffAccumulator() *diffAccumulator {
+	return &diffAccumulator{added: sets.NewString(), removed: sets.NewString(), changed: sets.NewString()}
+}
+
+func (d *diffAccumulator) add(old, new []workapiv1.Manifest) error {
+	added, removed, changed, err := manifests.Diff(old, new)
+	if err != nil {
+		return err
+	}
+	d.added.Insert(added...)
+	d.removed.Insert(removed...)
+	d.changed.Insert(changed...)
+	return nil
+}
+
+func (d *diffAccumulator) result() appv1alpha1.ManifestDiff {
+	return appv1alpha1.ManifestDiff{
+		Added:   d.added.List(),
+		Removed: d.removed.List(),
+		Changed: d.changed.List(),
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// unavailableSince returns when the cluster became not available, nil while it is
+// available
+func unavailableSince(cluster *clusterapiv1.ManagedCluster) *v1.Time {
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, clusterapiv1.ManagedClusterConditionAvailable)
+	switch {
+	case cond == nil:
+		// never reported available since it joined
+		since := cluster.CreationTimestamp
+		return &since
+	case cond.Status == v1.ConditionTrue:
+		return nil
+	default:
+		since := cond.LastTransitionTime
+		return &since
+	}
+}
+
+// checkAvailability records when the clusters of the bundle became not available and
+// sets the Degraded condition once they are not available beyond the toleration of
+// the availability policy. It returns the clusters beyond the toleration, and the delay
+// until the next cluster exceeds it, to check the clusters again then.
+func (r *AppBundleReconciler) checkAvailability(bundle *appv1alpha1.AppBundle) (sets.String, time.Duration) {
+	toleration := time.Duration(0)
+	if p := bundle.Spec.AvailabilityPolicy; p != nil && p.Toleration != nil {
+		toleration = p.Toleration.Duration
+	}
+	unreachable := sets.NewString()
+	tolerated := []string{}
+	requeue := time.Duration(0)
+	for i := range bundle.Status.Clusters {
+		status := &bundle.Status.Clusters[i]
+		status.UnavailableSince = nil
+		cluster, err := r.ManagedClusterLister.Get(status.ClusterName)
+		if err != nil {
+			// the clusters being detached leave the decision
+			continue
+		}
+		since := unavailableSince(cluster)
+		if since == nil {
+			continue
+		}
+		status.UnavailableSince = since
+		remaining := time.Until(since.Add(toleration))
+		if remaining <= 0 {
+			unreachable.Insert(status.ClusterName)
+			continue
+		}
+		tolerated = append(tolerated, status.ClusterName)
+		if requeue == 0 || remaining < requeue {
+			requeue = remaining + time.Second
+		}
+	}
+	sort.Strings(tolerated)
+
+	if unreachable.Len() == 0 {
+		message := "All clusters available"
+		if len(tolerated) > 0 {
+			message = fmt.Sprintf("Clusters %s not available within the toleration of %s", strings.Join(tolerated, ","), toleration)
+		}
+		setCondition(bundle, appv1alpha1.ConditionDegraded, v1.ConditionFalse, appv1alpha1.ReasonClustersAvailable, message)
+		return unreachable, requeue
+	}
+	message := fmt.Sprintf("Clusters %s not available beyond the toleration of %s", strings.Join(unreachable.List(), ","), toleration)
+	if !meta.IsStatusConditionTrue(bundle.Status.Conditions, appv1alpha1.ConditionDegraded) {
+		r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonClustersUnreachable, message)
+	}
+	setCondition(bundle, appv1alpha1.ConditionDegraded, v1.ConditionTrue, appv1alpha1.ReasonClustersUnreachable, message)
+	return unreachable, requeue
+}
+
+// proceedPast returns the clusters the rollout of the bundle does not wait for
+func proceedPast(bundle *appv1alpha1.AppBundle, unreachable sets.String) sets.String {
+	if p := bundle.Spec.AvailabilityPolicy; p != nil && p.ProceedPastUnreachable {
+		return unreachable
+	}
+	return sets.NewString()
+}
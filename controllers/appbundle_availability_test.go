@@ -0,0 +1,164 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/rollout"
+)
+
+// clusterAvailable returns a managed cluster whose Available condition changed since the
+// given delay, without condition when status is empty
+func clusterAvailable(name string, status v1.ConditionStatus, since time.Duration) *clusterv1.ManagedCluster {
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: v1.ObjectMeta{Name: name,
+		CreationTimestamp: v1.NewTime(time.Now().Add(-since))}}
+	if status != "" {
+		cluster.Status.Conditions = []v1.Condition{{Type: clusterv1.ManagedClusterConditionAvailable, Status: status,
+			LastTransitionTime: v1.NewTime(time.Now().Add(-since))}}
+	}
+	return cluster
+}
+
+func TestCheckAvailability(t *testing.T) {
+	f := newFixture(t)
+	f.add(f.clusters,
+		clusterAvailable("available", v1.ConditionTrue, time.Hour),
+		clusterAvailable("lost", v1.ConditionUnknown, time.Hour),
+		clusterAvailable("flapping", v1.ConditionFalse, time.Minute),
+		clusterAvailable("joining", "", 2*time.Hour),
+	)
+	r := f.reconciler()
+	tests := []struct {
+		name        string
+		toleration  time.Duration
+		clusters    []string
+		unreachable []string
+		degraded    v1.ConditionStatus
+		requeue     bool
+	}{
+		{"all available", 0, []string{"available"}, []string{}, v1.ConditionFalse, false},
+		{"no toleration", 0, []string{"available", "lost", "flapping"}, []string{"flapping", "lost"}, v1.ConditionTrue, false},
+		{"tolerated", 10 * time.Minute, []string{"available", "flapping"}, []string{}, v1.ConditionFalse, true},
+		{"beyond the toleration", 10 * time.Minute, []string{"lost", "flapping", "joining"}, []string{"joining", "lost"}, v1.ConditionTrue, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := &appv1alpha1.AppBundle{}
+			bundle.Spec.AvailabilityPolicy = &appv1alpha1.AvailabilityPolicy{Toleration: &v1.Duration{Duration: tt.toleration}}
+			for _, c := range tt.clusters {
+				bundle.Status.Clusters = append(bundle.Status.Clusters, appv1alpha1.ClusterStatus{ClusterName: c})
+			}
+			unreachable, requeue := r.checkAvailability(bundle)
+			if !reflect.DeepEqual(unreachable.List(), tt.unreachable) {
+				t.Errorf("expected %v unreachable, got %v", tt.unreachable, unreachable.List())
+			}
+			if (requeue > 0) != tt.requeue || requeue > tt.toleration {
+				t.Errorf("expected a requeue %v within %s, got %s", tt.requeue, tt.toleration, requeue)
+			}
+			if cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionDegraded); cond == nil || cond.Status != tt.degraded {
+				t.Errorf("expected the Degraded condition %s, got %+v", tt.degraded, cond)
+			}
+			for _, c := range bundle.Status.Clusters {
+				if (c.UnavailableSince == nil) != (c.ClusterName == "available") {
+					t.Errorf("unexpected unavailable since of %s: %v", c.ClusterName, c.UnavailableSince)
+				}
+			}
+		})
+	}
+}
+
+func TestProceedPast(t *testing.T) {
+	unreachable := sets.NewString("cluster2", "cluster3")
+	tests := []struct {
+		name     string
+		policy   *appv1alpha1.AvailabilityPolicy
+		expected []string
+	}{
+		{"no policy", nil, []string{}},
+		{"toleration only", &appv1alpha1.AvailabilityPolicy{Toleration: &v1.Duration{Duration: time.Minute}}, []string{}},
+		{"proceed past unreachable", &appv1alpha1.AvailabilityPolicy{ProceedPastUnreachable: true}, []string{"cluster2", "cluster3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := &appv1alpha1.AppBundle{}
+			bundle.Spec.AvailabilityPolicy = tt.policy
+			if skipped := proceedPast(bundle, unreachable); !reflect.DeepEqual(skipped.List(), tt.expected) {
+				t.Errorf("expected %v skipped, got %v", tt.expected, skipped.List())
+			}
+		})
+	}
+}
+
+func TestProceedPastWaves(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      *appv1alpha1.AvailabilityPolicy
+		unreachable string
+		completed   bool
+	}{
+		{"no policy", nil, "canary1", false},
+		{"within the toleration", &appv1alpha1.AvailabilityPolicy{Toleration: &v1.Duration{Duration: 2 * time.Hour},
+			ProceedPastUnreachable: true}, "canary1", false},
+		{"proceed past the first wave", &appv1alpha1.AvailabilityPolicy{ProceedPastUnreachable: true}, "canary1", true},
+		{"proceed past the last wave", &appv1alpha1.AvailabilityPolicy{ProceedPastUnreachable: true}, "prod1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			for _, name := range []string{"canary1", "canary2", "prod1"} {
+				cluster := clusterAvailable(name, v1.ConditionTrue, time.Hour)
+				if name == tt.unreachable {
+					cluster = clusterAvailable(name, v1.ConditionUnknown, time.Hour)
+				}
+				cluster.Labels = map[string]string{"tier": strings.TrimRight(name, "12")}
+				f.add(f.clusters, cluster)
+			}
+			r := f.reconciler()
+			bundle := wavedBundle()
+			bundle.Spec.AvailabilityPolicy = tt.policy
+			if _, _, err := r.planRollout(bundle, "digest", []string{"canary1", "canary2", "prod1"}); err != nil {
+				t.Fatal(err)
+			}
+			scheduled := &scheduleResult{conditions: map[string][]v1.Condition{}}
+			// the works of the reachable clusters are Available, the unreachable one is
+			// never applied
+			for _, name := range []string{"canary1", "canary2", "prod1"} {
+				status := availableStatus(name, "digest")
+				if name == tt.unreachable {
+					status.Conditions = nil
+				}
+				bundle.Status.Clusters = append(bundle.Status.Clusters, status)
+				scheduled.conditions[name] = status.Conditions
+			}
+			unreachable, _ := r.checkAvailability(bundle)
+			r.advanceRollout(bundle, scheduled, proceedPast(bundle, unreachable))
+			if plan := bundle.Status.Plan; rollout.Completed(plan) != tt.completed {
+				t.Errorf("expected the rollout completed %v, got wave %d of %d", tt.completed, plan.CurrentWave, len(plan.Waves))
+			}
+		})
+	}
+}
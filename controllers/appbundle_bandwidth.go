@@ -0,0 +1,55 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// DefaultUnpackImage is the image of the Jobs unpacking the compressed ConfigMaps
+const DefaultUnpackImage = "bitnami/kubectl:1.22"
+
+// bandwidthManifests packs the large ConfigMaps of the bundles with the bandwidth option
+func bandwidthManifests(bundle *appv1alpha1.AppBundle, ms []workapiv1.Manifest) ([]workapiv1.Manifest, error) {
+	b := bundle.Spec.Bandwidth
+	if b == nil {
+		return ms, nil
+	}
+	if b.CompressConfigMapsOver != nil {
+		image := b.UnpackImage
+		if image == "" {
+			image = DefaultUnpackImage
+		}
+		var err error
+		if ms, err = manifests.Pack(ms, b.CompressConfigMapsOver.Value(), image); err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+// unchangedWork returns true when writing the updated work would not change the
+// existing one, its content being identified by the digest annotations
+func unchangedWork(existing, updated *workapiv1.ManifestWork) bool {
+	return equality.Semantic.DeepEqual(existing.Labels, updated.Labels) &&
+		equality.Semantic.DeepEqual(existing.Annotations, updated.Annotations) &&
+		equality.Semantic.DeepEqual(existing.Spec.DeleteOption, updated.Spec.DeleteOption)
+}
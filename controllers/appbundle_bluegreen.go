@@ -0,0 +1,275 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// blueGreenRetry is the delay before checking again the works of a blue/green
+	// bundle being previewed or switched
+	blueGreenRetry = 15 * time.Second
+
+	// defaultStepDuration is how long the mesh steps of a blue/green bundle last when
+	// not set
+	defaultStepDuration = time.Minute
+)
+
+// blueGreenPlan describes the works of a blue/green bundle for a reconcile: the colors
+// kept from the current works, the color the rendered manifests are deployed to, if
+// any, the color the switch routes to and the percentage of the traffic it shifts to
+// the other color
+type blueGreenPlan struct {
+	keep   []string
+	deploy string
+	route  string
+	weight int32
+}
+
+func otherColor(color string) string {
+	if color == appv1alpha1.ColorBlue {
+		return appv1alpha1.ColorGreen
+	}
+	return appv1alpha1.ColorBlue
+}
+
+// planBlueGreen advances the blue/green state of the bundle for the digest of its
+// content, checking the works of the clusters, and returns the plan of the works and
+// when to check them again. A new digest is deployed to the preview color, the traffic
+// is switched to it once it is Available on all the clusters for the preview duration
+// and the mesh steps shifted part of the traffic to it, and the previous color is
+// removed once the switch is applied on all of them.
+func (r *AppBundleReconciler) planBlueGreen(ctx context.Context, bundle *appv1alpha1.AppBundle, digest string, clusters []string) (*blueGreenPlan, time.Duration, error) {
+	bg := bundle.Spec.BlueGreen
+	if bg == nil {
+		bundle.Status.BlueGreen = nil
+		return nil, 0, nil
+	}
+	st := bundle.Status.BlueGreen
+	if st == nil || st.ActiveColor == "" {
+		bundle.Status.BlueGreen = &appv1alpha1.BlueGreenStatus{ActiveColor: appv1alpha1.ColorBlue, ActiveDigest: digest}
+		return &blueGreenPlan{deploy: appv1alpha1.ColorBlue, route: appv1alpha1.ColorBlue}, 0, nil
+	}
+
+	if st.RetiringColor != "" {
+		switched, err := r.worksSettled(ctx, bundle, clusters, func(w *workapiv1.ManifestWork) (bool, error) {
+			route, err := manifests.SwitchOf(w.Spec.Workload.Manifests)
+			return route == st.ActiveColor && !isWorkChanging(w), err
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		if !switched {
+			plan := &blueGreenPlan{keep: []string{st.RetiringColor}, deploy: st.ActiveColor, route: st.ActiveColor}
+			if digest != st.ActiveDigest {
+				// the new content waits for the previous color to be removed
+				plan.keep, plan.deploy = append(plan.keep, st.ActiveColor), ""
+			}
+			return plan, blueGreenRetry, nil
+		}
+		st.RetiringColor = ""
+	}
+
+	switch {
+	case digest == st.ActiveDigest:
+		// unchanged, or reverted during the preview
+		st.PreviewColor, st.PreviewDigest, st.PreviewAvailableSince = "", "", nil
+		st.PreviewWeight, st.WeightShiftedAt = 0, nil
+		return &blueGreenPlan{deploy: st.ActiveColor, route: st.ActiveColor}, 0, nil
+	case st.PreviewColor == "" || digest != st.PreviewDigest:
+		st.PreviewColor, st.PreviewDigest, st.PreviewAvailableSince = otherColor(st.ActiveColor), digest, nil
+		st.PreviewWeight, st.WeightShiftedAt = 0, nil
+		r.Recorder.Event(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonBlueGreenPreview,
+			fmt.Sprintf("Deploying generation %d to the %s color next to the %s one", bundle.Generation, st.PreviewColor, st.ActiveColor))
+		return &blueGreenPlan{keep: []string{st.ActiveColor}, deploy: st.PreviewColor, route: st.ActiveColor}, blueGreenRetry, nil
+	}
+
+	preview := &blueGreenPlan{keep: []string{st.ActiveColor}, deploy: st.PreviewColor, route: st.ActiveColor, weight: st.PreviewWeight}
+	changing := false
+	available, err := r.worksSettled(ctx, bundle, clusters, func(w *workapiv1.ManifestWork) (bool, error) {
+		if w.Annotations[DigestAnnotation] != digest || isWorkChanging(w) {
+			changing = true
+			return false, nil
+		}
+		return colorAvailable(w, st.PreviewColor)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if !available {
+		// works being updated with a new weight keep it
+		if st.PreviewWeight > 0 && !changing {
+			r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonTrafficShifted,
+				fmt.Sprintf("Shifting the traffic back from the %s color, not Available on all the clusters", st.PreviewColor))
+			st.PreviewWeight, st.WeightShiftedAt = 0, nil
+			preview.weight = 0
+		}
+		if st.PreviewWeight == 0 {
+			st.PreviewAvailableSince = nil
+		}
+		return preview, blueGreenRetry, nil
+	}
+	if st.PreviewAvailableSince == nil {
+		now := v1.Now()
+		st.PreviewAvailableSince = &now
+	}
+	if bg.PreviewDuration != nil && st.PreviewWeight == 0 {
+		if remaining := time.Until(st.PreviewAvailableSince.Add(bg.PreviewDuration.Duration)); remaining > 0 {
+			return preview, remaining, nil
+		}
+	}
+	if m := bg.Mesh; m != nil {
+		stepDuration := defaultStepDuration
+		if m.StepDuration != nil && m.StepDuration.Duration > 0 {
+			stepDuration = m.StepDuration.Duration
+		}
+		if st.WeightShiftedAt != nil {
+			if remaining := time.Until(st.WeightShiftedAt.Add(stepDuration)); remaining > 0 {
+				return preview, remaining, nil
+			}
+		}
+		for _, w := range m.Steps {
+			if w > st.PreviewWeight {
+				now := v1.Now()
+				st.PreviewWeight, st.WeightShiftedAt = w, &now
+				r.Recorder.Event(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonTrafficShifted,
+					fmt.Sprintf("Shifting %d%% of the traffic to the %s color", w, st.PreviewColor))
+				preview.weight = w
+				return preview, stepDuration, nil
+			}
+		}
+	}
+	r.Recorder.Event(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonBlueGreenSwitched,
+		fmt.Sprintf("Switching the traffic from the %s color to the %s one", st.ActiveColor, st.PreviewColor))
+	bundle.Status.BlueGreen = &appv1alpha1.BlueGreenStatus{
+		ActiveColor:   st.PreviewColor,
+		ActiveDigest:  digest,
+		RetiringColor: st.ActiveColor,
+	}
+	return &blueGreenPlan{keep: []string{st.ActiveColor}, deploy: st.PreviewColor, route: st.PreviewColor}, blueGreenRetry, nil
+}
+
+// currentBlueGreenPlan returns the plan of the works of a blue/green bundle in the
+// current phase of its status, without advancing it
+func currentBlueGreenPlan(st *appv1alpha1.BlueGreenStatus) *blueGreenPlan {
+	switch {
+	case st == nil || st.ActiveColor == "":
+		return &blueGreenPlan{deploy: appv1alpha1.ColorBlue, route: appv1alpha1.ColorBlue}
+	case st.RetiringColor != "":
+		return &blueGreenPlan{keep: []string{st.RetiringColor}, deploy: st.ActiveColor, route: st.ActiveColor}
+	case st.PreviewColor != "":
+		return &blueGreenPlan{keep: []string{st.ActiveColor}, deploy: st.PreviewColor, route: st.ActiveColor, weight: st.PreviewWeight}
+	}
+	return &blueGreenPlan{deploy: st.ActiveColor, route: st.ActiveColor}
+}
+
+// worksSettled returns true if the works of the bundle on all the clusters exist and
+// satisfy the check
+func (r *AppBundleReconciler) worksSettled(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string, check func(*workapiv1.ManifestWork) (bool, error)) (bool, error) {
+	for _, c := range clusters {
+		w, err := r.WorkClient.WorkV1().ManifestWorks(c).Get(ctx, WorkName(bundle), v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if ok, err := check(w); err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// colorAvailable returns true if the work agent reports the manifests of the color in
+// the work Available
+func colorAvailable(work *workapiv1.ManifestWork, color string) (bool, error) {
+	return manifestsAvailable(work, manifests.ColorOf, color)
+}
+
+// manifestsAvailable returns true if the work agent reports Available the manifests of
+// the work for which of returns the value
+func manifestsAvailable(work *workapiv1.ManifestWork, of func(workapiv1.Manifest) (string, error), value string) (bool, error) {
+	available := map[int32]bool{}
+	for _, m := range work.Status.ResourceStatus.Manifests {
+		available[m.ResourceMeta.Ordinal] = meta.IsStatusConditionTrue(m.Conditions, string(workapiv1.ManifestAvailable))
+	}
+	for i, m := range work.Spec.Workload.Manifests {
+		v, err := of(m)
+		if err != nil {
+			return false, err
+		}
+		if v == value && !available[int32(i)] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// blueGreenManifests returns the manifests of the work of a blue/green bundle on the
+// cluster: the kept colors of its current work, the manifests deployed to the color of
+// the plan and the switch. A cluster without work gets the manifests in the color the
+// switch routes to, with all the traffic.
+func (r *AppBundleReconciler) blueGreenManifests(ctx context.Context, bundle *appv1alpha1.AppBundle, plan *blueGreenPlan, clusterName string, ms []workapiv1.Manifest) ([]workapiv1.Manifest, error) {
+	existing, err := r.WorkClient.WorkV1().ManifestWorks(clusterName).Get(ctx, WorkName(bundle), v1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	result := []workapiv1.Manifest{}
+	deploy := plan.deploy
+	c := manifests.SwitchContext{Color: plan.route, Inactive: otherColor(plan.route), Weight: plan.weight}
+	if apierrors.IsNotFound(err) {
+		deploy, c.Weight = plan.route, 0
+	} else {
+		for _, color := range plan.keep {
+			kept, err := manifests.Colored(existing.Spec.Workload.Manifests, color)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, kept...)
+		}
+	}
+	if deploy != "" {
+		colored, err := manifests.Colorize(ms, deploy)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, colored...)
+	}
+	var s workapiv1.Manifest
+	if mesh := bundle.Spec.BlueGreen.Mesh; mesh != nil {
+		s, err = manifests.MeshSwitch(mesh, c)
+	} else {
+		s, err = manifests.RenderSwitch(bundle.Spec.BlueGreen.Switch, c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(result, s), nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+const (
+	// changeBudgetRetry is the delay before retrying the clusters deferred because of
+	// their change budget
+	changeBudgetRetry = 15 * time.Second
+
+	// changeBudgetTimeout is how long a work written counts against the change budget
+	// of its cluster at most, so that the works the agent never applies do not hold
+	// the budget forever
+	changeBudgetTimeout = 10 * time.Minute
+)
+
+// changeBudgetExhausted returns true if the number of bundles changing the cluster,
+// other than with the given work, reached the budget configured in KealmConfig. The
+// works are read from the WorkInformer, and the bundles reconciled concurrently are not
+// serialized: the budget is best effort, and may be exceeded by the works written since
+// the informer last synced.
+func (r *AppBundleReconciler) changeBudgetExhausted(ctx context.Context, cfg *appv1alpha1.KealmConfigSpec, cluster, workName string) (bool, error) {
+	if cfg.MaxConcurrentChangesPerCluster == nil {
+		return false, nil
+	}
+	works, err := r.clusterWorks(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+	changing := int32(0)
+	now := time.Now()
+	for i := range works.Items {
+		if works.Items[i].Name != workName && budgetedChange(&works.Items[i], now) {
+			changing++
+		}
+	}
+	if changing >= *cfg.MaxConcurrentChangesPerCluster {
+		klog.Infof("Change budget of cluster %s exhausted, %d bundles changing", cluster, changing)
+		return true, nil
+	}
+	return false, nil
+}
+
+// isWorkChanging returns true until the agent reports the current generation of the
+// work as applied
+func isWorkChanging(work *workapiv1.ManifestWork) bool {
+	applied := meta.FindStatusCondition(work.Status.Conditions, workapiv1.WorkApplied)
+	if applied == nil || applied.Status != v1.ConditionTrue {
+		return true
+	}
+	return applied.ObservedGeneration != 0 && applied.ObservedGeneration < work.Generation
+}
+
+// budgetedChange returns true when the work counts against the change budget of its
+// cluster: it is changing, the agent did not fail to apply its current generation, and
+// it was written within the changeBudgetTimeout
+func budgetedChange(work *workapiv1.ManifestWork, now time.Time) bool {
+	if !isWorkChanging(work) {
+		return false
+	}
+	if applied := meta.FindStatusCondition(work.Status.Conditions, workapiv1.WorkApplied); applied != nil &&
+		applied.Status == v1.ConditionFalse && applied.ObservedGeneration >= work.Generation {
+		return false
+	}
+	written := work.CreationTimestamp.Time
+	if t, err := time.Parse(time.RFC3339, work.Annotations[WrittenAtAnnotation]); err == nil {
+		written = t
+	}
+	return now.Sub(written) < changeBudgetTimeout
+}
+
+// reportDeferred sets the WithinChangeBudget condition of the bundle
+func (r *AppBundleReconciler) reportDeferred(bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, deferred []string) {
+	if cfg.MaxConcurrentChangesPerCluster == nil && len(deferred) == 0 {
+		removeCondition(bundle, appv1alpha1.ConditionWithinChangeBudget)
+		return
+	}
+	if len(deferred) == 0 {
+		setCondition(bundle, appv1alpha1.ConditionWithinChangeBudget, v1.ConditionTrue, appv1alpha1.ReasonWithinChangeBudget,
+			"No cluster change deferred")
+		return
+	}
+	sorted := append([]string{}, deferred...)
+	sort.Strings(sorted)
+	message := fmt.Sprintf("Changes deferred on clusters %s", strings.Join(sorted, ","))
+	if c := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionWithinChangeBudget); c == nil || c.Message != message {
+		r.Recorder.Event(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonChangeBudgetExhausted, message)
+	}
+	setCondition(bundle, appv1alpha1.ConditionWithinChangeBudget, v1.ConditionFalse, appv1alpha1.ReasonChangeBudgetExhausted, message)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// changingWork returns a work of the cluster at generation 2, written since the given
+// delay, whose agent reports the Applied condition of the observed generation
+func changingWork(cluster, name string, applied v1.ConditionStatus, observed int64, since time.Duration) *workapiv1.ManifestWork {
+	w := &workapiv1.ManifestWork{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: cluster, Generation: 2,
+		Labels:      map[string]string{OwnedLabel: name},
+		Annotations: map[string]string{WrittenAtAnnotation: time.Now().Add(-since).UTC().Format(time.RFC3339)}}}
+	if applied != "" {
+		w.Status.Conditions = []v1.Condition{{Type: workapiv1.WorkApplied, Status: applied, ObservedGeneration: observed}}
+	}
+	return w
+}
+
+func TestBudgetedChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		work     *workapiv1.ManifestWork
+		budgeted bool
+	}{
+		{"applied", changingWork("cluster1", "web", v1.ConditionTrue, 2, time.Minute), false},
+		{"not applied yet", changingWork("cluster1", "web", "", 0, time.Minute), true},
+		{"previous generation applied", changingWork("cluster1", "web", v1.ConditionTrue, 1, time.Minute), true},
+		{"failed to apply", changingWork("cluster1", "web", v1.ConditionFalse, 2, time.Minute), false},
+		{"failed to apply the previous generation", changingWork("cluster1", "web", v1.ConditionFalse, 1, time.Minute), true},
+		{"beyond the timeout", changingWork("cluster1", "web", "", 0, time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if budgeted := budgetedChange(tt.work, time.Now()); budgeted != tt.budgeted {
+				t.Errorf("expected budgeted %v, got %v", tt.budgeted, budgeted)
+			}
+		})
+	}
+}
+
+func TestChangeBudgetExhausted(t *testing.T) {
+	f := newFixture(t)
+	for _, w := range []*workapiv1.ManifestWork{
+		changingWork("cluster1", "web", "", 0, time.Minute),
+		changingWork("cluster1", "db", "", 0, time.Minute),
+		changingWork("cluster1", "cache", v1.ConditionTrue, 2, time.Minute),
+		changingWork("cluster2", "queue", "", 0, time.Minute),
+	} {
+		if err := f.works.Tracker().Add(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := f.reconciler()
+	check := func(source string) {
+		for _, tt := range []struct {
+			budget    int32
+			work      string
+			exhausted bool
+		}{
+			{1, "web", true},
+			{2, "web", false},
+			{2, "api", true},
+		} {
+			cfg := &appv1alpha1.KealmConfigSpec{MaxConcurrentChangesPerCluster: &tt.budget}
+			exhausted, err := r.changeBudgetExhausted(context.TODO(), cfg, "cluster1", tt.work)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exhausted != tt.exhausted {
+				t.Errorf("%s: expected the budget of %d exhausted %v for %s, got %v", source, tt.budget, tt.exhausted, tt.work, exhausted)
+			}
+		}
+	}
+	check("listed")
+
+	// once synced, the works are read from the informer
+	factory := workinformers.NewSharedInformerFactoryWithOptions(f.works, 10*time.Minute,
+		workinformers.WithTweakListOptions(OwnedWorksListOptions))
+	r.WorkInformer = factory.Work().V1().ManifestWorks().Informer()
+	stop := make(chan struct{})
+	factory.Start(stop)
+	if !cache.WaitForCacheSync(stop, r.WorkInformer.HasSynced) {
+		t.Fatal("the informer did not sync")
+	}
+	close(stop)
+	f.works.ClearActions()
+	check("indexed")
+	if actions := f.works.Actions(); len(actions) != 0 {
+		t.Errorf("expected no call to the work API, got %v", actions)
+	}
+}
+
+func TestReportDeferred(t *testing.T) {
+	f := newFixture(t)
+	r := f.reconciler()
+	budget := int32(1)
+	cfg := &appv1alpha1.KealmConfigSpec{MaxConcurrentChangesPerCluster: &budget}
+	bundle := &appv1alpha1.AppBundle{}
+
+	// the retries of the same clusters record a single event
+	r.reportDeferred(bundle, cfg, []string{"cluster2", "cluster1"})
+	r.reportDeferred(bundle, cfg, []string{"cluster1", "cluster2"})
+	if len(f.recorder.Events) != 1 {
+		t.Errorf("expected a single event, got %d", len(f.recorder.Events))
+	}
+	r.reportDeferred(bundle, cfg, []string{"cluster1"})
+	if len(f.recorder.Events) != 2 {
+		t.Errorf("expected an event for the new clusters, got %d", len(f.recorder.Events))
+	}
+	r.reportDeferred(bundle, cfg, nil)
+	if !meta.IsStatusConditionTrue(bundle.Status.Conditions, appv1alpha1.ConditionWithinChangeBudget) {
+		t.Errorf("expected the bundle within its change budget, got %+v", bundle.Status.Conditions)
+	}
+}
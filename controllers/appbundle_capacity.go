@@ -0,0 +1,331 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// PreemptedClustersAnnotation lists the clusters a bundle is preempted from by bundles
+// of a higher priority, its works being removed from them until they fit it again
+const PreemptedClustersAnnotation = "cluster.open-cluster-management.io/preempted-clusters"
+
+// PreemptedAtAnnotation records when a bundle was last preempted. The clusters it is
+// preempted from are not released before gatingRetry, leaving time to the preempting
+// bundles to claim their resources.
+const PreemptedAtAnnotation = "cluster.open-cluster-management.io/preempted-at"
+
+// gatingRetry is the delay before checking again the clusters not fitting a bundle
+const gatingRetry = time.Minute
+
+// gatingResult is the outcome of the resource-aware gating of a bundle
+type gatingResult struct {
+	// requests are the resources requested on each cluster
+	requests map[string]corev1.ResourceList
+	// insufficient lists the resources each cluster not fitting the bundle lacks
+	insufficient map[string][]string
+	// preempted lists the clusters the bundle is preempted from
+	preempted []string
+}
+
+// claim is the resources requested by a bundle distributed to a cluster
+type claim struct {
+	bundle   *appv1alpha1.AppBundle
+	requests corev1.ResourceList
+}
+
+// gateResources checks that the clusters fit the resource requests of the bundle,
+// given the requests of the bundles already distributed to them. With preemption,
+// the bundles of a lower priority are preempted from the clusters not fitting the
+// bundle when that makes it fit. The clusters the bundle is preempted from are
+// released once they fit it again. It returns nil when the gating is disabled.
+func (r *AppBundleReconciler) gateResources(ctx context.Context, bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, clusters []string, ms []workapiv1.Manifest) (*gatingResult, error) {
+	if cfg.ResourceGating == nil {
+		return nil, nil
+	}
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(ctx, &bundles); err != nil {
+		return nil, err
+	}
+	claims := clusterClaims(bundles.Items, bundle)
+	preempted := sets.NewString(listAnnotation(bundle, PreemptedClustersAnnotation)...)
+	settled := true
+	if at, err := time.Parse(time.RFC3339, bundle.Annotations[PreemptedAtAnnotation]); err == nil {
+		settled = time.Since(at) >= gatingRetry
+	}
+	result := &gatingResult{
+		requests:     map[string]corev1.ResourceList{},
+		insufficient: map[string][]string{},
+	}
+	for _, c := range clusters {
+		cluster, err := r.ManagedClusterLister.Get(c)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		requests, err := clusterRequests(bundle, cluster.Labels, ms)
+		if err != nil {
+			return nil, err
+		}
+		allocatable := map[string]resource.Quantity{}
+		for name, q := range cluster.Status.Allocatable {
+			allocatable[string(name)] = q
+		}
+		result.requests[c] = requests
+		insufficient := manifests.Fits(requests, allocatable, claimed(claims[c])...)
+		if preempted.Has(c) {
+			if len(insufficient) > 0 || !settled {
+				result.preempted = append(result.preempted, c)
+			}
+			continue
+		}
+		if len(insufficient) == 0 {
+			continue
+		}
+		if cfg.ResourceGating.Preemption {
+			if victims := preemptionVictims(bundle, requests, allocatable, claims[c]); len(victims) > 0 {
+				if err := r.preempt(ctx, bundle, c, victims); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		result.insufficient[c] = insufficient
+	}
+	// release the clusters fitting the bundle again, or no longer targeted
+	if still := sets.NewString(result.preempted...); !still.Equal(preempted) {
+		if err := r.setPreempted(ctx, bundle, still.List()); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// clusterRequests returns the resources requested by the manifests of the bundle on a
+// cluster, once scaled for the cluster
+func clusterRequests(bundle *appv1alpha1.AppBundle, clusterLabels map[string]string, ms []workapiv1.Manifest) (corev1.ResourceList, error) {
+	rule, err := manifests.ScalingRule(bundle.Spec.Scaling, clusterLabels)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil {
+		autoscaled, err := manifests.Autoscaled(ms, bundle.Spec.AutoscaledWorkloads)
+		if err != nil {
+			return nil, err
+		}
+		if ms, err = manifests.Scale(ms, rule, autoscaled); err != nil {
+			return nil, err
+		}
+	}
+	return manifests.Requests(ms)
+}
+
+// clusterClaims returns the claims of the other bundles on each cluster, leaving out
+// the clusters they are preempted from
+func clusterClaims(bundles []appv1alpha1.AppBundle, bundle *appv1alpha1.AppBundle) map[string][]claim {
+	claims := map[string][]claim{}
+	for i := range bundles {
+		b := &bundles[i]
+		if b.UID == bundle.UID {
+			continue
+		}
+		preempted := sets.NewString(listAnnotation(b, PreemptedClustersAnnotation)...)
+		for _, c := range b.Status.Clusters {
+			if len(c.Requests) > 0 && !preempted.Has(c.ClusterName) {
+				claims[c.ClusterName] = append(claims[c.ClusterName], claim{bundle: b, requests: c.Requests})
+			}
+		}
+	}
+	return claims
+}
+
+func claimed(claims []claim) []corev1.ResourceList {
+	lists := []corev1.ResourceList{}
+	for _, c := range claims {
+		lists = append(lists, c.requests)
+	}
+	return lists
+}
+
+// preemptionVictims returns the claims of the bundles of a lower priority to remove
+// from a cluster for the requests to fit, the lowest priorities and the most recent
+// bundles first. It returns nil when the requests do not fit without all of them.
+func preemptionVictims(bundle *appv1alpha1.AppBundle, requests corev1.ResourceList, allocatable map[string]resource.Quantity, claims []claim) []claim {
+	kept, candidates := []claim{}, []claim{}
+	for _, c := range claims {
+		if c.bundle.Spec.Priority < bundle.Spec.Priority {
+			candidates = append(candidates, c)
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].bundle, candidates[j].bundle
+		if a.Spec.Priority != b.Spec.Priority {
+			return a.Spec.Priority < b.Spec.Priority
+		}
+		return createdBefore(b, a)
+	})
+	for i := range candidates {
+		remaining := append(claimed(kept), claimed(candidates[i+1:])...)
+		if len(manifests.Fits(requests, allocatable, remaining...)) == 0 {
+			return candidates[:i+1]
+		}
+	}
+	return nil
+}
+
+// preempt adds the cluster to the preempted clusters of the victims, and records the
+// decision on the bundles
+func (r *AppBundleReconciler) preempt(ctx context.Context, bundle *appv1alpha1.AppBundle, cluster string, victims []claim) error {
+	for _, v := range victims {
+		victim := v.bundle
+		clusters := sets.NewString(listAnnotation(victim, PreemptedClustersAnnotation)...).Insert(cluster)
+		patch := client.MergeFromWithOptions(victim.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		setPreemptedAnnotations(victim, clusters.List())
+		if err := r.Patch(ctx, victim, patch); err != nil {
+			return fmt.Errorf("failed to preempt AppBundle %s/%s from cluster %s: %w", victim.Namespace, victim.Name, cluster, err)
+		}
+		klog.Infof("AppBundle %s/%s of priority %d preempted from cluster %s by AppBundle %s/%s of priority %d",
+			victim.Namespace, victim.Name, victim.Spec.Priority, cluster, bundle.Namespace, bundle.Name, bundle.Spec.Priority)
+		r.Recorder.Eventf(victim, corev1.EventTypeWarning, appv1alpha1.ReasonPreempted,
+			"Preempted from cluster %s by AppBundle %s/%s of priority %d, requesting %s",
+			cluster, bundle.Namespace, bundle.Name, bundle.Spec.Priority, formatResources(v.requests))
+		r.Recorder.Eventf(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonPreempting,
+			"Preempted AppBundle %s/%s of priority %d from cluster %s to fit it",
+			victim.Namespace, victim.Name, victim.Spec.Priority, cluster)
+	}
+	return nil
+}
+
+// reportGating sets the ResourcesFit condition of the bundle, removed when the gating
+// is disabled
+func reportGating(bundle *appv1alpha1.AppBundle, g *gatingResult) {
+	if g == nil {
+		removeCondition(bundle, appv1alpha1.ConditionResourcesFit)
+		return
+	}
+	messages := []string{}
+	for _, c := range sortedKeys(g.insufficient) {
+		messages = append(messages, fmt.Sprintf("cluster %s lacks %s", c, strings.Join(g.insufficient[c], ", ")))
+	}
+	if len(g.preempted) > 0 {
+		messages = append(messages, "preempted from clusters "+strings.Join(g.preempted, ", "))
+	}
+	if len(messages) == 0 {
+		setCondition(bundle, appv1alpha1.ConditionResourcesFit, v1.ConditionTrue,
+			appv1alpha1.ReasonResourcesFit, "The clusters fit the resource requests")
+		return
+	}
+	setCondition(bundle, appv1alpha1.ConditionResourcesFit, v1.ConditionFalse,
+		appv1alpha1.ReasonResourcesInsufficient, strings.Join(messages, "; "))
+}
+
+// formatResources formats resources as name=quantity, sorted by name
+func formatResources(l corev1.ResourceList) string {
+	parts := []string{}
+	for name, q := range l {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, q.String()))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// withoutClusters returns the clusters not removed, in their order
+func withoutClusters(clusters, removed []string) []string {
+	if len(removed) == 0 {
+		return clusters
+	}
+	excluded := sets.NewString(removed...)
+	result := []string{}
+	for _, c := range clusters {
+		if !excluded.Has(c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// listAnnotation returns the comma-separated values of an annotation of the bundle
+func listAnnotation(bundle *appv1alpha1.AppBundle, key string) []string {
+	values := []string{}
+	for _, v := range strings.Split(bundle.Annotations[key], ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// setPreemptedAnnotations sets the clusters a bundle is preempted from, and the time when
+// clusters are added. The annotations are removed when it is not preempted from any
+// cluster.
+func setPreemptedAnnotations(bundle *appv1alpha1.AppBundle, clusters []string) {
+	if len(clusters) == 0 {
+		delete(bundle.Annotations, PreemptedClustersAnnotation)
+		delete(bundle.Annotations, PreemptedAtAnnotation)
+		return
+	}
+	if bundle.Annotations == nil {
+		bundle.Annotations = map[string]string{}
+	}
+	if !sets.NewString(listAnnotation(bundle, PreemptedClustersAnnotation)...).HasAll(clusters...) {
+		bundle.Annotations[PreemptedAtAnnotation] = v1.Now().UTC().Format(time.RFC3339)
+	}
+	bundle.Annotations[PreemptedClustersAnnotation] = strings.Join(clusters, ",")
+}
+
+// setPreempted patches the clusters the bundle being reconciled is preempted from,
+// keeping the changes made to its status
+func (r *AppBundleReconciler) setPreempted(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string) error {
+	updated := bundle.DeepCopy()
+	patch := client.MergeFrom(bundle)
+	setPreemptedAnnotations(updated, clusters)
+	if err := r.Patch(ctx, updated, patch); err != nil {
+		return err
+	}
+	bundle.Annotations = updated.Annotations
+	bundle.ResourceVersion = updated.ResourceVersion
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// reportCertificates records, for each certificate of the bundle, the number of
+// clusters where the work agent reports its Certificate Available and the clusters
+// where it is pending
+func (r *AppBundleReconciler) reportCertificates(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string) error {
+	if len(bundle.Spec.Certificates) == 0 {
+		bundle.Status.Certificates = nil
+		return nil
+	}
+	statuses := []appv1alpha1.CertificateStatus{}
+	for _, cert := range bundle.Spec.Certificates {
+		statuses = append(statuses, appv1alpha1.CertificateStatus{Name: cert.Name})
+	}
+	for _, clusterName := range clusters {
+		work, err := r.WorkClient.WorkV1().ManifestWorks(clusterName).Get(ctx, WorkName(bundle), v1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		for i := range statuses {
+			available := false
+			if err == nil {
+				if available, err = certificateAvailable(work, statuses[i].Name); err != nil {
+					return err
+				}
+			}
+			if available {
+				statuses[i].AvailableClusters++
+			} else {
+				statuses[i].Pending = append(statuses[i].Pending, clusterName)
+			}
+		}
+	}
+	bundle.Status.Certificates = statuses
+	return nil
+}
+
+// certificateAvailable returns true if the work has the Certificate of the certificate
+// and the work agent reports it Available. Works rendered before the certificate was
+// added have none.
+func certificateAvailable(work *workapiv1.ManifestWork, name string) (bool, error) {
+	found := false
+	for _, m := range work.Spec.Workload.Manifests {
+		cert, err := manifests.CertificateOf(m)
+		if err != nil {
+			return false, err
+		}
+		found = found || cert == name
+	}
+	if !found {
+		return false, nil
+	}
+	return manifestsAvailable(work, manifests.CertificateOf, name)
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/claims"
+	"github.com/pdettori/kealm/pkg/faults"
+	"github.com/pdettori/kealm/pkg/manifests"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// claimsRetry is the delay before reading again the claims whose outputs are not
+	// available
+	claimsRetry = 15 * time.Second
+	// claimsResync is the delay before reading again the outputs of the claims, to
+	// distribute their changes
+	claimsResync = 5 * time.Minute
+
+	// claimFieldOwner is the field manager of the claims applied on the hub
+	claimFieldOwner = "kealm"
+)
+
+// isCrossplane returns true if the component is applied on the hub
+func isCrossplane(c appv1alpha1.Component) bool {
+	return c.Type == appv1alpha1.ComponentCrossplane
+}
+
+// hasClaims returns true if the bundle has Crossplane components
+func hasClaims(bundle *appv1alpha1.AppBundle) bool {
+	for _, c := range bundle.Spec.Components {
+		if isCrossplane(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchClaims applies the claims of the Crossplane components of the bundle in its
+// namespace, deletes the ones no longer rendered and returns the outputs of the
+// components, with true while some are not available
+func (r *AppBundleReconciler) dispatchClaims(ctx context.Context, bundle *appv1alpha1.AppBundle) (map[string]map[string]string, bool, error) {
+	writer, err := r.claimWriter(ctx, bundle.Namespace)
+	if err != nil {
+		return nil, false, r.claimFailed(bundle, err)
+	}
+	applied := map[string]*unstructured.Unstructured{}
+	statuses := []appv1alpha1.ClaimStatus{}
+	for _, c := range bundle.Spec.Components {
+		if !isCrossplane(c) {
+			continue
+		}
+		ms, err := r.renderClaims(ctx, bundle, c)
+		if err != nil {
+			return nil, false, r.claimFailed(bundle, err)
+		}
+		for _, m := range ms {
+			u, err := manifests.ToUnstructured(m)
+			if err != nil {
+				return nil, false, r.claimFailed(bundle, err)
+			}
+			if err := r.applyClaim(ctx, writer, bundle, u); err != nil {
+				return nil, false, r.claimFailed(bundle, fmt.Errorf("failed to apply %s %s of component %s: %w", u.GetKind(), u.GetName(), c.Name, err))
+			}
+			applied[c.Name+"/"+u.GetName()] = u
+			statuses = append(statuses, appv1alpha1.ClaimStatus{
+				Component:  c.Name,
+				APIVersion: u.GetAPIVersion(),
+				Kind:       u.GetKind(),
+				Name:       u.GetName(),
+				Ready:      claims.Ready(u),
+			})
+		}
+	}
+	if err := r.deleteStaleClaims(ctx, writer, bundle, statuses); err != nil {
+		return nil, false, err
+	}
+	bundle.Status.Claims = statuses
+	if len(statuses) == 0 && !hasClaims(bundle) {
+		removeCondition(bundle, appv1alpha1.ConditionClaimsReady)
+		return nil, false, nil
+	}
+
+	outputs, missing, err := r.readOutputs(ctx, bundle, applied)
+	if err != nil {
+		return nil, false, r.claimFailed(bundle, err)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		setCondition(bundle, appv1alpha1.ConditionClaimsReady, v1.ConditionFalse, appv1alpha1.ReasonClaimsPending,
+			"Waiting for the outputs "+strings.Join(missing, ", "))
+		return nil, true, nil
+	}
+	setCondition(bundle, appv1alpha1.ConditionClaimsReady, v1.ConditionTrue, appv1alpha1.ReasonClaimsReady,
+		fmt.Sprintf("%d claims applied, their outputs are available", len(statuses)))
+	return outputs, false, nil
+}
+
+// claimOutputs returns the outputs of the claims reported in the status of the bundle,
+// without applying them
+func (r *AppBundleReconciler) claimOutputs(ctx context.Context, bundle *appv1alpha1.AppBundle) (map[string]map[string]string, error) {
+	if !hasClaims(bundle) {
+		return nil, nil
+	}
+	applied := map[string]*unstructured.Unstructured{}
+	for _, c := range bundle.Status.Claims {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(schema.FromAPIVersionAndKind(c.APIVersion, c.Kind))
+		err := r.Get(ctx, types.NamespacedName{Namespace: bundle.Namespace, Name: c.Name}, u)
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		applied[c.Component+"/"+c.Name] = u
+	}
+	outputs, _, err := r.readOutputs(ctx, bundle, applied)
+	return outputs, err
+}
+
+// readOutputs returns the outputs of the Crossplane components read from their applied
+// claims, by component and name, and the COMPONENT.OUTPUT outputs not yet available
+func (r *AppBundleReconciler) readOutputs(ctx context.Context, bundle *appv1alpha1.AppBundle, applied map[string]*unstructured.Unstructured) (map[string]map[string]string, []string, error) {
+	outputs := map[string]map[string]string{}
+	missing := []string{}
+	for _, c := range bundle.Spec.Components {
+		if !isCrossplane(c) {
+			continue
+		}
+		outputs[c.Name] = map[string]string{}
+		for _, o := range c.Outputs {
+			claim, ok := applied[c.Name+"/"+o.Claim]
+			if !ok {
+				missing = append(missing, c.Name+"."+o.Name)
+				continue
+			}
+			v, found, err := r.claimOutput(ctx, claim, o)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !found {
+				missing = append(missing, c.Name+"."+o.Name)
+				continue
+			}
+			outputs[c.Name][o.Name] = v
+		}
+	}
+	return outputs, missing, nil
+}
+
+// renderClaims returns the normalized manifests of the Crossplane component, in the
+// namespace of the bundle. Only the namespaced claim kinds of the KealmConfig are
+// rendered.
+func (r *AppBundleReconciler) renderClaims(ctx context.Context, bundle *appv1alpha1.AppBundle, c appv1alpha1.Component) ([]workapiv1.Manifest, error) {
+	ms, err := manifests.Normalize(c.Manifests)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifests of component %s: %w", c.Name, err)
+	}
+	referenced, err := r.workloadRefManifests(ctx, bundle.Namespace, c.WorkloadRefs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render component %s: %w", c.Name, err)
+	}
+	ms = append(ms, referenced...)
+	kinds := r.Config.Get().ClaimKinds
+	for _, m := range ms {
+		u, err := manifests.ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		gvk := u.GroupVersionKind()
+		if !claims.Allowed(gvk, kinds) {
+			return nil, fmt.Errorf("%s %s of component %s is not a claim kind of the KealmConfig", gvk.Kind, u.GetName(), c.Name)
+		}
+		mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("unknown kind %s of component %s: %w", gvk.Kind, c.Name, err)
+		}
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			return nil, fmt.Errorf("%s %s of component %s is not namespaced", gvk.Kind, u.GetName(), c.Name)
+		}
+	}
+	return manifests.SetNamespace(ms, bundle.Namespace)
+}
+
+// applyClaim applies the claim on the hub with the writer, owned by the bundle, and
+// updates it with the applied claim. The fields of the claim set by other managers are
+// not taken over.
+func (r *AppBundleReconciler) applyClaim(ctx context.Context, writer client.Client, bundle *appv1alpha1.AppBundle, u *unstructured.Unstructured) error {
+	if err := controllerutil.SetControllerReference(bundle, u, r.Scheme); err != nil {
+		return err
+	}
+	return writer.Patch(ctx, u, client.Apply, client.FieldOwner(claimFieldOwner))
+}
+
+// claimOutput returns the output read from the claim, false when not yet available
+func (r *AppBundleReconciler) claimOutput(ctx context.Context, claim *unstructured.Unstructured, o appv1alpha1.ComponentOutput) (string, bool, error) {
+	if o.FieldPath != "" {
+		return claims.Field(claim, o.FieldPath)
+	}
+	name := claims.ConnectionSecret(claim)
+	if name == "" {
+		return "", false, nil
+	}
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: claim.GetNamespace(), Name: name}, secret)
+	if apierrors.IsNotFound(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	v, ok := secret.Data[o.ConnectionSecretKey]
+	return string(v), ok, nil
+}
+
+// deleteStaleClaims deletes with the writer the claims reported in the status of the
+// bundle which are no longer applied
+func (r *AppBundleReconciler) deleteStaleClaims(ctx context.Context, writer client.Client, bundle *appv1alpha1.AppBundle, applied []appv1alpha1.ClaimStatus) error {
+	current := map[appv1alpha1.ClaimStatus]bool{}
+	for _, c := range applied {
+		c.Ready = false
+		current[c] = true
+	}
+	for _, c := range bundle.Status.Claims {
+		c.Ready = false
+		if current[c] {
+			continue
+		}
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(schema.FromAPIVersionAndKind(c.APIVersion, c.Kind))
+		u.SetNamespace(bundle.Namespace)
+		u.SetName(c.Name)
+		if err := writer.Delete(ctx, u); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// claimFailed reports the failure of the claims of the bundle
+func (r *AppBundleReconciler) claimFailed(bundle *appv1alpha1.AppBundle, err error) error {
+	setCondition(bundle, appv1alpha1.ConditionClaimsReady, v1.ConditionFalse, appv1alpha1.ReasonClaimFailed, err.Error())
+	return faults.New(appv1alpha1.ReasonClaimFailed, err)
+}
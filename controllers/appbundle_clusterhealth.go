@@ -0,0 +1,69 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/clusterhealth"
+)
+
+//+kubebuilder:rbac:groups=addon.open-cluster-management.io,resources=managedclusteraddons,verbs=get;list;watch
+
+// reportClusterHealth sets the health of the klusterlet and of the add-ons of the
+// clusters of the bundle in their status. The add-ons are ignored on the hubs without
+// the add-on API.
+func (r *AppBundleReconciler) reportClusterHealth(ctx context.Context, bundle *appv1alpha1.AppBundle) error {
+	if len(bundle.Status.Clusters) == 0 {
+		return nil
+	}
+	addons := map[string][]addonv1alpha1.ManagedClusterAddOn{}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(clusterhealth.AddOnListGVK)
+	err := r.List(ctx, list)
+	switch {
+	case err == nil:
+		if addons, err = clusterhealth.AddOns(list); err != nil {
+			return err
+		}
+	case !meta.IsNoMatchError(err):
+		return err
+	}
+	for i := range bundle.Status.Clusters {
+		s := &bundle.Status.Clusters[i]
+		cluster, err := r.ManagedClusterLister.Get(s.ClusterName)
+		if apierrors.IsNotFound(err) {
+			s.Health = &appv1alpha1.ClusterHealth{
+				Status:  appv1alpha1.ClusterHealthUnknown,
+				Message: "The cluster is not registered",
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		h := clusterhealth.Evaluate(cluster, addons[s.ClusterName])
+		s.Health = &h
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// reportIncompatible warns about the resources not distributed to clusters whose
+// Kubernetes version removed their API version
+func (r *AppBundleReconciler) reportIncompatible(bundle *appv1alpha1.AppBundle, incompatible map[string][]string) {
+	if len(incompatible) == 0 {
+		return
+	}
+	clusters := []string{}
+	for c := range incompatible {
+		clusters = append(clusters, c)
+	}
+	sort.Strings(clusters)
+	messages := []string{}
+	for _, c := range clusters {
+		messages = append(messages, fmt.Sprintf("cluster %s skips %s", c, strings.Join(incompatible[c], ", ")))
+	}
+	r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonIncompatibleAPI, strings.Join(messages, "; "))
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+	"github.com/pdettori/kealm/pkg/provenance"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// componentRetry is the delay before checking again the clusters where the changes of
+// components wait for the components they depend on
+const componentRetry = 15 * time.Second
+
+// ComponentResyncAnnotation requests resyncs of single components on single clusters,
+// as comma separated CLUSTER/COMPONENT=TOKEN entries. Setting a new token applies the
+// manifests of the component on the cluster again, leaving the others unchanged.
+const ComponentResyncAnnotation = "cluster.open-cluster-management.io/resync-components"
+
+// renderComponents returns the normalized manifests of the components of the bundle
+// distributed to the clusters,
+// moved to their target namespace, constrained to their operating system and annotated
+// with their component
+func (r *AppBundleReconciler) renderComponents(ctx context.Context, bundle *appv1alpha1.AppBundle) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, c := range bundle.Spec.Components {
+		if isCrossplane(c) {
+			continue
+		}
+		ms, err := manifests.Normalize(c.Manifests)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifests of component %s: %w", c.Name, err)
+		}
+		referenced, err := r.workloadRefManifests(ctx, bundle.Namespace, c.WorkloadRefs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render component %s: %w", c.Name, err)
+		}
+		ms = append(ms, referenced...)
+		if err := manifests.ValidateScopes(ms); err != nil {
+			return nil, fmt.Errorf("invalid manifests of component %s: %w", c.Name, err)
+		}
+		namespace := c.TargetNamespace
+		if namespace == "" {
+			namespace = bundle.Spec.TargetNamespace
+		}
+		if namespace != "" {
+			if ms, err = manifests.SetNamespace(ms, namespace); err != nil {
+				return nil, fmt.Errorf("invalid manifests of component %s: %w", c.Name, err)
+			}
+		}
+		if ms, err = manifests.ConstrainOS(ms, c.OS); err != nil {
+			return nil, fmt.Errorf("invalid manifests of component %s: %w", c.Name, err)
+		}
+		if ms, err = manifests.SetComponent(ms, c.Name); err != nil {
+			return nil, err
+		}
+		result = append(result, ms...)
+	}
+	return result, nil
+}
+
+// hasOSConstraints returns true if a component of the bundle is constrained to an
+// operating system
+func hasOSConstraints(bundle *appv1alpha1.AppBundle) bool {
+	for _, c := range bundle.Spec.Components {
+		if c.OS != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// unsupportedComponents returns the components of the bundle constrained to an
+// operating system the cluster does not advertise
+func unsupportedComponents(bundle *appv1alpha1.AppBundle, cluster manifests.ClusterContext) []string {
+	result := []string{}
+	for _, c := range bundle.Spec.Components {
+		if c.OS != nil && !manifests.RunsOS(cluster, c.OS.Name) {
+			result = append(result, c.Name)
+		}
+	}
+	return result
+}
+
+// withoutComponents returns the manifests without the ones of the components
+func withoutComponents(ms []workapiv1.Manifest, components []string) ([]workapiv1.Manifest, error) {
+	for _, c := range components {
+		var err error
+		if ms, err = manifests.ReplaceComponent(ms, c, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+// componentDigest returns the digest of the manifests of the component
+func componentDigest(ms []workapiv1.Manifest, component string) (string, error) {
+	of, err := manifests.OfComponent(ms, component)
+	if err != nil {
+		return "", err
+	}
+	payload, err := provenance.Payload(of)
+	if err != nil {
+		return "", err
+	}
+	return provenance.Digest(payload), nil
+}
+
+// componentManifests returns the manifests of the work of the bundle on the cluster
+// where the components whose dependencies are not ready keep the manifests of the
+// current work, and the names of those components. A dependency is ready when its
+// manifests in the current work are the ones rendered and Available. A cluster without
+// work gets all the rendered components.
+func (r *AppBundleReconciler) componentManifests(ctx context.Context, bundle *appv1alpha1.AppBundle, clusterName string, ms []workapiv1.Manifest) ([]workapiv1.Manifest, []string, error) {
+	gated := false
+	for _, c := range bundle.Spec.Components {
+		gated = gated || len(c.DependsOn) > 0
+	}
+	if !gated {
+		return ms, nil, nil
+	}
+	existing, err := r.WorkClient.WorkV1().ManifestWorks(clusterName).Get(ctx, WorkName(bundle), v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ms, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	current := existing.Spec.Workload.Manifests
+
+	ready := func(component string) (bool, error) {
+		want, err := componentDigest(ms, component)
+		if err != nil {
+			return false, err
+		}
+		got, err := componentDigest(current, component)
+		if err != nil || got != want {
+			return false, err
+		}
+		return manifestsAvailable(existing, manifests.ComponentOf, component)
+	}
+	// holding a component holds the components depending on it, repeat until no
+	// component is held anymore
+	held := sets.NewString()
+	for changed := true; changed; {
+		changed = false
+		for _, c := range bundle.Spec.Components {
+			if held.Has(c.Name) {
+				continue
+			}
+			for _, dep := range c.DependsOn {
+				ok, err := ready(dep)
+				if err != nil {
+					return nil, nil, err
+				}
+				if ok && !held.Has(dep) {
+					continue
+				}
+				previous, err := manifests.OfComponent(current, c.Name)
+				if err != nil {
+					return nil, nil, err
+				}
+				if ms, err = manifests.ReplaceComponent(ms, c.Name, previous); err != nil {
+					return nil, nil, err
+				}
+				held.Insert(c.Name)
+				changed = true
+				break
+			}
+		}
+	}
+	return ms, held.List(), nil
+}
+
+// reportComponents records the digest of each component of the bundle, the number of
+// clusters where it is Available, the clusters where its changes wait and the ones not
+// running its operating system
+func (r *AppBundleReconciler) reportComponents(ctx context.Context, bundle *appv1alpha1.AppBundle, ms []workapiv1.Manifest, clusters []string, waiting map[string][]string) error {
+	if len(bundle.Spec.Components) == 0 {
+		bundle.Status.Components = nil
+		return nil
+	}
+	statuses := []appv1alpha1.ComponentStatus{}
+	for _, c := range bundle.Spec.Components {
+		of, err := manifests.OfComponent(ms, c.Name)
+		if err != nil {
+			return err
+		}
+		digest, err := componentDigest(ms, c.Name)
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, appv1alpha1.ComponentStatus{Name: c.Name, Digest: digest, Manifests: int32(len(of))})
+	}
+	for _, clusterName := range clusters {
+		unsupported := sets.NewString()
+		if hasOSConstraints(bundle) {
+			cluster, err := r.ManagedClusterLister.Get(clusterName)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			if err == nil {
+				unsupported.Insert(unsupportedComponents(bundle, manifests.NewClusterContext(cluster))...)
+			}
+		}
+		for i := range statuses {
+			if unsupported.Has(statuses[i].Name) {
+				statuses[i].Unsupported = append(statuses[i].Unsupported, clusterName)
+			}
+		}
+		work, err := r.WorkClient.WorkV1().ManifestWorks(clusterName).Get(ctx, WorkName(bundle), v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for i := range statuses {
+			if unsupported.Has(statuses[i].Name) {
+				continue
+			}
+			ok, err := manifestsAvailable(work, manifests.ComponentOf, statuses[i].Name)
+			if err != nil {
+				return err
+			}
+			if ok {
+				statuses[i].AvailableClusters++
+			}
+		}
+	}
+	for i := range statuses {
+		for clusterName, components := range waiting {
+			if sets.NewString(components...).Has(statuses[i].Name) {
+				statuses[i].Waiting = append(statuses[i].Waiting, clusterName)
+			}
+		}
+		sort.Strings(statuses[i].Waiting)
+	}
+	bundle.Status.Components = statuses
+	return nil
+}
+
+// ComponentResyncs returns the tokens of the component resyncs requested on the bundle,
+// by cluster and component. Malformed entries are ignored.
+func ComponentResyncs(bundle *appv1alpha1.AppBundle) map[string]map[string]string {
+	result := map[string]map[string]string{}
+	value := bundle.Annotations[ComponentResyncAnnotation]
+	if value == "" {
+		return result
+	}
+	for _, entry := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		target := strings.SplitN(kv[0], "/", 2)
+		if len(kv) != 2 || len(target) != 2 || target[0] == "" || target[1] == "" {
+			klog.Warningf("Ignoring malformed entry %q of %s on AppBundle %s/%s", entry, ComponentResyncAnnotation, bundle.Namespace, bundle.Name)
+			continue
+		}
+		if result[target[0]] == nil {
+			result[target[0]] = map[string]string{}
+		}
+		result[target[0]][target[1]] = kv[1]
+	}
+	return result
+}
+
+// SetComponentResync requests a resync of the component on the cluster with the token
+func SetComponentResync(bundle *appv1alpha1.AppBundle, cluster, component, token string) {
+	resyncs := ComponentResyncs(bundle)
+	if resyncs[cluster] == nil {
+		resyncs[cluster] = map[string]string{}
+	}
+	resyncs[cluster][component] = token
+	entries := []string{}
+	for c, components := range resyncs {
+		for name, t := range components {
+			entries = append(entries, c+"/"+name+"="+t)
+		}
+	}
+	sort.Strings(entries)
+	if bundle.Annotations == nil {
+		bundle.Annotations = map[string]string{}
+	}
+	bundle.Annotations[ComponentResyncAnnotation] = strings.Join(entries, ",")
+}
+
+// resyncComponents annotates the manifests of the components resynced on the cluster
+// with their token, and returns the COMPONENT=TOKEN entries of the cluster to annotate
+// its work with
+func resyncComponents(bundle *appv1alpha1.AppBundle, clusterName string, ms []workapiv1.Manifest) ([]workapiv1.Manifest, string, error) {
+	resyncs := ComponentResyncs(bundle)[clusterName]
+	entries := []string{}
+	for component, token := range resyncs {
+		var err error
+		if ms, err = manifests.Resync(ms, component, token); err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, component+"="+token)
+	}
+	sort.Strings(entries)
+	return ms, strings.Join(entries, ","), nil
+}
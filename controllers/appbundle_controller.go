@@ -18,24 +18,62 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/audit"
+	"github.com/pdettori/kealm/pkg/config"
+	"github.com/pdettori/kealm/pkg/decisions"
+	"github.com/pdettori/kealm/pkg/diagnostics"
+	"github.com/pdettori/kealm/pkg/faults"
+	"github.com/pdettori/kealm/pkg/finalizers"
+	"github.com/pdettori/kealm/pkg/guardrails"
+	"github.com/pdettori/kealm/pkg/manifests"
+	"github.com/pdettori/kealm/pkg/metrics"
+	"github.com/pdettori/kealm/pkg/plugins"
+	"github.com/pdettori/kealm/pkg/provenance"
+	"github.com/pdettori/kealm/pkg/quota"
+	"github.com/pdettori/kealm/pkg/secrets"
+	"github.com/pdettori/kealm/pkg/securitygate"
+	"github.com/pdettori/kealm/pkg/sharding"
+	"github.com/pdettori/kealm/pkg/statusbatch"
+	"github.com/pdettori/kealm/pkg/tracing"
+	"github.com/pdettori/kealm/pkg/works"
+	"github.com/pdettori/kealm/pkg/writeback"
 	clusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterlisterv1alpha1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1alpha1"
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned"
 
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
 	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 )
@@ -43,11 +81,83 @@ import (
 // AppBundleReconciler reconciles a AppBundle object
 type AppBundleReconciler struct {
 	client.Client
-	Scheme                  *runtime.Scheme
-	ClusterClient           clusterclient.Interface
-	PlacementLister         clusterlisterv1alpha1.PlacementLister
-	PlacementDecisionLister clusterlisterv1alpha1.PlacementDecisionLister
-	WorkClient              workv1client.Clientset
+	Scheme               *runtime.Scheme
+	ClusterClient        clusterclient.Interface
+	PlacementLister      clusterlisterv1alpha1.PlacementLister
+	ManagedClusterLister clusterlisterv1.ManagedClusterLister
+	WorkClient           workv1client.Interface
+	// Decisions reads the clusters decided for each placement from the placement
+	// index of the PlacementDecisionInformer
+	Decisions decisions.Reader
+
+	// PlacementDecisionInformer and ManagedClusterInformer are watched so that
+	// bundles are rescheduled when decisions change or clusters are detached
+	PlacementDecisionInformer cache.SharedIndexInformer
+	ManagedClusterInformer    cache.SharedIndexInformer
+	// WorkInformer caches the works owned by bundles, indexed by OwnerIndex. The works
+	// are listed from the API when it is not set.
+	WorkInformer cache.SharedIndexInformer
+
+	Recorder record.EventRecorder
+
+	// Config holds the controller-wide configuration, ConfigChanges receives the
+	// bundles to reconcile when it is reloaded
+	Config                  *config.Store
+	ConfigChanges           <-chan event.GenericEvent
+	MaxConcurrentReconciles int
+
+	// AuditSink records the distribution actions when set
+	AuditSink audit.Sink
+
+	// Signer signs the distributed content when set, Identity identifies the
+	// controller in the recorded provenance
+	Signer   *provenance.Signer
+	Identity string
+
+	// Diagnostics tracks the in-flight reconciles when set
+	Diagnostics *diagnostics.Tracker
+	// Tracer records the spans of the reconciles when set
+	Tracer trace.Tracer
+	// QueueMetrics reports the pending reconciles per placement and bundle when set
+	QueueMetrics *metrics.QueueTracker
+	// DeploymentInfo reports the generation deployed on each cluster when set
+	DeploymentInfo *metrics.DeploymentInfo
+	// ApplyLatency reports how long the clusters take to apply the works when set
+	ApplyLatency *metrics.ApplyLatency
+	// RolloutSLO reports the rollout SLI and burn rate of the bundles when set
+	RolloutSLO *metrics.RolloutSLO
+	// Shard restricts the reconciled bundles to a subset when set
+	Shard *sharding.Shard
+	// SecurityGate reviews the images of the bundles with the scanner of the KealmConfig
+	SecurityGate *securitygate.Gate
+	// WASMRuntime runs the WebAssembly distribution plugins
+	WASMRuntime *plugins.Runtime
+	// GitWriter writes the state of the bundles with a write back to Git
+	GitWriter *writeback.Writer
+	// Secrets looks up the secret values of the bundles in the external backends
+	Secrets *secrets.Resolver
+
+	// StartupJitter spreads the resync of the already distributed bundles over this
+	// window after a restart, WriteLimiter bounds the rate of ManifestWork writes when set
+	StartupJitter time.Duration
+	WriteLimiter  flowcontrol.RateLimiter
+	stagger       *startupStagger
+
+	// StatusInterval coalesces the status changes of the clusters of a bundle into at
+	// most one write per interval when positive, the transitions being written right away
+	StatusInterval time.Duration
+	statuses       *statusbatch.Batcher
+
+	// AuthorsAttributed is true when the attribution webhook records the authors of the
+	// bundles, the hub access of the bundles is refused otherwise
+	AuthorsAttributed bool
+
+	// RestConfig is impersonated to write the ManifestWorks and the claims of the tenant
+	// namespaces
+	RestConfig    *rest.Config
+	tenantsLock   sync.Mutex
+	tenantClients map[string]*workv1client.Clientset
+	claimClients  map[string]client.Client
 }
 
 const (
@@ -56,11 +166,59 @@ const (
 
 	// OwnedLabel is the label to attach to owned manifest works
 	OwnedLabel = "cluster.open-cluster-management.io/owned-by"
+
+	// AdoptWorksAnnotation names existing manifest works the bundle takes over in its
+	// target clusters, replacing them without restarting their workload
+	AdoptWorksAnnotation = "cluster.open-cluster-management.io/adopt-works"
+
+	// GenerationAnnotation records the bundle generation a manifest work was rendered from
+	GenerationAnnotation = "cluster.open-cluster-management.io/bundle-generation"
+
+	// DigestAnnotation records the content digest of a manifest work
+	DigestAnnotation = "cluster.open-cluster-management.io/content-digest"
+
+	// ClusterDigestAnnotation records the digest of the content of a manifest work
+	// specific to its cluster, when it differs from the content of the bundle
+	ClusterDigestAnnotation = "cluster.open-cluster-management.io/cluster-content-digest"
+
+	// WrittenAtAnnotation records when the content of a manifest work was last written,
+	// to measure the latency of the cluster applying it
+	WrittenAtAnnotation = "cluster.open-cluster-management.io/content-written-at"
+
+	// SignatureAnnotation records the content signature of a manifest work
+	SignatureAnnotation = "cluster.open-cluster-management.io/content-signature"
+
+	// SignedByAnnotation records the identity of the controller which shipped a manifest work
+	SignedByAnnotation = "cluster.open-cluster-management.io/signed-by"
+
+	// TraceparentAnnotation records the W3C traceparent of the reconcile which wrote the
+	// content of a manifest work, so that its delivery can be traced on the cluster
+	TraceparentAnnotation = "cluster.open-cluster-management.io/traceparent"
 )
 
 //+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundles,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundles/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundletemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=catalogs,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=kealmtenants,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=impersonate
 //+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundles/finalizers,verbs=update
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundleaudits,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=placements,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=placementdecisions,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=create;update
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=create;update;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=bind
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=externaldns.k8s.io,resources=dnsendpoints,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=work.open-cluster-management.io,resources=manifestworks,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -72,37 +230,103 @@ const (
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.10.0/pkg/reconcile
 func (r *AppBundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.QueueMetrics.Dequeued(req.NamespacedName)
+	if !r.Shard.Owns(req.Namespace, req.Name) {
+		return ctrl.Result{}, nil
+	}
+	r.Diagnostics.Start(req.String())
+	ctx, span := r.tracer().Start(ctx, "AppBundle.Reconcile", trace.WithAttributes(
+		attribute.String("bundle.namespace", req.Namespace), attribute.String("bundle.name", req.Name)))
+	result, err := r.reconcile(ctx, req)
+	tracing.End(span, err)
+	r.Diagnostics.Finish(req.String(), err)
+	if reported := (reportedError{}); err != nil && !errors.As(err, &reported) {
+		countFault(err)
+	}
+	if err != nil || result.Requeue || result.RequeueAfter > 0 {
+		r.QueueMetrics.Requeued(req.NamespacedName)
+	}
+	return result, err
+}
+
+// tracer returns the tracer of the reconciler, one recording nothing when unset
+func (r *AppBundleReconciler) tracer() trace.Tracer {
+	if r.Tracer == nil {
+		return trace.NewNoopTracerProvider().Tracer("")
+	}
+	return r.Tracer
+}
+
+func (r *AppBundleReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	_ = log.FromContext(ctx)
 
 	var bundle appv1alpha1.AppBundle
 	if err := r.Get(ctx, req.NamespacedName, &bundle); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.Diagnostics.Forget(req.String())
+			r.QueueMetrics.Forget(req.NamespacedName)
+			r.DeploymentInfo.Forget(req.NamespacedName)
+			r.ApplyLatency.Forget(req.NamespacedName)
+			r.RolloutSLO.Forget(req.NamespacedName)
+			r.statuses.Forget(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	r.statuses.Restore(&bundle)
 
+	if bundle.DeletionTimestamp.IsZero() {
+		if delay := r.stagger.delay(&bundle); delay > 0 {
+			klog.Infof("Deferring resync of AppBundle %s by %s after startup", bundle.Name, delay)
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
+	}
+
+	r.Diagnostics.Phase(req.String(), "Waiting")
+	if err := r.Config.Limiter().AcquireWithPriority(ctx, bundle.Spec.Priority); err != nil {
+		return ctrl.Result{}, err
+	}
+	defer r.Config.Limiter().Release()
+	cfg := r.Config.Get()
+	r.Diagnostics.Phase(req.String(), "Resolving")
 	b := bundle.DeepCopy()
+	locked, err := r.lockedClusters(ctx, b, &cfg)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 	// examine DeletionTimestamp to determine if object is under deletion
 	if bundle.ObjectMeta.DeletionTimestamp.IsZero() {
 		// The object is not being deleted, so if it does not have our finalizer,
-		// then lets add the finalizer and update the object. This is equivalent
+		// then lets add the finalizer and patch the object. This is equivalent
 		// registering our finalizer.
 		if !containsString(b.GetFinalizers(), DeployFinalizer) {
-			controllerutil.AddFinalizer(b, DeployFinalizer)
-			err := r.Update(ctx, b, &client.UpdateOptions{})
-			if err != nil {
-				return ctrl.Result{}, err
+			if err := finalizers.Add(ctx, r.Client, b, DeployFinalizer); err != nil {
+				return ctrl.Result{}, IgnoreConflict(err)
 			}
 		}
 	} else {
 		// The object is being deleted
 		if containsString(b.GetFinalizers(), DeployFinalizer) {
 			// our finalizer is present, so lets handle any external dependency
-			if err := r.deleteAllChildManifests(b); err != nil {
-				return ctrl.Result{}, err
+			var blocked []string
+			if hubMigration(b) == HubMigrationRelease {
+				// orphan the works before deleting them, the locked ones are left
+				if _, _, err := r.releaseWorks(ctx, b, locked); err != nil {
+					return ctrl.Result{}, err
+				}
 			}
-			// remove our finalizer from the list and update it.
-			controllerutil.RemoveFinalizer(b, DeployFinalizer)
-
-			if err := r.Update(ctx, b, &client.UpdateOptions{}); err != nil {
+			// on the new hub, the works are still managed by the old one
+			if hubMigration(b) != HubMigrationAdopt {
+				if blocked, err = r.deleteAllChildManifests(b, locked); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			if len(blocked) > 0 {
+				// keep the finalizer until the clusters are unlocked
+				r.reportBlocked(b, &cfg, blocked)
+				return ctrl.Result{}, r.updateStatus(ctx, b)
+			}
+			// remove our finalizer from the list and patch it.
+			if err := finalizers.Remove(ctx, r.Client, b, DeployFinalizer); err != nil {
 				return ctrl.Result{}, IgnoreConflict(err)
 			}
 		}
@@ -111,76 +335,755 @@ func (r *AppBundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
-	var pLabel *string
-	if pLabel = getPlacementLabel(bundle); pLabel == nil {
+	placement, ok := PlacementName(&bundle)
+	if !ok {
 		klog.Infof("No placement label found on AppBundle %s", bundle.Name)
 		return ctrl.Result{}, nil
 	}
+	if placement == AllClustersPlacement {
+		if err := r.ensureAllClustersPlacement(ctx, &bundle); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	klog.Infof("Placement %s found on AppBundle %s", placement, bundle.Name)
+	status, reason, message, err := CheckPlacement(r.PlacementLister, req.Namespace, placement)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	setCondition(b, appv1alpha1.ConditionPlacementSatisfied, status, reason, message)
+
+	decision, err := r.getPlacementDecision(placement, req.Namespace)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		// the placement may not have been scheduled yet, or its name may be wrong:
+		// let the user know and check again later instead of failing hard
+		backoff := placementBackoff(b)
+		klog.Infof("No placement decision found for placement %s, retrying in %s", placement, backoff)
+		message := "No placement decision found for placement " + placement
+		r.reportFault(b, faults.New(appv1alpha1.ReasonPlacementMissing, errors.New(message)))
+		setCondition(b, appv1alpha1.ConditionPlacementResolved, v1.ConditionFalse,
+			appv1alpha1.ReasonPlacementDecisionNotFound, message)
+		if err := r.updateStatus(ctx, b); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: backoff}, nil
+	}
+	klog.Infof("found %v", decision.Clusters)
+	setCondition(b, appv1alpha1.ConditionPlacementResolved, v1.ConditionTrue,
+		appv1alpha1.ReasonPlacementDecisionFound, placementDecisionFound(decision))
+
+	clusters, err := r.getTargetClusters(decision)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if clusters, err = r.fallbackClusters(b, placement, clusters); err != nil {
+		return ctrl.Result{}, err
+	}
+	clusters = pinnedClusters(b, clusters)
+	if clusters, err = r.supportedClusters(b, clusters); err != nil {
+		return ctrl.Result{}, err
+	}
+	if clusters, err = r.applyAntiAffinity(ctx, b, clusters); err != nil {
+		return ctrl.Result{}, err
+	}
+	if clusters, err = r.selectClusters(b, clusters); err != nil {
+		return ctrl.Result{}, err
+	}
+	if hubMigration(b) != "" {
+		return r.reconcileMigration(ctx, b, clusters, locked)
+	}
+	removeCondition(b, appv1alpha1.ConditionHubMigration)
+
+	writable, blocked := splitLocked(clusters, locked)
+	missing, err := r.missingRequirements(ctx, b, writable)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	writable, _ = splitWaiting(writable, missing)
+	r.reportRequirements(b, missing)
+	if err := r.adoptWorks(b, writable); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	r.Diagnostics.Phase(req.String(), "Rendering")
+	renderCtx, renderSpan := r.tracer().Start(ctx, "AppBundle.Render")
+	manifests, err := r.renderWorkload(renderCtx, b)
+	tracing.End(renderSpan, err)
+	if err != nil {
+		setCondition(b, appv1alpha1.ConditionWorkloadResolved, v1.ConditionFalse,
+			appv1alpha1.ReasonWorkloadRefFailed, err.Error())
+		return r.fail(ctx, b, faults.New(appv1alpha1.ReasonRenderFailed, err))
+	}
+	setCondition(b, appv1alpha1.ConditionWorkloadResolved, v1.ConditionTrue,
+		appv1alpha1.ReasonWorkloadResolved, fmt.Sprintf("%d manifests resolved", len(manifests)))
+	outputs, claimsPending, err := r.dispatchClaims(ctx, b)
+	if err != nil {
+		return r.fail(ctx, b, err)
+	}
+	if claimsPending {
+		return ctrl.Result{RequeueAfter: claimsRetry}, r.updateStatus(ctx, b)
+	}
+	b.Status.InstanceSuffix = ""
+	if b.Spec.Instance != nil {
+		b.Status.InstanceSuffix = instanceSuffix(b)
+	}
+	b.Status.ManifestBytes = quota.Size(manifests)
 
-	klog.Infof("Placement label %s found on AppBundle %s", *pLabel, bundle.Name)
-	placementDec, err := r.getPlacementDecision(*pLabel, req.Namespace)
+	exceeded, err := r.checkQuota(ctx, b, clusters, manifests)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	klog.Infof("found %+v", placementDec.Status.Decisions)
+	r.reportQuota(b, exceeded)
+	if len(exceeded) > 0 {
+		return ctrl.Result{RequeueAfter: quotaRetry}, r.updateStatus(ctx, b)
+	}
+
+	gating, err := r.gateResources(ctx, b, &cfg, writable, manifests)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	reportGating(b, gating)
+	if gating == nil {
+		gating = &gatingResult{}
+	}
+	writable, _ = splitWaiting(writable, gating.insufficient)
+	// the works of the clusters the bundle is preempted from are deleted
+	writable = withoutClusters(writable, gating.preempted)
+	clusters = withoutClusters(clusters, gating.preempted)
+
+	violations, err := guardrails.Check(cfg.Guardrails, manifests)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(violations) > 0 {
+		messages := []string{}
+		for _, v := range violations {
+			messages = append(messages, v.String())
+		}
+		message := strings.Join(messages, "; ")
+		r.Recorder.Event(b, corev1.EventTypeWarning, appv1alpha1.ReasonGuardrailViolated, message)
+		setCondition(b, appv1alpha1.ConditionGuardrailsPassed, v1.ConditionFalse, appv1alpha1.ReasonGuardrailViolated, message)
+		return ctrl.Result{}, r.updateStatus(ctx, b)
+	}
+	setCondition(b, appv1alpha1.ConditionGuardrailsPassed, v1.ConditionTrue,
+		appv1alpha1.ReasonGuardrailsPassed, "No guardrail violated")
+
+	skew := ""
+	if b.Spec.VersionSkew != nil {
+		if skew, err = r.checkVersionSkew(ctx, b); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	r.reportVersionSkew(b, skew)
+	if skew != "" {
+		return ctrl.Result{RequeueAfter: skewRetry}, r.updateStatus(ctx, b)
+	}
+
+	passed, recheck, err := r.checkSecurityGate(ctx, b, &cfg, manifests)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !passed {
+		return ctrl.Result{RequeueAfter: recheck}, r.updateStatus(ctx, b)
+	}
+
+	prov, err := r.recordProvenance(bundle, manifests)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	held, planned, err := r.planRollout(b, prov.Digest, clusters)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if planned {
+		// the plan is published before the rollout starts
+		return ctrl.Result{RequeueAfter: waveStartDelay}, r.updateStatus(ctx, b)
+	}
+	writable = withoutClusters(writable, held)
+
+	blueGreen, blueGreenCheck, err := r.planBlueGreen(ctx, b, prov.Digest, writable)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	hubAccessPending, err := r.provisionHubAccess(ctx, b, clusters)
+	if err != nil {
+		return r.fail(ctx, b, err)
+	}
 
 	// schedule only non-empty bundles
-	if len(bundle.Spec.Workload.Manifests) > 0 {
-		err = r.scheduleBundle(bundle, placementDec)
-		if err != nil {
+	scheduled := &scheduleResult{}
+	var writeErr error
+	if len(manifests) > 0 {
+		r.Diagnostics.FanOut(req.String(), prov.Digest, len(clusters))
+		scheduled, writeErr = r.scheduleBundle(ctx, bundle, manifests, outputs, prov, &cfg, writable, blueGreen)
+		if scheduled == nil {
+			return r.fail(ctx, b, writeErr)
+		}
+		scheduled.blocked = blocked
+		scheduled.held = held
+		if err := r.observeBlocked(ctx, b, scheduled); err != nil {
 			return ctrl.Result{}, err
 		}
+	} else {
+		clusters = nil
+	}
+	scheduled.waiting = missing
+	scheduled.insufficient = gating.insufficient
+	scheduled.requests = gating.requests
+	r.reportDeferred(b, &cfg, scheduled.deferred)
+	r.reportDenied(b, &cfg, scheduled.denied)
+	r.reportIncompatible(b, scheduled.incompatible)
+
+	// remove works from clusters which are no longer part of the decision
+	r.Diagnostics.Phase(req.String(), "Pruning")
+	draining, drainCheck, err := r.drainStaleWorks(ctx, b, clusters, locked)
+	if err != nil {
+		return r.fail(ctx, b, faults.WorkWrite(err))
+	}
+	deleted, blockedStale, err := r.deleteStaleChildManifests(b, append(clusters, draining...), locked)
+	if err != nil {
+		return r.fail(ctx, b, faults.WorkWrite(err))
+	}
+	r.reportBlocked(b, &cfg, append(scheduled.blocked, blockedStale...))
+	r.reportDistributed(b, prov, scheduled.actions)
+	if err := r.recordAudit(ctx, b, prov.Digest, scheduled.diff, append(scheduled.actions, deleted...)); err != nil {
+		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	b.Status.Clusters = clusterStatuses(bundle, clusters, prov, scheduled)
+	if err := r.reportClusterHealth(ctx, b); err != nil {
+		return ctrl.Result{}, err
+	}
+	stallCheck, err := r.detectStalled(ctx, b, bundle.Status.Clusters, &cfg, scheduled)
+	if err != nil {
+		return r.fail(ctx, b, faults.WorkWrite(err))
+	}
+	r.trackRotation(b, bundle.Status.Clusters, scheduled)
+	unreachable, tolerationEnd := r.checkAvailability(b)
+	skipped := proceedPast(b, unreachable)
+	if scheduled.updated() {
+		b.Status.Pruned = scheduled.pruned.List()
+		b.Status.Orphaned = scheduled.orphaned.List()
+	}
+	if len(clusters) > 0 {
+		b.Status.Provenance = prov
+	}
+	if err := r.recordInventory(ctx, b, &cfg, manifests, prov); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.reportComponents(ctx, b, manifests, clusters, scheduled.components); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.reportCertificates(ctx, b, clusters); err != nil {
+		return ctrl.Result{}, err
+	}
+	globalDNSCheck, err := r.reconcileGlobalDNS(ctx, b)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	writeBackCheck := r.writeBack(ctx, b, manifests, &cfg)
+	setCondition(b, appv1alpha1.ConditionSynced, v1.ConditionTrue, appv1alpha1.ReasonSynced,
+		fmt.Sprintf("Distributed to %d clusters", len(b.Status.Clusters)))
+	requeue := minRequeue(
+		r.runAnalysis(ctx, b, sets.NewString(clusters...).Difference(skipped).List(), &cfg),
+		r.advanceRollout(b, scheduled, skipped),
+		r.evaluateSLO(b, &cfg, scheduled, skipped),
+		stallCheck,
+		tolerationEnd,
+		drainCheck,
+		blueGreenCheck,
+		requeueIf(len(scheduled.components) > 0, componentRetry),
+		requeueIf(hubAccessPending, hubAccessRetry),
+		globalDNSCheck,
+		writeBackCheck,
+		requeueIf(hasClaims(b), claimsResync),
+		secretsRefresh(b, &cfg),
+		requeueIf(rotationPending(b), rotationCheck),
+		requeueIf(len(missing) > 0, requirementRetry),
+		requeueIf(len(gating.insufficient)+len(gating.preempted) > 0, gatingRetry),
+	)
+	if writeErr != nil {
+		// the works written are reported with the others, the failed ones are retried
+		// with the backoff of the errors
+		return r.fail(ctx, b, writeErr)
+	}
+	if err := r.updateStatus(ctx, b); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.DeploymentInfo.Set(b)
+
+	requeue = minRequeue(requeue, requeueIf(len(scheduled.deferred) > 0, changeBudgetRetry))
+	return ctrl.Result{RequeueAfter: nextResync(&cfg, requeue)}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *AppBundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&appv1alpha1.AppBundle{}).
-		Complete(r)
+	r.stagger = newStartupStagger(r.StartupJitter)
+	if r.StatusInterval > 0 {
+		r.statuses = &statusbatch.Batcher{
+			Interval: r.StatusInterval,
+			Update: func(ctx context.Context, bundle *appv1alpha1.AppBundle) error {
+				return r.Status().Update(ctx, bundle)
+			},
+			Patch: func(ctx context.Context, bundle, written *appv1alpha1.AppBundle) error {
+				return client.IgnoreNotFound(r.Status().Patch(ctx, bundle, client.MergeFrom(written)))
+			},
+		}
+	}
+	q := r.QueueMetrics
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&appv1alpha1.AppBundle{}, builder.WithPredicates(r.Shard.Predicate())).
+		Watches(&source.Informer{Informer: r.PlacementDecisionInformer},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForPlacementDecision))).
+		Watches(&source.Informer{Informer: r.ManagedClusterInformer},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForManagedCluster))).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForWorkloadRef(appv1alpha1.WorkloadRefKindConfigMap)))).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForClusterValues))).
+		Watches(&source.Kind{Type: &corev1.Secret{}},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForWorkloadRef(appv1alpha1.WorkloadRefKindSecret)))).
+		Watches(&source.Kind{Type: &appv1alpha1.AppBundle{}},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForRequirement))).
+		Watches(&source.Kind{Type: &appv1alpha1.AppBundle{}},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForAntiAffinity))).
+		Watches(&source.Kind{Type: &appv1alpha1.KealmTenant{}},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForTenant))).
+		Watches(&source.Kind{Type: &appv1alpha1.AppBundleTemplate{}},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForTemplate))).
+		Watches(&source.Kind{Type: &appv1alpha1.ClusterLock{}},
+			q.Handler(handler.EnqueueRequestsFromMapFunc(r.bundlesForClusterLock))).
+		Watches(&source.Channel{Source: r.ConfigChanges}, q.Handler(&handler.EnqueueRequestForObject{})).
+		// run more workers than the limiter admits, so that pending reconciles wait in
+		// the limiter and are admitted by priority
+		WithOptions(controller.Options{MaxConcurrentReconciles: 2 * r.MaxConcurrentReconciles})
+	if q != nil {
+		// the bundles themselves are enqueued by For, only observe them
+		b = b.Watches(&source.Kind{Type: &appv1alpha1.AppBundle{}}, q.Observer(PlacementLabel),
+			builder.WithPredicates(r.Shard.Predicate()))
+	}
+	return b.Complete(r)
 }
 
-func getPlacementLabel(bundle appv1alpha1.AppBundle) *string {
-	l, ok := bundle.GetLabels()[PlacementLabel]
-	if ok {
-		return &l
+// bundlesForPlacementDecision maps a placement decision to the bundles in its
+// namespace referencing the same placement, or falling back to it
+func (r *AppBundleReconciler) bundlesForPlacementDecision(obj client.Object) []reconcile.Request {
+	placementName, ok := obj.GetLabels()[PlacementLabel]
+	if !ok {
+		return nil
+	}
+	// the bundles of the AllClusters policy or falling back to the placement have no
+	// placement label
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(context.TODO(), &bundles, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.Errorf("Failed to list AppBundles for placement %s: %v", placementName, err)
+		return nil
 	}
-	return nil
+	requests := []reconcile.Request{}
+	for _, bundle := range bundles.Items {
+		if name, _ := PlacementName(&bundle); name != placementName && bundle.Spec.FallbackPlacement != placementName {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+		})
+	}
+	return requests
 }
 
-func (r *AppBundleReconciler) getPlacementDecision(placementName, placementNamespace string) (*clusterapiv1alpha1.PlacementDecision, error) {
-	klog.Infof("Namespace: %s", placementNamespace)
-	pReq, _ := labels.NewRequirement(PlacementLabel, selection.Equals, []string{placementName})
-	selector := labels.NewSelector()
-	selector = selector.Add(*pReq)
-	pList, err := r.PlacementDecisionLister.PlacementDecisions(placementNamespace).List(selector)
+// bundlesForManagedCluster maps a managed cluster to the bundles reporting it in status,
+// so that bundles are cleaned up when the cluster is detached from the hub
+func (r *AppBundleReconciler) bundlesForManagedCluster(obj client.Object) []reconcile.Request {
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(context.TODO(), &bundles); err != nil {
+		klog.Errorf("Failed to list AppBundles for cluster %s: %v", obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, bundle := range bundles.Items {
+		for _, c := range bundle.Status.Clusters {
+			if c.ClusterName == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// getPlacementDecision returns the clusters decided by all the PlacementDecisions of
+// the placement, read from the placement index of the decisions
+func (r *AppBundleReconciler) getPlacementDecision(placementName, placementNamespace string) (decisions.Decision, error) {
+	decision, ok := r.Decisions.Get(placementNamespace, placementName)
+	if !ok {
+		return decision, apierrors.NewNotFound(clusterapiv1alpha1.Resource("placementdecisions"), placementName)
+	}
+	return decision, nil
+}
+
+// placementDecisionFound returns the message of the PlacementResolved condition
+func placementDecisionFound(decision decisions.Decision) string {
+	if len(decision.Names) == 1 {
+		return "Placement decision " + decision.Names[0] + " found"
+	}
+	return "Placement decisions " + strings.Join(decision.Names, ", ") + " found"
+}
+
+// getTargetClusters returns the names of the decided clusters which are still registered
+// with the hub. Clusters being detached are skipped, as their namespace and works are
+// going away.
+func (r *AppBundleReconciler) getTargetClusters(decision decisions.Decision) ([]string, error) {
+	clusters := []string{}
+	for _, name := range decision.Clusters {
+		cluster, err := r.ManagedClusterLister.Get(name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				klog.Infof("Cluster %s is not registered, skipping", name)
+				continue
+			}
+			return nil, err
+		}
+		if isClusterDeregistering(cluster) {
+			klog.Infof("Cluster %s is being detached, skipping", name)
+			continue
+		}
+		clusters = append(clusters, name)
+	}
+	return clusters, nil
+}
+
+func isClusterDeregistering(cluster *clusterapiv1.ManagedCluster) bool {
+	return !cluster.DeletionTimestamp.IsZero() || !cluster.Spec.HubAcceptsClient
+}
+
+// clusterStatuses returns the status of the clusters of the bundle. The deferred,
+// blocked, held and waiting clusters keep their previous status, if any, the waiting ones
+// listing the bundles they wait for, with the conditions of their work when observed.
+func clusterStatuses(bundle appv1alpha1.AppBundle, clusters []string, prov *appv1alpha1.Provenance, scheduled *scheduleResult) []appv1alpha1.ClusterStatus {
+	sorted := append([]string{}, clusters...)
+	sort.Strings(sorted)
+	unchanged := sets.NewString(scheduled.deferred...).Insert(scheduled.blocked...).Insert(scheduled.held...).
+		Insert(scheduled.failed...)
+	for c := range scheduled.denied {
+		unchanged.Insert(c)
+	}
+	for c := range scheduled.waiting {
+		unchanged.Insert(c)
+	}
+	for c := range scheduled.insufficient {
+		unchanged.Insert(c)
+	}
+	previous := map[string]appv1alpha1.ClusterStatus{}
+	for _, c := range bundle.Status.Clusters {
+		previous[c.ClusterName] = c
+	}
+	statuses := []appv1alpha1.ClusterStatus{}
+	for _, c := range sorted {
+		if unchanged.Has(c) {
+			p, ok := previous[c]
+			if conditions, observed := scheduled.conditions[c]; ok && observed {
+				p.Conditions = conditions
+			}
+			if p.Waiting = scheduled.waiting[c]; len(p.Waiting) > 0 {
+				p.ClusterName, ok = c, true
+			}
+			if p.Insufficient = scheduled.insufficient[c]; len(p.Insufficient) > 0 {
+				p.ClusterName, ok = c, true
+			}
+			if ok {
+				statuses = append(statuses, p)
+			}
+			continue
+		}
+		secretsHash, ok := scheduled.secrets[c]
+		if !ok {
+			// the work is not written, the values distributed are unchanged
+			secretsHash = previous[c].SecretsHash
+		}
+		statuses = append(statuses, appv1alpha1.ClusterStatus{
+			ClusterName:  c,
+			WorkName:     WorkName(&bundle),
+			Digest:       prov.Digest,
+			Conditions:   scheduled.conditions[c],
+			Incompatible: scheduled.incompatible[c],
+			Requests:     scheduled.requests[c],
+			SecretsHash:  secretsHash,
+		})
+	}
+	return statuses
+}
+
+// recordProvenance computes the digest, and the signature when a signer is configured,
+// of the manifests rendered for the bundle
+func (r *AppBundleReconciler) recordProvenance(bundle appv1alpha1.AppBundle, manifests []workapiv1.Manifest) (*appv1alpha1.Provenance, error) {
+	payload, err := provenance.Payload(manifests)
 	if err != nil {
 		return nil, err
 	}
-	if len(pList) >= 1 {
-		return pList[0], nil
+	prov := &appv1alpha1.Provenance{
+		Generation: bundle.Generation,
+		Digest:     provenance.Digest(payload),
+		SignedBy:   r.Identity,
+		ModifiedBy: audit.ModifiedBy(&bundle),
+	}
+	if r.Signer != nil {
+		if prov.Signature, err = r.Signer.Sign(payload); err != nil {
+			return nil, fmt.Errorf("failed to sign content of AppBundle %s: %w", bundle.Name, err)
+		}
+	}
+	return prov, nil
+}
+
+// reportDistributed records an event naming the generation distributed to created or
+// updated works and the user who changed it
+func (r *AppBundleReconciler) reportDistributed(bundle *appv1alpha1.AppBundle, prov *appv1alpha1.Provenance, actions []appv1alpha1.ClusterAction) {
+	changed := 0
+	for _, a := range actions {
+		if a.Action == appv1alpha1.ClusterActionCreated || a.Action == appv1alpha1.ClusterActionUpdated {
+			changed++
+		}
 	}
-	return nil, fmt.Errorf("Could not find placement decision for placement %s ", placementName)
+	if changed == 0 {
+		return
+	}
+	modifiedBy := prov.ModifiedBy
+	if modifiedBy == "" {
+		modifiedBy = "unknown"
+	}
+	r.Recorder.Eventf(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonDistributed,
+		"Distributed generation %d changed by %s to %d clusters", prov.Generation, modifiedBy, changed)
+}
+
+// scheduleResult is the outcome of the distribution of a bundle to its clusters
+type scheduleResult struct {
+	actions []appv1alpha1.ClusterAction
+	diff    appv1alpha1.ManifestDiff
+	// conditions of the works, by cluster
+	conditions map[string][]v1.Condition
+	// deferred lists the clusters not changed as their change budget is exhausted
+	deferred []string
+	// blocked lists the clusters not changed as they are locked
+	blocked []string
+	// held lists the clusters not changed as their wave of the rollout has not started
+	held []string
+	// denied lists the clusters not changed as a plugin denies their manifests, with
+	// the reason
+	denied map[string]string
+	// incompatible lists the resources not distributed to the clusters as their API
+	// version is removed on the cluster
+	incompatible map[string][]string
+	// written records when the content of the work of each changed or unchanged
+	// cluster was last written
+	written map[string]time.Time
+	// generations are the generations of the works of the changed or unchanged
+	// clusters, unset for the created works
+	generations map[string]int64
+	// waiting lists the clusters not changed as bundles they require are not
+	// Available on them, with the required bundles
+	waiting map[string][]string
+	// insufficient lists the clusters not changed as they do not fit the resource
+	// requests, with the resources they lack
+	insufficient map[string][]string
+	// requests are the resources requested on each cluster when the resource-aware
+	// gating is enabled
+	requests map[string]corev1.ResourceList
+	// pruned and orphaned list the resources removed from the updated works
+	pruned, orphaned sets.String
+	// components lists the clusters where the changes of components wait for the
+	// components they depend on, with the waiting components
+	components map[string][]string
+	// secrets are the hashes of the secret values written to the clusters
+	secrets map[string]string
+	// failed lists the clusters not changed as their work failed to be written, which
+	// are retried by the next reconcile
+	failed []string
 }
 
-func (r *AppBundleReconciler) scheduleBundle(bundle appv1alpha1.AppBundle, decision *clusterapiv1alpha1.PlacementDecision) error {
-	for _, dec := range decision.Status.Decisions {
-		klog.Infof("Generating manifest for cluster %s", dec.ClusterName)
-		manifest := generateManifest(bundle, dec.ClusterName)
+// updated returns true if works were updated with a changed content
+func (s *scheduleResult) updated() bool {
+	for _, a := range s.actions {
+		if a.Action == appv1alpha1.ClusterActionUpdated {
+			return true
+		}
+	}
+	return false
+}
 
-		existingManifest, err := r.WorkClient.WorkV1().ManifestWorks(dec.ClusterName).Get(context.TODO(), manifest.Name, v1.GetOptions{})
+// scheduleBundle writes the work of the bundle to each of the clusters, templated with
+// the outputs of its Crossplane components. Failing to render, read or write the work of
+// a cluster, to check its change budget, to prune its manifests or to record its history
+// or diff does not stop the others, the errors being returned together with the result.
+func (r *AppBundleReconciler) scheduleBundle(ctx context.Context, bundle appv1alpha1.AppBundle, workload []workapiv1.Manifest, outputs map[string]map[string]string, prov *appv1alpha1.Provenance, cfg *appv1alpha1.KealmConfigSpec, clusters []string, blueGreen *blueGreenPlan) (*scheduleResult, error) {
+	result := &scheduleResult{
+		actions:      []appv1alpha1.ClusterAction{},
+		conditions:   map[string][]v1.Condition{},
+		denied:       map[string]string{},
+		incompatible: map[string][]string{},
+		written:      map[string]time.Time{},
+		generations:  map[string]int64{},
+		pruned:       sets.NewString(),
+		orphaned:     sets.NewString(),
+		components:   map[string][]string{},
+		secrets:      map[string]string{},
+	}
+	diff := newDiffAccumulator()
+	var errs []error
+	retained, retainedRules, err := retention(&bundle, cfg, workload)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := plugins.Load(cfg.DistributionPlugins, r.WASMRuntime)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := r.workWriter(ctx, bundle.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	// the span of each cluster ends when the next cluster starts, or on return
+	var span trace.Span
+	defer func() { tracing.End(span, nil) }()
+	// failCluster records the error of a cluster whose work is not written, retried by
+	// the next reconcile
+	failCluster := func(clusterName string, err error) {
+		errs = append(errs, err)
+		result.failed = append(result.failed, clusterName)
+		tracing.RecordError(span, err)
+	}
+	for _, clusterName := range clusters {
+		tracing.End(span, nil)
+		var clusterCtx context.Context
+		clusterCtx, span = r.tracer().Start(ctx, "AppBundle.Apply",
+			trace.WithAttributes(attribute.String("cluster.name", clusterName)))
+		klog.Infof("Generating manifest for cluster %s", clusterName)
+		renderCtx, renderSpan := r.tracer().Start(clusterCtx, "AppBundle.RenderCluster")
+		clusterManifests, clusterDigest, incompatible, err := r.clusterManifests(renderCtx, &bundle, clusterName, workload, chain, outputs)
+		tracing.End(renderSpan, err)
+		var denied *plugins.DeniedError
+		if errors.As(err, &denied) {
+			result.denied[clusterName] = denied.Error()
+			continue
+		}
 		if err != nil {
-			if apierrors.IsNotFound(err) {
-				klog.Infof("Creating manifest for cluster %s", dec.ClusterName)
-				_, err = r.WorkClient.WorkV1().ManifestWorks(dec.ClusterName).Create(context.TODO(), manifest, v1.CreateOptions{})
+			failCluster(clusterName, fmt.Errorf("failed to generate manifest for cluster %s: %w", clusterName, err))
+			continue
+		}
+		if len(incompatible) > 0 {
+			result.incompatible[clusterName] = incompatible
+		}
+		secretsHash, err := r.secretsHash(ctx, &bundle, clusterName)
+		if err != nil {
+			failCluster(clusterName, fmt.Errorf("failed to hash the secret values of cluster %s: %w", clusterName, err))
+			continue
+		}
+		if clusterManifests, err = bandwidthManifests(&bundle, clusterManifests); err != nil {
+			failCluster(clusterName, renderFailed(clusterName, err))
+			continue
+		}
+		clusterManifests, held, err := r.componentManifests(ctx, &bundle, clusterName, clusterManifests)
+		if err != nil {
+			failCluster(clusterName, renderFailed(clusterName, err))
+			continue
+		}
+		if len(held) > 0 {
+			result.components[clusterName] = held
+		}
+		clusterManifests, resynced, err := resyncComponents(&bundle, clusterName, clusterManifests)
+		if err != nil {
+			failCluster(clusterName, renderFailed(clusterName, err))
+			continue
+		}
+		if blueGreen != nil {
+			if clusterManifests, err = r.blueGreenManifests(ctx, &bundle, blueGreen, clusterName, clusterManifests); err != nil {
+				failCluster(clusterName, renderFailed(clusterName, err))
+				continue
+			}
+		}
+		// the works are written in a stable order and encoding, whatever produced them
+		if clusterManifests, err = manifests.Canonical(clusterManifests); err != nil {
+			failCluster(clusterName, renderFailed(clusterName, err))
+			continue
+		}
+		manifest := generateManifest(bundle, clusterManifests, cfg, clusterName, prov, clusterDigest)
+		addOrphaningRules(manifest, retainedRules)
+		if tp := tracing.Traceparent(clusterCtx); tp != "" {
+			manifest.Annotations[TraceparentAnnotation] = tp
+		}
+		if resynced != "" {
+			manifest.Annotations[ComponentResyncAnnotation] = resynced
+		}
+
+		existingManifest, err := r.WorkClient.WorkV1().ManifestWorks(clusterName).Get(ctx, manifest.Name, v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			exhausted, budgetErr := r.changeBudgetExhausted(ctx, cfg, clusterName, manifest.Name)
+			if budgetErr != nil {
+				failCluster(clusterName, fmt.Errorf("failed to check the change budget of cluster %s: %w", clusterName, budgetErr))
+				continue
+			}
+			if exhausted {
+				result.deferred = append(result.deferred, clusterName)
+				continue
+			}
+			klog.Infof("Creating manifest for cluster %s", clusterName)
+			if err := waitForWrite(r.WriteLimiter); err != nil {
+				failCluster(clusterName, fmt.Errorf("failed to wait to create manifest for cluster %s: %w", clusterName, err))
+				continue
+			}
+			written := v1.Now().Rfc3339Copy()
+			manifest.Annotations[WrittenAtAnnotation] = written.UTC().Format(time.RFC3339)
+			// a concurrent reconcile may have created the work since it was read, which
+			// is then updated instead
+			existingManifest, err = works.Create(ctx, writer, r.WorkClient.WorkV1(), manifest)
+			if err == nil && existingManifest == nil {
+				result.actions = append(result.actions, appv1alpha1.ClusterAction{ClusterName: clusterName, Action: appv1alpha1.ClusterActionCreated})
+				result.written[clusterName] = written.Time
+				result.secrets[clusterName] = secretsHash
+				// the work is written, the history recorded by the next change
+				if err := r.recordHistory(ctx, &bundle, cfg, clusterName, manifest); err != nil {
+					errs = append(errs, fmt.Errorf("failed to record the history of cluster %s: %w", clusterName, err))
+					tracing.RecordError(span, err)
+				}
+				// the work is written, only its diff is missing from the audit trail
+				_, diffSpan := r.tracer().Start(clusterCtx, "AppBundle.Diff")
+				err := diff.add(nil, clusterManifests)
+				tracing.End(diffSpan, err)
 				if err != nil {
-					return err
+					errs = append(errs, fmt.Errorf("failed to diff the manifests of cluster %s: %w", clusterName, err))
+					tracing.RecordError(span, err)
 				}
+				span.SetAttributes(attribute.String("action", string(appv1alpha1.ClusterActionCreated)))
+				continue
+			}
+		}
+		if err != nil {
+			// the other clusters are still written, the errors are returned together
+			failCluster(clusterName, fmt.Errorf("failed to write manifest for cluster %s: %w", clusterName, faults.WorkWrite(err)))
+			continue
+		}
+
+		result.conditions[clusterName] = existingManifest.Status.Conditions
+		result.generations[clusterName] = existingManifest.Generation
+		if written, err := time.Parse(time.RFC3339, existingManifest.Annotations[WrittenAtAnnotation]); err == nil {
+			r.ApplyLatency.Observe(types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+				clusterName, written, existingManifest.Status.Conditions)
+		}
+		changed := existingManifest.Annotations[DigestAnnotation] != prov.Digest ||
+			existingManifest.Annotations[ClusterDigestAnnotation] != clusterDigest
+		if changed {
+			exhausted, err := r.changeBudgetExhausted(ctx, cfg, clusterName, manifest.Name)
+			if err != nil {
+				failCluster(clusterName, fmt.Errorf("failed to check the change budget of cluster %s: %w", clusterName, err))
+				continue
+			}
+			if exhausted {
+				result.deferred = append(result.deferred, clusterName)
 				continue
-			} else {
-				return err
 			}
 		}
 
@@ -189,46 +1092,169 @@ func (r *AppBundleReconciler) scheduleBundle(bundle appv1alpha1.AppBundle, decis
 		newManifest.Spec = manifest.Spec
 		newManifest.Labels = manifest.Labels
 		newManifest.Annotations = manifest.Annotations
-		klog.Infof("Updating manifest for cluster %s", dec.ClusterName)
-		_, err = r.WorkClient.WorkV1().ManifestWorks(dec.ClusterName).Update(context.TODO(), newManifest, v1.UpdateOptions{})
+		if !changed {
+			// the unchanged content keeps the time and the trace it was written with
+			for _, k := range []string{WrittenAtAnnotation, TraceparentAnnotation} {
+				if v, ok := existingManifest.Annotations[k]; ok {
+					newManifest.Annotations[k] = v
+				} else {
+					delete(newManifest.Annotations, k)
+				}
+			}
+		}
+		pruned, orphaned, err := pruneRemoved(&bundle, retained, existingManifest, newManifest)
+		if err != nil {
+			failCluster(clusterName, fmt.Errorf("failed to prune the manifests of cluster %s: %w", clusterName, err))
+			continue
+		}
+		result.pruned.Insert(pruned...)
+		result.orphaned.Insert(orphaned...)
+		if bundle.Spec.Bandwidth != nil && !changed && unchangedWork(existingManifest, newManifest) {
+			if written, err := time.Parse(time.RFC3339, existingManifest.Annotations[WrittenAtAnnotation]); err == nil {
+				result.written[clusterName] = written
+			}
+			result.secrets[clusterName] = secretsHash
+			continue
+		}
+		klog.Infof("Updating manifest for cluster %s", clusterName)
+		if err := waitForWrite(r.WriteLimiter); err != nil {
+			failCluster(clusterName, fmt.Errorf("failed to wait to update manifest for cluster %s: %w", clusterName, err))
+			continue
+		}
+		if _, ok := newManifest.Annotations[WrittenAtAnnotation]; !ok {
+			newManifest.Annotations[WrittenAtAnnotation] = v1.Now().UTC().Format(time.RFC3339)
+		}
+		updated, err := writer.ManifestWorks(clusterName).Update(ctx, newManifest, v1.UpdateOptions{})
 		if err != nil {
-			return err
+			failCluster(clusterName, fmt.Errorf("failed to update manifest for cluster %s: %w", clusterName, faults.WorkWrite(err)))
+			continue
+		}
+		result.generations[clusterName] = updated.Generation
+		result.secrets[clusterName] = secretsHash
+		if written, err := time.Parse(time.RFC3339, newManifest.Annotations[WrittenAtAnnotation]); err == nil {
+			result.written[clusterName] = written
+		}
+		if changed {
+			result.actions = append(result.actions, appv1alpha1.ClusterAction{ClusterName: clusterName, Action: appv1alpha1.ClusterActionUpdated})
+			if err := r.recordHistory(ctx, &bundle, cfg, clusterName, newManifest); err != nil {
+				errs = append(errs, fmt.Errorf("failed to record the history of cluster %s: %w", clusterName, err))
+				tracing.RecordError(span, err)
+			}
+			_, diffSpan := r.tracer().Start(clusterCtx, "AppBundle.Diff")
+			err := diff.add(existingManifest.Spec.Workload.Manifests, clusterManifests)
+			tracing.End(diffSpan, err)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to diff the manifests of cluster %s: %w", clusterName, err))
+				tracing.RecordError(span, err)
+			}
+			span.SetAttributes(attribute.String("action", string(appv1alpha1.ClusterActionUpdated)))
 		}
 	}
-	return nil
+	result.diff = diff.result()
+	return result, faults.Aggregate(errs)
+}
+
+// renderFailed returns the error of the manifests of a cluster failing to render, a user
+// error
+func renderFailed(clusterName string, err error) error {
+	return faults.New(appv1alpha1.ReasonRenderFailed, fmt.Errorf("failed to render the manifests of cluster %s: %w", clusterName, err))
 }
 
-func generateManifest(bundle appv1alpha1.AppBundle, namespace string) *workapiv1.ManifestWork {
-	manifest := &workapiv1.ManifestWork{
+// generateManifest returns the work of the bundle for a cluster, with new labels and
+// annotations built from the bundle and the provenance of the content
+func generateManifest(bundle appv1alpha1.AppBundle, manifests []workapiv1.Manifest, cfg *appv1alpha1.KealmConfigSpec, namespace string, prov *appv1alpha1.Provenance, clusterDigest string) *workapiv1.ManifestWork {
+	spec := bundle.Spec.ManifestWorkSpec.DeepCopy()
+	spec.Workload.Manifests = manifests
+	if spec.DeleteOption == nil && cfg.DeleteOption != nil {
+		spec.DeleteOption = cfg.DeleteOption.DeepCopy()
+	}
+	digest := prov.Digest
+	if clusterDigest != "" {
+		digest = clusterDigest
+	}
+	return &workapiv1.ManifestWork{
 		TypeMeta: v1.TypeMeta{
 			Kind:       "ManifestWork",
 			APIVersion: workapiv1.GroupVersion.Version,
 		},
 		ObjectMeta: v1.ObjectMeta{
-			Name:        bundle.Name,
-			Namespace:   bundle.Namespace,
-			Labels:      bundle.Labels,
-			Annotations: bundle.Annotations,
+			Name:        WorkName(&bundle),
+			Namespace:   namespace,
+			Labels:      workLabels(&bundle, cfg, digest),
+			Annotations: workAnnotations(&bundle, cfg, prov, clusterDigest),
 		},
-		Spec: bundle.Spec,
+		Spec: *spec,
+	}
+}
+
+// deleteAllChildManifests deletes the works owned by the bundle and returns the
+// locked clusters where works are left
+func (r *AppBundleReconciler) deleteAllChildManifests(bundle *appv1alpha1.AppBundle, locked sets.String) ([]string, error) {
+	// the works are listed from the API, the informer missing the works just written
+	// would leave them without owner once the finalizer is removed
+	mList, err := r.listOwnedWorks(context.TODO(), bundle)
+	if err != nil {
+		return nil, err
+	}
+	actions, blocked, err := r.deleteChildManifests(bundle, mList, nil, locked)
+	if err != nil {
+		return nil, err
+	}
+	// do not block the deletion of the bundle when the audit record cannot be stored,
+	// e.g. because its namespace is being deleted
+	if err := r.recordAudit(context.TODO(), bundle, "", appv1alpha1.ManifestDiff{}, actions); err != nil {
+		klog.Errorf("Failed to record audit for deleted AppBundle %s: %v", bundle.Name, err)
+	}
+	return blocked, nil
+}
+
+// deleteStaleChildManifests deletes the works owned by the bundle in any cluster namespace
+// not listed in clusters, and retires the legacy works replaced in the listed ones. The
+// works of the locked clusters are left untouched, these clusters are returned.
+func (r *AppBundleReconciler) deleteStaleChildManifests(bundle *appv1alpha1.AppBundle, clusters []string, locked sets.String) ([]appv1alpha1.ClusterAction, []string, error) {
+	mList, err := r.ownedWorks(context.TODO(), bundle)
+	if err != nil {
+		return nil, nil, err
 	}
-	manifest.Namespace = namespace
-	manifest.Labels[OwnedLabel] = string(bundle.UID)
-	return manifest
+	return r.deleteChildManifests(bundle, mList, clusters, locked)
 }
 
-func (r *AppBundleReconciler) deleteAllChildManifests(bundle *appv1alpha1.AppBundle) error {
-	req, _ := labels.NewRequirement(OwnedLabel, selection.Equals, []string{string(bundle.UID)})
-	selector := labels.NewSelector()
-	selector = selector.Add(*req)
-	mList, err := r.WorkClient.WorkV1().ManifestWorks("").List(context.TODO(), v1.ListOptions{LabelSelector: selector.String()})
+// deleteChildManifests deletes the works of the list in the clusters not kept
+func (r *AppBundleReconciler) deleteChildManifests(bundle *appv1alpha1.AppBundle, mList *workapiv1.ManifestWorkList, clusters []string, locked sets.String) ([]appv1alpha1.ClusterAction, []string, error) {
+	writer, err := r.workWriter(context.TODO(), bundle.Namespace)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	for _, m := range mList.Items {
-		if err := r.WorkClient.WorkV1().ManifestWorks(m.Namespace).Delete(context.TODO(), m.Name, v1.DeleteOptions{}); err != nil {
-			return err
+	actions := []appv1alpha1.ClusterAction{}
+	blocked := sets.NewString()
+	keep := sets.NewString(clusters...)
+	for i := range mList.Items {
+		m := &mList.Items[i]
+		if locked.Has(m.Namespace) {
+			if !keep.Has(m.Namespace) || isLegacyWork(bundle, m) {
+				blocked.Insert(m.Namespace)
+			}
+			continue
+		}
+		if keep.Has(m.Namespace) {
+			if isLegacyWork(bundle, m) {
+				if err := r.retireLegacyWork(bundle, m); err != nil && !apierrors.IsNotFound(err) {
+					return actions, blocked.List(), err
+				}
+			}
+			continue
+		}
+		klog.Infof("Deleting manifest %s for cluster %s", m.Name, m.Namespace)
+		if err := waitForWrite(r.WriteLimiter); err != nil {
+			return actions, blocked.List(), err
+		}
+		if err := writer.ManifestWorks(m.Namespace).Delete(context.TODO(), m.Name, v1.DeleteOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return actions, blocked.List(), err
 		}
+		actions = append(actions, appv1alpha1.ClusterAction{ClusterName: m.Namespace, Action: appv1alpha1.ClusterActionDeleted})
 	}
-	return nil
+	return actions, blocked.List(), nil
 }
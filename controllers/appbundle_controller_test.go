@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/decisions"
+)
+
+// addRegisteredClusters adds cluster1, registered, cluster2, no longer accepted by the
+// hub, and cluster3, being deleted, to the fixture. cluster4 is not registered.
+func addRegisteredClusters(f *fixture) {
+	now := v1.Now()
+	f.add(f.clusters,
+		&clusterv1.ManagedCluster{ObjectMeta: v1.ObjectMeta{Name: "cluster1"}, Spec: clusterv1.ManagedClusterSpec{HubAcceptsClient: true}},
+		&clusterv1.ManagedCluster{ObjectMeta: v1.ObjectMeta{Name: "cluster2"}},
+		&clusterv1.ManagedCluster{ObjectMeta: v1.ObjectMeta{Name: "cluster3", DeletionTimestamp: &now}, Spec: clusterv1.ManagedClusterSpec{HubAcceptsClient: true}},
+	)
+}
+
+// placementDecision returns the decision of the placement for the clusters
+func placementDecision(namespace, placement string, clusters ...string) *clusterv1alpha1.PlacementDecision {
+	decision := &clusterv1alpha1.PlacementDecision{ObjectMeta: v1.ObjectMeta{Name: placement + "-decision-1", Namespace: namespace,
+		Labels: map[string]string{PlacementLabel: placement}}}
+	for _, c := range clusters {
+		decision.Status.Decisions = append(decision.Status.Decisions, clusterv1alpha1.ClusterDecision{ClusterName: c})
+	}
+	return decision
+}
+
+func TestGetTargetClusters(t *testing.T) {
+	f := newFixture(t)
+	addRegisteredClusters(f)
+	r := f.reconciler()
+	tests := []struct {
+		name    string
+		decided []string
+		want    []string
+	}{
+		{"registered", []string{"cluster1"}, []string{"cluster1"}},
+		{"not accepted", []string{"cluster1", "cluster2"}, []string{"cluster1"}},
+		{"being deleted", []string{"cluster3", "cluster1"}, []string{"cluster1"}},
+		{"not registered", []string{"cluster4"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters, err := r.getTargetClusters(decisions.Decision{Clusters: tt.decided})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(clusters, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, clusters)
+			}
+		})
+	}
+}
+
+func TestReconcileDeregisteredClusters(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "default", UID: "uid",
+		Labels: map[string]string{PlacementLabel: "fleet"}}}
+	bundle.Spec.Workload.Manifests = []workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: []byte(
+		`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"shop","namespace":"default"}}`)}}}
+	// the bundle was distributed to the clusters before they left the hub
+	for _, c := range []string{"cluster2", "cluster3", "cluster4"} {
+		bundle.Status.Clusters = append(bundle.Status.Clusters, appv1alpha1.ClusterStatus{ClusterName: c})
+	}
+	f := newFixture(t, bundle)
+	addRegisteredClusters(f)
+	f.add(f.placements, &clusterv1alpha1.Placement{ObjectMeta: v1.ObjectMeta{Name: "fleet", Namespace: "default"}})
+	f.add(f.decisions, placementDecision("default", "fleet", "cluster1", "cluster2", "cluster3", "cluster4"))
+	for _, c := range []string{"cluster2", "cluster3", "cluster4"} {
+		if _, err := f.works.WorkV1().ManifestWorks(c).Create(context.TODO(), &workapiv1.ManifestWork{
+			ObjectMeta: v1.ObjectMeta{Name: WorkName(bundle), Namespace: c, Labels: map[string]string{OwnedLabel: "uid"}},
+		}, v1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := f.reconciler()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "shop"}}
+
+	// the bundles reporting a cluster are reconciled when it changes
+	requests := r.bundlesForManagedCluster(&clusterv1.ManagedCluster{ObjectMeta: v1.ObjectMeta{Name: "cluster2"}})
+	if expected := []reconcile.Request{req}; !reflect.DeepEqual(requests, expected) {
+		t.Errorf("expected %v, got %v", expected, requests)
+	}
+
+	result, err := r.Reconcile(context.TODO(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.works.WorkV1().ManifestWorks("cluster1").Get(context.TODO(), WorkName(bundle), v1.GetOptions{}); err != nil {
+		t.Errorf("expected the work of the registered cluster to be written: %v", err)
+	}
+	for _, c := range []string{"cluster2", "cluster3", "cluster4"} {
+		if _, err := f.works.WorkV1().ManifestWorks(c).Get(context.TODO(), WorkName(bundle), v1.GetOptions{}); !apierrors.IsNotFound(err) {
+			t.Errorf("expected the work of %s to be removed, got %v", c, err)
+		}
+	}
+	current := &appv1alpha1.AppBundle{}
+	if err := r.Get(context.TODO(), req.NamespacedName, current); err != nil {
+		t.Fatal(err)
+	}
+	// a bundle without rollout has no plan to publish or to poll
+	if current.Status.Plan != nil || result.RequeueAfter == waveStartDelay || result.RequeueAfter == rolloutCheck {
+		t.Errorf("expected no rollout plan, got %+v requeued after %s", current.Status.Plan, result.RequeueAfter)
+	}
+	clusters := []string{}
+	for _, c := range current.Status.Clusters {
+		clusters = append(clusters, c.ClusterName)
+	}
+	if !reflect.DeepEqual(clusters, []string{"cluster1"}) {
+		t.Errorf("expected only the registered cluster in the status, got %v", clusters)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+const (
+	// DrainStartedAnnotation records when the drain of a work started
+	DrainStartedAnnotation = "cluster.open-cluster-management.io/drain-started-at"
+
+	// defaultDrainGracePeriod is waited after the drained works are applied
+	defaultDrainGracePeriod = time.Minute
+	// defaultDrainTimeout bounds the drain of the works
+	defaultDrainTimeout = 10 * time.Minute
+	// drainRetry is the delay before checking again the works being drained
+	drainRetry = 10 * time.Second
+)
+
+// drainStaleWorks drains the works of the bundle in the cluster namespaces not listed
+// in clusters, except the locked ones. It returns the clusters whose works are still
+// draining, to be kept until drained, and when to check them again.
+func (r *AppBundleReconciler) drainStaleWorks(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string, locked sets.String) ([]string, time.Duration, error) {
+	d := bundle.Spec.Drain
+	if d == nil {
+		return nil, 0, nil
+	}
+	works, err := r.ownedWorks(ctx, bundle)
+	if err != nil {
+		return nil, 0, err
+	}
+	grace, timeout := defaultDrainGracePeriod, defaultDrainTimeout
+	if d.GracePeriod != nil {
+		grace = d.GracePeriod.Duration
+	}
+	if d.Timeout != nil {
+		timeout = d.Timeout.Duration
+	}
+	keep := sets.NewString(clusters...)
+	draining := []string{}
+	requeue := time.Duration(0)
+	for i := range works.Items {
+		w := &works.Items[i]
+		if keep.Has(w.Namespace) || locked.Has(w.Namespace) || isLegacyWork(bundle, w) {
+			continue
+		}
+		started, err := time.Parse(time.RFC3339, w.Annotations[DrainStartedAnnotation])
+		if err != nil {
+			if err := r.startDrain(ctx, bundle, w); err != nil {
+				return nil, 0, err
+			}
+			draining = append(draining, w.Namespace)
+			requeue = drainRetry
+			continue
+		}
+		remaining := time.Until(started.Add(grace))
+		if time.Since(started) < timeout && (isWorkChanging(w) || remaining > 0) {
+			draining = append(draining, w.Namespace)
+			if remaining < drainRetry {
+				remaining = drainRetry
+			}
+			requeue = minRequeue(requeue, remaining)
+		}
+	}
+	return draining, requeue, nil
+}
+
+// startDrain updates the work with the drained manifests
+func (r *AppBundleReconciler) startDrain(ctx context.Context, bundle *appv1alpha1.AppBundle, work *workapiv1.ManifestWork) error {
+	d := bundle.Spec.Drain
+	drained := work.DeepCopy()
+	ms := drained.Spec.Workload.Manifests
+	if d.ScaleToZero {
+		zero := int32(0)
+		var err error
+		if ms, err = manifests.Scale(ms, &appv1alpha1.ScalingRule{Replicas: &zero}, nil); err != nil {
+			return err
+		}
+	}
+	if d.Hook != nil {
+		ms = append(ms, *d.Hook)
+	}
+	drained.Spec.Workload.Manifests = ms
+	if drained.Annotations == nil {
+		drained.Annotations = map[string]string{}
+	}
+	drained.Annotations[DrainStartedAnnotation] = v1.Now().UTC().Format(time.RFC3339)
+	klog.Infof("Draining manifest %s for cluster %s", work.Name, work.Namespace)
+	if err := waitForWrite(r.WriteLimiter); err != nil {
+		return err
+	}
+	writer, err := r.workWriter(ctx, bundle.Namespace)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.ManifestWorks(work.Namespace).Update(ctx, drained, v1.UpdateOptions{}); err != nil {
+		return err
+	}
+	r.Recorder.Event(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonDraining,
+		fmt.Sprintf("Draining cluster %s before deleting its work", work.Namespace))
+	return nil
+}
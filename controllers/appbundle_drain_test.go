@@ -0,0 +1,125 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestDrainStaleWorks(t *testing.T) {
+	hook := workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(
+		`{"apiVersion":"batch/v1","kind":"Job","metadata":{"name":"deregister","namespace":"default"}}`)}}
+	deployment := workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(
+		`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"default"},"spec":{"replicas":3}}`)}}
+
+	tests := []struct {
+		name     string
+		cluster  string
+		started  time.Duration
+		applied  bool
+		locked   bool
+		draining bool
+		drained  bool
+		deleted  bool
+	}{
+		{name: "kept cluster", cluster: "cluster1"},
+		{name: "drain not started", cluster: "cluster2", draining: true, drained: true},
+		{name: "within grace period", cluster: "cluster2", started: 30 * time.Second, applied: true, draining: true},
+		{name: "drained manifests applying", cluster: "cluster2", started: 2 * time.Minute, draining: true},
+		{name: "drained", cluster: "cluster2", started: 2 * time.Minute, applied: true, deleted: true},
+		{name: "timed out", cluster: "cluster2", started: 20 * time.Minute, deleted: true},
+		{name: "locked cluster", cluster: "cluster2", locked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid"}}
+			bundle.Spec.Drain = &appv1alpha1.Drain{ScaleToZero: true, Hook: &hook}
+			work := &workapiv1.ManifestWork{ObjectMeta: v1.ObjectMeta{Name: WorkName(bundle), Namespace: tt.cluster,
+				Labels: map[string]string{OwnedLabel: "uid"}}}
+			work.Spec.Workload.Manifests = []workapiv1.Manifest{deployment}
+			if tt.started != 0 {
+				work.Annotations = map[string]string{DrainStartedAnnotation: time.Now().Add(-tt.started).UTC().Format(time.RFC3339)}
+			}
+			if tt.applied {
+				work.Status.Conditions = []v1.Condition{{Type: workapiv1.WorkApplied, Status: v1.ConditionTrue}}
+			}
+			f := newFixture(t)
+			if err := f.works.Tracker().Add(work); err != nil {
+				t.Fatal(err)
+			}
+			r := f.reconciler()
+			clusters := []string{"cluster1"}
+			locked := sets.NewString()
+			if tt.locked {
+				locked.Insert(tt.cluster)
+			}
+
+			draining, requeue, err := r.drainStaleWorks(context.TODO(), bundle, clusters, locked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.draining != (len(draining) == 1) {
+				t.Errorf("expected draining %v, got %v", tt.draining, draining)
+			}
+			if tt.draining && (requeue < drainRetry || requeue > time.Minute) {
+				t.Errorf("expected the drain to be checked again within the grace period, got %v", requeue)
+			}
+			if !tt.draining && requeue != 0 {
+				t.Errorf("expected no requeue, got %v", requeue)
+			}
+
+			current, err := f.works.WorkV1().ManifestWorks(tt.cluster).Get(context.TODO(), work.Name, v1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.drained {
+				ms := current.Spec.Workload.Manifests
+				if len(ms) != 2 || !strings.Contains(string(ms[0].Raw), `"replicas":0`) || string(ms[1].Raw) != string(hook.Raw) {
+					t.Errorf("expected the work scaled to zero with the hook, got %s", current.Spec.Workload.Manifests)
+				}
+				if current.Annotations[DrainStartedAnnotation] == "" {
+					t.Error("expected the start of the drain to be recorded")
+				}
+				if len(f.recorder.Events) != 1 {
+					t.Errorf("expected the drain to be reported, got %d events", len(f.recorder.Events))
+				}
+			} else if len(current.Spec.Workload.Manifests) != 1 || current.Annotations[DrainStartedAnnotation] != work.Annotations[DrainStartedAnnotation] {
+				t.Errorf("expected the work to be left, got %s", current.Spec.Workload.Manifests)
+			}
+
+			// the works still draining are kept, the others of the vacated clusters deleted
+			if _, _, err := r.deleteStaleChildManifests(bundle, append(clusters, draining...), locked); err != nil {
+				t.Fatal(err)
+			}
+			_, err = f.works.WorkV1().ManifestWorks(tt.cluster).Get(context.TODO(), work.Name, v1.GetOptions{})
+			if tt.deleted != apierrors.IsNotFound(err) {
+				t.Errorf("expected deleted %v, got %v", tt.deleted, err)
+			}
+		})
+	}
+}
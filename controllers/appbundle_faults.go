@@ -0,0 +1,62 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/faults"
+	"github.com/pdettori/kealm/pkg/metrics"
+)
+
+// reportedError is an error already reported in the status, events and metrics
+type reportedError struct {
+	error
+}
+
+func (e reportedError) Unwrap() error {
+	return e.error
+}
+
+// countFault counts a fault in the error metrics
+func countFault(err error) {
+	reason := faults.Reason(err)
+	metrics.BundleErrors.WithLabelValues(reason, faults.Category(reason)).Inc()
+}
+
+// reportFault reports a fault of the bundle in its Synced condition, in a warning event
+// and in the error metrics
+func (r *AppBundleReconciler) reportFault(bundle *appv1alpha1.AppBundle, err error) {
+	countFault(err)
+	reason := faults.Reason(err)
+	r.Recorder.Event(bundle, corev1.EventTypeWarning, reason, err.Error())
+	setCondition(bundle, appv1alpha1.ConditionSynced, v1.ConditionFalse, reason, err.Error())
+}
+
+// fail reports a fault of the bundle, updates its status and returns the error
+func (r *AppBundleReconciler) fail(ctx context.Context, bundle *appv1alpha1.AppBundle, err error) (ctrl.Result, error) {
+	r.reportFault(bundle, err)
+	if uerr := r.updateStatus(ctx, bundle); uerr != nil {
+		return ctrl.Result{}, uerr
+	}
+	return ctrl.Result{}, reportedError{err}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/globaldns"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// globalDNSRetry is the delay before checking again the clusters of a bundle not
+// routed to by its global record
+const globalDNSRetry = 30 * time.Second
+
+// reconcileGlobalDNS routes the global record of the bundle to the clusters it is
+// Available on, and deletes the DNSEndpoint when the bundle has no global record
+// anymore. It returns the delay before checking again the clusters not routed to.
+func (r *AppBundleReconciler) reconcileGlobalDNS(ctx context.Context, bundle *appv1alpha1.AppBundle) (time.Duration, error) {
+	g := bundle.Spec.GlobalDNS
+	endpoint := globaldns.Endpoint(globaldns.Name(bundle.Name), bundle.Namespace)
+	if g == nil {
+		if bundle.Status.GlobalDNS != nil {
+			err := r.Delete(ctx, endpoint)
+			if err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+				return 0, err
+			}
+			bundle.Status.GlobalDNS = nil
+		}
+		return 0, nil
+	}
+
+	clusters := []string{}
+	targets := []string{}
+	for _, clusterName := range globaldns.Routable(bundle.Status.Clusters) {
+		cluster, err := r.ManagedClusterLister.Get(clusterName)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		target, err := manifests.NewClusterContext(cluster).Render(g.Target)
+		if err != nil {
+			return 0, fmt.Errorf("failed to render the global DNS target of cluster %s: %w", clusterName, err)
+		}
+		if target == "" {
+			klog.Warningf("Skipping cluster %s without global DNS target for AppBundle %s/%s", clusterName, bundle.Namespace, bundle.Name)
+			continue
+		}
+		clusters = append(clusters, clusterName)
+		targets = append(targets, target)
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, endpoint, func() error {
+		endpoint.Object["spec"] = globaldns.Spec(g, targets)
+		return controllerutil.SetControllerReference(bundle, endpoint, r.Scheme)
+	}); err != nil {
+		return 0, err
+	}
+
+	var previous []string
+	if bundle.Status.GlobalDNS != nil {
+		previous = bundle.Status.GlobalDNS.Clusters
+	}
+	if strings.Join(previous, ",") != strings.Join(clusters, ",") {
+		r.Recorder.Eventf(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonGlobalDNSUpdated,
+			"Routing %s to clusters %s", g.Hostname, strings.Join(clusters, ", "))
+	}
+	bundle.Status.GlobalDNS = &appv1alpha1.GlobalDNSStatus{Clusters: clusters}
+	if len(clusters) < len(bundle.Status.Clusters) {
+		return globalDNSRetry, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/history"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// recordHistory records the spec of the work written to the cluster for the generation
+// of the bundle in its work history ConfigMap, when enabled
+func (r *AppBundleReconciler) recordHistory(ctx context.Context, bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, clusterName string, work *workapiv1.ManifestWork) error {
+	if cfg.WorkHistory == nil {
+		return nil
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: history.ConfigMapName(bundle.Name, clusterName), Namespace: bundle.Namespace},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		if cm.Annotations == nil {
+			cm.Annotations = map[string]string{}
+		}
+		cm.Labels[history.Label] = "true"
+		cm.Annotations[history.ClusterAnnotation] = clusterName
+		if err := history.Record(cm, bundle.Generation, &work.Spec, int(cfg.WorkHistory.Generations)); err != nil {
+			return err
+		}
+		return controllerutil.SetControllerReference(bundle, cm, r.Scheme)
+	})
+	return err
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/audit"
+	"github.com/pdettori/kealm/pkg/faults"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// HubAccessLabel is the name of the bundle a ServiceAccount, its token Secret or
+	// RoleBinding provisioning hub access belongs to, shortened with a hash when too long
+	HubAccessLabel = "cluster.open-cluster-management.io/hub-access"
+	// HubAccessClusterLabel is the cluster a ServiceAccount or token Secret provisioning
+	// hub access belongs to
+	HubAccessClusterLabel = "cluster.open-cluster-management.io/hub-access-cluster"
+
+	// defaultHubKubeconfigSecret is the default name of the Secret holding the
+	// kubeconfig of the hub on the clusters
+	defaultHubKubeconfigSecret = "hub-kubeconfig"
+
+	// hubKubeconfigKey is the key of the kubeconfig in its Secret
+	hubKubeconfigKey = "kubeconfig"
+
+	// hubAccessRetry is the delay before checking again the token Secrets not yet
+	// populated
+	hubAccessRetry = 5 * time.Second
+)
+
+// hubAccessName returns the name of the ServiceAccount and token Secret of the bundle
+// for the cluster. It ends with a hash of the bundle and cluster names, so that the
+// pairs joining to the same name, e.g. a-b and c or a and b-c, do not share a
+// ServiceAccount, and is shortened when too long.
+func hubAccessName(bundle, cluster string) string {
+	sum := sha256.Sum256([]byte(bundle + "/" + cluster))
+	suffix := "-" + hex.EncodeToString(sum[:])[:10] + "-hub-access"
+	name := bundle + "-" + cluster
+	if max := validation.DNS1123SubdomainMaxLength - len(suffix); len(name) > max {
+		name = strings.TrimRight(name[:max], "-.")
+	}
+	return name + suffix
+}
+
+// provisionHubAccess creates the ServiceAccount and token Secret of each cluster of the
+// bundle with hub access, binds them to its Role, and deletes the ones of the clusters
+// it left. The hub access of a bundle whose author may not bind the Role is revoked. It
+// returns true while tokens are not populated yet.
+func (r *AppBundleReconciler) provisionHubAccess(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string) (bool, error) {
+	access := bundle.Spec.HubAccess
+	var forbidden error
+	if access != nil {
+		allowed, reason, err := r.authorizeHubAccess(ctx, bundle)
+		if err != nil {
+			return false, err
+		}
+		if !allowed {
+			forbidden = faults.New(appv1alpha1.ReasonHubAccessForbidden, errors.New(reason))
+			access = nil
+		}
+	}
+	keep := sets.NewString()
+	if access != nil {
+		keep.Insert(clusters...)
+	}
+	if err := r.deleteHubAccess(ctx, bundle, keep); err != nil {
+		return false, err
+	}
+	if access == nil {
+		return false, forbidden
+	}
+
+	pending := false
+	subjects := []rbacv1.Subject{}
+	for _, clusterName := range clusters {
+		name := hubAccessName(bundle.Name, clusterName)
+		labels := map[string]string{HubAccessLabel: ownerName(bundle.Name), HubAccessClusterLabel: clusterName}
+		sa := &corev1.ServiceAccount{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: bundle.Namespace}}
+		if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, sa, func() error {
+			sa.Labels = labels
+			return controllerutil.SetControllerReference(bundle, sa, r.Scheme)
+		}); err != nil {
+			return false, err
+		}
+		secret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: bundle.Namespace}}
+		if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+			secret.Labels = labels
+			if secret.Annotations == nil {
+				secret.Annotations = map[string]string{}
+			}
+			secret.Annotations[corev1.ServiceAccountNameKey] = name
+			secret.Type = corev1.SecretTypeServiceAccountToken
+			return controllerutil.SetControllerReference(bundle, secret, r.Scheme)
+		}); err != nil {
+			return false, err
+		}
+		pending = pending || len(secret.Data[corev1.ServiceAccountTokenKey]) == 0
+		subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: bundle.Namespace})
+	}
+
+	binding := &rbacv1.RoleBinding{ObjectMeta: v1.ObjectMeta{Name: bundle.Name + "-hub-access", Namespace: bundle.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.Labels = map[string]string{HubAccessLabel: ownerName(bundle.Name)}
+		// the role of a binding cannot be changed
+		if binding.RoleRef.Name != "" && binding.RoleRef.Name != access.Role {
+			return fmt.Errorf("the Role of the hub access of AppBundle %s cannot be changed from %s", bundle.Name, binding.RoleRef.Name)
+		}
+		binding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: access.Role}
+		binding.Subjects = subjects
+		return controllerutil.SetControllerReference(bundle, binding, r.Scheme)
+	}); err != nil {
+		return false, err
+	}
+	return pending, nil
+}
+
+// authorizeHubAccess returns true if the author of the bundle, recorded by the
+// attribution webhook, may bind the Role of its hub access in the namespace of the
+// bundle, as the controller binds it with its own permissions. It returns the reason of
+// the refusal otherwise.
+func (r *AppBundleReconciler) authorizeHubAccess(ctx context.Context, bundle *appv1alpha1.AppBundle) (bool, string, error) {
+	access := bundle.Spec.HubAccess
+	if !r.AuthorsAttributed {
+		return false, fmt.Sprintf("the hub access of AppBundle %s requires the admission webhooks to authorize its author", bundle.Name), nil
+	}
+	user := bundle.Annotations[audit.ModifiedByAnnotation]
+	if user == "" {
+		return false, fmt.Sprintf("AppBundle %s has no recorded author to authorize its hub access", bundle.Name), nil
+	}
+	review := &authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		User:   user,
+		Groups: serviceAccountGroups(user),
+		ResourceAttributes: &authorizationv1.ResourceAttributes{
+			Namespace: bundle.Namespace,
+			Verb:      "bind",
+			Group:     rbacv1.GroupName,
+			Resource:  "roles",
+			Name:      access.Role,
+		},
+	}}
+	if err := r.Create(ctx, review); err != nil {
+		return false, "", err
+	}
+	if !review.Status.Allowed {
+		return false, fmt.Sprintf("user %s may not bind Role %s of namespace %s granted by the hub access",
+			user, access.Role, bundle.Namespace), nil
+	}
+	return true, "", nil
+}
+
+// serviceAccountGroups returns the groups of a ServiceAccount user, none for the other
+// users whose groups are not recorded
+func serviceAccountGroups(user string) []string {
+	parts := strings.Split(user, ":")
+	if len(parts) != 4 || parts[0] != "system" || parts[1] != "serviceaccount" {
+		return nil
+	}
+	return []string{"system:serviceaccounts", "system:serviceaccounts:" + parts[2]}
+}
+
+// deleteHubAccess deletes the ServiceAccounts and token Secrets of the bundle for the
+// clusters not kept, or not named by hubAccessName, and its RoleBinding when no cluster
+// is kept
+func (r *AppBundleReconciler) deleteHubAccess(ctx context.Context, bundle *appv1alpha1.AppBundle, keep sets.String) error {
+	selector := client.MatchingLabels{HubAccessLabel: ownerName(bundle.Name)}
+	var accounts corev1.ServiceAccountList
+	if err := r.List(ctx, &accounts, client.InNamespace(bundle.Namespace), selector); err != nil {
+		return err
+	}
+	for i := range accounts.Items {
+		sa := &accounts.Items[i]
+		cluster := sa.Labels[HubAccessClusterLabel]
+		if (keep.Has(cluster) && sa.Name == hubAccessName(bundle.Name, cluster)) || !v1.IsControlledBy(sa, bundle) {
+			continue
+		}
+		klog.Infof("Revoking the hub access of AppBundle %s/%s on cluster %s", bundle.Namespace, bundle.Name, cluster)
+		if err := r.Delete(ctx, sa); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		secret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: sa.Name, Namespace: sa.Namespace}}
+		if err := r.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	if keep.Len() > 0 {
+		return nil
+	}
+	binding := &rbacv1.RoleBinding{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name + "-hub-access"}, binding)
+	if apierrors.IsNotFound(err) || (err == nil && !v1.IsControlledBy(binding, bundle)) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, binding))
+}
+
+// hubKubeconfig appends the Secret holding the kubeconfig of the hub for the cluster to
+// the manifests, once the token of its ServiceAccount is populated
+func (r *AppBundleReconciler) hubKubeconfig(ctx context.Context, bundle *appv1alpha1.AppBundle, clusterName string, ms []workapiv1.Manifest) ([]workapiv1.Manifest, error) {
+	access := bundle.Spec.HubAccess
+	server := r.Config.Get().HubServer
+	if server == "" {
+		return nil, fmt.Errorf("hubServer must be set in the KealmConfig to provision hub access")
+	}
+	namespace := access.Namespace
+	if namespace == "" {
+		namespace = bundle.Spec.TargetNamespace
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("the namespace of the hub access must be set when the bundle has no target namespace")
+	}
+	token := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: bundle.Namespace, Name: hubAccessName(bundle.Name, clusterName)}, token)
+	if apierrors.IsNotFound(err) || (err == nil && len(token.Data[corev1.ServiceAccountTokenKey]) == 0) {
+		return ms, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters["hub"] = &clientcmdapi.Cluster{Server: server, CertificateAuthorityData: token.Data[corev1.ServiceAccountRootCAKey]}
+	config.AuthInfos["hub"] = &clientcmdapi.AuthInfo{Token: string(token.Data[corev1.ServiceAccountTokenKey])}
+	config.Contexts["hub"] = &clientcmdapi.Context{Cluster: "hub", AuthInfo: "hub", Namespace: bundle.Namespace}
+	config.CurrentContext = "hub"
+	kubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		return nil, err
+	}
+	name := access.SecretName
+	if name == "" {
+		name = defaultHubKubeconfigSecret
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   v1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string][]byte{hubKubeconfigKey: kubeconfig},
+	}
+	return append(ms, workapiv1.Manifest{RawExtension: runtime.RawExtension{Object: secret}}), nil
+}
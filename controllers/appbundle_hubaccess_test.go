@@ -0,0 +1,171 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/audit"
+	"github.com/pdettori/kealm/pkg/faults"
+)
+
+// reviewingClient answers the SubjectAccessReviews with the users allowed to bind
+type reviewingClient struct {
+	client.Client
+	binders map[string]bool
+	reviews []authorizationv1.SubjectAccessReviewSpec
+}
+
+func (c *reviewingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	review, ok := obj.(*authorizationv1.SubjectAccessReview)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	c.reviews = append(c.reviews, review.Spec)
+	review.Status.Allowed = c.binders[review.Spec.User]
+	return nil
+}
+
+func TestProvisionHubAccessAuthorization(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributed bool
+		author     string
+		allowed    bool
+	}{
+		{name: "allowed author", attributed: true, author: "alice", allowed: true},
+		{name: "forbidden author", attributed: true, author: "mallory"},
+		{name: "no recorded author", attributed: true},
+		{name: "webhooks disabled", attributed: false, author: "alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "agent", Namespace: "default", UID: "uid"}}
+			bundle.Spec.HubAccess = &appv1alpha1.HubAccess{Role: "agent-reporter"}
+			if tt.author != "" {
+				bundle.Annotations = map[string]string{audit.ModifiedByAnnotation: tt.author}
+			}
+			// the binding of a previously allowed author is revoked when no longer allowed
+			binding := &rbacv1.RoleBinding{ObjectMeta: v1.ObjectMeta{Name: "agent-hub-access", Namespace: "default",
+				Labels: map[string]string{HubAccessLabel: "agent"}}}
+			sa := &corev1.ServiceAccount{ObjectMeta: v1.ObjectMeta{Name: hubAccessName("agent", "cluster1"), Namespace: "default",
+				Labels: map[string]string{HubAccessLabel: "agent", HubAccessClusterLabel: "cluster1"}}}
+			for _, obj := range []v1.Object{binding, sa} {
+				obj.SetOwnerReferences([]v1.OwnerReference{*v1.NewControllerRef(bundle, appv1alpha1.GroupVersion.WithKind("AppBundle"))})
+			}
+			r := newFixture(t, binding, sa).reconciler()
+			c := &reviewingClient{Client: r.Client, binders: map[string]bool{"alice": true}}
+			r.Client = c
+			r.AuthorsAttributed = tt.attributed
+
+			_, err := r.provisionHubAccess(context.TODO(), bundle, []string{"cluster1"})
+			if tt.allowed {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if faults.Reason(err) != appv1alpha1.ReasonHubAccessForbidden {
+				t.Fatalf("expected a HubAccessForbidden fault, got %v", err)
+			}
+			err = c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "agent-hub-access"}, &rbacv1.RoleBinding{})
+			if tt.allowed && err != nil {
+				t.Errorf("expected the binding to be kept: %v", err)
+			}
+			if !tt.allowed && err == nil {
+				t.Error("expected the binding to be revoked")
+			}
+			if tt.attributed && tt.author != "" && len(c.reviews) != 1 {
+				t.Errorf("expected the author to be reviewed once, got %v", c.reviews)
+			}
+			if (!tt.attributed || tt.author == "") && len(c.reviews) != 0 {
+				t.Errorf("expected no review, got %v", c.reviews)
+			}
+		})
+	}
+}
+
+func TestServiceAccountGroups(t *testing.T) {
+	expected := []string{"system:serviceaccounts", "system:serviceaccounts:shop"}
+	if groups := serviceAccountGroups("system:serviceaccount:shop:deployer"); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected %v, got %v", expected, groups)
+	}
+	if groups := serviceAccountGroups("alice"); groups != nil {
+		t.Errorf("expected no group of a user, got %v", groups)
+	}
+}
+
+func TestHubAccessLabel(t *testing.T) {
+	name := strings.Repeat("agent", 20)
+	label := ownerName(name)
+	if errs := validation.IsValidLabelValue(label); len(errs) > 0 {
+		t.Errorf("expected a valid label value, got %q: %v", label, errs)
+	}
+	if ownerName("agent") != "agent" {
+		t.Error("expected a short name to be kept")
+	}
+}
+
+func TestHubAccessName(t *testing.T) {
+	if hubAccessName("a-b", "c") == hubAccessName("a", "b-c") {
+		t.Errorf("expected the names of a-b/c and a/b-c to differ, got %s", hubAccessName("a", "b-c"))
+	}
+	if name := hubAccessName("agent", "cluster1"); !strings.HasPrefix(name, "agent-cluster1-") || !strings.HasSuffix(name, "-hub-access") {
+		t.Errorf("expected the bundle and cluster names in %s", name)
+	}
+	long := hubAccessName(strings.Repeat("agent.", 40), strings.Repeat("c", 60))
+	if errs := validation.IsDNS1123Subdomain(long); len(errs) > 0 {
+		t.Errorf("expected a valid name, got %q: %v", long, errs)
+	}
+	if long == hubAccessName(strings.Repeat("agent.", 40), strings.Repeat("c", 61)) {
+		t.Error("expected the shortened names to differ")
+	}
+}
+
+func TestDeleteHubAccessRenamed(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "agent", Namespace: "default", UID: "uid"}}
+	// the ServiceAccount named before the hash was always added is replaced
+	legacy := &corev1.ServiceAccount{ObjectMeta: v1.ObjectMeta{Name: "agent-cluster1-hub-access", Namespace: "default",
+		Labels: map[string]string{HubAccessLabel: "agent", HubAccessClusterLabel: "cluster1"}}}
+	current := &corev1.ServiceAccount{ObjectMeta: v1.ObjectMeta{Name: hubAccessName("agent", "cluster1"), Namespace: "default",
+		Labels: map[string]string{HubAccessLabel: "agent", HubAccessClusterLabel: "cluster1"}}}
+	for _, sa := range []*corev1.ServiceAccount{legacy, current} {
+		sa.SetOwnerReferences([]v1.OwnerReference{*v1.NewControllerRef(bundle, appv1alpha1.GroupVersion.WithKind("AppBundle"))})
+	}
+	r := newFixture(t, legacy, current).reconciler()
+	if err := r.deleteHubAccess(context.TODO(), bundle, sets.NewString("cluster1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: legacy.Name}, &corev1.ServiceAccount{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the legacy ServiceAccount to be deleted, got %v", err)
+	}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: current.Name}, &corev1.ServiceAccount{}); err != nil {
+		t.Errorf("expected the ServiceAccount to be kept: %v", err)
+	}
+}
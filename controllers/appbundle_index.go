@@ -0,0 +1,96 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// OwnerIndex is the index of the ManifestWork informer by the UID of the bundle owning
+// the works
+const OwnerIndex = "owner"
+
+// OwnerIndexFunc indexes the works by their OwnedLabel
+func OwnerIndexFunc(obj interface{}) ([]string, error) {
+	work, ok := obj.(*workapiv1.ManifestWork)
+	if !ok {
+		return nil, nil
+	}
+	if uid, ok := work.Labels[OwnedLabel]; ok {
+		return []string{uid}, nil
+	}
+	return nil, nil
+}
+
+// OwnedWorksListOptions restricts the ManifestWork informer to the works owned by
+// bundles
+func OwnedWorksListOptions(options *v1.ListOptions) {
+	options.LabelSelector = OwnedLabel
+}
+
+// ownedWorks returns the works owned by the bundle in all the cluster namespaces,
+// looked up in the OwnerIndex of the WorkInformer once synced, instead of listing the
+// works of the fleet. The works are copies, which the caller may modify. The informer
+// may miss the works written by the latest reconciles: the paths which must see all
+// the works, e.g. before removing the finalizer, use listOwnedWorks.
+func (r *AppBundleReconciler) ownedWorks(ctx context.Context, bundle *appv1alpha1.AppBundle) (*workapiv1.ManifestWorkList, error) {
+	if r.WorkInformer == nil || !r.WorkInformer.HasSynced() {
+		return r.listOwnedWorks(ctx, bundle)
+	}
+	objs, err := r.WorkInformer.GetIndexer().ByIndex(OwnerIndex, string(bundle.UID))
+	if err != nil {
+		return nil, err
+	}
+	works := &workapiv1.ManifestWorkList{Items: make([]workapiv1.ManifestWork, 0, len(objs))}
+	for _, obj := range objs {
+		if work, ok := obj.(*workapiv1.ManifestWork); ok {
+			works.Items = append(works.Items, *work.DeepCopy())
+		}
+	}
+	return works, nil
+}
+
+// clusterWorks returns the works owned by bundles in the namespace of the cluster,
+// looked up in the namespace index of the WorkInformer once synced. The works are not
+// copied, and must not be modified.
+func (r *AppBundleReconciler) clusterWorks(ctx context.Context, cluster string) (*workapiv1.ManifestWorkList, error) {
+	if r.WorkInformer == nil || !r.WorkInformer.HasSynced() {
+		return r.WorkClient.WorkV1().ManifestWorks(cluster).List(ctx, v1.ListOptions{LabelSelector: OwnedLabel})
+	}
+	objs, err := r.WorkInformer.GetIndexer().ByIndex(cache.NamespaceIndex, cluster)
+	if err != nil {
+		return nil, err
+	}
+	works := &workapiv1.ManifestWorkList{Items: make([]workapiv1.ManifestWork, 0, len(objs))}
+	for _, obj := range objs {
+		if work, ok := obj.(*workapiv1.ManifestWork); ok {
+			works.Items = append(works.Items, *work)
+		}
+	}
+	return works, nil
+}
+
+// listOwnedWorks lists the works owned by the bundle with the work API
+func (r *AppBundleReconciler) listOwnedWorks(ctx context.Context, bundle *appv1alpha1.AppBundle) (*workapiv1.ManifestWorkList, error) {
+	return r.WorkClient.WorkV1().ManifestWorks("").List(ctx, v1.ListOptions{LabelSelector: ownedSelector(bundle).String()})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	workfake "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func indexedWork(cluster, owner string) *workapiv1.ManifestWork {
+	w := &workapiv1.ManifestWork{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: cluster}}
+	if owner != "" {
+		w.Labels = map[string]string{OwnedLabel: owner}
+	}
+	return w
+}
+
+func TestOwnerIndexFunc(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  interface{}
+		want []string
+	}{
+		{"owned", indexedWork("cluster1", "uid1"), []string{"uid1"}},
+		{"not owned", indexedWork("cluster1", ""), nil},
+		{"not a work", &appv1alpha1.AppBundle{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OwnerIndexFunc(tt.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OwnerIndexFunc() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnedWorks(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "shop", UID: "uid1"}}
+	client := workfake.NewSimpleClientset(indexedWork("cluster1", "uid1"), indexedWork("cluster2", "uid2"), indexedWork("cluster3", ""))
+	r := &AppBundleReconciler{WorkClient: client}
+	ctx := context.TODO()
+
+	// without informer, the works are listed
+	works, err := r.ownedWorks(ctx, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := workClusters(works); !reflect.DeepEqual(got, []string{"cluster1"}) {
+		t.Errorf("expected the listed works of cluster1, got %v", got)
+	}
+
+	// once synced, the works are read from the index
+	factory := workinformers.NewSharedInformerFactoryWithOptions(client, 10*time.Minute,
+		workinformers.WithTweakListOptions(OwnedWorksListOptions))
+	r.WorkInformer = factory.Work().V1().ManifestWorks().Informer()
+	if err := r.WorkInformer.AddIndexers(cache.Indexers{OwnerIndex: OwnerIndexFunc}); err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	factory.Start(stop)
+	if !cache.WaitForCacheSync(stop, r.WorkInformer.HasSynced) {
+		t.Fatal("the informer did not sync")
+	}
+	close(stop)
+	works, err = r.ownedWorks(ctx, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := workClusters(works); !reflect.DeepEqual(got, []string{"cluster1"}) {
+		t.Errorf("expected the indexed works of cluster1, got %v", got)
+	}
+	works.Items[0].Labels["changed"] = "true"
+	if obj, _, _ := r.WorkInformer.GetIndexer().GetByKey("cluster1/web"); len(obj.(*workapiv1.ManifestWork).Labels) != 1 {
+		t.Error("expected the indexed works copied")
+	}
+
+	// a work the informer has not seen yet is only listed by listOwnedWorks, which the
+	// deletion of the bundle relies on
+	if err := r.WorkInformer.GetIndexer().Delete(indexedWork("cluster1", "uid1")); err != nil {
+		t.Fatal(err)
+	}
+	if works, err = r.ownedWorks(ctx, bundle); err != nil || len(works.Items) != 0 {
+		t.Errorf("expected the stale index to miss the work, got %v, %v", workClusters(works), err)
+	}
+	if works, err = r.listOwnedWorks(ctx, bundle); err != nil || !reflect.DeepEqual(workClusters(works), []string{"cluster1"}) {
+		t.Errorf("expected the work listed from the API, got %v, %v", workClusters(works), err)
+	}
+}
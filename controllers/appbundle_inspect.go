@@ -0,0 +1,127 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/plugins"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// RenderWork returns the work the bundle would be distributed with to the cluster for
+// its current generation, rendered the way the reconcile does but without writing it.
+// The placement, locks, requirements and gates of the bundle are not evaluated, and the
+// works of blue/green bundles are rendered for the current phase of their status.
+func (r *AppBundleReconciler) RenderWork(ctx context.Context, key types.NamespacedName, clusterName string) (*workapiv1.ManifestWork, error) {
+	var bundle appv1alpha1.AppBundle
+	if err := r.Get(ctx, key, &bundle); err != nil {
+		return nil, err
+	}
+	if _, err := r.ManagedClusterLister.Get(clusterName); err != nil {
+		return nil, err
+	}
+	cfg := r.Config.Get()
+	b := bundle.DeepCopy()
+	ms, err := r.renderWorkload(ctx, b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render AppBundle %s: %w", key, err)
+	}
+	prov, err := r.recordProvenance(bundle, ms)
+	if err != nil {
+		return nil, err
+	}
+	_, retainedRules, err := retention(b, &cfg, ms)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := plugins.Load(cfg.DistributionPlugins, r.WASMRuntime)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := r.claimOutputs(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	clusterManifests, clusterDigest, _, err := r.clusterManifests(ctx, b, clusterName, ms, chain, outputs)
+	if err != nil {
+		return nil, err
+	}
+	if clusterManifests, err = bandwidthManifests(b, clusterManifests); err != nil {
+		return nil, err
+	}
+	if clusterManifests, _, err = r.componentManifests(ctx, b, clusterName, clusterManifests); err != nil {
+		return nil, err
+	}
+	clusterManifests, resynced, err := resyncComponents(b, clusterName, clusterManifests)
+	if err != nil {
+		return nil, err
+	}
+	if b.Spec.BlueGreen != nil {
+		plan := currentBlueGreenPlan(b.Status.BlueGreen)
+		if clusterManifests, err = r.blueGreenManifests(ctx, b, plan, clusterName, clusterManifests); err != nil {
+			return nil, err
+		}
+	}
+	work := generateManifest(*b, clusterManifests, &cfg, clusterName, prov, clusterDigest)
+	addOrphaningRules(work, retainedRules)
+	if resynced != "" {
+		work.Annotations[ComponentResyncAnnotation] = resynced
+	}
+	return work, nil
+}
+
+// RenderHandler serves the work rendered for the bundle and cluster of the namespace,
+// bundle and cluster query parameters as YAML
+func (r *AppBundleReconciler) RenderHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		namespace, bundle, cluster := q.Get("namespace"), q.Get("bundle"), q.Get("cluster")
+		if namespace == "" || bundle == "" || cluster == "" {
+			http.Error(w, "the namespace, bundle and cluster parameters are required", http.StatusBadRequest)
+			return
+		}
+		work, err := r.RenderWork(req.Context(), types.NamespacedName{Namespace: namespace, Name: bundle}, cluster)
+		var denied *plugins.DeniedError
+		switch {
+		case apierrors.IsNotFound(err):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.As(err, &denied):
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		out, err := yaml.Marshal(work)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(out)
+	})
+}
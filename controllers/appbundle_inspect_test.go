@@ -0,0 +1,74 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/yaml"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestRenderHandler(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "default", UID: "uid"}}
+	bundle.Spec.Workload.Manifests = []workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: []byte(
+		`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"shop","namespace":"default"}}`)}}}
+	f := newFixture(t, bundle)
+	f.add(f.clusters, &clusterv1.ManagedCluster{ObjectMeta: v1.ObjectMeta{Name: "cluster1"}})
+	handler := f.reconciler().RenderHandler()
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+	}{
+		{"rendered", "?namespace=default&bundle=shop&cluster=cluster1", http.StatusOK},
+		{"missing parameter", "?namespace=default&bundle=shop", http.StatusBadRequest},
+		{"unknown bundle", "?namespace=default&bundle=cart&cluster=cluster1", http.StatusNotFound},
+		{"unknown cluster", "?namespace=default&bundle=shop&cluster=cluster2", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/debug/render"+tt.query, nil))
+			if w.Code != tt.code {
+				t.Fatalf("expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			work := &workapiv1.ManifestWork{}
+			if err := yaml.Unmarshal(w.Body.Bytes(), work); err != nil {
+				t.Fatal(err)
+			}
+			if work.Name != WorkName(bundle) || work.Namespace != "cluster1" || len(work.Spec.Workload.Manifests) != 1 {
+				t.Errorf("unexpected rendered work %+v", work)
+			}
+		})
+	}
+	// rendering does not write the works
+	if actions := f.works.Actions(); len(actions) != 0 {
+		t.Errorf("expected no work written, got %v", actions)
+	}
+}
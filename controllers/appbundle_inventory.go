@@ -0,0 +1,69 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/inventory"
+)
+
+// recordInventory records the images of the manifests of the bundle and the clusters
+// they are distributed to in the inventory ConfigMap of the bundle
+func (r *AppBundleReconciler) recordInventory(ctx context.Context, bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, ms []workapiv1.Manifest, prov *appv1alpha1.Provenance) error {
+	if !cfg.ImageInventory {
+		return nil
+	}
+	images, err := inventory.Images(ms, bundle.Spec.SBOMs)
+	if err != nil {
+		return err
+	}
+	inv := inventory.Inventory{
+		Bundle:     bundle.Name,
+		Namespace:  bundle.Namespace,
+		Generation: bundle.Generation,
+		Digest:     prov.Digest,
+		Images:     images,
+		Clusters:   []string{},
+	}
+	for _, c := range bundle.Status.Clusters {
+		inv.Clusters = append(inv.Clusters, c.ClusterName)
+	}
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: inventory.ConfigMapName(bundle.Name), Namespace: bundle.Namespace},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[inventory.Label] = "true"
+		cm.Data = map[string]string{inventory.Key: string(data)}
+		return controllerutil.SetControllerReference(bundle, cm, r.Scheme)
+	})
+	return err
+}
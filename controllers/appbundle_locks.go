@@ -0,0 +1,139 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=clusterlocks,verbs=get;list;watch
+
+// lockedClusters returns the clusters with a ClusterLock in their namespace. In
+// read-only mode all the clusters are locked: the managed clusters and the namespaces
+// of the works of the bundle.
+func (r *AppBundleReconciler) lockedClusters(ctx context.Context, bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec) (sets.String, error) {
+	var locks appv1alpha1.ClusterLockList
+	if err := r.List(ctx, &locks); err != nil {
+		return nil, err
+	}
+	locked := sets.NewString()
+	for _, l := range locks.Items {
+		locked.Insert(l.Namespace)
+	}
+	if !cfg.ReadOnly {
+		return locked, nil
+	}
+	clusters, err := r.ManagedClusterLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clusters {
+		locked.Insert(c.Name)
+	}
+	works, err := r.ownedWorks(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range works.Items {
+		locked.Insert(w.Namespace)
+	}
+	return locked, nil
+}
+
+// splitLocked splits the clusters into the writable and the locked ones
+func splitLocked(clusters []string, locked sets.String) ([]string, []string) {
+	writable, blocked := []string{}, []string{}
+	for _, c := range clusters {
+		if locked.Has(c) {
+			blocked = append(blocked, c)
+			continue
+		}
+		writable = append(writable, c)
+	}
+	return writable, blocked
+}
+
+// reportBlocked sets the Blocked condition of the bundle when some of its clusters
+// are locked, and removes it otherwise
+func (r *AppBundleReconciler) reportBlocked(bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, blocked []string) {
+	if len(blocked) == 0 {
+		removeCondition(bundle, appv1alpha1.ConditionBlocked)
+		return
+	}
+	sorted := append([]string{}, blocked...)
+	sort.Strings(sorted)
+	reason, by := appv1alpha1.ReasonClusterLocked, "a ClusterLock"
+	if cfg.ReadOnly {
+		reason, by = appv1alpha1.ReasonReadOnly, "the read-only mode"
+	}
+	message := fmt.Sprintf("Changes blocked by %s on clusters %s", by, strings.Join(sorted, ","))
+	if c := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionBlocked); c == nil || c.Message != message {
+		r.Recorder.Event(bundle, corev1.EventTypeNormal, reason, message)
+	}
+	setCondition(bundle, appv1alpha1.ConditionBlocked, v1.ConditionTrue, reason, message)
+}
+
+// observeBlocked records the conditions of the works of the blocked clusters, so that
+// their status is still aggregated while they are not written
+func (r *AppBundleReconciler) observeBlocked(ctx context.Context, bundle *appv1alpha1.AppBundle, scheduled *scheduleResult) error {
+	for _, c := range scheduled.blocked {
+		work, err := r.WorkClient.WorkV1().ManifestWorks(c).Get(ctx, WorkName(bundle), v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		scheduled.conditions[c] = work.Status.Conditions
+	}
+	return nil
+}
+
+// bundlesForClusterLock maps a cluster lock to the blocked bundles, so that they are
+// reconciled again once the lock is removed
+func (r *AppBundleReconciler) bundlesForClusterLock(obj client.Object) []reconcile.Request {
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(context.TODO(), &bundles); err != nil {
+		klog.Errorf("Failed to list AppBundles for cluster lock %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, bundle := range bundles.Items {
+		if meta.IsStatusConditionTrue(bundle.Status.Conditions, appv1alpha1.ConditionBlocked) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+			})
+		}
+	}
+	return requests
+}
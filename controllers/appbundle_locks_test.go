@@ -0,0 +1,162 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestClusterLocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		locks    []string
+		readOnly bool
+		targets  []string
+		writable []string
+		blocked  []string
+		reason   string
+		message  string
+	}{
+		{
+			name:     "no lock",
+			targets:  []string{"cluster1", "cluster2"},
+			writable: []string{"cluster1", "cluster2"},
+			blocked:  []string{},
+		},
+		{
+			name:     "locked cluster",
+			locks:    []string{"cluster3", "cluster2"},
+			targets:  []string{"cluster3", "cluster1", "cluster2"},
+			writable: []string{"cluster1"},
+			blocked:  []string{"cluster3", "cluster2"},
+			reason:   appv1alpha1.ReasonClusterLocked,
+			message:  "Changes blocked by a ClusterLock on clusters cluster2,cluster3",
+		},
+		{
+			name:     "lock of another cluster",
+			locks:    []string{"cluster3"},
+			targets:  []string{"cluster1", "cluster2"},
+			writable: []string{"cluster1", "cluster2"},
+			blocked:  []string{},
+		},
+		{
+			name:     "read-only",
+			readOnly: true,
+			targets:  []string{"cluster1", "cluster4"},
+			writable: []string{},
+			blocked:  []string{"cluster1", "cluster4"},
+			reason:   appv1alpha1.ReasonReadOnly,
+			message:  "Changes blocked by the read-only mode on clusters cluster1,cluster4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			for _, name := range []string{"cluster1", "cluster2", "cluster3"} {
+				f.add(f.clusters, &clusterv1.ManagedCluster{ObjectMeta: v1.ObjectMeta{Name: name}})
+			}
+			// the work left in the namespace of a removed cluster is locked in read-only mode
+			if err := f.works.Tracker().Add(&workapiv1.ManifestWork{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "cluster4",
+				Labels: map[string]string{OwnedLabel: "uid"}}}); err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range tt.locks {
+				f.builder.WithObjects(&appv1alpha1.ClusterLock{ObjectMeta: v1.ObjectMeta{Name: "freeze", Namespace: c}})
+			}
+			r := f.reconciler()
+			bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid"}}
+			// a previous Blocked condition is removed once no cluster is locked
+			setCondition(bundle, appv1alpha1.ConditionBlocked, v1.ConditionTrue, appv1alpha1.ReasonClusterLocked, "previously")
+			cfg := &appv1alpha1.KealmConfigSpec{ReadOnly: tt.readOnly}
+
+			locked, err := r.lockedClusters(context.TODO(), bundle, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writable, blocked := splitLocked(tt.targets, locked)
+			if !reflect.DeepEqual(writable, tt.writable) || !reflect.DeepEqual(blocked, tt.blocked) {
+				t.Errorf("expected %v writable and %v blocked, got %v and %v", tt.writable, tt.blocked, writable, blocked)
+			}
+
+			r.reportBlocked(bundle, cfg, blocked)
+			cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionBlocked)
+			if tt.reason == "" {
+				if cond != nil {
+					t.Errorf("expected no Blocked condition, got %+v", cond)
+				}
+				if len(f.recorder.Events) != 0 {
+					t.Errorf("expected no event, got %s", <-f.recorder.Events)
+				}
+				return
+			}
+			if cond == nil || cond.Status != v1.ConditionTrue || cond.Reason != tt.reason || cond.Message != tt.message {
+				t.Fatalf("expected the Blocked condition %s %q, got %+v", tt.reason, tt.message, cond)
+			}
+			if len(f.recorder.Events) != 1 {
+				t.Errorf("expected an event, got %d", len(f.recorder.Events))
+			}
+			// an unchanged condition is not recorded again
+			r.reportBlocked(bundle, cfg, blocked)
+			if len(f.recorder.Events) != 1 {
+				t.Errorf("expected the unchanged condition not to be recorded again, got %d events", len(f.recorder.Events))
+			}
+		})
+	}
+}
+
+func TestDeleteStaleLockedWorks(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid"}}
+	f := newFixture(t)
+	for _, c := range []string{"cluster1", "cluster2", "cluster3"} {
+		if _, err := f.works.WorkV1().ManifestWorks(c).Create(context.TODO(), &workapiv1.ManifestWork{
+			ObjectMeta: v1.ObjectMeta{Name: WorkName(bundle), Namespace: c, Labels: map[string]string{OwnedLabel: "uid"}},
+		}, v1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := f.reconciler()
+
+	// the stale work of the locked cluster is left and its cluster reported blocked
+	actions, blocked, err := r.deleteStaleChildManifests(bundle, []string{"cluster1"}, sets.NewString("cluster1", "cluster2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"cluster2"}; !reflect.DeepEqual(blocked, expected) {
+		t.Errorf("expected the blocked clusters %v, got %v", expected, blocked)
+	}
+	expected := []appv1alpha1.ClusterAction{{ClusterName: "cluster3", Action: appv1alpha1.ClusterActionDeleted}}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("expected %v, got %v", expected, actions)
+	}
+	list, err := f.works.WorkV1().ManifestWorks("").List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clusters := workClusters(list); !reflect.DeepEqual(clusters, []string{"cluster1", "cluster2"}) {
+		t.Errorf("expected the works of cluster1 and cluster2 to be left, got %v", clusters)
+	}
+}
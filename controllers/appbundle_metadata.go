@@ -0,0 +1,130 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/config"
+	"github.com/pdettori/kealm/pkg/provenance"
+)
+
+const (
+	// OwnerNamespaceLabel is the namespace of the bundle owning a manifest work
+	OwnerNamespaceLabel = "cluster.open-cluster-management.io/owner-namespace"
+
+	// OwnerNameLabel is the name of the bundle owning a manifest work, shortened with a
+	// hash suffix when the name is not a valid label value
+	OwnerNameLabel = "cluster.open-cluster-management.io/owner-name"
+
+	// ContentHashLabel is the hash of the content of a manifest work, to select the
+	// works running a given content
+	ContentHashLabel = "cluster.open-cluster-management.io/content-hash"
+
+	// contentHashLength is the number of hex characters of the content hash label
+	contentHashLength = 32
+)
+
+// reservedWorkKeys are the labels and annotations set by the controller on the works,
+// never propagated from the bundle
+var reservedWorkKeys = map[string]bool{
+	OwnedLabel:              true,
+	OwnerNamespaceLabel:     true,
+	OwnerNameLabel:          true,
+	ContentHashLabel:        true,
+	GenerationAnnotation:    true,
+	DigestAnnotation:        true,
+	ClusterDigestAnnotation: true,
+	WrittenAtAnnotation:     true,
+	SignatureAnnotation:     true,
+	SignedByAnnotation:      true,
+	TraceparentAnnotation:   true,
+	// the work of each cluster gets its own component resyncs
+	ComponentResyncAnnotation: true,
+}
+
+// workLabels returns new labels for a work of the bundle: the labels propagated from
+// the bundle and the owner and content hash labels, which always override them
+func workLabels(bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, digest string) map[string]string {
+	result := propagated(config.Propagate(cfg.LabelPropagation, bundle.Labels, config.LabelPrefixes))
+	result[OwnedLabel] = string(bundle.UID)
+	result[OwnerNamespaceLabel] = bundle.Namespace
+	result[OwnerNameLabel] = ownerName(bundle.Name)
+	result[ContentHashLabel] = contentHash(digest)
+	return result
+}
+
+// workAnnotations returns new annotations for a work of the bundle: the annotations
+// propagated from the bundle and the provenance of the content, with the digest of the
+// content specific to the cluster when set
+func workAnnotations(bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, prov *appv1alpha1.Provenance, clusterDigest string) map[string]string {
+	result := propagated(config.Propagate(cfg.LabelPropagation, bundle.Annotations, config.AnnotationPrefixes))
+	result[GenerationAnnotation] = strconv.FormatInt(prov.Generation, 10)
+	result[DigestAnnotation] = prov.Digest
+	if clusterDigest != "" {
+		result[ClusterDigestAnnotation] = clusterDigest
+	}
+	if prov.Signature != "" {
+		result[SignatureAnnotation] = prov.Signature
+	}
+	if prov.SignedBy != "" {
+		result[SignedByAnnotation] = prov.SignedBy
+	}
+	return result
+}
+
+// ownedSelector selects the works owned by the bundle
+func ownedSelector(bundle *appv1alpha1.AppBundle) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{OwnedLabel: string(bundle.UID)})
+}
+
+// propagated drops the reserved keys from the propagated entries
+func propagated(m map[string]string) map[string]string {
+	for k := range m {
+		if reservedWorkKeys[k] {
+			delete(m, k)
+		}
+	}
+	return m
+}
+
+// ownerName returns the name as a label value, the longer names being truncated and
+// suffixed with their hash to remain unique
+func ownerName(name string) string {
+	if len(validation.IsValidLabelValue(name)) == 0 {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:16]
+	return strings.TrimRight(name[:validation.LabelValueMaxLength-len(suffix)], "-._") + suffix
+}
+
+// contentHash returns the hex hash of a content digest shortened to a label value
+func contentHash(digest string) string {
+	hash := strings.TrimPrefix(digest, provenance.DigestPrefix)
+	if len(hash) > contentHashLength {
+		hash = hash[:contentHashLength]
+	}
+	return hash
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestWorkMetadata(t *testing.T) {
+	digest := "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef"
+	prov := &appv1alpha1.Provenance{Generation: 3, Digest: digest, Signature: "sig", SignedBy: "release"}
+	reserved := map[string]string{"team": "shop", "example.com/tier": "web",
+		OwnedLabel: "forged", OwnerNameLabel: "forged", ContentHashLabel: "forged",
+		DigestAnnotation: "forged", SignedByAnnotation: "forged", ComponentResyncAnnotation: "forged"}
+	tests := []struct {
+		name        string
+		policy      *appv1alpha1.PropagationPolicy
+		labels      map[string]string
+		annotations map[string]string
+	}{
+		{
+			name: "all propagated",
+			labels: map[string]string{"team": "shop", "example.com/tier": "web",
+				OwnedLabel: "uid", OwnerNamespaceLabel: "default", OwnerNameLabel: "web",
+				ContentHashLabel: "0123456789abcdef0123456789abcdef"},
+			annotations: map[string]string{"team": "shop", "example.com/tier": "web",
+				GenerationAnnotation: "3", DigestAnnotation: digest, ClusterDigestAnnotation: "sha256:cluster",
+				SignatureAnnotation: "sig", SignedByAnnotation: "release"},
+		},
+		{
+			name:   "selected prefixes",
+			policy: &appv1alpha1.PropagationPolicy{Mode: appv1alpha1.PropagateSelected, Labels: []string{"example.com/"}, Annotations: []string{"team"}},
+			labels: map[string]string{"example.com/tier": "web",
+				OwnedLabel: "uid", OwnerNamespaceLabel: "default", OwnerNameLabel: "web",
+				ContentHashLabel: "0123456789abcdef0123456789abcdef"},
+			annotations: map[string]string{"team": "shop",
+				GenerationAnnotation: "3", DigestAnnotation: digest, ClusterDigestAnnotation: "sha256:cluster",
+				SignatureAnnotation: "sig", SignedByAnnotation: "release"},
+		},
+		{
+			name:   "none propagated",
+			policy: &appv1alpha1.PropagationPolicy{Mode: appv1alpha1.PropagateNone},
+			labels: map[string]string{OwnedLabel: "uid", OwnerNamespaceLabel: "default", OwnerNameLabel: "web",
+				ContentHashLabel: "0123456789abcdef0123456789abcdef"},
+			annotations: map[string]string{GenerationAnnotation: "3", DigestAnnotation: digest,
+				ClusterDigestAnnotation: "sha256:cluster", SignatureAnnotation: "sig", SignedByAnnotation: "release"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid",
+				Labels: copyMap(reserved), Annotations: copyMap(reserved)}}
+			cfg := &appv1alpha1.KealmConfigSpec{LabelPropagation: tt.policy}
+
+			labels := workLabels(bundle, cfg, digest)
+			if !reflect.DeepEqual(labels, tt.labels) {
+				t.Errorf("expected labels %v, got %v", tt.labels, labels)
+			}
+			annotations := workAnnotations(bundle, cfg, prov, "sha256:cluster")
+			if !reflect.DeepEqual(annotations, tt.annotations) {
+				t.Errorf("expected annotations %v, got %v", tt.annotations, annotations)
+			}
+
+			// the maps of the work do not alias the maps of the bundle
+			labels["team"], annotations["team"] = "changed", "changed"
+			if !reflect.DeepEqual(bundle.Labels, reserved) || !reflect.DeepEqual(bundle.Annotations, reserved) {
+				t.Errorf("expected the bundle to be unchanged, got %v and %v", bundle.Labels, bundle.Annotations)
+			}
+		})
+	}
+}
+
+func copyMap(m map[string]string) map[string]string {
+	result := map[string]string{}
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
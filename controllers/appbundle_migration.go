@@ -0,0 +1,146 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+const (
+	// HubMigrationAnnotation sets the role of the bundle during a hub migration:
+	// HubMigrationRelease on the old hub, HubMigrationAdopt on the new one
+	HubMigrationAnnotation = "cluster.open-cluster-management.io/hub-migration"
+
+	// HubMigrationRelease orphans the works of the bundle and stops changing them, so
+	// that the bundle can be deleted from the old hub without deleting its workload
+	HubMigrationRelease = "release"
+	// HubMigrationAdopt leaves the works of the bundle untouched and only reports which
+	// clusters have them, until the annotation is removed once the old hub released them
+	HubMigrationAdopt = "adopt"
+
+	// ReleasedAnnotation is set on the works orphaned by a release, for the new hub
+	// operators to check the handover
+	ReleasedAnnotation = "cluster.open-cluster-management.io/released"
+)
+
+// hubMigration returns the hub migration role of the bundle, empty when not migrating
+func hubMigration(bundle *appv1alpha1.AppBundle) string {
+	switch mode := bundle.Annotations[HubMigrationAnnotation]; mode {
+	case HubMigrationRelease, HubMigrationAdopt:
+		return mode
+	default:
+		return ""
+	}
+}
+
+// reconcileMigration releases or adopts the works of a migrating bundle instead of
+// distributing it
+func (r *AppBundleReconciler) reconcileMigration(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string, locked sets.String) (ctrl.Result, error) {
+	switch hubMigration(bundle) {
+	case HubMigrationRelease:
+		released, pending, err := r.releaseWorks(ctx, bundle, locked)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if pending > 0 {
+			setCondition(bundle, appv1alpha1.ConditionHubMigration, v1.ConditionFalse, appv1alpha1.ReasonWorksReleasing,
+				fmt.Sprintf("%d works released, %d in locked clusters pending", released, pending))
+		} else {
+			setCondition(bundle, appv1alpha1.ConditionHubMigration, v1.ConditionTrue, appv1alpha1.ReasonWorksReleased,
+				fmt.Sprintf("%d works released, the bundle can be deleted", released))
+		}
+	case HubMigrationAdopt:
+		statuses := []appv1alpha1.ClusterStatus{}
+		for _, c := range clusters {
+			work, err := r.WorkClient.WorkV1().ManifestWorks(c).Get(ctx, WorkName(bundle), v1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			statuses = append(statuses, appv1alpha1.ClusterStatus{
+				ClusterName: c,
+				WorkName:    work.Name,
+				Digest:      work.Annotations[DigestAnnotation],
+				Conditions:  work.Status.Conditions,
+			})
+		}
+		bundle.Status.Clusters = statuses
+		setCondition(bundle, appv1alpha1.ConditionHubMigration, v1.ConditionFalse, appv1alpha1.ReasonAdoptOnly,
+			fmt.Sprintf("%d of %d clusters have the works, remove the %s annotation once they are released by the old hub",
+				len(statuses), len(clusters), HubMigrationAnnotation))
+	}
+	return ctrl.Result{}, r.updateStatus(ctx, bundle)
+}
+
+// releaseWorks sets the orphan delete option on the works owned by the bundle, so that
+// deleting them leaves their resources on the managed clusters. It returns the number
+// of released works and of works left in locked clusters.
+func (r *AppBundleReconciler) releaseWorks(ctx context.Context, bundle *appv1alpha1.AppBundle, locked sets.String) (int, int, error) {
+	// the works are deleted next, each must be released
+	works, err := r.listOwnedWorks(ctx, bundle)
+	if err != nil {
+		return 0, 0, err
+	}
+	writer, err := r.workWriter(ctx, bundle.Namespace)
+	if err != nil {
+		return 0, 0, err
+	}
+	released, pending := 0, 0
+	for i := range works.Items {
+		w := &works.Items[i]
+		if isReleased(w) {
+			released++
+			continue
+		}
+		if locked.Has(w.Namespace) {
+			pending++
+			continue
+		}
+		klog.Infof("Releasing manifest %s for cluster %s", w.Name, w.Namespace)
+		w.Spec.DeleteOption = &workapiv1.DeleteOption{PropagationPolicy: workapiv1.DeletePropagationPolicyTypeOrphan}
+		if w.Annotations == nil {
+			w.Annotations = map[string]string{}
+		}
+		w.Annotations[ReleasedAnnotation] = "true"
+		if err := waitForWrite(r.WriteLimiter); err != nil {
+			return released, pending, err
+		}
+		if _, err := writer.ManifestWorks(w.Namespace).Update(ctx, w, v1.UpdateOptions{}); err != nil {
+			return released, pending, err
+		}
+		released++
+	}
+	return released, pending, nil
+}
+
+func isReleased(work *workapiv1.ManifestWork) bool {
+	return work.Spec.DeleteOption != nil &&
+		work.Spec.DeleteOption.PropagationPolicy == workapiv1.DeletePropagationPolicyTypeOrphan &&
+		work.Annotations[ReleasedAnnotation] == "true"
+}
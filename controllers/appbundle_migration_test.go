@@ -0,0 +1,112 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// migratingBundle returns a bundle in the given hub migration role and a fixture holding
+// its works in cluster1 and cluster2
+func migratingBundle(t *testing.T, mode string) (*appv1alpha1.AppBundle, *fixture) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid",
+		Annotations: map[string]string{HubMigrationAnnotation: mode}}}
+	f := newFixture(t, bundle)
+	for _, c := range []string{"cluster1", "cluster2"} {
+		if _, err := f.works.WorkV1().ManifestWorks(c).Create(context.TODO(), &workapiv1.ManifestWork{
+			ObjectMeta: v1.ObjectMeta{Name: WorkName(bundle), Namespace: c, Labels: map[string]string{OwnedLabel: "uid"},
+				Annotations: map[string]string{DigestAnnotation: "sha256:abc"}},
+		}, v1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bundle, f
+}
+
+func TestHubMigrationRelease(t *testing.T) {
+	bundle, f := migratingBundle(t, HubMigrationRelease)
+	r := f.reconciler()
+
+	// the work of the locked cluster is released once the cluster is unlocked
+	if _, err := r.reconcileMigration(context.TODO(), bundle, []string{"cluster1", "cluster2"}, sets.NewString("cluster2")); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionHubMigration)
+	if cond == nil || cond.Status != v1.ConditionFalse || cond.Reason != appv1alpha1.ReasonWorksReleasing {
+		t.Errorf("expected the works to be releasing, got %+v", cond)
+	}
+	released, err := f.works.WorkV1().ManifestWorks("cluster1").Get(context.TODO(), WorkName(bundle), v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isReleased(released) {
+		t.Errorf("expected the work to be orphaned and annotated, got %+v", released)
+	}
+
+	f.works.ClearActions()
+	if _, err := r.reconcileMigration(context.TODO(), bundle, []string{"cluster1", "cluster2"}, sets.NewString()); err != nil {
+		t.Fatal(err)
+	}
+	cond = meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionHubMigration)
+	if cond == nil || cond.Status != v1.ConditionTrue || cond.Reason != appv1alpha1.ReasonWorksReleased {
+		t.Errorf("expected the works to be released, got %+v", cond)
+	}
+	// the released work is not written again
+	updates := 0
+	for _, a := range f.works.Actions() {
+		if a.GetVerb() == "update" {
+			updates++
+		}
+	}
+	if updates != 1 {
+		t.Errorf("expected only the work of the unlocked cluster to be released, got %d updates", updates)
+	}
+}
+
+func TestHubMigrationAdopt(t *testing.T) {
+	bundle, f := migratingBundle(t, HubMigrationAdopt)
+	r := f.reconciler()
+	f.works.ClearActions()
+
+	if _, err := r.reconcileMigration(context.TODO(), bundle, []string{"cluster1", "cluster2", "cluster3"}, sets.NewString()); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range f.works.Actions() {
+		if a.GetVerb() != "get" {
+			t.Errorf("expected the works to be left untouched, got %s", a.GetVerb())
+		}
+	}
+	if len(bundle.Status.Clusters) != 2 || bundle.Status.Clusters[0].Digest != "sha256:abc" {
+		t.Errorf("expected the clusters having the works in the status, got %+v", bundle.Status.Clusters)
+	}
+	cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionHubMigration)
+	if cond == nil || cond.Reason != appv1alpha1.ReasonAdoptOnly {
+		t.Errorf("expected the bundle to be adopt only, got %+v", cond)
+	}
+	if hubMigration(&appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{
+		Annotations: map[string]string{HubMigrationAnnotation: "other"}}}) != "" {
+		t.Error("expected an unknown role to be ignored")
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// workNameHashLength is the number of hex characters of the hash suffix of the work names
+const workNameHashLength = 8
+
+// WorkName returns the name of the ManifestWorks of a bundle, unique across the bundle
+// namespaces: <namespace>-<name>-<hash of namespace/name>, the prefix being truncated
+// to keep the name a valid DNS subdomain
+func WorkName(bundle *appv1alpha1.AppBundle) string {
+	sum := sha256.Sum256([]byte(bundle.Namespace + "/" + bundle.Name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:workNameHashLength]
+	prefix := bundle.Namespace + "-" + bundle.Name
+	if max := validation.DNS1123SubdomainMaxLength - len(suffix); len(prefix) > max {
+		prefix = prefix[:max]
+	}
+	return prefix + suffix
+}
+
+// isLegacyWork returns true for the works owned by the bundle but not named with
+// WorkName: works created before the naming scheme or adopted from another owner
+func isLegacyWork(bundle *appv1alpha1.AppBundle, work *workapiv1.ManifestWork) bool {
+	return work.Name != WorkName(bundle)
+}
+
+// adoptWorks labels the works listed in the AdoptWorksAnnotation of the bundle as
+// owned by the bundle in the target clusters, to be retired once replaced
+func (r *AppBundleReconciler) adoptWorks(bundle *appv1alpha1.AppBundle, clusters []string) error {
+	names := bundle.Annotations[AdoptWorksAnnotation]
+	if names == "" {
+		return nil
+	}
+	writer, err := r.workWriter(context.TODO(), bundle.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		for _, cluster := range clusters {
+			work, err := r.WorkClient.WorkV1().ManifestWorks(cluster).Get(context.TODO(), name, v1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if owner, ok := work.Labels[OwnedLabel]; ok {
+				if owner != string(bundle.UID) {
+					klog.Warningf("Not adopting manifest %s for cluster %s owned by %s", name, cluster, owner)
+				}
+				continue
+			}
+			klog.Infof("Adopting manifest %s for cluster %s into AppBundle %s", name, cluster, bundle.Name)
+			if work.Labels == nil {
+				work.Labels = map[string]string{}
+			}
+			work.Labels[OwnedLabel] = string(bundle.UID)
+			if err := waitForWrite(r.WriteLimiter); err != nil {
+				return err
+			}
+			if _, err := writer.ManifestWorks(cluster).Update(context.TODO(), work, v1.UpdateOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// retireLegacyWork deletes a legacy work of the bundle once its replacement exists,
+// orphaning its resources so that the replacement adopts them on the managed cluster
+// instead of having them deleted and recreated
+func (r *AppBundleReconciler) retireLegacyWork(bundle *appv1alpha1.AppBundle, work *workapiv1.ManifestWork) error {
+	klog.Infof("Migrating manifest %s for cluster %s to %s", work.Name, work.Namespace, WorkName(bundle))
+	writer, err := r.workWriter(context.TODO(), bundle.Namespace)
+	if err != nil {
+		return err
+	}
+	if work.Spec.DeleteOption == nil || work.Spec.DeleteOption.PropagationPolicy != workapiv1.DeletePropagationPolicyTypeOrphan {
+		orphan := work.DeepCopy()
+		orphan.Spec.DeleteOption = &workapiv1.DeleteOption{PropagationPolicy: workapiv1.DeletePropagationPolicyTypeOrphan}
+		if err := waitForWrite(r.WriteLimiter); err != nil {
+			return err
+		}
+		if _, err := writer.ManifestWorks(work.Namespace).Update(context.TODO(), orphan, v1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	if err := waitForWrite(r.WriteLimiter); err != nil {
+		return err
+	}
+	return writer.ManifestWorks(work.Namespace).Delete(context.TODO(), work.Name, v1.DeleteOptions{})
+}
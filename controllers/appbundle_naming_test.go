@@ -0,0 +1,145 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	k8stesting "k8s.io/client-go/testing"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestWorkName(t *testing.T) {
+	long := strings.Repeat("a", validation.DNS1123SubdomainMaxLength)
+	tests := []struct {
+		name      string
+		namespace string
+		bundle    string
+	}{
+		{"short", "default", "web"},
+		{"long name", "default", long},
+		{"long namespace", strings.Repeat("n", 63), long},
+	}
+	names := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: tt.bundle, Namespace: tt.namespace}}
+			name := WorkName(bundle)
+			if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+				t.Errorf("expected a valid name, got %q: %v", name, errs)
+			}
+			if names[name] {
+				t.Errorf("expected a unique name, got %q twice", name)
+			}
+			names[name] = true
+			if name != WorkName(bundle) {
+				t.Error("expected a stable name")
+			}
+		})
+	}
+
+	// the bundles whose prefixes are the same once truncated get different names
+	a := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: long + "a", Namespace: "default"}}
+	b := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: long + "b", Namespace: "default"}}
+	if WorkName(a) == WorkName(b) {
+		t.Errorf("expected the hash to tell the truncated names apart, got %q", WorkName(a))
+	}
+	// the separator of the namespace and the name does not make names collide
+	c := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "b-c", Namespace: "a"}}
+	d := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "c", Namespace: "a-b"}}
+	if WorkName(c) == WorkName(d) {
+		t.Errorf("expected the hash to tell the namespaces apart, got %q", WorkName(c))
+	}
+}
+
+func TestRetireLegacyWork(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid"}}
+	legacy := &workapiv1.ManifestWork{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "cluster1",
+		Labels: map[string]string{OwnedLabel: "uid"}}}
+	f := newFixture(t)
+	if _, err := f.works.WorkV1().ManifestWorks("cluster1").Create(context.TODO(), legacy, v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	r := f.reconciler()
+	if !isLegacyWork(bundle, legacy) {
+		t.Error("expected the work named after the bundle to be legacy")
+	}
+	if isLegacyWork(bundle, &workapiv1.ManifestWork{ObjectMeta: v1.ObjectMeta{Name: WorkName(bundle)}}) {
+		t.Error("expected the work named by WorkName not to be legacy")
+	}
+
+	// the legacy work is deleted once its resources are orphaned
+	f.works.ClearActions()
+	if err := r.retireLegacyWork(bundle, legacy); err != nil {
+		t.Fatal(err)
+	}
+	actions := f.works.Actions()
+	if len(actions) != 2 || actions[0].GetVerb() != "update" || actions[1].GetVerb() != "delete" {
+		t.Fatalf("expected an update then a delete, got %v", actions)
+	}
+	orphaned := actions[0].(k8stesting.UpdateAction).GetObject().(*workapiv1.ManifestWork)
+	if orphaned.Spec.DeleteOption == nil || orphaned.Spec.DeleteOption.PropagationPolicy != workapiv1.DeletePropagationPolicyTypeOrphan {
+		t.Errorf("expected the resources to be orphaned before the delete, got %+v", orphaned.Spec.DeleteOption)
+	}
+	if _, err := f.works.WorkV1().ManifestWorks("cluster1").Get(context.TODO(), "web", v1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the legacy work to be deleted, got %v", err)
+	}
+}
+
+func TestAdoptWorks(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid",
+		Annotations: map[string]string{AdoptWorksAnnotation: "legacy, other"}}}
+	f := newFixture(t)
+	for _, work := range []*workapiv1.ManifestWork{
+		{ObjectMeta: v1.ObjectMeta{Name: "legacy", Namespace: "cluster1"}},
+		{ObjectMeta: v1.ObjectMeta{Name: "other", Namespace: "cluster1", Labels: map[string]string{OwnedLabel: "another"}}},
+	} {
+		if _, err := f.works.WorkV1().ManifestWorks("cluster1").Create(context.TODO(), work, v1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := f.reconciler()
+
+	// the unowned work is adopted, the work owned by another bundle is not
+	if err := r.adoptWorks(bundle, []string{"cluster1", "cluster2"}); err != nil {
+		t.Fatal(err)
+	}
+	adopted, err := f.works.WorkV1().ManifestWorks("cluster1").Get(context.TODO(), "legacy", v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adopted.Labels[OwnedLabel] != "uid" {
+		t.Errorf("expected the work to be adopted, got %v", adopted.Labels)
+	}
+	if !isLegacyWork(bundle, adopted) {
+		t.Error("expected the adopted work to be legacy")
+	}
+	kept, err := f.works.WorkV1().ManifestWorks("cluster1").Get(context.TODO(), "other", v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept.Labels[OwnedLabel] != "another" {
+		t.Errorf("expected the work of another owner to be kept, got %v", kept.Labels)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// AllClustersPlacement is the name of the Placement managed in the namespaces of the
+// bundles with the AllClusters placement policy
+const AllClustersPlacement = "kealm-all-clusters"
+
+// PlacementName returns the name of the placement of the bundle: the one of its
+// placement label, or the managed placement of the AllClusters policy
+func PlacementName(bundle *appv1alpha1.AppBundle) (string, bool) {
+	if name, ok := bundle.GetLabels()[PlacementLabel]; ok {
+		return name, true
+	}
+	if bundle.Spec.PlacementPolicy == appv1alpha1.PlacementAllClusters {
+		return AllClustersPlacement, true
+	}
+	return "", false
+}
+
+// ensureAllClustersPlacement creates the managed placement in the namespace of the
+// bundle, which selects all the clusters of the bound cluster sets, and adds the bundle
+// to its owners, so that it is garbage collected with the last bundle using it
+func (r *AppBundleReconciler) ensureAllClustersPlacement(ctx context.Context, bundle *appv1alpha1.AppBundle) error {
+	owner := v1.OwnerReference{
+		APIVersion: appv1alpha1.GroupVersion.String(),
+		Kind:       "AppBundle",
+		Name:       bundle.Name,
+		UID:        bundle.UID,
+	}
+	placements := r.ClusterClient.ClusterV1alpha1().Placements(bundle.Namespace)
+	placement, err := r.PlacementLister.Placements(bundle.Namespace).Get(AllClustersPlacement)
+	if apierrors.IsNotFound(err) {
+		klog.Infof("Creating placement %s/%s for AppBundle %s", bundle.Namespace, AllClustersPlacement, bundle.Name)
+		_, err = placements.Create(ctx, &clusterapiv1alpha1.Placement{
+			ObjectMeta: v1.ObjectMeta{
+				Name:            AllClustersPlacement,
+				Namespace:       bundle.Namespace,
+				OwnerReferences: []v1.OwnerReference{owner},
+			},
+		}, v1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			// another bundle created it, the next reconcile adds this one to the owners
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	for _, ref := range placement.OwnerReferences {
+		if ref.UID == bundle.UID {
+			return nil
+		}
+	}
+	placement = placement.DeepCopy()
+	placement.OwnerReferences = append(placement.OwnerReferences, owner)
+	_, err = placements.Update(ctx, placement, v1.UpdateOptions{})
+	return IgnoreConflict(err)
+}
+
+// fallbackClusters returns the registered clusters decided for the fallback placement
+// of the bundle when the decision of its placement has none, reporting the switch in
+// an event and the PlacementFallback condition
+func (r *AppBundleReconciler) fallbackClusters(bundle *appv1alpha1.AppBundle, placement string, clusters []string) ([]string, error) {
+	fallback := bundle.Spec.FallbackPlacement
+	if fallback == "" {
+		removeCondition(bundle, appv1alpha1.ConditionPlacementFallback)
+		return clusters, nil
+	}
+	cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionPlacementFallback)
+	if len(clusters) > 0 {
+		message := fmt.Sprintf("Placement %s decides %d clusters", placement, len(clusters))
+		if cond != nil && cond.Status == v1.ConditionTrue {
+			r.Recorder.Event(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonPrimaryPlacement, message)
+		}
+		setCondition(bundle, appv1alpha1.ConditionPlacementFallback, v1.ConditionFalse, appv1alpha1.ReasonPrimaryPlacement, message)
+		return clusters, nil
+	}
+	decision, err := r.getPlacementDecision(fallback, bundle.Namespace)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	fallbackClusters, err := r.getTargetClusters(decision)
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Placement %s decides no cluster, falling back to the %d clusters of placement %s",
+		placement, len(fallbackClusters), fallback)
+	if cond == nil || cond.Message != message {
+		r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonFallbackPlacement, message)
+	}
+	setCondition(bundle, appv1alpha1.ConditionPlacementFallback, v1.ConditionTrue, appv1alpha1.ReasonFallbackPlacement, message)
+	return fallbackClusters, nil
+}
+
+// pinnedClusters restricts the clusters of a bundle generated by an AppBundleSet for a
+// cluster to that cluster
+func pinnedClusters(bundle *appv1alpha1.AppBundle, clusters []string) []string {
+	pinned, ok := bundle.Labels[BundleSetClusterLabel]
+	if !ok {
+		return clusters
+	}
+	for _, c := range clusters {
+		if c == pinned {
+			return []string{c}
+		}
+	}
+	return []string{}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestPlacementName(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		labels   map[string]string
+		policy   appv1alpha1.PlacementPolicy
+		expected string
+		ok       bool
+	}{
+		{"label", map[string]string{PlacementLabel: "fleet"}, "", "fleet", true},
+		{"label over policy", map[string]string{PlacementLabel: "fleet"}, appv1alpha1.PlacementAllClusters, "fleet", true},
+		{"all clusters", nil, appv1alpha1.PlacementAllClusters, AllClustersPlacement, true},
+		{"none", nil, "", "", false},
+	} {
+		bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Labels: tc.labels}}
+		bundle.Spec.PlacementPolicy = tc.policy
+		if name, ok := PlacementName(bundle); name != tc.expected || ok != tc.ok {
+			t.Errorf("%s: expected %q %v, got %q %v", tc.name, tc.expected, tc.ok, name, ok)
+		}
+	}
+}
+
+func TestEnsureAllClustersPlacement(t *testing.T) {
+	web := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default", UID: "web"}}
+	agent := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "agent", Namespace: "default", UID: "agent"}}
+	f := newFixture(t)
+	r := f.reconciler()
+
+	// the first bundle creates the placement
+	if err := r.ensureAllClustersPlacement(context.TODO(), web); err != nil {
+		t.Fatal(err)
+	}
+	placement, err := f.cluster.ClusterV1alpha1().Placements("default").Get(context.TODO(), AllClustersPlacement, v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placement.OwnerReferences) != 1 || placement.OwnerReferences[0].UID != "web" {
+		t.Fatalf("expected the placement to be owned by the bundle, got %+v", placement.OwnerReferences)
+	}
+
+	// the next bundles are added to its owners once
+	f.add(f.placements, placement)
+	for i := 0; i < 2; i++ {
+		if err := r.ensureAllClustersPlacement(context.TODO(), agent); err != nil {
+			t.Fatal(err)
+		}
+		if placement, err = f.cluster.ClusterV1alpha1().Placements("default").Get(context.TODO(), AllClustersPlacement, v1.GetOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.placements.Update(placement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(placement.OwnerReferences) != 2 || placement.OwnerReferences[1].UID != "agent" {
+		t.Errorf("expected the placement to be owned by both bundles, got %+v", placement.OwnerReferences)
+	}
+}
+
+func TestFallbackClusters(t *testing.T) {
+	f := newFixture(t)
+	f.add(f.clusters, &clusterv1.ManagedCluster{ObjectMeta: v1.ObjectMeta{Name: "cloud1"},
+		Spec: clusterv1.ManagedClusterSpec{HubAcceptsClient: true}})
+	f.add(f.decisions, placementDecision("default", "cloud", "cloud1"))
+	r := f.reconciler()
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default"}}
+	bundle.Spec.FallbackPlacement = "cloud"
+	fallback := func(clusters []string, expected []string, status v1.ConditionStatus, events int) {
+		t.Helper()
+		result, err := r.fallbackClusters(bundle, "onprem", clusters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("expected the clusters %v, got %v", expected, result)
+		}
+		if cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionPlacementFallback); cond == nil || cond.Status != status {
+			t.Errorf("expected the PlacementFallback condition %s, got %+v", status, cond)
+		}
+		if len(f.recorder.Events) != events {
+			t.Errorf("expected %d events, got %d", events, len(f.recorder.Events))
+		}
+	}
+
+	// the primary placement is used while it decides clusters
+	fallback([]string{"onprem1"}, []string{"onprem1"}, v1.ConditionFalse, 0)
+	// the switch to the fallback placement is reported once
+	fallback([]string{}, []string{"cloud1"}, v1.ConditionTrue, 1)
+	fallback([]string{}, []string{"cloud1"}, v1.ConditionTrue, 1)
+	// and so is the switch back
+	fallback([]string{"onprem1"}, []string{"onprem1"}, v1.ConditionFalse, 2)
+
+	bundle.Spec.FallbackPlacement = ""
+	if _, err := r.fallbackClusters(bundle, "onprem", nil); err != nil {
+		t.Fatal(err)
+	}
+	if cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionPlacementFallback); cond != nil {
+		t.Errorf("expected no PlacementFallback condition without fallback, got %+v", cond)
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// reportDenied reports the clusters whose manifests are denied by a distribution plugin
+func (r *AppBundleReconciler) reportDenied(bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, denied map[string]string) {
+	if len(cfg.DistributionPlugins) == 0 && len(denied) == 0 {
+		removeCondition(bundle, appv1alpha1.ConditionPluginsPassed)
+		return
+	}
+	if len(denied) == 0 {
+		setCondition(bundle, appv1alpha1.ConditionPluginsPassed, v1.ConditionTrue, appv1alpha1.ReasonPluginsPassed,
+			"Manifests allowed by the distribution plugins")
+		return
+	}
+	clusters := []string{}
+	for c := range denied {
+		clusters = append(clusters, c)
+	}
+	sort.Strings(clusters)
+	messages := []string{}
+	for _, c := range clusters {
+		messages = append(messages, fmt.Sprintf("cluster %s %s", c, denied[c]))
+	}
+	message := strings.Join(messages, "; ")
+	r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonPluginDenied, message)
+	setCondition(bundle, appv1alpha1.ConditionPluginsPassed, v1.ConditionFalse, appv1alpha1.ReasonPluginDenied, message)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// pruneRemoved applies the pruning policy of the bundle to the resources removed from
+// the existing work: the resources not to be pruned are orphaned with the orphaning
+// rules of the updated work, the rules of the resources orphaned by previous updates
+// are kept. It returns the identities of the pruned and orphaned resources.
+func pruneRemoved(bundle *appv1alpha1.AppBundle, retained []string, existing, updated *workapiv1.ManifestWork) ([]string, []string, error) {
+	prune := bundle.Spec.Prune == nil || *bundle.Spec.Prune
+	pruned, orphaned, rules, err := manifests.Removed(existing.Spec.Workload.Manifests, updated.Spec.Workload.Manifests, prune, retained)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opt := existing.Spec.DeleteOption; opt != nil && opt.SelectivelyOrphan != nil {
+		current := map[workapiv1.OrphaningRule]bool{}
+		for _, m := range updated.Spec.Workload.Manifests {
+			u, err := manifests.ToUnstructured(m)
+			if err != nil {
+				return nil, nil, err
+			}
+			current[manifests.OrphaningRule(u)] = true
+		}
+		for _, rule := range opt.SelectivelyOrphan.OrphaningRules {
+			if !current[rule] {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	addOrphaningRules(updated, rules)
+	return pruned, orphaned, nil
+}
+
+// retention returns the kinds retained by the bundle and the configuration, with the
+// orphaning rules of the retained manifests
+func retention(bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, ms []workapiv1.Manifest) ([]string, []workapiv1.OrphaningRule, error) {
+	kinds := append(append([]string{}, cfg.RetainedKinds...), bundle.Spec.RetainedKinds...)
+	rules, err := manifests.Retained(ms, kinds)
+	return kinds, rules, err
+}
+
+// addOrphaningRules adds the rules missing from the delete option of the work, unless
+// the work orphans all its resources
+func addOrphaningRules(work *workapiv1.ManifestWork, rules []workapiv1.OrphaningRule) {
+	if len(rules) == 0 {
+		return
+	}
+	opt := work.Spec.DeleteOption
+	if opt == nil {
+		opt = &workapiv1.DeleteOption{}
+		work.Spec.DeleteOption = opt
+	}
+	if opt.PropagationPolicy == workapiv1.DeletePropagationPolicyTypeOrphan {
+		return
+	}
+	opt.PropagationPolicy = workapiv1.DeletePropagationPolicyTypeSelectivelyOrphan
+	if opt.SelectivelyOrphan == nil {
+		opt.SelectivelyOrphan = &workapiv1.SelectivelyOrphan{}
+	}
+	existing := map[workapiv1.OrphaningRule]bool{}
+	for _, rule := range opt.SelectivelyOrphan.OrphaningRules {
+		existing[rule] = true
+	}
+	for _, rule := range rules {
+		if !existing[rule] {
+			opt.SelectivelyOrphan.OrphaningRules = append(opt.SelectivelyOrphan.OrphaningRules, rule)
+			existing[rule] = true
+		}
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/quota"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// quotaRetry is how often the bundles exceeding the quota of their namespace are
+// checked again
+const quotaRetry = time.Minute
+
+// checkQuota returns the limits of the quota of the KealmTenant of the namespace the
+// bundle exceeds, adding the clusters and the manifests it distributes to the usage of
+// the other bundles. The bundles count against the quota in the order they were
+// created, so that the bundles in excess are the last ones.
+func (r *AppBundleReconciler) checkQuota(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string, ms []workapiv1.Manifest) ([]string, error) {
+	tenant, err := TenantOf(ctx, r.Client, bundle.Namespace)
+	if err != nil || tenant == nil || tenant.Spec.Quota == nil {
+		return nil, err
+	}
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(ctx, &bundles, client.InNamespace(bundle.Namespace)); err != nil {
+		return nil, err
+	}
+	others := []appv1alpha1.AppBundle{}
+	older := int32(0)
+	for _, b := range bundles.Items {
+		if b.Name == bundle.Name {
+			continue
+		}
+		others = append(others, b)
+		if createdBefore(&b, bundle) {
+			older++
+		}
+	}
+	usage, targeted := quota.Usage(others)
+	usage.Bundles = older + 1
+	targeted.Insert(clusters...)
+	usage.Clusters = int32(targeted.Len())
+	usage.ManifestBytes += quota.Size(ms)
+	return quota.Check(tenant.Spec.Quota, usage), nil
+}
+
+// createdBefore orders the bundles by creation, then by name
+func createdBefore(a, b *appv1alpha1.AppBundle) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// reportQuota sets the WithinQuota condition of the bundle, removed when its namespace
+// has no quota, and records an event when the limits exceeded change
+func (r *AppBundleReconciler) reportQuota(bundle *appv1alpha1.AppBundle, exceeded []string) {
+	if exceeded == nil {
+		removeCondition(bundle, appv1alpha1.ConditionWithinQuota)
+		return
+	}
+	if len(exceeded) == 0 {
+		setCondition(bundle, appv1alpha1.ConditionWithinQuota, v1.ConditionTrue,
+			appv1alpha1.ReasonWithinQuota, "The quota of the namespace is respected")
+		return
+	}
+	message := strings.Join(exceeded, "; ")
+	if cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionWithinQuota); cond == nil || cond.Message != message {
+		r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonQuotaExceeded, message)
+	}
+	setCondition(bundle, appv1alpha1.ConditionWithinQuota, v1.ConditionFalse, appv1alpha1.ReasonQuotaExceeded, message)
+}
@@ -0,0 +1,535 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/faults"
+	"github.com/pdettori/kealm/pkg/flux"
+	"github.com/pdettori/kealm/pkg/lint"
+	"github.com/pdettori/kealm/pkg/manifests"
+	"github.com/pdettori/kealm/pkg/plugins"
+	"github.com/pdettori/kealm/pkg/provenance"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// renderWorkload returns the manifests to distribute for the bundle: the manifests of
+// its template version, the normalized inline manifests and the manifests read from
+// the workload references, moved to the target namespace, followed by the manifests of
+// its components, with the Namespaces labeled as configured, without the replicas of
+// the autoscaled workloads, with config checksums injected in the pod templates and
+// suffixed for instance bundles
+func (r *AppBundleReconciler) renderWorkload(ctx context.Context, bundle *appv1alpha1.AppBundle) ([]workapiv1.Manifest, error) {
+	// users paste whole files or lists in a single manifest
+	result, err := manifests.Normalize(bundle.Spec.Workload.Manifests)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Status.TemplateVersion = ""
+	if bundle.Spec.Template != nil {
+		templated, err := r.renderTemplate(ctx, bundle)
+		if err != nil {
+			return nil, err
+		}
+		result = append(templated, result...)
+	}
+	referenced, err := r.workloadRefManifests(ctx, bundle.Namespace, bundle.Spec.WorkloadRefs)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, referenced...)
+	if err := manifests.ValidateScopes(result); err != nil {
+		return nil, err
+	}
+	if bundle.Spec.TargetNamespace != "" {
+		if result, err = manifests.SetNamespace(result, bundle.Spec.TargetNamespace); err != nil {
+			return nil, err
+		}
+	}
+	components, err := r.renderComponents(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, components...)
+	// leave the replicas of the autoscaled workloads to their autoscalers
+	autoscaled, err := manifests.Autoscaled(result, bundle.Spec.AutoscaledWorkloads)
+	if err != nil {
+		return nil, err
+	}
+	if result, err = manifests.ReleaseReplicas(result, autoscaled); err != nil {
+		return nil, err
+	}
+	if bundle.Spec.Flux != nil {
+		var credentials map[string][]byte
+		if helm := bundle.Spec.Flux.HelmRepository; helm != nil && helm.CredentialsSecret != "" {
+			secret := &corev1.Secret{}
+			key := types.NamespacedName{Namespace: bundle.Namespace, Name: helm.CredentialsSecret}
+			if err := r.Get(ctx, key, secret); err != nil {
+				return nil, fmt.Errorf("failed to get Secret %s: %w", helm.CredentialsSecret, err)
+			}
+			credentials = secret.Data
+		}
+		objs, err := flux.Render(bundle.Name, bundle.Spec.Flux, credentials)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, objs...)
+	}
+	result, err = manifests.InjectMonitoring(result, bundle)
+	if err != nil {
+		return nil, err
+	}
+	cfg := r.Config.Get()
+	if result, err = manifests.LabelNamespaces(result, namespaceLabels(bundle, cfg.NamespaceLabels)); err != nil {
+		return nil, err
+	}
+	// roll the workloads consuming bundled configuration when it changes
+	if result, err = manifests.InjectConfigChecksums(result); err != nil {
+		return nil, err
+	}
+	if bundle.Spec.Instance != nil {
+		if result, err = manifests.Instantiate(result, instanceSuffix(bundle)); err != nil {
+			return nil, err
+		}
+	}
+	// the digest of the content depends neither on the order nor on the formatting
+	// of the manifests
+	return manifests.Canonical(result)
+}
+
+// namespaceLabels returns the labels to set on the Namespaces distributed by the bundle
+func namespaceLabels(bundle *appv1alpha1.AppBundle, n *appv1alpha1.NamespaceLabels) map[string]string {
+	result := map[string]string{}
+	if n == nil {
+		return result
+	}
+	for k, v := range n.Labels {
+		result[k] = v
+	}
+	if n.PodSecurity != "" {
+		result["pod-security.kubernetes.io/enforce"] = n.PodSecurity
+	}
+	if n.IstioInjection != "" {
+		result["istio-injection"] = n.IstioInjection
+	}
+	if n.TenantLabel != "" {
+		result[n.TenantLabel] = bundle.Namespace
+	}
+	return result
+}
+
+// workloadRefManifests returns the normalized manifests read from the workload
+// references
+func (r *AppBundleReconciler) workloadRefManifests(ctx context.Context, namespace string, refs []appv1alpha1.WorkloadReference) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, ref := range refs {
+		data, err := r.getWorkloadRefData(ctx, namespace, ref)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range workloadRefKeys(ref, data) {
+			content, ok := data[key]
+			if !ok {
+				return nil, fmt.Errorf("key %s not found in %s %s", key, ref.Kind, ref.Name)
+			}
+			parsed, err := manifests.ParseYAML(content)
+			if err == nil {
+				parsed, err = manifests.Normalize(parsed)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid manifests in key %s of %s %s: %w", key, ref.Kind, ref.Name, err)
+			}
+			result = append(result, parsed...)
+		}
+	}
+	return result, nil
+}
+
+// renderTemplate returns the manifests of the template version referenced by the
+// bundle, and records the version in its status
+func (r *AppBundleReconciler) renderTemplate(ctx context.Context, bundle *appv1alpha1.AppBundle) ([]workapiv1.Manifest, error) {
+	ref := bundle.Spec.Template
+	key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	if key.Namespace == "" {
+		key.Namespace = bundle.Namespace
+	}
+	tmpl := &appv1alpha1.AppBundleTemplate{}
+	if err := r.Get(ctx, key, tmpl); err != nil {
+		return nil, fmt.Errorf("failed to get AppBundleTemplate %s: %w", ref.Name, err)
+	}
+	if err := r.checkCatalogTenant(ctx, tmpl, bundle.Namespace); err != nil {
+		return nil, err
+	}
+	version, err := manifests.TemplateVersion(tmpl, ref.Version)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := manifests.RenderTemplate(version, ref.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render version %s of AppBundleTemplate %s: %w", version.Version, ref.Name, err)
+	}
+	bundle.Status.TemplateVersion = version.Version
+	return ms, nil
+}
+
+// checkCatalogTenant fails when the template is in another namespace than the bundle,
+// unless it is synced from a catalog the namespace of the bundle is a tenant of
+func (r *AppBundleReconciler) checkCatalogTenant(ctx context.Context, tmpl *appv1alpha1.AppBundleTemplate, namespace string) error {
+	if tmpl.Namespace == namespace {
+		return nil
+	}
+	name, ok := tmpl.Labels[CatalogLabel]
+	if !ok {
+		return fmt.Errorf("AppBundleTemplate %s/%s is not in a Catalog, only the bundles of its namespace may reference it",
+			tmpl.Namespace, tmpl.Name)
+	}
+	c := &appv1alpha1.Catalog{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: tmpl.Namespace, Name: name}, c); err != nil {
+		return fmt.Errorf("failed to get Catalog %s of AppBundleTemplate %s: %w", name, tmpl.Name, err)
+	}
+	if c.Spec.Tenants != nil {
+		selector, err := v1.LabelSelectorAsSelector(c.Spec.Tenants)
+		if err != nil {
+			return fmt.Errorf("invalid tenants of Catalog %s: %w", name, err)
+		}
+		ns := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+			return err
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("namespace %s is not a tenant of Catalog %s/%s", namespace, tmpl.Namespace, name)
+}
+
+// bundlesForTemplate maps a template to the bundles referencing it
+func (r *AppBundleReconciler) bundlesForTemplate(obj client.Object) []reconcile.Request {
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(context.TODO(), &bundles); err != nil {
+		klog.Errorf("Failed to list AppBundles for template %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, bundle := range bundles.Items {
+		ref := bundle.Spec.Template
+		if ref == nil || ref.Name != obj.GetName() {
+			continue
+		}
+		if ns := ref.Namespace; ns == obj.GetNamespace() || (ns == "" && bundle.Namespace == obj.GetNamespace()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// instanceSuffixLength is the length of the instance suffixes derived from the UID
+const instanceSuffixLength = 5
+
+// instanceSuffix returns the suffix of the resources of an instance bundle
+func instanceSuffix(bundle *appv1alpha1.AppBundle) string {
+	switch {
+	case bundle.Spec.Instance.Suffix != "":
+		return bundle.Spec.Instance.Suffix
+	case bundle.GenerateName != "" && strings.HasPrefix(bundle.Name, bundle.GenerateName) && len(bundle.Name) > len(bundle.GenerateName):
+		return bundle.Name[len(bundle.GenerateName):]
+	case len(bundle.UID) > instanceSuffixLength:
+		return strings.ToLower(string(bundle.UID))[:instanceSuffixLength]
+	default:
+		return string(bundle.UID)
+	}
+}
+
+// clusterManifests returns the manifests distributed to a cluster, rendered for the
+// cluster and run through the distribution plugins, and their digest when they differ
+// from the manifests of the bundle. The manifests whose API version is removed on the
+// cluster are dropped and returned as incompatible.
+func (r *AppBundleReconciler) clusterManifests(ctx context.Context, bundle *appv1alpha1.AppBundle, clusterName string, ms []workapiv1.Manifest, chain plugins.Chain, outputs map[string]map[string]string) ([]workapiv1.Manifest, string, []string, error) {
+	var helm *appv1alpha1.FluxHelmRelease
+	if bundle.Spec.Flux != nil {
+		helm = bundle.Spec.Flux.HelmRelease
+	}
+	perCluster := renderedPerCluster(bundle, helm, chain)
+	cluster, err := r.ManagedClusterLister.Get(clusterName)
+	switch {
+	case apierrors.IsNotFound(err) && !perCluster:
+		// the version of the cluster is unknown, all the manifests are distributed
+		return ms, "", nil, nil
+	case apierrors.IsNotFound(err):
+		return nil, "", nil, faults.New(appv1alpha1.ReasonClusterUnavailable, err)
+	case err != nil:
+		return nil, "", nil, err
+	}
+	ms, incompatible, err := lint.Removed(ms, cluster.Status.Version.Kubernetes)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if !perCluster && len(incompatible) == 0 {
+		return ms, "", nil, nil
+	}
+	if ms, err = withoutComponents(ms, unsupportedComponents(bundle, manifests.NewClusterContext(cluster))); err != nil {
+		return nil, "", nil, err
+	}
+	if flux.HasClusterValues(helm) {
+		if ms, err = r.helmClusterValues(ctx, helm, cluster, ms); err != nil {
+			return nil, "", nil, err
+		}
+	}
+	if bundle.Spec.ClusterTemplating {
+		if ms, err = r.templateCluster(ctx, bundle, cluster, ms, outputs); err != nil {
+			return nil, "", nil, err
+		}
+	}
+	if a := bundle.Spec.Architectures; a != nil && len(a.Images) > 0 {
+		arch := manifests.NewClusterContext(cluster).Architecture
+		if ms, err = manifests.ArchitectureImages(ms, a.Images, arch); err != nil {
+			return nil, "", nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+		}
+	}
+	if manifests.IsOpenShift(manifests.NewClusterContext(cluster)) {
+		if ms, err = manifests.ApplyOpenShiftProfile(ms, bundle.Spec.OpenShift); err != nil {
+			return nil, "", nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+		}
+	}
+	if len(bundle.Spec.Certificates) > 0 {
+		certs, err := manifests.Certificates(bundle.Spec.Certificates, bundle.Spec.TargetNamespace, manifests.NewClusterContext(cluster))
+		if err != nil {
+			return nil, "", nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+		}
+		ms = append(ms, certs...)
+	}
+	if bundle.Spec.Scaling != nil {
+		rule, err := manifests.ScalingRule(bundle.Spec.Scaling, cluster.Labels)
+		if err != nil {
+			return nil, "", nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+		}
+		autoscaled, err := manifests.Autoscaled(ms, bundle.Spec.AutoscaledWorkloads)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if ms, err = manifests.Scale(ms, rule, autoscaled); err != nil {
+			return nil, "", nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+		}
+	}
+	if len(chain) > 0 {
+		ms, err = chain.Run(ctx, &plugins.Input{
+			Namespace:     bundle.Namespace,
+			Bundle:        bundle.Name,
+			Cluster:       clusterName,
+			ClusterLabels: cluster.Labels,
+			Manifests:     ms,
+		})
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+	if bundle.Spec.HubAccess != nil {
+		if ms, err = r.hubKubeconfig(ctx, bundle, clusterName, ms); err != nil {
+			return nil, "", nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+		}
+	}
+	if ms, err = manifests.Canonical(ms); err != nil {
+		return nil, "", nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+	}
+	payload, err := provenance.Payload(ms)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return ms, provenance.Digest(payload), incompatible, nil
+}
+
+// renderedPerCluster returns true when the manifests of the bundle are rendered for
+// each cluster: with the Helm values or the context of the cluster, the image variants
+// of its architecture, the OpenShift profile, the components of its operating system,
+// certificates, the scaling of the cluster, distribution plugins or the kubeconfig of
+// the hub
+func renderedPerCluster(bundle *appv1alpha1.AppBundle, helm *appv1alpha1.FluxHelmRelease, chain plugins.Chain) bool {
+	return bundle.Spec.ClusterTemplating || flux.HasClusterValues(helm) || bundle.Spec.Scaling != nil ||
+		len(chain) > 0 || bundle.Spec.HubAccess != nil || len(bundle.Spec.Certificates) > 0 ||
+		(bundle.Spec.Architectures != nil && len(bundle.Spec.Architectures.Images) > 0) || bundle.Spec.OpenShift != nil ||
+		hasOSConstraints(bundle)
+}
+
+// helmClusterValues sets the Helm values of the cluster on the HelmRelease of the
+// manifests: the values of its labels, merged with the ones of the ConfigMap of its
+// namespace when set
+func (r *AppBundleReconciler) helmClusterValues(ctx context.Context, helm *appv1alpha1.FluxHelmRelease, cluster *clusterv1.ManagedCluster, ms []workapiv1.Manifest) ([]workapiv1.Manifest, error) {
+	var clusterValues []byte
+	if helm.ClusterValuesConfigMap != "" {
+		cm := &corev1.ConfigMap{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: cluster.Name, Name: helm.ClusterValuesConfigMap}, cm)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		clusterValues = []byte(cm.Data[flux.ClusterValuesKey])
+	}
+	values, err := flux.ClusterValues(helm, cluster.Labels, clusterValues)
+	if err != nil {
+		return nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+	}
+	return flux.SetValues(ms, values)
+}
+
+// templateCluster applies the context of the cluster to the manifests, with the
+// outputs of the Crossplane components and the secret values of the bundle, and rolls
+// the workloads consuming the secret values when they rotate
+func (r *AppBundleReconciler) templateCluster(ctx context.Context, bundle *appv1alpha1.AppBundle, cluster *clusterv1.ManagedCluster, ms []workapiv1.Manifest, outputs map[string]map[string]string) ([]workapiv1.Manifest, error) {
+	c := manifests.NewClusterContext(cluster)
+	c.Outputs = outputs
+	var err error
+	if c.Secrets, err = r.resolveSecrets(ctx, bundle, c); err != nil {
+		return nil, err
+	}
+	if ms, err = manifests.ApplyClusterContext(ms, c); err != nil {
+		return nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+	}
+	if len(c.Secrets) > 0 {
+		if ms, err = manifests.InjectConfigChecksums(ms); err != nil {
+			return nil, faults.New(appv1alpha1.ReasonRenderFailed, err)
+		}
+	}
+	return ms, nil
+}
+
+// bundlesForClusterValues maps a ConfigMap of a cluster namespace to the bundles
+// reading the Helm values of the cluster from it
+func (r *AppBundleReconciler) bundlesForClusterValues(obj client.Object) []reconcile.Request {
+	if _, err := r.ManagedClusterLister.Get(obj.GetNamespace()); err != nil {
+		return nil
+	}
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(context.TODO(), &bundles); err != nil {
+		klog.Errorf("Failed to list AppBundles for cluster values %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, bundle := range bundles.Items {
+		if f := bundle.Spec.Flux; f != nil && f.HelmRelease != nil && f.HelmRelease.ClusterValuesConfigMap == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+			})
+		}
+	}
+	return requests
+}
+
+func (r *AppBundleReconciler) getWorkloadRefData(ctx context.Context, namespace string, ref appv1alpha1.WorkloadReference) (map[string][]byte, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+	data := map[string][]byte{}
+	switch ref.Kind {
+	case appv1alpha1.WorkloadRefKindConfigMap:
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(ctx, key, cm); err != nil {
+			return nil, fmt.Errorf("failed to get ConfigMap %s: %w", ref.Name, err)
+		}
+		for k, v := range cm.Data {
+			data[k] = []byte(v)
+		}
+		for k, v := range cm.BinaryData {
+			data[k] = v
+		}
+	case appv1alpha1.WorkloadRefKindSecret:
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, key, secret); err != nil {
+			return nil, fmt.Errorf("failed to get Secret %s: %w", ref.Name, err)
+		}
+		for k, v := range secret.Data {
+			data[k] = v
+		}
+	default:
+		return nil, fmt.Errorf("unsupported workload reference kind %s", ref.Kind)
+	}
+	return data, nil
+}
+
+func workloadRefKeys(ref appv1alpha1.WorkloadReference, data map[string][]byte) []string {
+	if len(ref.Keys) > 0 {
+		return ref.Keys
+	}
+	keys := []string{}
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// bundlesForWorkloadRef maps a ConfigMap or Secret to the bundles in its namespace
+// referencing it, Secrets also to the bundles using them as Helm repository credentials
+func (r *AppBundleReconciler) bundlesForWorkloadRef(kind string) func(client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		var bundles appv1alpha1.AppBundleList
+		if err := r.List(context.TODO(), &bundles, client.InNamespace(obj.GetNamespace())); err != nil {
+			klog.Errorf("Failed to list AppBundles for %s %s: %v", kind, obj.GetName(), err)
+			return nil
+		}
+		requests := []reconcile.Request{}
+		for _, bundle := range bundles.Items {
+			if kind == appv1alpha1.WorkloadRefKindSecret && usesCredentials(&bundle, obj.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+				})
+				continue
+			}
+			if referencesWorkload(&bundle, kind, obj.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+				})
+			}
+		}
+		return requests
+	}
+}
+
+// referencesWorkload returns true if the bundle or one of its components references
+// the ConfigMap or Secret in its workload references
+func referencesWorkload(bundle *appv1alpha1.AppBundle, kind, name string) bool {
+	refs := append([]appv1alpha1.WorkloadReference{}, bundle.Spec.WorkloadRefs...)
+	for _, c := range bundle.Spec.Components {
+		refs = append(refs, c.WorkloadRefs...)
+	}
+	for _, ref := range refs {
+		if ref.Kind == kind && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// usesCredentials returns true if the bundle distributes the Secret as the
+// credentials of its Helm repository
+func usesCredentials(bundle *appv1alpha1.AppBundle, secretName string) bool {
+	f := bundle.Spec.Flux
+	return f != nil && f.HelmRepository != nil && f.HelmRepository.CredentialsSecret == secretName
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// requirementRetry is the delay before checking again the required bundles of the
+// clusters waiting for them
+const requirementRetry = 30 * time.Second
+
+// missingRequirements returns, by cluster, the required bundles not Available on it.
+// A required bundle which does not exist is not Available on any cluster.
+func (r *AppBundleReconciler) missingRequirements(ctx context.Context, bundle *appv1alpha1.AppBundle, clusters []string) (map[string][]string, error) {
+	if len(bundle.Spec.Requires) == 0 {
+		return nil, nil
+	}
+	required := []appv1alpha1.AppBundle{}
+	for _, req := range bundle.Spec.Requires {
+		namespace := req.Namespace
+		if namespace == "" {
+			namespace = bundle.Namespace
+		}
+		if req.Name != "" {
+			b := appv1alpha1.AppBundle{}
+			err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: req.Name}, &b)
+			if apierrors.IsNotFound(err) {
+				b.Namespace, b.Name = namespace, req.Name
+			} else if err != nil {
+				return nil, err
+			}
+			required = append(required, b)
+		}
+		if req.Selector != nil {
+			selector, err := v1.LabelSelectorAsSelector(req.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector of required bundles: %w", err)
+			}
+			var list appv1alpha1.AppBundleList
+			if err := r.List(ctx, &list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+				return nil, err
+			}
+			for _, b := range list.Items {
+				if b.UID != bundle.UID {
+					required = append(required, b)
+				}
+			}
+		}
+	}
+	missing := map[string][]string{}
+	for _, c := range clusters {
+		for i := range required {
+			if !isAvailableOn(&required[i], c) {
+				missing[c] = append(missing[c], required[i].Namespace+"/"+required[i].Name)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// isAvailableOn returns true if the work of the bundle is Available on the cluster
+func isAvailableOn(bundle *appv1alpha1.AppBundle, cluster string) bool {
+	for _, c := range bundle.Status.Clusters {
+		if c.ClusterName == cluster {
+			return meta.IsStatusConditionTrue(c.Conditions, workapiv1.WorkAvailable)
+		}
+	}
+	return false
+}
+
+// splitWaiting splits the clusters between the ones whose required bundles are
+// Available and the ones waiting for them
+func splitWaiting(clusters []string, missing map[string][]string) ([]string, []string) {
+	ready, waiting := []string{}, []string{}
+	for _, c := range clusters {
+		if len(missing[c]) > 0 {
+			waiting = append(waiting, c)
+			continue
+		}
+		ready = append(ready, c)
+	}
+	return ready, waiting
+}
+
+// reportRequirements sets the RequirementsMet condition of the bundle, listing the
+// required bundles missing on each cluster
+func (r *AppBundleReconciler) reportRequirements(bundle *appv1alpha1.AppBundle, missing map[string][]string) {
+	if len(bundle.Spec.Requires) == 0 {
+		removeCondition(bundle, appv1alpha1.ConditionRequirementsMet)
+		return
+	}
+	if len(missing) == 0 {
+		setCondition(bundle, appv1alpha1.ConditionRequirementsMet, v1.ConditionTrue, appv1alpha1.ReasonRequirementsMet,
+			"Required bundles Available on all clusters")
+		return
+	}
+	clusters := []string{}
+	for c := range missing {
+		clusters = append(clusters, c)
+	}
+	sort.Strings(clusters)
+	messages := []string{}
+	for _, c := range clusters {
+		messages = append(messages, fmt.Sprintf("cluster %s waits for %s", c, strings.Join(missing[c], ",")))
+	}
+	message := strings.Join(messages, "; ")
+	if c := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionRequirementsMet); c == nil || c.Message != message {
+		r.Recorder.Event(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonRequirementsMissing, message)
+	}
+	setCondition(bundle, appv1alpha1.ConditionRequirementsMet, v1.ConditionFalse, appv1alpha1.ReasonRequirementsMissing, message)
+}
+
+// bundlesForRequirement maps a bundle to the bundles requiring it, so that they are
+// reconciled again once its status changes
+func (r *AppBundleReconciler) bundlesForRequirement(obj client.Object) []reconcile.Request {
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(context.TODO(), &bundles); err != nil {
+		klog.Errorf("Failed to list AppBundles for required bundle %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, bundle := range bundles.Items {
+		if requires(&bundle, obj) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// requires returns true if one of the requirements of the bundle references the object
+func requires(bundle *appv1alpha1.AppBundle, obj client.Object) bool {
+	for _, req := range bundle.Spec.Requires {
+		namespace := req.Namespace
+		if namespace == "" {
+			namespace = bundle.Namespace
+		}
+		if namespace != obj.GetNamespace() {
+			continue
+		}
+		if req.Name == obj.GetName() {
+			return true
+		}
+		if req.Selector != nil {
+			if selector, err := v1.LabelSelectorAsSelector(req.Selector); err == nil && selector.Matches(labels.Set(obj.GetLabels())) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// availableBundle returns a bundle Available on the clusters
+func availableBundle(namespace, name string, labels map[string]string, clusters ...string) *appv1alpha1.AppBundle {
+	b := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID("uid-" + name), Labels: labels}}
+	for _, c := range clusters {
+		b.Status.Clusters = append(b.Status.Clusters, appv1alpha1.ClusterStatus{ClusterName: c,
+			Conditions: []v1.Condition{{Type: workapiv1.WorkAvailable, Status: v1.ConditionTrue}}})
+	}
+	return b
+}
+
+func TestRequirements(t *testing.T) {
+	web := map[string]string{"tier": "web"}
+	r := newFixture(t,
+		availableBundle("default", "ingress", web, "cluster1"),
+		availableBundle("platform", "cert-manager", map[string]string{"tier": "platform"}, "cluster1", "cluster2"),
+		availableBundle("default", "web", web),
+	).reconciler()
+	clusters := []string{"cluster1", "cluster2"}
+
+	tests := []struct {
+		name     string
+		requires []appv1alpha1.BundleRequirement
+		ready    []string
+		waiting  []string
+		status   v1.ConditionStatus
+		message  string
+	}{
+		{
+			name:    "no requirement",
+			ready:   clusters,
+			waiting: []string{},
+		},
+		{
+			name:     "required by name",
+			requires: []appv1alpha1.BundleRequirement{{Name: "ingress"}},
+			ready:    []string{"cluster1"},
+			waiting:  []string{"cluster2"},
+			status:   v1.ConditionFalse,
+			message:  "cluster cluster2 waits for default/ingress",
+		},
+		{
+			name: "required by selector in another namespace",
+			requires: []appv1alpha1.BundleRequirement{{Namespace: "platform",
+				Selector: &v1.LabelSelector{MatchLabels: map[string]string{"tier": "platform"}}}},
+			ready:   clusters,
+			waiting: []string{},
+			status:  v1.ConditionTrue,
+			message: "Required bundles Available on all clusters",
+		},
+		{
+			name:     "selector matching the bundle",
+			requires: []appv1alpha1.BundleRequirement{{Selector: &v1.LabelSelector{MatchLabels: web}}},
+			ready:    []string{"cluster1"},
+			waiting:  []string{"cluster2"},
+			status:   v1.ConditionFalse,
+			message:  "cluster cluster2 waits for default/ingress",
+		},
+		{
+			name:     "missing bundle",
+			requires: []appv1alpha1.BundleRequirement{{Name: "dns"}, {Name: "cert-manager", Namespace: "platform"}},
+			ready:    []string{},
+			waiting:  clusters,
+			status:   v1.ConditionFalse,
+			message:  "cluster cluster1 waits for default/dns; cluster cluster2 waits for default/dns",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := availableBundle("default", "web", web)
+			bundle.Spec.Requires = tt.requires
+
+			missing, err := r.missingRequirements(context.TODO(), bundle, clusters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ready, waiting := splitWaiting(clusters, missing)
+			if !reflect.DeepEqual(ready, tt.ready) || !reflect.DeepEqual(waiting, tt.waiting) {
+				t.Errorf("expected %v ready and %v waiting, got %v and %v", tt.ready, tt.waiting, ready, waiting)
+			}
+
+			r.reportRequirements(bundle, missing)
+			cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionRequirementsMet)
+			if tt.status == "" {
+				if cond != nil {
+					t.Errorf("expected no RequirementsMet condition, got %+v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.status || cond.Message != tt.message {
+				t.Errorf("expected the RequirementsMet condition %s %q, got %+v", tt.status, tt.message, cond)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+const (
+	// defaultResyncInterval is the interval between the resyncs of a bundle when not
+	// configured
+	defaultResyncInterval = 10 * time.Minute
+	// resyncJitter is the largest fraction of the interval added to the resyncs, so that
+	// the bundles reconciled together do not resync together
+	resyncJitter = 0.2
+)
+
+// minRequeue returns the shortest of the requeue delays, the delays of zero requesting
+// no requeue
+func minRequeue(durations ...time.Duration) time.Duration {
+	requeue := time.Duration(0)
+	for _, d := range durations {
+		if d > 0 && (requeue == 0 || d < requeue) {
+			requeue = d
+		}
+	}
+	return requeue
+}
+
+// requeueIf returns the requeue delay when pending, no requeue otherwise
+func requeueIf(pending bool, after time.Duration) time.Duration {
+	if !pending {
+		return 0
+	}
+	return after
+}
+
+// nextResync caps the requeue delay of a bundle to the jittered resync interval, so
+// that the bundle converges even when a watch event is missed
+func nextResync(cfg *appv1alpha1.KealmConfigSpec, requeue time.Duration) time.Duration {
+	interval := defaultResyncInterval
+	if cfg.ResyncInterval != nil {
+		interval = cfg.ResyncInterval.Duration
+	}
+	if interval <= 0 {
+		return requeue
+	}
+	return minRequeue(requeue, wait.Jitter(interval, resyncJitter))
+}
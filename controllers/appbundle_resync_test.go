@@ -0,0 +1,67 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestNextResync(t *testing.T) {
+	jittered := func(interval time.Duration) [2]time.Duration {
+		return [2]time.Duration{interval, time.Duration(float64(interval) * (1 + resyncJitter))}
+	}
+	for _, tc := range []struct {
+		name     string
+		interval *v1.Duration
+		requeue  time.Duration
+		expected [2]time.Duration
+	}{
+		{"default interval", nil, 0, jittered(defaultResyncInterval)},
+		{"configured interval", &v1.Duration{Duration: time.Minute}, 0, jittered(time.Minute)},
+		{"sooner requeue", &v1.Duration{Duration: time.Minute}, 10 * time.Second, [2]time.Duration{10 * time.Second, 10 * time.Second}},
+		{"later requeue", &v1.Duration{Duration: time.Minute}, time.Hour, jittered(time.Minute)},
+		{"disabled", &v1.Duration{}, time.Hour, [2]time.Duration{time.Hour, time.Hour}},
+		{"disabled without requeue", &v1.Duration{}, 0, [2]time.Duration{0, 0}},
+	} {
+		cfg := &appv1alpha1.KealmConfigSpec{ResyncInterval: tc.interval}
+		if resync := nextResync(cfg, tc.requeue); resync < tc.expected[0] || resync > tc.expected[1] {
+			t.Errorf("%s: expected a resync in %v, got %s", tc.name, tc.expected, resync)
+		}
+	}
+}
+
+func TestMinRequeue(t *testing.T) {
+	for _, tc := range []struct {
+		durations []time.Duration
+		expected  time.Duration
+	}{
+		{nil, 0},
+		{[]time.Duration{0, 0}, 0},
+		{[]time.Duration{0, time.Minute, 0}, time.Minute},
+		{[]time.Duration{time.Minute, 10 * time.Second, requeueIf(false, time.Second)}, 10 * time.Second},
+		{[]time.Duration{time.Minute, requeueIf(true, time.Second)}, time.Second},
+	} {
+		if requeue := minRequeue(tc.durations...); requeue != tc.expected {
+			t.Errorf("expected %s for %v, got %s", tc.expected, tc.durations, requeue)
+		}
+	}
+}
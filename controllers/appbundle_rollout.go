@@ -0,0 +1,165 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/rollout"
+	"github.com/pdettori/kealm/pkg/stall"
+)
+
+const (
+	// rolloutCheck is the interval between two evaluations of the gates of the current
+	// wave, the works not being watched
+	rolloutCheck = 30 * time.Second
+
+	// waveStartDelay is the delay before writing the works of a new plan, once it is
+	// published, or of a new wave
+	waveStartDelay = time.Second
+)
+
+// planRollout computes the plan of the rollout of the digest to the clusters, or goes
+// on with the plan rolling it out, in the status of the bundle. It returns the clusters
+// held until their wave starts, and true for a new plan, published before any work is
+// written. The bundles without rollout have no plan and write all their clusters at
+// once.
+func (r *AppBundleReconciler) planRollout(bundle *appv1alpha1.AppBundle, digest string, clusters []string) ([]string, bool, error) {
+	if bundle.Spec.Rollout == nil {
+		bundle.Status.Plan = nil
+		removeCondition(bundle, appv1alpha1.ConditionRolloutOverridesHonored)
+		return nil, false, nil
+	}
+	clusterLabels := map[string]labels.Set{}
+	for _, c := range clusters {
+		cluster, err := r.ManagedClusterLister.Get(c)
+		switch {
+		case apierrors.IsNotFound(err):
+			clusterLabels[c] = labels.Set{}
+		case err != nil:
+			return nil, false, err
+		default:
+			clusterLabels[c] = labels.Set(cluster.Labels)
+		}
+	}
+	waves, ignored, err := rollout.Assign(bundle.Spec.Rollout, bundle.Spec.RolloutOverrides, clusterLabels)
+	if err != nil {
+		return nil, false, err
+	}
+	r.reportOverrides(bundle, ignored)
+	plan, created := rollout.Plan(bundle.Status.Plan, digest, waves, bundle.Spec.Analysis != nil, pausedWaves(bundle), time.Now())
+	bundle.Status.Plan = plan
+	if w := plan.Waves[0]; created && w.StartedAt != nil {
+		r.reportWavesStarted(bundle, []string{w.Name})
+	}
+	return rollout.Held(plan), created, nil
+}
+
+// advanceRollout records the progress of the clusters against the plan of the bundle
+// and passes the gates of the current wave, without waiting for the skipped clusters.
+// It returns when to evaluate the gates again, 0 once the rollout completed.
+func (r *AppBundleReconciler) advanceRollout(bundle *appv1alpha1.AppBundle, scheduled *scheduleResult, skipped sets.String) time.Duration {
+	plan := bundle.Status.Plan
+	if plan == nil || rollout.Completed(plan) {
+		return 0
+	}
+	written := sets.NewString()
+	for _, a := range scheduled.actions {
+		written.Insert(a.ClusterName)
+	}
+	progress := map[string]rollout.Progress{}
+	for _, s := range bundle.Status.Clusters {
+		p := rollout.Progress{Updated: s.Digest == plan.Digest}
+		// the conditions observed for the works just written predate their content
+		if _, observed := scheduled.conditions[s.ClusterName]; p.Updated && observed && !written.Has(s.ClusterName) {
+			p.Available = meta.IsStatusConditionTrue(s.Conditions, workapiv1.WorkAvailable) &&
+				stall.Settled(stall.Work{Generation: scheduled.generations[s.ClusterName], Conditions: s.Conditions})
+		}
+		progress[s.ClusterName] = p
+	}
+	for c := range skipped {
+		p := progress[c]
+		p.Skipped = true
+		progress[c] = p
+	}
+	analysisPassed := meta.IsStatusConditionTrue(bundle.Status.Conditions, appv1alpha1.ConditionAnalysisPassed)
+	started, soak := rollout.Advance(plan, progress, analysisPassed, pausedWaves(bundle), time.Now())
+	switch {
+	case rollout.Completed(plan):
+		r.Recorder.Eventf(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonRolloutCompleted,
+			"Rollout of %s completed in %d waves", plan.Digest, len(plan.Waves))
+		return 0
+	case len(started) > 0:
+		r.reportWavesStarted(bundle, started)
+		return waveStartDelay
+	case soak > 0:
+		return soak
+	case plan.Waves[plan.CurrentWave].Phase == appv1alpha1.WavePaused:
+		// the rollout resumes when the overrides change
+		return 0
+	}
+	return rolloutCheck
+}
+
+// pausedWaves returns the waves paused by the rollout overrides of the bundle
+func pausedWaves(bundle *appv1alpha1.AppBundle) sets.String {
+	if o := bundle.Spec.RolloutOverrides; o != nil {
+		return sets.NewString(o.PausedWaves...)
+	}
+	return nil
+}
+
+// reportOverrides sets the RolloutOverridesHonored condition of the bundle with the
+// overrides ignored
+func (r *AppBundleReconciler) reportOverrides(bundle *appv1alpha1.AppBundle, ignored []string) {
+	if bundle.Spec.RolloutOverrides == nil {
+		removeCondition(bundle, appv1alpha1.ConditionRolloutOverridesHonored)
+		return
+	}
+	if len(ignored) == 0 {
+		setCondition(bundle, appv1alpha1.ConditionRolloutOverridesHonored, v1.ConditionTrue, appv1alpha1.ReasonOverridesHonored,
+			"All the rollout overrides are honored")
+		return
+	}
+	message := "Rollout overrides ignored: " + strings.Join(ignored, "; ")
+	if c := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionRolloutOverridesHonored); c == nil || c.Message != message {
+		r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonOverridesIgnored, message)
+	}
+	setCondition(bundle, appv1alpha1.ConditionRolloutOverridesHonored, v1.ConditionFalse, appv1alpha1.ReasonOverridesIgnored, message)
+}
+
+// reportWavesStarted records an event for each wave started, naming its clusters
+func (r *AppBundleReconciler) reportWavesStarted(bundle *appv1alpha1.AppBundle, started []string) {
+	for _, name := range started {
+		for _, w := range bundle.Status.Plan.Waves {
+			if w.Name == name {
+				r.Recorder.Eventf(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonWaveStarted,
+					"Wave %s started on clusters %s", w.Name, strings.Join(w.Clusters, ","))
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// wavedBundle returns a bundle rolled out to the canary clusters, then to the prod
+// clusters, by the tier label
+func wavedBundle() *appv1alpha1.AppBundle {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "default", UID: "uid"}}
+	bundle.Spec.Rollout = &appv1alpha1.Rollout{Waves: []appv1alpha1.RolloutWave{
+		{Name: "canary", ClusterSelector: &v1.LabelSelector{MatchLabels: map[string]string{"tier": "canary"}}},
+		{Name: "prod", ClusterSelector: &v1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}}},
+	}}
+	return bundle
+}
+
+// availableStatus returns the status of a cluster whose work applied the digest and is
+// Available
+func availableStatus(cluster, digest string) appv1alpha1.ClusterStatus {
+	return appv1alpha1.ClusterStatus{ClusterName: cluster, Digest: digest, Conditions: []v1.Condition{
+		{Type: workapiv1.WorkApplied, Status: v1.ConditionTrue},
+		{Type: workapiv1.WorkAvailable, Status: v1.ConditionTrue},
+	}}
+}
+
+func TestPlanRolloutWithoutRollout(t *testing.T) {
+	r := newFixture(t).reconciler()
+	bundle := wavedBundle()
+	bundle.Spec.Rollout = nil
+	bundle.Status.Plan = &appv1alpha1.RolloutPlan{Digest: "previous"}
+	held, planned, err := r.planRollout(bundle, "digest", []string{"cluster1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(held) > 0 || planned || bundle.Status.Plan != nil {
+		t.Errorf("expected no plan and no cluster held, got %v, %v, %+v", held, planned, bundle.Status.Plan)
+	}
+	if requeue := r.advanceRollout(bundle, &scheduleResult{}, nil); requeue != 0 {
+		t.Errorf("expected no rollout check, got %s", requeue)
+	}
+}
+
+func TestAdvanceRolloutUnreachable(t *testing.T) {
+	f := newFixture(t)
+	unreachable := clusterAvailable("canary1", v1.ConditionUnknown, time.Hour)
+	unreachable.Labels = map[string]string{"tier": "canary"}
+	available := clusterAvailable("canary2", v1.ConditionTrue, time.Hour)
+	available.Labels = map[string]string{"tier": "canary"}
+	prod := clusterAvailable("prod1", v1.ConditionTrue, time.Hour)
+	prod.Labels = map[string]string{"tier": "prod"}
+	f.add(f.clusters, unreachable, available, prod)
+	r := f.reconciler()
+
+	bundle := wavedBundle()
+	bundle.Spec.AvailabilityPolicy = &appv1alpha1.AvailabilityPolicy{ProceedPastUnreachable: true}
+	if _, planned, err := r.planRollout(bundle, "digest", []string{"canary1", "canary2", "prod1"}); err != nil || !planned {
+		t.Fatalf("expected a new plan, got %v, %v", planned, err)
+	}
+	// the work of canary1 was written but its cluster never applied it
+	bundle.Status.Clusters = []appv1alpha1.ClusterStatus{
+		{ClusterName: "canary1", Digest: "digest"},
+		availableStatus("canary2", "digest"),
+	}
+	scheduled := &scheduleResult{conditions: map[string][]v1.Condition{}, generations: map[string]int64{}}
+	for _, s := range bundle.Status.Clusters {
+		scheduled.conditions[s.ClusterName] = s.Conditions
+	}
+	lost, _ := r.checkAvailability(bundle)
+	if requeue := r.advanceRollout(bundle, scheduled, proceedPast(bundle, lost)); requeue != waveStartDelay {
+		t.Errorf("expected the prod wave to start, got a requeue after %s", requeue)
+	}
+	if plan := bundle.Status.Plan; plan.CurrentWave != 1 || plan.Waves[0].Phase != appv1alpha1.WaveCompleted {
+		t.Errorf("expected the canary wave completed past canary1, got %+v", plan)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// failNamespace fails the actions on the works of the namespace
+func failNamespace(namespace string) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() != namespace {
+			return false, nil, nil
+		}
+		return true, nil, errors.New("connection refused")
+	}
+}
+
+func TestScheduleBundleClusterFailures(t *testing.T) {
+	clusterNames := []string{"cluster1", "cluster2", "cluster3"}
+	f := newFixture(t)
+	for _, name := range clusterNames {
+		f.add(f.clusters, &clusterv1.ManagedCluster{
+			ObjectMeta: v1.ObjectMeta{Name: name},
+			Spec:       clusterv1.ManagedClusterSpec{HubAcceptsClient: true},
+		})
+	}
+	// the work of cluster1 cannot be read, and the works of cluster2 cannot be listed
+	// to check its change budget
+	f.works.PrependReactor("get", "manifestworks", failNamespace("cluster1"))
+	f.works.PrependReactor("list", "manifestworks", failNamespace("cluster2"))
+	r := f.reconciler()
+
+	bundle := appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "default", UID: "uid"}}
+	bundle.Spec.Workload.Manifests = []workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: []byte(
+		`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"shop","namespace":"default"}}`)}}}
+	budget := int32(1)
+	cfg := &appv1alpha1.KealmConfigSpec{MaxConcurrentChangesPerCluster: &budget}
+	result, err := r.scheduleBundle(context.TODO(), bundle, bundle.Spec.Workload.Manifests, nil,
+		&appv1alpha1.Provenance{Digest: "digest"}, cfg, clusterNames, nil)
+	if err == nil {
+		t.Fatal("expected the failures of the clusters to be returned")
+	}
+	if result == nil {
+		t.Fatal("expected the result of the other clusters")
+	}
+	if expected := []string{"cluster1", "cluster2"}; !reflect.DeepEqual(result.failed, expected) {
+		t.Errorf("expected the failed clusters %v, got %v", expected, result.failed)
+	}
+	if _, err := f.works.WorkV1().ManifestWorks("cluster3").Get(context.TODO(), WorkName(&bundle), v1.GetOptions{}); err != nil {
+		t.Errorf("expected the work of cluster3 to be written: %v", err)
+	}
+	expected := []appv1alpha1.ClusterAction{{ClusterName: "cluster3", Action: appv1alpha1.ClusterActionCreated}}
+	if !reflect.DeepEqual(result.actions, expected) {
+		t.Errorf("expected %v, got %v", expected, result.actions)
+	}
+	for _, name := range []string{"cluster1", "cluster2"} {
+		if !strings.Contains(err.Error(), "cluster "+name) {
+			t.Errorf("expected the error of %s, got %v", name, err)
+		}
+	}
+}
+
+func TestScheduleBundleClusterRenderFailure(t *testing.T) {
+	clusterNames := []string{"cluster1", "cluster2", "cluster3"}
+	f := newFixture(t)
+	for _, name := range clusterNames {
+		cluster := &clusterv1.ManagedCluster{
+			ObjectMeta: v1.ObjectMeta{Name: name, Labels: map[string]string{"tier": "gold"}},
+			Spec:       clusterv1.ManagedClusterSpec{HubAcceptsClient: true},
+		}
+		// the template of cluster2 references a missing label
+		if name == "cluster2" {
+			cluster.Labels = nil
+		}
+		f.add(f.clusters, cluster)
+	}
+	r := f.reconciler()
+
+	bundle := appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "default", UID: "uid"}}
+	bundle.Spec.ClusterTemplating = true
+	bundle.Spec.Workload.Manifests = []workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: []byte(
+		`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"shop","namespace":"default"},"data":{"tier":"{{ .Labels.tier }}"}}`)}}}
+	result, err := r.scheduleBundle(context.TODO(), bundle, bundle.Spec.Workload.Manifests, nil,
+		&appv1alpha1.Provenance{Digest: "digest"}, &appv1alpha1.KealmConfigSpec{}, clusterNames, nil)
+	if err == nil || !strings.Contains(err.Error(), "cluster cluster2") {
+		t.Fatalf("expected the failure of cluster2 to be returned, got %v", err)
+	}
+	if result == nil {
+		t.Fatal("expected the result of the other clusters")
+	}
+	if expected := []string{"cluster2"}; !reflect.DeepEqual(result.failed, expected) {
+		t.Errorf("expected the failed clusters %v, got %v", expected, result.failed)
+	}
+	expected := []appv1alpha1.ClusterAction{
+		{ClusterName: "cluster1", Action: appv1alpha1.ClusterActionCreated},
+		{ClusterName: "cluster3", Action: appv1alpha1.ClusterActionCreated},
+	}
+	if !reflect.DeepEqual(result.actions, expected) {
+		t.Errorf("expected %v, got %v", expected, result.actions)
+	}
+	if _, err := f.works.WorkV1().ManifestWorks("cluster2").Get(context.TODO(), WorkName(&bundle), v1.GetOptions{}); err == nil {
+		t.Error("expected no work to be written to cluster2")
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/scheduler"
+)
+
+// selectClusters applies the cluster selection and the spread constraints of the
+// bundle to the clusters of the placement decision, setting the ClustersSelected and
+// SpreadSatisfied conditions
+func (r *AppBundleReconciler) selectClusters(bundle *appv1alpha1.AppBundle, clusters []string) ([]string, error) {
+	sel, spread := bundle.Spec.ClusterSelection, bundle.Spec.Spread
+	if sel == nil {
+		removeCondition(bundle, appv1alpha1.ConditionClustersSelected)
+	}
+	if len(spread) == 0 {
+		removeCondition(bundle, appv1alpha1.ConditionSpreadSatisfied)
+	}
+	if sel == nil && len(spread) == 0 {
+		return clusters, nil
+	}
+	managed, err := r.managedClusters(clusters)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := 0
+	if sel != nil {
+		limit = int(sel.Clusters)
+	}
+	selected, unsatisfied := scheduler.Spread(scheduler.Rank(managed, sel), spread, limit)
+
+	if len(spread) > 0 {
+		if len(unsatisfied) > 0 {
+			message := strings.Join(unsatisfied, "; ")
+			r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonSpreadUnsatisfiable, message)
+			setCondition(bundle, appv1alpha1.ConditionSpreadSatisfied, v1.ConditionFalse, appv1alpha1.ReasonSpreadUnsatisfiable, message)
+		} else {
+			setCondition(bundle, appv1alpha1.ConditionSpreadSatisfied, v1.ConditionTrue, appv1alpha1.ReasonSpreadSatisfied,
+				fmt.Sprintf("%d of %d clusters satisfy the spread constraints", len(selected), len(clusters)))
+		}
+	}
+	if sel != nil {
+		if len(selected) < limit {
+			setCondition(bundle, appv1alpha1.ConditionClustersSelected, v1.ConditionFalse, appv1alpha1.ReasonNotEnoughClusters,
+				fmt.Sprintf("%d clusters required, %d of the %d clusters of the placement decision can be selected",
+					sel.Clusters, len(selected), len(clusters)))
+		} else {
+			setCondition(bundle, appv1alpha1.ConditionClustersSelected, v1.ConditionTrue, appv1alpha1.ReasonClustersSelected,
+				fmt.Sprintf("%d of %d clusters selected", len(selected), len(clusters)))
+		}
+	}
+	return scheduler.Names(selected), nil
+}
+
+// managedClusters returns the ManagedClusters with the given names
+func (r *AppBundleReconciler) managedClusters(names []string) ([]*clusterapiv1.ManagedCluster, error) {
+	clusters := make([]*clusterapiv1.ManagedCluster, 0, len(names))
+	for _, name := range names {
+		c, err := r.ManagedClusterLister.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
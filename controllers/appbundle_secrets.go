@@ -0,0 +1,155 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/faults"
+	"github.com/pdettori/kealm/pkg/manifests"
+	"github.com/pdettori/kealm/pkg/secrets"
+	"github.com/pdettori/kealm/pkg/stall"
+)
+
+// rotationCheck is the interval between two checks of the works where rotated secret
+// values are not yet applied, the works not being watched
+const rotationCheck = 30 * time.Second
+
+// resolveSecrets looks up the secret values of the bundle for the cluster
+func (r *AppBundleReconciler) resolveSecrets(ctx context.Context, bundle *appv1alpha1.AppBundle, c manifests.ClusterContext) (map[string]string, error) {
+	if len(bundle.Spec.SecretValues) == 0 {
+		return nil, nil
+	}
+	cfg := r.Config.Get()
+	values := map[string]string{}
+	for _, s := range bundle.Spec.SecretValues {
+		ref, err := secrets.NewReference(bundle.Namespace, s.ValueFrom, c.Render)
+		if err != nil {
+			return nil, faults.New(appv1alpha1.ReasonSecretLookupFailed, fmt.Errorf("invalid secret value %s: %w", s.Name, err))
+		}
+		v, err := r.Secrets.Resolve(ctx, cfg.SecretProviders, ref)
+		if err != nil {
+			return nil, faults.New(appv1alpha1.ReasonSecretLookupFailed, fmt.Errorf("failed to look up secret value %s: %w", s.Name, err))
+		}
+		values[s.Name] = v
+	}
+	return values, nil
+}
+
+// secretsHash returns the hash of the secret values of the bundle for the cluster, the
+// values being served from the cache of the lookups of the rendering
+func (r *AppBundleReconciler) secretsHash(ctx context.Context, bundle *appv1alpha1.AppBundle, clusterName string) (string, error) {
+	if len(bundle.Spec.SecretValues) == 0 || !bundle.Spec.ClusterTemplating {
+		return "", nil
+	}
+	cluster, err := r.ManagedClusterLister.Get(clusterName)
+	if err != nil {
+		return "", err
+	}
+	values, err := r.resolveSecrets(ctx, bundle, manifests.NewClusterContext(cluster))
+	if err != nil {
+		return "", err
+	}
+	return secrets.Hash(values), nil
+}
+
+// trackRotation records the clusters where the hash of the secret values written
+// changed since the previous status, until their work agents apply the rotated values,
+// with events when a rotation is distributed and when it completes
+func (r *AppBundleReconciler) trackRotation(bundle *appv1alpha1.AppBundle, previous []appv1alpha1.ClusterStatus, scheduled *scheduleResult) {
+	if len(bundle.Spec.SecretValues) == 0 {
+		bundle.Status.SecretRotation = nil
+		return
+	}
+	hashes := map[string]string{}
+	for _, s := range previous {
+		hashes[s.ClusterName] = s.SecretsHash
+	}
+	targets := sets.NewString()
+	rotated := sets.NewString()
+	for _, s := range bundle.Status.Clusters {
+		targets.Insert(s.ClusterName)
+		// the first values distributed to a cluster are not a rotation
+		if h := hashes[s.ClusterName]; h != "" && s.SecretsHash != "" && h != s.SecretsHash {
+			rotated.Insert(s.ClusterName)
+		}
+	}
+	rotation := bundle.Status.SecretRotation
+	if rotated.Len() > 0 {
+		if rotation == nil || rotation.CompletedAt != nil {
+			rotation = &appv1alpha1.SecretRotationStatus{DetectedAt: metav1.Now()}
+		}
+		rotation.Pending = sets.NewString(rotation.Pending...).Union(rotated).List()
+		rotation.Completed = sets.NewString(rotation.Completed...).Difference(rotated).List()
+		r.Recorder.Eventf(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonSecretRotated,
+			"Rotated secret values distributed to clusters %s", strings.Join(rotated.List(), ", "))
+	}
+	if rotation == nil || rotation.CompletedAt != nil {
+		bundle.Status.SecretRotation = rotation
+		return
+	}
+	pending := []string{}
+	completed := sets.NewString(rotation.Completed...)
+	for _, c := range rotation.Pending {
+		switch {
+		case !targets.Has(c):
+			// the clusters no longer targeted are dropped
+		case rotated.Has(c):
+			// the conditions observed predate the work just written
+			pending = append(pending, c)
+		case stall.Settled(stall.Work{Generation: scheduled.generations[c], Conditions: scheduled.conditions[c]}):
+			completed.Insert(c)
+		default:
+			pending = append(pending, c)
+		}
+	}
+	rotation.Pending, rotation.Completed = pending, completed.List()
+	if len(pending) == 0 {
+		now := metav1.Now()
+		rotation.CompletedAt = &now
+		r.Recorder.Eventf(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonSecretRotationCompleted,
+			"Rotated secret values applied on %d clusters", completed.Len())
+	}
+	bundle.Status.SecretRotation = rotation
+}
+
+// rotationPending returns true while rotated secret values are not applied on all the
+// clusters of the bundle
+func rotationPending(bundle *appv1alpha1.AppBundle) bool {
+	r := bundle.Status.SecretRotation
+	return r != nil && r.CompletedAt == nil
+}
+
+// secretsRefresh returns the delay before looking up the secret values of the bundle
+// again, to distribute their rotations, 0 for bundles without secret values
+func secretsRefresh(bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec) time.Duration {
+	if len(bundle.Spec.SecretValues) == 0 {
+		return 0
+	}
+	if p := cfg.SecretProviders; p != nil && p.RefreshInterval != nil {
+		return p.RefreshInterval.Duration
+	}
+	return secrets.DefaultRefreshInterval
+}
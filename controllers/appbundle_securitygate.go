@@ -0,0 +1,96 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/inventory"
+	"github.com/pdettori/kealm/pkg/securitygate"
+)
+
+// SecurityGateOverrideAnnotation distributes a bundle denied by the security gate, its
+// value justifying the override. Only the users of the override groups of the
+// security gate may set it.
+const SecurityGateOverrideAnnotation = "cluster.open-cluster-management.io/security-gate-override"
+
+// securityGateRetry is the delay before retrying to reach a failing scanner
+const securityGateRetry = time.Minute
+
+// checkSecurityGate reviews the images of the manifests with the security gate, and
+// returns false with the delay before the next review when the bundle must not be
+// distributed
+func (r *AppBundleReconciler) checkSecurityGate(ctx context.Context, bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, ms []workapiv1.Manifest) (bool, time.Duration, error) {
+	gateCfg := cfg.SecurityGate
+	if gateCfg == nil {
+		removeCondition(bundle, appv1alpha1.ConditionSecurityGatePassed)
+		return true, 0, nil
+	}
+	images, err := inventory.Images(ms, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	review := &securitygate.Review{Namespace: bundle.Namespace, Bundle: bundle.Name, Generation: bundle.Generation}
+	for _, i := range images {
+		review.Images = append(review.Images, i.Image)
+	}
+	gate := r.SecurityGate
+	if gate == nil {
+		gate = &securitygate.Gate{}
+	}
+	result, err := gate.Review(ctx, gateCfg, review)
+	if err != nil {
+		klog.Errorf("Failed to review the images of AppBundle %s: %v", bundle.Name, err)
+		if gateCfg.FailurePolicy == appv1alpha1.SecurityGateIgnore {
+			setCondition(bundle, appv1alpha1.ConditionSecurityGatePassed, v1.ConditionTrue,
+				appv1alpha1.ReasonSecurityGateError, "Scanner failed, ignored: "+err.Error())
+			return true, 0, nil
+		}
+		r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonSecurityGateError, err.Error())
+		setCondition(bundle, appv1alpha1.ConditionSecurityGatePassed, v1.ConditionFalse,
+			appv1alpha1.ReasonSecurityGateError, "Scanner failed: "+err.Error())
+		return false, securityGateRetry, nil
+	}
+	if result.Allowed {
+		setCondition(bundle, appv1alpha1.ConditionSecurityGatePassed, v1.ConditionTrue,
+			appv1alpha1.ReasonSecurityGatePassed, "Images allowed by the scanner")
+		return true, 0, nil
+	}
+	recheck := securitygate.DefaultRecheckInterval
+	if gateCfg.RecheckInterval != nil {
+		recheck = gateCfg.RecheckInterval.Duration
+	}
+	message := "Images denied by the scanner: " + result.Summary()
+	if justification, ok := bundle.Annotations[SecurityGateOverrideAnnotation]; ok {
+		r.Recorder.Eventf(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonSecurityGateOverridden,
+			"%s, overridden: %s", message, justification)
+		setCondition(bundle, appv1alpha1.ConditionSecurityGatePassed, v1.ConditionTrue,
+			appv1alpha1.ReasonSecurityGateOverridden, message+", overridden: "+justification)
+		return true, 0, nil
+	}
+	r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonSecurityGateFailed, message)
+	setCondition(bundle, appv1alpha1.ConditionSecurityGatePassed, v1.ConditionFalse,
+		appv1alpha1.ReasonSecurityGateFailed, message)
+	return false, recheck, nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+	"github.com/pdettori/kealm/pkg/registry"
+)
+
+const (
+	// VersionLabel is the label of the version of the agents of a bundle, and of the
+	// hub components
+	VersionLabel = "app.kubernetes.io/version"
+
+	// skewRetry is the delay before checking again the version skew of a blocked
+	// bundle, as the hub components are not watched
+	skewRetry = time.Minute
+)
+
+// checkVersionSkew returns the violation of the version skew policy of the bundle,
+// empty if the version of its agents is compatible with the version of the hub
+// component
+func (r *AppBundleReconciler) checkVersionSkew(ctx context.Context, bundle *appv1alpha1.AppBundle) (string, error) {
+	policy := bundle.Spec.VersionSkew
+	agent, ok := bundle.Labels[VersionLabel]
+	if !ok {
+		return fmt.Sprintf("the bundle has no %s label", VersionLabel), nil
+	}
+	ref := policy.HubComponent
+	hub, err := r.hubVersion(ctx, ref)
+	switch {
+	case apierrors.IsNotFound(err):
+		return fmt.Sprintf("hub component %s/%s not found", ref.Namespace, ref.Name), nil
+	case err != nil:
+		return "", err
+	case hub == "":
+		return fmt.Sprintf("the version of hub component %s/%s is unknown", ref.Namespace, ref.Name), nil
+	}
+	matched := false
+	for _, c := range policy.Compatibility {
+		inHub, err := registry.InRange(hub, c.Hub)
+		if err != nil {
+			return fmt.Sprintf("hub component %s/%s: %v", ref.Namespace, ref.Name, err), nil
+		}
+		if !inHub {
+			continue
+		}
+		matched = true
+		inAgent, err := registry.InRange(agent, c.Agent)
+		if err != nil {
+			return err.Error(), nil
+		}
+		if inAgent {
+			return "", nil
+		}
+	}
+	if !matched {
+		return fmt.Sprintf("no compatibility declared for version %s of hub component %s/%s",
+			hub, ref.Namespace, ref.Name), nil
+	}
+	return fmt.Sprintf("agent version %s is not compatible with version %s of hub component %s/%s",
+		agent, hub, ref.Namespace, ref.Name), nil
+}
+
+// hubVersion returns the version of the hub Deployment: its version label, else the tag
+// of the image of its first container, empty if unknown
+func (r *AppBundleReconciler) hubVersion(ctx context.Context, ref appv1alpha1.HubComponentReference) (string, error) {
+	d := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, d); err != nil {
+		return "", err
+	}
+	if v, ok := d.Labels[VersionLabel]; ok {
+		return v, nil
+	}
+	if containers := d.Spec.Template.Spec.Containers; len(containers) > 0 {
+		if _, tag := manifests.SplitImage(containers[0].Image); tag != "" && !strings.HasPrefix(tag, "@") {
+			return tag, nil
+		}
+	}
+	return "", nil
+}
+
+// reportVersionSkew sets the VersionSkewAllowed condition of the bundle, and records an
+// event when the violation changes
+func (r *AppBundleReconciler) reportVersionSkew(bundle *appv1alpha1.AppBundle, violation string) {
+	if bundle.Spec.VersionSkew == nil {
+		removeCondition(bundle, appv1alpha1.ConditionVersionSkewAllowed)
+		return
+	}
+	if violation == "" {
+		setCondition(bundle, appv1alpha1.ConditionVersionSkewAllowed, v1.ConditionTrue,
+			appv1alpha1.ReasonVersionSkewAllowed, "The agent version is compatible with the hub component")
+		return
+	}
+	if c := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionVersionSkewAllowed); c == nil || c.Message != violation {
+		r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonVersionSkewViolated, violation)
+	}
+	setCondition(bundle, appv1alpha1.ConditionVersionSkewAllowed, v1.ConditionFalse,
+		appv1alpha1.ReasonVersionSkewViolated, violation)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/slo"
+)
+
+const (
+	// defaultSLOObjective is the percentage of the clusters of the rollout SLO when
+	// not configured
+	defaultSLOObjective = 95
+	// defaultSLOWindow is the window of the rollout SLO when not configured
+	defaultSLOWindow = 15 * time.Minute
+)
+
+// sloObjective returns the objective of the rollout SLO, with the defaults applied
+func sloObjective(cfg *appv1alpha1.RolloutSLO) slo.Objective {
+	o := slo.Objective{Target: defaultSLOObjective / 100.0, Window: defaultSLOWindow}
+	if cfg.Objective > 0 {
+		o.Target = float64(cfg.Objective) / 100
+	}
+	if cfg.Window != nil {
+		o.Window = cfg.Window.Duration
+	}
+	return o
+}
+
+// evaluateSLO reports whether the latest rollout of the bundle, on the clusters not
+// skipped, meets the rollout SLO, and returns the delay until the next pending cluster reaches the end of its window,
+// to evaluate it again then
+func (r *AppBundleReconciler) evaluateSLO(bundle *appv1alpha1.AppBundle, cfg *appv1alpha1.KealmConfigSpec, scheduled *scheduleResult, skipped sets.String) time.Duration {
+	name := types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name}
+	if cfg.RolloutSLO == nil {
+		removeCondition(bundle, appv1alpha1.ConditionSLOViolated)
+		r.RolloutSLO.Forget(name)
+		return 0
+	}
+	o := sloObjective(cfg.RolloutSLO)
+	clusters := []slo.Cluster{}
+	for c, written := range scheduled.written {
+		if skipped.Has(c) {
+			continue
+		}
+		clusters = append(clusters, slo.Cluster{Name: c, Written: written, Conditions: scheduled.conditions[c]})
+	}
+	result := slo.Evaluate(clusters, o, time.Now())
+	r.RolloutSLO.Set(name, result, o)
+
+	total := result.Good + result.Bad + result.Pending
+	if result.Violated(o) {
+		message := fmt.Sprintf("%d of %d clusters not Available within %s, the objective is %.0f%%",
+			result.Bad, total, o.Window, o.Target*100)
+		if !meta.IsStatusConditionTrue(bundle.Status.Conditions, appv1alpha1.ConditionSLOViolated) {
+			r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonObjectiveMissed, message)
+		}
+		setCondition(bundle, appv1alpha1.ConditionSLOViolated, v1.ConditionTrue, appv1alpha1.ReasonObjectiveMissed, message)
+	} else {
+		setCondition(bundle, appv1alpha1.ConditionSLOViolated, v1.ConditionFalse, appv1alpha1.ReasonObjectiveMet,
+			fmt.Sprintf("%d of %d clusters Available within %s, %d pending", result.Good, total, o.Window, result.Pending))
+	}
+	if result.Pending == 0 {
+		return 0
+	}
+	return time.Until(result.Deadline) + time.Second
+}
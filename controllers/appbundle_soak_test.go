@@ -0,0 +1,195 @@
+//go:build chaos
+// +build chaos
+
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/chaos"
+)
+
+// The soak tests reconcile bundles while the work API fails and the listers are slow,
+// and assert that the works converge. Run them with:
+//
+//	go test -tags chaos ./controllers -run TestSoak
+const (
+	soakClusters      = 25
+	soakFailureRate   = 0.2
+	soakMaxReconciles = 50
+)
+
+// soakEnv is a hub of fake clients and listers with faults injected
+type soakEnv struct {
+	*fixture
+	r          *AppBundleReconciler
+	injector   *chaos.Injector
+	bundleName types.NamespacedName
+	workName   string
+}
+
+func newSoakEnv(t *testing.T, seed int64) *soakEnv {
+	injector := chaos.New(chaos.Config{FailureRate: soakFailureRate, MaxDelay: time.Millisecond, Seed: seed})
+	bundle := &appv1alpha1.AppBundle{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "shop",
+			Namespace: "default",
+			UID:       "soak",
+			Labels:    map[string]string{PlacementLabel: "fleet"},
+		},
+	}
+	bundle.Spec.Workload.Manifests = []workapiv1.Manifest{configMap("v1")}
+
+	f := newFixture(t, bundle)
+	for i := 0; i < soakClusters; i++ {
+		f.add(f.clusters, &clusterv1.ManagedCluster{
+			ObjectMeta: v1.ObjectMeta{Name: fmt.Sprintf("cluster%d", i)},
+			Spec:       clusterv1.ManagedClusterSpec{HubAcceptsClient: true},
+		})
+	}
+	f.add(f.placements, &clusterv1alpha1.Placement{ObjectMeta: v1.ObjectMeta{Name: "fleet", Namespace: "default"}})
+	f.works.PrependReactor("*", "*", injector.Reactor)
+
+	r := f.reconciler()
+	r.PlacementLister = injector.PlacementLister(r.PlacementLister)
+	r.Decisions = injector.Decisions(r.Decisions)
+	r.ManagedClusterLister = injector.ManagedClusterLister(r.ManagedClusterLister)
+	// the events of the many reconciles are dropped
+	r.Recorder = &record.FakeRecorder{}
+	env := &soakEnv{
+		fixture:    f,
+		r:          r,
+		injector:   injector,
+		bundleName: types.NamespacedName{Namespace: "default", Name: "shop"},
+		workName:   WorkName(bundle),
+	}
+	env.decide(soakClusters)
+	return env
+}
+
+// configMap returns the manifest of the bundle, in the canonical encoding of the works
+func configMap(version string) workapiv1.Manifest {
+	return workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(fmt.Sprintf(
+		`{"apiVersion":"v1","data":{"version":%q},"kind":"ConfigMap","metadata":{"name":"shop","namespace":"default"}}`, version))}}
+}
+
+// decide places the bundle on the first n clusters
+func (e *soakEnv) decide(n int) {
+	decided := []clusterv1alpha1.ClusterDecision{}
+	for i := 0; i < n; i++ {
+		decided = append(decided, clusterv1alpha1.ClusterDecision{ClusterName: fmt.Sprintf("cluster%d", i)})
+	}
+	_ = e.decisions.Update(&clusterv1alpha1.PlacementDecision{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "fleet-decision-1",
+			Namespace: "default",
+			Labels:    map[string]string{PlacementLabel: "fleet"},
+		},
+		Status: clusterv1alpha1.PlacementDecisionStatus{Decisions: decided},
+	})
+}
+
+// converge reconciles the bundle until its works match the expected clusters and
+// version, failing the test if they do not within soakMaxReconciles reconciles
+func (e *soakEnv) converge(t *testing.T, clusters int, version string) {
+	for i := 0; i < soakMaxReconciles; i++ {
+		// the errors injected are retried by the next reconciles
+		_, _ = e.r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: e.bundleName})
+		if e.converged(clusters, version) {
+			t.Logf("converged to %d clusters with %s in %d reconciles, %d failures injected",
+				clusters, version, i+1, e.injector.Failures())
+			return
+		}
+	}
+	t.Fatalf("not converged to %d clusters with %s after %d reconciles", clusters, version, soakMaxReconciles)
+}
+
+// converged reads the works and the bundle without injecting faults
+func (e *soakEnv) converged(clusters int, version string) bool {
+	works := e.works.Tracker()
+	count := 0
+	for i := 0; i < soakClusters; i++ {
+		obj, err := works.Get(workapiv1.GroupVersion.WithResource("manifestworks"), fmt.Sprintf("cluster%d", i), e.workName)
+		if err != nil {
+			continue
+		}
+		work := obj.(*workapiv1.ManifestWork)
+		if i >= clusters || len(work.Spec.Workload.Manifests) != 1 ||
+			string(work.Spec.Workload.Manifests[0].Raw) != string(configMap(version).Raw) {
+			return false
+		}
+		count++
+	}
+	if count != clusters {
+		return false
+	}
+	var bundle appv1alpha1.AppBundle
+	if err := e.r.Get(context.TODO(), e.bundleName, &bundle); err != nil {
+		return false
+	}
+	return len(bundle.Status.Clusters) == clusters
+}
+
+// update changes the version of the ConfigMap of the bundle
+func (e *soakEnv) update(t *testing.T, version string) {
+	var bundle appv1alpha1.AppBundle
+	if err := e.r.Get(context.TODO(), e.bundleName, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	bundle.Spec.Workload.Manifests = []workapiv1.Manifest{configMap(version)}
+	if err := e.r.Update(context.TODO(), &bundle); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSoakFanOut(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		t.Run(fmt.Sprintf("seed%d", seed), func(t *testing.T) {
+			e := newSoakEnv(t, seed)
+			e.converge(t, soakClusters, "v1")
+
+			e.update(t, "v2")
+			e.converge(t, soakClusters, "v2")
+
+			// the works of the clusters left by the placement are deleted
+			e.decide(soakClusters / 2)
+			e.converge(t, soakClusters/2, "v2")
+
+			e.decide(soakClusters)
+			e.update(t, "v3")
+			e.converge(t, soakClusters, "v3")
+			if e.injector.Failures() == 0 {
+				t.Fatal("no failures injected")
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/stall"
+)
+
+const (
+	// defaultStallTimeout is the timeout of the stalled works when not configured
+	defaultStallTimeout = 15 * time.Minute
+	// stalledRecreateRetry is the delay before creating the deleted stalled works again
+	stalledRecreateRetry = 5 * time.Second
+)
+
+// detectStalled marks the clusters of the bundle whose work is stalled, reporting the
+// newly stalled ones in events, and deletes their works when they are recreated. It
+// returns the delay until the next check, when works may still stall or were deleted.
+func (r *AppBundleReconciler) detectStalled(ctx context.Context, bundle *appv1alpha1.AppBundle, previous []appv1alpha1.ClusterStatus, cfg *appv1alpha1.KealmConfigSpec, scheduled *scheduleResult) (time.Duration, error) {
+	if cfg.StalledWorks == nil {
+		return 0, nil
+	}
+	timeout := defaultStallTimeout
+	if cfg.StalledWorks.Timeout != nil {
+		timeout = cfg.StalledWorks.Timeout.Duration
+	}
+	wasStalled := map[string]bool{}
+	for _, s := range previous {
+		wasStalled[s.ClusterName] = s.Stalled
+	}
+	now := time.Now()
+	var recheck time.Duration
+	recreated := []string{}
+	for i := range bundle.Status.Clusters {
+		s := &bundle.Status.Clusters[i]
+		written, ok := scheduled.written[s.ClusterName]
+		if !ok {
+			// the clusters not written keep their status
+			continue
+		}
+		stalled, next := stall.Check(stall.Work{
+			Written:    written,
+			Generation: scheduled.generations[s.ClusterName],
+			Conditions: scheduled.conditions[s.ClusterName],
+		}, timeout, now)
+		if next > 0 && (recheck == 0 || next < recheck) {
+			recheck = next
+		}
+		if !stalled {
+			continue
+		}
+		s.Stalled = true
+		if !wasStalled[s.ClusterName] {
+			message := fmt.Sprintf("Work %s not applied on cluster %s within %s of its write", s.WorkName, s.ClusterName, timeout)
+			// attribute the stall to the agents of the cluster when they are not healthy
+			if h := s.Health; h != nil && h.Status != appv1alpha1.ClusterHealthy {
+				message += fmt.Sprintf(", the cluster is %s: %s", h.Status, h.Message)
+			}
+			r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonWorkStalled, message)
+		}
+		if cfg.StalledWorks.Recreate {
+			recreated = append(recreated, s.ClusterName)
+		}
+	}
+	if len(recreated) == 0 {
+		return recheck, nil
+	}
+	writer, err := r.workWriter(ctx, bundle.Namespace)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range recreated {
+		klog.Infof("Deleting stalled manifest %s for cluster %s to recreate it", WorkName(bundle), c)
+		if err := waitForWrite(r.WriteLimiter); err != nil {
+			return 0, err
+		}
+		// orphan the resources of the work, the agent may be working and the workload
+		// must survive the recreation
+		work, err := writer.ManifestWorks(c).Get(ctx, WorkName(bundle), v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if work.Spec.DeleteOption == nil || work.Spec.DeleteOption.PropagationPolicy != workapiv1.DeletePropagationPolicyTypeOrphan {
+			work.Spec.DeleteOption = &workapiv1.DeleteOption{PropagationPolicy: workapiv1.DeletePropagationPolicyTypeOrphan}
+			if _, err := writer.ManifestWorks(c).Update(ctx, work, v1.UpdateOptions{}); err != nil {
+				return 0, err
+			}
+			if err := waitForWrite(r.WriteLimiter); err != nil {
+				return 0, err
+			}
+		}
+		err = writer.ManifestWorks(c).Delete(ctx, WorkName(bundle), v1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return 0, err
+		}
+		r.Recorder.Event(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonWorkRecreated,
+			fmt.Sprintf("Stalled work %s deleted from cluster %s to be created again", WorkName(bundle), c))
+	}
+	if recheck == 0 || stalledRecreateRetry < recheck {
+		recheck = stalledRecreateRetry
+	}
+	return recheck, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/flowcontrol"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// startupStagger spreads the first reconcile of the bundles already distributed
+// before the controller started over a time window, instead of resyncing them all at once
+type startupStagger struct {
+	mu      sync.Mutex
+	window  time.Duration
+	started time.Time
+	seen    sets.String
+}
+
+func newStartupStagger(window time.Duration) *startupStagger {
+	return &startupStagger{window: window, started: time.Now(), seen: sets.NewString()}
+}
+
+// delay returns how long to defer the reconcile of the bundle, 0 if it can proceed
+func (s *startupStagger) delay(bundle *appv1alpha1.AppBundle) time.Duration {
+	if s == nil || s.window <= 0 || len(bundle.Status.Clusters) == 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elapsed := time.Since(s.started)
+	if elapsed >= s.window {
+		s.seen = nil
+		return 0
+	}
+	key := bundle.Namespace + "/" + bundle.Name
+	if s.seen.Has(key) {
+		return 0
+	}
+	s.seen.Insert(key)
+	return time.Duration(rand.Int63n(int64(s.window))) - elapsed
+}
+
+// waitForWrite blocks until the global ManifestWork write budget allows one more write
+func waitForWrite(limiter flowcontrol.RateLimiter) error {
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(context.TODO())
+}
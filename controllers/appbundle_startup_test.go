@@ -0,0 +1,54 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestStartupStagger(t *testing.T) {
+	distributed := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "default"}}
+	distributed.Status.Clusters = []appv1alpha1.ClusterStatus{{ClusterName: "cluster1"}}
+	fresh := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "cart", Namespace: "default"}}
+
+	s := newStartupStagger(time.Hour)
+	if delay := s.delay(fresh); delay != 0 {
+		t.Errorf("expected the bundle not distributed yet to proceed, got %s", delay)
+	}
+	if delay := s.delay(distributed); delay >= time.Hour {
+		t.Errorf("expected the resync to be deferred within the window, got %s", delay)
+	}
+	if delay := s.delay(distributed); delay != 0 {
+		t.Errorf("expected the deferred resync to proceed, got %s", delay)
+	}
+
+	// the bundles resynced after the window proceed
+	s = newStartupStagger(time.Hour)
+	s.started = time.Now().Add(-2 * time.Hour)
+	if delay := s.delay(distributed); delay != 0 {
+		t.Errorf("expected the resync after the window to proceed, got %s", delay)
+	}
+	var disabled *startupStagger
+	if delay := disabled.delay(distributed); delay != 0 {
+		t.Errorf("expected no delay without a window, got %s", delay)
+	}
+}
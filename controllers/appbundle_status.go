@@ -0,0 +1,108 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	clusterlisterv1alpha1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1alpha1"
+	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+)
+
+const (
+	// minPlacementBackoff and maxPlacementBackoff bound the requeue delay used while
+	// waiting for a placement decision to appear
+	minPlacementBackoff = 5 * time.Second
+	maxPlacementBackoff = 5 * time.Minute
+)
+
+// setCondition sets a condition on the bundle status, preserving the transition time
+// when the status is unchanged
+func setCondition(bundle *appv1alpha1.AppBundle, condType string, status v1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&bundle.Status.Conditions, v1.Condition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: bundle.Generation,
+	})
+}
+
+// removeCondition removes a condition no longer relevant, e.g. of a feature disabled
+// on the bundle
+func removeCondition(bundle *appv1alpha1.AppBundle, condType string) {
+	meta.RemoveStatusCondition(&bundle.Status.Conditions, condType)
+}
+
+// placementBackoff returns the delay before checking again for a missing placement
+// decision. The delay doubles with every retry as it matches the time already spent
+// waiting since the condition was first set.
+func placementBackoff(bundle *appv1alpha1.AppBundle) time.Duration {
+	cond := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionPlacementResolved)
+	if cond == nil || cond.Status != v1.ConditionFalse {
+		return minPlacementBackoff
+	}
+	backoff := time.Since(cond.LastTransitionTime.Time)
+	if backoff < minPlacementBackoff {
+		return minPlacementBackoff
+	}
+	if backoff > maxPlacementBackoff {
+		return maxPlacementBackoff
+	}
+	return backoff
+}
+
+// updateStatus writes the status of the bundle, through the batcher when the status
+// writes are coalesced
+func (r *AppBundleReconciler) updateStatus(ctx context.Context, bundle *appv1alpha1.AppBundle) error {
+	if r.statuses != nil {
+		return IgnoreConflict(r.statuses.Write(ctx, bundle))
+	}
+	return IgnoreConflict(r.Status().Update(ctx, bundle))
+}
+
+// CheckPlacement verifies that the named placement exists and that its requirements are
+// satisfied. It returns the status of the check together with a reason and a message
+// suitable for a condition or an admission warning.
+func CheckPlacement(lister clusterlisterv1alpha1.PlacementLister, namespace, name string) (v1.ConditionStatus, string, string, error) {
+	placement, err := lister.Placements(namespace).Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return v1.ConditionFalse, appv1alpha1.ReasonPlacementNotFound,
+				fmt.Sprintf("Placement %s not found in namespace %s", name, namespace), nil
+		}
+		return v1.ConditionUnknown, "", "", err
+	}
+	cond := meta.FindStatusCondition(placement.Status.Conditions, clusterapiv1alpha1.PlacementConditionSatisfied)
+	switch {
+	case cond == nil:
+		return v1.ConditionUnknown, appv1alpha1.ReasonPlacementUnsatisfied,
+			fmt.Sprintf("Placement %s has not been scheduled yet", name), nil
+	case cond.Status != v1.ConditionTrue:
+		return v1.ConditionFalse, appv1alpha1.ReasonPlacementUnsatisfied,
+			fmt.Sprintf("Placement %s is not satisfied: %s", name, cond.Message), nil
+	}
+	return v1.ConditionTrue, appv1alpha1.ReasonPlacementSatisfied,
+		fmt.Sprintf("Placement %s is satisfied", name), nil
+}
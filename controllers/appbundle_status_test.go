@@ -0,0 +1,131 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestPlacementBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   v1.ConditionStatus
+		since    time.Duration
+		expected time.Duration
+	}{
+		{"no condition", "", 0, minPlacementBackoff},
+		{"placement resolved", v1.ConditionTrue, time.Hour, minPlacementBackoff},
+		{"just missing", v1.ConditionFalse, time.Second, minPlacementBackoff},
+		{"missing for a while", v1.ConditionFalse, 2 * time.Minute, 2 * time.Minute},
+		{"missing for long", v1.ConditionFalse, time.Hour, maxPlacementBackoff},
+	} {
+		bundle := &appv1alpha1.AppBundle{}
+		if tc.status != "" {
+			bundle.Status.Conditions = []v1.Condition{{Type: appv1alpha1.ConditionPlacementResolved, Status: tc.status,
+				LastTransitionTime: v1.NewTime(time.Now().Add(-tc.since))}}
+		}
+		// the delay elapsed since the condition was set grows while the test runs
+		if backoff := placementBackoff(bundle); backoff < tc.expected || backoff > tc.expected+time.Second {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, backoff)
+		}
+	}
+}
+
+func TestReconcileMissingPlacementDecision(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "default", UID: "uid",
+		Labels: map[string]string{PlacementLabel: "fleet"}}}
+	f := newFixture(t, bundle)
+	f.add(f.placements, &clusterv1alpha1.Placement{ObjectMeta: v1.ObjectMeta{Name: "fleet", Namespace: "default"}})
+	r := f.reconciler()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "shop"}}
+
+	// the missing decision is reported and checked again later instead of failing
+	result, err := r.Reconcile(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("expected no error while the decision is missing, got %v", err)
+	}
+	if result.RequeueAfter != minPlacementBackoff {
+		t.Errorf("expected a requeue after %s, got %+v", minPlacementBackoff, result)
+	}
+	current := &appv1alpha1.AppBundle{}
+	if err := r.Get(context.TODO(), req.NamespacedName, current); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(current.Status.Conditions, appv1alpha1.ConditionPlacementResolved)
+	if cond == nil || cond.Status != v1.ConditionFalse || cond.Reason != appv1alpha1.ReasonPlacementDecisionNotFound {
+		t.Fatalf("expected the PlacementDecisionNotFound condition, got %+v", cond)
+	}
+	if len(f.recorder.Events) != 1 {
+		t.Errorf("expected the missing decision to be reported, got %d events", len(f.recorder.Events))
+	}
+
+	// the delay grows with the time the decision has been missing
+	meta.FindStatusCondition(current.Status.Conditions, appv1alpha1.ConditionPlacementResolved).LastTransitionTime =
+		v1.NewTime(time.Now().Add(-time.Minute))
+	if err := r.Status().Update(context.TODO(), current); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = r.Reconcile(context.TODO(), req); err != nil {
+		t.Fatal(err)
+	}
+	if result.RequeueAfter < time.Minute || result.RequeueAfter > time.Minute+time.Second {
+		t.Errorf("expected a requeue after about a minute, got %+v", result)
+	}
+}
+
+func TestCheckPlacement(t *testing.T) {
+	f := newFixture(t)
+	scheduled := func(name string, status v1.ConditionStatus) *clusterv1alpha1.Placement {
+		placement := &clusterv1alpha1.Placement{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"}}
+		placement.Status.Conditions = []v1.Condition{{Type: clusterv1alpha1.PlacementConditionSatisfied, Status: status}}
+		return placement
+	}
+	f.add(f.placements,
+		&clusterv1alpha1.Placement{ObjectMeta: v1.ObjectMeta{Name: "pending", Namespace: "default"}},
+		scheduled("satisfied", v1.ConditionTrue),
+		scheduled("unsatisfied", v1.ConditionFalse),
+	)
+	r := f.reconciler()
+	for _, tc := range []struct {
+		placement string
+		status    v1.ConditionStatus
+		reason    string
+	}{
+		{"missing", v1.ConditionFalse, appv1alpha1.ReasonPlacementNotFound},
+		{"pending", v1.ConditionUnknown, appv1alpha1.ReasonPlacementUnsatisfied},
+		{"unsatisfied", v1.ConditionFalse, appv1alpha1.ReasonPlacementUnsatisfied},
+		{"satisfied", v1.ConditionTrue, appv1alpha1.ReasonPlacementSatisfied},
+	} {
+		status, reason, message, err := CheckPlacement(r.PlacementLister, "default", tc.placement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != tc.status || reason != tc.reason || message == "" {
+			t.Errorf("%s: expected %s %s, got %s %s %q", tc.placement, tc.status, tc.reason, status, reason, message)
+		}
+	}
+}
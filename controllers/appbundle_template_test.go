@@ -0,0 +1,67 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestCheckCatalogTenant(t *testing.T) {
+	tenants := &v1.LabelSelector{MatchLabels: map[string]string{"catalog": "platform"}}
+	r := newFixture(t,
+		&corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "shop", Labels: map[string]string{"catalog": "platform"}}},
+		&corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "lab"}},
+		&appv1alpha1.Catalog{ObjectMeta: v1.ObjectMeta{Name: "platform", Namespace: "catalog"},
+			Spec: appv1alpha1.CatalogSpec{Tenants: tenants}},
+		&appv1alpha1.Catalog{ObjectMeta: v1.ObjectMeta{Name: "private", Namespace: "catalog"}},
+	).reconciler()
+	tests := []struct {
+		name      string
+		namespace string
+		catalog   string
+		bundle    string
+		allowed   bool
+	}{
+		{"same namespace", "shop", "", "shop", true},
+		{"other namespace without catalog", "team", "", "shop", false},
+		{"catalog tenant", "catalog", "platform", "shop", true},
+		{"not a catalog tenant", "catalog", "platform", "lab", false},
+		{"catalog without tenants", "catalog", "private", "shop", false},
+		{"catalog namespace", "catalog", "private", "catalog", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := &appv1alpha1.AppBundleTemplate{ObjectMeta: v1.ObjectMeta{Name: "nginx", Namespace: tt.namespace}}
+			if tt.catalog != "" {
+				tmpl.Labels = map[string]string{CatalogLabel: tt.catalog}
+			}
+			err := r.checkCatalogTenant(context.TODO(), tmpl, tt.bundle)
+			if tt.allowed && err != nil {
+				t.Errorf("expected the template allowed, got %v", err)
+			}
+			if !tt.allowed && err == nil {
+				t.Error("expected the template rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	workv1client "open-cluster-management.io/api/client/work/clientset/versioned"
+	workv1 "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
+)
+
+// TenantOf returns the KealmTenant of a namespace, nil when the namespace is not a
+// tenant
+func TenantOf(ctx context.Context, c client.Reader, namespace string) (*appv1alpha1.KealmTenant, error) {
+	var tenants appv1alpha1.KealmTenantList
+	if err := c.List(ctx, &tenants, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	switch len(tenants.Items) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &tenants.Items[0], nil
+	}
+	return nil, fmt.Errorf("namespace %s has %d KealmTenants, expected at most one", namespace, len(tenants.Items))
+}
+
+// tenantUser returns the ServiceAccount user of the KealmTenant of a namespace, empty
+// when the namespace is not a tenant
+func (r *AppBundleReconciler) tenantUser(ctx context.Context, namespace string) (string, error) {
+	tenant, err := TenantOf(ctx, r.Client, namespace)
+	if err != nil {
+		return "", err
+	}
+	if tenant == nil || tenant.Spec.ServiceAccountName == "" {
+		return "", nil
+	}
+	if r.RestConfig == nil {
+		return "", fmt.Errorf("namespace %s is a tenant and impersonation is not configured", namespace)
+	}
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, tenant.Spec.ServiceAccountName), nil
+}
+
+// impersonating returns a copy of the rest config of the controller impersonating user
+func (r *AppBundleReconciler) impersonating(user string) *rest.Config {
+	config := rest.CopyConfig(r.RestConfig)
+	config.Impersonate = rest.ImpersonationConfig{UserName: user}
+	return config
+}
+
+// workWriter returns the client writing the ManifestWorks of the bundles of a namespace:
+// the controller client, or a client impersonating the ServiceAccount of the
+// KealmTenant of the namespace
+func (r *AppBundleReconciler) workWriter(ctx context.Context, namespace string) (workv1.WorkV1Interface, error) {
+	user, err := r.tenantUser(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if user == "" {
+		return r.WorkClient.WorkV1(), nil
+	}
+	r.tenantsLock.Lock()
+	defer r.tenantsLock.Unlock()
+	if c, ok := r.tenantClients[user]; ok {
+		return c.WorkV1(), nil
+	}
+	c, err := workv1client.NewForConfig(r.impersonating(user))
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate %s: %w", user, err)
+	}
+	if r.tenantClients == nil {
+		r.tenantClients = map[string]*workv1client.Clientset{}
+	}
+	r.tenantClients[user] = c
+	return c.WorkV1(), nil
+}
+
+// claimWriter returns the client applying the claims of the bundles of a namespace on
+// the hub: the controller client, or a client impersonating the ServiceAccount of the
+// KealmTenant of the namespace
+func (r *AppBundleReconciler) claimWriter(ctx context.Context, namespace string) (client.Client, error) {
+	user, err := r.tenantUser(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if user == "" {
+		return r.Client, nil
+	}
+	r.tenantsLock.Lock()
+	defer r.tenantsLock.Unlock()
+	if c, ok := r.claimClients[user]; ok {
+		return c, nil
+	}
+	c, err := client.New(r.impersonating(user), client.Options{Scheme: r.Scheme, Mapper: r.RESTMapper()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate %s: %w", user, err)
+	}
+	if r.claimClients == nil {
+		r.claimClients = map[string]client.Client{}
+	}
+	r.claimClients[user] = c
+	return c, nil
+}
+
+// bundlesForTenant maps a tenant to the bundles of its namespace
+func (r *AppBundleReconciler) bundlesForTenant(obj client.Object) []reconcile.Request {
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(context.TODO(), &bundles, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.Errorf("Failed to list AppBundles for KealmTenant %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, bundle := range bundles.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// impersonatedHub records the users impersonated by the requests, echoing the
+// objects created
+type impersonatedHub struct {
+	mu    sync.Mutex
+	users []string
+}
+
+func (h *impersonatedHub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.mu.Lock()
+	h.users = append(h.users, req.Header.Get("Impersonate-User"))
+	h.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(body)
+}
+
+func TestWorkWriter(t *testing.T) {
+	hub := &impersonatedHub{}
+	server := httptest.NewServer(hub)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		tenant     *appv1alpha1.KealmTenant
+		restConfig bool
+		user       string
+		wantErr    bool
+	}{
+		{name: "not a tenant", restConfig: true},
+		{name: "tenant without ServiceAccount", restConfig: true,
+			tenant: &appv1alpha1.KealmTenant{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "shop"}}},
+		{name: "tenant", restConfig: true, user: "system:serviceaccount:shop:deployer",
+			tenant: &appv1alpha1.KealmTenant{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "shop"},
+				Spec: appv1alpha1.KealmTenantSpec{ServiceAccountName: "deployer"}}},
+		{name: "tenant without impersonation", wantErr: true,
+			tenant: &appv1alpha1.KealmTenant{ObjectMeta: v1.ObjectMeta{Name: "shop", Namespace: "shop"},
+				Spec: appv1alpha1.KealmTenantSpec{ServiceAccountName: "deployer"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.tenant != nil {
+				f.builder.WithObjects(tt.tenant)
+			}
+			r := f.reconciler()
+			if tt.restConfig {
+				r.RestConfig = &rest.Config{Host: server.URL}
+			}
+			hub.users = nil
+
+			writer, err := r.workWriter(context.TODO(), "shop")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error without impersonation")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			work := &workapiv1.ManifestWork{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "cluster1"}}
+			if _, err := writer.ManifestWorks("cluster1").Create(context.TODO(), work, v1.CreateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.user == "" {
+				if len(f.works.Actions()) != 1 || len(hub.users) != 0 {
+					t.Errorf("expected the work written by the controller, got %d actions and users %v", len(f.works.Actions()), hub.users)
+				}
+				return
+			}
+			if len(f.works.Actions()) != 0 || len(hub.users) != 1 || hub.users[0] != tt.user {
+				t.Errorf("expected the work written as %s, got %d actions and users %v", tt.user, len(f.works.Actions()), hub.users)
+			}
+			// the client of the tenant is reused
+			if _, err := r.workWriter(context.TODO(), "shop"); err != nil {
+				t.Fatal(err)
+			}
+			if len(r.tenantClients) != 1 {
+				t.Errorf("expected the client of the tenant to be cached, got %d", len(r.tenantClients))
+			}
+		})
+	}
+}
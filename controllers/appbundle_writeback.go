@@ -0,0 +1,124 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/plugins"
+	"github.com/pdettori/kealm/pkg/writeback"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// writeBackRetry is the delay before writing again the state of a bundle whose
+	// write to Git failed
+	writeBackRetry = time.Minute
+
+	// writeBackTokenKey is the key of the token in the credentials Secret of the write
+	// back
+	writeBackTokenKey = "token"
+)
+
+// writeBack commits the state resolved for the clusters of the bundle to its Git
+// repository when it changed since the last write, and returns the delay before
+// retrying a failed write
+func (r *AppBundleReconciler) writeBack(ctx context.Context, bundle *appv1alpha1.AppBundle, ms []workapiv1.Manifest, cfg *appv1alpha1.KealmConfigSpec) time.Duration {
+	wb := bundle.Spec.WriteBack
+	if wb == nil {
+		bundle.Status.WriteBack = nil
+		removeCondition(bundle, appv1alpha1.ConditionWrittenBack)
+		return 0
+	}
+	state := writeback.State{Bundle: bundle.Namespace + "/" + bundle.Name, Generation: bundle.Generation}
+	for _, c := range bundle.Status.Clusters {
+		if c.Digest != "" {
+			state.Clusters = append(state.Clusters, writeback.Cluster{Name: c.ClusterName, Digest: c.Digest})
+		}
+	}
+	digest := state.Digest()
+	if s := bundle.Status.WriteBack; s != nil && s.Digest == digest &&
+		meta.IsStatusConditionTrue(bundle.Status.Conditions, appv1alpha1.ConditionWrittenBack) {
+		return 0
+	}
+	written, err := r.writeState(ctx, bundle, state, ms, cfg)
+	if err != nil {
+		message := fmt.Sprintf("Failed to write the state of generation %d to %s: %v", bundle.Generation, wb.URL, err)
+		if c := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionWrittenBack); c == nil || c.Message != message {
+			r.Recorder.Event(bundle, corev1.EventTypeWarning, appv1alpha1.ReasonWriteBackFailed, message)
+		}
+		setCondition(bundle, appv1alpha1.ConditionWrittenBack, v1.ConditionFalse, appv1alpha1.ReasonWriteBackFailed, message)
+		return writeBackRetry
+	}
+	if len(written) > 0 {
+		r.Recorder.Eventf(bundle, corev1.EventTypeNormal, appv1alpha1.ReasonWrittenBack,
+			"Wrote the state of generation %d to %d files of %s", bundle.Generation, len(written), wb.URL)
+	}
+	bundle.Status.WriteBack = &appv1alpha1.WriteBackStatus{Generation: bundle.Generation, Digest: digest, Time: v1.Now()}
+	setCondition(bundle, appv1alpha1.ConditionWrittenBack, v1.ConditionTrue, appv1alpha1.ReasonWrittenBack,
+		fmt.Sprintf("The state of generation %d is written to %s", bundle.Generation, wb.URL))
+	return 0
+}
+
+// writeState renders the manifests of the clusters of the state and writes its files,
+// returning the written ones
+func (r *AppBundleReconciler) writeState(ctx context.Context, bundle *appv1alpha1.AppBundle, state writeback.State, ms []workapiv1.Manifest, cfg *appv1alpha1.KealmConfigSpec) ([]string, error) {
+	wb := bundle.Spec.WriteBack
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: bundle.Namespace, Name: wb.CredentialsSecret}, secret); err != nil {
+		return nil, err
+	}
+	token := string(secret.Data[writeBackTokenKey])
+	if token == "" {
+		return nil, fmt.Errorf("no %s in Secret %s", writeBackTokenKey, wb.CredentialsSecret)
+	}
+	chain, err := plugins.Load(cfg.DistributionPlugins, r.WASMRuntime)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := r.claimOutputs(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range state.Clusters {
+		if state.Clusters[i].Manifests, _, _, err = r.clusterManifests(ctx, bundle, c.Name, ms, chain, outputs); err != nil {
+			return nil, fmt.Errorf("failed to render the manifests of cluster %s: %w", c.Name, err)
+		}
+	}
+	dir := wb.Path
+	if dir == "" {
+		dir = bundle.Namespace + "/" + bundle.Name
+	}
+	files, err := writeback.Files(state, dir, wb.Content == appv1alpha1.WriteBackManifests)
+	if err != nil {
+		return nil, err
+	}
+	branch := wb.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	message := fmt.Sprintf("Record AppBundle %s generation %d", state.Bundle, bundle.Generation)
+	return r.GitWriter.Write(ctx, wb.URL, branch, token, files, message)
+}
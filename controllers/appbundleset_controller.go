@@ -0,0 +1,312 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/bundleset"
+	"github.com/pdettori/kealm/pkg/config"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+const (
+	// BundleSetLabel on an AppBundle references the AppBundleSet generating it
+	BundleSetLabel = "cluster.open-cluster-management.io/bundle-set"
+
+	// BundleSetClusterLabel on an AppBundle generated for a cluster restricts its
+	// distribution to that cluster
+	BundleSetClusterLabel = "cluster.open-cluster-management.io/bundle-set-cluster"
+)
+
+// defaultGitDirectoryInterval is the interval between two listings of the directories
+// of the gitDirectories generators without interval
+const defaultGitDirectoryInterval = 3 * time.Minute
+
+// AppBundleSetReconciler generates the AppBundles of the AppBundleSets and deletes the
+// bundles no longer generated
+type AppBundleSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	ManagedClusterLister   clusterlisterv1.ManagedClusterLister
+	ManagedClusterInformer cache.SharedIndexInformer
+	Directories            *bundleset.DirectoryLister
+	// Config holds the Git hosts the directories are listed from
+	Config *config.Store
+}
+
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundlesets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundlesets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundles,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile creates or updates the bundles of the parameter sets generated for a set,
+// and deletes the bundles of the set no longer generated
+func (r *AppBundleSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	set := &appv1alpha1.AppBundleSet{}
+	if err := r.Get(ctx, req.NamespacedName, set); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !set.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	s := set.DeepCopy()
+	params, requeue, err := r.generate(ctx, s)
+	if err != nil {
+		r.setCondition(s, v1.ConditionFalse, appv1alpha1.ReasonGeneratorFailed, err.Error())
+		return ctrl.Result{RequeueAfter: requeue}, r.updateSetStatus(ctx, set, s)
+	}
+	bundles, err := r.renderBundles(s, params)
+	if err != nil {
+		r.setCondition(s, v1.ConditionFalse, appv1alpha1.ReasonGeneratorFailed, err.Error())
+		return ctrl.Result{RequeueAfter: requeue}, r.updateSetStatus(ctx, set, s)
+	}
+
+	generated := map[string]bool{}
+	for _, desired := range bundles {
+		if err := r.applyBundle(ctx, s, desired); err != nil {
+			return ctrl.Result{}, err
+		}
+		generated[desired.Name] = true
+	}
+	if err := r.deleteStaleBundles(ctx, s, generated); err != nil {
+		return ctrl.Result{}, err
+	}
+	s.Status.Bundles = int32(len(bundles))
+	r.setCondition(s, v1.ConditionTrue, appv1alpha1.ReasonBundlesGenerated,
+		fmt.Sprintf("%d bundles generated", len(bundles)))
+	return ctrl.Result{RequeueAfter: requeue}, r.updateSetStatus(ctx, set, s)
+}
+
+// generate returns the matrix of the parameter sets of the generators, and the
+// interval to the next listing of the directories of the Git repositories
+func (r *AppBundleSetReconciler) generate(ctx context.Context, s *appv1alpha1.AppBundleSet) ([]bundleset.Params, time.Duration, error) {
+	var sets, clusters []bundleset.Params
+	var requeue time.Duration
+	for i, g := range s.Spec.Generators {
+		switch {
+		case g.Clusters != nil:
+			if clusters != nil {
+				return nil, requeue, fmt.Errorf("generator %d: a set has at most one clusters generator", i)
+			}
+			selector := labels.Everything()
+			if g.Clusters.Selector != nil {
+				var err error
+				if selector, err = v1.LabelSelectorAsSelector(g.Clusters.Selector); err != nil {
+					return nil, requeue, fmt.Errorf("generator %d: %w", i, err)
+				}
+			}
+			selected, err := r.ManagedClusterLister.List(selector)
+			if err != nil {
+				return nil, requeue, err
+			}
+			clusters = []bundleset.Params{}
+			for _, c := range selected {
+				clusters = append(clusters, bundleset.ClusterParams(manifests.NewClusterContext(c)))
+			}
+		case g.List != nil:
+			for _, e := range g.List.Elements {
+				sets = append(sets, bundleset.Params(e))
+			}
+		case g.GitDirectories != nil:
+			interval := defaultGitDirectoryInterval
+			if g.GitDirectories.Interval != nil {
+				interval = g.GitDirectories.Interval.Duration
+			}
+			if requeue == 0 || interval < requeue {
+				requeue = interval
+			}
+			dirs, err := r.Directories.Directories(ctx, g.GitDirectories, r.Config.Get().GitHosts)
+			if err != nil {
+				return nil, requeue, fmt.Errorf("generator %d: %w", i, err)
+			}
+			if sets == nil {
+				sets = []bundleset.Params{}
+			}
+			for _, d := range dirs {
+				sets = append(sets, bundleset.DirectoryParams(d))
+			}
+		default:
+			return nil, requeue, fmt.Errorf("generator %d has no generator set", i)
+		}
+	}
+	return bundleset.Matrix(sets, clusters), requeue, nil
+}
+
+// renderBundles returns the bundles of the parameter sets, failing on invalid or
+// duplicate names. The template may not grant hub access, the bundles being authored by
+// the controller rather than by the author of the set.
+func (r *AppBundleSetReconciler) renderBundles(s *appv1alpha1.AppBundleSet, params []bundleset.Params) ([]*appv1alpha1.AppBundle, error) {
+	if s.Spec.Template.Spec.HubAccess != nil {
+		return nil, fmt.Errorf("the template of AppBundleSet %s may not set hubAccess", s.Name)
+	}
+	bundles := []*appv1alpha1.AppBundle{}
+	names := map[string]bool{}
+	for _, p := range params {
+		t, err := bundleset.Render(&s.Spec.Template, p)
+		if err != nil {
+			return nil, err
+		}
+		name := bundleset.Name(s.Name, t, p)
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid bundle name %q: %v", name, errs)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("several parameter sets generate the bundle %s", name)
+		}
+		names[name] = true
+		b := &appv1alpha1.AppBundle{}
+		b.Name = name
+		b.Namespace = s.Namespace
+		b.Labels = map[string]string{}
+		for k, v := range t.Labels {
+			b.Labels[k] = v
+		}
+		b.Labels[BundleSetLabel] = s.Name
+		b.Annotations = t.Annotations
+		b.Spec = t.Spec
+		if cluster, ok := p[bundleset.ClusterNameParam]; ok {
+			b.Labels[BundleSetClusterLabel] = cluster
+			// the bundles of a cluster are distributed to all the clusters of the bound
+			// cluster sets, restricted to theirs, unless the template sets a placement
+			if _, ok := PlacementName(b); !ok {
+				b.Spec.PlacementPolicy = appv1alpha1.PlacementAllClusters
+			}
+		}
+		bundles = append(bundles, b)
+	}
+	return bundles, nil
+}
+
+// applyBundle creates or updates a generated bundle, merging its annotations into the
+// ones set by others, e.g. the author recorded by the attribution webhook
+func (r *AppBundleSetReconciler) applyBundle(ctx context.Context, s *appv1alpha1.AppBundleSet, desired *appv1alpha1.AppBundle) error {
+	bundle := &appv1alpha1.AppBundle{}
+	bundle.Name = desired.Name
+	bundle.Namespace = desired.Namespace
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, bundle, func() error {
+		// do not take over bundles not generated by this set
+		if bundle.ResourceVersion != "" && bundle.Labels[BundleSetLabel] != s.Name {
+			return fmt.Errorf("AppBundle %s/%s exists and is not generated by AppBundleSet %s",
+				bundle.Namespace, bundle.Name, s.Name)
+		}
+		bundle.Labels = desired.Labels
+		for k, v := range desired.Annotations {
+			if bundle.Annotations == nil {
+				bundle.Annotations = map[string]string{}
+			}
+			bundle.Annotations[k] = v
+		}
+		bundle.Spec = desired.Spec
+		return controllerutil.SetControllerReference(s, bundle, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+	if result != controllerutil.OperationResultNone {
+		klog.Infof("AppBundle %s/%s %s from AppBundleSet %s", bundle.Namespace, bundle.Name, result, s.Name)
+	}
+	return nil
+}
+
+// deleteStaleBundles deletes the bundles of the set no longer generated
+func (r *AppBundleSetReconciler) deleteStaleBundles(ctx context.Context, s *appv1alpha1.AppBundleSet, generated map[string]bool) error {
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(ctx, &bundles, client.InNamespace(s.Namespace), client.MatchingLabels{BundleSetLabel: s.Name}); err != nil {
+		return err
+	}
+	for i := range bundles.Items {
+		b := &bundles.Items[i]
+		if generated[b.Name] || !v1.IsControlledBy(b, s) {
+			continue
+		}
+		klog.Infof("AppBundle %s/%s no longer generated by AppBundleSet %s, deleting it", b.Namespace, b.Name, s.Name)
+		if err := r.Delete(ctx, b); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *AppBundleSetReconciler) setCondition(s *appv1alpha1.AppBundleSet, status v1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&s.Status.Conditions, v1.Condition{
+		Type:               appv1alpha1.ConditionBundlesGenerated,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: s.Generation,
+	})
+}
+
+func (r *AppBundleSetReconciler) updateSetStatus(ctx context.Context, old, s *appv1alpha1.AppBundleSet) error {
+	if equality.Semantic.DeepEqual(old.Status, s.Status) {
+		return nil
+	}
+	return IgnoreConflict(r.Status().Update(ctx, s))
+}
+
+// setsForManagedCluster maps a managed cluster to the sets with a clusters generator
+func (r *AppBundleSetReconciler) setsForManagedCluster(obj client.Object) []reconcile.Request {
+	var sets appv1alpha1.AppBundleSetList
+	if err := r.List(context.TODO(), &sets); err != nil {
+		klog.Errorf("Failed to list AppBundleSets for ManagedCluster %s: %v", obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, s := range sets.Items {
+		for _, g := range s.Spec.Generators {
+			if g.Clusters != nil {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: s.Namespace, Name: s.Name},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AppBundleSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appv1alpha1.AppBundleSet{}).
+		Owns(&appv1alpha1.AppBundle{}).
+		Watches(&source.Informer{Informer: r.ManagedClusterInformer},
+			handler.EnqueueRequestsFromMapFunc(r.setsForManagedCluster)).
+		Complete(r)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/audit"
+	"github.com/pdettori/kealm/pkg/bundleset"
+)
+
+func TestAppBundleSetBundles(t *testing.T) {
+	scheme := testScheme(t)
+	set := &appv1alpha1.AppBundleSet{ObjectMeta: v1.ObjectMeta{Name: "tenants", Namespace: "default", UID: "uid"}}
+	set.Spec.Template.Annotations = map[string]string{"team": "shop"}
+	params := []bundleset.Params{{"tenant": "a"}}
+
+	set.Spec.Template.Spec.HubAccess = &appv1alpha1.HubAccess{Role: "admin"}
+	r := &AppBundleSetReconciler{Scheme: scheme}
+	if _, err := r.renderBundles(set, params); err == nil {
+		t.Error("expected a template with hub access to be rejected")
+	}
+	set.Spec.Template.Spec.HubAccess = nil
+	bundles, err := r.renderBundles(set, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the author recorded by the attribution webhook is kept
+	existing := bundles[0].DeepCopy()
+	existing.Annotations = map[string]string{audit.ModifiedByAnnotation: "system:serviceaccount:kealm-system:kealm"}
+	r.Client = ctrl.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+	if err := r.applyBundle(context.TODO(), set, bundles[0]); err != nil {
+		t.Fatal(err)
+	}
+	applied := &appv1alpha1.AppBundle{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: bundles[0].Name}, applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied.Annotations["team"] != "shop" || applied.Annotations[audit.ModifiedByAnnotation] == "" {
+		t.Errorf("expected the annotations to be merged, got %v", applied.Annotations)
+	}
+	version := applied.ResourceVersion
+	if err := r.applyBundle(context.TODO(), set, bundles[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: bundles[0].Name}, applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied.ResourceVersion != version {
+		t.Error("expected an unchanged bundle not to be updated")
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/argocd"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+const (
+	// ArgoPlacementAnnotation on an Argo CD Application names the placement of the
+	// AppBundle generated from it
+	ArgoPlacementAnnotation = "cluster.open-cluster-management.io/placement"
+
+	// ArgoBundleNamespaceAnnotation on an Argo CD Application sets the namespace of
+	// the generated AppBundle, defaults to the namespace of the Application
+	ArgoBundleNamespaceAnnotation = "cluster.open-cluster-management.io/bundle-namespace"
+
+	// ArgoApplicationLabel on an AppBundle references the Application it is generated from
+	ArgoApplicationLabel = "cluster.open-cluster-management.io/argocd-application"
+)
+
+// ApplicationReconciler maintains an AppBundle for each Argo CD Application annotated
+// with a placement, with the manifests rendered by Argo CD
+type ApplicationReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Argo   *argocd.Client
+
+	// ResyncPeriod is how often the manifests are rendered again
+	ResyncPeriod time.Duration
+}
+
+//+kubebuilder:rbac:groups=argoproj.io,resources=applications,verbs=get;list;watch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundles,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile renders the manifests of an Application and updates its AppBundle
+func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(argocd.ApplicationGVK)
+	if err := r.Get(ctx, req.NamespacedName, app); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.deleteBundles(ctx, req.Namespace, req.Name)
+	}
+	placement, ok := app.GetAnnotations()[ArgoPlacementAnnotation]
+	if !ok || !app.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, r.deleteBundles(ctx, req.Namespace, req.Name)
+	}
+
+	rendered, err := r.Argo.Manifests(ctx, app.GetNamespace(), app.GetName())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	destination, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+	workload := []workapiv1.Manifest{}
+	for _, raw := range rendered {
+		u := &unstructured.Unstructured{}
+		if err := u.UnmarshalJSON(raw); err != nil {
+			return ctrl.Result{}, err
+		}
+		// Argo CD sets the destination namespace when syncing, do the same
+		if u.GetNamespace() == "" && destination != "" && !manifests.IsClusterScoped(u.GroupVersionKind().GroupKind()) {
+			u.SetNamespace(destination)
+		}
+		m, err := manifests.FromUnstructured(u)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		workload = append(workload, m)
+	}
+
+	namespace := app.GetNamespace()
+	if ns, ok := app.GetAnnotations()[ArgoBundleNamespaceAnnotation]; ok && ns != "" {
+		namespace = ns
+	}
+	bundle := &appv1alpha1.AppBundle{}
+	bundle.Name = app.GetName()
+	bundle.Namespace = namespace
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, bundle, func() error {
+		// do not take over bundles not generated from this Application
+		if bundle.ResourceVersion != "" && bundle.Labels[ArgoApplicationLabel] != applicationRef(app.GetNamespace(), app.GetName()) {
+			return fmt.Errorf("AppBundle %s/%s exists and is not generated from Application %s/%s",
+				namespace, bundle.Name, app.GetNamespace(), app.GetName())
+		}
+		if bundle.Labels == nil {
+			bundle.Labels = map[string]string{}
+		}
+		bundle.Labels[PlacementLabel] = placement
+		bundle.Labels[ArgoApplicationLabel] = applicationRef(app.GetNamespace(), app.GetName())
+		bundle.Spec.Workload.Manifests = workload
+		if namespace == app.GetNamespace() {
+			return controllerutil.SetControllerReference(app, bundle, r.Scheme)
+		}
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if result != controllerutil.OperationResultNone {
+		klog.Infof("AppBundle %s/%s %s from Application %s/%s", namespace, bundle.Name, result, app.GetNamespace(), app.GetName())
+	}
+	return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+}
+
+// deleteBundles deletes the AppBundles generated from an Application
+func (r *ApplicationReconciler) deleteBundles(ctx context.Context, namespace, name string) error {
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(ctx, &bundles, client.MatchingLabels{ArgoApplicationLabel: applicationRef(namespace, name)}); err != nil {
+		return err
+	}
+	for i := range bundles.Items {
+		klog.Infof("Deleting AppBundle %s/%s generated from Application %s/%s", bundles.Items[i].Namespace, bundles.Items[i].Name, namespace, name)
+		if err := r.Delete(ctx, &bundles.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applicationRef returns the value of the ArgoApplicationLabel for an Application
+func applicationRef(namespace, name string) string {
+	return namespace + "." + name
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(argocd.ApplicationGVK)
+	annotated := func(obj client.Object) bool {
+		_, ok := obj.GetAnnotations()[ArgoPlacementAnnotation]
+		return ok
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(app, builder.WithPredicates(predicate.Funcs{
+			CreateFunc:  func(e event.CreateEvent) bool { return annotated(e.Object) },
+			UpdateFunc:  func(e event.UpdateEvent) bool { return annotated(e.ObjectOld) || annotated(e.ObjectNew) },
+			DeleteFunc:  func(e event.DeleteEvent) bool { return annotated(e.Object) },
+			GenericFunc: func(e event.GenericEvent) bool { return annotated(e.Object) },
+		})).
+		Owns(&appv1alpha1.AppBundle{}).
+		Complete(r)
+}
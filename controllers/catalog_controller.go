@@ -0,0 +1,248 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/catalog"
+)
+
+// CatalogLabel on an AppBundleTemplate references the Catalog it is synced from
+const CatalogLabel = "cluster.open-cluster-management.io/catalog"
+
+// defaultCatalogInterval is the interval of the catalogs without interval
+const defaultCatalogInterval = 10 * time.Minute
+
+// CatalogReconciler syncs the AppBundleTemplates of the catalogs from their index and
+// grants their viewers read access to them
+type CatalogReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Fetcher  *catalog.Fetcher
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=catalogs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=catalogs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundletemplates,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile reads the index of a catalog, creates or updates its templates, deletes
+// the templates removed from the index and requeues for the next sync. The templates
+// last synced are kept while the index cannot be read.
+func (r *CatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	c := &appv1alpha1.Catalog{}
+	if err := r.Get(ctx, req.NamespacedName, c); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !c.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	interval := defaultCatalogInterval
+	if c.Spec.Interval != nil {
+		interval = c.Spec.Interval.Duration
+	}
+
+	updated := c.DeepCopy()
+	names, revision, err := r.sync(ctx, updated)
+	if err != nil {
+		klog.Errorf("Failed to sync Catalog %s: %v", req, err)
+		r.setCondition(updated, v1.ConditionFalse, appv1alpha1.ReasonCatalogSyncFailed, err.Error())
+		if !meta.IsStatusConditionFalse(c.Status.Conditions, appv1alpha1.ConditionCatalogSynced) {
+			r.Recorder.Event(c, corev1.EventTypeWarning, appv1alpha1.ReasonCatalogSyncFailed, err.Error())
+		}
+		return ctrl.Result{RequeueAfter: interval}, r.updateCatalogStatus(ctx, c, updated)
+	}
+	if revision != c.Status.Revision {
+		message := fmt.Sprintf("Synced %d templates at revision %s", len(names), revision)
+		klog.Infof("%s from Catalog %s", message, req)
+		r.Recorder.Event(c, corev1.EventTypeNormal, appv1alpha1.ReasonCatalogSynced, message)
+	}
+	now := v1.Now()
+	updated.Status.Revision = revision
+	updated.Status.LastSyncTime = &now
+	updated.Status.Templates = names
+	r.setCondition(updated, v1.ConditionTrue, appv1alpha1.ReasonCatalogSynced,
+		fmt.Sprintf("%d templates synced", len(names)))
+	return ctrl.Result{RequeueAfter: interval}, r.updateCatalogStatus(ctx, c, updated)
+}
+
+// sync applies the templates of the index of the catalog and returns their sorted
+// names and the revision of the index
+func (r *CatalogReconciler) sync(ctx context.Context, c *appv1alpha1.Catalog) ([]string, string, error) {
+	content, revision, err := r.Fetcher.Fetch(ctx, c.Spec)
+	if err != nil {
+		return nil, "", err
+	}
+	templates, err := catalog.Parse(content)
+	if err != nil {
+		return nil, "", err
+	}
+	names := []string{}
+	for i := range templates {
+		if err := r.applyTemplate(ctx, c, &templates[i]); err != nil {
+			return nil, "", err
+		}
+		names = append(names, templates[i].Name)
+	}
+	sort.Strings(names)
+	if err := r.deleteRemovedTemplates(ctx, c, names); err != nil {
+		return nil, "", err
+	}
+	if err := r.applyViewers(ctx, c, names); err != nil {
+		return nil, "", err
+	}
+	return names, revision, nil
+}
+
+func (r *CatalogReconciler) applyTemplate(ctx context.Context, c *appv1alpha1.Catalog, t *appv1alpha1.AppBundleTemplate) error {
+	tmpl := &appv1alpha1.AppBundleTemplate{}
+	tmpl.Name = t.Name
+	tmpl.Namespace = c.Namespace
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, tmpl, func() error {
+		// do not take over templates not synced from this catalog
+		if tmpl.ResourceVersion != "" && tmpl.Labels[CatalogLabel] != c.Name {
+			return fmt.Errorf("AppBundleTemplate %s/%s exists and is not synced from Catalog %s",
+				tmpl.Namespace, tmpl.Name, c.Name)
+		}
+		tmpl.Labels = map[string]string{}
+		for k, v := range t.Labels {
+			tmpl.Labels[k] = v
+		}
+		tmpl.Labels[CatalogLabel] = c.Name
+		tmpl.Annotations = t.Annotations
+		tmpl.Spec = t.Spec
+		return controllerutil.SetControllerReference(c, tmpl, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+	if result != controllerutil.OperationResultNone {
+		klog.Infof("AppBundleTemplate %s/%s %s from Catalog %s", tmpl.Namespace, tmpl.Name, result, c.Name)
+	}
+	return nil
+}
+
+// deleteRemovedTemplates deletes the templates of the catalog no longer in its index
+func (r *CatalogReconciler) deleteRemovedTemplates(ctx context.Context, c *appv1alpha1.Catalog, names []string) error {
+	var templates appv1alpha1.AppBundleTemplateList
+	if err := r.List(ctx, &templates, client.InNamespace(c.Namespace),
+		client.MatchingLabels{CatalogLabel: c.Name}); err != nil {
+		return err
+	}
+	for i := range templates.Items {
+		t := &templates.Items[i]
+		if j := sort.SearchStrings(names, t.Name); j < len(names) && names[j] == t.Name {
+			continue
+		}
+		klog.Infof("AppBundleTemplate %s/%s removed from Catalog %s, deleting it", t.Namespace, t.Name, c.Name)
+		if err := r.Delete(ctx, t); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyViewers grants the viewers of the catalog read access to its templates with a
+// Role and a RoleBinding named after the catalog, deleted when it has no viewer
+func (r *CatalogReconciler) applyViewers(ctx context.Context, c *appv1alpha1.Catalog, names []string) error {
+	key := types.NamespacedName{Namespace: c.Namespace, Name: catalogViewerName(c)}
+	role := &rbacv1.Role{ObjectMeta: v1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+	binding := &rbacv1.RoleBinding{ObjectMeta: v1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+	if len(c.Spec.Viewers) == 0 || len(names) == 0 {
+		for _, obj := range []client.Object{binding, role} {
+			if err := r.Get(ctx, key, obj); err != nil {
+				if client.IgnoreNotFound(err) != nil {
+					return err
+				}
+				continue
+			}
+			if v1.IsControlledBy(obj, c) {
+				if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Rules = []rbacv1.PolicyRule{{
+			APIGroups:     []string{appv1alpha1.GroupVersion.Group},
+			Resources:     []string{"appbundletemplates"},
+			ResourceNames: names,
+			Verbs:         []string{"get", "list", "watch"},
+		}}
+		return controllerutil.SetControllerReference(c, role, r.Scheme)
+	}); err != nil {
+		return err
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: role.Name}
+		binding.Subjects = c.Spec.Viewers
+		return controllerutil.SetControllerReference(c, binding, r.Scheme)
+	})
+	return err
+}
+
+// catalogViewerName returns the name of the Role and RoleBinding of the viewers of a
+// catalog
+func catalogViewerName(c *appv1alpha1.Catalog) string {
+	return c.Name + "-catalog-viewer"
+}
+
+func (r *CatalogReconciler) setCondition(c *appv1alpha1.Catalog, status v1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&c.Status.Conditions, v1.Condition{
+		Type:               appv1alpha1.ConditionCatalogSynced,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: c.Generation,
+	})
+}
+
+func (r *CatalogReconciler) updateCatalogStatus(ctx context.Context, old, c *appv1alpha1.Catalog) error {
+	if equality.Semantic.DeepEqual(old.Status, c.Status) {
+		return nil
+	}
+	return IgnoreConflict(r.Status().Update(ctx, c))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appv1alpha1.Catalog{}).
+		Owns(&appv1alpha1.AppBundleTemplate{}).
+		Complete(r)
+}
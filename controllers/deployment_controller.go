@@ -91,7 +91,7 @@ func (r *DeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if !isPresent || (isPresent && !isUptodate) {
 		appendOrUpdateDeploymentInAppBundle(deploy, bundle, updIndex)
 		if err = r.Update(ctx, bundle, &client.UpdateOptions{}); err != nil {
-			return ctrl.Result{}, err
+			return ctrl.Result{}, IgnoreConflict(err)
 		}
 	}
 
@@ -0,0 +1,113 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sort"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterlisterv1alpha1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1alpha1"
+	workfake "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/config"
+	"github.com/pdettori/kealm/pkg/decisions"
+)
+
+// testScheme returns a scheme holding the client-go and the AppBundle types
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := appv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+// fixture holds the fake clients and the informer caches of a reconciler under test
+type fixture struct {
+	t          *testing.T
+	scheme     *runtime.Scheme
+	builder    *ctrl.ClientBuilder
+	clusters   cache.Indexer
+	placements cache.Indexer
+	decisions  cache.Indexer
+	cluster    *clusterfake.Clientset
+	works      *workfake.Clientset
+	recorder   *record.FakeRecorder
+}
+
+// newFixture returns a fixture whose client holds objects
+func newFixture(t *testing.T, objects ...client.Object) *fixture {
+	scheme := testScheme(t)
+	return &fixture{
+		t:          t,
+		scheme:     scheme,
+		builder:    ctrl.NewClientBuilder().WithScheme(scheme).WithObjects(objects...),
+		clusters:   cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		placements: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+		decisions:  cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{decisions.IndexPlacement: decisions.IndexFunc}),
+		cluster:    clusterfake.NewSimpleClientset(),
+		works:      workfake.NewSimpleClientset(),
+		recorder:   record.NewFakeRecorder(100),
+	}
+}
+
+// add adds objects to the informer cache indexer
+func (f *fixture) add(indexer cache.Indexer, objects ...interface{}) {
+	for _, obj := range objects {
+		if err := indexer.Add(obj); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+}
+
+// reconciler returns an AppBundleReconciler reading and writing the fixture
+func (f *fixture) reconciler() *AppBundleReconciler {
+	return &AppBundleReconciler{
+		Client:               f.builder.Build(),
+		Scheme:               f.scheme,
+		ClusterClient:        f.cluster,
+		PlacementLister:      clusterlisterv1alpha1.NewPlacementLister(f.placements),
+		Decisions:            decisions.New(f.decisions),
+		ManagedClusterLister: clusterlisterv1.NewManagedClusterLister(f.clusters),
+		WorkClient:           f.works,
+		Recorder:             f.recorder,
+		Config:               config.NewStore(1),
+	}
+}
+
+// workClusters returns the sorted cluster namespaces of the works
+func workClusters(works *workapiv1.ManifestWorkList) []string {
+	clusters := []string{}
+	for _, w := range works.Items {
+		clusters = append(clusters, w.Namespace)
+	}
+	sort.Strings(clusters)
+	return clusters
+}
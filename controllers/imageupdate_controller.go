@@ -0,0 +1,208 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+	"github.com/pdettori/kealm/pkg/registry"
+	"github.com/pdettori/kealm/pkg/sharding"
+)
+
+const (
+	// ImageUpdateManager is the field manager of the image updates, recorded as the
+	// author of the changes in the audit records
+	ImageUpdateManager = "kealm-image-update"
+
+	// defaultImageUpdateInterval is the interval of the policies without interval
+	defaultImageUpdateInterval = 5 * time.Minute
+)
+
+// ImageUpdateReconciler updates the image tags of the bundles with image update policies
+type ImageUpdateReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Registry *registry.Client
+	Recorder record.EventRecorder
+	// Shard restricts the updated bundles to a subset when set
+	Shard *sharding.Shard
+
+	pushes chan event.GenericEvent
+}
+
+// Reconcile checks the registries of the image update policies of a bundle and updates
+// its inline manifests to the selected tags
+func (r *ImageUpdateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	bundle := &appv1alpha1.AppBundle{}
+	if err := r.Get(ctx, req.NamespacedName, bundle); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if len(bundle.Spec.ImageUpdates) == 0 || !bundle.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	updated := bundle.DeepCopy()
+	statuses := []appv1alpha1.ImageStatus{}
+	changes := []string{}
+	requeue := time.Duration(0)
+	for _, policy := range bundle.Spec.ImageUpdates {
+		interval := defaultImageUpdateInterval
+		if policy.Interval != nil {
+			interval = policy.Interval.Duration
+		}
+		if requeue == 0 || interval < requeue {
+			requeue = interval
+		}
+		now := v1.Now()
+		status := appv1alpha1.ImageStatus{Image: policy.Image, LastChecked: &now}
+		tag, err := r.latestTag(ctx, policy)
+		if err != nil {
+			klog.Errorf("Failed to check image %s of AppBundle %s: %v", policy.Image, req, err)
+			status.Message = err.Error()
+		}
+		status.Tag = tag
+		statuses = append(statuses, status)
+		if tag == "" {
+			continue
+		}
+		ms, changed, err := manifests.SetImageTag(updated.Spec.Workload.Manifests, policy.Image, tag)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if changed > 0 {
+			updated.Spec.Workload.Manifests = ms
+			changes = append(changes, manifests.JoinImage(policy.Image, tag))
+		}
+	}
+
+	if len(changes) > 0 {
+		if err := r.Update(ctx, updated, client.FieldOwner(ImageUpdateManager)); err != nil {
+			return ctrl.Result{}, IgnoreConflict(err)
+		}
+		message := "Updated images to " + strings.Join(changes, ", ")
+		klog.Infof("%s in AppBundle %s", message, req)
+		r.Recorder.Event(updated, corev1.EventTypeNormal, appv1alpha1.ReasonImageUpdated, message)
+	}
+	if !equalImageStatuses(updated.Status.Images, statuses) {
+		updated.Status.Images = statuses
+		if err := r.Status().Update(ctx, updated); err != nil {
+			return ctrl.Result{}, IgnoreConflict(err)
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
+}
+
+func (r *ImageUpdateReconciler) latestTag(ctx context.Context, policy appv1alpha1.ImageUpdatePolicy) (string, error) {
+	tags, err := r.Registry.Tags(ctx, policy.Image)
+	if err != nil {
+		return "", err
+	}
+	tag, err := registry.Latest(tags, policy.SemVer, policy.Pattern)
+	if err != nil {
+		return "", err
+	}
+	if tag == "" {
+		return "", fmt.Errorf("no tag matches the policy among %d tags", len(tags))
+	}
+	return tag, nil
+}
+
+// equalImageStatuses compares the statuses ignoring when they were checked, so that
+// the status is only written on changes
+func equalImageStatuses(a, b []appv1alpha1.ImageStatus) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		x.LastChecked, y.LastChecked = nil, nil
+		if !equality.Semantic.DeepEqual(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// ImagePushed checks the bundles with policies on the pushed image repositories,
+// as notified by registry webhooks
+func (r *ImageUpdateReconciler) ImagePushed(ctx context.Context, repositories []string) error {
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(ctx, &bundles); err != nil {
+		return err
+	}
+	for i := range bundles.Items {
+		b := &bundles.Items[i]
+		if !r.Shard.Owns(b.Namespace, b.Name) || !watchesRepository(b, repositories) {
+			continue
+		}
+		select {
+		case r.pushes <- event.GenericEvent{Object: b}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// watchesRepository returns true if the bundle has a policy on one of the repositories,
+// which may be notified without their registry host
+func watchesRepository(bundle *appv1alpha1.AppBundle, repositories []string) bool {
+	for _, policy := range bundle.Spec.ImageUpdates {
+		host, repo := registry.Reference(policy.Image)
+		for _, pushed := range repositories {
+			pushedHost, pushedRepo := registry.Reference(pushed)
+			if repo == pushedRepo && (host == pushedHost || pushedHost == registry.DockerHub) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ImageUpdateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.pushes = make(chan event.GenericEvent)
+	hasPolicies := func(obj client.Object) bool {
+		b, ok := obj.(*appv1alpha1.AppBundle)
+		return ok && len(b.Spec.ImageUpdates) > 0
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("imageupdate").
+		For(&appv1alpha1.AppBundle{}, builder.WithPredicates(
+			predicate.NewPredicateFuncs(hasPolicies), predicate.GenerationChangedPredicate{}, r.Shard.Predicate())).
+		Watches(&source.Channel{Source: r.pushes}, &handler.EnqueueRequestForObject{}).
+		Complete(r)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/config"
+	"github.com/pdettori/kealm/pkg/sharding"
+)
+
+// KealmConfigReconciler loads the KealmConfig singleton into the configuration store
+type KealmConfigReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Store  *config.Store
+
+	// Changes receives an event for every AppBundle when the configuration changes,
+	// so that bundles are reconciled with the new configuration
+	Changes chan<- event.GenericEvent
+
+	// Shard writes the status of the KealmConfig when it leads, every replica loading
+	// the configuration
+	Shard *sharding.Shard
+}
+
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=kealmconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=kealmconfigs/status,verbs=get;update;patch
+
+// Reconcile reloads the configuration when the KealmConfig singleton changes
+func (r *KealmConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if req.Name != appv1alpha1.KealmConfigName {
+		klog.Infof("Ignoring KealmConfig %s, only %s is read", req.Name, appv1alpha1.KealmConfigName)
+		return ctrl.Result{}, nil
+	}
+
+	cfg := &appv1alpha1.KealmConfig{}
+	if err := r.Get(ctx, req.NamespacedName, cfg); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		// deleted, revert to the defaults
+		cfg = &appv1alpha1.KealmConfig{}
+	}
+
+	if !equality.Semantic.DeepEqual(r.Store.Get(), cfg.Spec) {
+		klog.Infof("Reloading configuration from KealmConfig %s", req.Name)
+		r.Store.Set(cfg.Spec)
+		if err := r.requeueBundles(ctx); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if cfg.UID != "" && cfg.Status.ObservedGeneration != cfg.Generation && r.Shard.Lead() {
+		cfg.Status.ObservedGeneration = cfg.Generation
+		if err := r.Status().Update(ctx, cfg); err != nil {
+			return ctrl.Result{}, IgnoreConflict(err)
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// requeueBundles notifies the AppBundle controller that all bundles need reconciling
+func (r *KealmConfigReconciler) requeueBundles(ctx context.Context) error {
+	if r.Changes == nil {
+		return nil
+	}
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(ctx, &bundles); err != nil {
+		return err
+	}
+	for i := range bundles.Items {
+		select {
+		case r.Changes <- event.GenericEvent{Object: &bundles.Items[i]}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KealmConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appv1alpha1.KealmConfig{}).
+		Complete(r)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/quota"
+)
+
+// KealmTenantReconciler reports the usage of the quota of the tenant namespaces
+type KealmTenantReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=kealmtenants,verbs=get;list;watch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=kealmtenants/status,verbs=get;update;patch
+
+// Reconcile sums the usage of the bundles of the namespace of a tenant as last
+// reconciled, and reports whether it exceeds the quota
+func (r *KealmTenantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	tenant := &appv1alpha1.KealmTenant{}
+	if err := r.Get(ctx, req.NamespacedName, tenant); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	var bundles appv1alpha1.AppBundleList
+	if err := r.List(ctx, &bundles, client.InNamespace(tenant.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+	t := tenant.DeepCopy()
+	t.Status.Usage, _ = quota.Usage(bundles.Items)
+	condition := v1.Condition{
+		Type:               appv1alpha1.ConditionWithinQuota,
+		Status:             v1.ConditionTrue,
+		Reason:             appv1alpha1.ReasonWithinQuota,
+		Message:            "The quota is respected",
+		ObservedGeneration: t.Generation,
+	}
+	if exceeded := quota.Check(t.Spec.Quota, t.Status.Usage); len(exceeded) > 0 {
+		condition.Status = v1.ConditionFalse
+		condition.Reason = appv1alpha1.ReasonQuotaExceeded
+		condition.Message = strings.Join(exceeded, "; ")
+	}
+	if t.Spec.Quota == nil {
+		meta.RemoveStatusCondition(&t.Status.Conditions, appv1alpha1.ConditionWithinQuota)
+	} else {
+		meta.SetStatusCondition(&t.Status.Conditions, condition)
+	}
+	if equality.Semantic.DeepEqual(tenant.Status, t.Status) {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, IgnoreConflict(r.Status().Update(ctx, t))
+}
+
+// tenantsForBundle maps a bundle to the tenants of its namespace
+func (r *KealmTenantReconciler) tenantsForBundle(obj client.Object) []reconcile.Request {
+	var tenants appv1alpha1.KealmTenantList
+	if err := r.List(context.TODO(), &tenants, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.Errorf("Failed to list KealmTenants for AppBundle %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, t := range tenants.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: t.Namespace, Name: t.Name},
+		})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KealmTenantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appv1alpha1.KealmTenant{}).
+		Watches(&source.Kind{Type: &appv1alpha1.AppBundle{}},
+			handler.EnqueueRequestsFromMapFunc(r.tenantsForBundle)).
+		Complete(r)
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// PreviewBundleLabel on an AppBundle references the PreviewBundle it is stamped out from
+const PreviewBundleLabel = "cluster.open-cluster-management.io/preview-bundle"
+
+// defaultPreviewTTL is the lifetime of the previews without TTL
+const defaultPreviewTTL = 72 * time.Hour
+
+// PreviewBundleReconciler stamps out an AppBundle for each PreviewBundle and deletes
+// the expired previews
+type PreviewBundleReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=previewbundles,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=previewbundles/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=app.open-cluster-management.io,resources=appbundles,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile creates or updates the AppBundle of a preview from its base bundle, and
+// deletes the preview once expired, the AppBundle being garbage collected with it
+func (r *PreviewBundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	preview := &appv1alpha1.PreviewBundle{}
+	if err := r.Get(ctx, req.NamespacedName, preview); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !preview.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	ttl := defaultPreviewTTL
+	if preview.Spec.TTL != nil {
+		ttl = preview.Spec.TTL.Duration
+	}
+	expiresAt := preview.CreationTimestamp.Add(ttl)
+	if remaining := time.Until(expiresAt); remaining <= 0 {
+		klog.Infof("PreviewBundle %s expired, deleting it", req)
+		return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, preview))
+	}
+
+	p := preview.DeepCopy()
+	p.Status.ExpiresAt = &v1.Time{Time: expiresAt}
+	base := &appv1alpha1.AppBundle{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: p.Namespace, Name: p.Spec.BaseBundle}, base); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		r.setCondition(p, v1.ConditionFalse, appv1alpha1.ReasonBaseBundleNotFound,
+			fmt.Sprintf("AppBundle %s not found", p.Spec.BaseBundle))
+		return ctrl.Result{RequeueAfter: time.Until(expiresAt)}, r.updatePreviewStatus(ctx, preview, p)
+	}
+
+	bundle := &appv1alpha1.AppBundle{}
+	bundle.Name = previewBundleName(p)
+	bundle.Namespace = p.Namespace
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, bundle, func() error {
+		// do not take over bundles not stamped out from this preview
+		if bundle.ResourceVersion != "" && bundle.Labels[PreviewBundleLabel] != p.Name {
+			return fmt.Errorf("AppBundle %s/%s exists and is not stamped out from PreviewBundle %s",
+				bundle.Namespace, bundle.Name, p.Name)
+		}
+		bundle.Labels = map[string]string{}
+		for k, v := range base.Labels {
+			bundle.Labels[k] = v
+		}
+		bundle.Labels[PlacementLabel] = p.Spec.Placement
+		bundle.Labels[PreviewBundleLabel] = p.Name
+		bundle.Spec = *base.Spec.DeepCopy()
+		// the author of the preview is not authorized to bind the Role of the hub access
+		bundle.Spec.HubAccess = nil
+		// previews of the same base must not collide on the preview clusters
+		bundle.Spec.Instance = &appv1alpha1.Instance{Suffix: fmt.Sprintf("pr-%d", p.Spec.PullRequest.Number)}
+		return controllerutil.SetControllerReference(p, bundle, r.Scheme)
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if result != controllerutil.OperationResultNone {
+		klog.Infof("AppBundle %s/%s %s from PreviewBundle %s", bundle.Namespace, bundle.Name, result, p.Name)
+	}
+	p.Status.BundleName = bundle.Name
+	r.setCondition(p, v1.ConditionTrue, appv1alpha1.ReasonPreviewCreated, "AppBundle "+bundle.Name+" stamped out")
+	return ctrl.Result{RequeueAfter: time.Until(expiresAt)}, r.updatePreviewStatus(ctx, preview, p)
+}
+
+// ClosePullRequest deletes the previews of a closed pull request
+func (r *PreviewBundleReconciler) ClosePullRequest(ctx context.Context, repository string, number int32) error {
+	var previews appv1alpha1.PreviewBundleList
+	if err := r.List(ctx, &previews); err != nil {
+		return err
+	}
+	for i := range previews.Items {
+		p := &previews.Items[i]
+		if p.Spec.PullRequest.Repository != repository || p.Spec.PullRequest.Number != number {
+			continue
+		}
+		klog.Infof("Pull request %s#%d closed, deleting PreviewBundle %s/%s", repository, number, p.Namespace, p.Name)
+		if err := r.Delete(ctx, p); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// previewBundleName returns the name of the AppBundle of a preview
+func previewBundleName(p *appv1alpha1.PreviewBundle) string {
+	return fmt.Sprintf("%s-pr-%d", p.Spec.BaseBundle, p.Spec.PullRequest.Number)
+}
+
+func (r *PreviewBundleReconciler) setCondition(p *appv1alpha1.PreviewBundle, status v1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&p.Status.Conditions, v1.Condition{
+		Type:               appv1alpha1.ConditionPreviewReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: p.Generation,
+	})
+}
+
+func (r *PreviewBundleReconciler) updatePreviewStatus(ctx context.Context, old, p *appv1alpha1.PreviewBundle) error {
+	if equality.Semantic.DeepEqual(old.Status, p.Status) {
+		return nil
+	}
+	return IgnoreConflict(r.Status().Update(ctx, p))
+}
+
+// previewsForBaseBundle maps a bundle to the previews stamped out from it
+func (r *PreviewBundleReconciler) previewsForBaseBundle(obj client.Object) []reconcile.Request {
+	var previews appv1alpha1.PreviewBundleList
+	if err := r.List(context.TODO(), &previews, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.Errorf("Failed to list PreviewBundles for AppBundle %s: %v", obj.GetName(), err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, p := range previews.Items {
+		if p.Spec.BaseBundle == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: p.Namespace, Name: p.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PreviewBundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appv1alpha1.PreviewBundle{}).
+		Owns(&appv1alpha1.AppBundle{}).
+		Watches(&source.Kind{Type: &appv1alpha1.AppBundle{}},
+			handler.EnqueueRequestsFromMapFunc(r.previewsForBaseBundle)).
+		Complete(r)
+}
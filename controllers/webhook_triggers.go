@@ -0,0 +1,158 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/config"
+	"github.com/pdettori/kealm/pkg/manifests"
+	"github.com/pdettori/kealm/pkg/receiver"
+)
+
+// ResyncAnnotation records when a resync of the bundle was last requested
+const ResyncAnnotation = "cluster.open-cluster-management.io/resync-requested"
+
+// TriggerHandler performs the actions of the webhook triggers configured in KealmConfig
+type TriggerHandler struct {
+	client.Client
+	Store *config.Store
+}
+
+// Trigger performs the action of the named trigger for a payload of the event type.
+// Payloads of other event types than the one of the trigger are ignored.
+func (h *TriggerHandler) Trigger(ctx context.Context, name, event string, payload []byte) error {
+	var trigger *appv1alpha1.WebhookTrigger
+	cfg := h.Store.Get()
+	for i := range cfg.WebhookTriggers {
+		if cfg.WebhookTriggers[i].Name == name {
+			trigger = &cfg.WebhookTriggers[i]
+		}
+	}
+	if trigger == nil {
+		return fmt.Errorf("%w %s", receiver.ErrUnknownTrigger, name)
+	}
+	if trigger.Event != "" && trigger.Event != event {
+		klog.V(2).Infof("Ignoring event %q for trigger %s", event, name)
+		return nil
+	}
+
+	key := types.NamespacedName{Namespace: trigger.BundleNamespace, Name: trigger.BundleName}
+	switch trigger.Action {
+	case appv1alpha1.TriggerActionImageTag:
+		tag, err := evalPayload(trigger.TagPath, payload)
+		if err != nil {
+			return err
+		}
+		if err := manifests.ValidateTag(tag); err != nil {
+			return fmt.Errorf("trigger %s: %w", name, err)
+		}
+		return h.updateBundle(ctx, key, func(bundle *appv1alpha1.AppBundle) error {
+			updated, changed, err := manifests.SetImageTag(bundle.Spec.Workload.Manifests, trigger.Image, tag)
+			if err != nil {
+				return err
+			}
+			klog.Infof("Trigger %s set the tag of image %s to %s in %d containers of AppBundle %s", name, trigger.Image, tag, changed, key)
+			bundle.Spec.Workload.Manifests = updated
+			return nil
+		})
+	case appv1alpha1.TriggerActionResync:
+		return h.updateBundle(ctx, key, func(bundle *appv1alpha1.AppBundle) error {
+			if bundle.Annotations == nil {
+				bundle.Annotations = map[string]string{}
+			}
+			bundle.Annotations[ResyncAnnotation] = time.Now().UTC().Format(time.RFC3339)
+			klog.Infof("Trigger %s requested a resync of AppBundle %s", name, key)
+			return nil
+		})
+	case appv1alpha1.TriggerActionPromote:
+		source := &appv1alpha1.AppBundle{}
+		if err := h.Get(ctx, key, source); err != nil {
+			return err
+		}
+		target := types.NamespacedName{Namespace: key.Namespace, Name: trigger.PromoteTo}
+		return h.updateBundle(ctx, target, func(bundle *appv1alpha1.AppBundle) error {
+			promote(source, bundle)
+			klog.Infof("Trigger %s promoted AppBundle %s generation %d to %s", name, key, source.Generation, target)
+			return nil
+		})
+	default:
+		return fmt.Errorf("unknown action %q of trigger %s", trigger.Action, name)
+	}
+}
+
+// promote copies the sources of the workload of the bundle to the target: its inline
+// manifests, workload references, Flux source, components and template, pinned to the
+// version of the template the source distributes
+func promote(source, target *appv1alpha1.AppBundle) {
+	spec := source.Spec.DeepCopy()
+	target.Spec.Workload = spec.Workload
+	target.Spec.WorkloadRefs = spec.WorkloadRefs
+	target.Spec.Flux = spec.Flux
+	target.Spec.Components = spec.Components
+	target.Spec.Template = spec.Template
+	if t := target.Spec.Template; t != nil && t.Version == "" {
+		t.Version = source.Status.TemplateVersion
+	}
+}
+
+// updateBundle applies the mutation to the bundle, retrying on conflicts
+func (h *TriggerHandler) updateBundle(ctx context.Context, key types.NamespacedName, mutate func(*appv1alpha1.AppBundle) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		bundle := &appv1alpha1.AppBundle{}
+		if err := h.Get(ctx, key, bundle); err != nil {
+			return err
+		}
+		if err := mutate(bundle); err != nil {
+			return err
+		}
+		return h.Update(ctx, bundle)
+	})
+}
+
+// evalPayload returns the value at the JSONPath of the payload
+func evalPayload(path string, payload []byte) (string, error) {
+	var data interface{}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return "", fmt.Errorf("invalid payload: %w", err)
+	}
+	jp := jsonpath.New("trigger")
+	if err := jp.Parse(path); err != nil {
+		return "", fmt.Errorf("invalid JSONPath %s: %w", path, err)
+	}
+	var out bytes.Buffer
+	if err := jp.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to evaluate %s: %w", path, err)
+	}
+	value := strings.TrimSpace(out.String())
+	if value == "" {
+		return "", fmt.Errorf("no value at %s in the payload", path)
+	}
+	return value, nil
+}
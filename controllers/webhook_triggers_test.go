@@ -0,0 +1,138 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/config"
+)
+
+// triggerHandler returns a handler of the trigger over the bundles
+func triggerHandler(t *testing.T, trigger appv1alpha1.WebhookTrigger, bundles ...*appv1alpha1.AppBundle) *TriggerHandler {
+	f := newFixture(t)
+	for _, b := range bundles {
+		f.builder.WithObjects(b)
+	}
+	store := config.NewStore(1)
+	store.Set(appv1alpha1.KealmConfigSpec{WebhookTriggers: []appv1alpha1.WebhookTrigger{trigger}})
+	return &TriggerHandler{Client: f.builder.Build(), Store: store}
+}
+
+func TestTriggerPromote(t *testing.T) {
+	manifest := workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(
+		`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"web","namespace":"default"}}`)}}
+	tests := []struct {
+		name     string
+		source   func(*appv1alpha1.AppBundle)
+		expected func(*appv1alpha1.AppBundleSpec)
+	}{
+		{"inline manifests", func(b *appv1alpha1.AppBundle) {
+			b.Spec.Workload.Manifests = []workapiv1.Manifest{manifest}
+		}, func(s *appv1alpha1.AppBundleSpec) {
+			s.Workload.Manifests = []workapiv1.Manifest{manifest}
+		}},
+		{"workload references", func(b *appv1alpha1.AppBundle) {
+			b.Spec.WorkloadRefs = []appv1alpha1.WorkloadReference{{Kind: "ConfigMap", Name: "web-v2"}}
+		}, func(s *appv1alpha1.AppBundleSpec) {
+			s.WorkloadRefs = []appv1alpha1.WorkloadReference{{Kind: "ConfigMap", Name: "web-v2"}}
+		}},
+		{"flux", func(b *appv1alpha1.AppBundle) {
+			b.Spec.Flux = &appv1alpha1.FluxSource{Namespace: "flux-v2"}
+		}, func(s *appv1alpha1.AppBundleSpec) {
+			s.Flux = &appv1alpha1.FluxSource{Namespace: "flux-v2"}
+		}},
+		{"components", func(b *appv1alpha1.AppBundle) {
+			b.Spec.Components = []appv1alpha1.Component{{Name: "web", Manifests: []workapiv1.Manifest{manifest}}}
+		}, func(s *appv1alpha1.AppBundleSpec) {
+			s.Components = []appv1alpha1.Component{{Name: "web", Manifests: []workapiv1.Manifest{manifest}}}
+		}},
+		{"pinned template", func(b *appv1alpha1.AppBundle) {
+			b.Spec.Template = &appv1alpha1.TemplateReference{Name: "web", Version: "1.0.0"}
+			b.Status.TemplateVersion = "1.0.0"
+		}, func(s *appv1alpha1.AppBundleSpec) {
+			s.Template = &appv1alpha1.TemplateReference{Name: "web", Version: "1.0.0"}
+		}},
+		{"latest template", func(b *appv1alpha1.AppBundle) {
+			b.Spec.Template = &appv1alpha1.TemplateReference{Name: "web", Parameters: map[string]string{"replicas": "3"}}
+			b.Status.TemplateVersion = "1.2.0"
+		}, func(s *appv1alpha1.AppBundleSpec) {
+			s.Template = &appv1alpha1.TemplateReference{Name: "web", Version: "1.2.0", Parameters: map[string]string{"replicas": "3"}}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			staging := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "staging", Namespace: "default"}}
+			tt.source(staging)
+			// the previous sources of the target are replaced
+			prod := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "prod", Namespace: "default"}}
+			prod.Spec.Workload.Manifests = []workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: []byte(`{"kind":"Secret"}`)}}}
+			prod.Spec.WorkloadRefs = []appv1alpha1.WorkloadReference{{Kind: "Secret", Name: "web-v1"}}
+			prod.Spec.Flux = &appv1alpha1.FluxSource{Namespace: "flux-v1"}
+			prod.Spec.Components = []appv1alpha1.Component{{Name: "db"}}
+			prod.Spec.Template = &appv1alpha1.TemplateReference{Name: "web", Version: "0.9.0"}
+			prod.Spec.TargetNamespace = "prod"
+			h := triggerHandler(t, appv1alpha1.WebhookTrigger{Name: "promote", BundleNamespace: "default", BundleName: "staging",
+				Action: appv1alpha1.TriggerActionPromote, PromoteTo: "prod"}, staging, prod)
+
+			if err := h.Trigger(context.TODO(), "promote", "", nil); err != nil {
+				t.Fatal(err)
+			}
+			promoted := &appv1alpha1.AppBundle{}
+			if err := h.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "prod"}, promoted); err != nil {
+				t.Fatal(err)
+			}
+			expected := appv1alpha1.AppBundleSpec{TargetNamespace: "prod"}
+			tt.expected(&expected)
+			if !reflect.DeepEqual(promoted.Spec, expected) {
+				t.Errorf("expected %+v, got %+v", expected, promoted.Spec)
+			}
+		})
+	}
+}
+
+func TestTriggerImageTag(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default"}}
+	bundle.Spec.Workload.Manifests = []workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: []byte(
+		`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"web"},"spec":{"containers":[{"name":"web","image":"acme/web:v1"}]}}`)}}}
+	h := triggerHandler(t, appv1alpha1.WebhookTrigger{Name: "release", BundleNamespace: "default", BundleName: "web",
+		Action: appv1alpha1.TriggerActionImageTag, Image: "acme/web", TagPath: "{.tag}"}, bundle)
+	for _, payload := range []string{`{"tag":"v2 --privileged"}`, `{"tag":"v2;id"}`, `{"tag":"-v2"}`} {
+		if err := h.Trigger(context.TODO(), "release", "", []byte(payload)); err == nil {
+			t.Errorf("expected the tag of %s to be rejected", payload)
+		}
+	}
+	if err := h.Trigger(context.TODO(), "release", "", []byte(`{"tag":"v2"}`)); err != nil {
+		t.Fatal(err)
+	}
+	updated := &appv1alpha1.AppBundle{}
+	if err := h.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "web"}, updated); err != nil {
+		t.Fatal(err)
+	}
+	if manifest := string(updated.Spec.Workload.Manifests[0].Raw); !strings.Contains(manifest, `"image":"acme/web:v2"`) {
+		t.Errorf("expected the tag set to v2, got %s", manifest)
+	}
+}
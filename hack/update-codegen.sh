@@ -0,0 +1,54 @@
+#!/usr/bin/env bash
+
+# Copyright 2022.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Generates the clientset, listers and informers of the kealm APIs in pkg/client with
+# the client-gen, lister-gen and informer-gen binaries of bin/.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+BIN=${BIN:-${ROOT}/bin}
+MODULE=github.com/pdettori/kealm
+OUTPUT=${MODULE}/pkg/client
+HEADER=${ROOT}/hack/boilerplate.go.txt
+
+# the generators name the group after the parent directory of the types, the api/
+# directory standing for the legacy core group: they read the types through a
+# <group>/<version> link, their import path rewritten in the generated code
+STAGING=hack/codegen
+APIS=${MODULE}/${STAGING}/app/v1alpha1
+OUTPUT_BASE=$(mktemp -d)
+trap 'rm -rf "${OUTPUT_BASE}" "${ROOT}/${STAGING}"' EXIT
+
+cd "${ROOT}"
+mkdir -p "${STAGING}/app"
+ln -s ../../../api/v1alpha1 "${STAGING}/app/v1alpha1"
+
+"${BIN}/client-gen" --go-header-file "${HEADER}" --output-base "${OUTPUT_BASE}" \
+  --input-base "${MODULE}/${STAGING}" --input app/v1alpha1 --clientset-name versioned --output-package "${OUTPUT}/clientset"
+"${BIN}/lister-gen" --go-header-file "${HEADER}" --output-base "${OUTPUT_BASE}" \
+  --input-dirs "${APIS}" --output-package "${OUTPUT}/listers"
+"${BIN}/informer-gen" --go-header-file "${HEADER}" --output-base "${OUTPUT_BASE}" \
+  --input-dirs "${APIS}" --versioned-clientset-package "${OUTPUT}/clientset/versioned" \
+  --listers-package "${OUTPUT}/listers" --output-package "${OUTPUT}/informers"
+
+find "${OUTPUT_BASE}" -name '*.go' -exec sed -i "s#${APIS}#${MODULE}/api/v1alpha1#g" {} +
+rm -rf pkg/client/clientset pkg/client/listers pkg/client/informers
+mkdir -p pkg/client
+cp -r "${OUTPUT_BASE}/${OUTPUT}/." pkg/client/
+gofmt -w pkg/client
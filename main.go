@@ -19,26 +19,64 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	clusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
 	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
 
 	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
 	"github.com/pdettori/kealm/controllers"
+	"github.com/pdettori/kealm/pkg/argocd"
+	"github.com/pdettori/kealm/pkg/audit"
+	"github.com/pdettori/kealm/pkg/bundleset"
+	"github.com/pdettori/kealm/pkg/catalog"
+	"github.com/pdettori/kealm/pkg/chaos"
+	"github.com/pdettori/kealm/pkg/config"
+	"github.com/pdettori/kealm/pkg/decisions"
+	"github.com/pdettori/kealm/pkg/diagnostics"
+	"github.com/pdettori/kealm/pkg/health"
+	"github.com/pdettori/kealm/pkg/metrics"
+	"github.com/pdettori/kealm/pkg/notify"
+	"github.com/pdettori/kealm/pkg/plugins"
+	"github.com/pdettori/kealm/pkg/provenance"
+	"github.com/pdettori/kealm/pkg/receiver"
+	"github.com/pdettori/kealm/pkg/registry"
+	"github.com/pdettori/kealm/pkg/secrets"
+	"github.com/pdettori/kealm/pkg/securitygate"
+	"github.com/pdettori/kealm/pkg/sharding"
+	"github.com/pdettori/kealm/pkg/tracing"
+	"github.com/pdettori/kealm/pkg/tracker"
+	"github.com/pdettori/kealm/pkg/writeback"
+	"github.com/pdettori/kealm/webhooks"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -58,11 +96,75 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var enableWebhooks bool
+	var signingKey string
+	var identity string
+	var auditSink string
+	var maxConcurrentReconciles int
+	var enableDiagnostics bool
+	var shards, shardID int
+	var shardAssignment, shardLeaseNamespace string
+	var startupJitter time.Duration
+	var statusInterval time.Duration
+	var workWriteQPS float64
+	var workWriteBurst int
+	var argocdServer, argocdTokenFile string
+	var receiverAddr, receiverSecretFile, receiverRegistryTokenFile string
+	var wasmRuntime, wasmPluginDir string
+	var otlpEndpoint string
+	var traceSampleRatio float64
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Enable the admission webhooks. Requires serving certificates for the webhook server.")
+	flag.StringVar(&signingKey, "signing-key", "",
+		"Path to a PEM encoded private key used to sign the distributed content. Content is not signed if empty.")
+	flag.StringVar(&auditSink, "audit-sink", "crd",
+		"Where to record the distribution audit trail: 'crd' for AppBundleAudit resources, 'log' for JSON lines on stdout, 'none' to disable.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 4,
+		"The maximum number of AppBundles reconciled concurrently. KealmConfig can lower it at runtime.")
+	flag.StringVar(&identity, "controller-identity", defaultIdentity(),
+		"The identity of the controller recorded in the provenance of the distributed content.")
+	flag.BoolVar(&enableDiagnostics, "enable-diagnostics", false,
+		"Serve the pprof, expvar, controller state and rendered work endpoints under /debug on the metrics endpoint.")
+	flag.IntVar(&shards, "shards", 1,
+		"The number of shards the AppBundles are split into, each replica reconciling the bundles of one shard. "+
+			"The replica of shard 0 runs the other controllers. Leader election must be disabled when larger than 1.")
+	flag.IntVar(&shardID, "shard-id", -1,
+		"The shard of this replica with the 'ordinal' assignment, defaults to the ordinal of the StatefulSet pod.")
+	flag.StringVar(&shardAssignment, "shard-assignment", "ordinal",
+		"How a replica gets its shard: 'ordinal' from --shard-id or the pod ordinal, 'lease' by holding a free shard lease.")
+	flag.StringVar(&shardLeaseNamespace, "shard-lease-namespace", os.Getenv("POD_NAMESPACE"),
+		"The namespace of the shard leases with the 'lease' assignment.")
+	flag.DurationVar(&startupJitter, "startup-jitter", 0,
+		"Spread the resync of the already distributed AppBundles over this window after a restart. Disabled if 0.")
+	flag.DurationVar(&statusInterval, "status-interval", 10*time.Second,
+		"Write the per-cluster status changes of an AppBundle at most once per interval, transitions being written right away. Disabled if 0.")
+	flag.Float64Var(&workWriteQPS, "work-write-qps", 0,
+		"The maximum rate of ManifestWork creations, updates and deletions across all bundles. Unlimited if 0.")
+	flag.IntVar(&workWriteBurst, "work-write-burst", 20,
+		"The burst of ManifestWork writes allowed above --work-write-qps.")
+	flag.StringVar(&argocdServer, "argocd-server", "",
+		"URL of the Argo CD server. When set, AppBundles are generated from the Argo CD Applications annotated with a placement.")
+	flag.StringVar(&argocdTokenFile, "argocd-token-file", "",
+		"Path to a file holding the Argo CD token used to render the Applications.")
+	flag.StringVar(&receiverAddr, "receiver-bind-address", "",
+		"The address the webhook receiver binds to, e.g. :8090. The receiver is disabled if empty.")
+	flag.StringVar(&receiverSecretFile, "receiver-secret-file", "",
+		"Path to a file holding the secret authenticating the payloads of the webhook receiver.")
+	flag.StringVar(&receiverRegistryTokenFile, "receiver-registry-token-file", "",
+		"Path to a file holding the bearer token authenticating the registry notifications of the webhook receiver. Bearer tokens are refused if empty.")
+	flag.StringVar(&wasmRuntime, "wasm-runtime", "",
+		"The command running the WebAssembly distribution plugins, e.g. 'wasmtime run'. Experimental.")
+	flag.StringVar(&wasmPluginDir, "wasm-plugin-dir", "",
+		"The directory of the modules of the WebAssembly distribution plugins. The modules out of it are refused.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		"Base URL of the OpenTelemetry collector receiving the spans of the reconciles over OTLP/HTTP, e.g. http://otel-collector:4318. Tracing is disabled if empty.")
+	flag.Float64Var(&traceSampleRatio, "trace-sample-ratio", 1,
+		"The ratio of the reconciles whose spans are exported, between 0 and 1.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -73,19 +175,59 @@ func main() {
 
 	ctx := context.Background()
 
-	clusterClient, err := clusterclient.NewForConfig(ctrl.GetConfigOrDie())
+	if shards > 1 && enableLeaderElection {
+		setupLog.Error(fmt.Errorf("--leader-elect and --shards are mutually exclusive"), "invalid flags")
+		os.Exit(1)
+	}
+	shard, err := newShard(ctx, shards, shardID, shardAssignment, shardLeaseNamespace)
+	if err != nil {
+		setupLog.Error(err, "unable to get shard")
+		os.Exit(1)
+	}
+	setupLog.Info("reconciling AppBundles", "shard", shard.String())
+
+	// the requests of the clients are spans of the reconciles when tracing
+	var tracerProvider *sdktrace.TracerProvider
+	restConfig := ctrl.GetConfigOrDie
+	if otlpEndpoint != "" {
+		if tracerProvider, err = tracing.NewProvider(ctx, otlpEndpoint, "kealm", traceSampleRatio); err != nil {
+			setupLog.Error(err, "unable to create tracer provider")
+			os.Exit(1)
+		}
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		restConfig = func() *rest.Config { return tracing.WrapConfig(ctrl.GetConfigOrDie()) }
+	}
+
+	clusterClient, err := clusterclient.NewForConfig(restConfig())
 	if err != nil {
 		setupLog.Error(err, "unable to create clusterClient")
 		os.Exit(1)
 	}
 
-	workClient, err := workclientset.NewForConfig(ctrl.GetConfigOrDie())
+	workConfig := ctrl.GetConfigOrDie()
+	var injector *chaos.Injector
+	if chaos.Enabled {
+		chaosConfig, err := chaos.FromEnv()
+		if err != nil {
+			setupLog.Error(err, "invalid fault injection")
+			os.Exit(1)
+		}
+		setupLog.Info("injecting faults", "failureRate", chaosConfig.FailureRate,
+			"maxDelay", chaosConfig.MaxDelay, "seed", chaosConfig.Seed)
+		injector = chaos.New(chaosConfig)
+		workConfig.WrapTransport = injector.Transport
+	}
+	if tracerProvider != nil {
+		tracing.WrapConfig(workConfig)
+	}
+	workClient, err := workclientset.NewForConfig(workConfig)
 	if err != nil {
 		setupLog.Error(err, "unable to create workClient")
 		os.Exit(1)
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	mgr, err := ctrl.NewManager(restConfig(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
@@ -99,29 +241,307 @@ func main() {
 	}
 
 	clusterInformers := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
+	// the works owned by bundles are cached, indexed by their owner, so that the works
+	// of a bundle are looked up without listing the works of the fleet
+	workInformers := workinformers.NewSharedInformerFactoryWithOptions(workClient, 10*time.Minute,
+		workinformers.WithTweakListOptions(controllers.OwnedWorksListOptions))
+	workInformer := workInformers.Work().V1().ManifestWorks().Informer()
+	if err := workInformer.AddIndexers(cache.Indexers{controllers.OwnerIndex: controllers.OwnerIndexFunc}); err != nil {
+		setupLog.Error(err, "unable to index the works")
+		os.Exit(1)
+	}
 
-	if err = (&controllers.AppBundleReconciler{
-		Client:                  mgr.GetClient(),
-		Scheme:                  mgr.GetScheme(),
-		ClusterClient:           clusterClient,
-		PlacementLister:         clusterInformers.Cluster().V1alpha1().Placements().Lister(),
-		PlacementDecisionLister: clusterInformers.Cluster().V1alpha1().PlacementDecisions().Lister(),
-		WorkClient:              *workClient,
+	var signer *provenance.Signer
+	if signingKey != "" {
+		if signer, err = provenance.NewSignerFromFile(signingKey); err != nil {
+			setupLog.Error(err, "unable to load signing key")
+			os.Exit(1)
+		}
+	}
+
+	configStore := config.NewStore(maxConcurrentReconciles)
+	configChanges := make(chan event.GenericEvent)
+	recorder := &notify.Recorder{
+		EventRecorder: mgr.GetEventRecorderFor("appbundle-controller"),
+		Sinks: func() []appv1alpha1.NotificationSink {
+			return configStore.Get().NotificationSinks
+		},
+	}
+
+	var diagnosticsTracker *diagnostics.Tracker
+	if enableDiagnostics {
+		diagnosticsTracker = diagnostics.NewTracker()
+		diagnosticsTracker.AddExtra("limiter", func() interface{} {
+			holders, limit := configStore.Limiter().Usage()
+			return map[string]int{"holders": holders, "limit": limit, "max": configStore.Limiter().Max()}
+		})
+		for path, handler := range diagnostics.Handlers(diagnosticsTracker) {
+			if err := mgr.AddMetricsExtraHandler(path, handler); err != nil {
+				setupLog.Error(err, "unable to add diagnostics handler", "path", path)
+				os.Exit(1)
+			}
+		}
+	}
+
+	var tracer trace.Tracer
+	if tracerProvider != nil {
+		if err := mgr.Add(shutdownTracing(tracerProvider)); err != nil {
+			setupLog.Error(err, "unable to add tracer provider")
+			os.Exit(1)
+		}
+		tracer = tracerProvider.Tracer("kealm")
+	}
+
+	queueMetrics := metrics.NewQueueTracker()
+	deploymentInfo := metrics.NewDeploymentInfo()
+	applyLatency := metrics.NewApplyLatency()
+	rolloutSLO := metrics.NewRolloutSLO()
+	crmetrics.Registry.MustRegister(queueMetrics, deploymentInfo, applyLatency, rolloutSLO, metrics.BundleErrors, metrics.StatusWrites)
+
+	if err = (&controllers.KealmConfigReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Store:   configStore,
+		Changes: configChanges,
+		Shard:   shard,
 	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KealmConfig")
+		os.Exit(1)
+	}
+
+	// the clusters of the placements are read from the decisions indexed by placement,
+	// instead of listing the decisions of a placement in each reconcile
+	decisionInformer := clusterInformers.Cluster().V1alpha1().PlacementDecisions().Informer()
+	if err = decisions.AddIndexers(decisionInformer); err != nil {
+		setupLog.Error(err, "unable to index the PlacementDecisions")
+		os.Exit(1)
+	}
+
+	// the sinks are notified of the clusters failing from the transitions streamed from
+	// the watch of the bundles of the shard, by the leader, or by every replica for the
+	// bundles of its shard when sharding
+	transitions := tracker.NewHub()
+	bundleInformer, err := mgr.GetCache().GetInformer(ctx, &appv1alpha1.AppBundle{})
+	if err != nil {
+		setupLog.Error(err, "unable to get AppBundle informer")
+		os.Exit(1)
+	}
+	bundleInformer.AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return false
+			}
+			namespace, name, err := cache.SplitMetaNamespaceKey(key)
+			return err == nil && shard.Owns(namespace, name)
+		},
+		Handler: transitions,
+	})
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		recorder.NotifyTransitions(transitions.Subscribe(ctx, "", ""))
+		return nil
+	})); err != nil {
+		setupLog.Error(err, "unable to add transition notifier")
+		os.Exit(1)
+	}
+	bundleReconciler := &controllers.AppBundleReconciler{
+		Client:               mgr.GetClient(),
+		Scheme:               mgr.GetScheme(),
+		ClusterClient:        clusterClient,
+		PlacementLister:      clusterInformers.Cluster().V1alpha1().Placements().Lister(),
+		ManagedClusterLister: clusterInformers.Cluster().V1().ManagedClusters().Lister(),
+		WorkClient:           workClient,
+		Decisions:            decisions.New(decisionInformer.GetIndexer()),
+		RestConfig:           mgr.GetConfig(),
+		AuthorsAttributed:    enableWebhooks,
+
+		PlacementDecisionInformer: decisionInformer,
+		ManagedClusterInformer:    clusterInformers.Cluster().V1().ManagedClusters().Informer(),
+		WorkInformer:              workInformer,
+
+		Recorder: recorder,
+		Signer:   signer,
+		Identity: identity,
+
+		AuditSink:   newAuditSink(auditSink, mgr),
+		Diagnostics: diagnosticsTracker,
+		Tracer:      tracer,
+
+		QueueMetrics:   queueMetrics,
+		DeploymentInfo: deploymentInfo,
+		ApplyLatency:   applyLatency,
+		RolloutSLO:     rolloutSLO,
+		Shard:          shard,
+		SecurityGate:   &securitygate.Gate{},
+		WASMRuntime:    &plugins.Runtime{Command: strings.Fields(wasmRuntime), Dir: wasmPluginDir},
+		GitWriter:      &writeback.Writer{HTTP: &http.Client{Timeout: 30 * time.Second}},
+		Secrets:        &secrets.Resolver{HTTP: &http.Client{Timeout: 30 * time.Second}},
+
+		StartupJitter:  startupJitter,
+		StatusInterval: statusInterval,
+		WriteLimiter:   newWriteLimiter(workWriteQPS, workWriteBurst),
+
+		Config:                  configStore,
+		ConfigChanges:           configChanges,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+	}
+	if injector != nil {
+		bundleReconciler.PlacementLister = injector.PlacementLister(bundleReconciler.PlacementLister)
+		bundleReconciler.Decisions = injector.Decisions(bundleReconciler.Decisions)
+		bundleReconciler.ManagedClusterLister = injector.ManagedClusterLister(bundleReconciler.ManagedClusterLister)
+	}
+	if err = bundleReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AppBundle")
 		os.Exit(1)
 	}
+	if enableDiagnostics {
+		if err := mgr.AddMetricsExtraHandler(diagnostics.RenderPath, bundleReconciler.RenderHandler()); err != nil {
+			setupLog.Error(err, "unable to add diagnostics handler", "path", diagnostics.RenderPath)
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
-	if err = (&controllers.DeploymentReconciler{
+	// the controllers which are not sharded run on the replica of the first shard only
+	if argocdServer != "" && shard.Lead() {
+		token := ""
+		if argocdTokenFile != "" {
+			data, err := os.ReadFile(argocdTokenFile)
+			if err != nil {
+				setupLog.Error(err, "unable to read Argo CD token")
+				os.Exit(1)
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		if err = (&controllers.ApplicationReconciler{
+			Client:       mgr.GetClient(),
+			Scheme:       mgr.GetScheme(),
+			Argo:         &argocd.Client{URL: argocdServer, Token: token},
+			ResyncPeriod: 3 * time.Minute,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Application")
+			os.Exit(1)
+		}
+	}
+
+	previews := &controllers.PreviewBundleReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "AppBundle")
+	}
+	if shard.Lead() {
+		if err = previews.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PreviewBundle")
+			os.Exit(1)
+		}
+
+		if err = (&controllers.AppBundleSetReconciler{
+			Client:                 mgr.GetClient(),
+			Scheme:                 mgr.GetScheme(),
+			ManagedClusterLister:   clusterInformers.Cluster().V1().ManagedClusters().Lister(),
+			ManagedClusterInformer: clusterInformers.Cluster().V1().ManagedClusters().Informer(),
+			Directories:            &bundleset.DirectoryLister{HTTP: &http.Client{Timeout: 30 * time.Second}},
+			Config:                 configStore,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AppBundleSet")
+			os.Exit(1)
+		}
+	}
+
+	imageUpdates := &controllers.ImageUpdateReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Registry: &registry.Client{HTTP: &http.Client{Timeout: 30 * time.Second}},
+		Recorder: mgr.GetEventRecorderFor("imageupdate-controller"),
+		Shard:    shard,
+	}
+	if err = imageUpdates.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ImageUpdate")
 		os.Exit(1)
 	}
+
+	if receiverAddr != "" {
+		secret, err := os.ReadFile(receiverSecretFile)
+		if err != nil {
+			setupLog.Error(err, "unable to read webhook receiver secret")
+			os.Exit(1)
+		}
+		var registryToken []byte
+		if receiverRegistryTokenFile != "" {
+			token, err := os.ReadFile(receiverRegistryTokenFile)
+			if err != nil {
+				setupLog.Error(err, "unable to read webhook receiver registry token")
+				os.Exit(1)
+			}
+			registryToken = []byte(strings.TrimSpace(string(token)))
+		}
+		r := &receiver.Receiver{
+			Secret:            []byte(strings.TrimSpace(string(secret))),
+			RegistryToken:     registryToken,
+			PullRequestClosed: previews.ClosePullRequest,
+			Trigger:           (&controllers.TriggerHandler{Client: mgr.GetClient(), Store: configStore}).Trigger,
+			RegistryPush:      imageUpdates.ImagePushed,
+		}
+		if err := mgr.Add(serveReceiver(receiverAddr, r)); err != nil {
+			setupLog.Error(err, "unable to add webhook receiver")
+			os.Exit(1)
+		}
+	}
+
+	if shard.Lead() {
+		if err = (&controllers.KealmTenantReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "KealmTenant")
+			os.Exit(1)
+		}
+
+		if err = (&controllers.CatalogReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+			Fetcher: &catalog.Fetcher{
+				HTTP:     &http.Client{Timeout: 30 * time.Second},
+				Registry: &registry.Client{HTTP: &http.Client{Timeout: 30 * time.Second}},
+			},
+			Recorder: mgr.GetEventRecorderFor("catalog-controller"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Catalog")
+			os.Exit(1)
+		}
+
+		if err = (&controllers.DeploymentReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AppBundle")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	if enableWebhooks {
+		if err = (&webhooks.AppBundleValidator{
+			Client:          mgr.GetClient(),
+			PlacementLister: clusterInformers.Cluster().V1alpha1().Placements().Lister(),
+			Config:          configStore,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "AppBundle")
+			os.Exit(1)
+		}
+		if err = (&webhooks.HubAccessValidator{Client: mgr.GetClient()}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "AppBundle")
+			os.Exit(1)
+		}
+		if err = (&webhooks.AppBundleNormalizer{}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "AppBundle")
+			os.Exit(1)
+		}
+		if err = (&webhooks.AppBundleAttributor{}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "AppBundle")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -130,9 +550,34 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	// readiness also verifies the dependencies, so that rollouts of the controller are
+	// gated on actual functionality. Liveness does not, to avoid restart loops.
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(ctrl.GetConfigOrDie())
+	if err != nil {
+		setupLog.Error(err, "unable to create discoveryClient")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("ocm-crds", health.ResourcesPresent(discoveryClient, health.RequiredResources)); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("informers", health.InformersSynced(map[string]cache.SharedIndexInformer{
+		"placements":         clusterInformers.Cluster().V1alpha1().Placements().Informer(),
+		"placementdecisions": clusterInformers.Cluster().V1alpha1().PlacementDecisions().Informer(),
+		"managedclusters":    clusterInformers.Cluster().V1().ManagedClusters().Informer(),
+		"manifestworks":      workInformer,
+	})); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("work-api", health.WorkAPIReachable(workClient)); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting informers")
 	go clusterInformers.Start(ctx.Done())
+	go workInformers.Start(ctx.Done())
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -140,3 +585,107 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// shutdownTracing returns a runnable exporting the spans left when the manager stops
+func shutdownTracing(provider *sdktrace.TracerProvider) manager.RunnableFunc {
+	return func(ctx context.Context) error {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return provider.Shutdown(shutdownCtx)
+	}
+}
+
+// defaultIdentity returns the service account identity of the controller when running
+// in a pod with the POD_NAMESPACE and SERVICE_ACCOUNT variables set, the host name otherwise
+func defaultIdentity() string {
+	ns, sa := os.Getenv("POD_NAMESPACE"), os.Getenv("SERVICE_ACCOUNT")
+	if ns != "" && sa != "" {
+		return fmt.Sprintf("system:serviceaccount:%s:%s", ns, sa)
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+// newShard returns the shard of the AppBundles reconciled by this replica, nil when
+// sharding is disabled
+func newShard(ctx context.Context, count, id int, assignment, leaseNamespace string) (*sharding.Shard, error) {
+	if count <= 1 {
+		return nil, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	switch assignment {
+	case "ordinal":
+		if id < 0 {
+			if id, err = sharding.OrdinalFromHostname(hostname); err != nil {
+				return nil, err
+			}
+		}
+		return sharding.NewShard(id, count)
+	case "lease":
+		client, err := kubernetes.NewForConfig(ctrl.GetConfigOrDie())
+		if err != nil {
+			return nil, err
+		}
+		assigner := &sharding.LeaseAssigner{
+			Client:        client.CoordinationV1(),
+			Namespace:     leaseNamespace,
+			Prefix:        "kealm-shard-",
+			Identity:      hostname,
+			Count:         count,
+			LeaseDuration: 15 * time.Second,
+			RenewDeadline: 10 * time.Second,
+			RetryPeriod:   2 * time.Second,
+		}
+		return assigner.Acquire(ctx, func() {
+			setupLog.Error(fmt.Errorf("shard lease lost"), "exiting")
+			os.Exit(1)
+		})
+	}
+	return nil, fmt.Errorf("unknown shard assignment %s", assignment)
+}
+
+// newWriteLimiter returns the token bucket bounding the ManifestWork writes, nil if unlimited
+func newWriteLimiter(qps float64, burst int) flowcontrol.RateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(float32(qps), burst)
+}
+
+// serveReceiver serves the webhook receiver on addr until the manager stops
+func serveReceiver(addr string, handler http.Handler) manager.RunnableFunc {
+	return func(ctx context.Context) error {
+		server := &http.Server{Addr: addr, Handler: handler}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				setupLog.Error(err, "unable to stop webhook receiver")
+			}
+		}()
+		setupLog.Info("serving webhook receiver", "address", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	}
+}
+
+func newAuditSink(kind string, mgr ctrl.Manager) audit.Sink {
+	switch kind {
+	case "crd":
+		return &audit.CRSink{Client: mgr.GetClient()}
+	case "log":
+		return &audit.LogSink{Writer: os.Stdout}
+	case "none":
+		return nil
+	}
+	setupLog.Error(fmt.Errorf("unknown audit sink %s", kind), "unable to set up audit sink")
+	os.Exit(1)
+	return nil
+}
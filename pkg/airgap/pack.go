@@ -0,0 +1,134 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package airgap packs AppBundles for hubs and clusters disconnected from the sources
+// of their workloads
+package airgap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+	"github.com/pdettori/kealm/pkg/registry"
+)
+
+// Resolver resolves the digest of an image tag
+type Resolver interface {
+	Digest(ctx context.Context, image, tag string) (string, error)
+}
+
+// Image is an image of a packed bundle
+type Image struct {
+	// Source is the reference of the image in the original bundle, pinned to its digest
+	Source string `json:"source"`
+	// Packed is the reference of the image in the packed bundle
+	Packed string `json:"packed"`
+}
+
+// Options of the packing
+type Options struct {
+	// Registry is the mirror registry, with an optional path prefix, the images are
+	// rewritten to
+	Registry string
+	// AirGap rejects the bundles pulling from remote sources
+	AirGap bool
+}
+
+// Pack returns a copy of the bundle with the images of its manifests pinned to their
+// digest and rewritten to the mirror registry, with the images to copy to the mirror
+func Pack(ctx context.Context, bundle *appv1alpha1.AppBundle, r Resolver, opts Options) (*appv1alpha1.AppBundle, []Image, error) {
+	if opts.AirGap {
+		switch {
+		case bundle.Spec.Flux != nil:
+			return nil, nil, fmt.Errorf("the flux source is pulled by the clusters, mirror it and inline its manifests")
+		case len(bundle.Spec.WorkloadRefs) > 0:
+			return nil, nil, fmt.Errorf("workload references are read from the hub, inline their manifests")
+		case componentRefs(bundle):
+			return nil, nil, fmt.Errorf("the workload references of components are read from the hub, inline their manifests")
+		case len(bundle.Spec.ImageUpdates) > 0:
+			return nil, nil, fmt.Errorf("image update policies poll the registries, remove them")
+		}
+	}
+	packed := bundle.DeepCopy()
+	packed.Status = appv1alpha1.AppBundleStatus{}
+	images := []Image{}
+	seen := map[string]string{}
+	mapImage := func(image string) (string, error) {
+		if p, ok := seen[image]; ok {
+			return p, nil
+		}
+		source, err := pin(ctx, r, image)
+		if err != nil {
+			return "", err
+		}
+		p := mirror(source, opts.Registry)
+		seen[image] = p
+		images = append(images, Image{Source: source, Packed: p})
+		return p, nil
+	}
+	ms, err := manifests.MapImages(bundle.Spec.Workload.Manifests, mapImage)
+	if err != nil {
+		return nil, nil, err
+	}
+	packed.Spec.Workload.Manifests = ms
+	for i, c := range bundle.Spec.Components {
+		ms, err := manifests.MapImages(c.Manifests, mapImage)
+		if err != nil {
+			return nil, nil, err
+		}
+		packed.Spec.Components[i].Manifests = ms
+	}
+	return packed, images, nil
+}
+
+// componentRefs returns true if a component of the bundle has workload references
+func componentRefs(bundle *appv1alpha1.AppBundle) bool {
+	for _, c := range bundle.Spec.Components {
+		if len(c.WorkloadRefs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pin returns the image reference with the digest of its tag
+func pin(ctx context.Context, r Resolver, image string) (string, error) {
+	repo, tag := manifests.SplitImage(image)
+	if strings.HasPrefix(tag, "@") {
+		return image, nil
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	digest, err := r.Digest(ctx, repo, tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the digest of %s: %w", image, err)
+	}
+	return repo + ":" + tag + "@" + digest, nil
+}
+
+// mirror returns the reference of the image in the mirror registry, the image
+// itself when not set
+func mirror(image, reg string) string {
+	if reg == "" {
+		return image
+	}
+	_, path := registry.Reference(image)
+	return strings.TrimSuffix(reg, "/") + "/" + path
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package airgap
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) Digest(ctx context.Context, image, tag string) (string, error) {
+	if d, ok := f[image+":"+tag]; ok {
+		return d, nil
+	}
+	return "", fmt.Errorf("%s:%s not found", image, tag)
+}
+
+func testBundle(t *testing.T) *appv1alpha1.AppBundle {
+	ms, err := manifests.ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: ghcr.io/acme/web:v1
+      - name: proxy
+        image: nginx
+      - name: agent
+        image: ghcr.io/acme/agent@sha256:0001
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := &appv1alpha1.AppBundle{}
+	bundle.Spec.Workload.Manifests = ms
+	return bundle
+}
+
+func TestPack(t *testing.T) {
+	r := fakeResolver{"ghcr.io/acme/web:v1": "sha256:1111", "nginx:latest": "sha256:2222"}
+	packed, images, err := Pack(context.TODO(), testBundle(t), r, Options{Registry: "mirror.local:5000/hub", AirGap: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Image{
+		{Source: "ghcr.io/acme/web:v1@sha256:1111", Packed: "mirror.local:5000/hub/acme/web:v1@sha256:1111"},
+		{Source: "nginx:latest@sha256:2222", Packed: "mirror.local:5000/hub/library/nginx:latest@sha256:2222"},
+		{Source: "ghcr.io/acme/agent@sha256:0001", Packed: "mirror.local:5000/hub/acme/agent@sha256:0001"},
+	}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("expected images %v, got %v", expected, images)
+	}
+	u, err := manifests.ToUnstructured(packed.Spec.Workload.Manifests[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+	for i, c := range containers {
+		if image := c.(map[string]interface{})["image"]; image != expected[i].Packed {
+			t.Errorf("expected container %d to run %s, got %v", i, expected[i].Packed, image)
+		}
+	}
+
+	if _, _, err := Pack(context.TODO(), testBundle(t), fakeResolver{}, Options{}); err == nil {
+		t.Error("expected an unresolved image to fail")
+	}
+	bundle := testBundle(t)
+	bundle.Spec.Flux = &appv1alpha1.FluxSource{}
+	if _, _, err := Pack(context.TODO(), bundle, r, Options{AirGap: true}); err == nil {
+		t.Error("expected a flux source to be rejected")
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package analysis evaluates the analysis metrics of the bundles against a Prometheus
+// compatible API
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// Client queries a Prometheus compatible API, e.g. Prometheus or a Thanos querier
+type Client struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string            `json:"resultType"`
+		Result     []json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// Query runs an instant query and returns the value of its single sample
+func (c *Client) Query(ctx context.Context, query string) (float64, error) {
+	u := strings.TrimSuffix(c.URL, "/") + "/api/v1/query?query=" + url.QueryEscape(query)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	var out queryResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return 0, fmt.Errorf("invalid response %s: %w", resp.Status, err)
+	}
+	if out.Status != "success" {
+		return 0, fmt.Errorf("query failed: %s", out.Error)
+	}
+	return sampleValue(out.Data.ResultType, out.Data.Result)
+}
+
+// sampleValue returns the value of a scalar or of a single element vector
+func sampleValue(resultType string, result []json.RawMessage) (float64, error) {
+	var value []interface{}
+	switch resultType {
+	case "scalar":
+		// the result of a scalar is the [time, value] pair itself
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return 0, err
+		}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return 0, err
+		}
+	case "vector":
+		if len(result) != 1 {
+			return 0, fmt.Errorf("expected a single sample, got %d", len(result))
+		}
+		var sample struct {
+			Value []interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(result[0], &sample); err != nil {
+			return 0, err
+		}
+		value = sample.Value
+	default:
+		return 0, fmt.Errorf("unsupported result type %s", resultType)
+	}
+	if len(value) != 2 {
+		return 0, fmt.Errorf("malformed sample")
+	}
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("malformed sample value")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// Vars are the variables given to the query templates
+type Vars struct {
+	Cluster    string
+	Namespace  string
+	Bundle     string
+	Generation int64
+}
+
+// Result is the outcome of the evaluation of a metric for a cluster
+type Result struct {
+	Metric  string
+	Cluster string
+	Value   float64
+	Err     error
+}
+
+// Failed returns true if the value is out of the bounds of the metric
+func (r Result) Failed(m appv1alpha1.AnalysisMetric) bool {
+	if r.Err != nil {
+		return false
+	}
+	if m.Min != nil && r.Value < m.Min.AsApproximateFloat64() {
+		return true
+	}
+	return m.Max != nil && r.Value > m.Max.AsApproximateFloat64()
+}
+
+// String describes the result
+func (r Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s on %s: %v", r.Metric, r.Cluster, r.Err)
+	}
+	return fmt.Sprintf("%s on %s: %g", r.Metric, r.Cluster, r.Value)
+}
+
+// Render renders a query template
+func Render(query string, vars Vars) (string, error) {
+	t, err := template.New("query").Option("missingkey=error").Parse(query)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Evaluate evaluates the metrics of an analysis on every cluster, returning the results
+// out of bounds and those which could not be evaluated
+func (c *Client) Evaluate(ctx context.Context, a *appv1alpha1.Analysis, vars Vars, clusters []string) (failed, errored []Result) {
+	for _, cluster := range clusters {
+		vars.Cluster = cluster
+		for _, m := range a.Metrics {
+			r := Result{Metric: m.Name, Cluster: cluster}
+			query, err := Render(m.Query, vars)
+			if err == nil {
+				r.Value, err = c.Query(ctx, query)
+			}
+			r.Err = err
+			if err != nil {
+				errored = append(errored, r)
+			} else if r.Failed(m) {
+				failed = append(failed, r)
+			}
+		}
+	}
+	return failed, errored
+}
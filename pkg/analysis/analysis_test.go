@@ -0,0 +1,66 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestEvaluate(t *testing.T) {
+	values := map[string]string{
+		`errors{cluster="cluster1"}`: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"0.01"]}]}}`,
+		`errors{cluster="cluster2"}`: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"0.2"]}]}}`,
+		`errors{cluster="cluster3"}`: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := values[r.URL.Query().Get("query")]
+		if !ok {
+			http.Error(w, `{"status":"error","error":"unexpected query"}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	max := resource.MustParse("0.05")
+	a := &appv1alpha1.Analysis{Metrics: []appv1alpha1.AnalysisMetric{{
+		Name:  "error-rate",
+		Query: `errors{cluster="{{ .Cluster }}"}`,
+		Max:   &max,
+	}}}
+	c := &Client{URL: server.URL}
+	failed, errored := c.Evaluate(context.TODO(), a, Vars{Bundle: "web"}, []string{"cluster1", "cluster2", "cluster3"})
+	if len(failed) != 1 || failed[0].Cluster != "cluster2" || failed[0].Value != 0.2 {
+		t.Errorf("expected cluster2 to fail, got %v", failed)
+	}
+	if len(errored) != 1 || errored[0].Cluster != "cluster3" {
+		t.Errorf("expected cluster3 to have no data, got %v", errored)
+	}
+}
+
+func TestRender(t *testing.T) {
+	if _, err := Render(`{{ .Unknown }}`, Vars{}); err == nil {
+		t.Error("expected an error for an unknown variable")
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package argocd reads the rendered manifests of Argo CD Applications
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ApplicationGVK is the kind of the Argo CD Applications
+var ApplicationGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"}
+
+// Client calls the API of an Argo CD server
+type Client struct {
+	// URL of the Argo CD server, e.g. https://argocd-server.argocd.svc
+	URL string
+	// Token is the bearer token of an Argo CD account allowed to get the applications
+	Token string
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+type manifestResponse struct {
+	Manifests []string `json:"manifests"`
+}
+
+// Manifests returns the manifests of an application as rendered by the repo server
+// of Argo CD for its target revision, as JSON documents
+func (c *Client) Manifests(ctx context.Context, namespace, name string) ([][]byte, error) {
+	u := fmt.Sprintf("%s/api/v1/applications/%s/manifests", strings.TrimSuffix(c.URL, "/"), url.PathEscape(name))
+	if namespace != "" {
+		u += "?appNamespace=" + url.QueryEscape(namespace)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("failed to get manifests of application %s: %s: %s", name, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var out manifestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	manifests := make([][]byte, 0, len(out.Manifests))
+	for _, m := range out.Manifests {
+		manifests = append(manifests, []byte(m))
+	}
+	return manifests, nil
+}
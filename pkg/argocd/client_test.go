@@ -0,0 +1,48 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argocd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestManifests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/applications/guestbook/manifests" || r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"manifests":["{\"kind\":\"Service\"}","{\"kind\":\"Deployment\"}"]}`))
+	}))
+	defer server.Close()
+
+	c := &Client{URL: server.URL + "/", Token: "token"}
+	manifests, err := c.Manifests(context.TODO(), "", "guestbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 2 || string(manifests[1]) != `{"kind":"Deployment"}` {
+		t.Errorf("unexpected manifests %q", manifests)
+	}
+
+	if _, err := (&Client{URL: server.URL}).Manifests(context.TODO(), "", "guestbook"); err == nil {
+		t.Error("expected an error without token")
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit records the distribution actions performed for AppBundles
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+const (
+	// AuditedBundleLabel is set on audit records to select the records of a bundle
+	AuditedBundleLabel = "cluster.open-cluster-management.io/audited-bundle"
+
+	// ModifiedByAnnotation records on a bundle the user who last changed its spec, set
+	// by the admission webhook
+	ModifiedByAnnotation = "cluster.open-cluster-management.io/last-modified-by"
+)
+
+// Sink stores audit records
+type Sink interface {
+	Record(ctx context.Context, record *appv1alpha1.AppBundleAudit) error
+}
+
+// CRSink stores audit records as AppBundleAudit resources in the bundle namespace
+type CRSink struct {
+	Client client.Client
+}
+
+// Record creates the audit record
+func (s *CRSink) Record(ctx context.Context, record *appv1alpha1.AppBundleAudit) error {
+	return s.Client.Create(ctx, record)
+}
+
+// LogSink writes audit records as JSON lines, e.g. to be shipped by a log collector
+type LogSink struct {
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+// Record writes the audit record
+func (s *LogSink) Record(ctx context.Context, record *appv1alpha1.AppBundleAudit) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.Writer.Write(append(data, '\n'))
+	return err
+}
+
+// ChangeAuthors returns who changed the bundle spec, as recorded in its managed fields,
+// most recent first. Status updates are not included.
+func ChangeAuthors(bundle *appv1alpha1.AppBundle) []appv1alpha1.ChangeAuthor {
+	authors := []appv1alpha1.ChangeAuthor{}
+	for _, f := range bundle.ManagedFields {
+		if f.Subresource != "" {
+			continue
+		}
+		authors = append(authors, appv1alpha1.ChangeAuthor{
+			Manager:   f.Manager,
+			Operation: string(f.Operation),
+			Time:      f.Time,
+		})
+	}
+	sort.SliceStable(authors, func(i, j int) bool {
+		if authors[i].Time == nil || authors[j].Time == nil {
+			return authors[j].Time == nil && authors[i].Time != nil
+		}
+		return authors[j].Time.Before(authors[i].Time)
+	})
+	return authors
+}
+
+// ModifiedBy returns the user who last changed the bundle spec, as recorded by the
+// admission webhook, or the latest field manager of the bundle without the webhook
+func ModifiedBy(bundle *appv1alpha1.AppBundle) string {
+	if user, ok := bundle.Annotations[ModifiedByAnnotation]; ok {
+		return user
+	}
+	if authors := ChangeAuthors(bundle); len(authors) > 0 {
+		return authors[0].Manager
+	}
+	return ""
+}
+
+// NewRecord returns an audit record for a distribution action on the bundle
+func NewRecord(bundle *appv1alpha1.AppBundle, digest string, diff appv1alpha1.ManifestDiff, clusters []appv1alpha1.ClusterAction) *appv1alpha1.AppBundleAudit {
+	record := &appv1alpha1.AppBundleAudit{}
+	record.GenerateName = bundle.Name + "-"
+	record.Namespace = bundle.Namespace
+	record.Labels = map[string]string{AuditedBundleLabel: bundle.Name}
+	record.Spec = appv1alpha1.AppBundleAuditSpec{
+		BundleName: bundle.Name,
+		BundleUID:  string(bundle.UID),
+		Generation: bundle.Generation,
+		Digest:     digest,
+		ChangedBy:  ChangeAuthors(bundle),
+		ModifiedBy: ModifiedBy(bundle),
+		Diff:       diff,
+		Clusters:   clusters,
+		Time:       metav1.Now(),
+	}
+	return record
+}
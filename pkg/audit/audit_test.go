@@ -0,0 +1,81 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestChangeAuthors(t *testing.T) {
+	at := func(minutes int) *metav1.Time {
+		ts := metav1.NewTime(time.Date(2022, 1, 1, 0, minutes, 0, 0, time.UTC))
+		return &ts
+	}
+	bundle := &appv1alpha1.AppBundle{}
+	bundle.ManagedFields = []metav1.ManagedFieldsEntry{
+		{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, Time: at(1)},
+		{Manager: "unknown", Operation: metav1.ManagedFieldsOperationUpdate},
+		{Manager: "kealm", Operation: metav1.ManagedFieldsOperationUpdate, Time: at(3), Subresource: "status"},
+		{Manager: "argocd", Operation: metav1.ManagedFieldsOperationApply, Time: at(2)},
+	}
+	managers := []string{}
+	for _, a := range ChangeAuthors(bundle) {
+		managers = append(managers, a.Manager)
+	}
+	// status updates are left out and the authors without a time come last
+	if expected := []string{"argocd", "kubectl", "unknown"}; !reflect.DeepEqual(managers, expected) {
+		t.Errorf("expected %v, got %v", expected, managers)
+	}
+}
+
+func TestLogSink(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default", UID: "uid", Generation: 2}}
+	clusters := []appv1alpha1.ClusterAction{{ClusterName: "cluster1", Action: "Created"}}
+	record := NewRecord(bundle, "sha256:abc", appv1alpha1.ManifestDiff{Added: []string{"/ConfigMap/default/shop"}}, clusters)
+	if record.Labels[AuditedBundleLabel] != "shop" || record.Spec.BundleUID != "uid" || record.Spec.Generation != 2 {
+		t.Errorf("unexpected record %+v", record)
+	}
+
+	var out bytes.Buffer
+	sink := &LogSink{Writer: &out}
+	if err := sink.Record(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Record(context.TODO(), record); err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per record, got %d", len(lines))
+	}
+	written := &appv1alpha1.AppBundleAudit{}
+	if err := json.Unmarshal(lines[0], written); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written.Spec.Clusters, clusters) || written.Spec.Digest != "sha256:abc" {
+		t.Errorf("unexpected record written %+v", written.Spec)
+	}
+}
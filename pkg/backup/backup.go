@@ -0,0 +1,301 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup exports the kealm state of a hub to a portable archive and restores
+// it, possibly on another hub
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/controllers"
+)
+
+// Version is the version of the archive format
+const Version = "kealm.backup/v1"
+
+// Archive is the exported kealm state: the bundles with the objects they reference,
+// their placements and audit records, and the cluster scoped configuration
+type Archive struct {
+	Version string      `json:"version"`
+	Created metav1.Time `json:"created"`
+
+	Configs      []appv1alpha1.KealmConfig                     `json:"configs,omitempty"`
+	ClusterLocks []appv1alpha1.ClusterLock                     `json:"clusterLocks,omitempty"`
+	Bundles      []appv1alpha1.AppBundle                       `json:"bundles,omitempty"`
+	Audits       []appv1alpha1.AppBundleAudit                  `json:"audits,omitempty"`
+	Placements   []clusterapiv1alpha1.Placement                `json:"placements,omitempty"`
+	SetBindings  []clusterapiv1alpha1.ManagedClusterSetBinding `json:"setBindings,omitempty"`
+	ConfigMaps   []corev1.ConfigMap                            `json:"configMaps,omitempty"`
+	Secrets      []corev1.Secret                               `json:"secrets,omitempty"`
+}
+
+// Marshal returns the archive as YAML
+func (a *Archive) Marshal() ([]byte, error) {
+	return yaml.Marshal(a)
+}
+
+// Unmarshal reads an archive written by Marshal
+func Unmarshal(data []byte) (*Archive, error) {
+	a := &Archive{}
+	if err := yaml.Unmarshal(data, a); err != nil {
+		return nil, err
+	}
+	if a.Version != Version {
+		return nil, fmt.Errorf("unsupported archive version %q, expected %q", a.Version, Version)
+	}
+	return a, nil
+}
+
+// Export reads the kealm state of the bundles in namespace, or in all namespaces when
+// empty. Only the placements, cluster set bindings, config maps and secrets of the
+// bundle namespaces are exported, the config maps and secrets only when referenced by
+// a bundle.
+func Export(ctx context.Context, c client.Reader, namespace string) (*Archive, error) {
+	a := &Archive{Version: Version, Created: metav1.Now()}
+
+	var configs appv1alpha1.KealmConfigList
+	if err := c.List(ctx, &configs); err != nil {
+		return nil, err
+	}
+	for i := range configs.Items {
+		a.Configs = append(a.Configs, configs.Items[i])
+		clean(&a.Configs[i].ObjectMeta)
+		a.Configs[i].Status = appv1alpha1.KealmConfigStatus{}
+	}
+	var locks appv1alpha1.ClusterLockList
+	if err := c.List(ctx, &locks); err != nil {
+		return nil, err
+	}
+	for i := range locks.Items {
+		a.ClusterLocks = append(a.ClusterLocks, locks.Items[i])
+		clean(&a.ClusterLocks[i].ObjectMeta)
+	}
+
+	var bundles appv1alpha1.AppBundleList
+	if err := c.List(ctx, &bundles, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	namespaces := sets.NewString()
+	configMaps, secrets := sets.NewString(), sets.NewString()
+	for _, b := range bundles.Items {
+		namespaces.Insert(b.Namespace)
+		refs := append([]appv1alpha1.WorkloadReference{}, b.Spec.WorkloadRefs...)
+		for _, c := range b.Spec.Components {
+			refs = append(refs, c.WorkloadRefs...)
+		}
+		for _, ref := range refs {
+			key := b.Namespace + "/" + ref.Name
+			switch ref.Kind {
+			case appv1alpha1.WorkloadRefKindConfigMap:
+				configMaps.Insert(key)
+			case appv1alpha1.WorkloadRefKindSecret:
+				secrets.Insert(key)
+			}
+		}
+		// the UID is kept to re-adopt the works of the bundle on import
+		b.ResourceVersion = ""
+		b.ManagedFields = nil
+		b.Finalizers = nil
+		b.Status = appv1alpha1.AppBundleStatus{}
+		a.Bundles = append(a.Bundles, b)
+	}
+
+	for _, ns := range namespaces.List() {
+		var audits appv1alpha1.AppBundleAuditList
+		if err := c.List(ctx, &audits, client.InNamespace(ns)); err != nil {
+			return nil, err
+		}
+		for i := range audits.Items {
+			a.Audits = append(a.Audits, audits.Items[i])
+			clean(&a.Audits[len(a.Audits)-1].ObjectMeta)
+		}
+		var placements clusterapiv1alpha1.PlacementList
+		if err := c.List(ctx, &placements, client.InNamespace(ns)); err != nil {
+			return nil, err
+		}
+		for i := range placements.Items {
+			a.Placements = append(a.Placements, placements.Items[i])
+			last := &a.Placements[len(a.Placements)-1]
+			clean(&last.ObjectMeta)
+			last.Status = clusterapiv1alpha1.PlacementStatus{}
+		}
+		var bindings clusterapiv1alpha1.ManagedClusterSetBindingList
+		if err := c.List(ctx, &bindings, client.InNamespace(ns)); err != nil {
+			return nil, err
+		}
+		for i := range bindings.Items {
+			a.SetBindings = append(a.SetBindings, bindings.Items[i])
+			clean(&a.SetBindings[len(a.SetBindings)-1].ObjectMeta)
+		}
+	}
+
+	for _, key := range configMaps.List() {
+		cm := corev1.ConfigMap{}
+		if err := c.Get(ctx, objectKey(key), &cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		clean(&cm.ObjectMeta)
+		a.ConfigMaps = append(a.ConfigMaps, cm)
+	}
+	for _, key := range secrets.List() {
+		s := corev1.Secret{}
+		if err := c.Get(ctx, objectKey(key), &s); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		clean(&s.ObjectMeta)
+		a.Secrets = append(a.Secrets, s)
+	}
+	return a, nil
+}
+
+// Report lists what an import did
+type Report struct {
+	// Created and Skipped list the restored objects as kind namespace/name, the
+	// skipped ones already existing
+	Created []string
+	Skipped []string
+	// Adopted lists the ManifestWorks relabeled as owned by the restored bundles
+	Adopted []string
+}
+
+// Import restores the archive, creating the objects which do not exist yet. The
+// ManifestWorks owned by the exported bundles are relabeled as owned by the restored
+// ones, so that the restored bundles adopt them instead of distributing new works.
+// The works are not relabeled when works is nil.
+func Import(ctx context.Context, c client.Client, works workclientset.Interface, a *Archive) (*Report, error) {
+	report := &Report{}
+	create := func(kind string, obj client.Object) error {
+		key := fmt.Sprintf("%s %s", kind, client.ObjectKeyFromObject(obj))
+		if err := c.Create(ctx, obj); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				report.Skipped = append(report.Skipped, key)
+				return nil
+			}
+			return fmt.Errorf("failed to create %s: %w", key, err)
+		}
+		report.Created = append(report.Created, key)
+		return nil
+	}
+
+	// restore the locks first, so that no work is written to a locked cluster
+	for i := range a.ClusterLocks {
+		if err := create("ClusterLock", &a.ClusterLocks[i]); err != nil {
+			return report, err
+		}
+	}
+	for i := range a.Configs {
+		if err := create("KealmConfig", &a.Configs[i]); err != nil {
+			return report, err
+		}
+	}
+	for i := range a.ConfigMaps {
+		if err := create("ConfigMap", &a.ConfigMaps[i]); err != nil {
+			return report, err
+		}
+	}
+	for i := range a.Secrets {
+		if err := create("Secret", &a.Secrets[i]); err != nil {
+			return report, err
+		}
+	}
+	for i := range a.SetBindings {
+		if err := create("ManagedClusterSetBinding", &a.SetBindings[i]); err != nil {
+			return report, err
+		}
+	}
+	for i := range a.Placements {
+		if err := create("Placement", &a.Placements[i]); err != nil {
+			return report, err
+		}
+	}
+	for i := range a.Audits {
+		if err := create("AppBundleAudit", &a.Audits[i]); err != nil {
+			return report, err
+		}
+	}
+
+	for i := range a.Bundles {
+		bundle := a.Bundles[i].DeepCopy()
+		exported := bundle.UID
+		clean(&bundle.ObjectMeta)
+		if err := create("AppBundle", bundle); err != nil {
+			return report, err
+		}
+		if works == nil || exported == "" {
+			continue
+		}
+		restored := &appv1alpha1.AppBundle{}
+		if err := c.Get(ctx, client.ObjectKeyFromObject(bundle), restored); err != nil {
+			return report, err
+		}
+		if restored.UID == exported {
+			continue
+		}
+		owned, err := works.WorkV1().ManifestWorks("").List(ctx, metav1.ListOptions{
+			LabelSelector: controllers.OwnedLabel + "=" + string(exported),
+		})
+		if err != nil {
+			return report, err
+		}
+		for i := range owned.Items {
+			w := &owned.Items[i]
+			w.Labels[controllers.OwnedLabel] = string(restored.UID)
+			if _, err := works.WorkV1().ManifestWorks(w.Namespace).Update(ctx, w, metav1.UpdateOptions{}); err != nil {
+				return report, err
+			}
+			report.Adopted = append(report.Adopted, w.Namespace+"/"+w.Name)
+		}
+	}
+	sort.Strings(report.Adopted)
+	return report, nil
+}
+
+// clean removes the server populated metadata
+func clean(meta *metav1.ObjectMeta) {
+	meta.UID = ""
+	meta.ResourceVersion = ""
+	meta.Generation = 0
+	meta.CreationTimestamp = metav1.Time{}
+	meta.ManagedFields = nil
+	meta.OwnerReferences = nil
+	meta.SelfLink = ""
+}
+
+func objectKey(key string) types.NamespacedName {
+	parts := strings.SplitN(key, "/", 2)
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+}
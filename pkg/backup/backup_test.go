@@ -0,0 +1,127 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	workfake "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/controllers"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme, appv1alpha1.AddToScheme, clusterapiv1alpha1.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return scheme
+}
+
+func bundle() *appv1alpha1.AppBundle {
+	return &appv1alpha1.AppBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps", UID: "old", ResourceVersion: "7",
+			Labels: map[string]string{controllers.PlacementLabel: "all"}},
+		Spec: appv1alpha1.AppBundleSpec{
+			WorkloadRefs: []appv1alpha1.WorkloadReference{{Kind: appv1alpha1.WorkloadRefKindConfigMap, Name: "web-manifests"}},
+		},
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	scheme := newScheme(t)
+	source := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		bundle(),
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web-manifests", Namespace: "apps"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "apps"}},
+		&clusterapiv1alpha1.Placement{ObjectMeta: metav1.ObjectMeta{Name: "all", Namespace: "apps"}},
+		&clusterapiv1alpha1.Placement{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"}},
+		&appv1alpha1.ClusterLock{ObjectMeta: metav1.ObjectMeta{Name: "incident", Namespace: "cluster1"}},
+	).Build()
+
+	exported, err := Export(context.TODO(), source, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := exported.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Bundles) != 1 || a.Bundles[0].UID != "old" || a.Bundles[0].ResourceVersion != "" {
+		t.Errorf("unexpected bundles %+v", a.Bundles)
+	}
+	if len(a.ConfigMaps) != 1 || a.ConfigMaps[0].Name != "web-manifests" {
+		t.Errorf("expected only the referenced config map, got %+v", a.ConfigMaps)
+	}
+	if len(a.Placements) != 1 || a.Placements[0].Name != "all" {
+		t.Errorf("expected only the placement of the bundle namespace, got %+v", a.Placements)
+	}
+	if len(a.ClusterLocks) != 1 {
+		t.Errorf("expected the cluster lock, got %+v", a.ClusterLocks)
+	}
+
+	// the bundle was already restored with another UID, its works are re-adopted
+	restored := bundle()
+	restored.UID = "new"
+	restored.ResourceVersion = ""
+	target := fake.NewClientBuilder().WithScheme(scheme).WithObjects(restored).Build()
+	works := workfake.NewSimpleClientset(
+		&workapiv1.ManifestWork{ObjectMeta: metav1.ObjectMeta{Name: "apps-web", Namespace: "cluster1",
+			Labels: map[string]string{controllers.OwnedLabel: "old"}}},
+		&workapiv1.ManifestWork{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "cluster1",
+			Labels: map[string]string{controllers.OwnedLabel: "another"}}},
+	)
+	report, err := Import(context.TODO(), target, works, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Created) != 3 || len(report.Skipped) != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.Adopted) != 1 || report.Adopted[0] != "cluster1/apps-web" {
+		t.Errorf("expected the work of the bundle to be adopted, got %v", report.Adopted)
+	}
+	w, err := works.WorkV1().ManifestWorks("cluster1").Get(context.TODO(), "apps-web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Labels[controllers.OwnedLabel] != "new" {
+		t.Errorf("expected the work to be owned by the restored bundle, got %v", w.Labels)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := target.Get(context.TODO(), client.ObjectKey{Namespace: "apps", Name: "web-manifests"}, cm); err != nil {
+		t.Errorf("expected the config map to be restored: %v", err)
+	}
+}
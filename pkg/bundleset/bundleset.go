@@ -0,0 +1,127 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bundleset generates the parameter sets of the AppBundleSets and renders the
+// templates of their bundles
+package bundleset
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// ClusterNameParam is the parameter holding the name of the cluster of a bundle
+const ClusterNameParam = "cluster.name"
+
+// Params is a parameter set of a generated bundle
+type Params map[string]string
+
+// ClusterParams returns the parameters of a cluster
+func ClusterParams(c manifests.ClusterContext) Params {
+	p := Params{
+		ClusterNameParam:   c.Name,
+		"cluster.region":   c.Region,
+		"cluster.cloud":    c.Cloud,
+		"cluster.platform": c.Platform,
+	}
+	for k, v := range c.Labels {
+		p["cluster.labels."+k] = v
+	}
+	return p
+}
+
+// DirectoryParams returns the parameters of a directory of a Git repository
+func DirectoryParams(path string) Params {
+	path = strings.Trim(path, "/")
+	return Params{
+		"path":          path,
+		"path.basename": path[strings.LastIndex(path, "/")+1:],
+	}
+}
+
+// Matrix returns the product of the parameter sets and of the parameters of the
+// clusters. The sets or the clusters are nil without generator of their kind, and
+// Matrix then returns the other ones.
+func Matrix(sets []Params, clusters []Params) []Params {
+	if clusters == nil {
+		return sets
+	}
+	if sets == nil {
+		return clusters
+	}
+	result := make([]Params, 0, len(sets)*len(clusters))
+	for _, s := range sets {
+		for _, c := range clusters {
+			p := Params{}
+			for k, v := range s {
+				p[k] = v
+			}
+			for k, v := range c {
+				p[k] = v
+			}
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Render returns the template with {{name}} substituted in all its strings with the
+// value of the parameter name. Other references, such as the {{ .Region }} templates
+// of cluster templating, are kept.
+func Render(t *appv1alpha1.BundleSetTemplate, p Params) (*appv1alpha1.BundleSetTemplate, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	pairs := make([]string, 0, 2*len(p))
+	for k, v := range p {
+		// the values are substituted within JSON strings
+		escaped, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, "{{"+k+"}}", string(escaped[1:len(escaped)-1]))
+	}
+	rendered := &appv1alpha1.BundleSetTemplate{}
+	if err := json.Unmarshal([]byte(strings.NewReplacer(pairs...).Replace(string(data))), rendered); err != nil {
+		return nil, fmt.Errorf("failed to render the template: %w", err)
+	}
+	return rendered, nil
+}
+
+// Name returns the name of the bundle of a parameter set: the name of the rendered
+// template, or the name of the set suffixed with a hash of the parameters
+func Name(set string, t *appv1alpha1.BundleSetTemplate, p Params) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, p[k])
+	}
+	return fmt.Sprintf("%s-%x", set, h.Sum(nil)[:4])
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundleset
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+func TestMatrix(t *testing.T) {
+	sets := []Params{{"tenant": "a"}, {"tenant": "b"}}
+	clusters := []Params{
+		ClusterParams(manifests.ClusterContext{Name: "cluster1", Region: "us-east-1"}),
+		ClusterParams(manifests.ClusterContext{Name: "cluster2", Labels: map[string]string{"env": "prod"}}),
+	}
+	m := Matrix(sets, clusters)
+	if len(m) != 4 {
+		t.Fatalf("expected 4 parameter sets, got %v", m)
+	}
+	if m[1]["tenant"] != "a" || m[1][ClusterNameParam] != "cluster2" || m[1]["cluster.labels.env"] != "prod" {
+		t.Errorf("unexpected parameter set %v", m[1])
+	}
+	if m := Matrix(sets, nil); !reflect.DeepEqual(m, sets) {
+		t.Errorf("expected the parameter sets without cluster generator, got %v", m)
+	}
+	if m := Matrix(nil, clusters); !reflect.DeepEqual(m, clusters) {
+		t.Errorf("expected the clusters without parameter generator, got %v", m)
+	}
+	if m := Matrix(sets, []Params{}); len(m) != 0 {
+		t.Errorf("expected no parameter set without cluster selected, got %v", m)
+	}
+	if p := DirectoryParams("/envs/prod/"); p["path"] != "envs/prod" || p["path.basename"] != "prod" {
+		t.Errorf("unexpected directory parameters %v", p)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tmpl := &appv1alpha1.BundleSetTemplate{
+		Name:   "web-{{cluster.name}}",
+		Labels: map[string]string{"tenant": "{{tenant}}"},
+		Spec: appv1alpha1.AppBundleSpec{
+			TargetNamespace: "{{tenant}}-web",
+			Template: &appv1alpha1.TemplateReference{
+				Name:       "web",
+				Parameters: map[string]string{"title": "{{title}}", "region": "{{ .Region }}"},
+			},
+		},
+	}
+	p := Params{ClusterNameParam: "cluster1", "tenant": "acme", "title": `"quoted" <title>`}
+	r, err := Render(tmpl, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "web-cluster1" || r.Labels["tenant"] != "acme" || r.Spec.TargetNamespace != "acme-web" {
+		t.Errorf("unexpected rendered template %+v", r)
+	}
+	if r.Spec.Template.Parameters["title"] != p["title"] || r.Spec.Template.Parameters["region"] != "{{ .Region }}" {
+		t.Errorf("unexpected rendered parameters %v", r.Spec.Template.Parameters)
+	}
+	if tmpl.Name != "web-{{cluster.name}}" {
+		t.Error("expected the template to be unchanged")
+	}
+
+	if n := Name("web", r, p); n != "web-cluster1" {
+		t.Errorf("expected the name of the template, got %s", n)
+	}
+	r.Name = ""
+	if n := Name("web", r, p); n != Name("web", r, Params{"tenant": "acme", "title": p["title"], ClusterNameParam: "cluster1"}) ||
+		n == Name("web", r, Params{"tenant": "acme"}) || len(n) != len("web-")+8 {
+		t.Errorf("unexpected generated name %s", n)
+	}
+}
+
+func TestListingURL(t *testing.T) {
+	tests := []struct {
+		repo, ref, path, expected string
+	}{
+		{repo: "https://github.com/acme/apps", path: "/envs/",
+			expected: "https://api.github.com/repos/acme/apps/contents/envs?ref=main"},
+		{repo: "https://gitlab.com/acme/apps.git", ref: "v1", path: "envs",
+			expected: "https://gitlab.com/api/v4/projects/acme%2Fapps/repository/tree?path=envs&per_page=100&ref=v1"},
+		{repo: "https://git.acme.com/platform/apps/", ref: "prod",
+			expected: "https://git.acme.com/api/v1/repos/platform/apps/contents/?ref=prod"},
+	}
+	for _, tt := range tests {
+		u, err := ListingURL(tt.repo, tt.ref, tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u != tt.expected {
+			t.Errorf("ListingURL(%s) = %s, expected %s", tt.repo, u, tt.expected)
+		}
+	}
+	if _, err := ListingURL("git@github.com:acme/apps.git", "", ""); err == nil {
+		t.Error("expected an SSH URL to fail")
+	}
+	if _, err := ListingURL("http://github.com/acme/apps", "", ""); err == nil {
+		t.Error("expected an HTTP URL to fail")
+	}
+}
+
+func TestDirectories(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/repos/acme/redirected/contents/envs" {
+			http.Redirect(w, r, "https://169.254.169.254/latest/meta-data", http.StatusFound)
+			return
+		}
+		if r.URL.Path != "/api/v1/repos/acme/apps/contents/envs" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"name": "staging", "path": "envs/staging", "type": "dir"},
+			{"name": "README.md", "path": "envs/README.md", "type": "file"},
+			{"name": "prod", "path": "envs/prod", "type": "dir"}
+		]`))
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	l := &DirectoryLister{HTTP: server.Client()}
+	g := &appv1alpha1.GitDirectoryGenerator{URL: server.URL + "/acme/apps", Path: "envs"}
+	if _, err := l.Directories(context.TODO(), g, []string{"github.com"}); err == nil {
+		t.Error("expected a host not listed to fail")
+	}
+	dirs, err := l.Directories(context.TODO(), g, []string{host})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dirs, []string{"envs/prod", "envs/staging"}) {
+		t.Errorf("unexpected directories %v", dirs)
+	}
+	g.Path = "apps"
+	if _, err := l.Directories(context.TODO(), g, []string{host}); err == nil {
+		t.Error("expected a missing directory to fail")
+	}
+	g = &appv1alpha1.GitDirectoryGenerator{URL: server.URL + "/acme/redirected", Path: "envs"}
+	if _, err := l.Directories(context.TODO(), g, []string{host}); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("expected the redirect to another host to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundleset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// maxListingSize bounds the size of the directory listings read from Git hosts
+const maxListingSize = 4 << 20
+
+// DirectoryLister lists the directories of Git repositories
+type DirectoryLister struct {
+	HTTP *http.Client
+}
+
+// entry is a file or directory of a listing of GitHub, GitLab or Gitea
+type entry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// Directories returns the paths of the directories of the generator, sorted. The
+// repository must be on one of the hosts, which the listing is not redirected away from.
+func (l *DirectoryLister) Directories(ctx context.Context, g *appv1alpha1.GitDirectoryGenerator, hosts []string) ([]string, error) {
+	repo, err := url.Parse(g.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL %s: %w", g.URL, err)
+	}
+	if !AllowedHost(repo, hosts) {
+		return nil, fmt.Errorf("host %s of repository %s is not a Git host of the KealmConfig", repo.Host, g.URL)
+	}
+	u, err := ListingURL(g.URL, g.Ref, g.Path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := http.Client{}
+	if l.HTTP != nil {
+		client = *l.HTTP
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" || req.URL.Host != via[0].URL.Host {
+			return fmt.Errorf("redirect of %s to %s refused", via[0].URL, req.URL)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list %s: %s", u, resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxListingSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxListingSize {
+		return nil, fmt.Errorf("the listing of %s is larger than %d bytes", u, maxListingSize)
+	}
+	var entries []entry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse the listing of %s: %w", u, err)
+	}
+	dirs := []string{}
+	for _, e := range entries {
+		// GitLab lists the directories as trees
+		if e.Type == "dir" || e.Type == "tree" {
+			dirs = append(dirs, e.Path)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// AllowedHost returns true if the host of the URL, with or without its port, is one of
+// the hosts
+func AllowedHost(u *url.URL, hosts []string) bool {
+	for _, h := range hosts {
+		if h != "" && (h == u.Host || h == u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListingURL returns the URL of the API listing a directory of a Git repository on
+// GitHub, GitLab or Gitea, e.g. https://api.github.com/repos/acme/apps/contents/envs?ref=main
+func ListingURL(repo, ref, path string) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git"))
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL %s: %w", repo, err)
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("repository URL %s is not an HTTPS URL", repo)
+	}
+	if ref == "" {
+		ref = "main"
+	}
+	path = strings.Trim(path, "/")
+	project := strings.TrimPrefix(u.Path, "/")
+	query := url.Values{"ref": {ref}}
+	switch {
+	case u.Host == "github.com":
+		u.Host = "api.github.com"
+		u.Path = fmt.Sprintf("/repos/%s/contents/%s", project, path)
+	case strings.Contains(u.Host, "gitlab"):
+		u.Path = fmt.Sprintf("/api/v4/projects/%s/repository/tree", project)
+		// the project is identified by its escaped full path
+		u.RawPath = fmt.Sprintf("/api/v4/projects/%s/repository/tree", url.PathEscape(project))
+		query.Set("path", path)
+		query.Set("per_page", "100")
+	default:
+		u.Path = fmt.Sprintf("/api/v1/repos/%s/contents/%s", project, path)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
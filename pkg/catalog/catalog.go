@@ -0,0 +1,159 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package catalog reads the indexes of AppBundleTemplates of the catalogs from Git
+// repositories and OCI registries
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/registry"
+)
+
+// maxIndexSize bounds the size of the indexes read from Git hosts
+const maxIndexSize = 4 << 20
+
+// Fetcher reads the indexes of the catalogs
+type Fetcher struct {
+	HTTP     *http.Client
+	Registry *registry.Client
+}
+
+// Fetch returns the content of the index of a catalog and its revision, the digest of
+// the artifact for OCI sources and of the file for Git sources
+func (f *Fetcher) Fetch(ctx context.Context, spec appv1alpha1.CatalogSpec) ([]byte, string, error) {
+	switch {
+	case spec.OCI != nil:
+		tag := spec.OCI.Tag
+		if tag == "" {
+			tag = "latest"
+		}
+		return f.Registry.Artifact(ctx, spec.OCI.Image, tag)
+	case spec.Git != nil:
+		u, err := RawURL(spec.Git.URL, spec.Git.Ref, spec.Git.Path)
+		if err != nil {
+			return nil, "", err
+		}
+		content, err := f.get(ctx, u)
+		if err != nil {
+			return nil, "", err
+		}
+		return content, fmt.Sprintf("sha256:%x", sha256.Sum256(content)), nil
+	}
+	return nil, "", fmt.Errorf("the catalog has neither a git nor an oci source")
+}
+
+func (f *Fetcher) get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := f.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %s", u, resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxIndexSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxIndexSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", u, maxIndexSize)
+	}
+	return content, nil
+}
+
+// RawURL returns the URL of the raw content of a file in a Git repository on GitHub,
+// GitLab or Gitea, e.g. https://raw.githubusercontent.com/acme/catalog/main/catalog.yaml
+func RawURL(repo, ref, path string) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git"))
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL %s: %w", repo, err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", fmt.Errorf("repository URL %s is not an HTTP URL", repo)
+	}
+	if ref == "" {
+		ref = "main"
+	}
+	if path == "" {
+		path = "catalog.yaml"
+	}
+	path = strings.TrimPrefix(path, "/")
+	switch {
+	case u.Host == "github.com":
+		u.Host = "raw.githubusercontent.com"
+		u.Path = fmt.Sprintf("%s/%s/%s", u.Path, ref, path)
+	case strings.Contains(u.Host, "gitlab"):
+		u.Path = fmt.Sprintf("%s/-/raw/%s/%s", u.Path, ref, path)
+	default:
+		u.Path = fmt.Sprintf("%s/raw/%s/%s", u.Path, ref, path)
+	}
+	return u.String(), nil
+}
+
+// Parse returns the AppBundleTemplates of an index, a stream of YAML or JSON documents
+func Parse(data []byte) ([]appv1alpha1.AppBundleTemplate, error) {
+	templates := []appv1alpha1.AppBundleTemplate{}
+	names := map[string]bool{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for i := 0; ; i++ {
+		t := appv1alpha1.AppBundleTemplate{}
+		if err := decoder.Decode(&t); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse document %d: %w", i, err)
+		}
+		if t.Kind == "" && t.Name == "" {
+			continue
+		}
+		if t.Kind != "AppBundleTemplate" {
+			return nil, fmt.Errorf("document %d is a %s, not an AppBundleTemplate", i, t.Kind)
+		}
+		if errs := validation.IsDNS1123Subdomain(t.Name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid name %q of document %d: %s", t.Name, i, strings.Join(errs, ", "))
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("duplicate AppBundleTemplate %s", t.Name)
+		}
+		if len(t.Spec.Versions) == 0 {
+			return nil, fmt.Errorf("AppBundleTemplate %s has no version", t.Name)
+		}
+		names[t.Name] = true
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
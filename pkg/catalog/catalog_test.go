@@ -0,0 +1,121 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package catalog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestRawURL(t *testing.T) {
+	tests := []struct {
+		repo, ref, path, expected string
+	}{
+		{repo: "https://github.com/acme/catalog", expected: "https://raw.githubusercontent.com/acme/catalog/main/catalog.yaml"},
+		{repo: "https://github.com/acme/catalog.git", ref: "v1", path: "/index/all.yaml",
+			expected: "https://raw.githubusercontent.com/acme/catalog/v1/index/all.yaml"},
+		{repo: "https://gitlab.com/acme/catalog", expected: "https://gitlab.com/acme/catalog/-/raw/main/catalog.yaml"},
+		{repo: "https://git.acme.com/platform/catalog/", ref: "prod",
+			expected: "https://git.acme.com/platform/catalog/raw/prod/catalog.yaml"},
+	}
+	for _, tt := range tests {
+		u, err := RawURL(tt.repo, tt.ref, tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u != tt.expected {
+			t.Errorf("RawURL(%s) = %s, expected %s", tt.repo, u, tt.expected)
+		}
+	}
+	if _, err := RawURL("git@github.com:acme/catalog.git", "", ""); err == nil {
+		t.Error("expected an SSH URL to fail")
+	}
+}
+
+const index = `
+apiVersion: app.open-cluster-management.io/v1alpha1
+kind: AppBundleTemplate
+metadata:
+  name: nginx
+spec:
+  versions:
+  - version: "1.0.0"
+    manifests: |
+      kind: ConfigMap
+---
+apiVersion: app.open-cluster-management.io/v1alpha1
+kind: AppBundleTemplate
+metadata:
+  name: redis
+spec:
+  versions:
+  - version: "6.2"
+    manifests: |
+      kind: ConfigMap
+`
+
+func TestParse(t *testing.T) {
+	templates, err := Parse([]byte(index))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 2 || templates[0].Name != "nginx" || templates[1].Spec.Versions[0].Version != "6.2" {
+		t.Errorf("unexpected templates %v", templates)
+	}
+
+	invalid := map[string]string{
+		"kind":      "kind: ConfigMap\nmetadata:\n  name: nginx",
+		"duplicate": index + "---\n" + strings.SplitN(index, "---", 2)[0],
+		"versions":  "kind: AppBundleTemplate\nmetadata:\n  name: nginx",
+		"name":      "kind: AppBundleTemplate\nmetadata:\n  name: Nginx\nspec:\n  versions:\n  - version: v1",
+	}
+	for name, data := range invalid {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("expected the %s case to fail", name)
+		}
+	}
+}
+
+func TestFetchGit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/acme/catalog/raw/main/catalog.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(index))
+	}))
+	defer server.Close()
+
+	f := &Fetcher{HTTP: server.Client()}
+	spec := appv1alpha1.CatalogSpec{Git: &appv1alpha1.CatalogGitSource{URL: server.URL + "/acme/catalog"}}
+	content, revision, err := f.Fetch(context.TODO(), spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != index || !strings.HasPrefix(revision, "sha256:") {
+		t.Errorf("unexpected index with revision %s", revision)
+	}
+	spec.Git.Ref = "dev"
+	if _, _, err := f.Fetch(context.TODO(), spec); err == nil {
+		t.Error("expected a missing file to fail")
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package chaos injects faults in the calls of the controller to the work API and
+// delays its lister reads, to test that the reconciles converge despite them. The
+// controller injects them only when built with the chaos tag.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const (
+	// FailureRateEnv is the environment variable setting the failure rate
+	FailureRateEnv = "KEALM_CHAOS_FAILURE_RATE"
+	// MaxDelayEnv is the environment variable setting the maximum delay
+	MaxDelayEnv = "KEALM_CHAOS_MAX_DELAY"
+	// SeedEnv is the environment variable setting the seed
+	SeedEnv = "KEALM_CHAOS_SEED"
+)
+
+// ErrInjected is the error of the failed calls
+var ErrInjected = errors.New("injected failure")
+
+// Config sets the faults injected
+type Config struct {
+	// FailureRate is the ratio of the calls failing, between 0 and 1
+	FailureRate float64
+	// MaxDelay bounds the random delay of the lister reads
+	MaxDelay time.Duration
+	// Seed of the random faults, for reproducible runs
+	Seed int64
+}
+
+// FromEnv returns the configuration set by the environment variables
+func FromEnv() (Config, error) {
+	cfg := Config{Seed: time.Now().UnixNano()}
+	var err error
+	if v := os.Getenv(FailureRateEnv); v != "" {
+		if cfg.FailureRate, err = strconv.ParseFloat(v, 64); err != nil || cfg.FailureRate < 0 || cfg.FailureRate > 1 {
+			return cfg, fmt.Errorf("invalid %s %q, expected a ratio between 0 and 1", FailureRateEnv, v)
+		}
+	}
+	if v := os.Getenv(MaxDelayEnv); v != "" {
+		if cfg.MaxDelay, err = time.ParseDuration(v); err != nil {
+			return cfg, fmt.Errorf("invalid %s %q: %w", MaxDelayEnv, v, err)
+		}
+	}
+	if v := os.Getenv(SeedEnv); v != "" {
+		if cfg.Seed, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return cfg, fmt.Errorf("invalid %s %q: %w", SeedEnv, v, err)
+		}
+	}
+	return cfg, nil
+}
+
+// Injector decides the faults injected
+type Injector struct {
+	cfg Config
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	failures int
+}
+
+// New returns an injector of the faults of the configuration
+func New(cfg Config) *Injector {
+	return &Injector{cfg: cfg, rand: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// Failures returns the number of failures injected
+func (i *Injector) Failures() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.failures
+}
+
+func (i *Injector) fail() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.cfg.FailureRate == 0 || i.rand.Float64() >= i.cfg.FailureRate {
+		return false
+	}
+	i.failures++
+	return true
+}
+
+func (i *Injector) delay() {
+	if i.cfg.MaxDelay <= 0 {
+		return
+	}
+	i.mu.Lock()
+	d := time.Duration(i.rand.Int63n(int64(i.cfg.MaxDelay)))
+	i.mu.Unlock()
+	time.Sleep(d)
+}
+
+// Transport fails the requests of the round tripper at the failure rate, alternately
+// with a transport error and a 503 response, e.g. to wrap the REST configuration of
+// the work client
+func (i *Injector) Transport(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if !i.fail() {
+			return rt.RoundTrip(req)
+		}
+		if i.Failures()%2 == 0 {
+			return nil, ErrInjected
+		}
+		return &http.Response{
+			Status:     "503 Service Unavailable",
+			StatusCode: http.StatusServiceUnavailable,
+			Header:     http.Header{"Content-Type": []string{"text/plain"}},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Reactor fails the actions of a fake clientset at the failure rate, alternately with
+// a conflict and a service unavailable error. It is prepended to the reactors of the
+// fake clientsets of the tests.
+func (i *Injector) Reactor(action k8stesting.Action) (bool, runtime.Object, error) {
+	if !i.fail() {
+		return false, nil, nil
+	}
+	gr := schema.GroupResource{Group: action.GetResource().Group, Resource: action.GetResource().Resource}
+	if action.GetVerb() == "update" && i.Failures()%2 == 0 {
+		return true, nil, apierrors.NewConflict(gr, "", ErrInjected)
+	}
+	return true, nil, apierrors.NewServiceUnavailable(ErrInjected.Error())
+}
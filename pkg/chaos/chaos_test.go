@@ -0,0 +1,109 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		rate, delay, seed string
+		want              Config
+		err               bool
+	}{
+		{rate: "0.2", delay: "50ms", seed: "7", want: Config{FailureRate: 0.2, MaxDelay: 50 * time.Millisecond, Seed: 7}},
+		{seed: "7", want: Config{Seed: 7}},
+		{rate: "1.5", err: true},
+		{rate: "-0.1", err: true},
+		{delay: "soon", err: true},
+		{seed: "x", err: true},
+	}
+	for _, tt := range tests {
+		for k, v := range map[string]string{FailureRateEnv: tt.rate, MaxDelayEnv: tt.delay, SeedEnv: tt.seed} {
+			os.Setenv(k, v)
+			defer os.Unsetenv(k)
+		}
+		cfg, err := FromEnv()
+		if (err != nil) != tt.err {
+			t.Errorf("FromEnv(%q, %q, %q) error = %v, want error %v", tt.rate, tt.delay, tt.seed, err, tt.err)
+			continue
+		}
+		if !tt.err && cfg != tt.want {
+			t.Errorf("FromEnv(%q, %q, %q) = %+v, want %+v", tt.rate, tt.delay, tt.seed, cfg, tt.want)
+		}
+	}
+}
+
+func TestReactor(t *testing.T) {
+	i := New(Config{FailureRate: 0.3, Seed: 1})
+	gvr := schema.GroupVersionResource{Group: "work.open-cluster-management.io", Version: "v1", Resource: "manifestworks"}
+	calls, failed := 1000, 0
+	for n := 0; n < calls; n++ {
+		handled, _, err := i.Reactor(k8stesting.NewUpdateAction(gvr, "cluster1", nil))
+		if !handled {
+			continue
+		}
+		failed++
+		if !apierrors.IsConflict(err) && !apierrors.IsServiceUnavailable(err) {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if failed != i.Failures() {
+		t.Errorf("failed %d calls, counted %d", failed, i.Failures())
+	}
+	if failed < 250 || failed > 350 {
+		t.Errorf("failed %d of %d calls, want about 30%%", failed, calls)
+	}
+
+	// without a failure rate the actions go to the next reactors
+	if handled, _, _ := New(Config{}).Reactor(k8stesting.NewGetAction(gvr, "cluster1", "web")); handled {
+		t.Error("action handled without failure rate")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	ok := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	rt := New(Config{FailureRate: 0.5, Seed: 1}).Transport(ok)
+	codes := map[int]int{}
+	errs := 0
+	for n := 0; n < 200; n++ {
+		req, _ := http.NewRequest(http.MethodGet, "https://hub/apis", nil)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			if !errors.Is(err, ErrInjected) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			errs++
+			continue
+		}
+		codes[resp.StatusCode]++
+	}
+	if codes[http.StatusOK] == 0 || codes[http.StatusServiceUnavailable] == 0 || errs == 0 {
+		t.Errorf("got %v responses and %d errors, want successes, 503 responses and errors", codes, errs)
+	}
+}
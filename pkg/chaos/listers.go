@@ -0,0 +1,96 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterlisterv1alpha1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+
+	"github.com/pdettori/kealm/pkg/decisions"
+)
+
+// ManagedClusterLister delays the reads of the lister
+func (i *Injector) ManagedClusterLister(l clusterlisterv1.ManagedClusterLister) clusterlisterv1.ManagedClusterLister {
+	return &managedClusterLister{ManagedClusterLister: l, i: i}
+}
+
+type managedClusterLister struct {
+	clusterlisterv1.ManagedClusterLister
+	i *Injector
+}
+
+func (l *managedClusterLister) List(selector labels.Selector) ([]*clusterv1.ManagedCluster, error) {
+	l.i.delay()
+	return l.ManagedClusterLister.List(selector)
+}
+
+func (l *managedClusterLister) Get(name string) (*clusterv1.ManagedCluster, error) {
+	l.i.delay()
+	return l.ManagedClusterLister.Get(name)
+}
+
+// PlacementLister delays the reads of the lister
+func (i *Injector) PlacementLister(l clusterlisterv1alpha1.PlacementLister) clusterlisterv1alpha1.PlacementLister {
+	return &placementLister{PlacementLister: l, i: i}
+}
+
+type placementLister struct {
+	clusterlisterv1alpha1.PlacementLister
+	i *Injector
+}
+
+func (l *placementLister) List(selector labels.Selector) ([]*clusterv1alpha1.Placement, error) {
+	l.i.delay()
+	return l.PlacementLister.List(selector)
+}
+
+func (l *placementLister) Placements(namespace string) clusterlisterv1alpha1.PlacementNamespaceLister {
+	return &placementNamespaceLister{PlacementNamespaceLister: l.PlacementLister.Placements(namespace), i: l.i}
+}
+
+type placementNamespaceLister struct {
+	clusterlisterv1alpha1.PlacementNamespaceLister
+	i *Injector
+}
+
+func (l *placementNamespaceLister) List(selector labels.Selector) ([]*clusterv1alpha1.Placement, error) {
+	l.i.delay()
+	return l.PlacementNamespaceLister.List(selector)
+}
+
+func (l *placementNamespaceLister) Get(name string) (*clusterv1alpha1.Placement, error) {
+	l.i.delay()
+	return l.PlacementNamespaceLister.Get(name)
+}
+
+// Decisions delays the reads of the decisions of the placements
+func (i *Injector) Decisions(r decisions.Reader) decisions.Reader {
+	return &decisionReader{Reader: r, i: i}
+}
+
+type decisionReader struct {
+	decisions.Reader
+	i *Injector
+}
+
+func (r *decisionReader) Get(namespace, placement string) (decisions.Decision, bool) {
+	r.i.delay()
+	return r.Reader.Get(namespace, placement)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package claims reads the readiness and outputs of the Crossplane claims applied on
+// the hub for the components of the bundles
+package claims
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Allowed returns true if the kind is listed, as Kind.group, in the allowed kinds. The
+// kinds of the core group are never allowed.
+func Allowed(gvk schema.GroupVersionKind, kinds []string) bool {
+	if gvk.Group == "" {
+		return false
+	}
+	for _, k := range kinds {
+		if k == gvk.Kind+"."+gvk.Group {
+			return true
+		}
+	}
+	return false
+}
+
+// Ready returns true if the claim reports a true Ready condition
+func Ready(claim *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(claim.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Ready" && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns the value of the dot separated path of a field of the claim, as is for
+// strings and in JSON for the other values
+func Field(claim *unstructured.Unstructured, path string) (string, bool, error) {
+	v, found, err := unstructured.NestedFieldNoCopy(claim.Object, strings.Split(strings.Trim(path, "."), ".")...)
+	if err != nil || !found || v == nil {
+		return "", false, err
+	}
+	if s, ok := v.(string); ok {
+		return s, s != "", nil
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid field %s of %s %s: %w", path, claim.GetKind(), claim.GetName(), err)
+	}
+	return string(out), true, nil
+}
+
+// ConnectionSecret returns the name of the Secret the claim writes its connection
+// details to, in its namespace, empty if it writes none
+func ConnectionSecret(claim *unstructured.Unstructured) string {
+	name, _, _ := unstructured.NestedString(claim.Object, "spec", "writeConnectionSecretToRef", "name")
+	return name
+}
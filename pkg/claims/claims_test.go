@@ -0,0 +1,113 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package claims
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+func claim(t *testing.T, s string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(s), &u.Object); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestClaim(t *testing.T) {
+	c := claim(t, `apiVersion: database.acme.com/v1alpha1
+kind: PostgreSQLInstance
+metadata:
+  name: shop-db
+spec:
+  parameters:
+    storageGB: 20
+  writeConnectionSecretToRef:
+    name: shop-db-conn
+status:
+  address: shop-db.abc.eu-west-1.rds.amazonaws.com
+  conditions:
+  - type: Synced
+    status: "True"
+  - type: Ready
+    status: "True"
+`)
+	if !Ready(c) {
+		t.Error("expected the claim to be ready")
+	}
+	if name := ConnectionSecret(c); name != "shop-db-conn" {
+		t.Errorf("expected the connection secret shop-db-conn, got %q", name)
+	}
+	tests := []struct {
+		path     string
+		expected string
+		found    bool
+	}{
+		{path: "status.address", expected: "shop-db.abc.eu-west-1.rds.amazonaws.com", found: true},
+		{path: ".spec.parameters.storageGB", expected: "20", found: true},
+		{path: "spec.parameters", expected: `{"storageGB":20}`, found: true},
+		{path: "status.port", found: false},
+	}
+	for _, tt := range tests {
+		v, found, err := Field(c, tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != tt.expected || found != tt.found {
+			t.Errorf("Field(%s) = %q, %t, expected %q, %t", tt.path, v, found, tt.expected, tt.found)
+		}
+	}
+
+	pending := claim(t, `apiVersion: database.acme.com/v1alpha1
+kind: PostgreSQLInstance
+metadata:
+  name: shop-db
+status:
+  conditions:
+  - type: Ready
+    status: "False"
+`)
+	if Ready(pending) {
+		t.Error("expected the claim not to be ready")
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	kinds := []string{"PostgreSQLInstance.database.acme.com", "Secret", "RoleBinding.rbac.authorization.k8s.io"}
+	tests := []struct {
+		gvk      schema.GroupVersionKind
+		expected bool
+	}{
+		{gvk: schema.GroupVersionKind{Group: "database.acme.com", Version: "v1alpha1", Kind: "PostgreSQLInstance"}, expected: true},
+		{gvk: schema.GroupVersionKind{Group: "cache.acme.com", Version: "v1alpha1", Kind: "PostgreSQLInstance"}, expected: false},
+		{gvk: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, expected: false},
+		{gvk: schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}, expected: false},
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}, expected: true},
+	}
+	for _, tt := range tests {
+		if allowed := Allowed(tt.gvk, kinds); allowed != tt.expected {
+			t.Errorf("Allowed(%s) = %t, expected %t", tt.gvk, allowed, tt.expected)
+		}
+	}
+	if Allowed(schema.GroupVersionKind{Group: "database.acme.com", Version: "v1alpha1", Kind: "PostgreSQLInstance"}, nil) {
+		t.Error("expected no kind to be allowed when none is listed")
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bundles helps the integrators distribute workloads with AppBundles through
+// the generated clientset: applying a bundle and waiting for its distribution
+package bundles
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/client-go/util/retry"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/client/clientset/versioned"
+)
+
+// Client applies and watches the AppBundles of a hub
+type Client struct {
+	Clientset versioned.Interface
+}
+
+// New returns a client of the AppBundles using the clientset
+func New(clientset versioned.Interface) *Client {
+	return &Client{Clientset: clientset}
+}
+
+// NewForConfig returns a client of the AppBundles of the hub of the config
+func NewForConfig(config *rest.Config) (*Client, error) {
+	clientset, err := versioned.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return New(clientset), nil
+}
+
+// Apply creates the bundle, or updates the spec, the labels and the annotations of the
+// existing bundle, retrying on conflicts. It returns the bundle written.
+func (c *Client) Apply(ctx context.Context, bundle *appv1alpha1.AppBundle) (*appv1alpha1.AppBundle, error) {
+	bundles := c.Clientset.AppV1alpha1().AppBundles(bundle.Namespace)
+	var applied *appv1alpha1.AppBundle
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := bundles.Get(ctx, bundle.Name, v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			applied, err = bundles.Create(ctx, bundle, v1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		updated := existing.DeepCopy()
+		updated.Spec = *bundle.Spec.DeepCopy()
+		updated.Labels = mergeStrings(existing.Labels, bundle.Labels)
+		updated.Annotations = mergeStrings(existing.Annotations, bundle.Annotations)
+		applied, err = bundles.Update(ctx, updated, v1.UpdateOptions{})
+		return err
+	})
+	return applied, err
+}
+
+// mergeStrings returns the values of base overridden by the values of overrides
+func mergeStrings(base, overrides map[string]string) map[string]string {
+	if len(base) == 0 && len(overrides) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Wait watches the bundle namespace/name until the condition returns true, the
+// condition failing, or the context being done. It returns the last version of the
+// bundle seen.
+func (c *Client) Wait(ctx context.Context, namespace, name string, condition func(*appv1alpha1.AppBundle) (bool, error)) (*appv1alpha1.AppBundle, error) {
+	bundles := c.Clientset.AppV1alpha1().AppBundles(namespace)
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return bundles.List(ctx, options)
+		},
+		WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return bundles.Watch(ctx, options)
+		},
+	}
+	var last *appv1alpha1.AppBundle
+	_, err := watchtools.UntilWithSync(ctx, lw, &appv1alpha1.AppBundle{}, nil, func(event watch.Event) (bool, error) {
+		bundle, ok := event.Object.(*appv1alpha1.AppBundle)
+		if !ok || bundle.Name != name {
+			return false, nil
+		}
+		if event.Type == watch.Deleted {
+			return false, fmt.Errorf("AppBundle %s/%s was deleted", namespace, name)
+		}
+		last = bundle
+		return condition(bundle)
+	})
+	return last, err
+}
+
+// Available returns true once the controller distributed the current generation of the
+// bundle to at least one cluster and its work is Available on each of its clusters. A
+// bundle whose placement decides no cluster is never available.
+func Available(bundle *appv1alpha1.AppBundle) (bool, error) {
+	synced := meta.FindStatusCondition(bundle.Status.Conditions, appv1alpha1.ConditionSynced)
+	if synced == nil || synced.Status != v1.ConditionTrue || synced.ObservedGeneration != bundle.Generation {
+		return false, nil
+	}
+	if len(bundle.Status.Clusters) == 0 {
+		return false, nil
+	}
+	for _, c := range bundle.Status.Clusters {
+		if !meta.IsStatusConditionTrue(c.Conditions, workapiv1.WorkAvailable) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundles
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/client/clientset/versioned/fake"
+)
+
+func TestApply(t *testing.T) {
+	existing := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{
+		Name: "web", Namespace: "shop", Labels: map[string]string{"team": "web"},
+	}}
+	existing.Spec.TargetNamespace = "old"
+	existing.Status.Clusters = []appv1alpha1.ClusterStatus{{ClusterName: "cluster1"}}
+	c := New(fake.NewSimpleClientset(existing))
+	ctx := context.TODO()
+
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{
+		Name: "web", Namespace: "shop", Labels: map[string]string{"tier": "frontend"},
+	}}
+	bundle.Spec.TargetNamespace = "web"
+	applied, err := c.Apply(ctx, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Spec.TargetNamespace != "web" || applied.Labels["team"] != "web" || applied.Labels["tier"] != "frontend" {
+		t.Errorf("expected the spec and the labels applied, got %+v", applied.ObjectMeta)
+	}
+	if len(applied.Status.Clusters) != 1 {
+		t.Errorf("expected the status kept, got %+v", applied.Status)
+	}
+
+	created := bundle.DeepCopy()
+	created.Name = "api"
+	if _, err := c.Apply(ctx, created); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Clientset.AppV1alpha1().AppBundles("shop").Get(ctx, "api", v1.GetOptions{}); err != nil {
+		t.Errorf("expected the bundle created, got %v", err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "shop", Generation: 2}}
+	bundle.Status.Clusters = []appv1alpha1.ClusterStatus{{ClusterName: "cluster1"}}
+	other := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "api", Namespace: "shop"}}
+	c := New(fake.NewSimpleClientset(bundle, other))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		available := bundle.DeepCopy()
+		available.Status.Conditions = []v1.Condition{{
+			Type: appv1alpha1.ConditionSynced, Status: v1.ConditionTrue, Reason: appv1alpha1.ReasonSynced, ObservedGeneration: 2,
+		}}
+		available.Status.Clusters[0].Conditions = []v1.Condition{{Type: workapiv1.WorkAvailable, Status: v1.ConditionTrue}}
+		if _, err := c.Clientset.AppV1alpha1().AppBundles("shop").UpdateStatus(ctx, available, v1.UpdateOptions{}); err != nil {
+			t.Error(err)
+		}
+	}()
+	last, err := c.Wait(ctx, "shop", "web", Available)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := Available(last); !ok || last.Name != "web" {
+		t.Errorf("expected the bundle available, got %+v", last.Status)
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	bundle := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Generation: 3}}
+	bundle.Status.Conditions = []v1.Condition{{Type: appv1alpha1.ConditionSynced, Status: v1.ConditionTrue, ObservedGeneration: 3}}
+	if ok, _ := Available(bundle); ok {
+		t.Error("expected a bundle without cluster not available")
+	}
+	bundle.Status.Conditions[0].ObservedGeneration = 2
+	bundle.Status.Clusters = []appv1alpha1.ClusterStatus{{
+		ClusterName: "cluster1", Conditions: []v1.Condition{{Type: workapiv1.WorkAvailable, Status: v1.ConditionTrue}},
+	}}
+	if ok, _ := Available(bundle); ok {
+		t.Error("expected a bundle synced for a previous generation not available")
+	}
+	bundle.Status.Conditions[0].ObservedGeneration = 3
+	if ok, _ := Available(bundle); !ok {
+		t.Error("expected the bundle available")
+	}
+	bundle.Status.Clusters = append(bundle.Status.Clusters, appv1alpha1.ClusterStatus{ClusterName: "cluster2"})
+	if ok, _ := Available(bundle); ok {
+		t.Error("expected a bundle with a cluster not available not available")
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clusterhealth evaluates the health of the agents of the managed clusters, so
+// that the works not applied are attributed to a broken agent rather than to their
+// manifests
+package clusterhealth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// AddOnListGVK is the kind of the lists of ManagedClusterAddOns, read as unstructured
+// as the add-on API is not installed on every hub
+var AddOnListGVK = schema.GroupVersionKind{
+	Group:   addonv1alpha1.GroupName,
+	Version: addonv1alpha1.GroupVersion.Version,
+	Kind:    "ManagedClusterAddOnList",
+}
+
+// AddOns returns the typed ManagedClusterAddOns of an unstructured list, by namespace
+func AddOns(list *unstructured.UnstructuredList) (map[string][]addonv1alpha1.ManagedClusterAddOn, error) {
+	addons := map[string][]addonv1alpha1.ManagedClusterAddOn{}
+	for _, item := range list.Items {
+		addon := addonv1alpha1.ManagedClusterAddOn{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &addon); err != nil {
+			return nil, fmt.Errorf("invalid ManagedClusterAddOn %s/%s: %w", item.GetNamespace(), item.GetName(), err)
+		}
+		addons[addon.Namespace] = append(addons[addon.Namespace], addon)
+	}
+	return addons, nil
+}
+
+// Evaluate returns the health of a cluster: Unavailable while its klusterlet does not
+// report it available, Degraded while some of its add-ons are not available or are
+// degraded, Healthy otherwise
+func Evaluate(cluster *clusterv1.ManagedCluster, addons []addonv1alpha1.ManagedClusterAddOn) appv1alpha1.ClusterHealth {
+	degraded := []string{}
+	for _, a := range addons {
+		if !meta.IsStatusConditionTrue(a.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionAvailable) ||
+			meta.IsStatusConditionTrue(a.Status.Conditions, addonv1alpha1.ManagedClusterAddOnConditionDegraded) {
+			degraded = append(degraded, a.Name)
+		}
+	}
+	sort.Strings(degraded)
+	h := appv1alpha1.ClusterHealth{Status: appv1alpha1.ClusterHealthy}
+	if len(degraded) > 0 {
+		h.Status = appv1alpha1.ClusterDegraded
+		h.DegradedAddOns = degraded
+		h.Message = "Add-ons " + strings.Join(degraded, ",") + " not available"
+	}
+	available := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+	switch {
+	case available == nil:
+		h.Status = appv1alpha1.ClusterUnavailable
+		h.Message = "The klusterlet never reported the cluster available"
+	case available.Status != metav1.ConditionTrue:
+		h.Status = appv1alpha1.ClusterUnavailable
+		h.Message = fmt.Sprintf("The cluster is not available since %s: %s",
+			available.LastTransitionTime.UTC().Format(time.RFC3339), available.Message)
+	}
+	return h
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterhealth
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func cluster(available metav1.ConditionStatus) *clusterv1.ManagedCluster {
+	c := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}}
+	if available != "" {
+		c.Status.Conditions = []metav1.Condition{{
+			Type: clusterv1.ManagedClusterConditionAvailable, Status: available, Message: "lease not renewed",
+		}}
+	}
+	return c
+}
+
+func addon(name string, conditions ...metav1.Condition) addonv1alpha1.ManagedClusterAddOn {
+	return addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cluster1"},
+		Status:     addonv1alpha1.ManagedClusterAddOnStatus{Conditions: conditions},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	available := metav1.Condition{Type: addonv1alpha1.ManagedClusterAddOnConditionAvailable, Status: metav1.ConditionTrue}
+	degraded := metav1.Condition{Type: addonv1alpha1.ManagedClusterAddOnConditionDegraded, Status: metav1.ConditionTrue}
+	addons := []addonv1alpha1.ManagedClusterAddOn{
+		addon("work-manager", available),
+		addon("search", available, degraded),
+		addon("policy"),
+	}
+
+	if h := Evaluate(cluster(metav1.ConditionTrue), addons[:1]); h.Status != appv1alpha1.ClusterHealthy || h.Message != "" {
+		t.Errorf("expected a healthy cluster, got %+v", h)
+	}
+	h := Evaluate(cluster(metav1.ConditionTrue), addons)
+	if h.Status != appv1alpha1.ClusterDegraded || !reflect.DeepEqual(h.DegradedAddOns, []string{"policy", "search"}) {
+		t.Errorf("expected a degraded cluster, got %+v", h)
+	}
+	h = Evaluate(cluster(metav1.ConditionUnknown), addons)
+	if h.Status != appv1alpha1.ClusterUnavailable || !strings.Contains(h.Message, "lease not renewed") || len(h.DegradedAddOns) != 2 {
+		t.Errorf("expected an unavailable cluster, got %+v", h)
+	}
+	if h := Evaluate(cluster(""), nil); h.Status != appv1alpha1.ClusterUnavailable {
+		t.Errorf("expected a cluster never available to be unavailable, got %+v", h)
+	}
+}
+
+func TestAddOns(t *testing.T) {
+	list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: map[string]interface{}{
+		"apiVersion": "addon.open-cluster-management.io/v1alpha1",
+		"kind":       "ManagedClusterAddOn",
+		"metadata":   map[string]interface{}{"name": "work-manager", "namespace": "cluster1"},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": "False", "reason": "LeaseExpired",
+				"message": "lease expired", "lastTransitionTime": "2022-01-01T00:00:00Z"},
+		}},
+	}}}}
+	addons, err := AddOns(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addons["cluster1"]) != 1 || addons["cluster1"][0].Status.Conditions[0].Reason != "LeaseExpired" {
+		t.Errorf("unexpected add-ons %+v", addons)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Limiter is a counting semaphore whose limit can be changed while in use. Waiters
+// are admitted by decreasing priority, then in arrival order.
+type Limiter struct {
+	mu      sync.Mutex
+	max     int
+	limit   int
+	holders int
+	waiters []*waiter
+	seq     uint64
+	changed chan struct{}
+}
+
+type waiter struct {
+	priority int32
+	seq      uint64
+}
+
+// NewLimiter returns a limiter allowing limit holders, which can be raised up to max
+func NewLimiter(limit, max int) *Limiter {
+	if max < 1 {
+		max = 1
+	}
+	l := &Limiter{max: max, changed: make(chan struct{})}
+	l.SetLimit(limit)
+	return l
+}
+
+// Max returns the upper bound of the limit
+func (l *Limiter) Max() int {
+	return l.max
+}
+
+// Usage returns the current number of holders and limit
+func (l *Limiter) Usage() (holders, limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.holders, l.limit
+}
+
+// SetLimit changes the limit, bounded to [1, max]. Current holders are not affected.
+func (l *Limiter) SetLimit(limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > l.max {
+		limit = l.max
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.broadcast()
+}
+
+// Acquire blocks until the limiter can be held or the context is done
+func (l *Limiter) Acquire(ctx context.Context) error {
+	return l.AcquireWithPriority(ctx, 0)
+}
+
+// AcquireWithPriority blocks until the limiter can be held or the context is done.
+// Waiters with a higher priority are admitted first.
+func (l *Limiter) AcquireWithPriority(ctx context.Context, priority int32) error {
+	l.mu.Lock()
+	l.seq++
+	w := &waiter{priority: priority, seq: l.seq}
+	i := sort.Search(len(l.waiters), func(i int) bool { return l.waiters[i].priority < priority })
+	l.waiters = append(l.waiters, nil)
+	copy(l.waiters[i+1:], l.waiters[i:])
+	l.waiters[i] = w
+	for {
+		if l.holders < l.limit && l.waiters[0] == w {
+			l.waiters = l.waiters[1:]
+			l.holders++
+			// let the next waiter check whether it can be admitted too
+			l.broadcast()
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+		select {
+		case <-changed:
+			l.mu.Lock()
+		case <-ctx.Done():
+			l.mu.Lock()
+			l.remove(w)
+			l.broadcast()
+			l.mu.Unlock()
+			return ctx.Err()
+		}
+	}
+}
+
+// remove removes a waiter, must be called with the lock held
+func (l *Limiter) remove(w *waiter) {
+	for i := range l.waiters {
+		if l.waiters[i] == w {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// Release releases a hold on the limiter
+func (l *Limiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holders--
+	l.broadcast()
+}
+
+// broadcast wakes up the waiters, must be called with the lock held
+func (l *Limiter) broadcast() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(1, 2)
+	ctx := context.Background()
+	if err := l.Acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.Acquire(timeout); err == nil {
+		t.Fatalf("expected acquire to block beyond the limit")
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		if err := l.Acquire(ctx); err == nil {
+			close(acquired)
+		}
+	}()
+	l.SetLimit(2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatalf("expected acquire to succeed after raising the limit")
+	}
+	l.Release()
+	l.Release()
+}
+
+func TestLimiterPriority(t *testing.T) {
+	l := NewLimiter(1, 1)
+	ctx := context.Background()
+	if err := l.Acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan int32, 3)
+	for i, p := range []int32{0, 10, 5} {
+		p := p
+		go func() {
+			if err := l.AcquireWithPriority(ctx, p); err == nil {
+				order <- p
+				l.Release()
+			}
+		}()
+		// wait for the waiter to be queued
+		for {
+			l.mu.Lock()
+			n := len(l.waiters)
+			l.mu.Unlock()
+			if n == i+1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	l.Release()
+
+	for _, expected := range []int32{10, 5, 0} {
+		select {
+		case p := <-order:
+			if p != expected {
+				t.Fatalf("expected priority %d to be admitted, got %d", expected, p)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected priority %d to be admitted", expected)
+		}
+	}
+}
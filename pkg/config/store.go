@@ -0,0 +1,97 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config holds the controller-wide configuration loaded from the KealmConfig
+// resource, so that it can be reloaded without restarting the controller
+package config
+
+import (
+	"strings"
+	"sync"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// Store holds the current configuration. It is safe for concurrent use.
+type Store struct {
+	mu   sync.RWMutex
+	spec appv1alpha1.KealmConfigSpec
+
+	limiter *Limiter
+}
+
+// NewStore returns a store holding the default configuration, whose limiter allows
+// at most maxConcurrency concurrent holders
+func NewStore(maxConcurrency int) *Store {
+	return &Store{limiter: NewLimiter(maxConcurrency, maxConcurrency)}
+}
+
+// Get returns a copy of the current configuration
+func (s *Store) Get() appv1alpha1.KealmConfigSpec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return *s.spec.DeepCopy()
+}
+
+// Set replaces the current configuration
+func (s *Store) Set(spec appv1alpha1.KealmConfigSpec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spec = *spec.DeepCopy()
+	limit := s.limiter.Max()
+	if spec.MaxConcurrentReconciles != nil && int(*spec.MaxConcurrentReconciles) < limit {
+		limit = int(*spec.MaxConcurrentReconciles)
+	}
+	s.limiter.SetLimit(limit)
+}
+
+// Limiter returns the limiter bounding the concurrent reconciles
+func (s *Store) Limiter() *Limiter {
+	return s.limiter
+}
+
+// Propagate returns the entries of m whose keys are propagated by the policy
+func Propagate(policy *appv1alpha1.PropagationPolicy, m map[string]string, prefixes func(*appv1alpha1.PropagationPolicy) []string) map[string]string {
+	result := map[string]string{}
+	if policy != nil && policy.Mode == appv1alpha1.PropagateNone {
+		return result
+	}
+	for k, v := range m {
+		if policy == nil || policy.Mode != appv1alpha1.PropagateSelected || hasAnyPrefix(k, prefixes(policy)) {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// LabelPrefixes returns the label prefixes of a propagation policy
+func LabelPrefixes(p *appv1alpha1.PropagationPolicy) []string {
+	return p.Labels
+}
+
+// AnnotationPrefixes returns the annotation prefixes of a propagation policy
+func AnnotationPrefixes(p *appv1alpha1.PropagationPolicy) []string {
+	return p.Annotations
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
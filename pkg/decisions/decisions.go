@@ -0,0 +1,110 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package decisions reads the clusters decided for each placement from the
+// PlacementDecision informer, indexed by placement, so that the reconciles read them
+// without listing the decisions with a label selector
+package decisions
+
+import (
+	"sort"
+
+	"k8s.io/client-go/tools/cache"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+)
+
+// IndexPlacement is the index of the PlacementDecisions by the namespace and name of
+// their placement
+const IndexPlacement = "placement"
+
+// Decision is the outcome of the decisions of a placement
+type Decision struct {
+	// Names of the PlacementDecisions of the placement, sorted
+	Names []string
+	// Clusters decided, in the order of the decisions, each listed once
+	Clusters []string
+}
+
+// Reader returns the decision of a placement, false if the placement has no
+// PlacementDecision
+type Reader interface {
+	Get(namespace, placement string) (Decision, bool)
+}
+
+// Cache reads the decisions of the placements from the placement index of the
+// PlacementDecision informer
+type Cache struct {
+	indexer cache.Indexer
+}
+
+var _ Reader = &Cache{}
+
+// IndexFunc indexes the PlacementDecisions by the namespace and name of the placement
+// of their label
+func IndexFunc(obj interface{}) ([]string, error) {
+	d, ok := obj.(*clusterv1alpha1.PlacementDecision)
+	if !ok {
+		return nil, nil
+	}
+	placement, ok := d.Labels[clusterv1alpha1.PlacementLabel]
+	if !ok {
+		return nil, nil
+	}
+	return []string{key(d.Namespace, placement)}, nil
+}
+
+// AddIndexers adds the placement index to the PlacementDecision informer, before it is
+// started
+func AddIndexers(informer cache.SharedIndexInformer) error {
+	return informer.AddIndexers(cache.Indexers{IndexPlacement: IndexFunc})
+}
+
+// New returns the cache reading the indexer, which has the placement index
+func New(indexer cache.Indexer) *Cache {
+	return &Cache{indexer: indexer}
+}
+
+// Get returns the decision of the placement, false if the placement has no
+// PlacementDecision
+func (c *Cache) Get(namespace, placement string) (Decision, bool) {
+	objs, err := c.indexer.ByIndex(IndexPlacement, key(namespace, placement))
+	if err != nil || len(objs) == 0 {
+		return Decision{}, false
+	}
+	decisions := map[string]*clusterv1alpha1.PlacementDecision{}
+	d := Decision{Names: make([]string, 0, len(objs)), Clusters: []string{}}
+	for _, obj := range objs {
+		if decision, ok := obj.(*clusterv1alpha1.PlacementDecision); ok {
+			decisions[decision.Name] = decision
+			d.Names = append(d.Names, decision.Name)
+		}
+	}
+	sort.Strings(d.Names)
+	seen := map[string]bool{}
+	for _, name := range d.Names {
+		for _, decision := range decisions[name].Status.Decisions {
+			if !seen[decision.ClusterName] {
+				seen[decision.ClusterName] = true
+				d.Clusters = append(d.Clusters, decision.ClusterName)
+			}
+		}
+	}
+	return d, true
+}
+
+func key(namespace, placement string) string {
+	return namespace + "/" + placement
+}
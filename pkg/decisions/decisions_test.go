@@ -0,0 +1,95 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decisions
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+)
+
+func decision(name, placement string, clusters ...string) *clusterv1alpha1.PlacementDecision {
+	d := &clusterv1alpha1.PlacementDecision{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "shop"}}
+	if placement != "" {
+		d.Labels = map[string]string{clusterv1alpha1.PlacementLabel: placement}
+	}
+	for _, c := range clusters {
+		d.Status.Decisions = append(d.Status.Decisions, clusterv1alpha1.ClusterDecision{ClusterName: c})
+	}
+	return d
+}
+
+func TestCache(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{IndexPlacement: IndexFunc})
+	c := New(indexer)
+	if _, ok := c.Get("shop", "web"); ok {
+		t.Fatal("expected no decision in an empty cache")
+	}
+
+	// the clusters of a placement span several decisions
+	for _, d := range []*clusterv1alpha1.PlacementDecision{
+		decision("web-decision-2", "web", "cluster3", "cluster1"),
+		decision("web-decision-1", "web", "cluster1", "cluster2"),
+		decision("api-decision-1", "api", "cluster4"),
+		decision("orphan", ""),
+	} {
+		if err := indexer.Add(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, ok := c.Get("shop", "web")
+	if !ok {
+		t.Fatal("expected the decision of the placement")
+	}
+	expected := Decision{Names: []string{"web-decision-1", "web-decision-2"}, Clusters: []string{"cluster1", "cluster2", "cluster3"}}
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("expected %+v, got %+v", expected, d)
+	}
+	if _, ok := c.Get("other", "web"); ok {
+		t.Error("expected the placements to be namespaced")
+	}
+	if keys := indexer.ListIndexFuncValues(IndexPlacement); len(keys) != 2 {
+		t.Errorf("expected 2 placements, got %v", keys)
+	}
+
+	// a placement deciding no cluster still has a decision
+	_ = indexer.Update(decision("api-decision-1", "api"))
+	if d, ok := c.Get("shop", "api"); !ok || len(d.Clusters) != 0 {
+		t.Errorf("expected an empty decision, got %+v, %t", d, ok)
+	}
+
+	// a decision relabeled to another placement moves
+	_ = indexer.Update(decision("web-decision-2", "api", "cluster5"))
+	if d, _ := c.Get("shop", "web"); !reflect.DeepEqual(d.Clusters, []string{"cluster1", "cluster2"}) {
+		t.Errorf("expected the clusters of the remaining decision, got %v", d.Clusters)
+	}
+	if d, _ := c.Get("shop", "api"); !reflect.DeepEqual(d.Clusters, []string{"cluster5"}) {
+		t.Errorf("expected the clusters of the moved decision, got %v", d.Clusters)
+	}
+
+	_ = indexer.Delete(decision("web-decision-1", "web"))
+	if _, ok := c.Get("shop", "web"); ok {
+		t.Error("expected the placement without decision to be dropped")
+	}
+	_ = indexer.Delete(decision("web-decision-2", "api"))
+	if d, _ := c.Get("shop", "api"); !reflect.DeepEqual(d.Clusters, []string{}) {
+		t.Errorf("expected the deleted decision to be dropped, got %v", d.Clusters)
+	}
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diagnostics tracks the internal state of the controller and exposes it,
+// together with the pprof and expvar endpoints, for troubleshooting
+package diagnostics
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// StatePath is the path the state snapshot is served on
+	StatePath = "/debug/state"
+	// PprofPath is the path prefix of the pprof endpoints
+	PprofPath = "/debug/pprof/"
+	// VarsPath is the path of the expvar endpoint
+	VarsPath = "/debug/vars"
+	// RenderPath is the path the works rendered for a cluster are served on, with the
+	// namespace, bundle and cluster query parameters
+	RenderPath = "/debug/render"
+)
+
+// Reconcile describes an in-flight reconcile of a bundle
+type Reconcile struct {
+	Bundle   string    `json:"bundle"`
+	Started  time.Time `json:"started"`
+	Phase    string    `json:"phase"`
+	Clusters int       `json:"clusters,omitempty"`
+	Digest   string    `json:"digest,omitempty"`
+}
+
+// BundleState describes the outcome of the last reconcile of a bundle
+type BundleState struct {
+	LastReconcile time.Time `json:"lastReconcile"`
+	Duration      string    `json:"duration"`
+	Digest        string    `json:"digest,omitempty"`
+	Clusters      int       `json:"clusters"`
+	LastError     string    `json:"lastError,omitempty"`
+}
+
+// Snapshot is a point in time view of the controller state
+type Snapshot struct {
+	Time       time.Time              `json:"time"`
+	Goroutines int                    `json:"goroutines"`
+	InFlight   []Reconcile            `json:"inFlight"`
+	Bundles    map[string]BundleState `json:"bundles"`
+	Queues     map[string]QueueState  `json:"queues"`
+	Extra      map[string]interface{} `json:"extra,omitempty"`
+}
+
+// QueueState reports the work queue metrics of a controller
+type QueueState struct {
+	Depth                 float64 `json:"depth"`
+	UnfinishedWorkSeconds float64 `json:"unfinishedWorkSeconds"`
+	LongestRunningSeconds float64 `json:"longestRunningSeconds"`
+}
+
+// Tracker records the in-flight and completed reconciles. A nil tracker is valid and
+// records nothing.
+type Tracker struct {
+	mu       sync.Mutex
+	inFlight map[string]*Reconcile
+	bundles  map[string]BundleState
+	extra    map[string]func() interface{}
+}
+
+// NewTracker returns an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		inFlight: map[string]*Reconcile{},
+		bundles:  map[string]BundleState{},
+		extra:    map[string]func() interface{}{},
+	}
+}
+
+// Start records the start of the reconcile of a bundle
+func (t *Tracker) Start(bundle string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inFlight[bundle] = &Reconcile{Bundle: bundle, Started: time.Now(), Phase: "Started"}
+}
+
+// Phase records the current phase of the reconcile of a bundle
+func (t *Tracker) Phase(bundle, phase string) {
+	t.update(bundle, func(r *Reconcile) { r.Phase = phase })
+}
+
+// FanOut records the start of the distribution of a bundle with the given digest to
+// the given number of clusters
+func (t *Tracker) FanOut(bundle, digest string, clusters int) {
+	t.update(bundle, func(r *Reconcile) {
+		r.Phase = "FanOut"
+		r.Digest = digest
+		r.Clusters = clusters
+	})
+}
+
+func (t *Tracker) update(bundle string, f func(*Reconcile)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if r, ok := t.inFlight[bundle]; ok {
+		f(r)
+	}
+}
+
+// Finish records the outcome of the reconcile of a bundle
+func (t *Tracker) Finish(bundle string, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := BundleState{LastReconcile: time.Now()}
+	if r, ok := t.inFlight[bundle]; ok {
+		state.Duration = time.Since(r.Started).String()
+		state.Digest = r.Digest
+		state.Clusters = r.Clusters
+		delete(t.inFlight, bundle)
+	}
+	if err != nil {
+		state.LastError = err.Error()
+	}
+	t.bundles[bundle] = state
+}
+
+// Forget drops the state of a deleted bundle
+func (t *Tracker) Forget(bundle string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.bundles, bundle)
+}
+
+// AddExtra registers a function reporting additional state in the snapshots
+func (t *Tracker) AddExtra(name string, f func() interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.extra[name] = f
+}
+
+// Snapshot returns the current state
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	s := Snapshot{
+		Time:       time.Now(),
+		Goroutines: runtime.NumGoroutine(),
+		InFlight:   []Reconcile{},
+		Bundles:    map[string]BundleState{},
+		Extra:      map[string]interface{}{},
+	}
+	for _, r := range t.inFlight {
+		s.InFlight = append(s.InFlight, *r)
+	}
+	for k, v := range t.bundles {
+		s.Bundles[k] = v
+	}
+	extra := map[string]func() interface{}{}
+	for k, f := range t.extra {
+		extra[k] = f
+	}
+	t.mu.Unlock()
+
+	for k, f := range extra {
+		s.Extra[k] = f()
+	}
+	sort.Slice(s.InFlight, func(i, j int) bool { return s.InFlight[i].Started.Before(s.InFlight[j].Started) })
+	s.Queues = queueStates()
+	return s
+}
+
+// queueStates reads the work queue metrics of the controllers from the metrics registry
+func queueStates() map[string]QueueState {
+	states := map[string]QueueState{}
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		return states
+	}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			name := ""
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "name" {
+					name = l.GetValue()
+				}
+			}
+			if name == "" {
+				continue
+			}
+			state := states[name]
+			switch f.GetName() {
+			case "workqueue_depth":
+				state.Depth = m.GetGauge().GetValue()
+			case "workqueue_unfinished_work_seconds":
+				state.UnfinishedWorkSeconds = m.GetGauge().GetValue()
+			case "workqueue_longest_running_processor_seconds":
+				state.LongestRunningSeconds = m.GetGauge().GetValue()
+			default:
+				continue
+			}
+			states[name] = state
+		}
+	}
+	return states
+}
+
+// Handlers returns the diagnostics handlers by path
+func Handlers(t *Tracker) map[string]http.Handler {
+	return map[string]http.Handler{
+		StatePath: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(t.Snapshot())
+		}),
+		VarsPath:                   expvar.Handler(),
+		PprofPath:                  http.HandlerFunc(pprof.Index),
+		PprofPath + "cmdline":      http.HandlerFunc(pprof.Cmdline),
+		PprofPath + "profile":      http.HandlerFunc(pprof.Profile),
+		PprofPath + "symbol":       http.HandlerFunc(pprof.Symbol),
+		PprofPath + "trace":        http.HandlerFunc(pprof.Trace),
+		PprofPath + "goroutine":    pprof.Handler("goroutine"),
+		PprofPath + "heap":         pprof.Handler("heap"),
+		PprofPath + "allocs":       pprof.Handler("allocs"),
+		PprofPath + "block":        pprof.Handler("block"),
+		PprofPath + "mutex":        pprof.Handler("mutex"),
+		PprofPath + "threadcreate": pprof.Handler("threadcreate"),
+	}
+}
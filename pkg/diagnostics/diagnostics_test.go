@@ -0,0 +1,85 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Start("default/shop")
+	tracker.FanOut("default/shop", "sha256:abc", 3)
+	tracker.Start("default/cart")
+	tracker.AddExtra("stagger", func() interface{} { return 2 })
+
+	s := tracker.Snapshot()
+	if len(s.InFlight) != 2 {
+		t.Fatalf("expected both reconciles in flight, got %+v", s.InFlight)
+	}
+	for _, r := range s.InFlight {
+		if r.Bundle == "default/shop" && (r.Phase != "FanOut" || r.Clusters != 3) {
+			t.Errorf("expected the fan out to be recorded, got %+v", r)
+		}
+	}
+	if s.Extra["stagger"] != 2 {
+		t.Errorf("expected the extra state in the snapshot, got %+v", s.Extra)
+	}
+
+	tracker.Finish("default/shop", nil)
+	tracker.Finish("default/cart", errors.New("conflict"))
+	s = tracker.Snapshot()
+	if len(s.InFlight) != 0 {
+		t.Errorf("expected no reconcile in flight, got %+v", s.InFlight)
+	}
+	if shop := s.Bundles["default/shop"]; shop.Digest != "sha256:abc" || shop.Clusters != 3 || shop.LastError != "" {
+		t.Errorf("unexpected state of the finished reconcile %+v", shop)
+	}
+	if cart := s.Bundles["default/cart"]; cart.LastError != "conflict" {
+		t.Errorf("expected the error of the failed reconcile, got %+v", cart)
+	}
+
+	tracker.Forget("default/shop")
+	if _, ok := tracker.Snapshot().Bundles["default/shop"]; ok {
+		t.Errorf("expected the deleted bundle to be forgotten")
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	var tracker *Tracker
+	tracker.Start("default/shop")
+	tracker.Phase("default/shop", "Render")
+	tracker.Finish("default/shop", nil)
+	tracker.Forget("default/shop")
+}
+
+func TestStateHandler(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Start("default/shop")
+	w := httptest.NewRecorder()
+	Handlers(tracker)[StatePath].ServeHTTP(w, httptest.NewRequest("GET", StatePath, nil))
+	s := Snapshot{}
+	if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.InFlight) != 1 || s.InFlight[0].Bundle != "default/shop" {
+		t.Errorf("expected the in-flight reconcile in the dump, got %+v", s.InFlight)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package faults classifies the errors of the distribution of the bundles into the
+// reasons reported in their conditions, events and metrics, separating user errors
+// from system faults
+package faults
+
+import (
+	"errors"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+const (
+	// User is the category of the faults fixed by changing the bundle or its placement
+	User = "user"
+	// System is the category of the faults of the controller or the hub
+	System = "system"
+)
+
+// userReasons are the reasons of the user errors
+var userReasons = map[string]bool{
+	appv1alpha1.ReasonPlacementMissing:   true,
+	appv1alpha1.ReasonRenderFailed:       true,
+	appv1alpha1.ReasonPayloadTooLarge:    true,
+	appv1alpha1.ReasonHubAccessForbidden: true,
+}
+
+// Error is an error with the reason of its fault
+type Error struct {
+	Reason string
+	Err    error
+}
+
+// New returns an error with the reason of its fault
+func New(reason string, err error) *Error {
+	return &Error{Reason: reason, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors are the errors of several clusters, whose reason is the one of the first
+// classified error
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// As finds the first error matching the target
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Is reports whether any error matches the target
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Aggregate returns nil without error, the error when single, and Errors otherwise
+func Aggregate(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return Errors(errs)
+}
+
+// Reason returns the reason of the fault of an error, InternalError when not classified
+func Reason(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Reason
+	}
+	return appv1alpha1.ReasonInternalError
+}
+
+// Category returns the category of the fault of a reason
+func Category(reason string) string {
+	if userReasons[reason] {
+		return User
+	}
+	return System
+}
+
+// WorkWrite classifies an error writing a ManifestWork
+func WorkWrite(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsForbidden(err):
+		return New(appv1alpha1.ReasonWorkCreateForbidden, err)
+	case apierrors.IsRequestEntityTooLargeError(err) || tooLarge(err):
+		return New(appv1alpha1.ReasonPayloadTooLarge, err)
+	case apierrors.IsNotFound(err):
+		// the namespace of the cluster does not exist
+		return New(appv1alpha1.ReasonClusterUnavailable, err)
+	}
+	return err
+}
+
+// tooLarge returns true for the errors of etcd and of the ManifestWork webhook
+// rejecting large works
+func tooLarge(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "request is too large") ||
+		(strings.Contains(msg, "manifests") && strings.Contains(msg, "exceeds"))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestWorkWrite(t *testing.T) {
+	gr := schema.GroupResource{Group: "work.open-cluster-management.io", Resource: "manifestworks"}
+	tests := []struct {
+		err      error
+		reason   string
+		category string
+	}{
+		{err: apierrors.NewForbidden(gr, "web", errors.New("denied")), reason: appv1alpha1.ReasonWorkCreateForbidden, category: System},
+		{err: apierrors.NewRequestEntityTooLargeError("limit"), reason: appv1alpha1.ReasonPayloadTooLarge, category: User},
+		{err: apierrors.NewBadRequest("the size of manifests is 60000 bytes which exceeds the 50k limit"), reason: appv1alpha1.ReasonPayloadTooLarge, category: User},
+		{err: apierrors.NewNotFound(gr, "cluster1"), reason: appv1alpha1.ReasonClusterUnavailable, category: System},
+		{err: apierrors.NewConflict(gr, "web", errors.New("changed")), reason: appv1alpha1.ReasonInternalError, category: System},
+	}
+	for _, tt := range tests {
+		err := fmt.Errorf("failed: %w", WorkWrite(tt.err))
+		if reason := Reason(err); reason != tt.reason || Category(reason) != tt.category {
+			t.Errorf("expected %v to be a %s %s, got %s %s", tt.err, tt.category, tt.reason, Category(reason), reason)
+		}
+	}
+	if WorkWrite(nil) != nil {
+		t.Error("expected no error")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	gr := schema.GroupResource{Group: "work.open-cluster-management.io", Resource: "manifestworks"}
+	if Aggregate(nil) != nil {
+		t.Error("expected no error")
+	}
+	single := errors.New("failed")
+	if Aggregate([]error{single}) != single {
+		t.Error("expected the single error")
+	}
+	err := Aggregate([]error{
+		fmt.Errorf("cluster1: %w", context.DeadlineExceeded),
+		fmt.Errorf("cluster2: %w", WorkWrite(apierrors.NewForbidden(gr, "web", errors.New("denied")))),
+		fmt.Errorf("cluster3: %w", WorkWrite(apierrors.NewNotFound(gr, "cluster3"))),
+	})
+	if reason := Reason(err); reason != appv1alpha1.ReasonWorkCreateForbidden {
+		t.Errorf("expected the reason of the first classified error, got %s", reason)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, single) {
+		t.Errorf("unexpected errors %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "cluster1: ") || !strings.Contains(msg, "; cluster3: ") {
+		t.Errorf("unexpected message %s", msg)
+	}
+}
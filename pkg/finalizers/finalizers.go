@@ -0,0 +1,75 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package finalizers adds and removes finalizers with merge patches guarded by
+// the resource version, retrying on conflicts with concurrent writers.
+package finalizers
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Add adds the finalizer to the object. On conflicts the object is read again,
+// so it always reflects the latest version stored on the server.
+func Add(ctx context.Context, c client.Client, obj client.Object, finalizer string) error {
+	return patch(ctx, c, obj, func() bool {
+		if controllerutil.ContainsFinalizer(obj, finalizer) {
+			return false
+		}
+		controllerutil.AddFinalizer(obj, finalizer)
+		return true
+	})
+}
+
+// Remove removes the finalizer from the object. An object that is already gone
+// is not an error, as removing the last finalizer of a deleted object may
+// race with other controllers doing the same.
+func Remove(ctx context.Context, c client.Client, obj client.Object, finalizer string) error {
+	return client.IgnoreNotFound(patch(ctx, c, obj, func() bool {
+		if !controllerutil.ContainsFinalizer(obj, finalizer) {
+			return false
+		}
+		controllerutil.RemoveFinalizer(obj, finalizer)
+		return true
+	}))
+}
+
+// patch applies the mutation and patches the object when it changed, reading
+// the object again before each retry
+func patch(ctx context.Context, c client.Client, obj client.Object, mutate func() bool) error {
+	attempt := 0
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if attempt++; attempt > 1 {
+			// decode into a zero object, as decoding does not clear the fields
+			// missing from the latest version
+			latest := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+			if err := c.Get(ctx, client.ObjectKeyFromObject(obj), latest); err != nil {
+				return err
+			}
+			reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(latest).Elem())
+		}
+		base := obj.DeepCopyObject().(client.Object)
+		if !mutate() {
+			return nil
+		}
+		return c.Patch(ctx, obj, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+	})
+}
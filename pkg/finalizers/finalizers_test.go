@@ -0,0 +1,123 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package finalizers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const finalizer = "cluster.open-cluster-management.io/test"
+
+func configMap(finalizers ...string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps", ResourceVersion: "999", Finalizers: finalizers}}
+}
+
+// updated returns the object as written by another client
+func updated(cm *corev1.ConfigMap) *corev1.ConfigMap {
+	cm.ResourceVersion = "1000"
+	return cm
+}
+
+func TestAddConflict(t *testing.T) {
+	ctx := context.TODO()
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(configMap()).Build()
+	stale := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(configMap()), stale); err != nil {
+		t.Fatal(err)
+	}
+
+	// another writer updates the object after it was read
+	current := stale.DeepCopy()
+	current.Labels = map[string]string{"team": "web"}
+	if err := c.Update(ctx, current); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Add(ctx, c, stale, finalizer); err != nil {
+		t.Fatal(err)
+	}
+	got := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(stale), got); err != nil {
+		t.Fatal(err)
+	}
+	if !controllerutil.ContainsFinalizer(got, finalizer) {
+		t.Errorf("finalizer not added: %v", got.Finalizers)
+	}
+	if got.Labels["team"] != "web" {
+		t.Errorf("concurrent update lost: %v", got.Labels)
+	}
+	if stale.ResourceVersion != got.ResourceVersion {
+		t.Errorf("object not refreshed: %s, want %s", stale.ResourceVersion, got.ResourceVersion)
+	}
+}
+
+func TestAddPresent(t *testing.T) {
+	ctx := context.TODO()
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(configMap(finalizer)).Build()
+	obj := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(configMap()), obj); err != nil {
+		t.Fatal(err)
+	}
+	version := obj.ResourceVersion
+	if err := Add(ctx, c, obj, finalizer); err != nil {
+		t.Fatal(err)
+	}
+	if obj.ResourceVersion != version {
+		t.Errorf("unchanged object patched: %s, want %s", obj.ResourceVersion, version)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	ctx := context.TODO()
+	tests := []struct {
+		name    string
+		objects []client.Object
+		obj     *corev1.ConfigMap
+	}{
+		// another controller removed the finalizer and the object is gone
+		{name: "deleted", obj: configMap(finalizer)},
+		// the finalizer was removed by an earlier reconcile
+		{name: "removed", objects: []client.Object{updated(configMap("other"))}, obj: configMap(finalizer)},
+		{name: "present", objects: []client.Object{configMap(finalizer, "other")}, obj: configMap(finalizer, "other")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(tt.objects...).Build()
+			if err := Remove(ctx, c, tt.obj, finalizer); err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.objects) == 0 {
+				return
+			}
+			got := &corev1.ConfigMap{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(tt.obj), got); err != nil {
+				t.Fatal(err)
+			}
+			if controllerutil.ContainsFinalizer(got, finalizer) || !controllerutil.ContainsFinalizer(got, "other") {
+				t.Errorf("unexpected finalizers %v", got.Finalizers)
+			}
+		})
+	}
+}
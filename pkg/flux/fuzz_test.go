@@ -0,0 +1,91 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flux
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// FuzzClusterValues checks that malformed values and patches of the releases fail with
+// an error, never a panic, and that the values merged for a cluster are those set in
+// the rendered release. Fuzz it with:
+//
+//	go test ./pkg/flux -run '^$' -fuzz FuzzClusterValues -fuzztime 1m
+func FuzzClusterValues(f *testing.F) {
+	f.Add([]byte(`{"replicas":2,"image":{"tag":"v1"}}`), []byte(`{"image":{"tag":"v2"}}`), []byte("region: us-east-1\n"), "- op: replace\n  path: /spec/replicas\n  value: 3\n")
+	f.Add([]byte(`{"a":{"b":[1,2]}}`), []byte(`{"a":null}`), []byte("a:\n  b: {c: d}\n"), "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n")
+	f.Add([]byte(`[1]`), []byte(`"x"`), []byte("- not a map"), "")
+	f.Add([]byte(``), []byte(`{}`), []byte("a: [\n"), "[")
+	f.Fuzz(func(t *testing.T, release, clusterSet, cluster []byte, patch string) {
+		h := &appv1alpha1.FluxHelmRelease{
+			Chart:  "./charts/web",
+			Values: &runtime.RawExtension{Raw: release},
+			ClusterSetValues: []appv1alpha1.HelmClusterSetValues{
+				{ClusterSet: "prod", Values: &runtime.RawExtension{Raw: clusterSet}},
+			},
+		}
+		src := &appv1alpha1.FluxSource{
+			GitRepository: &appv1alpha1.FluxGitRepository{URL: "https://github.com/example/apps"},
+			HelmRelease:   h,
+			Patches:       []appv1alpha1.FluxPatch{{Patch: patch}},
+		}
+		ms, err := Render("web", src, nil)
+		if err != nil {
+			return
+		}
+		values, err := ClusterValues(h, map[string]string{ClusterSetLabel: "prod"}, cluster)
+		if err != nil {
+			return
+		}
+		result, err := SetValues(ms, values)
+		if err != nil {
+			t.Fatalf("set values %v: %v", values, err)
+		}
+		if len(result) != len(ms) {
+			t.Fatalf("set values in %d manifests of %d", len(result), len(ms))
+		}
+		for _, m := range result {
+			u, err := manifests.ToUnstructured(m)
+			if err != nil {
+				t.Fatalf("manifest %q does not decode: %v", m.Raw, err)
+			}
+			if u.GetKind() != "HelmRelease" {
+				continue
+			}
+			set, _, _ := unstructured.NestedMap(u.Object, "spec", "values")
+			if len(values) == 0 && len(set) == 0 {
+				continue
+			}
+			// the decoded integers are int64, the merged ones float64
+			got, _ := json.Marshal(set)
+			want, _ := json.Marshal(values)
+			if string(got) != string(want) {
+				t.Fatalf("set values %s, want %s", got, want)
+			}
+		}
+	})
+}
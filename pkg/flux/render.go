@@ -0,0 +1,232 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package flux renders the Flux objects shipped to the managed clusters
+package flux
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/yaml"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+const (
+	// DefaultNamespace is the namespace of the Flux objects when not set
+	DefaultNamespace = "flux-system"
+	// DefaultInterval is the reconcile interval of the Flux objects when not set
+	DefaultInterval = 5 * time.Minute
+
+	sourceAPIVersion    = "source.toolkit.fluxcd.io/v1beta2"
+	kustomizeAPIVersion = "kustomize.toolkit.fluxcd.io/v1beta2"
+	helmAPIVersion      = "helm.toolkit.fluxcd.io/v2beta1"
+)
+
+// Render returns the GitRepository or HelmRepository and the Kustomization or
+// HelmRelease named name described by the source, with the Secret holding the
+// credentials of the Helm repository when given
+func Render(name string, src *appv1alpha1.FluxSource, credentials map[string][]byte) ([]workapiv1.Manifest, error) {
+	if (src.Kustomization == nil) == (src.HelmRelease == nil) {
+		return nil, fmt.Errorf("flux source must set exactly one of kustomization or helmRelease")
+	}
+	if (src.GitRepository == nil) == (src.HelmRepository == nil) {
+		return nil, fmt.Errorf("flux source must set exactly one of gitRepository or helmRepository")
+	}
+	if src.HelmRepository != nil && src.HelmRelease == nil {
+		return nil, fmt.Errorf("flux helmRepository requires a helmRelease")
+	}
+	namespace := src.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	interval := DefaultInterval.String()
+	if src.Interval != nil {
+		interval = src.Interval.Duration.String()
+	}
+
+	var objs []map[string]interface{}
+	var sourceRef map[string]interface{}
+	chartInterval := ""
+	if git := src.GitRepository; git != nil {
+		sourceRef = map[string]interface{}{"kind": "GitRepository", "name": name}
+		ref := map[string]interface{}{}
+		for key, value := range map[string]string{"branch": git.Branch, "tag": git.Tag, "semver": git.SemVer, "commit": git.Commit} {
+			if value != "" {
+				ref[key] = value
+			}
+		}
+		if len(ref) == 0 {
+			ref["branch"] = "master"
+		}
+		repoSpec := map[string]interface{}{"url": git.URL, "interval": interval, "ref": ref}
+		if git.SecretName != "" {
+			repoSpec["secretRef"] = map[string]interface{}{"name": git.SecretName}
+		}
+		objs = append(objs, object(sourceAPIVersion, "GitRepository", name, namespace, repoSpec))
+	}
+	if helm := src.HelmRepository; helm != nil {
+		sourceRef = map[string]interface{}{"kind": "HelmRepository", "name": name}
+		chartInterval = interval
+		if helm.Interval != nil {
+			chartInterval = helm.Interval.Duration.String()
+		}
+		repoSpec := map[string]interface{}{"url": helm.URL, "interval": chartInterval}
+		if strings.HasPrefix(helm.URL, "oci://") {
+			repoSpec["type"] = "oci"
+		}
+		if helm.CredentialsSecret != "" {
+			if credentials == nil {
+				return nil, fmt.Errorf("missing credentials of helm repository %s", helm.URL)
+			}
+			secretName := CredentialsSecretName(name)
+			repoSpec["secretRef"] = map[string]interface{}{"name": secretName}
+			objs = append(objs, secret(secretName, namespace, credentials))
+		}
+		objs = append(objs, object(sourceAPIVersion, "HelmRepository", name, namespace, repoSpec))
+	}
+	patches, err := renderPatches(src.Patches)
+	if err != nil {
+		return nil, err
+	}
+
+	if k := src.Kustomization; k != nil {
+		spec := map[string]interface{}{"interval": interval, "sourceRef": sourceRef, "prune": k.Prune}
+		if k.Path != "" {
+			spec["path"] = k.Path
+		}
+		if k.TargetNamespace != "" {
+			spec["targetNamespace"] = k.TargetNamespace
+		}
+		if len(patches) > 0 {
+			spec["patches"] = patches
+		}
+		objs = append(objs, object(kustomizeAPIVersion, "Kustomization", name, namespace, spec))
+	}
+
+	if h := src.HelmRelease; h != nil {
+		spec := map[string]interface{}{
+			"interval": interval,
+			"chart": map[string]interface{}{
+				"spec": map[string]interface{}{"chart": h.Chart, "sourceRef": sourceRef},
+			},
+		}
+		if h.Version != "" {
+			spec["chart"].(map[string]interface{})["spec"].(map[string]interface{})["version"] = h.Version
+		}
+		if chartInterval != "" {
+			spec["chart"].(map[string]interface{})["spec"].(map[string]interface{})["interval"] = chartInterval
+		}
+		if h.ReleaseName != "" {
+			spec["releaseName"] = h.ReleaseName
+		}
+		if h.TargetNamespace != "" {
+			spec["targetNamespace"] = h.TargetNamespace
+		}
+		if h.Values != nil && len(h.Values.Raw) > 0 {
+			values := map[string]interface{}{}
+			if err := json.Unmarshal(h.Values.Raw, &values); err != nil {
+				return nil, fmt.Errorf("invalid helm release values: %w", err)
+			}
+			spec["values"] = values
+		}
+		if len(patches) > 0 {
+			spec["postRenderers"] = []interface{}{
+				map[string]interface{}{"kustomize": map[string]interface{}{"patches": patches}},
+			}
+		}
+		objs = append(objs, object(helmAPIVersion, "HelmRelease", name, namespace, spec))
+	}
+
+	result := []workapiv1.Manifest{}
+	for _, obj := range objs {
+		m, err := manifests.FromUnstructured(&unstructured.Unstructured{Object: obj})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// renderPatches returns the Flux patches of the source
+func renderPatches(patches []appv1alpha1.FluxPatch) ([]interface{}, error) {
+	result := []interface{}{}
+	for i, p := range patches {
+		var ops []interface{}
+		if err := yaml.Unmarshal([]byte(p.Patch), &ops); err == nil && p.Target == nil {
+			return nil, fmt.Errorf("patch %d is a JSON 6902 patch without target", i)
+		}
+		patch := map[string]interface{}{"patch": p.Patch}
+		if t := p.Target; t != nil {
+			target := map[string]interface{}{}
+			for key, value := range map[string]string{
+				"group": t.Group, "version": t.Version, "kind": t.Kind, "name": t.Name, "namespace": t.Namespace,
+				"labelSelector": t.LabelSelector, "annotationSelector": t.AnnotationSelector,
+			} {
+				if value != "" {
+					target[key] = value
+				}
+			}
+			patch["target"] = target
+		}
+		result = append(result, patch)
+	}
+	return result, nil
+}
+
+// CredentialsSecretName returns the name of the Secret holding the credentials of the
+// Helm repository of the Flux objects named name
+func CredentialsSecretName(name string) string {
+	return name + "-helm-credentials"
+}
+
+// secret returns a Secret holding data, of the docker config type when data holds a
+// docker config for OCI registries
+func secret(name, namespace string, data map[string][]byte) map[string]interface{} {
+	encoded := map[string]interface{}{}
+	for k, v := range data {
+		encoded[k] = base64.StdEncoding.EncodeToString(v)
+	}
+	secretType := string(corev1.SecretTypeOpaque)
+	if _, ok := data[corev1.DockerConfigJsonKey]; ok {
+		secretType = string(corev1.SecretTypeDockerConfigJson)
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"type":       secretType,
+		"data":       encoded,
+	}
+}
+
+func object(apiVersion, kind, name, namespace string, spec map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"spec":       spec,
+	}
+}
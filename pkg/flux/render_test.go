@@ -0,0 +1,174 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flux
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+func TestRender(t *testing.T) {
+	src := &appv1alpha1.FluxSource{
+		GitRepository: &appv1alpha1.FluxGitRepository{URL: "https://github.com/example/apps", Tag: "v1.0.0"},
+		HelmRelease: &appv1alpha1.FluxHelmRelease{
+			Chart:  "./charts/web",
+			Values: &runtime.RawExtension{Raw: []byte(`{"replicas":2}`)},
+		},
+	}
+	result, err := Render("web", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(result))
+	}
+
+	repo, err := manifests.ToUnstructured(result[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.GetKind() != "GitRepository" || repo.GetNamespace() != DefaultNamespace {
+		t.Errorf("unexpected source %s %s", repo.GetKind(), repo.GetNamespace())
+	}
+	if tag, _, _ := unstructured.NestedString(repo.Object, "spec", "ref", "tag"); tag != "v1.0.0" {
+		t.Errorf("expected tag v1.0.0, got %q", tag)
+	}
+
+	release, err := manifests.ToUnstructured(result[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chart, _, _ := unstructured.NestedString(release.Object, "spec", "chart", "spec", "chart"); chart != "./charts/web" {
+		t.Errorf("expected chart ./charts/web, got %q", chart)
+	}
+	if replicas, _, _ := unstructured.NestedFieldNoCopy(release.Object, "spec", "values", "replicas"); replicas == nil {
+		t.Errorf("expected values to be set")
+	}
+
+	src.Kustomization = &appv1alpha1.FluxKustomization{}
+	if _, err := Render("web", src, nil); err == nil {
+		t.Error("expected an error with both kustomization and helmRelease")
+	}
+}
+
+func TestRenderPatches(t *testing.T) {
+	src := &appv1alpha1.FluxSource{
+		GitRepository: &appv1alpha1.FluxGitRepository{URL: "https://github.com/example/apps"},
+		HelmRelease:   &appv1alpha1.FluxHelmRelease{Chart: "./charts/web"},
+		Patches: []appv1alpha1.FluxPatch{
+			{
+				Patch:  "- op: remove\n  path: /spec/template/spec/volumes/0\n",
+				Target: &appv1alpha1.FluxPatchTarget{Kind: "DaemonSet", Name: "agent"},
+			},
+			{Patch: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 3\n"},
+		},
+	}
+	result, err := Render("web", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := manifests.ToUnstructured(result[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderers, _, _ := unstructured.NestedSlice(release.Object, "spec", "postRenderers")
+	if len(renderers) != 1 {
+		t.Fatalf("expected a post renderer, got %v", renderers)
+	}
+	patches, _, _ := unstructured.NestedSlice(renderers[0].(map[string]interface{}), "kustomize", "patches")
+	if len(patches) != 2 {
+		t.Fatalf("expected 2 patches, got %v", patches)
+	}
+	if kind, _, _ := unstructured.NestedString(patches[0].(map[string]interface{}), "target", "kind"); kind != "DaemonSet" {
+		t.Errorf("expected the DaemonSet target, got %q", kind)
+	}
+
+	src.HelmRelease, src.Kustomization = nil, &appv1alpha1.FluxKustomization{}
+	result, err = Render("web", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kustomization, err := manifests.ToUnstructured(result[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patches, _, _ := unstructured.NestedSlice(kustomization.Object, "spec", "patches"); len(patches) != 2 {
+		t.Errorf("expected 2 patches on the kustomization, got %v", patches)
+	}
+
+	src.Patches[0].Target = nil
+	if _, err := Render("web", src, nil); err == nil {
+		t.Error("expected a JSON 6902 patch without target to fail")
+	}
+}
+
+func TestRenderHelmRepository(t *testing.T) {
+	src := &appv1alpha1.FluxSource{
+		HelmRepository: &appv1alpha1.FluxHelmRepository{
+			URL:               "oci://ghcr.io/acme/charts",
+			Interval:          &metav1.Duration{Duration: time.Hour},
+			CredentialsSecret: "registry",
+		},
+		HelmRelease: &appv1alpha1.FluxHelmRelease{Chart: "web", Version: "1.x"},
+	}
+	if _, err := Render("web", src, nil); err == nil {
+		t.Error("expected missing credentials to fail")
+	}
+	result, err := Render("web", src, map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 3 {
+		t.Fatalf("expected 3 objects, got %d", len(result))
+	}
+	secret, err := manifests.ToUnstructured(result[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.GetName() != CredentialsSecretName("web") || secret.Object["type"] != string(corev1.SecretTypeDockerConfigJson) {
+		t.Errorf("unexpected credentials Secret %s of type %v", secret.GetName(), secret.Object["type"])
+	}
+	repo, err := manifests.ToUnstructured(result[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repoType, _, _ := unstructured.NestedString(repo.Object, "spec", "type"); repo.GetKind() != "HelmRepository" || repoType != "oci" {
+		t.Errorf("expected an OCI HelmRepository, got %s of type %q", repo.GetKind(), repoType)
+	}
+	release, err := manifests.ToUnstructured(result[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, _, _ := unstructured.NestedString(release.Object, "spec", "chart", "spec", "version")
+	interval, _, _ := unstructured.NestedString(release.Object, "spec", "chart", "spec", "interval")
+	if version != "1.x" || interval != "1h0m0s" {
+		t.Errorf("unexpected chart version %q and interval %q", version, interval)
+	}
+
+	src.GitRepository = &appv1alpha1.FluxGitRepository{URL: "https://github.com/example/apps"}
+	if _, err := Render("web", src, nil); err == nil {
+		t.Error("expected an error with both gitRepository and helmRepository")
+	}
+}
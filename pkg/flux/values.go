@@ -0,0 +1,112 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flux
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/yaml"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+const (
+	// ClusterSetLabel is the label of the managed clusters with their cluster set
+	ClusterSetLabel = "cluster.open-cluster-management.io/clusterset"
+	// ClusterValuesKey is the key of the values in the cluster values ConfigMaps
+	ClusterValuesKey = "values.yaml"
+)
+
+// HasClusterValues returns true if the values of the release depend on the cluster
+func HasClusterValues(h *appv1alpha1.FluxHelmRelease) bool {
+	return h != nil && (len(h.ClusterSetValues) > 0 || h.ClusterValuesConfigMap != "")
+}
+
+// ClusterValues returns the values of the release on a cluster, merging in order of
+// precedence the values of the release, those of the cluster set of the cluster and
+// its cluster values, a YAML document that may be empty
+func ClusterValues(h *appv1alpha1.FluxHelmRelease, clusterLabels map[string]string, clusterValues []byte) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if h.Values != nil && len(h.Values.Raw) > 0 {
+		if err := json.Unmarshal(h.Values.Raw, &values); err != nil {
+			return nil, fmt.Errorf("invalid helm release values: %w", err)
+		}
+	}
+	clusterSet := clusterLabels[ClusterSetLabel]
+	for _, v := range h.ClusterSetValues {
+		if v.ClusterSet != clusterSet || v.Values == nil || len(v.Values.Raw) == 0 {
+			continue
+		}
+		override := map[string]interface{}{}
+		if err := json.Unmarshal(v.Values.Raw, &override); err != nil {
+			return nil, fmt.Errorf("invalid helm release values of cluster set %s: %w", v.ClusterSet, err)
+		}
+		MergeValues(values, override)
+	}
+	if len(clusterValues) > 0 {
+		override := map[string]interface{}{}
+		if err := yaml.Unmarshal(clusterValues, &override); err != nil {
+			return nil, fmt.Errorf("invalid cluster values: %w", err)
+		}
+		MergeValues(values, override)
+	}
+	return values, nil
+}
+
+// MergeValues merges override into values as Helm does: maps are merged recursively,
+// other values replaced
+func MergeValues(values, override map[string]interface{}) {
+	for k, v := range override {
+		if src, ok := v.(map[string]interface{}); ok {
+			if dst, ok := values[k].(map[string]interface{}); ok {
+				MergeValues(dst, src)
+				continue
+			}
+		}
+		values[k] = v
+	}
+}
+
+// SetValues sets the values of the Helm releases of the manifests
+func SetValues(ms []workapiv1.Manifest, values map[string]interface{}) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := manifests.ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		if u.GetAPIVersion() != helmAPIVersion || u.GetKind() != "HelmRelease" {
+			result = append(result, m)
+			continue
+		}
+		if len(values) == 0 {
+			unstructured.RemoveNestedField(u.Object, "spec", "values")
+		} else if err := unstructured.SetNestedField(u.Object, values, "spec", "values"); err != nil {
+			return nil, err
+		}
+		updated, err := manifests.FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, updated)
+	}
+	return result, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flux
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+func TestClusterValues(t *testing.T) {
+	h := &appv1alpha1.FluxHelmRelease{
+		Chart:  "./charts/web",
+		Values: &runtime.RawExtension{Raw: []byte(`{"replicas":2,"image":{"repository":"web","tag":"v1"},"region":"none"}`)},
+		ClusterSetValues: []appv1alpha1.HelmClusterSetValues{
+			{ClusterSet: "prod", Values: &runtime.RawExtension{Raw: []byte(`{"replicas":5,"image":{"tag":"v1.1"}}`)}},
+			{ClusterSet: "dev", Values: &runtime.RawExtension{Raw: []byte(`{"replicas":1}`)}},
+		},
+		ClusterValuesConfigMap: "web-values",
+	}
+	values, err := ClusterValues(h, map[string]string{ClusterSetLabel: "prod"}, []byte("region: us-east-1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"replicas": float64(5),
+		"image":    map[string]interface{}{"repository": "web", "tag": "v1.1"},
+		"region":   "us-east-1",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+
+	values, err = ClusterValues(h, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["replicas"] != float64(2) || values["region"] != "none" {
+		t.Errorf("expected the release values outside of the cluster sets, got %v", values)
+	}
+
+	if _, err := ClusterValues(h, nil, []byte("- not a map")); err == nil {
+		t.Error("expected invalid cluster values to fail")
+	}
+}
+
+func TestSetValues(t *testing.T) {
+	src := &appv1alpha1.FluxSource{
+		GitRepository: &appv1alpha1.FluxGitRepository{URL: "https://github.com/example/apps"},
+		HelmRelease:   &appv1alpha1.FluxHelmRelease{Chart: "./charts/web"},
+	}
+	ms, err := Render("web", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := SetValues(ms, map[string]interface{}{"replicas": float64(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result[0], ms[0]) {
+		t.Error("expected the source to be unchanged")
+	}
+	release, err := manifests.ToUnstructured(result[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(release.Object, "spec", "values", "replicas"); replicas != 3 {
+		t.Errorf("expected 3 replicas, got %v", replicas)
+	}
+}
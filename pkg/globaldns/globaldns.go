@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package globaldns renders the ExternalDNS DNSEndpoint routing the traffic of a
+// global record to the clusters of a bundle
+package globaldns
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// GroupVersionKind of the ExternalDNS DNSEndpoint
+var GroupVersionKind = schema.GroupVersionKind{Group: "externaldns.k8s.io", Version: "v1alpha1", Kind: "DNSEndpoint"}
+
+// Name returns the name of the DNSEndpoint of a bundle
+func Name(bundle string) string {
+	return bundle + "-global-dns"
+}
+
+// Spec returns the spec of the DNSEndpoint publishing the targets behind the record
+func Spec(g *appv1alpha1.GlobalDNS, targets []string) map[string]interface{} {
+	recordType := g.RecordType
+	if recordType == "" {
+		recordType = "A"
+	}
+	values := []interface{}{}
+	for _, t := range sets.NewString(targets...).List() {
+		values = append(values, t)
+	}
+	endpoint := map[string]interface{}{
+		"dnsName":    g.Hostname,
+		"recordType": recordType,
+		"targets":    values,
+	}
+	if g.TTL > 0 {
+		endpoint["recordTTL"] = g.TTL
+	}
+	return map[string]interface{}{"endpoints": []interface{}{endpoint}}
+}
+
+// Endpoint returns a DNSEndpoint without spec
+func Endpoint(name, namespace string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(GroupVersionKind)
+	u.SetName(name)
+	u.SetNamespace(namespace)
+	return u
+}
+
+// Routable returns the clusters to route the traffic to: the reachable clusters whose
+// work is Available and applied. During a rollout updating all the clusters at once,
+// the Available clusters are kept rather than leaving the record without target.
+func Routable(clusters []appv1alpha1.ClusterStatus) []string {
+	available, applied := []string{}, []string{}
+	for _, c := range clusters {
+		if c.UnavailableSince != nil || !meta.IsStatusConditionTrue(c.Conditions, workapiv1.WorkAvailable) {
+			continue
+		}
+		available = append(available, c.ClusterName)
+		if meta.IsStatusConditionTrue(c.Conditions, workapiv1.WorkApplied) {
+			applied = append(applied, c.ClusterName)
+		}
+	}
+	if len(applied) == 0 {
+		applied = available
+	}
+	sort.Strings(applied)
+	return applied
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package globaldns
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func status(name string, available, applied bool) appv1alpha1.ClusterStatus {
+	condition := func(t string, ok bool) metav1.Condition {
+		s := metav1.ConditionFalse
+		if ok {
+			s = metav1.ConditionTrue
+		}
+		return metav1.Condition{Type: t, Status: s}
+	}
+	return appv1alpha1.ClusterStatus{ClusterName: name, Conditions: []metav1.Condition{
+		condition(workapiv1.WorkAvailable, available),
+		condition(workapiv1.WorkApplied, applied),
+	}}
+}
+
+func TestRoutable(t *testing.T) {
+	unreachable := status("c4", true, true)
+	unreachable.UnavailableSince = &metav1.Time{}
+	clusters := []appv1alpha1.ClusterStatus{
+		status("c3", true, true),
+		status("c1", true, true),
+		status("c2", true, false),
+		status("c5", false, true),
+		unreachable,
+	}
+	if got, want := Routable(clusters), []string{"c1", "c3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	rolling := []appv1alpha1.ClusterStatus{status("c1", true, false), status("c2", true, false)}
+	if got, want := Routable(rolling), []string{"c1", "c2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the Available clusters %v during a rollout, got %v", want, got)
+	}
+}
+
+func TestSpec(t *testing.T) {
+	g := &appv1alpha1.GlobalDNS{Hostname: "web.example.com", TTL: 60}
+	spec := Spec(g, []string{"10.0.0.2", "10.0.0.1", "10.0.0.2"})
+	endpoint := spec["endpoints"].([]interface{})[0].(map[string]interface{})
+	if endpoint["recordType"] != "A" || endpoint["recordTTL"] != int64(60) {
+		t.Errorf("unexpected endpoint %v", endpoint)
+	}
+	if got, want := endpoint["targets"], []interface{}{"10.0.0.1", "10.0.0.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected targets %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package guardrails checks rendered manifests against the guardrails configured in
+// KealmConfig
+package guardrails
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// Violation describes a manifest violating a guardrail
+type Violation struct {
+	Guardrail string
+	Resource  string
+	Message   string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s violates guardrail %s: %s", v.Resource, v.Guardrail, v.Message)
+}
+
+// Check returns the violations of the guardrails by the manifests
+func Check(guardrails []appv1alpha1.Guardrail, ms []workapiv1.Manifest) ([]Violation, error) {
+	violations := []Violation{}
+	if len(guardrails) == 0 {
+		return violations, nil
+	}
+	for _, m := range ms {
+		u, err := manifests.ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		id, err := manifests.Identity(m)
+		if err != nil {
+			return nil, err
+		}
+		kinds := manifests.KindNames(u.GroupVersionKind())
+		for _, g := range guardrails {
+			message := g.Message
+			switch {
+			case sets.NewString(g.DeniedKinds...).HasAny(kinds...):
+				if message == "" {
+					message = fmt.Sprintf("kind %s is not allowed", strings.Join(kinds, ", "))
+				}
+			case u.GetNamespace() != "" && sets.NewString(g.DeniedNamespaces...).Has(u.GetNamespace()):
+				if message == "" {
+					message = fmt.Sprintf("namespace %s is not allowed", u.GetNamespace())
+				}
+			default:
+				continue
+			}
+			violations = append(violations, Violation{Guardrail: g.Name, Resource: id, Message: message})
+		}
+	}
+	return violations, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package health provides readiness checks verifying that the dependencies of the
+// controller are functional
+package health
+
+import (
+	"fmt"
+	"net/http"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	workv1client "open-cluster-management.io/api/client/work/clientset/versioned"
+)
+
+// RequiredResources lists, by group version, the OCM resources the controller depends on
+var RequiredResources = map[string][]string{
+	"cluster.open-cluster-management.io/v1":       {"managedclusters"},
+	"cluster.open-cluster-management.io/v1alpha1": {"placements", "placementdecisions"},
+	"work.open-cluster-management.io/v1":          {"manifestworks"},
+}
+
+// ResourcesPresent checks that the required resources are served by the API server,
+// i.e. that their CRDs are installed
+func ResourcesPresent(dc discovery.DiscoveryInterface, required map[string][]string) healthz.Checker {
+	return func(_ *http.Request) error {
+		for gv, resources := range required {
+			list, err := dc.ServerResourcesForGroupVersion(gv)
+			if err != nil {
+				return fmt.Errorf("group version %s not available: %w", gv, err)
+			}
+			served := map[string]bool{}
+			for _, r := range list.APIResources {
+				served[r.Name] = true
+			}
+			for _, r := range resources {
+				if !served[r] {
+					return fmt.Errorf("resource %s not served in group version %s", r, gv)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// InformersSynced checks that the named informers have synced their caches
+func InformersSynced(informers map[string]cache.SharedIndexInformer) healthz.Checker {
+	return func(_ *http.Request) error {
+		for name, informer := range informers {
+			if !informer.HasSynced() {
+				return fmt.Errorf("informer for %s has not synced", name)
+			}
+		}
+		return nil
+	}
+}
+
+// WorkAPIReachable checks that ManifestWorks can be listed
+func WorkAPIReachable(client workv1client.Interface) healthz.Checker {
+	return func(req *http.Request) error {
+		_, err := client.WorkV1().ManifestWorks("").List(req.Context(), v1.ListOptions{Limit: 1})
+		if err != nil {
+			return fmt.Errorf("work API not reachable: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	workfake "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+)
+
+func TestResourcesPresent(t *testing.T) {
+	dc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*v1.APIResourceList{
+		{GroupVersion: "cluster.open-cluster-management.io/v1", APIResources: []v1.APIResource{{Name: "managedclusters"}}},
+		{GroupVersion: "cluster.open-cluster-management.io/v1alpha1", APIResources: []v1.APIResource{{Name: "placements"}}},
+	}}}
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	if err := ResourcesPresent(dc, map[string][]string{"cluster.open-cluster-management.io/v1": {"managedclusters"}})(req); err != nil {
+		t.Errorf("expected the served resources to be present, got %v", err)
+	}
+	for _, required := range []map[string][]string{
+		{"cluster.open-cluster-management.io/v1alpha1": {"placements", "placementdecisions"}},
+		{"work.open-cluster-management.io/v1": {"manifestworks"}},
+	} {
+		if err := ResourcesPresent(dc, required)(req); err == nil {
+			t.Errorf("expected %v to be missing", required)
+		}
+	}
+}
+
+func TestWorkAPIReachable(t *testing.T) {
+	client := workfake.NewSimpleClientset()
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	if err := WorkAPIReachable(client)(req); err != nil {
+		t.Errorf("expected the work API to be reachable, got %v", err)
+	}
+	client.PrependReactor("list", "manifestworks", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	if err := WorkAPIReachable(client)(req); err == nil {
+		t.Errorf("expected the work API not to be reachable")
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package history records the ManifestWork specs applied to each cluster, per
+// generation of the bundle, and explains what changed between two of them
+package history
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+const (
+	// Label marks the ConfigMaps holding the work history of a bundle on a cluster
+	Label = "cluster.open-cluster-management.io/work-history"
+	// ClusterAnnotation records the cluster of a work history ConfigMap
+	ClusterAnnotation = "cluster.open-cluster-management.io/cluster"
+
+	keyPrefix = "generation-"
+	// context is the number of unchanged lines around the changed ones in a diff
+	context = 2
+)
+
+// ConfigMapName returns the name of the work history ConfigMap of a bundle on a
+// cluster: <bundle>-<cluster>-work-history, the prefix being truncated and suffixed
+// with a hash when too long for a valid name
+func ConfigMapName(bundle, cluster string) string {
+	name := bundle + "-" + cluster + "-work-history"
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(bundle + "/" + cluster))
+	suffix := "-" + hex.EncodeToString(sum[:])[:10] + "-work-history"
+	return name[:validation.DNS1123SubdomainMaxLength-len(suffix)] + suffix
+}
+
+// Record stores the spec applied for the generation in the ConfigMap, compressed,
+// replacing the one recorded for the same generation, and keeps the latest
+// generations only
+func Record(cm *corev1.ConfigMap, generation int64, spec *workapiv1.ManifestWorkSpec, keep int) error {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if cm.BinaryData == nil {
+		cm.BinaryData = map[string][]byte{}
+	}
+	cm.BinaryData[key(generation)] = buf.Bytes()
+	if gens := Generations(cm); keep > 0 && len(gens) > keep {
+		for _, g := range gens[:len(gens)-keep] {
+			delete(cm.BinaryData, key(g))
+		}
+	}
+	return nil
+}
+
+// Generations returns the sorted generations recorded in the ConfigMap
+func Generations(cm *corev1.ConfigMap) []int64 {
+	gens := []int64{}
+	for k := range cm.BinaryData {
+		if !strings.HasPrefix(k, keyPrefix) {
+			continue
+		}
+		if g, err := strconv.ParseInt(strings.TrimPrefix(k, keyPrefix), 10, 64); err == nil {
+			gens = append(gens, g)
+		}
+	}
+	sort.Slice(gens, func(i, j int) bool { return gens[i] < gens[j] })
+	return gens
+}
+
+// Get returns the spec recorded for the generation in the ConfigMap
+func Get(cm *corev1.ConfigMap, generation int64) (*workapiv1.ManifestWorkSpec, error) {
+	data, ok := cm.BinaryData[key(generation)]
+	if !ok {
+		return nil, fmt.Errorf("generation %d is not recorded, recorded generations: %v", generation, Generations(cm))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	spec := &workapiv1.ManifestWorkSpec{}
+	if err := json.Unmarshal(raw, spec); err != nil {
+		return nil, fmt.Errorf("invalid spec of generation %d: %w", generation, err)
+	}
+	return spec, nil
+}
+
+// Diff returns the changes from the old spec to the new one: the manifests added and
+// removed, and the changed lines of the manifests and delete option changed, as YAML
+func Diff(old, new *workapiv1.ManifestWorkSpec) (string, error) {
+	oldDocs, err := documents(old)
+	if err != nil {
+		return "", err
+	}
+	newDocs, err := documents(new)
+	if err != nil {
+		return "", err
+	}
+	ids := []string{}
+	for id := range oldDocs {
+		ids = append(ids, id)
+	}
+	for id := range newDocs {
+		if _, ok := oldDocs[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	var out strings.Builder
+	for _, id := range ids {
+		o, inOld := oldDocs[id]
+		n, inNew := newDocs[id]
+		switch {
+		case !inOld:
+			fmt.Fprintf(&out, "added %s\n", id)
+		case !inNew:
+			fmt.Fprintf(&out, "removed %s\n", id)
+		case o != n:
+			fmt.Fprintf(&out, "changed %s\n", id)
+			out.WriteString(lineDiff(o, n))
+		}
+	}
+	return out.String(), nil
+}
+
+func key(generation int64) string {
+	return keyPrefix + strconv.FormatInt(generation, 10)
+}
+
+// documents returns the YAML of the manifests of the spec by identity, and of its
+// delete option
+func documents(spec *workapiv1.ManifestWorkSpec) (map[string]string, error) {
+	docs := map[string]string{}
+	for _, m := range spec.Workload.Manifests {
+		u, err := manifests.ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		id, err := manifests.Identity(m)
+		if err != nil {
+			return nil, err
+		}
+		data, err := yaml.Marshal(u.Object)
+		if err != nil {
+			return nil, err
+		}
+		docs[id] = string(data)
+	}
+	if spec.DeleteOption != nil {
+		data, err := yaml.Marshal(spec.DeleteOption)
+		if err != nil {
+			return nil, err
+		}
+		docs["deleteOption"] = string(data)
+	}
+	return docs, nil
+}
+
+// lineDiff returns the changed lines from old to new prefixed with - and +, with a few
+// unchanged lines of context prefixed with spaces
+func lineDiff(old, new string) string {
+	a, b := strings.Split(strings.TrimSuffix(old, "\n"), "\n"), strings.Split(strings.TrimSuffix(new, "\n"), "\n")
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	lines := []string{}
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	// keep the unchanged lines close to a changed one
+	var out strings.Builder
+	last := -1
+	for i, l := range lines {
+		keep := !strings.HasPrefix(l, "  ")
+		for d := -context; d <= context && !keep; d++ {
+			if k := i + d; k >= 0 && k < len(lines) && !strings.HasPrefix(lines[k], "  ") {
+				keep = true
+			}
+		}
+		if !keep {
+			continue
+		}
+		if last >= 0 && i > last+1 {
+			out.WriteString("    ...\n")
+		}
+		out.WriteString("  " + l + "\n")
+		last = i
+	}
+	return out.String()
+}
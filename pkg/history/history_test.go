@@ -0,0 +1,132 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+func spec(t *testing.T, doc string) *workapiv1.ManifestWorkSpec {
+	ms, err := manifests.ParseYAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &workapiv1.ManifestWorkSpec{Workload: workapiv1.ManifestsTemplate{Manifests: ms}}
+}
+
+const v1 = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - image: ghcr.io/acme/web:v1
+        name: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: default
+data:
+  mode: blue
+`
+
+const v2 = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - image: ghcr.io/acme/web:v2
+        name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+`
+
+func TestRecord(t *testing.T) {
+	cm := &corev1.ConfigMap{}
+	for gen := int64(1); gen <= 4; gen++ {
+		if err := Record(cm, gen, spec(t, v1), 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Record(cm, 4, spec(t, v2), 3); err != nil {
+		t.Fatal(err)
+	}
+	if gens := Generations(cm); !reflect.DeepEqual(gens, []int64{2, 3, 4}) {
+		t.Errorf("unexpected generations %v", gens)
+	}
+	got, err := Get(cm, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff, err := Diff(got, spec(t, v2)); err != nil || diff != "" {
+		t.Errorf("unexpected spec of generation 4: %v %s", err, diff)
+	}
+	if _, err := Get(cm, 1); err == nil || !strings.Contains(err.Error(), "[2 3 4]") {
+		t.Errorf("expected an error listing the recorded generations, got %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	diff, err := Diff(spec(t, v1), spec(t, v2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"changed apps/Deployment/default/web\n",
+		"  -       - image: ghcr.io/acme/web:v1\n  +       - image: ghcr.io/acme/web:v2\n",
+		"removed /ConfigMap/default/web-config\n",
+		"added /Service/default/web\n",
+	} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("expected %q in diff:\n%s", expected, diff)
+		}
+	}
+	if strings.Contains(diff, "apiVersion") {
+		t.Errorf("expected the unchanged lines far from the changes to be left out:\n%s", diff)
+	}
+}
+
+func TestConfigMapName(t *testing.T) {
+	if name := ConfigMapName("web", "cluster1"); name != "web-cluster1-work-history" {
+		t.Errorf("unexpected name %s", name)
+	}
+	if name := ConfigMapName(strings.Repeat("a", 200), strings.Repeat("b", 100)); len(name) > 253 || !strings.HasSuffix(name, "-work-history") {
+		t.Errorf("unexpected name %s", name)
+	}
+}
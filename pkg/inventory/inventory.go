@@ -0,0 +1,113 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inventory records the container images distributed by the bundles, so that
+// the clusters running an image can be found
+package inventory
+
+import (
+	"encoding/json"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+const (
+	// Label marks the ConfigMaps holding the image inventory of a bundle
+	Label = "cluster.open-cluster-management.io/image-inventory"
+	// Key is the key of the inventory in its ConfigMap
+	Key = "inventory.json"
+)
+
+// Inventory lists the images of a generation of a bundle and the clusters it is
+// distributed to
+type Inventory struct {
+	Bundle     string   `json:"bundle"`
+	Namespace  string   `json:"namespace"`
+	Generation int64    `json:"generation"`
+	Digest     string   `json:"digest,omitempty"`
+	Images     []Image  `json:"images"`
+	Clusters   []string `json:"clusters"`
+}
+
+// Image is an image of a bundle, with the reference of its SBOM when known
+type Image struct {
+	Image string `json:"image"`
+	SBOM  string `json:"sbom,omitempty"`
+}
+
+// ConfigMapName returns the name of the inventory ConfigMap of a bundle
+func ConfigMapName(bundle string) string {
+	return bundle + "-image-inventory"
+}
+
+// Images returns the sorted images of the workloads of the manifests, with the SBOM
+// references of their repository
+func Images(ms []workapiv1.Manifest, sboms []appv1alpha1.SBOMReference) ([]Image, error) {
+	refs := map[string]string{}
+	for _, s := range sboms {
+		refs[s.Image] = s.URL
+	}
+	seen := map[string]bool{}
+	images := []Image{}
+	_, err := manifests.MapImages(ms, func(image string) (string, error) {
+		if !seen[image] {
+			seen[image] = true
+			sbom, ok := refs[image]
+			if !ok {
+				repo, _ := manifests.SplitImage(image)
+				sbom = refs[repo]
+			}
+			images = append(images, Image{Image: image, SBOM: sbom})
+		}
+		return image, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Image < images[j].Image })
+	return images, nil
+}
+
+// FromConfigMap returns the inventory held by a ConfigMap
+func FromConfigMap(cm *corev1.ConfigMap) (*Inventory, error) {
+	inv := &Inventory{}
+	if err := json.Unmarshal([]byte(cm.Data[Key]), inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// Running returns the inventories with the image, matched by reference when it has a
+// tag or digest, otherwise by repository
+func Running(invs []Inventory, image string) []Inventory {
+	repo, tag := manifests.SplitImage(image)
+	result := []Inventory{}
+	for _, inv := range invs {
+		for _, i := range inv.Images {
+			r, _ := manifests.SplitImage(i.Image)
+			if i.Image == image || (tag == "" && r == repo) {
+				result = append(result, inv)
+				break
+			}
+		}
+	}
+	return result
+}
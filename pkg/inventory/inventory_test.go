@@ -0,0 +1,97 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"reflect"
+	"testing"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+func TestImages(t *testing.T) {
+	ms, err := manifests.ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: ghcr.io/acme/web:v1
+      containers:
+      - name: web
+        image: ghcr.io/acme/web:v1
+      - name: proxy
+        image: envoyproxy/envoy:v1.20.0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: backup
+            image: ghcr.io/acme/backup:v3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sboms := []appv1alpha1.SBOMReference{
+		{Image: "ghcr.io/acme/web", URL: "https://sbom.acme.io/web.spdx.json"},
+		{Image: "ghcr.io/acme/backup:v3", URL: "https://sbom.acme.io/backup-v3.spdx.json"},
+	}
+	images, err := Images(ms, sboms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Image{
+		{Image: "envoyproxy/envoy:v1.20.0"},
+		{Image: "ghcr.io/acme/backup:v3", SBOM: "https://sbom.acme.io/backup-v3.spdx.json"},
+		{Image: "ghcr.io/acme/web:v1", SBOM: "https://sbom.acme.io/web.spdx.json"},
+	}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("expected images %v, got %v", expected, images)
+	}
+}
+
+func TestRunning(t *testing.T) {
+	invs := []Inventory{
+		{Bundle: "web", Images: []Image{{Image: "ghcr.io/acme/web:v1"}}},
+		{Bundle: "web-canary", Images: []Image{{Image: "ghcr.io/acme/web:v2"}}},
+		{Bundle: "proxy", Images: []Image{{Image: "envoyproxy/envoy:v1.20.0"}}},
+	}
+	bundles := func(invs []Inventory) []string {
+		names := []string{}
+		for _, inv := range invs {
+			names = append(names, inv.Bundle)
+		}
+		return names
+	}
+	if got := bundles(Running(invs, "ghcr.io/acme/web")); !reflect.DeepEqual(got, []string{"web", "web-canary"}) {
+		t.Errorf("expected the bundles running the repository, got %v", got)
+	}
+	if got := bundles(Running(invs, "ghcr.io/acme/web:v2")); !reflect.DeepEqual(got, []string{"web-canary"}) {
+		t.Errorf("expected the bundles running the tag, got %v", got)
+	}
+}
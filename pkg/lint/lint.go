@@ -0,0 +1,199 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint checks the manifests of bundles for common problems, with the severity
+// of each rule configured in KealmConfig
+package lint
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+const (
+	// ResourceRequests finds the containers without cpu or memory requests
+	ResourceRequests = "resource-requests"
+	// LatestTag finds the images with the latest tag, or without tag nor digest
+	LatestTag = "latest-tag"
+	// HostPath finds the pods mounting hostPath volumes
+	HostPath = "host-path"
+	// DeprecatedAPI finds the resources of API versions deprecated and removed in later
+	// Kubernetes versions
+	DeprecatedAPI = "deprecated-api"
+)
+
+// deprecation is the replacement of a deprecated API version, empty when the kind has
+// none, and the Kubernetes minor version removing it
+type deprecation struct {
+	replacement string
+	removed     string
+}
+
+// deprecatedAPIs lists the deprecated API versions of the well-known kinds
+var deprecatedAPIs = map[schema.GroupVersionKind]deprecation{
+	{Group: "extensions", Version: "v1beta1", Kind: "Deployment"}:                                       {"apps/v1", "1.16"},
+	{Group: "extensions", Version: "v1beta1", Kind: "DaemonSet"}:                                        {"apps/v1", "1.16"},
+	{Group: "extensions", Version: "v1beta1", Kind: "ReplicaSet"}:                                       {"apps/v1", "1.16"},
+	{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}:                                          {"networking.k8s.io/v1", "1.22"},
+	{Group: "extensions", Version: "v1beta1", Kind: "NetworkPolicy"}:                                    {"networking.k8s.io/v1", "1.16"},
+	{Group: "extensions", Version: "v1beta1", Kind: "PodSecurityPolicy"}:                                {"policy/v1beta1", "1.16"},
+	{Group: "apps", Version: "v1beta1", Kind: "Deployment"}:                                             {"apps/v1", "1.16"},
+	{Group: "apps", Version: "v1beta1", Kind: "StatefulSet"}:                                            {"apps/v1", "1.16"},
+	{Group: "apps", Version: "v1beta2", Kind: "Deployment"}:                                             {"apps/v1", "1.16"},
+	{Group: "apps", Version: "v1beta2", Kind: "StatefulSet"}:                                            {"apps/v1", "1.16"},
+	{Group: "apps", Version: "v1beta2", Kind: "DaemonSet"}:                                              {"apps/v1", "1.16"},
+	{Group: "apps", Version: "v1beta2", Kind: "ReplicaSet"}:                                             {"apps/v1", "1.16"},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}:                                   {"networking.k8s.io/v1", "1.22"},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "IngressClass"}:                              {"networking.k8s.io/v1", "1.22"},
+	{Group: "batch", Version: "v1beta1", Kind: "CronJob"}:                                               {"batch/v1", "1.25"},
+	{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}:                                  {"policy/v1", "1.25"},
+	{Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy"}:                                    {"", "1.25"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "Role"}:                              {"rbac.authorization.k8s.io/v1", "1.22"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "RoleBinding"}:                       {"rbac.authorization.k8s.io/v1", "1.22"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRole"}:                       {"rbac.authorization.k8s.io/v1", "1.22"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRoleBinding"}:                {"rbac.authorization.k8s.io/v1", "1.22"},
+	{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}:               {"apiextensions.k8s.io/v1", "1.22"},
+	{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "MutatingWebhookConfiguration"}:   {"admissionregistration.k8s.io/v1", "1.22"},
+	{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration"}: {"admissionregistration.k8s.io/v1", "1.22"},
+	{Group: "apiregistration.k8s.io", Version: "v1beta1", Kind: "APIService"}:                           {"apiregistration.k8s.io/v1", "1.22"},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "StorageClass"}:                                 {"storage.k8s.io/v1", "1.22"},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIDriver"}:                                    {"storage.k8s.io/v1", "1.22"},
+	{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "PriorityClass"}:                             {"scheduling.k8s.io/v1", "1.22"},
+	{Group: "coordination.k8s.io", Version: "v1beta1", Kind: "Lease"}:                                   {"coordination.k8s.io/v1", "1.22"},
+	{Group: "discovery.k8s.io", Version: "v1beta1", Kind: "EndpointSlice"}:                              {"discovery.k8s.io/v1", "1.25"},
+	{Group: "autoscaling", Version: "v2beta1", Kind: "HorizontalPodAutoscaler"}:                         {"autoscaling/v2", "1.25"},
+	{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"}:                         {"autoscaling/v2", "1.26"},
+}
+
+// Finding is a problem of a manifest found by a rule
+type Finding struct {
+	Rule     string
+	Severity appv1alpha1.LintSeverity
+	Resource string
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s (%s)", f.Resource, f.Message, f.Rule)
+}
+
+// Lint returns the findings of the rules in the manifests. The rules not configured
+// warn, the disabled ones are skipped.
+func Lint(ms []workapiv1.Manifest, rules []appv1alpha1.LintRule) ([]Finding, error) {
+	severities := map[string]appv1alpha1.LintSeverity{}
+	for _, r := range rules {
+		severities[r.Name] = r.Severity
+	}
+	findings := []Finding{}
+	report := func(rule, resource, format string, args ...interface{}) {
+		severity, ok := severities[rule]
+		if !ok {
+			severity = appv1alpha1.LintWarn
+		}
+		if severity == appv1alpha1.LintOff {
+			return
+		}
+		findings = append(findings, Finding{Rule: rule, Severity: severity, Resource: resource, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, m := range ms {
+		u, err := manifests.ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		id, err := manifests.Identity(m)
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := deprecatedAPIs[u.GroupVersionKind()]; ok {
+			report(DeprecatedAPI, id, "%s", d.message(u))
+		}
+		for _, c := range manifests.Containers(u) {
+			name, _ := c["name"].(string)
+			for _, resource := range []string{"cpu", "memory"} {
+				if _, found, _ := unstructured.NestedFieldNoCopy(c, "resources", "requests", resource); !found {
+					report(ResourceRequests, id, "container %s has no %s request", name, resource)
+				}
+			}
+			image, _ := c["image"].(string)
+			if _, tag := manifests.SplitImage(image); tag == "" || tag == "latest" {
+				report(LatestTag, id, "container %s uses the latest tag of image %s", name, image)
+			}
+		}
+		for _, v := range manifests.Volumes(u) {
+			if _, ok := v["hostPath"]; ok {
+				report(HostPath, id, "volume %v mounts a host path", v["name"])
+			}
+		}
+	}
+	return findings, nil
+}
+
+// Split splits the findings into the denied and the warned ones
+func Split(findings []Finding) (denied, warned []Finding) {
+	for _, f := range findings {
+		if f.Severity == appv1alpha1.LintDeny {
+			denied = append(denied, f)
+		} else {
+			warned = append(warned, f)
+		}
+	}
+	return denied, warned
+}
+
+// message describes the deprecation of the API version of the resource
+func (d deprecation) message(u *unstructured.Unstructured) string {
+	message := fmt.Sprintf("%s %s is removed in Kubernetes %s", u.GetAPIVersion(), u.GetKind(), d.removed)
+	if d.replacement != "" {
+		message += ", use " + d.replacement
+	}
+	return message
+}
+
+// Removed splits the manifests into the ones whose API version is served by the
+// Kubernetes version, e.g. v1.25.3 as reported by a managed cluster, and the
+// descriptions of the ones whose API version is removed on it. All the manifests are
+// served when the version is unknown.
+func Removed(ms []workapiv1.Manifest, kubernetesVersion string) ([]workapiv1.Manifest, []string, error) {
+	v, err := version.ParseGeneric(kubernetesVersion)
+	if err != nil {
+		return ms, nil, nil
+	}
+	served, removed := []workapiv1.Manifest{}, []string{}
+	for _, m := range ms {
+		u, err := manifests.ToUnstructured(m)
+		if err != nil {
+			return nil, nil, err
+		}
+		if d, ok := deprecatedAPIs[u.GroupVersionKind()]; ok && v.AtLeast(version.MustParseGeneric(d.removed)) {
+			id, err := manifests.Identity(m)
+			if err != nil {
+				return nil, nil, err
+			}
+			removed = append(removed, id+": "+d.message(u))
+			continue
+		}
+		served = append(served, m)
+	}
+	return served, removed, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"testing"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+func TestLint(t *testing.T) {
+	ms, err := manifests.ParseYAML([]byte(`apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        resources:
+          requests:
+            cpu: 100m
+      volumes:
+      - name: logs
+        hostPath:
+          path: /var/log
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  template:
+    spec:
+      containers:
+      - name: api
+        image: registry.example.com:5000/api@sha256:0123
+        resources:
+          requests:
+            cpu: 100m
+            memory: 64Mi
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := Lint(ms, []appv1alpha1.LintRule{
+		{Name: HostPath, Severity: appv1alpha1.LintDeny},
+		{Name: ResourceRequests, Severity: appv1alpha1.LintOff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := map[string]appv1alpha1.LintSeverity{}
+	for _, f := range findings {
+		if f.Resource != "extensions/Deployment/shop/web" {
+			t.Errorf("unexpected finding %s", f)
+		}
+		rules[f.Rule] = f.Severity
+	}
+	want := map[string]appv1alpha1.LintSeverity{
+		DeprecatedAPI: appv1alpha1.LintWarn,
+		LatestTag:     appv1alpha1.LintWarn,
+		HostPath:      appv1alpha1.LintDeny,
+	}
+	if len(rules) != len(want) || len(findings) != len(want) {
+		t.Fatalf("got findings %v, want rules %v", findings, want)
+	}
+	for rule, severity := range want {
+		if rules[rule] != severity {
+			t.Errorf("rule %s: got severity %q, want %q", rule, rules[rule], severity)
+		}
+	}
+	denied, warned := Split(findings)
+	if len(denied) != 1 || len(warned) != 2 {
+		t.Errorf("got %d denied and %d warned findings", len(denied), len(warned))
+	}
+}
+
+func TestRemoved(t *testing.T) {
+	ms, err := manifests.ParseYAML([]byte(`apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: api
+  namespace: shop
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		version string
+		removed int
+	}{
+		{version: "v1.24.9", removed: 0},
+		{version: "v1.25.3+k3s1", removed: 1},
+		{version: "v1.27.1", removed: 1},
+		{version: "", removed: 0},
+	}
+	for _, tt := range tests {
+		served, removed, err := Removed(ms, tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(removed) != tt.removed || len(served) != len(ms)-tt.removed {
+			t.Errorf("version %q: got %d served and removed %v", tt.version, len(served), removed)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// SupportsArchitecture returns true if the architectures support the architecture of a
+// cluster. Unknown architectures are supported, as are all when none is listed.
+func SupportsArchitecture(a *appv1alpha1.Architectures, arch string) bool {
+	if a == nil || len(a.Supported) == 0 || arch == "" {
+		return true
+	}
+	for _, s := range a.Supported {
+		if string(s) == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchitectureImages replaces the images of the manifests by their variants for the
+// architecture. Variants without tag or digest keep the one of the manifests.
+func ArchitectureImages(ms []workapiv1.Manifest, images []appv1alpha1.ArchitectureImage, arch string) ([]workapiv1.Manifest, error) {
+	variants := map[string]string{}
+	for _, i := range images {
+		if v, ok := i.Variants[appv1alpha1.Architecture(arch)]; ok {
+			variants[i.Image] = v
+		}
+	}
+	if len(variants) == 0 {
+		return ms, nil
+	}
+	return MapImages(ms, func(image string) (string, error) {
+		repository, tag := SplitImage(image)
+		variant, ok := variants[repository]
+		if !ok {
+			return image, nil
+		}
+		if _, variantTag := SplitImage(variant); variantTag != "" || tag == "" {
+			return variant, nil
+		}
+		return JoinImage(variant, tag), nil
+	})
+}
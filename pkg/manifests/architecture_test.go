@@ -0,0 +1,86 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestSupportsArchitecture(t *testing.T) {
+	a := &appv1alpha1.Architectures{Supported: []appv1alpha1.Architecture{"amd64", "arm64"}}
+	tests := []struct {
+		a        *appv1alpha1.Architectures
+		arch     string
+		expected bool
+	}{
+		{a: nil, arch: "s390x", expected: true},
+		{a: &appv1alpha1.Architectures{}, arch: "s390x", expected: true},
+		{a: a, arch: "arm64", expected: true},
+		{a: a, arch: "s390x", expected: false},
+		{a: a, arch: "", expected: true},
+	}
+	for _, tt := range tests {
+		if supported := SupportsArchitecture(tt.a, tt.arch); supported != tt.expected {
+			t.Errorf("SupportsArchitecture(%v, %q) = %t, expected %t", tt.a, tt.arch, supported, tt.expected)
+		}
+	}
+}
+
+func TestArchitectureImages(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: quay.io/acme/web:v2
+      - name: proxy
+        image: quay.io/acme/proxy@sha256:1234
+      - name: sidecar
+        image: quay.io/acme/sidecar:v1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := []appv1alpha1.ArchitectureImage{
+		{Image: "quay.io/acme/web", Variants: map[appv1alpha1.Architecture]string{"arm64": "quay.io/acme/web-arm64"}},
+		{Image: "quay.io/acme/proxy", Variants: map[appv1alpha1.Architecture]string{"arm64": "quay.io/acme/proxy-arm64:v1"}},
+		{Image: "quay.io/acme/sidecar", Variants: map[appv1alpha1.Architecture]string{"s390x": "quay.io/acme/sidecar-s390x"}},
+	}
+	mapped, err := ArchitectureImages(ms, images, "arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := ToUnstructured(mapped[0])
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+	expected := []string{"quay.io/acme/web-arm64:v2", "quay.io/acme/proxy-arm64:v1", "quay.io/acme/sidecar:v1"}
+	for i, c := range containers {
+		if image := c.(map[string]interface{})["image"]; image != expected[i] {
+			t.Errorf("expected image %s, got %v", expected[i], image)
+		}
+	}
+	if same, _ := ArchitectureImages(ms, images, "amd64"); &same[0] != &ms[0] {
+		t.Error("expected the manifests to be unchanged without variant")
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// Autoscaled returns the names of the Deployments and StatefulSets targeted by the
+// HorizontalPodAutoscalers of the manifests, together with the external names of the
+// workloads autoscaled by HorizontalPodAutoscalers defined outside the manifests
+func Autoscaled(ms []workapiv1.Manifest, external []string) (sets.String, error) {
+	result := sets.NewString(external...)
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		gvk := u.GroupVersionKind()
+		if gvk.Group != "autoscaling" || gvk.Kind != "HorizontalPodAutoscaler" {
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(u.Object, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(u.Object, "spec", "scaleTargetRef", "name")
+		if (kind == "Deployment" || kind == "StatefulSet") && name != "" {
+			result.Insert(name)
+		}
+	}
+	return result, nil
+}
+
+// ReleaseReplicas removes the replicas of the autoscaled Deployments and StatefulSets,
+// so that the distributed manifests do not reset the replicas set by the autoscalers
+func ReleaseReplicas(ms []workapiv1.Manifest, autoscaled sets.String) ([]workapiv1.Manifest, error) {
+	if autoscaled.Len() == 0 {
+		return ms, nil
+	}
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		if _, found, _ := unstructured.NestedFieldNoCopy(u.Object, "spec", "replicas"); !found || !isScalable(u) || !autoscaled.Has(u.GetName()) {
+			result = append(result, m)
+			continue
+		}
+		unstructured.RemoveNestedField(u.Object, "spec", "replicas")
+		if m, err = FromUnstructured(u); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
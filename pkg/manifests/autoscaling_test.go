@@ -0,0 +1,82 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestReleaseReplicas(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  replicas: 3
+---
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: web
+  maxReplicas: 10
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	autoscaled, err := Autoscaled(ms, []string{"db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !autoscaled.Equal(sets.NewString("web", "db")) {
+		t.Errorf("expected web and db to be autoscaled, got %v", autoscaled.List())
+	}
+	result, err := ReleaseReplicas(ms, autoscaled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []bool{false, true, false} {
+		u, err := ToUnstructured(result[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, found, _ := unstructured.NestedInt64(u.Object, "spec", "replicas"); found != expected {
+			t.Errorf("expected replicas of %s set %v, got %v", u.GetName(), expected, found)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+const (
+	// ColorAnnotation records the color of the manifests of a blue/green bundle
+	ColorAnnotation = "cluster.open-cluster-management.io/color"
+	// SwitchAnnotation marks the switch manifest of a blue/green bundle with the color
+	// it routes to
+	SwitchAnnotation = "cluster.open-cluster-management.io/color-switch"
+)
+
+// SwitchContext is the context of the templates of a switch manifest
+type SwitchContext struct {
+	// Color is the color the switch routes to
+	Color string
+	// Inactive is the other color
+	Inactive string
+	// Weight is the percentage of the traffic shifted to the inactive color
+	Weight int32
+}
+
+// Colorize returns the manifests of a color of a blue/green bundle, instantiated with
+// the color as suffix and annotated with it. The namespaced manifests must be in the
+// namespaces the manifests define, as they would otherwise be shared by both colors.
+func Colorize(ms []workapiv1.Manifest, color string) ([]workapiv1.Manifest, error) {
+	namespaces := sets.NewString()
+	objs := []*unstructured.Unstructured{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		if gk := u.GroupVersionKind().GroupKind(); gk.Group == "" && gk.Kind == "Namespace" {
+			namespaces.Insert(u.GetName())
+		}
+		objs = append(objs, u)
+	}
+	shared := []string{}
+	for _, u := range objs {
+		if !IsClusterScoped(u.GroupVersionKind().GroupKind()) && !namespaces.Has(u.GetNamespace()) {
+			shared = append(shared, fmt.Sprintf("%s %s/%s", u.GetKind(), u.GetNamespace(), u.GetName()))
+		}
+	}
+	if len(shared) > 0 {
+		return nil, fmt.Errorf("manifests outside the namespaces of the bundle would be shared by both colors: %s",
+			strings.Join(shared, ", "))
+	}
+	instantiated, err := Instantiate(ms, color)
+	if err != nil {
+		return nil, err
+	}
+	return annotate(instantiated, ColorAnnotation, color)
+}
+
+// Colored returns the manifests of the color
+func Colored(ms []workapiv1.Manifest, color string) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		c, err := ColorOf(m)
+		if err != nil {
+			return nil, err
+		}
+		if c == color {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// ColorOf returns the color of a manifest, empty if it has none
+func ColorOf(m workapiv1.Manifest) (string, error) {
+	u, err := ToUnstructured(m)
+	if err != nil {
+		return "", err
+	}
+	return u.GetAnnotations()[ColorAnnotation], nil
+}
+
+// SwitchOf returns the color the switch manifest among the manifests routes to, empty
+// if there is none
+func SwitchOf(ms []workapiv1.Manifest) (string, error) {
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return "", err
+		}
+		if color, ok := u.GetAnnotations()[SwitchAnnotation]; ok {
+			return color, nil
+		}
+	}
+	return "", nil
+}
+
+// RenderSwitch executes the templates in the string values of the switch manifest with
+// the color it routes to, and annotates it with the color
+func RenderSwitch(m workapiv1.Manifest, c SwitchContext) (workapiv1.Manifest, error) {
+	u, err := ToUnstructured(m)
+	if err != nil {
+		return workapiv1.Manifest{}, err
+	}
+	obj, err := substitute(u.Object, c)
+	if err != nil {
+		return workapiv1.Manifest{}, fmt.Errorf("failed to render switch %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+	u.Object = obj.(map[string]interface{})
+	rendered, err := FromUnstructured(u)
+	if err != nil {
+		return workapiv1.Manifest{}, err
+	}
+	annotated, err := annotate([]workapiv1.Manifest{rendered}, SwitchAnnotation, c.Color)
+	if err != nil {
+		return workapiv1.Manifest{}, err
+	}
+	return annotated[0], nil
+}
+
+// MeshSwitch returns the Istio VirtualService routing the traffic to the Service of the
+// color, with the weight of the traffic shifted to the inactive color, annotated as
+// the switch
+func MeshSwitch(m *appv1alpha1.MeshTraffic, c SwitchContext) (workapiv1.Manifest, error) {
+	parts := strings.SplitN(m.Service, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return workapiv1.Manifest{}, fmt.Errorf("the mesh service %q must be namespace/name", m.Service)
+	}
+	destination := func(color string, weight int32) interface{} {
+		d := map[string]interface{}{"host": fmt.Sprintf("%s.%s-%s.svc.cluster.local", parts[1], parts[0], color)}
+		if m.Port != 0 {
+			d["port"] = map[string]interface{}{"number": int64(m.Port)}
+		}
+		return map[string]interface{}{"destination": d, "weight": int64(weight)}
+	}
+	route := []interface{}{destination(c.Color, 100-c.Weight)}
+	if c.Weight > 0 {
+		route = append(route, destination(c.Inactive, c.Weight))
+	}
+	hosts := []interface{}{}
+	for _, h := range m.Hosts {
+		hosts = append(hosts, h)
+	}
+	spec := map[string]interface{}{
+		"hosts": hosts,
+		"http":  []interface{}{map[string]interface{}{"route": route}},
+	}
+	if len(m.Gateways) > 0 {
+		gateways := []interface{}{}
+		for _, g := range m.Gateways {
+			gateways = append(gateways, g)
+		}
+		spec["gateways"] = gateways
+	}
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "VirtualService",
+		"metadata": map[string]interface{}{
+			"name":        parts[1],
+			"namespace":   m.Namespace,
+			"annotations": map[string]interface{}{SwitchAnnotation: c.Color},
+		},
+		"spec": spec,
+	}}
+	return FromUnstructured(u)
+}
+
+// annotate sets the annotation on the manifests
+func annotate(ms []workapiv1.Manifest, key, value string) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		annotations := u.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[key] = value
+		u.SetAnnotations(annotations)
+		annotated, err := FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, annotated)
+	}
+	return result, nil
+}
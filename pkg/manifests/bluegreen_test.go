@@ -0,0 +1,148 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestColorize(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: web
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blue, err := Colorize(ms, "blue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := ToUnstructured(blue[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetNamespace() != "web-blue" || u.GetAnnotations()[ColorAnnotation] != "blue" {
+		t.Errorf("unexpected colorized manifest %v", u.Object)
+	}
+	green, err := Colorize(ms, "green")
+	if err != nil {
+		t.Fatal(err)
+	}
+	colored, err := Colored(append(blue, green...), "green")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(colored) != 2 {
+		t.Errorf("expected the 2 green manifests, got %d", len(colored))
+	}
+
+	shared, err := ParseYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Colorize(shared, "blue"); err == nil || !strings.Contains(err.Error(), "ConfigMap default/settings") {
+		t.Errorf("expected the shared manifests to be rejected, got %v", err)
+	}
+}
+
+func TestRenderSwitch(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+spec:
+  type: ExternalName
+  externalName: web.web-{{ .Color }}.svc.cluster.local
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := RenderSwitch(ms[0], SwitchContext{Color: "green", Inactive: "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := ToUnstructured(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _, _ := unstructured.NestedString(u.Object, "spec", "externalName"); name != "web.web-green.svc.cluster.local" {
+		t.Errorf("unexpected external name %s", name)
+	}
+	if color, err := SwitchOf(append(ms, m)); err != nil || color != "green" {
+		t.Errorf("expected the switch to route to green, got %q %v", color, err)
+	}
+}
+
+func TestMeshSwitch(t *testing.T) {
+	mesh := &appv1alpha1.MeshTraffic{Service: "web/frontend", Port: 8080, Namespace: "gateways", Hosts: []string{"web.example.com"}}
+	m, err := MeshSwitch(mesh, SwitchContext{Color: "blue", Inactive: "green", Weight: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := ToUnstructured(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetNamespace() != "gateways" || u.GetAnnotations()[SwitchAnnotation] != "blue" {
+		t.Errorf("unexpected virtual service %v", u.Object)
+	}
+	http, _, _ := unstructured.NestedSlice(u.Object, "spec", "http")
+	route := http[0].(map[string]interface{})["route"].([]interface{})
+	if len(route) != 2 {
+		t.Fatalf("expected routes to both colors, got %v", route)
+	}
+	preview := route[1].(map[string]interface{})
+	host, _, _ := unstructured.NestedString(preview, "destination", "host")
+	if host != "frontend.web-green.svc.cluster.local" || preview["weight"] != int64(10) {
+		t.Errorf("unexpected preview route %v", preview)
+	}
+
+	m, err = MeshSwitch(mesh, SwitchContext{Color: "green", Inactive: "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, err = ToUnstructured(m); err != nil {
+		t.Fatal(err)
+	}
+	http, _, _ = unstructured.NestedSlice(u.Object, "spec", "http")
+	if route := http[0].(map[string]interface{})["route"].([]interface{}); len(route) != 1 {
+		t.Errorf("expected a single route without shifted traffic, got %v", route)
+	}
+
+	if _, err := MeshSwitch(&appv1alpha1.MeshTraffic{Service: "frontend"}, SwitchContext{Color: "blue"}); err == nil {
+		t.Error("expected an error for a service without namespace")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// CertificateAnnotation records the certificate of a bundle a Certificate manifest is
+// rendered for
+const CertificateAnnotation = "cluster.open-cluster-management.io/certificate"
+
+// Certificates returns the cert-manager Certificates of the bundle for the cluster,
+// with their DNS names executed as templates with the cluster context. Certificates
+// without namespace are in the default namespace.
+func Certificates(certs []appv1alpha1.BundleCertificate, defaultNamespace string, c ClusterContext) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, cert := range certs {
+		namespace := cert.Namespace
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+		if namespace == "" {
+			return nil, fmt.Errorf("certificate %s has no namespace", cert.Name)
+		}
+		dnsNames := []interface{}{}
+		for _, name := range cert.DNSNames {
+			rendered, err := substitute(name, c)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render the DNS names of certificate %s: %w", cert.Name, err)
+			}
+			dnsNames = append(dnsNames, rendered)
+		}
+		secretName := cert.SecretName
+		if secretName == "" {
+			secretName = cert.Name
+		}
+		issuer := map[string]interface{}{"name": cert.IssuerRef.Name, "kind": "Issuer", "group": "cert-manager.io"}
+		if cert.IssuerRef.Kind != "" {
+			issuer["kind"] = cert.IssuerRef.Kind
+		}
+		if cert.IssuerRef.Group != "" {
+			issuer["group"] = cert.IssuerRef.Group
+		}
+		spec := map[string]interface{}{
+			"secretName": secretName,
+			"dnsNames":   dnsNames,
+			"issuerRef":  issuer,
+		}
+		if cert.Duration != nil {
+			spec["duration"] = cert.Duration.Duration.String()
+		}
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata": map[string]interface{}{
+				"name":        cert.Name,
+				"namespace":   namespace,
+				"annotations": map[string]interface{}{CertificateAnnotation: cert.Name},
+			},
+			"spec": spec,
+		}}
+		m, err := FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// CertificateOf returns the certificate of the bundle a manifest is rendered for, empty
+// if it is none
+func CertificateOf(m workapiv1.Manifest) (string, error) {
+	u, err := ToUnstructured(m)
+	if err != nil {
+		return "", err
+	}
+	return u.GetAnnotations()[CertificateAnnotation], nil
+}
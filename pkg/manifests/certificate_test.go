@@ -0,0 +1,61 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestCertificates(t *testing.T) {
+	certs := []appv1alpha1.BundleCertificate{{
+		Name:      "web-tls",
+		DNSNames:  []string{"web.{{ .Name }}.example.com", "{{ .Region }}.example.com"},
+		IssuerRef: appv1alpha1.CertificateIssuerReference{Name: "letsencrypt", Kind: "ClusterIssuer"},
+	}}
+	ms, err := Certificates(certs, "web", ClusterContext{Name: "cluster1", Region: "eu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := ToUnstructured(ms[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetNamespace() != "web" || u.GetKind() != "Certificate" {
+		t.Errorf("unexpected certificate %v", u.Object)
+	}
+	names, _, _ := unstructured.NestedStringSlice(u.Object, "spec", "dnsNames")
+	if want := []string{"web.cluster1.example.com", "eu.example.com"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected DNS names %v, got %v", want, names)
+	}
+	secret, _, _ := unstructured.NestedString(u.Object, "spec", "secretName")
+	kind, _, _ := unstructured.NestedString(u.Object, "spec", "issuerRef", "kind")
+	if secret != "web-tls" || kind != "ClusterIssuer" {
+		t.Errorf("unexpected secret %s or issuer kind %s", secret, kind)
+	}
+	if name, err := CertificateOf(ms[0]); err != nil || name != "web-tls" {
+		t.Errorf("unexpected certificate %q: %v", name, err)
+	}
+
+	if _, err := Certificates(certs, "", ClusterContext{Name: "cluster1", Region: "eu"}); err == nil {
+		t.Error("expected an error for a certificate without namespace")
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// ConfigChecksumAnnotation is set on the pod templates of workloads consuming
+// ConfigMaps or Secrets shipped in the same bundle, so that workloads are rolled
+// when their configuration changes
+const ConfigChecksumAnnotation = "cluster.open-cluster-management.io/config-checksum"
+
+// podTemplatePaths lists where the pod template lives for the supported workload kinds
+var podTemplatePaths = map[string][]string{
+	"Deployment":  {"spec", "template"},
+	"StatefulSet": {"spec", "template"},
+	"DaemonSet":   {"spec", "template"},
+	"ReplicaSet":  {"spec", "template"},
+	"Job":         {"spec", "template"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template"},
+}
+
+// ToUnstructured converts a manifest, either raw or typed, to an unstructured object
+func ToUnstructured(m workapiv1.Manifest) (*unstructured.Unstructured, error) {
+	if m.Object != nil {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(m.Object)
+		if err != nil {
+			return nil, err
+		}
+		return &unstructured.Unstructured{Object: obj}, nil
+	}
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(m.Raw); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return u, nil
+}
+
+// FromUnstructured converts an unstructured object back to a raw manifest
+func FromUnstructured(u *unstructured.Unstructured) (workapiv1.Manifest, error) {
+	raw, err := u.MarshalJSON()
+	if err != nil {
+		return workapiv1.Manifest{}, err
+	}
+	return workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: raw}}, nil
+}
+
+// InjectConfigChecksums annotates the pod templates of the workloads referencing
+// ConfigMaps or Secrets included in the manifests with a checksum of their content,
+// mirroring the helm checksum/config pattern
+func InjectConfigChecksums(manifests []workapiv1.Manifest) ([]workapiv1.Manifest, error) {
+	objs := make([]*unstructured.Unstructured, len(manifests))
+	checksums := map[string]string{}
+	for i, m := range manifests {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		objs[i] = u
+		if u.GetAPIVersion() != "v1" || (u.GetKind() != "ConfigMap" && u.GetKind() != "Secret") {
+			continue
+		}
+		sum, err := configChecksum(u)
+		if err != nil {
+			return nil, err
+		}
+		checksums[configKey(u.GetKind(), u.GetNamespace(), u.GetName())] = sum
+	}
+	if len(checksums) == 0 {
+		return manifests, nil
+	}
+
+	result := make([]workapiv1.Manifest, len(manifests))
+	copy(result, manifests)
+	for i, u := range objs {
+		path, ok := podTemplatePaths[u.GetKind()]
+		if !ok {
+			continue
+		}
+		template, found, err := unstructured.NestedMap(u.Object, path...)
+		if err != nil || !found {
+			continue
+		}
+		podSpec := corev1.PodSpec{}
+		if spec, ok := template["spec"].(map[string]interface{}); ok {
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &podSpec); err != nil {
+				return nil, fmt.Errorf("invalid pod template in %s %s: %w", u.GetKind(), u.GetName(), err)
+			}
+		}
+		refs := []string{}
+		for _, ref := range podSpecConfigRefs(&podSpec) {
+			if sum, ok := checksums[configKey(ref.kind, u.GetNamespace(), ref.name)]; ok {
+				refs = append(refs, ref.kind+"/"+ref.name+"="+sum)
+			}
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		sort.Strings(refs)
+		sum := sha256.Sum256([]byte(strings.Join(refs, "\n")))
+		annotationsPath := append(append([]string{}, path...), "metadata", "annotations")
+		if err := unstructured.SetNestedField(u.Object, hex.EncodeToString(sum[:]), append(annotationsPath, ConfigChecksumAnnotation)...); err != nil {
+			return nil, err
+		}
+		if result[i], err = FromUnstructured(u); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+type configRef struct {
+	kind string
+	name string
+}
+
+// podSpecConfigRefs returns the ConfigMaps and Secrets consumed by a pod spec
+func podSpecConfigRefs(spec *corev1.PodSpec) []configRef {
+	refs := map[configRef]bool{}
+	add := func(kind, name string) {
+		if name != "" {
+			refs[configRef{kind: kind, name: name}] = true
+		}
+	}
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			add("ConfigMap", v.ConfigMap.Name)
+		}
+		if v.Secret != nil {
+			add("Secret", v.Secret.SecretName)
+		}
+		if v.Projected != nil {
+			for _, s := range v.Projected.Sources {
+				if s.ConfigMap != nil {
+					add("ConfigMap", s.ConfigMap.Name)
+				}
+				if s.Secret != nil {
+					add("Secret", s.Secret.Name)
+				}
+			}
+		}
+	}
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, e := range c.EnvFrom {
+			if e.ConfigMapRef != nil {
+				add("ConfigMap", e.ConfigMapRef.Name)
+			}
+			if e.SecretRef != nil {
+				add("Secret", e.SecretRef.Name)
+			}
+		}
+		for _, e := range c.Env {
+			if e.ValueFrom == nil {
+				continue
+			}
+			if e.ValueFrom.ConfigMapKeyRef != nil {
+				add("ConfigMap", e.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if e.ValueFrom.SecretKeyRef != nil {
+				add("Secret", e.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	result := []configRef{}
+	for ref := range refs {
+		result = append(result, ref)
+	}
+	return result
+}
+
+func configKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// configChecksum hashes the data of a ConfigMap or Secret. encoding/json sorts map
+// keys, so the checksum is stable.
+func configChecksum(u *unstructured.Unstructured) (string, error) {
+	content := map[string]interface{}{}
+	for _, field := range []string{"data", "binaryData", "stringData"} {
+		if v, ok := u.Object[field]; ok {
+			content[field] = v
+		}
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const checksumTestData = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: app
+data:
+  key: value1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: uses-config
+  namespace: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: c
+        envFrom:
+        - configMapRef:
+            name: config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: no-config
+  namespace: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: c
+`
+
+func templateChecksums(t *testing.T, data string) []string {
+	parsed, err := ParseYAML([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := InjectConfigChecksums(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums := []string{}
+	for _, m := range result[1:] {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum, _, _ := unstructured.NestedString(u.Object, "spec", "template", "metadata", "annotations", ConfigChecksumAnnotation)
+		sums = append(sums, sum)
+	}
+	return sums
+}
+
+func TestInjectConfigChecksums(t *testing.T) {
+	first := templateChecksums(t, checksumTestData)
+	if first[0] == "" {
+		t.Errorf("expected checksum annotation on the deployment consuming the config map")
+	}
+	if first[1] != "" {
+		t.Errorf("unexpected checksum annotation on the deployment not consuming the config map")
+	}
+
+	second := templateChecksums(t, strings.Replace(checksumTestData, "value1", "value2", 1))
+	if first[0] == second[0] {
+		t.Errorf("expected checksum to change when the config map data changes")
+	}
+	if again := templateChecksums(t, checksumTestData); again[0] != first[0] {
+		t.Errorf("expected checksum to be stable, got %s and %s", first[0], again[0])
+	}
+}
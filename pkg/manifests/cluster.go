@@ -0,0 +1,212 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// well-known cluster claims
+	ProductClaim  = "product.open-cluster-management.io"
+	PlatformClaim = "platform.open-cluster-management.io"
+	RegionClaim   = "region.open-cluster-management.io"
+	// ArchitectureClaim is the CPU architecture of the nodes of the cluster
+	ArchitectureClaim = "architecture.open-cluster-management.io"
+	// OSClaim lists the operating systems of the nodes of the cluster, comma separated
+	OSClaim = "os.open-cluster-management.io"
+	// CloudLabel is the label set on managed clusters with their cloud provider
+	CloudLabel = "cloud"
+	// ArchitectureLabel is the label set on managed clusters with the CPU architecture
+	// of their nodes
+	ArchitectureLabel = "kubernetes.io/arch"
+
+	// ClusterContextValue is the key of the cluster context in the values of Helm releases
+	ClusterContextValue = "clusterContext"
+)
+
+// ClusterContext describes the cluster a bundle is distributed to. The manifests
+// reference it with templates such as {{ .Region }}, {{ index .Claims "id.k8s.io" }}
+// {{ .Outputs.db.endpoint }} or {{ .Secrets.password }}, the Helm charts with the clusterContext value.
+type ClusterContext struct {
+	Name string `json:"name"`
+	// Platform is the Kubernetes distribution, e.g. OpenShift or EKS
+	Platform string `json:"platform,omitempty"`
+	// Cloud is the infrastructure provider, e.g. AWS
+	Cloud  string `json:"cloud,omitempty"`
+	Region string `json:"region,omitempty"`
+	// Architecture is the CPU architecture of the nodes, e.g. arm64
+	Architecture string `json:"architecture,omitempty"`
+	// OperatingSystems of the nodes, linux when the cluster does not advertise them
+	OperatingSystems []string          `json:"operatingSystems,omitempty"`
+	APIServerURL     string            `json:"apiServerURL,omitempty"`
+	Claims           map[string]string `json:"claims,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	// Outputs of the Crossplane components of the bundle, by component and name
+	Outputs map[string]map[string]string `json:"outputs,omitempty"`
+	// Secrets are the secret values of the bundle looked up for the cluster, never
+	// serialized
+	Secrets map[string]string `json:"-"`
+}
+
+// NewClusterContext returns the context of a managed cluster, from its cluster claims
+// and labels
+func NewClusterContext(cluster *clusterv1.ManagedCluster) ClusterContext {
+	c := ClusterContext{
+		Name:   cluster.Name,
+		Claims: map[string]string{},
+		Labels: map[string]string{},
+	}
+	for _, claim := range cluster.Status.ClusterClaims {
+		c.Claims[claim.Name] = claim.Value
+	}
+	for k, v := range cluster.Labels {
+		c.Labels[k] = v
+	}
+	c.Platform = c.Claims[ProductClaim]
+	c.Region = c.Claims[RegionClaim]
+	c.Cloud = c.Claims[PlatformClaim]
+	if c.Cloud == "" {
+		c.Cloud = c.Labels[CloudLabel]
+	}
+	c.Architecture = c.Claims[ArchitectureClaim]
+	if c.Architecture == "" {
+		c.Architecture = c.Labels[ArchitectureLabel]
+	}
+	c.OperatingSystems = []string{"linux"}
+	if v := c.Claims[OSClaim]; v != "" {
+		c.OperatingSystems = []string{}
+		for _, os := range strings.Split(v, ",") {
+			if os = strings.TrimSpace(os); os != "" {
+				c.OperatingSystems = append(c.OperatingSystems, os)
+			}
+		}
+	}
+	if len(cluster.Spec.ManagedClusterClientConfigs) > 0 {
+		c.APIServerURL = cluster.Spec.ManagedClusterClientConfigs[0].URL
+	}
+	return c
+}
+
+// values returns the cluster context as Helm values
+func (c ClusterContext) values() map[string]interface{} {
+	values := map[string]interface{}{"name": c.Name}
+	for k, v := range map[string]string{
+		"platform":     c.Platform,
+		"cloud":        c.Cloud,
+		"region":       c.Region,
+		"apiServerURL": c.APIServerURL,
+	} {
+		if v != "" {
+			values[k] = v
+		}
+	}
+	for k, m := range map[string]map[string]string{"claims": c.Claims, "labels": c.Labels} {
+		if len(m) == 0 {
+			continue
+		}
+		vm := map[string]interface{}{}
+		for name, v := range m {
+			vm[name] = v
+		}
+		values[k] = vm
+	}
+	return values
+}
+
+// ApplyClusterContext executes the templates in the string values of the manifests
+// with the cluster context, and sets the clusterContext value of the Flux Helm
+// releases. Templates referencing a missing claim or label fail.
+func ApplyClusterContext(ms []workapiv1.Manifest, c ClusterContext) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		obj, err := substitute(u.Object, c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to substitute cluster context in %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+		u.Object = obj.(map[string]interface{})
+		if u.GetKind() == "HelmRelease" && strings.HasPrefix(u.GetAPIVersion(), "helm.toolkit.fluxcd.io/") {
+			if err := unstructured.SetNestedField(u.Object, c.values(), "spec", "values", ClusterContextValue); err != nil {
+				return nil, err
+			}
+		}
+		updated, err := FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, updated)
+	}
+	return result, nil
+}
+
+// Render executes the template with the cluster context
+func (c ClusterContext) Render(text string) (string, error) {
+	v, err := substitute(text, c)
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// substitute executes the templates in the strings of a JSON value with the data
+func substitute(v interface{}, data interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		if !strings.Contains(t, "{{") {
+			return t, nil
+		}
+		tmpl, err := template.New("").Option("missingkey=error").Parse(t)
+		if err != nil {
+			return nil, err
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, err
+		}
+		return b.String(), nil
+	case map[string]interface{}:
+		for k, e := range t {
+			s, err := substitute(e, data)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = s
+		}
+		return t, nil
+	case []interface{}:
+		for i, e := range t {
+			s, err := substitute(e, data)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = s
+		}
+		return t, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+func testCluster() *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: v1.ObjectMeta{Name: "cluster1", Labels: map[string]string{"cloud": "Amazon", "env": "prod"}},
+		Spec: clusterv1.ManagedClusterSpec{
+			ManagedClusterClientConfigs: []clusterv1.ClientConfig{{URL: "https://api.cluster1:6443"}},
+		},
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{
+				{Name: ProductClaim, Value: "EKS"},
+				{Name: PlatformClaim, Value: "AWS"},
+				{Name: RegionClaim, Value: "us-east-1"},
+				{Name: "id.k8s.io", Value: "1234"},
+			},
+		},
+	}
+}
+
+func TestNewClusterContext(t *testing.T) {
+	c := NewClusterContext(testCluster())
+	if c.Platform != "EKS" || c.Cloud != "AWS" || c.Region != "us-east-1" || c.APIServerURL != "https://api.cluster1:6443" {
+		t.Errorf("unexpected cluster context %+v", c)
+	}
+	cluster := testCluster()
+	cluster.Status.ClusterClaims = nil
+	if c := NewClusterContext(cluster); c.Cloud != "Amazon" {
+		t.Errorf("expected cloud from the cluster label, got %q", c.Cloud)
+	}
+}
+
+func TestApplyClusterContext(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+data:
+  region: "{{ .Region }}"
+  endpoint: "https://web.{{ .Name }}.{{ .Labels.env }}.example.com"
+  id: '{{ index .Claims "id.k8s.io" }}'
+  plain: "no template"
+---
+apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: web
+spec:
+  values:
+    replicas: 2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ApplyClusterContext(ms, NewClusterContext(testCluster()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := ToUnstructured(result[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+	expected := map[string]string{
+		"region":   "us-east-1",
+		"endpoint": "https://web.cluster1.prod.example.com",
+		"id":       "1234",
+		"plain":    "no template",
+	}
+	for k, v := range expected {
+		if data[k] != v {
+			t.Errorf("expected %s to be %q, got %q", k, v, data[k])
+		}
+	}
+	u, err = ToUnstructured(result[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if region, _, _ := unstructured.NestedString(u.Object, "spec", "values", ClusterContextValue, "region"); region != "us-east-1" {
+		t.Errorf("expected the region in the Helm values, got %q", region)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(u.Object, "spec", "values", "replicas"); replicas != 2 {
+		t.Errorf("expected the Helm values to be kept, got replicas %d", replicas)
+	}
+
+	ms, err = ParseYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+data:
+  zone: "{{ .Labels.zone }}"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplyClusterContext(ms, NewClusterContext(testCluster())); err == nil {
+		t.Error("expected a missing label to fail")
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// ComponentAnnotation records the component of a bundle a manifest belongs to
+	ComponentAnnotation = "cluster.open-cluster-management.io/component"
+	// ResyncAnnotation records the last resync requested for the component of a
+	// manifest, changing it makes the work agent apply the manifest again
+	ResyncAnnotation = "cluster.open-cluster-management.io/component-resync-requested"
+)
+
+// SetComponent annotates the manifests with the component they belong to
+func SetComponent(ms []workapiv1.Manifest, component string) ([]workapiv1.Manifest, error) {
+	return annotate(ms, ComponentAnnotation, component)
+}
+
+// ComponentOf returns the component of a manifest, empty if it has none
+func ComponentOf(m workapiv1.Manifest) (string, error) {
+	u, err := ToUnstructured(m)
+	if err != nil {
+		return "", err
+	}
+	return u.GetAnnotations()[ComponentAnnotation], nil
+}
+
+// OfComponent returns the manifests of the component
+func OfComponent(ms []workapiv1.Manifest, component string) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		c, err := ComponentOf(m)
+		if err != nil {
+			return nil, err
+		}
+		if c == component {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// ReplaceComponent returns the manifests with the ones of the component replaced by
+// the replacement, which is appended after the other manifests
+func ReplaceComponent(ms []workapiv1.Manifest, component string, replacement []workapiv1.Manifest) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		c, err := ComponentOf(m)
+		if err != nil {
+			return nil, err
+		}
+		if c != component {
+			result = append(result, m)
+		}
+	}
+	return append(result, replacement...), nil
+}
+
+// Resync annotates the manifests of the component with the token of the resync
+func Resync(ms []workapiv1.Manifest, component, token string) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		c, err := ComponentOf(m)
+		if err != nil {
+			return nil, err
+		}
+		if c == component {
+			annotated, err := annotate([]workapiv1.Manifest{m}, ResyncAnnotation, token)
+			if err != nil {
+				return nil, err
+			}
+			m = annotated[0]
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+)
+
+func TestComponents(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, err := SetComponent(ms[1:], "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := append(ms[:1:1], api...)
+	if c, err := ComponentOf(all[1]); err != nil || c != "api" {
+		t.Errorf("unexpected component %q: %v", c, err)
+	}
+	if c, err := ComponentOf(all[0]); err != nil || c != "" {
+		t.Errorf("unexpected component %q: %v", c, err)
+	}
+	of, err := OfComponent(all, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(of) != 1 {
+		t.Errorf("expected the manifest of the component, got %d", len(of))
+	}
+
+	previous, err := ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api-v1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err = SetComponent(previous, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaced, err := ReplaceComponent(all, "api", previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replaced) != 2 {
+		t.Fatalf("expected 2 manifests, got %d", len(replaced))
+	}
+	u, err := ToUnstructured(replaced[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetName() != "api-v1" {
+		t.Errorf("expected the previous manifest of the component, got %s", u.GetName())
+	}
+}
+
+func TestResync(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  annotations:
+    cluster.open-cluster-management.io/component: api
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resynced, err := Resync(ms, "api", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"", "1"} {
+		u, err := ToUnstructured(resynced[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := u.GetAnnotations()[ResyncAnnotation]; got != want {
+			t.Errorf("expected resync %q of manifest %d, got %q", want, i, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// Identity returns the group/kind/namespace/name identity of a manifest
+func Identity(m workapiv1.Manifest) (string, error) {
+	u, err := ToUnstructured(m)
+	if err != nil {
+		return "", err
+	}
+	gvk := u.GroupVersionKind()
+	return strings.Join([]string{gvk.Group, gvk.Kind, u.GetNamespace(), u.GetName()}, "/"), nil
+}
+
+// Diff compares two sets of manifests by identity, returning the sorted identities of the
+// manifests added, removed and changed in the new set
+func Diff(old, new []workapiv1.Manifest) (added, removed, changed []string, err error) {
+	oldContent, err := contentByIdentity(old)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	newContent, err := contentByIdentity(new)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for id, content := range newContent {
+		oldC, ok := oldContent[id]
+		switch {
+		case !ok:
+			added = append(added, id)
+		case oldC != content:
+			changed = append(changed, id)
+		}
+	}
+	for id := range oldContent {
+		if _, ok := newContent[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed, nil
+}
+
+func contentByIdentity(manifests []workapiv1.Manifest) (map[string]string, error) {
+	result := map[string]string{}
+	for _, m := range manifests {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		id, err := Identity(m)
+		if err != nil {
+			return nil, err
+		}
+		// encoding/json sorts map keys, so equal objects have equal encodings
+		data, err := json.Marshal(u.Object)
+		if err != nil {
+			return nil, err
+		}
+		result[id] = string(data)
+	}
+	return result, nil
+}
@@ -0,0 +1,163 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// The fuzz targets check that malformed manifests and templates of the users fail
+// with an error, never a panic, and that the manifests returned without error are
+// valid objects. They run as unit tests with their seeds, and fuzz with e.g.
+//
+//	go test ./pkg/manifests -run '^$' -fuzz FuzzParseYAML -fuzztime 1m
+
+var fuzzSeeds = []string{
+	"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\ndata:\n  a: b\n",
+	"apiVersion: v1\nkind: ConfigMap\n---\n---\napiVersion: apps/v1\nkind: Deployment\n",
+	`{"apiVersion":"v1","kind":"List","items":[{"apiVersion":"v1","kind":"Secret"}]}`,
+	`[{"apiVersion":"v1","kind":"ConfigMap"},{"apiVersion":"v1","kind":"Service"}]`,
+	`"apiVersion: v1\nkind: ConfigMap\n"`,
+	`{"apiVersion":"v1","kind":"ConfigMap","data":{"region":"{{ .Region }}","id":"{{ index .Claims \"id.k8s.io\" }}"}}`,
+	`{"apiVersion":"helm.toolkit.fluxcd.io/v2beta1","kind":"HelmRelease","spec":{"values":{"a":[1,"{{ .Name }}"]}}}`,
+	"kind: ConfigMap\n",
+	"apiVersion: [\n",
+	"{{ range .Params }}{{ . }}{{ end }}",
+	"",
+}
+
+// checkManifests fails the fuzz test if a manifest returned without error does not
+// decode to an object with an apiVersion and a kind
+func checkManifests(t *testing.T, ms []workapiv1.Manifest) {
+	for i, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			t.Fatalf("manifest %d %q does not decode: %v", i, m.Raw, err)
+		}
+		if u.GetAPIVersion() == "" || u.GetKind() == "" {
+			t.Fatalf("manifest %d %q is missing apiVersion or kind", i, m.Raw)
+		}
+	}
+}
+
+func FuzzParseYAML(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ms, err := ParseYAML(data)
+		if err != nil {
+			return
+		}
+		checkManifests(t, ms)
+		// the parsed manifests are stable
+		again, err := ParseYAML(joinJSON(ms))
+		if err != nil {
+			t.Fatalf("parsed manifests do not parse again: %v", err)
+		}
+		if len(again) != len(ms) {
+			t.Fatalf("parsed %d manifests, then %d", len(ms), len(again))
+		}
+	})
+}
+
+func FuzzNormalize(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		ms, err := Normalize([]workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: raw}}})
+		if err != nil {
+			return
+		}
+		// a single resource is left unchanged, the expanded ones are objects
+		if len(ms) == 1 && string(ms[0].Raw) == string(raw) {
+			return
+		}
+		checkManifests(t, ms)
+	})
+}
+
+func FuzzApplyClusterContext(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s), "eu-west-1")
+	}
+	f.Fuzz(func(t *testing.T, raw []byte, region string) {
+		ms, err := ParseYAML(raw)
+		if err != nil {
+			return
+		}
+		c := ClusterContext{
+			Name:    "cluster1",
+			Region:  region,
+			Claims:  map[string]string{"id.k8s.io": region},
+			Labels:  map[string]string{"env": region},
+			Secrets: map[string]string{"password": region},
+		}
+		rendered, err := ApplyClusterContext(ms, c)
+		if err != nil {
+			return
+		}
+		if len(rendered) != len(ms) {
+			t.Fatalf("rendered %d manifests from %d", len(rendered), len(ms))
+		}
+		for i, m := range rendered {
+			if _, err := ToUnstructured(m); err != nil {
+				t.Fatalf("rendered manifest %d %q does not decode: %v", i, m.Raw, err)
+			}
+		}
+		if _, err := InjectConfigChecksums(rendered); err != nil {
+			t.Fatalf("checksums of the rendered manifests: %v", err)
+		}
+	})
+}
+
+func FuzzRenderTemplate(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s, "web")
+	}
+	f.Add("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Params.name }}\n", "a\nb: {c")
+	f.Fuzz(func(t *testing.T, manifests, name string) {
+		v := &appv1alpha1.AppBundleTemplateVersion{
+			Version:    "1.0.0",
+			Parameters: []appv1alpha1.TemplateParameter{{Name: "name", Default: "web"}},
+			Manifests:  manifests,
+		}
+		ms, err := RenderTemplate(v, map[string]string{"name": name})
+		if err != nil {
+			return
+		}
+		checkManifests(t, ms)
+	})
+}
+
+// joinJSON returns the manifests as a stream of JSON documents
+func joinJSON(ms []workapiv1.Manifest) []byte {
+	data := []byte{}
+	for _, m := range ms {
+		data = append(append(data, m.Raw...), '\n')
+	}
+	return data
+}
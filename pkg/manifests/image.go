@@ -0,0 +1,180 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// podSpecPaths are the paths of the pod specs in the well-known workload kinds
+var podSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// SplitImage splits an image reference into its repository and tag, the digest being
+// part of the tag. The tag is empty when not set.
+func SplitImage(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i:]
+	}
+	// a colon before the last slash separates the registry port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, ""
+}
+
+// tagPattern matches the tags of the image references, and digestPattern the digests
+// as returned by SplitImage
+var (
+	tagPattern    = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	digestPattern = regexp.MustCompile(`^@sha256:[a-f0-9]{64}$`)
+)
+
+// ValidateTag returns an error unless the tag is a valid tag, or a digest prefixed
+// with @
+func ValidateTag(tag string) error {
+	if !tagPattern.MatchString(tag) && !digestPattern.MatchString(tag) {
+		return fmt.Errorf("invalid image tag %q", tag)
+	}
+	return nil
+}
+
+// JoinImage returns the image reference of a repository and a tag or digest
+func JoinImage(repository, tag string) string {
+	if strings.HasPrefix(tag, "@") {
+		return repository + tag
+	}
+	return repository + ":" + tag
+}
+
+// SetImageTag sets the tag of the containers running the image repository in the
+// workloads of the manifests, and returns the updated manifests with the number of
+// containers changed
+func SetImageTag(ms []workapiv1.Manifest, repository, tag string) ([]workapiv1.Manifest, int, error) {
+	changed := 0
+	result, err := MapImages(ms, func(image string) (string, error) {
+		repo, current := SplitImage(image)
+		if repo != repository || current == tag {
+			return image, nil
+		}
+		changed++
+		return JoinImage(repo, tag), nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, changed, nil
+}
+
+// MapImages replaces the images of the containers in the workloads of the manifests
+// by the result of f, and returns the updated manifests
+func MapImages(ms []workapiv1.Manifest, f func(image string) (string, error)) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		path, ok := podSpecPaths[u.GetKind()]
+		if !ok {
+			result = append(result, m)
+			continue
+		}
+		changed := false
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, found, err := unstructured.NestedSlice(u.Object, append(append([]string{}, path...), field)...)
+			if err != nil || !found {
+				continue
+			}
+			for _, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				image, _ := container["image"].(string)
+				mapped, err := f(image)
+				if err != nil {
+					return nil, err
+				}
+				if mapped != image {
+					container["image"] = mapped
+					changed = true
+				}
+			}
+			if err := unstructured.SetNestedSlice(u.Object, containers, append(append([]string{}, path...), field)...); err != nil {
+				return nil, err
+			}
+		}
+		if !changed {
+			result = append(result, m)
+			continue
+		}
+		updated, err := FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, updated)
+	}
+	return result, nil
+}
+
+// Containers returns the init containers and containers of the pod spec of a workload,
+// none for the other kinds
+func Containers(u *unstructured.Unstructured) []map[string]interface{} {
+	path, ok := podSpecPaths[u.GetKind()]
+	if !ok {
+		return nil
+	}
+	result := []map[string]interface{}{}
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(u.Object, append(append([]string{}, path...), field)...)
+		for _, c := range containers {
+			if container, ok := c.(map[string]interface{}); ok {
+				result = append(result, container)
+			}
+		}
+	}
+	return result
+}
+
+// Volumes returns the volumes of the pod spec of a workload, none for the other kinds
+func Volumes(u *unstructured.Unstructured) []map[string]interface{} {
+	path, ok := podSpecPaths[u.GetKind()]
+	if !ok {
+		return nil
+	}
+	result := []map[string]interface{}{}
+	volumes, _, _ := unstructured.NestedSlice(u.Object, append(append([]string{}, path...), "volumes")...)
+	for _, v := range volumes {
+		if volume, ok := v.(map[string]interface{}); ok {
+			result = append(result, volume)
+		}
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSplitImage(t *testing.T) {
+	tests := []struct {
+		image, repo, tag string
+	}{
+		{image: "nginx", repo: "nginx"},
+		{image: "nginx:1.21", repo: "nginx", tag: "1.21"},
+		{image: "registry:5000/acme/web", repo: "registry:5000/acme/web"},
+		{image: "registry:5000/acme/web:v2", repo: "registry:5000/acme/web", tag: "v2"},
+		{image: "acme/web@sha256:abcd", repo: "acme/web", tag: "@sha256:abcd"},
+	}
+	for _, tt := range tests {
+		repo, tag := SplitImage(tt.image)
+		if repo != tt.repo || tag != tt.tag {
+			t.Errorf("SplitImage(%s) = %s, %s, expected %s, %s", tt.image, repo, tag, tt.repo, tt.tag)
+		}
+		if tag != "" && JoinImage(repo, tag) != tt.image {
+			t.Errorf("JoinImage(%s, %s) = %s, expected %s", repo, tag, JoinImage(repo, tag), tt.image)
+		}
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	for tag, valid := range map[string]bool{
+		"v2":                                  true,
+		"1.21.0-alpine_3":                     true,
+		"@sha256:" + strings.Repeat("ab", 32): true,
+		"":                                    false,
+		".hidden":                             false,
+		"v2 --privileged":                     false,
+		"v2\n":                                false,
+		"v2@sha256:abcd":                      false,
+		"@sha256:abcd":                        false,
+		strings.Repeat("v", 129):              false,
+	} {
+		if err := ValidateTag(tag); (err == nil) != valid {
+			t.Errorf("ValidateTag(%q) = %v, expected valid %v", tag, err, valid)
+		}
+	}
+}
+
+func TestSetImageTag(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: ghcr.io/acme/web:v1
+      containers:
+      - name: web
+        image: ghcr.io/acme/web:v1
+      - name: proxy
+        image: envoyproxy/envoy:v1.20.0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, changed, err := SetImageTag(ms, "ghcr.io/acme/web", "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 2 || len(result) != 2 {
+		t.Fatalf("expected 2 containers changed in 2 manifests, got %d in %d", changed, len(result))
+	}
+	u, err := ToUnstructured(result[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+	if image := containers[0].(map[string]interface{})["image"]; image != "ghcr.io/acme/web:v2" {
+		t.Errorf("expected the web image to be updated, got %v", image)
+	}
+	if image := containers[1].(map[string]interface{})["image"]; image != "envoyproxy/envoy:v1.20.0" {
+		t.Errorf("expected the proxy image to be unchanged, got %v", image)
+	}
+
+	if _, changed, _ := SetImageTag(result, "ghcr.io/acme/web", "v2"); changed != 0 {
+		t.Errorf("expected no change when the tag is already set, got %d", changed)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// Instantiate makes the manifests of a bundle instance unique by appending
+// "-<suffix>" to the names of the namespaces and of the other cluster-scoped resources
+// the manifests define. The resources of the renamed namespaces are moved to them, and
+// the role bindings follow the renamed namespaces and cluster roles. Resources in the
+// namespaces not defined by the manifests are left unchanged.
+func Instantiate(ms []workapiv1.Manifest, suffix string) ([]workapiv1.Manifest, error) {
+	objs := []*unstructured.Unstructured{}
+	namespaces, clusterRoles := sets.NewString(), sets.NewString()
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, u)
+		switch gk := u.GroupVersionKind().GroupKind(); {
+		case gk.Group == "" && gk.Kind == "Namespace":
+			namespaces.Insert(u.GetName())
+		case gk.Group == "rbac.authorization.k8s.io" && gk.Kind == "ClusterRole":
+			clusterRoles.Insert(u.GetName())
+		}
+	}
+	rename := func(name string) string {
+		return name + "-" + suffix
+	}
+
+	result := []workapiv1.Manifest{}
+	for _, u := range objs {
+		gvk := u.GroupVersionKind()
+		switch {
+		case u.GetNamespace() != "":
+			if namespaces.Has(u.GetNamespace()) {
+				u.SetNamespace(rename(u.GetNamespace()))
+			}
+		case IsClusterScoped(gvk.GroupKind()):
+			u.SetName(rename(u.GetName()))
+		}
+		if gvk.Group == "rbac.authorization.k8s.io" && (gvk.Kind == "RoleBinding" || gvk.Kind == "ClusterRoleBinding") {
+			if err := instantiateBinding(u, namespaces, clusterRoles, rename); err != nil {
+				return nil, err
+			}
+		}
+		m, err := FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// instantiateBinding updates the role and the service account subjects of a binding
+// to the renamed cluster roles and namespaces
+func instantiateBinding(u *unstructured.Unstructured, namespaces, clusterRoles sets.String, rename func(string) string) error {
+	kind, _, _ := unstructured.NestedString(u.Object, "roleRef", "kind")
+	name, _, _ := unstructured.NestedString(u.Object, "roleRef", "name")
+	if kind == "ClusterRole" && clusterRoles.Has(name) {
+		if err := unstructured.SetNestedField(u.Object, rename(name), "roleRef", "name"); err != nil {
+			return err
+		}
+	}
+	subjects, _, err := unstructured.NestedSlice(u.Object, "subjects")
+	if err != nil {
+		return err
+	}
+	for _, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ns, ok := subject["namespace"].(string); ok && namespaces.Has(ns) {
+			subject["namespace"] = rename(ns)
+		}
+	}
+	if subjects != nil {
+		return unstructured.SetNestedSlice(u.Object, subjects, "subjects")
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestInstantiate(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: preview
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: preview
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared
+  namespace: default
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: reader
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: reader
+subjects:
+- kind: ServiceAccount
+  name: web
+  namespace: preview
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Instantiate(ms, "x7k2p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := []*unstructured.Unstructured{}
+	for _, m := range result {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, u)
+	}
+	if objs[0].GetName() != "preview-x7k2p" {
+		t.Errorf("expected the namespace to be renamed, got %s", objs[0].GetName())
+	}
+	if objs[1].GetNamespace() != "preview-x7k2p" || objs[1].GetName() != "web" {
+		t.Errorf("expected the deployment to move to the renamed namespace, got %s/%s", objs[1].GetNamespace(), objs[1].GetName())
+	}
+	if objs[2].GetNamespace() != "default" || objs[2].GetName() != "shared" {
+		t.Errorf("expected the config map of an external namespace to be unchanged, got %s/%s", objs[2].GetNamespace(), objs[2].GetName())
+	}
+	if objs[3].GetName() != "reader-x7k2p" || objs[4].GetName() != "reader-x7k2p" {
+		t.Errorf("expected the cluster scoped resources to be renamed, got %s and %s", objs[3].GetName(), objs[4].GetName())
+	}
+	role, _, _ := unstructured.NestedString(objs[4].Object, "roleRef", "name")
+	subjects, _, _ := unstructured.NestedSlice(objs[4].Object, "subjects")
+	if role != "reader-x7k2p" || subjects[0].(map[string]interface{})["namespace"] != "preview-x7k2p" {
+		t.Errorf("expected the binding to follow the renamed resources, got role %s subjects %v", role, subjects)
+	}
+}
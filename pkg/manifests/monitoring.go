@@ -0,0 +1,123 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+const (
+	// BundleLabel is set on the Services of the bundles with monitoring, and copied to
+	// their metrics by the generated ServiceMonitors
+	BundleLabel = "cluster.open-cluster-management.io/bundle"
+	// BundleGenerationLabel is set and copied like BundleLabel with the bundle generation
+	BundleGenerationLabel = "cluster.open-cluster-management.io/bundle-generation"
+
+	// DefaultMetricsPort is the name of the port scraped when not set
+	DefaultMetricsPort = "metrics"
+)
+
+// InjectMonitoring labels the Services exposing the metrics port of the monitoring
+// spec with the bundle name and generation, and appends a ServiceMonitor scraping them
+// in each of their namespaces, so that federated metrics can be sliced by bundle and
+// generation
+func InjectMonitoring(manifests []workapiv1.Manifest, bundle *appv1alpha1.AppBundle) ([]workapiv1.Manifest, error) {
+	spec := bundle.Spec.Monitoring
+	if spec == nil {
+		return manifests, nil
+	}
+	port := spec.Port
+	if port == "" {
+		port = DefaultMetricsPort
+	}
+	generation := strconv.FormatInt(bundle.Generation, 10)
+
+	result := make([]workapiv1.Manifest, 0, len(manifests))
+	monitors := []workapiv1.Manifest{}
+	namespaces := map[string]bool{}
+	for _, m := range manifests {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		if u.GetAPIVersion() != "v1" || u.GetKind() != "Service" || !hasPort(u, port) {
+			result = append(result, m)
+			continue
+		}
+		labels := u.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[BundleLabel] = bundle.Name
+		labels[BundleGenerationLabel] = generation
+		u.SetLabels(labels)
+		if m, err = FromUnstructured(u); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+
+		if namespaces[u.GetNamespace()] {
+			continue
+		}
+		namespaces[u.GetNamespace()] = true
+		monitor, err := FromUnstructured(serviceMonitor(u.GetNamespace(), port, spec.Interval, bundle.Name))
+		if err != nil {
+			return nil, err
+		}
+		monitors = append(monitors, monitor)
+	}
+	return append(result, monitors...), nil
+}
+
+func hasPort(service *unstructured.Unstructured, name string) bool {
+	ports, _, _ := unstructured.NestedSlice(service.Object, "spec", "ports")
+	for _, p := range ports {
+		if port, ok := p.(map[string]interface{}); ok && port["name"] == name {
+			return true
+		}
+	}
+	return false
+}
+
+func serviceMonitor(namespace, port, interval, bundle string) *unstructured.Unstructured {
+	endpoint := map[string]interface{}{"port": port}
+	if interval != "" {
+		endpoint["interval"] = interval
+	}
+	monitor := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "ServiceMonitor",
+		"metadata": map[string]interface{}{
+			"name":   bundle,
+			"labels": map[string]interface{}{BundleLabel: bundle},
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{BundleLabel: bundle},
+			},
+			"endpoints":    []interface{}{endpoint},
+			"targetLabels": []interface{}{BundleLabel, BundleGenerationLabel},
+		},
+	}}
+	monitor.SetNamespace(namespace)
+	return monitor
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestInjectMonitoring(t *testing.T) {
+	raw := func(s string) workapiv1.Manifest {
+		return workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(s)}}
+	}
+	input := []workapiv1.Manifest{
+		raw(`{"apiVersion":"v1","kind":"Service","metadata":{"name":"web","namespace":"apps"},"spec":{"ports":[{"name":"metrics","port":9090}]}}`),
+		raw(`{"apiVersion":"v1","kind":"Service","metadata":{"name":"api","namespace":"apps"},"spec":{"ports":[{"name":"metrics","port":9090}]}}`),
+		raw(`{"apiVersion":"v1","kind":"Service","metadata":{"name":"db","namespace":"apps"},"spec":{"ports":[{"name":"sql","port":5432}]}}`),
+	}
+	bundle := &appv1alpha1.AppBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Generation: 3},
+		Spec:       appv1alpha1.AppBundleSpec{Monitoring: &appv1alpha1.Monitoring{}},
+	}
+
+	result, err := InjectMonitoring(input, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 4 {
+		t.Fatalf("expected a single ServiceMonitor to be added, got %d manifests", len(result))
+	}
+	web, _ := ToUnstructured(result[0])
+	if web.GetLabels()[BundleLabel] != "shop" || web.GetLabels()[BundleGenerationLabel] != "3" {
+		t.Errorf("unexpected labels on the web service %v", web.GetLabels())
+	}
+	db, _ := ToUnstructured(result[2])
+	if _, ok := db.GetLabels()[BundleLabel]; ok {
+		t.Errorf("service without metrics port should not be labeled")
+	}
+	monitor, _ := ToUnstructured(result[3])
+	if monitor.GetKind() != "ServiceMonitor" || monitor.GetNamespace() != "apps" {
+		t.Errorf("unexpected monitor %s/%s", monitor.GetKind(), monitor.GetNamespace())
+	}
+
+	bundle.Spec.Monitoring = nil
+	if result, _ := InjectMonitoring(input, bundle); len(result) != 3 {
+		t.Errorf("expected manifests unchanged without monitoring")
+	}
+}
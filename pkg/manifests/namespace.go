@@ -0,0 +1,54 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// LabelNamespaces sets the labels on the Namespaces among the manifests, overriding
+// the labels they define
+func LabelNamespaces(ms []workapiv1.Manifest, labels map[string]string) ([]workapiv1.Manifest, error) {
+	if len(labels) == 0 {
+		return ms, nil
+	}
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		if gk := u.GroupVersionKind().GroupKind(); gk.Group != "" || gk.Kind != "Namespace" {
+			result = append(result, m)
+			continue
+		}
+		merged := u.GetLabels()
+		if merged == nil {
+			merged = map[string]string{}
+		}
+		for k, v := range labels {
+			merged[k] = v
+		}
+		u.SetLabels(merged)
+		labeled, err := FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, labeled)
+	}
+	return result, nil
+}
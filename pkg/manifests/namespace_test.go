@@ -0,0 +1,62 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+)
+
+func TestLabelNamespaces(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+  labels:
+    istio-injection: enabled
+    team: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: web
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	labeled, err := LabelNamespaces(ms, map[string]string{"istio-injection": "disabled", "tenant": "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns, err := ToUnstructured(labeled[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"istio-injection": "disabled", "team": "web", "tenant": "shop"}
+	for k, v := range want {
+		if got := ns.GetLabels()[k]; got != v {
+			t.Errorf("expected label %s=%s, got %q", k, v, got)
+		}
+	}
+	cm, err := ToUnstructured(labeled[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.GetLabels()) != 0 {
+		t.Errorf("expected no label on the ConfigMap, got %v", cm.GetLabels())
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// openShiftProducts are the product claims of OpenShift and of its managed offerings
+var openShiftProducts = map[string]bool{
+	"OpenShift":          true,
+	"OpenShiftDedicated": true,
+	"OSD":                true,
+	"ROSA":               true,
+	"ARO":                true,
+	"ROKS":               true,
+}
+
+// IsOpenShift returns true if the cluster runs OpenShift
+func IsOpenShift(c ClusterContext) bool {
+	return openShiftProducts[c.Platform]
+}
+
+// ApplyOpenShiftProfile applies the adjustments of the profile to the manifests
+func ApplyOpenShiftProfile(ms []workapiv1.Manifest, p *appv1alpha1.OpenShiftProfile) ([]workapiv1.Manifest, error) {
+	if p == nil {
+		return ms, nil
+	}
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case p.Routes && isIngress(u):
+			routes, err := Routes(u)
+			if err != nil {
+				return nil, err
+			}
+			for _, route := range routes {
+				m, err := FromUnstructured(route)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, m)
+			}
+		case p.SecurityContext && restrictSecurityContexts(u):
+			updated, err := FromUnstructured(u)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, updated)
+		default:
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// restrictSecurityContexts removes the user, group and fsGroup IDs of the security
+// contexts of the pod spec of a workload, and disallows the privilege escalation of its
+// unprivileged containers. It returns true if the workload changed.
+func restrictSecurityContexts(u *unstructured.Unstructured) bool {
+	path, ok := podSpecPaths[u.GetKind()]
+	if !ok {
+		return false
+	}
+	changed := false
+	if sc, found, _ := unstructured.NestedMap(u.Object, append(append([]string{}, path...), "securityContext")...); found {
+		for _, field := range []string{"runAsUser", "runAsGroup", "fsGroup"} {
+			if _, ok := sc[field]; ok {
+				delete(sc, field)
+				changed = true
+			}
+		}
+		_ = unstructured.SetNestedMap(u.Object, sc, append(append([]string{}, path...), "securityContext")...)
+	}
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, found, err := unstructured.NestedSlice(u.Object, append(append([]string{}, path...), field)...)
+		if err != nil || !found {
+			continue
+		}
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			sc, _ := container["securityContext"].(map[string]interface{})
+			if sc == nil {
+				sc = map[string]interface{}{}
+			}
+			for _, field := range []string{"runAsUser", "runAsGroup"} {
+				if _, ok := sc[field]; ok {
+					delete(sc, field)
+					changed = true
+				}
+			}
+			privileged, _ := sc["privileged"].(bool)
+			if _, ok := sc["allowPrivilegeEscalation"]; !ok && !privileged {
+				sc["allowPrivilegeEscalation"] = false
+				changed = true
+			}
+			if len(sc) > 0 {
+				container["securityContext"] = sc
+			}
+		}
+		_ = unstructured.SetNestedSlice(u.Object, containers, append(append([]string{}, path...), field)...)
+	}
+	return changed
+}
+
+func isIngress(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Kind == "Ingress" && (gvk.Group == "networking.k8s.io" || gvk.Group == "extensions")
+}
+
+// routeBackend is the Service and port a Route sends the traffic to
+type routeBackend struct {
+	service string
+	port    interface{}
+}
+
+// ingressBackend returns the backend of an Ingress path or default backend, in the
+// networking.k8s.io/v1 or v1beta1 format
+func ingressBackend(backend map[string]interface{}) (routeBackend, bool) {
+	if name, found, _ := unstructured.NestedString(backend, "service", "name"); found {
+		port, _, _ := unstructured.NestedFieldNoCopy(backend, "service", "port", "name")
+		if port == nil || port == "" {
+			port, _, _ = unstructured.NestedFieldNoCopy(backend, "service", "port", "number")
+		}
+		return routeBackend{service: name, port: port}, true
+	}
+	if name, found, _ := unstructured.NestedString(backend, "serviceName"); found {
+		port, _, _ := unstructured.NestedFieldNoCopy(backend, "servicePort")
+		return routeBackend{service: name, port: port}, true
+	}
+	return routeBackend{}, false
+}
+
+// Routes returns the OpenShift Routes replacing an Ingress, one per path of its rules,
+// or one for its default backend when it has no rules. The Routes are named after the
+// Ingress, suffixed by their index when there are several.
+func Routes(ingress *unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	tlsHosts := map[string]bool{}
+	tls, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "tls")
+	for _, t := range tls {
+		hosts, _, _ := unstructured.NestedStringSlice(t.(map[string]interface{}), "hosts")
+		for _, h := range hosts {
+			tlsHosts[h] = true
+		}
+	}
+
+	type route struct {
+		host, path string
+		backend    routeBackend
+	}
+	routes := []route{}
+	rules, _, err := unstructured.NestedSlice(ingress.Object, "spec", "rules")
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules of Ingress %s: %w", ingress.GetName(), err)
+	}
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		host, _, _ := unstructured.NestedString(rule, "host")
+		paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+		for _, p := range paths {
+			path, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			backend, _, _ := unstructured.NestedMap(path, "backend")
+			b, ok := ingressBackend(backend)
+			if !ok {
+				return nil, fmt.Errorf("a backend of Ingress %s is not a Service", ingress.GetName())
+			}
+			p, _, _ := unstructured.NestedString(path, "path")
+			routes = append(routes, route{host: host, path: p, backend: b})
+		}
+	}
+	if len(rules) == 0 {
+		backend, found, _ := unstructured.NestedMap(ingress.Object, "spec", "defaultBackend")
+		if !found {
+			backend, found, _ = unstructured.NestedMap(ingress.Object, "spec", "backend")
+		}
+		if found {
+			b, ok := ingressBackend(backend)
+			if !ok {
+				return nil, fmt.Errorf("a backend of Ingress %s is not a Service", ingress.GetName())
+			}
+			routes = append(routes, route{backend: b})
+		}
+	}
+
+	result := []*unstructured.Unstructured{}
+	for i, r := range routes {
+		name := ingress.GetName()
+		if len(routes) > 1 {
+			name = fmt.Sprintf("%s-%d", name, i)
+		}
+		spec := map[string]interface{}{
+			"to": map[string]interface{}{"kind": "Service", "name": r.backend.service, "weight": int64(100)},
+		}
+		if r.host != "" {
+			spec["host"] = r.host
+		}
+		if r.path != "" && r.path != "/" {
+			spec["path"] = r.path
+		}
+		if r.backend.port != nil && r.backend.port != "" {
+			spec["port"] = map[string]interface{}{"targetPort": r.backend.port}
+		}
+		if tlsHosts[r.host] {
+			spec["tls"] = map[string]interface{}{"termination": "edge", "insecureEdgeTerminationPolicy": "Redirect"}
+		}
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "route.openshift.io/v1",
+			"kind":       "Route",
+			"spec":       spec,
+		}}
+		u.SetName(name)
+		u.SetNamespace(ingress.GetNamespace())
+		u.SetLabels(ingress.GetLabels())
+		result = append(result, u)
+	}
+	return result, nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestIsOpenShift(t *testing.T) {
+	for platform, expected := range map[string]bool{"OpenShift": true, "ROSA": true, "EKS": false, "": false} {
+		if openShift := IsOpenShift(ClusterContext{Platform: platform}); openShift != expected {
+			t.Errorf("IsOpenShift(%q) = %t, expected %t", platform, openShift, expected)
+		}
+	}
+}
+
+func TestApplyOpenShiftProfile(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      securityContext:
+        runAsUser: 1000
+        fsGroup: 2000
+        runAsNonRoot: true
+      containers:
+      - name: web
+        image: quay.io/acme/web:v2
+        securityContext:
+          runAsUser: 1000
+      - name: agent
+        image: quay.io/acme/agent:v1
+        securityContext:
+          privileged: true
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+  namespace: shop
+spec:
+  tls:
+  - hosts: [shop.example.com]
+    secretName: shop-tls
+  rules:
+  - host: shop.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: web
+            port:
+              name: http
+      - path: /api
+        pathType: Prefix
+        backend:
+          service:
+            name: api
+            port:
+              number: 8080
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ApplyOpenShiftProfile(ms, &appv1alpha1.OpenShiftProfile{SecurityContext: true, Routes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 3 {
+		t.Fatalf("expected the deployment and 2 routes, got %d manifests", len(result))
+	}
+
+	u, _ := ToUnstructured(result[0])
+	sc, _, _ := unstructured.NestedMap(u.Object, "spec", "template", "spec", "securityContext")
+	if !reflect.DeepEqual(sc, map[string]interface{}{"runAsNonRoot": true}) {
+		t.Errorf("unexpected pod security context %v", sc)
+	}
+	containers := Containers(u)
+	if sc := containers[0]["securityContext"]; !reflect.DeepEqual(sc, map[string]interface{}{"allowPrivilegeEscalation": false}) {
+		t.Errorf("unexpected security context of web %v", sc)
+	}
+	if sc := containers[1]["securityContext"]; !reflect.DeepEqual(sc, map[string]interface{}{"privileged": true}) {
+		t.Errorf("unexpected security context of agent %v", sc)
+	}
+
+	expected := []map[string]interface{}{
+		{
+			"host": "shop.example.com",
+			"to":   map[string]interface{}{"kind": "Service", "name": "web", "weight": int64(100)},
+			"port": map[string]interface{}{"targetPort": "http"},
+			"tls":  map[string]interface{}{"termination": "edge", "insecureEdgeTerminationPolicy": "Redirect"},
+		},
+		{
+			"host": "shop.example.com",
+			"path": "/api",
+			"to":   map[string]interface{}{"kind": "Service", "name": "api", "weight": int64(100)},
+			"port": map[string]interface{}{"targetPort": int64(8080)},
+			"tls":  map[string]interface{}{"termination": "edge", "insecureEdgeTerminationPolicy": "Redirect"},
+		},
+	}
+	for i, m := range result[1:] {
+		route, _ := ToUnstructured(m)
+		if route.GetKind() != "Route" || route.GetNamespace() != "shop" || route.GetName() != []string{"web-0", "web-1"}[i] {
+			t.Errorf("unexpected route %s %s/%s", route.GetKind(), route.GetNamespace(), route.GetName())
+		}
+		if spec, _, _ := unstructured.NestedMap(route.Object, "spec"); !reflect.DeepEqual(spec, expected[i]) {
+			t.Errorf("expected route spec %v, got %v", expected[i], spec)
+		}
+	}
+}
+
+func TestApplyOpenShiftProfileDisabled(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  defaultBackend:
+    service:
+      name: web
+      port:
+        number: 80
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ApplyOpenShiftProfile(ms, &appv1alpha1.OpenShiftProfile{SecurityContext: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, ms) {
+		t.Error("expected the ingress to be kept without routes")
+	}
+}
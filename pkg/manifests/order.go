@@ -0,0 +1,132 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// kindOrder lists the kinds applied before the others, the resources they depend on
+// first
+var kindOrder = map[string]int{
+	"Namespace":                1,
+	"CustomResourceDefinition": 2,
+	"ServiceAccount":           3,
+	"ClusterRole":              4,
+	"ClusterRoleBinding":       5,
+	"Role":                     6,
+	"RoleBinding":              7,
+	"Secret":                   8,
+	"ConfigMap":                9,
+	"PersistentVolumeClaim":    10,
+	"Service":                  11,
+}
+
+// Sort orders the manifests by kind, the kinds of kindOrder first, then by namespace
+// and name, so that reordering the manifests of a bundle does not change its content
+func Sort(ms []workapiv1.Manifest) ([]workapiv1.Manifest, error) {
+	type entry struct {
+		order                        int
+		group, kind, namespace, name string
+		manifest                     workapiv1.Manifest
+	}
+	entries := make([]entry, 0, len(ms))
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		gvk := u.GroupVersionKind()
+		order, ok := kindOrder[gvk.Kind]
+		if !ok {
+			order = len(kindOrder) + 1
+		}
+		entries = append(entries, entry{order, gvk.Group, gvk.Kind, u.GetNamespace(), u.GetName(), m})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch {
+		case a.order != b.order:
+			return a.order < b.order
+		case a.group != b.group:
+			return a.group < b.group
+		case a.kind != b.kind:
+			return a.kind < b.kind
+		case a.namespace != b.namespace:
+			return a.namespace < b.namespace
+		default:
+			return a.name < b.name
+		}
+	})
+	result := make([]workapiv1.Manifest, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.manifest)
+	}
+	return result, nil
+}
+
+// Canonical returns the manifests ordered by Sort, each encoded as compact JSON with
+// sorted keys and unescaped HTML characters, so that the works and the digests of
+// their content depend neither on the order and formatting of the manifests nor on
+// how the controller produced them. Numbers keep their literal.
+func Canonical(ms []workapiv1.Manifest) ([]workapiv1.Manifest, error) {
+	encoded := make([]workapiv1.Manifest, 0, len(ms))
+	for i, m := range ms {
+		raw, err := canonicalJSON(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest %d: %w", i, err)
+		}
+		encoded = append(encoded, workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: raw}})
+	}
+	return Sort(encoded)
+}
+
+// canonicalJSON returns the canonical encoding of a manifest, either raw or typed
+func canonicalJSON(m workapiv1.Manifest) ([]byte, error) {
+	data := m.Raw
+	if m.Object != nil {
+		var err error
+		if data, err = json.Marshal(m.Object); err != nil {
+			return nil, err
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var obj interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the object")
+	}
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	// encoding/json sorts the keys of the maps
+	if err := encoder.Encode(obj); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func TestSort(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: shop
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Sort(ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, m := range result {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, u.GetKind()+"/"+u.GetName())
+	}
+	if got := strings.Join(names, " "); got != "Namespace/shop ConfigMap/web Deployment/api Deployment/web" {
+		t.Errorf("unexpected order %s", got)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	raw := func(s string) workapiv1.Manifest {
+		return workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(s)}}
+	}
+	deployment := `{
+  "kind": "Deployment", "apiVersion": "apps/v1",
+  "metadata": {"namespace": "shop", "name": "web"},
+  "spec": {"replicas": 2, "template": {"metadata": {"annotations": {"cpu": 1.50, "rule": "a<b && c>d"}}}}
+}`
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   v1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Data:       map[string]string{"b": "2", "a": "1"},
+	}
+	first, err := Canonical([]workapiv1.Manifest{raw(deployment), {RawExtension: runtime.RawExtension{Object: configMap}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Canonical([]workapiv1.Manifest{
+		raw(`{"apiVersion":"v1","data":{"a":"1","b":"2"},"kind":"ConfigMap","metadata":{"creationTimestamp":null,"name":"web","namespace":"shop"}}`),
+		raw(strings.ReplaceAll(deployment, "\n", "")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`{"apiVersion":"v1","data":{"a":"1","b":"2"},"kind":"ConfigMap","metadata":{"creationTimestamp":null,"name":"web","namespace":"shop"}}`,
+		`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"shop"},"spec":{"replicas":2,"template":{"metadata":{"annotations":{"cpu":1.50,"rule":"a<b && c>d"}}}}}`,
+	}
+	for _, result := range [][]workapiv1.Manifest{first, second} {
+		if len(result) != len(expected) {
+			t.Fatalf("expected %d manifests, got %d", len(expected), len(result))
+		}
+		for i, m := range result {
+			if string(m.Raw) != expected[i] || m.Object != nil {
+				t.Errorf("expected manifest %d %s, got %s", i, expected[i], m.Raw)
+			}
+		}
+	}
+
+	again, err := Canonical(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range again {
+		if string(again[i].Raw) != string(first[i].Raw) {
+			t.Errorf("expected the canonical manifest %d unchanged, got %s", i, again[i].Raw)
+		}
+	}
+
+	for _, invalid := range []string{`{"kind":`, `{"apiVersion":"v1","kind":"ConfigMap"} {}`, `[]`} {
+		if _, err := Canonical([]workapiv1.Manifest{raw(invalid)}); err == nil {
+			t.Errorf("expected %s to fail", invalid)
+		}
+	}
+}
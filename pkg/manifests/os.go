@@ -0,0 +1,113 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// OSLabel is the node label selecting the operating system of the pods
+const OSLabel = "kubernetes.io/os"
+
+// windowsTolerations tolerate the taints commonly set on the Windows node pools
+var windowsTolerations = []corev1.Toleration{
+	{Key: "os", Operator: corev1.TolerationOpEqual, Value: "windows", Effect: corev1.TaintEffectNoSchedule},
+	{Key: "node.kubernetes.io/os", Operator: corev1.TolerationOpEqual, Value: "windows", Effect: corev1.TaintEffectNoSchedule},
+}
+
+// RunsOS returns true if the cluster advertises nodes of the operating system
+func RunsOS(c ClusterContext, os appv1alpha1.OperatingSystem) bool {
+	for _, o := range c.OperatingSystems {
+		if o == string(os) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConstrainOS sets the operating system of the constraint as node selector of the pod
+// specs of the workloads of the manifests, and adds its tolerations
+func ConstrainOS(ms []workapiv1.Manifest, c *appv1alpha1.OSConstraint) ([]workapiv1.Manifest, error) {
+	if c == nil {
+		return ms, nil
+	}
+	tolerations := c.Tolerations
+	if len(tolerations) == 0 && c.Name == "windows" {
+		tolerations = windowsTolerations
+	}
+	added := []interface{}{}
+	for i := range tolerations {
+		t, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&tolerations[i])
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, t)
+	}
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		path, ok := podSpecPaths[u.GetKind()]
+		if !ok {
+			result = append(result, m)
+			continue
+		}
+		selectorPath := append(append([]string{}, path...), "nodeSelector", OSLabel)
+		if err := unstructured.SetNestedField(u.Object, string(c.Name), selectorPath...); err != nil {
+			return nil, err
+		}
+		tolerationsPath := append(append([]string{}, path...), "tolerations")
+		existing, _, err := unstructured.NestedSlice(u.Object, tolerationsPath...)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range added {
+			if !containsValue(existing, t) {
+				existing = append(existing, t)
+			}
+		}
+		if len(existing) > 0 {
+			if err := unstructured.SetNestedSlice(u.Object, existing, tolerationsPath...); err != nil {
+				return nil, err
+			}
+		}
+		updated, err := FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, updated)
+	}
+	return result, nil
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, e := range values {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
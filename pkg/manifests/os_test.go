@@ -0,0 +1,104 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestRunsOS(t *testing.T) {
+	linux := NewClusterContext(&clusterv1.ManagedCluster{})
+	mixed := NewClusterContext(&clusterv1.ManagedCluster{Status: clusterv1.ManagedClusterStatus{
+		ClusterClaims: []clusterv1.ManagedClusterClaim{{Name: OSClaim, Value: "linux, windows"}},
+	}})
+	tests := []struct {
+		c        ClusterContext
+		os       appv1alpha1.OperatingSystem
+		expected bool
+	}{
+		{c: linux, os: "linux", expected: true},
+		{c: linux, os: "windows", expected: false},
+		{c: mixed, os: "windows", expected: true},
+	}
+	for _, tt := range tests {
+		if runs := RunsOS(tt.c, tt.os); runs != tt.expected {
+			t.Errorf("RunsOS(%v, %s) = %t, expected %t", tt.c.OperatingSystems, tt.os, runs, tt.expected)
+		}
+	}
+}
+
+func TestConstrainOS(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      nodeSelector:
+        pool: web
+      tolerations:
+      - key: os
+        operator: Equal
+        value: windows
+        effect: NoSchedule
+      containers:
+      - name: web
+        image: mcr.microsoft.com/dotnet/samples:aspnetapp
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	constrained, err := ConstrainOS(ms, &appv1alpha1.OSConstraint{Name: "windows"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := ToUnstructured(constrained[0])
+	selector, _, _ := unstructured.NestedStringMap(u.Object, "spec", "template", "spec", "nodeSelector")
+	if !reflect.DeepEqual(selector, map[string]string{"pool": "web", OSLabel: "windows"}) {
+		t.Errorf("unexpected node selector %v", selector)
+	}
+	tolerations, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "tolerations")
+	if len(tolerations) != 2 {
+		t.Errorf("expected the 2 windows tolerations, got %v", tolerations)
+	}
+	if !reflect.DeepEqual(constrained[1], ms[1]) {
+		t.Error("expected the config map to be unchanged")
+	}
+
+	constrained, err = ConstrainOS(ms, &appv1alpha1.OSConstraint{Name: "linux", Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ = ToUnstructured(constrained[0])
+	tolerations, _, _ = unstructured.NestedSlice(u.Object, "spec", "template", "spec", "tolerations")
+	if len(tolerations) != 2 || tolerations[1].(map[string]interface{})["key"] != "dedicated" {
+		t.Errorf("expected the dedicated toleration to be added, got %v", tolerations)
+	}
+}
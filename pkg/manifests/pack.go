@@ -0,0 +1,236 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// PackedSuffix is appended to the names of the packed ConfigMaps
+	PackedSuffix = "-packed"
+
+	// PackedConfigMapLabel is set on the packed ConfigMaps and their unpacking Jobs
+	// with the name of the unpacked ConfigMap
+	PackedConfigMapLabel = "cluster.open-cluster-management.io/packed-configmap"
+
+	// packedHashLength is the number of hex characters of the hash suffixing the names
+	// of the unpacking Jobs
+	packedHashLength = 8
+)
+
+// Pack replaces the ConfigMaps of the manifests whose data exceeds size bytes by a
+// ConfigMap holding their gzip compressed data, and a Job running the image, which
+// provides sh, gunzip and kubectl, to unpack it on the managed cluster, with its
+// ServiceAccount, Role and RoleBinding. The unpacking Job is named after the hash of
+// the packed data, so that it runs again when the data changes.
+func Pack(ms []workapiv1.Manifest, size int64, image string) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		if u.GetAPIVersion() != "v1" || u.GetKind() != "ConfigMap" || u.GetNamespace() == "" || dataSize(u) <= size {
+			result = append(result, m)
+			continue
+		}
+		objs, err := pack(u, image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack ConfigMap %s: %w", u.GetName(), err)
+		}
+		for _, obj := range objs {
+			packed, err := FromUnstructured(obj)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, packed)
+		}
+	}
+	return result, nil
+}
+
+// dataSize returns the size of the data of a ConfigMap
+func dataSize(u *unstructured.Unstructured) int64 {
+	size := 0
+	data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+	for k, v := range data {
+		size += len(k) + len(v)
+	}
+	binary, _, _ := unstructured.NestedStringMap(u.Object, "binaryData")
+	for k, v := range binary {
+		size += len(k) + base64.StdEncoding.DecodedLen(len(v))
+	}
+	return int64(size)
+}
+
+// pack returns the packed ConfigMap and the objects unpacking it
+func pack(u *unstructured.Unstructured, image string) ([]*unstructured.Unstructured, error) {
+	name, namespace := u.GetName(), u.GetNamespace()
+	contents := map[string][]byte{}
+	data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+	for k, v := range data {
+		contents[k] = []byte(v)
+	}
+	binary, _, _ := unstructured.NestedStringMap(u.Object, "binaryData")
+	for k, v := range binary {
+		decoded, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, err
+		}
+		contents[k] = decoded
+	}
+	keys := []string{}
+	for k := range contents {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	packed := map[string]interface{}{}
+	for _, k := range keys {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(contents[k]); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		packed[k+".gz"] = base64.StdEncoding.EncodeToString(buf.Bytes())
+		hash.Write([]byte(k))
+		hash.Write(buf.Bytes())
+	}
+	suffix := hex.EncodeToString(hash.Sum(nil))[:packedHashLength]
+
+	labels := map[string]interface{}{PackedConfigMapLabel: name}
+	unpacker := truncate(name, 63-len("-unpack")) + "-unpack"
+	script := unpackScript(u, name+PackedSuffix)
+	return []*unstructured.Unstructured{
+		object("v1", "ConfigMap", name+PackedSuffix, namespace, labels, map[string]interface{}{"binaryData": packed}),
+		object("v1", "ServiceAccount", unpacker, namespace, labels, nil),
+		object("rbac.authorization.k8s.io/v1", "Role", unpacker, namespace, labels, map[string]interface{}{
+			"rules": []interface{}{map[string]interface{}{
+				"apiGroups": []interface{}{""},
+				"resources": []interface{}{"configmaps"},
+				"verbs":     []interface{}{"get", "create", "update", "patch"},
+			}},
+		}),
+		object("rbac.authorization.k8s.io/v1", "RoleBinding", unpacker, namespace, labels, map[string]interface{}{
+			"roleRef": map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": "Role", "name": unpacker},
+			"subjects": []interface{}{map[string]interface{}{
+				"kind": "ServiceAccount", "name": unpacker, "namespace": namespace,
+			}},
+		}),
+		object("batch/v1", "Job", truncate(unpacker, 63-packedHashLength-1)+"-"+suffix, namespace, labels, map[string]interface{}{
+			"spec": map[string]interface{}{
+				"backoffLimit": int64(6),
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": labels},
+					"spec": map[string]interface{}{
+						"serviceAccountName": unpacker,
+						"restartPolicy":      "OnFailure",
+						"containers": []interface{}{map[string]interface{}{
+							"name":         "unpack",
+							"image":        image,
+							"command":      []interface{}{"/bin/sh", "-c", script},
+							"volumeMounts": []interface{}{map[string]interface{}{"name": "packed", "mountPath": "/packed"}},
+						}},
+						"volumes": []interface{}{map[string]interface{}{
+							"name":      "packed",
+							"configMap": map[string]interface{}{"name": name + PackedSuffix},
+						}},
+					},
+				},
+			},
+		}),
+	}, nil
+}
+
+// unpackScript returns the script decompressing the packed files into the ConfigMap,
+// with its labels and annotations, owned by the packed ConfigMap
+func unpackScript(u *unstructured.Unstructured, packed string) string {
+	name, namespace := shellQuote(u.GetName()), shellQuote(u.GetNamespace())
+	lines := []string{
+		"set -e",
+		"mkdir -p /tmp/unpacked",
+		`for f in /packed/*.gz; do gunzip -c "$f" > "/tmp/unpacked/$(basename "$f" .gz)"; done`,
+	}
+	create := fmt.Sprintf("kubectl create configmap %s -n %s --from-file=/tmp/unpacked --dry-run=client -o yaml", name, namespace)
+	if l := keyValues(u.GetLabels()); l != "" {
+		create += " | kubectl label --local -f - -o yaml " + l
+	}
+	if a := keyValues(u.GetAnnotations()); a != "" {
+		create += " | kubectl annotate --local -f - -o yaml " + a
+	}
+	lines = append(lines,
+		create+" | kubectl apply -f -",
+		fmt.Sprintf("uid=$(kubectl get configmap %s -n %s -o jsonpath='{.metadata.uid}')", shellQuote(packed), namespace),
+		fmt.Sprintf(`kubectl patch configmap %s -n %s --type merge -p "{\"metadata\":{\"ownerReferences\":[{\"apiVersion\":\"v1\",\"kind\":\"ConfigMap\",\"name\":\"%s\",\"uid\":\"$uid\"}]}}"`,
+			name, namespace, packed),
+	)
+	return strings.Join(lines, "\n")
+}
+
+// keyValues returns the shell quoted key=value arguments of the map, sorted by key
+func keyValues(m map[string]string) string {
+	args := []string{}
+	for k, v := range m {
+		args = append(args, shellQuote(k+"="+v))
+	}
+	sort.Strings(args)
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes a string for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// truncate returns the first max characters of the name
+func truncate(name string, max int) string {
+	if len(name) > max {
+		return strings.TrimRight(name[:max], "-.")
+	}
+	return name
+}
+
+// object returns an unstructured object with the content merged into its metadata
+func object(apiVersion, kind, name, namespace string, labels map[string]interface{}, content map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    labels,
+		},
+	}}
+	for k, v := range content {
+		u.Object[k] = v
+	}
+	return u
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPack(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: dashboards
+  namespace: monitoring
+  labels:
+    grafana_dashboard: "1"
+data:
+  web.json: '` + strings.Repeat(`{"panels":[]}`, 100) + `'
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: small
+  namespace: monitoring
+data:
+  key: value
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Pack(ms, 1024, "bitnami/kubectl:1.22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := []string{}
+	objs := []*unstructured.Unstructured{}
+	for _, m := range result {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kinds = append(kinds, u.GetKind()+"/"+u.GetName())
+		objs = append(objs, u)
+	}
+	expected := "ConfigMap/dashboards-packed ServiceAccount/dashboards-unpack Role/dashboards-unpack RoleBinding/dashboards-unpack Job/dashboards-unpack-"
+	if got := strings.Join(kinds, " "); !strings.HasPrefix(got, expected) || !strings.HasSuffix(got, " ConfigMap/small") {
+		t.Fatalf("expected the large ConfigMap to be packed, got %s", got)
+	}
+	packed, _, _ := unstructured.NestedStringMap(objs[0].Object, "binaryData")
+	decoded, err := base64.StdEncoding.DecodeString(packed["web.json.gz"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(decoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != strings.Repeat(`{"panels":[]}`, 100) {
+		t.Errorf("expected the packed data to unpack to the original data, got %s", content)
+	}
+
+	again, err := Pack(ms, 1024, "bitnami/kubectl:1.22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again[4].Raw, result[4].Raw) {
+		t.Errorf("expected packing to be deterministic")
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package manifests contains helpers to parse and normalize the manifests
+// distributed by AppBundles
+package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// ParseYAML parses a stream of YAML (or JSON) documents into manifests, skipping
+// empty documents
+func ParseYAML(data []byte) ([]workapiv1.Manifest, error) {
+	manifests := []workapiv1.Manifest{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for i := 0; ; i++ {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse document %d: %w", i, err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		if u.GetKind() == "" || u.GetAPIVersion() == "" {
+			return nil, fmt.Errorf("document %d is missing apiVersion or kind", i)
+		}
+		raw, err := u.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode document %d: %w", i, err)
+		}
+		manifests = append(manifests, workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: raw}})
+	}
+	return manifests, nil
+}
+
+// Normalize expands the manifests holding several resources into one manifest per
+// resource: strings of YAML or JSON documents, JSON arrays and List objects with
+// items, e.g. the output of kubectl get -o json. The other manifests are left
+// unchanged.
+func Normalize(ms []workapiv1.Manifest) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for i, m := range ms {
+		expanded, err := expand(m.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest %d: %w", i, err)
+		}
+		if expanded == nil {
+			result = append(result, m)
+			continue
+		}
+		result = append(result, expanded...)
+	}
+	return result, nil
+}
+
+// expand returns the manifests of a raw manifest holding several resources, or nil if
+// it holds a single resource
+func expand(raw []byte) ([]workapiv1.Manifest, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	switch raw[0] {
+	case '"':
+		var documents string
+		if err := json.Unmarshal(raw, &documents); err != nil {
+			return nil, err
+		}
+		ms, err := ParseYAML([]byte(documents))
+		if err != nil {
+			return nil, err
+		}
+		return Normalize(ms)
+	case '[':
+		items := []json.RawMessage{}
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+		return normalizeItems(items)
+	case '{':
+		list := struct {
+			Kind  string            `json:"kind"`
+			Items []json.RawMessage `json:"items"`
+		}{}
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, err
+		}
+		if !strings.HasSuffix(list.Kind, "List") || list.Items == nil {
+			return nil, nil
+		}
+		return normalizeItems(list.Items)
+	}
+	return nil, fmt.Errorf("expected an object, a list or a string of YAML documents")
+}
+
+// normalizeItems normalizes the items of a JSON array or List
+func normalizeItems(items []json.RawMessage) ([]workapiv1.Manifest, error) {
+	ms := []workapiv1.Manifest{}
+	for i, item := range items {
+		parsed, err := ParseYAML(item)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		ms = append(ms, parsed...)
+	}
+	return Normalize(ms)
+}
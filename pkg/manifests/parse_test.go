@@ -0,0 +1,98 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "single document",
+			data: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n",
+			want: 1,
+		},
+		{
+			name: "multiple documents with empty ones",
+			data: "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: b\n",
+			want: 2,
+		},
+		{
+			name: "json document",
+			data: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}`,
+			want: 1,
+		},
+		{
+			name:    "missing kind",
+			data:    "apiVersion: v1\nmetadata:\n  name: a\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			data:    "apiVersion: v1\nkind: [\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseYAML([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseYAML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("ParseYAML() returned %d manifests, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	configMap := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}`
+	tests := []struct {
+		name    string
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{name: "object", raw: configMap, want: 1},
+		{name: "yaml documents", raw: `"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: b\n"`, want: 2},
+		{name: "json array", raw: "[" + configMap + "," + configMap + "]", want: 2},
+		{name: "list", raw: `{"apiVersion":"v1","kind":"List","items":[` + configMap + `,{"apiVersion":"v1","kind":"List","items":[` + configMap + `]}]}`, want: 2},
+		{name: "item missing kind", raw: `[{"apiVersion":"v1","metadata":{"name":"a"}}]`, wantErr: true},
+		{name: "number", raw: "42", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize([]workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: []byte(tt.raw)}}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Normalize() returned %d manifests, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// PruneAnnotation set to "false" on a manifest keeps its resource on the managed
+// clusters once removed from the bundle, "true" prunes it even when the bundle does
+// not prune by default
+const PruneAnnotation = "cluster.open-cluster-management.io/prune"
+
+// Removed returns the identities of the manifests removed from old in new, split into
+// the pruned ones and the orphaned ones, with the orphaning rules keeping the latter on
+// the managed clusters. prune is the default for the manifests without PruneAnnotation,
+// the manifests of the retained kinds are never pruned.
+func Removed(old, new []workapiv1.Manifest, prune bool, retained []string) (pruned, orphaned []string, rules []workapiv1.OrphaningRule, err error) {
+	retainedKinds := sets.NewString(retained...)
+	kept, err := contentByIdentity(new)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, m := range old {
+		id, err := Identity(m)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if _, ok := kept[id]; ok {
+			continue
+		}
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if retainedKinds.HasAny(KindNames(u.GroupVersionKind())...) || !shouldPrune(u, prune) {
+			orphaned = append(orphaned, id)
+			rules = append(rules, OrphaningRule(u))
+			continue
+		}
+		pruned = append(pruned, id)
+	}
+	sort.Strings(pruned)
+	sort.Strings(orphaned)
+	return pruned, orphaned, rules, nil
+}
+
+func shouldPrune(u *unstructured.Unstructured, prune bool) bool {
+	switch u.GetAnnotations()[PruneAnnotation] {
+	case "false":
+		return false
+	case "true":
+		return true
+	default:
+		return prune
+	}
+}
+
+// Retained returns the orphaning rules of the manifests of the retained kinds, so that
+// their resources are left on the managed clusters when the work is deleted
+func Retained(ms []workapiv1.Manifest, retained []string) ([]workapiv1.OrphaningRule, error) {
+	rules := []workapiv1.OrphaningRule{}
+	if len(retained) == 0 {
+		return rules, nil
+	}
+	retainedKinds := sets.NewString(retained...)
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		if retainedKinds.HasAny(KindNames(u.GroupVersionKind())...) {
+			rules = append(rules, OrphaningRule(u))
+		}
+	}
+	return rules, nil
+}
+
+// KindNames returns the names matching a kind in kind lists: Kind, and Kind.group for
+// the kinds of named groups
+func KindNames(gvk schema.GroupVersionKind) []string {
+	if gvk.Group == "" {
+		return []string{gvk.Kind}
+	}
+	return []string{gvk.Kind, gvk.Kind + "." + gvk.Group}
+}
+
+// OrphaningRule returns the orphaning rule of a resource. The resource is guessed from
+// the kind, the managed clusters being unknown to the hub.
+func OrphaningRule(u *unstructured.Unstructured) workapiv1.OrphaningRule {
+	gvr, _ := meta.UnsafeGuessKindToResource(u.GroupVersionKind())
+	return workapiv1.OrphaningRule{
+		Group:     gvr.Group,
+		Resource:  gvr.Resource,
+		Namespace: u.GetNamespace(),
+		Name:      u.GetName(),
+	}
+}
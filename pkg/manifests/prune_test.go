@@ -0,0 +1,107 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"reflect"
+	"testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func TestRemoved(t *testing.T) {
+	old, err := ParseYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kept
+  namespace: apps
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: apps
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+  namespace: apps
+  annotations:
+    cluster.open-cluster-management.io/prune: "false"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, orphaned, rules, err := Removed(old, old[:1], true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pruned, []string{"apps/Deployment/apps/web"}) {
+		t.Errorf("unexpected pruned %v", pruned)
+	}
+	if !reflect.DeepEqual(orphaned, []string{"/PersistentVolumeClaim/apps/data"}) {
+		t.Errorf("unexpected orphaned %v", orphaned)
+	}
+	want := []workapiv1.OrphaningRule{{Group: "", Resource: "persistentvolumeclaims", Namespace: "apps", Name: "data"}}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	pruned, orphaned, _, err = Removed(old, old[:1], false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 0 || len(orphaned) != 2 {
+		t.Errorf("expected all removed manifests to be orphaned, got pruned %v orphaned %v", pruned, orphaned)
+	}
+
+	pruned, orphaned, _, err = Removed(old, old[:1], true, []string{"Deployment.apps"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 0 || len(orphaned) != 2 {
+		t.Errorf("expected the retained deployment to be orphaned, got pruned %v orphaned %v", pruned, orphaned)
+	}
+}
+
+func TestRetained(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: apps
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: apps
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := Retained(ms, []string{"Secret", "PersistentVolumeClaim"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []workapiv1.OrphaningRule{{Group: "", Resource: "secrets", Namespace: "apps", Name: "creds"}}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+}
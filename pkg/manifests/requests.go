@@ -0,0 +1,122 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// Requests returns the resources requested by the containers of the Deployments,
+// StatefulSets, Jobs and Pods of the manifests, multiplied by their replicas or
+// parallelism. The DaemonSets, whose requests depend on the nodes of the cluster, are
+// not counted.
+func Requests(ms []workapiv1.Manifest) (corev1.ResourceList, error) {
+	total := corev1.ResourceList{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		path, count, err := podTemplate(u)
+		if err != nil {
+			return nil, err
+		}
+		if path == nil {
+			continue
+		}
+		obj, found, err := unstructured.NestedMap(u.Object, path...)
+		if err != nil || !found {
+			continue
+		}
+		spec := corev1.PodSpec{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &spec); err != nil {
+			return nil, fmt.Errorf("invalid pod spec of %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+		for _, c := range spec.Containers {
+			for name, q := range c.Resources.Requests {
+				sum := total[name]
+				sum.Add(*resource.NewMilliQuantity(q.MilliValue()*count, q.Format))
+				total[name] = sum
+			}
+		}
+	}
+	return total, nil
+}
+
+// podTemplate returns the path of the pod spec of a workload and its number of pods,
+// a nil path for the other resources
+func podTemplate(u *unstructured.Unstructured) ([]string, int64, error) {
+	gk := u.GroupVersionKind().GroupKind()
+	field := ""
+	switch {
+	case gk.Group == "" && gk.Kind == "Pod":
+		return []string{"spec"}, 1, nil
+	case gk.Group == "apps" && (gk.Kind == "Deployment" || gk.Kind == "StatefulSet" || gk.Kind == "ReplicaSet"):
+		field = "replicas"
+	case gk.Group == "batch" && gk.Kind == "Job":
+		field = "parallelism"
+	default:
+		return nil, 0, nil
+	}
+	count, found, err := unstructured.NestedInt64(u.Object, "spec", field)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid %s of %s %s: %w", field, u.GetKind(), u.GetName(), err)
+	}
+	if !found {
+		count = 1
+	}
+	return []string{"spec", "template", "spec"}, count, nil
+}
+
+// Fits returns the resources whose requests exceed what is left of the allocatable
+// resources once the claims are subtracted. The resources not reported as allocatable
+// are not checked.
+func Fits(requests corev1.ResourceList, allocatable map[string]resource.Quantity, claims ...corev1.ResourceList) []string {
+	insufficient := []string{}
+	for _, name := range sortedResources(requests) {
+		free, ok := allocatable[string(name)]
+		if !ok {
+			continue
+		}
+		free = free.DeepCopy()
+		for _, c := range claims {
+			if q, ok := c[name]; ok {
+				free.Sub(q)
+			}
+		}
+		if requested := requests[name]; requested.Cmp(free) > 0 {
+			insufficient = append(insufficient, string(name))
+		}
+	}
+	return insufficient
+}
+
+func sortedResources(l corev1.ResourceList) []corev1.ResourceName {
+	names := []corev1.ResourceName{}
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
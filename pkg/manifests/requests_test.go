@@ -0,0 +1,109 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const workloads = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        resources:
+          requests:
+            cpu: 250m
+            memory: 128Mi
+      - name: proxy
+        resources:
+          requests:
+            cpu: 50m
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        resources:
+          requests:
+            memory: 1Gi
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      containers:
+      - name: agent
+        resources:
+          requests:
+            cpu: "1"
+`
+
+func TestRequests(t *testing.T) {
+	ms, err := ParseYAML([]byte(workloads))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests, err := Requests(ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu, memory := requests[corev1.ResourceCPU], requests[corev1.ResourceMemory]
+	if cpu.MilliValue() != 900 {
+		t.Errorf("expected 900m cpu, got %s", cpu.String())
+	}
+	if expected := resource.MustParse("1408Mi"); memory.Cmp(expected) != 0 {
+		t.Errorf("expected 1408Mi memory, got %s", memory.String())
+	}
+}
+
+func TestFits(t *testing.T) {
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("4Gi"),
+		"nvidia.com/gpu":      resource.MustParse("1"),
+	}
+	allocatable := map[string]resource.Quantity{
+		"cpu":    resource.MustParse("8"),
+		"memory": resource.MustParse("16Gi"),
+	}
+	claim := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}
+	if insufficient := Fits(requests, allocatable, claim, claim); len(insufficient) != 0 {
+		t.Errorf("expected the requests to fit, got %v", insufficient)
+	}
+	if insufficient := Fits(requests, allocatable, claim, claim, claim); !reflect.DeepEqual(insufficient, []string{"cpu"}) {
+		t.Errorf("expected cpu to be insufficient, got %v", insufficient)
+	}
+}
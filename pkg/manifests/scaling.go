@@ -0,0 +1,103 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// ScalingRule returns the first rule of the scaling selecting the cluster labels, nil
+// when no rule matches
+func ScalingRule(s *appv1alpha1.Scaling, clusterLabels map[string]string) (*appv1alpha1.ScalingRule, error) {
+	if s == nil {
+		return nil, nil
+	}
+	for i := range s.Rules {
+		selector, err := metav1.LabelSelectorAsSelector(&s.Rules[i].ClusterSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster selector of scaling rule %d: %w", i, err)
+		}
+		if selector.Matches(labels.Set(clusterLabels)) {
+			return &s.Rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Scale sets the replicas of the Deployments and StatefulSets selected by the rule,
+// except the autoscaled ones. The workloads without replicas are scaled from the
+// single replica they default to.
+func Scale(ms []workapiv1.Manifest, rule *appv1alpha1.ScalingRule, autoscaled sets.String) ([]workapiv1.Manifest, error) {
+	if rule == nil {
+		return ms, nil
+	}
+	workloads := sets.NewString(rule.Workloads...)
+	result := []workapiv1.Manifest{}
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		if !isScalable(u) || autoscaled.Has(u.GetName()) || (workloads.Len() > 0 && !workloads.Has(u.GetName())) {
+			result = append(result, m)
+			continue
+		}
+		replicas, found, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+		if err != nil {
+			return nil, fmt.Errorf("invalid replicas of %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+		if !found {
+			replicas = 1
+		}
+		if err := unstructured.SetNestedField(u.Object, scaledReplicas(replicas, rule), "spec", "replicas"); err != nil {
+			return nil, err
+		}
+		if m, err = FromUnstructured(u); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// isScalable returns true for the Deployments and StatefulSets
+func isScalable(u *unstructured.Unstructured) bool {
+	gk := u.GroupVersionKind().GroupKind()
+	return gk.Group == "apps" && (gk.Kind == "Deployment" || gk.Kind == "StatefulSet")
+}
+
+// scaledReplicas returns the replicas set by the rule, the multiplied replicas being
+// rounded up
+func scaledReplicas(replicas int64, rule *appv1alpha1.ScalingRule) int64 {
+	switch {
+	case rule.Replicas != nil:
+		return int64(*rule.Replicas)
+	case rule.Multiplier != nil:
+		milli := replicas * rule.Multiplier.MilliValue()
+		return (milli + 999) / 1000
+	default:
+		return replicas
+	}
+}
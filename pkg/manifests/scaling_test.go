@@ -0,0 +1,100 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestScale(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := resource.MustParse("0.5")
+	one, five := int32(1), int32(5)
+	scaling := &appv1alpha1.Scaling{Rules: []appv1alpha1.ScalingRule{
+		{ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"size": "edge"}}, Replicas: &one},
+		{ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"size": "small"}}, Multiplier: &half},
+		{ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"size": "regional"}}, Workloads: []string{"web"}, Replicas: &five},
+	}}
+	replicas := func(m []byte) int64 {
+		u := &unstructured.Unstructured{}
+		if err := u.UnmarshalJSON(m); err != nil {
+			t.Fatal(err)
+		}
+		r, found, _ := unstructured.NestedInt64(u.Object, "spec", "replicas")
+		if !found {
+			return -1
+		}
+		return r
+	}
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		web, db, cm int64
+	}{
+		{"replaced", map[string]string{"size": "edge"}, 1, -1, -1},
+		{"multiplied", map[string]string{"size": "small"}, 2, -1, -1},
+		{"selected workloads", map[string]string{"size": "regional"}, 5, -1, -1},
+		{"no match", map[string]string{"size": "large"}, 3, -1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ScalingRule(scaling, tt.labels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := Scale(ms, rule, sets.NewString("db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := replicas(result[0].Raw); got != tt.web {
+				t.Errorf("expected %d web replicas, got %d", tt.web, got)
+			}
+			if got := replicas(result[1].Raw); got != tt.db {
+				t.Errorf("expected %d db replicas, got %d", tt.db, got)
+			}
+			if got := replicas(result[2].Raw); got != tt.cm {
+				t.Errorf("expected the config map unchanged, got %d replicas", got)
+			}
+		})
+	}
+}
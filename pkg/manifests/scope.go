@@ -0,0 +1,133 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// clusterScopedKinds lists the well-known cluster-scoped kinds, by group and kind
+var clusterScopedKinds = map[schema.GroupKind]bool{
+	{Group: "", Kind: "Namespace"}:                                                  true,
+	{Group: "", Kind: "Node"}:                                                       true,
+	{Group: "", Kind: "PersistentVolume"}:                                           true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                       true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                true,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:               true,
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: true,
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}:                           true,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                 true,
+	{Group: "storage.k8s.io", Kind: "CSIDriver"}:                                    true,
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                             true,
+	{Group: "networking.k8s.io", Kind: "IngressClass"}:                              true,
+	{Group: "policy", Kind: "PodSecurityPolicy"}:                                    true,
+}
+
+// IsClusterScoped returns true for the well-known cluster-scoped kinds. Kinds not
+// known, e.g. of custom resources, are assumed namespaced.
+func IsClusterScoped(gk schema.GroupKind) bool {
+	return clusterScopedKinds[gk]
+}
+
+// ValidateScopes checks that the well-known cluster-scoped resources of the manifests
+// do not set a namespace, which the work agent would fail to apply
+func ValidateScopes(ms []workapiv1.Manifest) error {
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return err
+		}
+		if u.GetNamespace() != "" && IsClusterScoped(u.GroupVersionKind().GroupKind()) {
+			return fmt.Errorf("%s %s is cluster-scoped and cannot set namespace %s", u.GetKind(), u.GetName(), u.GetNamespace())
+		}
+	}
+	return nil
+}
+
+// SetNamespace moves the namespaced resources of the manifests to the namespace, and
+// the service account subjects of the role bindings to the service accounts moved with
+// them. Cluster-scoped resources are never moved. It fails when the manifests have no
+// namespaced resources, as the namespace would have no effect.
+func SetNamespace(ms []workapiv1.Manifest, namespace string) ([]workapiv1.Manifest, error) {
+	objs := []*unstructured.Unstructured{}
+	serviceAccounts := sets.NewString()
+	namespaced := 0
+	for _, m := range ms {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, u)
+		gk := u.GroupVersionKind().GroupKind()
+		if IsClusterScoped(gk) {
+			continue
+		}
+		namespaced++
+		if gk.Group == "" && gk.Kind == "ServiceAccount" {
+			serviceAccounts.Insert(u.GetNamespace() + "/" + u.GetName())
+		}
+	}
+	if namespaced == 0 {
+		return nil, fmt.Errorf("target namespace %s has no effect, the manifests only define cluster-scoped resources", namespace)
+	}
+
+	result := []workapiv1.Manifest{}
+	for _, u := range objs {
+		gvk := u.GroupVersionKind()
+		if !IsClusterScoped(gvk.GroupKind()) {
+			u.SetNamespace(namespace)
+		}
+		if gvk.Group == "rbac.authorization.k8s.io" && (gvk.Kind == "RoleBinding" || gvk.Kind == "ClusterRoleBinding") {
+			if err := moveSubjects(u, serviceAccounts, namespace); err != nil {
+				return nil, err
+			}
+		}
+		m, err := FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// moveSubjects moves the service account subjects of a binding referencing the moved
+// service accounts to the namespace
+func moveSubjects(u *unstructured.Unstructured, serviceAccounts sets.String, namespace string) error {
+	subjects, _, err := unstructured.NestedSlice(u.Object, "subjects")
+	if err != nil || subjects == nil {
+		return err
+	}
+	for _, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok || subject["kind"] != "ServiceAccount" {
+			continue
+		}
+		ns, _ := subject["namespace"].(string)
+		name, _ := subject["name"].(string)
+		if serviceAccounts.Has(ns + "/" + name) {
+			subject["namespace"] = namespace
+		}
+	}
+	return unstructured.SetNestedSlice(u.Object, subjects, "subjects")
+}
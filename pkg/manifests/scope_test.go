@@ -0,0 +1,128 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSetNamespace(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+  namespace: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: web
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+- kind: ServiceAccount
+  name: web
+  namespace: web
+- kind: ServiceAccount
+  name: monitor
+  namespace: web
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := SetNamespace(ms, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := []*unstructured.Unstructured{}
+	for _, m := range result {
+		u, err := ToUnstructured(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, u)
+	}
+	if objs[0].GetNamespace() != "" || objs[0].GetName() != "web" {
+		t.Errorf("expected the namespace to be left unchanged, got %s/%s", objs[0].GetNamespace(), objs[0].GetName())
+	}
+	for _, u := range objs[1:3] {
+		if u.GetNamespace() != "shop" {
+			t.Errorf("expected %s to move to the target namespace, got %s", u.GetKind(), u.GetNamespace())
+		}
+	}
+	if objs[3].GetNamespace() != "" {
+		t.Errorf("expected the cluster role binding to stay cluster-scoped, got %s", objs[3].GetNamespace())
+	}
+	subjects, _, _ := unstructured.NestedSlice(objs[3].Object, "subjects")
+	if ns := subjects[0].(map[string]interface{})["namespace"]; ns != "shop" {
+		t.Errorf("expected the subject to follow the service account, got %v", ns)
+	}
+	if ns := subjects[1].(map[string]interface{})["namespace"]; ns != "web" {
+		t.Errorf("expected the subject not in the manifests to be unchanged, got %v", ns)
+	}
+}
+
+func TestSetNamespaceClusterScoped(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: widgets
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetNamespace(ms, "shop"); err == nil {
+		t.Error("expected a target namespace without namespaced resources to fail")
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	ms, err := ParseYAML([]byte(`apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: widgets
+  namespace: shop
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateScopes(ms); err == nil {
+		t.Error("expected a namespaced cluster role to fail")
+	}
+	if err := ValidateScopes(ms[:0]); err != nil {
+		t.Error(err)
+	}
+}
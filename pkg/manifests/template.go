@@ -0,0 +1,88 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// TemplateVersion returns the version of the template, the latest one when the
+// version is empty
+func TemplateVersion(t *appv1alpha1.AppBundleTemplate, version string) (*appv1alpha1.AppBundleTemplateVersion, error) {
+	versions := t.Spec.Versions
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("template %s has no version", t.Name)
+	}
+	if version == "" {
+		return &versions[len(versions)-1], nil
+	}
+	for i := range versions {
+		if versions[i].Version == version {
+			return &versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("template %s has no version %s", t.Name, version)
+}
+
+// RenderTemplate executes the manifests of the template version with the parameters,
+// the parameters not set taking their default value. Missing required parameters,
+// unknown parameters and templates referencing undeclared parameters fail.
+func RenderTemplate(v *appv1alpha1.AppBundleTemplateVersion, params map[string]string) ([]workapiv1.Manifest, error) {
+	values := map[string]string{}
+	missing := []string{}
+	for _, p := range v.Parameters {
+		value, ok := params[p.Name]
+		switch {
+		case ok:
+			values[p.Name] = value
+		case p.Required:
+			missing = append(missing, p.Name)
+		default:
+			values[p.Name] = p.Default
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required parameters %s", strings.Join(missing, ","))
+	}
+	unknown := []string{}
+	for name := range params {
+		if _, ok := values[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown parameters %s", strings.Join(unknown, ","))
+	}
+	tmpl, err := template.New(v.Version).Option("missingkey=error").Parse(v.Manifests)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, struct{ Params map[string]string }{values}); err != nil {
+		return nil, err
+	}
+	return ParseYAML(b.Bytes())
+}
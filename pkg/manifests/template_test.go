@@ -0,0 +1,73 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl := &appv1alpha1.AppBundleTemplate{Spec: appv1alpha1.AppBundleTemplateSpec{Versions: []appv1alpha1.AppBundleTemplateVersion{
+		{Version: "1.0.0", Manifests: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web`},
+		{Version: "1.1.0", Parameters: []appv1alpha1.TemplateParameter{
+			{Name: "name", Required: true},
+			{Name: "replicas", Default: "2"},
+		}, Manifests: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Params.name }}
+spec:
+  replicas: {{ .Params.replicas }}`},
+	}}}
+
+	latest, err := TemplateVersion(tmpl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Version != "1.1.0" {
+		t.Errorf("expected the latest version, got %s", latest.Version)
+	}
+	if _, err := TemplateVersion(tmpl, "2.0.0"); err == nil {
+		t.Errorf("expected an unknown version to fail")
+	}
+
+	ms, err := RenderTemplate(latest, map[string]string{"name": "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := ToUnstructured(ms[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas, _, _ := unstructured.NestedInt64(u.Object, "spec", "replicas")
+	if u.GetName() != "shop" || replicas != 2 {
+		t.Errorf("expected the parameters and defaults to be applied, got %s with %d replicas", u.GetName(), replicas)
+	}
+	if _, err := RenderTemplate(latest, nil); err == nil {
+		t.Errorf("expected a missing required parameter to fail")
+	}
+	if _, err := RenderTemplate(latest, map[string]string{"name": "shop", "image": "web"}); err == nil {
+		t.Errorf("expected an unknown parameter to fail")
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+var deploymentInfoDesc = prometheus.NewDesc("kealm_bundle_deployment_info",
+	"Generation and content digest of the bundles distributed to each cluster, always 1.",
+	[]string{"namespace", "bundle", "cluster", "generation", "digest"}, nil)
+
+type deployment struct {
+	generation int64
+	clusters   []appv1alpha1.ClusterStatus
+}
+
+// DeploymentInfo exports the generation and digest of the bundles deployed on each
+// cluster, to join federated workload metrics with. A nil DeploymentInfo is valid and
+// records nothing.
+type DeploymentInfo struct {
+	mu          sync.Mutex
+	deployments map[types.NamespacedName]deployment
+}
+
+// NewDeploymentInfo returns a collector to be registered with the metrics registry
+func NewDeploymentInfo() *DeploymentInfo {
+	return &DeploymentInfo{deployments: map[types.NamespacedName]deployment{}}
+}
+
+// Set records the clusters a bundle is deployed on
+func (d *DeploymentInfo) Set(bundle *appv1alpha1.AppBundle) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	name := types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name}
+	d.deployments[name] = deployment{
+		generation: bundle.Generation,
+		clusters:   append([]appv1alpha1.ClusterStatus{}, bundle.Status.Clusters...),
+	}
+}
+
+// Forget drops a deleted bundle
+func (d *DeploymentInfo) Forget(name types.NamespacedName) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.deployments, name)
+}
+
+// Describe implements prometheus.Collector
+func (d *DeploymentInfo) Describe(ch chan<- *prometheus.Desc) {
+	ch <- deploymentInfoDesc
+}
+
+// Collect implements prometheus.Collector
+func (d *DeploymentInfo) Collect(ch chan<- prometheus.Metric) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for name, dep := range d.deployments {
+		for _, c := range dep.clusters {
+			ch <- prometheus.MustNewConstMetric(deploymentInfoDesc, prometheus.GaugeValue, 1,
+				name.Namespace, name.Name, c.ClusterName, strconv.FormatInt(dep.generation, 10), c.Digest)
+		}
+	}
+}
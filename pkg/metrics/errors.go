@@ -0,0 +1,26 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// BundleErrors counts the faults of the reconciles of the bundles, by reason and by
+// category, user or system
+var BundleErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kealm_bundle_errors_total",
+	Help: "Faults of the reconciles of the bundles, by reason and category (user or system).",
+}, []string{"reason", "category"})
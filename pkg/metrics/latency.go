@@ -0,0 +1,128 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// PhaseApplied is the latency from the write of the work on the hub until the
+	// agent applies it
+	PhaseApplied = "applied"
+	// PhaseAvailable is the latency from the work being applied until its resources
+	// are available
+	PhaseAvailable = "available"
+)
+
+type latencyKey struct {
+	bundle  types.NamespacedName
+	cluster string
+	phase   string
+}
+
+// ApplyLatency exports histograms of the latencies of the clusters applying the works
+// of the bundles, computed from the transition times of the work conditions. Each write
+// of the content of a work is observed at most once per phase. A nil ApplyLatency is
+// valid and records nothing.
+type ApplyLatency struct {
+	mu        sync.Mutex
+	histogram *prometheus.HistogramVec
+	// observed records the write observed last for each phase of a work
+	observed map[latencyKey]time.Time
+}
+
+// NewApplyLatency returns a collector to be registered with the metrics registry
+func NewApplyLatency() *ApplyLatency {
+	return &ApplyLatency{
+		histogram: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kealm_bundle_apply_latency_seconds",
+			Help:    "Latency of the clusters applying the works of the bundles, from the hub write to Applied and from Applied to Available.",
+			Buckets: []float64{1, 2, 5, 10, 30, 60, 120, 300, 600, 1800},
+		}, []string{"namespace", "bundle", "cluster", "phase"}),
+		observed: map[latencyKey]time.Time{},
+	}
+}
+
+// Observe records the latencies of the work of a bundle on a cluster whose content was
+// written at the given time. The conditions transitioned before the write, e.g. a work
+// staying Applied across an update, are not observed.
+func (l *ApplyLatency) Observe(bundle types.NamespacedName, cluster string, written time.Time, conditions []metav1.Condition) {
+	if l == nil || written.IsZero() {
+		return
+	}
+	applied := transitionedAfter(conditions, workapiv1.WorkApplied, written)
+	if applied == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.observe(latencyKey{bundle, cluster, PhaseApplied}, written, applied.Sub(written))
+	if available := transitionedAfter(conditions, workapiv1.WorkAvailable, *applied); available != nil {
+		l.observe(latencyKey{bundle, cluster, PhaseAvailable}, written, available.Sub(*applied))
+	}
+}
+
+func (l *ApplyLatency) observe(key latencyKey, written time.Time, latency time.Duration) {
+	if l.observed[key].Equal(written) {
+		return
+	}
+	l.observed[key] = written
+	l.histogram.WithLabelValues(key.bundle.Namespace, key.bundle.Name, key.cluster, key.phase).Observe(latency.Seconds())
+}
+
+// transitionedAfter returns the transition time of the condition when it is true and
+// transitioned at or after the given time
+func transitionedAfter(conditions []metav1.Condition, conditionType string, after time.Time) *time.Time {
+	cond := meta.FindStatusCondition(conditions, conditionType)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.LastTransitionTime.Time.Before(after) {
+		return nil
+	}
+	return &cond.LastTransitionTime.Time
+}
+
+// Forget drops the histograms of a deleted bundle
+func (l *ApplyLatency) Forget(bundle types.NamespacedName) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key := range l.observed {
+		if key.bundle == bundle {
+			l.histogram.DeleteLabelValues(bundle.Namespace, bundle.Name, key.cluster, key.phase)
+			delete(l.observed, key)
+		}
+	}
+}
+
+// Describe implements prometheus.Collector
+func (l *ApplyLatency) Describe(ch chan<- *prometheus.Desc) {
+	l.histogram.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (l *ApplyLatency) Collect(ch chan<- prometheus.Metric) {
+	l.histogram.Collect(ch)
+}
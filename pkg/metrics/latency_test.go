@@ -0,0 +1,86 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func condition(conditionType string, at time.Time) metav1.Condition {
+	return metav1.Condition{Type: conditionType, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(at)}
+}
+
+func TestApplyLatency(t *testing.T) {
+	written := time.Unix(1000, 0)
+	bundle := types.NamespacedName{Namespace: "ns", Name: "web"}
+	conditions := []metav1.Condition{
+		condition(workapiv1.WorkApplied, written.Add(3*time.Second)),
+		condition(workapiv1.WorkAvailable, written.Add(43*time.Second)),
+	}
+	latency := NewApplyLatency()
+	latency.Observe(bundle, "cluster1", written, conditions)
+	// observed once per write
+	latency.Observe(bundle, "cluster1", written, conditions)
+	// applied before the write, e.g. an update of a work staying Applied
+	latency.Observe(bundle, "cluster2", written.Add(time.Hour), conditions)
+
+	expected := `
+# HELP kealm_bundle_apply_latency_seconds Latency of the clusters applying the works of the bundles, from the hub write to Applied and from Applied to Available.
+# TYPE kealm_bundle_apply_latency_seconds histogram
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="1"} 0
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="2"} 0
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="5"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="10"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="30"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="60"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="120"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="300"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="600"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="1800"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="applied",le="+Inf"} 1
+kealm_bundle_apply_latency_seconds_sum{bundle="web",cluster="cluster1",namespace="ns",phase="applied"} 3
+kealm_bundle_apply_latency_seconds_count{bundle="web",cluster="cluster1",namespace="ns",phase="applied"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="1"} 0
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="2"} 0
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="5"} 0
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="10"} 0
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="30"} 0
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="60"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="120"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="300"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="600"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="1800"} 1
+kealm_bundle_apply_latency_seconds_bucket{bundle="web",cluster="cluster1",namespace="ns",phase="available",le="+Inf"} 1
+kealm_bundle_apply_latency_seconds_sum{bundle="web",cluster="cluster1",namespace="ns",phase="available"} 40
+kealm_bundle_apply_latency_seconds_count{bundle="web",cluster="cluster1",namespace="ns",phase="available"} 1
+`
+	if err := testutil.CollectAndCompare(latency, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	latency.Forget(bundle)
+	if n := testutil.CollectAndCount(latency); n != 0 {
+		t.Errorf("expected the histograms of the bundle to be dropped, got %d", n)
+	}
+}
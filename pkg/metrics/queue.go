@@ -0,0 +1,252 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exports the controller specific prometheus metrics
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	placementDepthDesc = prometheus.NewDesc("kealm_placement_queue_depth",
+		"Number of bundles of a placement waiting to be reconciled.",
+		[]string{"namespace", "placement"}, nil)
+	placementOldestDesc = prometheus.NewDesc("kealm_placement_oldest_unreconciled_seconds",
+		"Age of the oldest pending change of the bundles of a placement.",
+		[]string{"namespace", "placement"}, nil)
+	bundleEventsDesc = prometheus.NewDesc("kealm_bundle_queued_events",
+		"Number of events coalesced into the pending reconcile of a bundle.",
+		[]string{"namespace", "bundle", "placement"}, nil)
+	bundleAgeDesc = prometheus.NewDesc("kealm_bundle_unreconciled_seconds",
+		"Age of the oldest pending change of a bundle.",
+		[]string{"namespace", "bundle", "placement"}, nil)
+)
+
+type pending struct {
+	since  time.Time
+	events int
+}
+
+// QueueTracker follows the bundles waiting in the work queue of the controller, to
+// report the queue depth and wait time per placement and per bundle. A nil tracker is
+// valid and records nothing.
+type QueueTracker struct {
+	mu         sync.Mutex
+	pending    map[types.NamespacedName]*pending
+	placements map[types.NamespacedName]string
+	requeues   *prometheus.CounterVec
+	now        func() time.Time
+}
+
+// NewQueueTracker returns a tracker to be registered with the metrics registry
+func NewQueueTracker() *QueueTracker {
+	return &QueueTracker{
+		pending:    map[types.NamespacedName]*pending{},
+		placements: map[types.NamespacedName]string{},
+		requeues: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kealm_bundle_requeues_total",
+			Help: "Number of times the reconcile of a bundle was requeued, after an error or a requested retry.",
+		}, []string{"namespace", "bundle", "placement"}),
+		now: time.Now,
+	}
+}
+
+// Enqueued records a change waiting for the reconcile of a bundle
+func (t *QueueTracker) Enqueued(name types.NamespacedName) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.pending[name]
+	if !ok {
+		p = &pending{since: t.now()}
+		t.pending[name] = p
+	}
+	p.events++
+}
+
+// Dequeued records the start of the reconcile of a bundle
+func (t *QueueTracker) Dequeued(name types.NamespacedName) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, name)
+}
+
+// Requeued records a bundle put back in the queue by its reconcile
+func (t *QueueTracker) Requeued(name types.NamespacedName) {
+	if t == nil {
+		return
+	}
+	t.Enqueued(name)
+	t.mu.Lock()
+	placement := t.placements[name]
+	t.mu.Unlock()
+	t.requeues.WithLabelValues(name.Namespace, name.Name, placement).Inc()
+}
+
+// SetPlacement records the placement of a bundle
+func (t *QueueTracker) SetPlacement(name types.NamespacedName, placement string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.placements[name]; ok && old != placement {
+		t.requeues.DeleteLabelValues(name.Namespace, name.Name, old)
+	}
+	t.placements[name] = placement
+}
+
+// Forget drops a deleted bundle
+func (t *QueueTracker) Forget(name types.NamespacedName) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, name)
+	if placement, ok := t.placements[name]; ok {
+		t.requeues.DeleteLabelValues(name.Namespace, name.Name, placement)
+		delete(t.placements, name)
+	}
+}
+
+// Handler wraps an event handler to record the requests it adds to the queue
+func (t *QueueTracker) Handler(h handler.EventHandler) handler.EventHandler {
+	if t == nil {
+		return h
+	}
+	return &trackingHandler{tracker: t, handler: h}
+}
+
+// Observer returns an event handler recording the changes to the bundles without
+// enqueuing them, for the bundles enqueued by the controller itself
+func (t *QueueTracker) Observer(placementLabel string) handler.EventHandler {
+	observe := func(obj client.Object) {
+		name := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+		t.SetPlacement(name, obj.GetLabels()[placementLabel])
+		t.Enqueued(name)
+	}
+	return handler.Funcs{
+		CreateFunc:  func(e event.CreateEvent, _ workqueue.RateLimitingInterface) { observe(e.Object) },
+		UpdateFunc:  func(e event.UpdateEvent, _ workqueue.RateLimitingInterface) { observe(e.ObjectNew) },
+		DeleteFunc:  func(e event.DeleteEvent, _ workqueue.RateLimitingInterface) { observe(e.Object) },
+		GenericFunc: func(e event.GenericEvent, _ workqueue.RateLimitingInterface) { observe(e.Object) },
+	}
+}
+
+// Describe implements prometheus.Collector
+func (t *QueueTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- placementDepthDesc
+	ch <- placementOldestDesc
+	ch <- bundleEventsDesc
+	ch <- bundleAgeDesc
+	t.requeues.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (t *QueueTracker) Collect(ch chan<- prometheus.Metric) {
+	type placementKey struct{ namespace, name string }
+	depth := map[placementKey]int{}
+	oldest := map[placementKey]time.Duration{}
+
+	t.mu.Lock()
+	now := t.now()
+	for name, p := range t.pending {
+		placement := t.placements[name]
+		age := now.Sub(p.since)
+		ch <- prometheus.MustNewConstMetric(bundleEventsDesc, prometheus.GaugeValue, float64(p.events),
+			name.Namespace, name.Name, placement)
+		ch <- prometheus.MustNewConstMetric(bundleAgeDesc, prometheus.GaugeValue, age.Seconds(),
+			name.Namespace, name.Name, placement)
+		key := placementKey{name.Namespace, placement}
+		depth[key]++
+		if age > oldest[key] {
+			oldest[key] = age
+		}
+	}
+	t.mu.Unlock()
+
+	for key, d := range depth {
+		ch <- prometheus.MustNewConstMetric(placementDepthDesc, prometheus.GaugeValue, float64(d),
+			key.namespace, key.name)
+		ch <- prometheus.MustNewConstMetric(placementOldestDesc, prometheus.GaugeValue, oldest[key].Seconds(),
+			key.namespace, key.name)
+	}
+	t.requeues.Collect(ch)
+}
+
+type trackingHandler struct {
+	tracker *QueueTracker
+	handler handler.EventHandler
+}
+
+func (h *trackingHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Create(e, &trackingQueue{RateLimitingInterface: q, tracker: h.tracker})
+}
+
+func (h *trackingHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Update(e, &trackingQueue{RateLimitingInterface: q, tracker: h.tracker})
+}
+
+func (h *trackingHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Delete(e, &trackingQueue{RateLimitingInterface: q, tracker: h.tracker})
+}
+
+func (h *trackingHandler) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Generic(e, &trackingQueue{RateLimitingInterface: q, tracker: h.tracker})
+}
+
+// trackingQueue records the requests added to the wrapped queue
+type trackingQueue struct {
+	workqueue.RateLimitingInterface
+	tracker *QueueTracker
+}
+
+func (q *trackingQueue) Add(item interface{}) {
+	q.record(item)
+	q.RateLimitingInterface.Add(item)
+}
+
+func (q *trackingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.record(item)
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
+
+func (q *trackingQueue) AddRateLimited(item interface{}) {
+	q.record(item)
+	q.RateLimitingInterface.AddRateLimited(item)
+}
+
+func (q *trackingQueue) record(item interface{}) {
+	if req, ok := item.(reconcile.Request); ok {
+		q.tracker.Enqueued(req.NamespacedName)
+	}
+}
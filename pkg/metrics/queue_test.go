@@ -0,0 +1,80 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestQueueTracker(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tracker := NewQueueTracker()
+	tracker.now = func() time.Time { return now }
+
+	a := types.NamespacedName{Namespace: "ns", Name: "a"}
+	b := types.NamespacedName{Namespace: "ns", Name: "b"}
+	tracker.SetPlacement(a, "p1")
+	tracker.SetPlacement(b, "p1")
+
+	tracker.Enqueued(a)
+	now = now.Add(10 * time.Second)
+	tracker.Enqueued(a)
+	tracker.Enqueued(b)
+	now = now.Add(5 * time.Second)
+
+	expected := `
+# HELP kealm_placement_oldest_unreconciled_seconds Age of the oldest pending change of the bundles of a placement.
+# TYPE kealm_placement_oldest_unreconciled_seconds gauge
+kealm_placement_oldest_unreconciled_seconds{namespace="ns",placement="p1"} 15
+# HELP kealm_placement_queue_depth Number of bundles of a placement waiting to be reconciled.
+# TYPE kealm_placement_queue_depth gauge
+kealm_placement_queue_depth{namespace="ns",placement="p1"} 2
+# HELP kealm_bundle_queued_events Number of events coalesced into the pending reconcile of a bundle.
+# TYPE kealm_bundle_queued_events gauge
+kealm_bundle_queued_events{bundle="a",namespace="ns",placement="p1"} 2
+kealm_bundle_queued_events{bundle="b",namespace="ns",placement="p1"} 1
+`
+	if err := testutil.CollectAndCompare(tracker, strings.NewReader(expected),
+		"kealm_placement_oldest_unreconciled_seconds", "kealm_placement_queue_depth", "kealm_bundle_queued_events"); err != nil {
+		t.Error(err)
+	}
+
+	tracker.Dequeued(a)
+	tracker.Requeued(b)
+	tracker.Dequeued(b)
+	expected = `
+# HELP kealm_bundle_requeues_total Number of times the reconcile of a bundle was requeued, after an error or a requested retry.
+# TYPE kealm_bundle_requeues_total counter
+kealm_bundle_requeues_total{bundle="b",namespace="ns",placement="p1"} 1
+# HELP kealm_placement_queue_depth Number of bundles of a placement waiting to be reconciled.
+# TYPE kealm_placement_queue_depth gauge
+`
+	if err := testutil.CollectAndCompare(tracker, strings.NewReader(expected),
+		"kealm_bundle_requeues_total", "kealm_placement_queue_depth"); err != nil {
+		t.Error(err)
+	}
+
+	tracker.Forget(b)
+	if n := testutil.CollectAndCount(tracker, "kealm_bundle_requeues_total"); n != 0 {
+		t.Errorf("expected no requeue series after forget, got %d", n)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/pdettori/kealm/pkg/slo"
+)
+
+// RolloutSLO exports the rollout SLI, the error budget burn rate and the violation of
+// the SLO by the latest rollout of each bundle, and its clusters by outcome. A nil RolloutSLO is valid and
+// records nothing.
+type RolloutSLO struct {
+	sli      *prometheus.GaugeVec
+	burnRate *prometheus.GaugeVec
+	violated *prometheus.GaugeVec
+	clusters *prometheus.GaugeVec
+}
+
+// NewRolloutSLO returns a collector to be registered with the metrics registry
+func NewRolloutSLO() *RolloutSLO {
+	labels := []string{"namespace", "bundle"}
+	return &RolloutSLO{
+		sli: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kealm_bundle_rollout_sli",
+			Help: "Fraction of the clusters of the latest rollout of a bundle Available within the SLO window.",
+		}, labels),
+		burnRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kealm_bundle_rollout_slo_burn_rate",
+			Help: "Rate the latest rollout of a bundle consumes the error budget of the rollout SLO, 1 exhausting it.",
+		}, labels),
+		violated: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kealm_bundle_rollout_slo_violated",
+			Help: "Whether the latest rollout of a bundle violates the rollout SLO, 1 when violated.",
+		}, labels),
+		clusters: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kealm_bundle_rollout_clusters",
+			Help: "Clusters of the latest rollout of a bundle, by result: good, bad or pending.",
+		}, append(labels, "result")),
+	}
+}
+
+// Set records the evaluation of the latest rollout of a bundle
+func (s *RolloutSLO) Set(bundle types.NamespacedName, r slo.Result, o slo.Objective) {
+	if s == nil {
+		return
+	}
+	s.sli.WithLabelValues(bundle.Namespace, bundle.Name).Set(r.SLI())
+	s.burnRate.WithLabelValues(bundle.Namespace, bundle.Name).Set(r.BurnRate(o))
+	violated := 0.0
+	if r.Violated(o) {
+		violated = 1
+	}
+	s.violated.WithLabelValues(bundle.Namespace, bundle.Name).Set(violated)
+	for result, n := range map[string]int{"good": r.Good, "bad": r.Bad, "pending": r.Pending} {
+		s.clusters.WithLabelValues(bundle.Namespace, bundle.Name, result).Set(float64(n))
+	}
+}
+
+// Forget drops a deleted bundle, or a bundle whose rollouts are no longer evaluated
+func (s *RolloutSLO) Forget(bundle types.NamespacedName) {
+	if s == nil {
+		return
+	}
+	s.sli.DeleteLabelValues(bundle.Namespace, bundle.Name)
+	s.burnRate.DeleteLabelValues(bundle.Namespace, bundle.Name)
+	s.violated.DeleteLabelValues(bundle.Namespace, bundle.Name)
+	for _, result := range []string{"good", "bad", "pending"} {
+		s.clusters.DeleteLabelValues(bundle.Namespace, bundle.Name, result)
+	}
+}
+
+// Describe implements prometheus.Collector
+func (s *RolloutSLO) Describe(ch chan<- *prometheus.Desc) {
+	s.sli.Describe(ch)
+	s.burnRate.Describe(ch)
+	s.violated.Describe(ch)
+	s.clusters.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (s *RolloutSLO) Collect(ch chan<- prometheus.Metric) {
+	s.sli.Collect(ch)
+	s.burnRate.Collect(ch)
+	s.violated.Collect(ch)
+	s.clusters.Collect(ch)
+}
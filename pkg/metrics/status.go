@@ -0,0 +1,27 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// StatusWrites counts the status updates of the bundles by outcome: written right away,
+// deferred to be coalesced with the next ones, flushed once deferred, failed to flush
+// and deferred again, or skipped as unchanged
+var StatusWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kealm_bundle_status_writes_total",
+	Help: "Status updates of the bundles, by outcome (written, deferred, flushed, failed or skipped).",
+}, []string{"outcome"})
@@ -0,0 +1,133 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrate converts existing ManifestWorks to AppBundles and Placements
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/controllers"
+)
+
+// ClusterNameLabel is the label of the managed clusters holding their name, used to
+// select the clusters of the generated placements
+const ClusterNameLabel = "name"
+
+// Result is the AppBundle and Placement replacing the works with the same name
+type Result struct {
+	Bundle    *appv1alpha1.AppBundle
+	Placement *clusterapiv1alpha1.Placement
+	Clusters  []string
+}
+
+// FromManifestWorks groups the works by name and returns an AppBundle in namespace
+// for each group, with a Placement selecting the clusters of the works. The bundles
+// adopt the works, so that they replace them without restarting the workload. Works
+// owned by a bundle are skipped, groups whose works differ are returned as errors.
+func FromManifestWorks(works []workapiv1.ManifestWork, namespace string) ([]Result, []error) {
+	groups := map[string][]workapiv1.ManifestWork{}
+	for _, w := range works {
+		if _, owned := w.Labels[controllers.OwnedLabel]; owned {
+			continue
+		}
+		groups[w.Name] = append(groups[w.Name], w)
+	}
+	names := []string{}
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := []Result{}
+	errs := []error{}
+	for _, name := range names {
+		group := groups[name]
+		spec := group[0].Spec
+		clusters := []string{}
+		differs := []string{}
+		for _, w := range group {
+			clusters = append(clusters, w.Namespace)
+			if !equality.Semantic.DeepEqual(w.Spec, spec) {
+				differs = append(differs, w.Namespace)
+			}
+		}
+		sort.Strings(clusters)
+		if len(differs) > 0 {
+			errs = append(errs, fmt.Errorf("manifestwork %s in %s differs from the one in %s, migrate it manually",
+				name, strings.Join(differs, ","), group[0].Namespace))
+			continue
+		}
+		results = append(results, Result{
+			Bundle:    newBundle(name, namespace, spec),
+			Placement: newPlacement(name, namespace, clusters),
+			Clusters:  clusters,
+		})
+	}
+	return results, errs
+}
+
+func newBundle(name, namespace string, spec workapiv1.ManifestWorkSpec) *appv1alpha1.AppBundle {
+	return &appv1alpha1.AppBundle{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appv1alpha1.GroupVersion.String(),
+			Kind:       "AppBundle",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{controllers.PlacementLabel: name},
+			Annotations: map[string]string{controllers.AdoptWorksAnnotation: name},
+		},
+		Spec: appv1alpha1.AppBundleSpec{ManifestWorkSpec: *spec.DeepCopy()},
+	}
+}
+
+func newPlacement(name, namespace string, clusters []string) *clusterapiv1alpha1.Placement {
+	n := int32(len(clusters))
+	return &clusterapiv1alpha1.Placement{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterapiv1alpha1.GroupVersion.String(),
+			Kind:       "Placement",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: clusterapiv1alpha1.PlacementSpec{
+			NumberOfClusters: &n,
+			Predicates: []clusterapiv1alpha1.ClusterPredicate{{
+				RequiredClusterSelector: clusterapiv1alpha1.ClusterSelector{
+					LabelSelector: metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{
+							Key:      ClusterNameLabel,
+							Operator: metav1.LabelSelectorOpIn,
+							Values:   clusters,
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"github.com/pdettori/kealm/controllers"
+)
+
+func work(name, cluster, content string, labels map[string]string) workapiv1.ManifestWork {
+	return workapiv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster, Labels: labels},
+		Spec: workapiv1.ManifestWorkSpec{
+			Workload: workapiv1.ManifestsTemplate{
+				Manifests: []workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: []byte(content)}}},
+			},
+		},
+	}
+}
+
+func TestFromManifestWorks(t *testing.T) {
+	works := []workapiv1.ManifestWork{
+		work("web", "cluster2", `{"kind":"ConfigMap"}`, nil),
+		work("web", "cluster1", `{"kind":"ConfigMap"}`, nil),
+		work("db", "cluster1", `{"kind":"Secret"}`, nil),
+		work("db", "cluster2", `{"kind":"Deployment"}`, nil),
+		work("owned", "cluster1", `{}`, map[string]string{controllers.OwnedLabel: "uid"}),
+	}
+	results, errs := FromManifestWorks(works, "apps")
+	if len(errs) != 1 {
+		t.Fatalf("expected an error for the differing db works, got %v", errs)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected a single bundle, got %d", len(results))
+	}
+
+	r := results[0]
+	if r.Bundle.Name != "web" || r.Bundle.Namespace != "apps" {
+		t.Errorf("unexpected bundle %s/%s", r.Bundle.Namespace, r.Bundle.Name)
+	}
+	if r.Bundle.Labels[controllers.PlacementLabel] != r.Placement.Name {
+		t.Errorf("bundle not bound to placement %s", r.Placement.Name)
+	}
+	if r.Bundle.Annotations[controllers.AdoptWorksAnnotation] != "web" {
+		t.Errorf("bundle does not adopt the web works")
+	}
+	if n := *r.Placement.Spec.NumberOfClusters; n != 2 {
+		t.Errorf("expected 2 clusters, got %d", n)
+	}
+	values := r.Placement.Spec.Predicates[0].RequiredClusterSelector.LabelSelector.MatchExpressions[0].Values
+	if len(values) != 2 || values[0] != "cluster1" || values[1] != "cluster2" {
+		t.Errorf("unexpected cluster selector %v", values)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notify forwards the warning events recorded by the controller to the
+// notification sinks configured in KealmConfig
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/tracker"
+)
+
+// Notification is the payload posted to the notification sinks
+type Notification struct {
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// Recorder is an event recorder which, in addition to recording events, posts the
+// warning events to the notification sinks returned by Sinks
+type Recorder struct {
+	record.EventRecorder
+
+	Sinks  func() []appv1alpha1.NotificationSink
+	Client *http.Client
+}
+
+// Event records the event and notifies the sinks of warnings
+func (r *Recorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.EventRecorder.Event(object, eventtype, reason, message)
+	r.notify(object, eventtype, reason, message)
+}
+
+// Eventf records the event and notifies the sinks of warnings
+func (r *Recorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.EventRecorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	r.notify(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf records the event and notifies the sinks of warnings
+func (r *Recorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	r.notify(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *Recorder) notify(object runtime.Object, eventtype, reason, message string) {
+	if eventtype != corev1.EventTypeWarning || r.Sinks == nil {
+		return
+	}
+	sinks := r.Sinks()
+	if len(sinks) == 0 {
+		return
+	}
+	n := Notification{
+		Kind:    object.GetObjectKind().GroupVersionKind().Kind,
+		Type:    eventtype,
+		Reason:  reason,
+		Message: message,
+		Time:    time.Now(),
+	}
+	if accessor, err := meta.Accessor(object); err == nil {
+		n.Namespace, n.Name = accessor.GetNamespace(), accessor.GetName()
+	}
+	for _, sink := range sinks {
+		go r.post(sink, n)
+	}
+}
+
+// NotifyTransitions notifies the sinks of the clusters of the bundles failing, as
+// streamed by the tracker, until the stream is closed
+func (r *Recorder) NotifyTransitions(transitions <-chan tracker.Transition) {
+	for t := range transitions {
+		if t.EventType() != corev1.EventTypeWarning || r.Sinks == nil {
+			continue
+		}
+		message := fmt.Sprintf("%s is %s on cluster %s", t.Type, t.Status, t.Cluster)
+		if t.Message != "" {
+			message += ": " + t.Message
+		}
+		n := Notification{
+			Kind:      "AppBundle",
+			Namespace: t.Namespace,
+			Name:      t.Bundle,
+			Cluster:   t.Cluster,
+			Type:      corev1.EventTypeWarning,
+			Reason:    t.Reason,
+			Message:   message,
+			Time:      t.Time,
+		}
+		for _, sink := range r.Sinks() {
+			go r.post(sink, n)
+		}
+	}
+}
+
+func (r *Recorder) post(sink appv1alpha1.NotificationSink, n Notification) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		klog.Errorf("Failed to encode notification for sink %s: %v", sink.Name, err)
+		return
+	}
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, bytes.NewReader(data))
+	if err != nil {
+		klog.Errorf("Failed to create notification request for sink %s: %v", sink.Name, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		klog.Errorf("Failed to notify sink %s: %v", sink.Name, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		klog.Errorf("Sink %s rejected notification with status %d", sink.Name, resp.StatusCode)
+	}
+}
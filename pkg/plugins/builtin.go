@@ -0,0 +1,105 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// ClusterNameLabel is set by the cluster-label plugin on the distributed resources
+const ClusterNameLabel = "cluster.open-cluster-management.io/cluster-name"
+
+// builtins are the built-in plugins, by name
+var builtins = map[string]DistributionPlugin{
+	"cluster-label":           clusterLabel{},
+	"require-resource-limits": resourceLimits{},
+}
+
+// Register adds a plugin to the built-in plugins, for controllers built with additional
+// Go plugins. It must be called before the controller starts.
+func Register(p DistributionPlugin) {
+	builtins[p.Name()] = p
+}
+
+// clusterLabel labels the distributed resources with the name of their cluster
+type clusterLabel struct{}
+
+func (clusterLabel) Name() string {
+	return "cluster-label"
+}
+
+func (clusterLabel) Mutate(ctx context.Context, in *Input) ([]workapiv1.Manifest, error) {
+	result := []workapiv1.Manifest{}
+	for _, m := range in.Manifests {
+		u, err := manifests.ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		labels := u.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[ClusterNameLabel] = in.Cluster
+		u.SetLabels(labels)
+		updated, err := manifests.FromUnstructured(u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, updated)
+	}
+	return result, nil
+}
+
+func (clusterLabel) Validate(ctx context.Context, in *Input) ([]string, error) {
+	return nil, nil
+}
+
+// resourceLimits denies the workloads with containers without cpu and memory limits
+type resourceLimits struct{}
+
+func (resourceLimits) Name() string {
+	return "require-resource-limits"
+}
+
+func (resourceLimits) Mutate(ctx context.Context, in *Input) ([]workapiv1.Manifest, error) {
+	return in.Manifests, nil
+}
+
+func (resourceLimits) Validate(ctx context.Context, in *Input) ([]string, error) {
+	violations := []string{}
+	for _, m := range in.Manifests {
+		u, err := manifests.ToUnstructured(m)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range manifests.Containers(u) {
+			for _, resource := range []string{"cpu", "memory"} {
+				if _, found, _ := unstructured.NestedFieldNoCopy(c, "resources", "limits", resource); !found {
+					violations = append(violations, fmt.Sprintf("%s %s: container %v has no %s limit",
+						u.GetKind(), u.GetName(), c["name"], resource))
+				}
+			}
+		}
+	}
+	return violations, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plugins extends the distribution of the bundles with validation and mutation
+// logic run on the manifests distributed to every cluster
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// Input is the set of manifests of a bundle distributed to a cluster
+type Input struct {
+	Namespace     string               `json:"namespace"`
+	Bundle        string               `json:"bundle"`
+	Cluster       string               `json:"cluster"`
+	ClusterLabels map[string]string    `json:"clusterLabels,omitempty"`
+	Manifests     []workapiv1.Manifest `json:"manifests"`
+}
+
+// DistributionPlugin validates and mutates the manifests distributed to every cluster
+type DistributionPlugin interface {
+	// Name of the plugin
+	Name() string
+	// Mutate returns the manifests to distribute
+	Mutate(ctx context.Context, in *Input) ([]workapiv1.Manifest, error)
+	// Validate returns the violations of the policy of the plugin by the manifests,
+	// none when they may be distributed
+	Validate(ctx context.Context, in *Input) ([]string, error)
+}
+
+// DeniedError is returned when a plugin denies the distribution of manifests
+type DeniedError struct {
+	Plugin     string
+	Violations []string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("denied by plugin %s: %s", e.Plugin, strings.Join(e.Violations, "; "))
+}
+
+// Chain runs plugins in order: the mutations of all plugins first, then their
+// validations of the mutated manifests
+type Chain []DistributionPlugin
+
+// Run returns the manifests mutated by the plugins, or a DeniedError when a plugin
+// denies them
+func (c Chain) Run(ctx context.Context, in *Input) ([]workapiv1.Manifest, error) {
+	mutated := *in
+	for _, p := range c {
+		ms, err := p.Mutate(ctx, &mutated)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s failed: %w", p.Name(), err)
+		}
+		mutated.Manifests = ms
+	}
+	for _, p := range c {
+		violations, err := p.Validate(ctx, &mutated)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s failed: %w", p.Name(), err)
+		}
+		if len(violations) > 0 {
+			return nil, &DeniedError{Plugin: p.Name(), Violations: violations}
+		}
+	}
+	return mutated.Manifests, nil
+}
+
+// Load returns the chain of the configured plugins: built-in plugins by name, and
+// WebAssembly modules of the directory of the runtime
+func Load(configs []appv1alpha1.DistributionPlugin, runtime *Runtime) (Chain, error) {
+	chain := Chain{}
+	for _, cfg := range configs {
+		if cfg.Module != "" {
+			if _, err := runtime.Module(cfg.Module); err != nil {
+				return nil, fmt.Errorf("plugin %s: %w", cfg.Name, err)
+			}
+			chain = append(chain, &WASM{PluginName: cfg.Name, Runtime: runtime, Module: cfg.Module})
+			continue
+		}
+		p, ok := builtins[cfg.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown built-in plugin %s", cfg.Name)
+		}
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+func testInput(t *testing.T, limits string) *Input {
+	ms, err := manifests.ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        resources:
+          limits:
+` + limits))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Input{Namespace: "default", Bundle: "web", Cluster: "cluster1", Manifests: ms}
+}
+
+func TestChain(t *testing.T) {
+	chain, err := Load([]appv1alpha1.DistributionPlugin{{Name: "cluster-label"}, {Name: "require-resource-limits"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := chain.Run(context.TODO(), testInput(t, "            cpu: 100m\n            memory: 64Mi\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := manifests.ToUnstructured(ms[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetLabels()[ClusterNameLabel] != "cluster1" {
+		t.Errorf("expected the cluster label, got %v", u.GetLabels())
+	}
+
+	_, err = chain.Run(context.TODO(), testInput(t, "            cpu: 100m\n"))
+	var denied *DeniedError
+	if !errors.As(err, &denied) || denied.Plugin != "require-resource-limits" || len(denied.Violations) != 1 {
+		t.Errorf("expected the missing memory limit to be denied, got %v", err)
+	}
+
+	if _, err := Load([]appv1alpha1.DistributionPlugin{{Name: "unknown"}}, nil); err == nil {
+		t.Error("expected an unknown plugin to fail")
+	}
+	if _, err := Load([]appv1alpha1.DistributionPlugin{{Name: "policy", Module: "policy.wasm"}}, nil); err == nil {
+		t.Error("expected a WebAssembly plugin without runtime to fail")
+	}
+	if _, err := Load([]appv1alpha1.DistributionPlugin{{Name: "policy", Module: "policy.wasm"}}, &Runtime{Dir: "/plugins"}); err == nil {
+		t.Error("expected a WebAssembly plugin with a blank runtime to fail")
+	}
+	if _, err := Load([]appv1alpha1.DistributionPlugin{{Name: "policy", Module: "policy.wasm"}}, &Runtime{Command: []string{"sh"}}); err == nil {
+		t.Error("expected a WebAssembly plugin without plugin directory to fail")
+	}
+	if _, err := (&WASM{PluginName: "policy", Module: "policy.wasm"}).Validate(context.TODO(), &Input{}); err == nil {
+		t.Error("expected a WebAssembly plugin without runtime to fail to run")
+	}
+}
+
+func TestRuntimeModule(t *testing.T) {
+	root, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "plugins")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{filepath.Join(dir, "policy.wasm"), filepath.Join(root, "other.wasm")} {
+		if err := ioutil.WriteFile(f, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(root, "other.wasm"), filepath.Join(dir, "link.wasm")); err != nil {
+		t.Fatal(err)
+	}
+	r := &Runtime{Command: []string{"wasmtime", "run"}, Dir: dir}
+	tests := []struct {
+		module  string
+		allowed bool
+	}{
+		{"policy.wasm", true},
+		{filepath.Join(dir, "policy.wasm"), true},
+		{"../plugins/policy.wasm", true},
+		{"../other.wasm", false},
+		{filepath.Join(root, "other.wasm"), false},
+		{"link.wasm", false},
+		{"missing.wasm", false},
+	}
+	for _, tt := range tests {
+		path, err := r.Module(tt.module)
+		if tt.allowed && err != nil {
+			t.Errorf("expected %s allowed, got %v", tt.module, err)
+		}
+		if !tt.allowed && err == nil {
+			t.Errorf("expected %s refused, got %s", tt.module, path)
+		}
+	}
+}
+
+func TestWASM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// a shell script stands for the runtime running the module, and counts its runs
+	module := filepath.Join(dir, "policy.sh")
+	script := `echo run >> "$0.runs"
+read -r request
+case "$request" in
+*'"operation":"validate"'*) echo '{"violations":["web is not allowed"]}' ;;
+*) echo '{"manifests":[]}' ;;
+esac
+`
+	if err := ioutil.WriteFile(module, []byte(script), 0600); err != nil {
+		t.Fatal(err)
+	}
+	chain, err := Load([]appv1alpha1.DistributionPlugin{{Name: "policy", Module: "policy.sh"}}, &Runtime{Command: []string{"sh"}, Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := testInput(t, "            cpu: 100m\n")
+	ms, err := chain[0].Mutate(context.TODO(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 0 {
+		t.Errorf("expected the module to remove the manifests, got %d", len(ms))
+	}
+	// the responses to the same request are cached
+	for i := 0; i < 2; i++ {
+		violations, err := chain[0].Validate(context.TODO(), in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(violations) != 1 || violations[0] != "web is not allowed" {
+			t.Errorf("unexpected violations %v", violations)
+		}
+	}
+	runs, err := ioutil.ReadFile(module + ".runs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(runs), "run"); n != 2 {
+		t.Errorf("expected the module run once per request, got %d runs", n)
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// wasmTimeout bounds the execution of a WebAssembly plugin
+	wasmTimeout = 30 * time.Second
+	// wasmCacheTTL is how long the response of a module to a request is reused
+	wasmCacheTTL = 10 * time.Minute
+	// wasmCacheSize bounds the number of cached responses
+	wasmCacheSize = 1024
+)
+
+// Runtime runs the modules of the WebAssembly plugins, and caches their responses so
+// that the clusters receiving the same manifests run each module once.
+//
+// The runtime is an exec plugin, not a sandbox: the modules are run by a command of the
+// controller, with its privileges, and are only as isolated as that command isolates
+// them. Only the modules of Dir, which should be read-only and writable by the
+// administrators of the controller alone, may be run.
+type Runtime struct {
+	// Command runs a module given as last argument, e.g. wasmtime run
+	Command []string
+	// Dir is the directory of the modules
+	Dir string
+
+	mu    sync.Mutex
+	cache map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	response *WASMResponse
+	expires  time.Time
+}
+
+// Module returns the path of the module in the directory of the runtime, relative to
+// it or absolute, or an error when the path escapes the directory
+func (r *Runtime) Module(module string) (string, error) {
+	if r == nil || len(r.Command) == 0 {
+		return "", fmt.Errorf("module %s: no WebAssembly runtime configured", module)
+	}
+	if r.Dir == "" {
+		return "", fmt.Errorf("module %s: no WebAssembly plugin directory configured", module)
+	}
+	dir, err := filepath.EvalSymlinks(r.Dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid WebAssembly plugin directory: %w", err)
+	}
+	path := module
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	// the links are resolved so that a link of the directory may not escape it
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("module %s: %w", module, err)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("module %s is not in the WebAssembly plugin directory %s", module, r.Dir)
+	}
+	return path, nil
+}
+
+// WASM is an experimental plugin compiled to a WebAssembly module implementing the
+// WASI command interface. The runtime runs the module with a WASMRequest on stdin, and
+// the module writes a WASMResponse to stdout.
+type WASM struct {
+	PluginName string
+	Runtime    *Runtime
+	// Module is the path of the module, in the directory of the runtime
+	Module string
+}
+
+// WASMRequest is the request read by WebAssembly plugins on stdin
+type WASMRequest struct {
+	// Operation is mutate or validate
+	Operation string `json:"operation"`
+	Input     *Input `json:"input"`
+}
+
+// WASMResponse is the response written by WebAssembly plugins on stdout
+type WASMResponse struct {
+	// Manifests are the mutated manifests
+	Manifests []workapiv1.Manifest `json:"manifests,omitempty"`
+	// Violations of the policy of the plugin
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Name of the plugin
+func (w *WASM) Name() string {
+	return w.PluginName
+}
+
+// Mutate returns the manifests mutated by the module
+func (w *WASM) Mutate(ctx context.Context, in *Input) ([]workapiv1.Manifest, error) {
+	resp, err := w.run(ctx, "mutate", in)
+	if err != nil {
+		return nil, err
+	}
+	// the response may be cached, its manifests are copied for the caller
+	ms := make([]workapiv1.Manifest, len(resp.Manifests))
+	for i := range resp.Manifests {
+		resp.Manifests[i].DeepCopyInto(&ms[i])
+	}
+	return ms, nil
+}
+
+// Validate returns the violations reported by the module
+func (w *WASM) Validate(ctx context.Context, in *Input) ([]string, error) {
+	resp, err := w.run(ctx, "validate", in)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, resp.Violations...), nil
+}
+
+func (w *WASM) run(ctx context.Context, operation string, in *Input) (*WASMResponse, error) {
+	req, err := json.Marshal(&WASMRequest{Operation: operation, Input: in})
+	if err != nil {
+		return nil, err
+	}
+	module, err := w.Runtime.Module(w.Module)
+	if err != nil {
+		return nil, err
+	}
+	key, err := cacheKey(module, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp := w.Runtime.cached(key); resp != nil {
+		return resp, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, wasmTimeout)
+	defer cancel()
+	command := w.Runtime.Command
+	args := append(append([]string{}, command[1:]...), module)
+	cmd := exec.CommandContext(ctx, command[0], args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("module %s failed: %w: %s", w.Module, err, stderr.String())
+	}
+	resp := &WASMResponse{}
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return nil, fmt.Errorf("invalid response of module %s: %w", w.Module, err)
+	}
+	w.Runtime.store(key, resp)
+	return resp, nil
+}
+
+// cacheKey returns the key of the response of the module to the request, changed when
+// the module is replaced
+func cacheKey(module string, req []byte) (string, error) {
+	info, err := os.Stat(module)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%d\x00", module, info.Size(), info.ModTime().UnixNano())
+	h.Write(req)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (r *Runtime) cached(key string) *WASMResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.cache[key]; ok && time.Now().Before(c.expires) {
+		return c.response
+	}
+	return nil
+}
+
+func (r *Runtime) store(key string, resp *WASMResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cache == nil {
+		r.cache = map[string]cachedResponse{}
+	}
+	now := time.Now()
+	if len(r.cache) >= wasmCacheSize {
+		for k, c := range r.cache {
+			if now.After(c.expires) {
+				delete(r.cache, k)
+			}
+		}
+	}
+	if len(r.cache) >= wasmCacheSize {
+		r.cache = map[string]cachedResponse{}
+	}
+	r.cache[key] = cachedResponse{response: resp, expires: now.Add(wasmCacheTTL)}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package provenance computes content digests of distributed workloads and signs
+// them with a key held on the hub
+package provenance
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// DigestPrefix is prepended to the hex encoded sha256 content digests
+const DigestPrefix = "sha256:"
+
+// Payload returns the canonical JSON encoding of the manifests, which is the content
+// digested and signed. Manifests are re-encoded so that the payload does not depend
+// on the formatting of the raw manifests.
+func Payload(manifests []workapiv1.Manifest) ([]byte, error) {
+	objs := make([]interface{}, 0, len(manifests))
+	for i, m := range manifests {
+		var data []byte
+		var err error
+		if m.Object != nil {
+			data, err = json.Marshal(m.Object)
+		} else {
+			data = m.Raw
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode manifest %d: %w", i, err)
+		}
+		var obj interface{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("failed to decode manifest %d: %w", i, err)
+		}
+		objs = append(objs, obj)
+	}
+	// encoding/json sorts map keys, making the encoding canonical
+	return json.Marshal(objs)
+}
+
+// Digest returns the content digest of a payload
+func Digest(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return DigestPrefix + hex.EncodeToString(sum[:])
+}
+
+// Signer signs payloads with a private key
+type Signer struct {
+	key crypto.Signer
+}
+
+// NewSignerFromFile loads a PEM encoded, unencrypted PKCS#8, PKCS#1 or EC private key.
+// ECDSA signatures can be verified with `cosign verify-blob` using the matching public key.
+func NewSignerFromFile(path string) (*Signer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key in %s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return &Signer{key: signer}, nil
+}
+
+// Sign returns the base64 encoded signature of the payload
+func (s *Signer) Sign(payload []byte) (string, error) {
+	var sig []byte
+	var err error
+	switch s.key.(type) {
+	case ed25519.PrivateKey:
+		sig, err = s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+	case *ecdsa.PrivateKey, *rsa.PrivateKey:
+		sum := sha256.Sum256(payload)
+		sig, err = s.key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	default:
+		return "", fmt.Errorf("unsupported private key type %T", s.key)
+	}
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
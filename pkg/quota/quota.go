@@ -0,0 +1,72 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package quota measures the usage of the quotas of the tenant namespaces by their
+// bundles and checks it against their limits
+package quota
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// Usage returns the usage of the bundles as last reconciled and the clusters they
+// target
+func Usage(bundles []appv1alpha1.AppBundle) (appv1alpha1.TenantUsage, sets.String) {
+	usage := appv1alpha1.TenantUsage{Bundles: int32(len(bundles))}
+	clusters := sets.NewString()
+	for _, b := range bundles {
+		for _, c := range b.Status.Clusters {
+			clusters.Insert(c.ClusterName)
+		}
+		usage.ManifestBytes += b.Status.ManifestBytes
+	}
+	usage.Clusters = int32(clusters.Len())
+	return usage, clusters
+}
+
+// Size returns the size of the manifests in bytes
+func Size(ms []workapiv1.Manifest) int64 {
+	size := int64(0)
+	for _, m := range ms {
+		size += int64(len(m.Raw))
+	}
+	return size
+}
+
+// Check returns the limits of the quota exceeded by the usage, empty when the quota
+// is nil
+func Check(q *appv1alpha1.TenantQuota, usage appv1alpha1.TenantUsage) []string {
+	exceeded := []string{}
+	if q == nil {
+		return exceeded
+	}
+	if q.MaxBundles != nil && usage.Bundles > *q.MaxBundles {
+		exceeded = append(exceeded, fmt.Sprintf("%d bundles exceed the quota of %d", usage.Bundles, *q.MaxBundles))
+	}
+	if q.MaxClusters != nil && usage.Clusters > *q.MaxClusters {
+		exceeded = append(exceeded, fmt.Sprintf("%d clusters exceed the quota of %d", usage.Clusters, *q.MaxClusters))
+	}
+	if q.MaxManifestBytes != nil && usage.ManifestBytes > q.MaxManifestBytes.Value() {
+		exceeded = append(exceeded, fmt.Sprintf("%d manifest bytes exceed the quota of %s",
+			usage.ManifestBytes, q.MaxManifestBytes.String()))
+	}
+	return exceeded
+}
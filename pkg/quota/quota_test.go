@@ -0,0 +1,84 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func bundle(bytes int64, clusters ...string) appv1alpha1.AppBundle {
+	b := appv1alpha1.AppBundle{}
+	b.Status.ManifestBytes = bytes
+	for _, c := range clusters {
+		b.Status.Clusters = append(b.Status.Clusters, appv1alpha1.ClusterStatus{ClusterName: c})
+	}
+	return b
+}
+
+func TestUsage(t *testing.T) {
+	usage, clusters := Usage([]appv1alpha1.AppBundle{
+		bundle(100, "cluster1", "cluster2"),
+		bundle(50, "cluster2", "cluster3"),
+		bundle(0),
+	})
+	expected := appv1alpha1.TenantUsage{Bundles: 3, Clusters: 3, ManifestBytes: 150}
+	if usage != expected {
+		t.Errorf("expected usage %v, got %v", expected, usage)
+	}
+	if !reflect.DeepEqual(clusters.List(), []string{"cluster1", "cluster2", "cluster3"}) {
+		t.Errorf("unexpected clusters %v", clusters.List())
+	}
+}
+
+func TestSize(t *testing.T) {
+	ms := []workapiv1.Manifest{
+		{RawExtension: runtime.RawExtension{Raw: []byte(`{"kind":"ConfigMap"}`)}},
+		{RawExtension: runtime.RawExtension{Raw: []byte(`{}`)}},
+	}
+	if size := Size(ms); size != 22 {
+		t.Errorf("expected 22 bytes, got %d", size)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	bundles, clusters := int32(2), int32(10)
+	bytes := resource.MustParse("1Ki")
+	q := &appv1alpha1.TenantQuota{MaxBundles: &bundles, MaxClusters: &clusters, MaxManifestBytes: &bytes}
+
+	if exceeded := Check(q, appv1alpha1.TenantUsage{Bundles: 2, Clusters: 10, ManifestBytes: 1024}); len(exceeded) != 0 {
+		t.Errorf("expected the quota to be respected, got %v", exceeded)
+	}
+	exceeded := Check(q, appv1alpha1.TenantUsage{Bundles: 3, Clusters: 11, ManifestBytes: 1025})
+	expected := []string{
+		"3 bundles exceed the quota of 2",
+		"11 clusters exceed the quota of 10",
+		"1025 manifest bytes exceed the quota of 1Ki",
+	}
+	if !reflect.DeepEqual(exceeded, expected) {
+		t.Errorf("expected %v, got %v", expected, exceeded)
+	}
+	if exceeded := Check(nil, appv1alpha1.TenantUsage{Bundles: 100}); len(exceeded) != 0 {
+		t.Errorf("expected no quota to be respected, got %v", exceeded)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package receiver serves the webhooks of external systems, e.g. GitHub, authenticated
+// with a HMAC signature of their payload
+package receiver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// SignatureHeader holds the hex encoded HMAC-SHA256 of the payload, prefixed with
+	// sha256= as sent by GitHub
+	SignatureHeader = "X-Hub-Signature-256"
+	// EventHeader holds the type of the event
+	EventHeader = "X-GitHub-Event"
+
+	// maxPayloadSize bounds the size of the accepted payloads
+	maxPayloadSize = 1 << 20
+)
+
+// ErrUnknownTrigger is returned by the TriggerFunc for unknown triggers
+var ErrUnknownTrigger = errors.New("unknown trigger")
+
+// TriggersPath prefixes the paths of the triggers, /triggers/<name>
+const TriggersPath = "/triggers/"
+
+// RegistryPath receives the push notifications of registries
+const RegistryPath = "/registry"
+
+// RegistryPushFunc is called with the image repositories pushed to a registry
+type RegistryPushFunc func(ctx context.Context, repositories []string) error
+
+// TriggerFunc performs the action of the named trigger for a payload of the event type
+type TriggerFunc func(ctx context.Context, name, event string, payload []byte) error
+
+// PullRequestClosedFunc is called when a pull request is closed, merged or not
+type PullRequestClosedFunc func(ctx context.Context, repository string, number int32) error
+
+// Receiver handles the webhooks signed with Secret
+type Receiver struct {
+	Secret []byte
+
+	// RegistryToken authenticates as a bearer token the notifications received on
+	// RegistryPath, for the registries which cannot sign their payloads. Bearer tokens
+	// are refused when empty.
+	RegistryToken []byte
+
+	// PullRequestClosed is called for the closed pull requests when set
+	PullRequestClosed PullRequestClosedFunc
+
+	// Trigger is called for the payloads received on TriggersPath when set
+	Trigger TriggerFunc
+
+	// RegistryPush is called for the notifications received on RegistryPath when set
+	RegistryPush RegistryPushFunc
+}
+
+// pullRequestEvent is the part of the GitHub pull_request event payload used
+type pullRequestEvent struct {
+	Action     string `json:"action"`
+	Number     int32  `json:"number"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// ServeHTTP verifies the signature of the payload and dispatches the event
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+	if err := r.authenticate(req, payload); err != nil {
+		klog.Warningf("Rejected webhook from %s: %v", req.RemoteAddr, err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event := req.Header.Get(EventHeader)
+	if req.URL.Path == RegistryPath {
+		r.registryPush(w, req, payload)
+		return
+	}
+	if strings.HasPrefix(req.URL.Path, TriggersPath) {
+		r.trigger(w, req, strings.TrimPrefix(req.URL.Path, TriggersPath), event, payload)
+		return
+	}
+
+	switch event {
+	case "ping":
+	case "pull_request":
+		pr := pullRequestEvent{}
+		if err := json.Unmarshal(payload, &pr); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		if pr.Action != "closed" || r.PullRequestClosed == nil {
+			break
+		}
+		if err := r.PullRequestClosed(req.Context(), pr.Repository.FullName, pr.Number); err != nil {
+			klog.Errorf("Failed to handle closed pull request %s#%d: %v", pr.Repository.FullName, pr.Number, err)
+			http.Error(w, "failed to handle event", http.StatusInternalServerError)
+			return
+		}
+	default:
+		klog.V(2).Infof("Ignoring webhook event %q", event)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (r *Receiver) trigger(w http.ResponseWriter, req *http.Request, name, event string, payload []byte) {
+	if r.Trigger == nil {
+		http.NotFound(w, req)
+		return
+	}
+	if err := r.Trigger(req.Context(), name, event, payload); err != nil {
+		if errors.Is(err, ErrUnknownTrigger) {
+			http.NotFound(w, req)
+			return
+		}
+		klog.Errorf("Failed to handle trigger %s: %v", name, err)
+		http.Error(w, "failed to handle trigger", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// registryNotification is the part of the push notifications of Docker Hub and of
+// the distribution registry used
+type registryNotification struct {
+	Repository struct {
+		RepoName string `json:"repo_name"`
+	} `json:"repository"`
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			Repository string `json:"repository"`
+		} `json:"target"`
+		Request struct {
+			Host string `json:"host"`
+		} `json:"request"`
+	} `json:"events"`
+}
+
+func (r *Receiver) registryPush(w http.ResponseWriter, req *http.Request, payload []byte) {
+	if r.RegistryPush == nil {
+		http.NotFound(w, req)
+		return
+	}
+	n := registryNotification{}
+	if err := json.Unmarshal(payload, &n); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	repositories := []string{}
+	if n.Repository.RepoName != "" {
+		repositories = append(repositories, n.Repository.RepoName)
+	}
+	for _, e := range n.Events {
+		if e.Action != "push" || e.Target.Repository == "" {
+			continue
+		}
+		repo := e.Target.Repository
+		if e.Request.Host != "" {
+			repo = e.Request.Host + "/" + repo
+		}
+		repositories = append(repositories, repo)
+	}
+	if len(repositories) > 0 {
+		if err := r.RegistryPush(req.Context(), repositories); err != nil {
+			klog.Errorf("Failed to handle push of %v: %v", repositories, err)
+			http.Error(w, "failed to handle notification", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// authenticate accepts the payloads signed with the secret, or, on RegistryPath for the
+// registries which cannot sign their payloads, the requests bearing the registry token
+func (r *Receiver) authenticate(req *http.Request, payload []byte) error {
+	if auth := req.Header.Get("Authorization"); auth != "" && req.URL.Path == RegistryPath {
+		if len(r.RegistryToken) == 0 {
+			return fmt.Errorf("no registry token configured")
+		}
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if token == auth {
+			return fmt.Errorf("unsupported authorization scheme")
+		}
+		if !hmac.Equal([]byte(token), r.RegistryToken) {
+			return fmt.Errorf("invalid bearer token")
+		}
+		return nil
+	}
+	return Verify(r.Secret, payload, req.Header.Get(SignatureHeader))
+}
+
+// Verify checks the signature of a payload, formatted as in SignatureHeader
+func Verify(secret, payload []byte, signature string) error {
+	if len(secret) == 0 {
+		return fmt.Errorf("no secret configured")
+	}
+	hexSum := strings.TrimPrefix(signature, "sha256=")
+	if hexSum == signature {
+		return fmt.Errorf("missing sha256 signature")
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return fmt.Errorf("malformed signature: %w", err)
+	}
+	if !hmac.Equal(sum, Sign(secret, payload)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// Sign returns the HMAC-SHA256 of a payload
+func Sign(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
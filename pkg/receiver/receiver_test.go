@@ -0,0 +1,162 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package receiver
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReceiver(t *testing.T) {
+	secret := []byte("s3cr3t")
+	closed := []string{}
+	r := &Receiver{
+		Secret: secret,
+		PullRequestClosed: func(ctx context.Context, repository string, number int32) error {
+			closed = append(closed, repository)
+			return nil
+		},
+	}
+	payload := `{"action":"closed","number":42,"repository":{"full_name":"acme/web"}}`
+
+	tests := []struct {
+		name      string
+		signature string
+		want      int
+		closed    int
+	}{
+		{name: "valid signature", signature: "sha256=" + hex.EncodeToString(Sign(secret, []byte(payload))), want: http.StatusAccepted, closed: 1},
+		{name: "wrong secret", signature: "sha256=" + hex.EncodeToString(Sign([]byte("other"), []byte(payload))), want: http.StatusUnauthorized},
+		{name: "missing signature", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closed = nil
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+			req.Header.Set(EventHeader, "pull_request")
+			if tt.signature != "" {
+				req.Header.Set(SignatureHeader, tt.signature)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+			if len(closed) != tt.closed {
+				t.Errorf("expected %d closed pull requests, got %v", tt.closed, closed)
+			}
+		})
+	}
+}
+
+func TestReceiverTrigger(t *testing.T) {
+	secret := []byte("s3cr3t")
+	triggered := ""
+	r := &Receiver{
+		Secret: secret,
+		Trigger: func(ctx context.Context, name, event string, payload []byte) error {
+			if name != "web" {
+				return fmt.Errorf("%w %s", ErrUnknownTrigger, name)
+			}
+			triggered = event
+			return nil
+		},
+	}
+	payload := `{"release":{"tag_name":"v2"}}`
+	signature := "sha256=" + hex.EncodeToString(Sign(secret, []byte(payload)))
+
+	for path, want := range map[string]int{"/triggers/web": http.StatusAccepted, "/triggers/db": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(payload))
+		req.Header.Set(EventHeader, "release")
+		req.Header.Set(SignatureHeader, signature)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: expected status %d, got %d", path, want, w.Code)
+		}
+	}
+	if triggered != "release" {
+		t.Errorf("expected the web trigger to receive the release event, got %q", triggered)
+	}
+}
+
+func TestReceiverRegistryPush(t *testing.T) {
+	pushed := []string{}
+	r := &Receiver{
+		Secret:        []byte("s3cr3t"),
+		RegistryToken: []byte("t0k3n"),
+		RegistryPush: func(ctx context.Context, repositories []string) error {
+			pushed = append(pushed, repositories...)
+			return nil
+		},
+	}
+	payload := `{"events":[{"action":"push","target":{"repository":"acme/web"},"request":{"host":"registry:5000"}},` +
+		`{"action":"pull","target":{"repository":"acme/db"}}]}`
+
+	for token, want := range map[string]int{
+		"Bearer t0k3n": http.StatusAccepted,
+		"Bearer wrong": http.StatusUnauthorized,
+		// the signing secret is not a token
+		"Bearer s3cr3t": http.StatusUnauthorized,
+		// the scheme is required
+		"t0k3n":       http.StatusUnauthorized,
+		"Basic t0k3n": http.StatusUnauthorized,
+	} {
+		pushed = nil
+		req := httptest.NewRequest(http.MethodPost, RegistryPath, strings.NewReader(payload))
+		req.Header.Set("Authorization", token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: expected status %d, got %d", token, want, w.Code)
+		}
+		if want == http.StatusAccepted && (len(pushed) != 1 || pushed[0] != "registry:5000/acme/web") {
+			t.Errorf("expected the pushed repository, got %v", pushed)
+		}
+	}
+}
+
+func TestReceiverBearerTokenOnlyOnRegistryPath(t *testing.T) {
+	closed := 0
+	r := &Receiver{
+		Secret:        []byte("s3cr3t"),
+		RegistryToken: []byte("t0k3n"),
+		PullRequestClosed: func(ctx context.Context, repository string, number int32) error {
+			closed++
+			return nil
+		},
+	}
+	payload := `{"action":"closed","number":7,"repository":{"full_name":"acme/web"}}`
+	for _, token := range []string{"Bearer t0k3n", "Bearer s3cr3t"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+		req.Header.Set(EventHeader, "pull_request")
+		req.Header.Set("Authorization", token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected an unsigned pull request event to be rejected, got %d", token, w.Code)
+		}
+	}
+	if closed != 0 {
+		t.Errorf("expected no pull request to be closed, got %d", closed)
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Latest returns the latest tag matching the pattern and the semantic version range,
+// empty if none. The tags are ordered by semantic version when a range is set, the
+// tags not being semantic versions are then skipped, as the pre-releases unless the
+// range references one. The tags are otherwise ordered in lexical order.
+// A range is a space separated list of comparisons, e.g. ">=1.2.0 <2.0.0".
+func Latest(tags []string, semverRange, pattern string) (string, error) {
+	var re *regexp.Regexp
+	if pattern != "" {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	matches := []string{}
+	for _, t := range tags {
+		if re == nil || re.MatchString(t) {
+			matches = append(matches, t)
+		}
+	}
+	if semverRange == "" {
+		sort.Strings(matches)
+		if len(matches) == 0 {
+			return "", nil
+		}
+		return matches[len(matches)-1], nil
+	}
+
+	constraints, err := parseRange(semverRange)
+	if err != nil {
+		return "", err
+	}
+	latest, latestTag := (*version.Version)(nil), ""
+	for _, t := range matches {
+		v, err := version.ParseSemantic(strings.TrimPrefix(t, "v"))
+		if err != nil || (v.PreRelease() != "" && !constraints.prerelease()) || !constraints.match(v) {
+			continue
+		}
+		if latest == nil || latest.LessThan(v) {
+			latest, latestTag = v, t
+		}
+	}
+	return latestTag, nil
+}
+
+// InRange returns true if the semantic version, with an optional v prefix, is in the
+// range, a space separated list of comparisons
+func InRange(v, semverRange string) (bool, error) {
+	constraints, err := parseRange(semverRange)
+	if err != nil {
+		return false, err
+	}
+	parsed, err := version.ParseSemantic(strings.TrimPrefix(v, "v"))
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %w", v, err)
+	}
+	return constraints.match(parsed), nil
+}
+
+type constraint struct {
+	op string
+	v  *version.Version
+}
+
+type constraints []constraint
+
+func (cs constraints) match(v *version.Version) bool {
+	for _, c := range cs {
+		cmp, _ := v.Compare(c.v.String())
+		ok := false
+		switch c.op {
+		case ">=":
+			ok = cmp >= 0
+		case ">":
+			ok = cmp > 0
+		case "<=":
+			ok = cmp <= 0
+		case "<":
+			ok = cmp < 0
+		case "=":
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (cs constraints) prerelease() bool {
+	for _, c := range cs {
+		if c.v.PreRelease() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRange(r string) (constraints, error) {
+	cs := constraints{}
+	for _, f := range strings.Fields(r) {
+		op := "="
+		for _, o := range []string{">=", "<=", ">", "<", "="} {
+			if strings.HasPrefix(f, o) {
+				op = o
+				break
+			}
+		}
+		v, err := version.ParseSemantic(strings.TrimPrefix(strings.TrimPrefix(f, op), "v"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", r, err)
+		}
+		cs = append(cs, constraint{op: op, v: v})
+	}
+	return cs, nil
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry lists the tags of container images in OCI registries, resolves
+// their digests, selects the latest tag matching a policy and pulls artifacts
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DockerHub is the registry of the images without registry host
+const DockerHub = "registry-1.docker.io"
+
+// Client lists the tags and resolves the digests of images with the registry HTTP
+// API v2, anonymously
+type Client struct {
+	HTTP *http.Client
+}
+
+// Reference splits an image repository into its registry host and repository path,
+// e.g. nginx into registry-1.docker.io and library/nginx
+func Reference(image string) (string, string) {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		if parts[0] == "docker.io" {
+			return Reference(parts[1])
+		}
+		return parts[0], parts[1]
+	}
+	if len(parts) == 1 {
+		return DockerHub, "library/" + image
+	}
+	return DockerHub, image
+}
+
+// Tags returns the tags of the image repository, following the pagination
+func (c *Client) Tags(ctx context.Context, image string) ([]string, error) {
+	host, repo := Reference(image)
+	next := fmt.Sprintf("https://%s/v2/%s/tags/list", host, repo)
+	token := ""
+	tags := []string{}
+	for next != "" {
+		resp, err := c.get(ctx, next, token)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && token == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if token, err = c.token(ctx, challenge); err != nil {
+				return nil, fmt.Errorf("failed to authenticate to %s: %w", host, err)
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to list the tags of %s: %s", image, resp.Status)
+		}
+		list := struct {
+			Tags []string `json:"tags"`
+		}{}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag list of %s: %w", image, err)
+		}
+		tags = append(tags, list.Tags...)
+		if next, err = nextPage(next, resp.Header.Get("Link")); err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// manifestTypes are the media types of the image manifests and indexes
+var manifestTypes = strings.Join([]string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+// Digest returns the digest of the manifest of an image tag, which is the index of its
+// platforms for multi-platform images
+func (c *Client) Digest(ctx context.Context, image, tag string) (string, error) {
+	host, repo := Reference(image)
+	u := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, repo, tag)
+	token := ""
+	for {
+		resp, err := c.do(ctx, http.MethodHead, u, manifestTypes, token)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized && token == "" {
+			if token, err = c.token(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return "", fmt.Errorf("failed to authenticate to %s: %w", host, err)
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("failed to get the manifest of %s:%s: %s", image, tag, resp.Status)
+		}
+		digest := resp.Header.Get("Docker-Content-Digest")
+		if digest == "" {
+			return "", fmt.Errorf("no digest returned for %s:%s", image, tag)
+		}
+		return digest, nil
+	}
+}
+
+// artifactTypes are the media types of the manifests of single-platform images and
+// artifacts
+var artifactTypes = strings.Join([]string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+// Artifact returns the content of the first layer of an artifact tag, e.g. a file
+// pushed with oras push, and the digest of its manifest
+func (c *Client) Artifact(ctx context.Context, image, tag string) ([]byte, string, error) {
+	host, repo := Reference(image)
+	manifest, digest, err := c.fetch(ctx, fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, repo, tag), artifactTypes)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get the manifest of %s:%s: %w", image, tag, err)
+	}
+	m := struct {
+		Layers []struct {
+			Digest string `json:"digest"`
+		} `json:"layers"`
+	}{}
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return nil, "", fmt.Errorf("invalid manifest of %s:%s: %w", image, tag, err)
+	}
+	if len(m.Layers) == 0 {
+		return nil, "", fmt.Errorf("no layer in %s:%s", image, tag)
+	}
+	content, _, err := c.fetch(ctx, fmt.Sprintf("https://%s/v2/%s/blobs/%s", host, repo, m.Layers[0].Digest), "*/*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get the layer of %s:%s: %w", image, tag, err)
+	}
+	return content, digest, nil
+}
+
+// fetch gets the content and the digest header of a registry URL, authenticating on
+// challenge
+func (c *Client) fetch(ctx context.Context, u, accept string) ([]byte, string, error) {
+	token := ""
+	for {
+		resp, err := c.do(ctx, http.MethodGet, u, accept, token)
+		if err != nil {
+			return nil, "", err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && token == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if token, err = c.token(ctx, challenge); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, "", errors.New(resp.Status)
+		}
+		content, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return content, resp.Header.Get("Docker-Content-Digest"), err
+	}
+}
+
+func (c *Client) get(ctx context.Context, u, token string) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, u, "application/json", token)
+}
+
+func (c *Client) do(ctx context.Context, method, u, accept, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return c.client().Do(req)
+}
+
+func (c *Client) client() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return http.DefaultClient
+}
+
+// token gets an anonymous bearer token as described by the challenge of the registry
+func (c *Client) token(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported challenge %q", challenge)
+	}
+	params := map[string]string{}
+	for _, p := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid realm in challenge %q", challenge)
+	}
+	q := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if v, ok := params[k]; ok {
+			q.Set(k, v)
+		}
+	}
+	realm.RawQuery = q.Encode()
+	resp, err := c.get(ctx, realm.String(), "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed: %s", resp.Status)
+	}
+	t := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return "", err
+	}
+	if t.Token != "" {
+		return t.Token, nil
+	}
+	return t.AccessToken, nil
+}
+
+// nextPage returns the URL of the next page from the Link header, empty on the last page
+func nextPage(current, link string) (string, error) {
+	if link == "" {
+		return "", nil
+	}
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start || !strings.Contains(link, `rel="next"`) {
+		return "", nil
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return "", fmt.Errorf("invalid Link header %q: %w", link, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
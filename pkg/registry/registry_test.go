@@ -0,0 +1,176 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReference(t *testing.T) {
+	tests := []struct {
+		image, host, repo string
+	}{
+		{image: "nginx", host: DockerHub, repo: "library/nginx"},
+		{image: "acme/web", host: DockerHub, repo: "acme/web"},
+		{image: "docker.io/acme/web", host: DockerHub, repo: "acme/web"},
+		{image: "ghcr.io/acme/web", host: "ghcr.io", repo: "acme/web"},
+		{image: "localhost:5000/web", host: "localhost:5000", repo: "web"},
+	}
+	for _, tt := range tests {
+		host, repo := Reference(tt.image)
+		if host != tt.host || repo != tt.repo {
+			t.Errorf("Reference(%s) = %s, %s, expected %s, %s", tt.image, host, repo, tt.host, tt.repo)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:acme/web:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "t0k3n"})
+		case r.Header.Get("Authorization") != "Bearer t0k3n":
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:acme/web:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/acme/web/tags/list?last=v1&n=2>; rel="next"`)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"tags": []string{"v0", "v1"}})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"tags": []string{"v2"}})
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{HTTP: server.Client()}
+	tags, err := c.Tags(context.TODO(), strings.TrimPrefix(server.URL, "https://")+"/acme/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tags, []string{"v0", "v1", "v2"}) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/v2/acme/web/manifests/v1" ||
+			!strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", "sha256:abcd")
+	}))
+	defer server.Close()
+
+	c := &Client{HTTP: server.Client()}
+	image := strings.TrimPrefix(server.URL, "https://") + "/acme/web"
+	digest, err := c.Digest(context.TODO(), image, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != "sha256:abcd" {
+		t.Errorf("expected digest sha256:abcd, got %s", digest)
+	}
+	if _, err := c.Digest(context.TODO(), image, "v2"); err == nil {
+		t.Error("expected a missing tag to fail")
+	}
+}
+
+func TestArtifact(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/acme/catalog/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", "sha256:abcd")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"layers": []map[string]string{{"digest": "sha256:1234"}},
+			})
+		case "/v2/acme/catalog/blobs/sha256:1234":
+			_, _ = w.Write([]byte("kind: AppBundleTemplate"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{HTTP: server.Client()}
+	image := strings.TrimPrefix(server.URL, "https://") + "/acme/catalog"
+	content, digest, err := c.Artifact(context.TODO(), image, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "kind: AppBundleTemplate" || digest != "sha256:abcd" {
+		t.Errorf("unexpected artifact %q with digest %s", content, digest)
+	}
+	if _, _, err := c.Artifact(context.TODO(), image, "v2"); err == nil {
+		t.Error("expected a missing tag to fail")
+	}
+}
+
+func TestLatest(t *testing.T) {
+	tags := []string{"latest", "v1.2.0", "v1.10.1", "v2.0.0-rc.1", "v2.0.0", "1.9.0", "main-20220101", "main-20220301"}
+	tests := []struct {
+		name, semver, pattern, want string
+	}{
+		{name: "semver range", semver: ">=1.0.0 <2.0.0", want: "v1.10.1"},
+		{name: "any semver", semver: ">=0.0.0", want: "v2.0.0"},
+		{name: "pattern", pattern: `^main-\d+$`, want: "main-20220301"},
+		{name: "pre-release", semver: ">=2.0.0-rc.0 <2.0.0", want: "v2.0.0-rc.1"},
+		{name: "no match", semver: ">=3.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Latest(tags, tt.semver, tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+	if _, err := Latest(tags, ">=x", ""); err == nil {
+		t.Errorf("expected an error for an invalid range")
+	}
+}
+
+func TestInRange(t *testing.T) {
+	for v, want := range map[string]bool{"v1.4.2": true, "1.5.0": false, "1.3.9": false} {
+		got, err := InRange(v, ">=1.4.0 <1.5.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("expected %s in range to be %t", v, want)
+		}
+	}
+	if _, err := InRange("latest", ">=1.4.0"); err == nil {
+		t.Errorf("expected an error for an invalid version")
+	}
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rollout plans the rollouts of the bundles in ordered waves of clusters and
+// records their progress against the plan
+package rollout
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+const (
+	// AllWave is the single wave of the bundles without rollout
+	AllWave = "all"
+	// RemainingWave holds the clusters no wave of the rollout selects
+	RemainingWave = "remaining"
+)
+
+// Wave is a wave of a rollout with its clusters
+type Wave struct {
+	Name     string
+	Clusters []string
+	SoakTime *metav1.Duration
+}
+
+// Assign returns the waves of the clusters, by their labels, with the clusters deferred
+// by the overrides moved to their later wave. The clusters no wave selects form a last
+// remaining wave when the last wave has a selector, the bundles without rollout having
+// a single wave. The deferrals which cannot be honored are ignored and returned.
+func Assign(r *appv1alpha1.Rollout, overrides *appv1alpha1.RolloutOverrides, clusters map[string]labels.Set) ([]Wave, []string, error) {
+	waves, err := assign(r, clusters)
+	if err != nil || overrides == nil {
+		return waves, nil, err
+	}
+	ignored := []string{}
+	for _, d := range overrides.Deferrals {
+		from, to := -1, -1
+		for i, w := range waves {
+			if sets.NewString(w.Clusters...).Has(d.Cluster) {
+				from = i
+			}
+			if w.Name == d.Wave {
+				to = i
+			}
+		}
+		switch {
+		case from < 0:
+			ignored = append(ignored, fmt.Sprintf("cluster %s is not a target of the bundle", d.Cluster))
+		case to < 0:
+			ignored = append(ignored, fmt.Sprintf("wave %s of cluster %s does not exist", d.Wave, d.Cluster))
+		case to <= from:
+			ignored = append(ignored, fmt.Sprintf("cluster %s cannot move from wave %s to wave %s, not a later wave",
+				d.Cluster, waves[from].Name, d.Wave))
+		default:
+			waves[from].Clusters = sets.NewString(waves[from].Clusters...).Delete(d.Cluster).List()
+			waves[to].Clusters = sets.NewString(waves[to].Clusters...).Insert(d.Cluster).List()
+		}
+	}
+	return waves, ignored, nil
+}
+
+func assign(r *appv1alpha1.Rollout, clusters map[string]labels.Set) ([]Wave, error) {
+	names := make([]string, 0, len(clusters))
+	for c := range clusters {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	if r == nil || len(r.Waves) == 0 {
+		return []Wave{{Name: AllWave, Clusters: names}}, nil
+	}
+	assigned := sets.NewString()
+	waves := []Wave{}
+	for _, w := range r.Waves {
+		selector := labels.Everything()
+		if w.ClusterSelector != nil {
+			var err error
+			if selector, err = metav1.LabelSelectorAsSelector(w.ClusterSelector); err != nil {
+				return nil, fmt.Errorf("invalid cluster selector of wave %s: %w", w.Name, err)
+			}
+		}
+		wave := Wave{Name: w.Name, SoakTime: w.SoakTime}
+		for _, c := range names {
+			if !assigned.Has(c) && selector.Matches(clusters[c]) {
+				wave.Clusters = append(wave.Clusters, c)
+				assigned.Insert(c)
+			}
+		}
+		waves = append(waves, wave)
+	}
+	if r.Waves[len(r.Waves)-1].ClusterSelector != nil {
+		waves = append(waves, Wave{Name: RemainingWave, Clusters: sets.NewString(names...).Difference(assigned).List()})
+	}
+	return waves, nil
+}
+
+// Plan returns the plan of the rollout of the digest in the waves, and true when it is
+// a new plan. The previous plan goes on when it rolls out the same digest in the same
+// waves, with the clusters of its waves updated: the clusters joining a wave already
+// completed are rolled out right away. The current wave is paused when listed in
+// paused.
+func Plan(previous *appv1alpha1.RolloutPlan, digest string, waves []Wave, analysis bool, paused sets.String, now time.Time) (*appv1alpha1.RolloutPlan, bool) {
+	planned := make([]appv1alpha1.WavePlan, len(waves))
+	for i, w := range waves {
+		gates := []appv1alpha1.RolloutGate{{Type: appv1alpha1.GateAvailable}}
+		if w.SoakTime != nil && w.SoakTime.Duration > 0 {
+			gates = append(gates, appv1alpha1.RolloutGate{Type: appv1alpha1.GateSoak, Duration: w.SoakTime.DeepCopy()})
+		}
+		if analysis {
+			gates = append(gates, appv1alpha1.RolloutGate{Type: appv1alpha1.GateAnalysis})
+		}
+		planned[i] = appv1alpha1.WavePlan{Name: w.Name, Clusters: w.Clusters, Gates: gates, Phase: appv1alpha1.WavePending}
+	}
+	if previous != nil && previous.Digest == digest && sameWaves(previous.Waves, planned) {
+		plan := previous.DeepCopy()
+		for i := range plan.Waves {
+			plan.Waves[i].Clusters = planned[i].Clusters
+		}
+		pause(plan, paused, now)
+		return plan, false
+	}
+	plan := &appv1alpha1.RolloutPlan{Digest: digest, ComputedAt: metav1.NewTime(now), Waves: planned}
+	pause(plan, paused, now)
+	return plan, true
+}
+
+// sameWaves returns true when the waves have the same names and gates
+func sameWaves(previous, planned []appv1alpha1.WavePlan) bool {
+	if len(previous) != len(planned) {
+		return false
+	}
+	for i := range previous {
+		if previous[i].Name != planned[i].Name || len(previous[i].Gates) != len(planned[i].Gates) {
+			return false
+		}
+		for j, g := range previous[i].Gates {
+			p := planned[i].Gates[j]
+			if g.Type != p.Type || (g.Duration == nil) != (p.Duration == nil) ||
+				(g.Duration != nil && g.Duration.Duration != p.Duration.Duration) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pause pauses the current wave of the plan when listed in paused, and starts or
+// resumes it otherwise. It returns true when the wave starts.
+func pause(plan *appv1alpha1.RolloutPlan, paused sets.String, now time.Time) bool {
+	if Completed(plan) {
+		return false
+	}
+	w := &plan.Waves[plan.CurrentWave]
+	switch {
+	case paused.Has(w.Name):
+		w.Phase = appv1alpha1.WavePaused
+	case w.StartedAt == nil:
+		t := metav1.NewTime(now)
+		w.Phase, w.StartedAt = appv1alpha1.WaveProgressing, &t
+		return true
+	default:
+		w.Phase = appv1alpha1.WaveProgressing
+	}
+	return false
+}
+
+// Completed returns true once all the waves of the plan completed
+func Completed(plan *appv1alpha1.RolloutPlan) bool {
+	return int(plan.CurrentWave) >= len(plan.Waves)
+}
+
+// Held returns the clusters of the waves not started, which keep their previous
+// content
+func Held(plan *appv1alpha1.RolloutPlan) []string {
+	held := []string{}
+	for i := int(plan.CurrentWave); i < len(plan.Waves); i++ {
+		if plan.Waves[i].StartedAt == nil {
+			held = append(held, plan.Waves[i].Clusters...)
+		}
+	}
+	return held
+}
+
+// Progress is the state of a cluster in a rollout
+type Progress struct {
+	// Updated is true when the work of the cluster has the content rolled out
+	Updated bool
+	// Available is true when the work agent applied the content rolled out and
+	// reports it Available
+	Available bool
+	// Skipped is true when the rollout does not wait for the cluster, unreachable
+	// beyond the toleration of the availability policy
+	Skipped bool
+}
+
+// Advance records the clusters updated in each wave and passes the gates of the current
+// wave in order, the next wave starting once they all passed unless it is paused. The
+// gates wait for all the clusters of the wave but the skipped ones, and do not pass
+// while it is paused. It
+// returns the names of the waves started, and the delay before the soak gate of the
+// current wave passes, 0 if it does not wait on one.
+func Advance(plan *appv1alpha1.RolloutPlan, progress map[string]Progress, analysisPassed bool, paused sets.String, now time.Time) ([]string, time.Duration) {
+	for i := range plan.Waves {
+		w := &plan.Waves[i]
+		w.Updated = nil
+		for _, c := range w.Clusters {
+			if progress[c].Updated {
+				w.Updated = append(w.Updated, c)
+			}
+		}
+	}
+	started := []string{}
+	if pause(plan, paused, now) {
+		started = append(started, plan.Waves[plan.CurrentWave].Name)
+	}
+	for !Completed(plan) {
+		w := &plan.Waves[plan.CurrentWave]
+		if w.Phase == appv1alpha1.WavePaused {
+			return started, 0
+		}
+		wait, passed := passGates(w, progress, analysisPassed, now)
+		if !passed {
+			return started, wait
+		}
+		t := metav1.NewTime(now)
+		w.Phase, w.CompletedAt = appv1alpha1.WaveCompleted, &t
+		plan.CurrentWave++
+		if pause(plan, paused, now) {
+			started = append(started, plan.Waves[plan.CurrentWave].Name)
+		}
+	}
+	return started, 0
+}
+
+// passGates passes the gates of the wave in order, up to the first one not passing. The
+// gates of the waves without clusters pass right away.
+func passGates(w *appv1alpha1.WavePlan, progress map[string]Progress, analysisPassed bool, now time.Time) (time.Duration, bool) {
+	var availableAt time.Time
+	for i := range w.Gates {
+		g := &w.Gates[i]
+		if g.PassedAt != nil {
+			if g.Type == appv1alpha1.GateAvailable {
+				availableAt = g.PassedAt.Time
+			}
+			continue
+		}
+		if len(w.Clusters) > 0 {
+			switch g.Type {
+			case appv1alpha1.GateAvailable:
+				for _, c := range w.Clusters {
+					if p := progress[c]; !p.Available && !p.Skipped {
+						return 0, false
+					}
+				}
+				availableAt = now
+			case appv1alpha1.GateSoak:
+				if end := availableAt.Add(g.Duration.Duration); now.Before(end) {
+					return end.Sub(now), false
+				}
+			case appv1alpha1.GateAnalysis:
+				if !analysisPassed {
+					return 0, false
+				}
+			}
+		}
+		t := metav1.NewTime(now)
+		g.PassedAt = &t
+	}
+	return 0, true
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+var clusters = map[string]labels.Set{
+	"canary1": {"tier": "canary"},
+	"prod1":   {"tier": "prod", "region": "eu"},
+	"prod2":   {"tier": "prod", "region": "us"},
+	"dev1":    {"tier": "dev"},
+}
+
+func TestAssign(t *testing.T) {
+	waves, _, err := Assign(nil, nil, clusters)
+	if err != nil || len(waves) != 1 || waves[0].Name != AllWave || len(waves[0].Clusters) != 4 {
+		t.Fatalf("expected a single wave of all the clusters, got %+v, %v", waves, err)
+	}
+
+	r := &appv1alpha1.Rollout{Waves: []appv1alpha1.RolloutWave{
+		{Name: "canary", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "canary"}}},
+		{Name: "prod", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}}},
+	}}
+	waves, _, err = Assign(r, nil, clusters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := [][]string{}
+	for _, w := range waves {
+		names = append(names, append([]string{w.Name}, w.Clusters...))
+	}
+	expected := [][]string{{"canary", "canary1"}, {"prod", "prod1", "prod2"}, {RemainingWave, "dev1"}}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	r.Waves = append(r.Waves, appv1alpha1.RolloutWave{Name: "rest"})
+	if waves, _, _ = Assign(r, nil, clusters); len(waves) != 3 || waves[2].Name != "rest" {
+		t.Errorf("expected no remaining wave after a wave without selector, got %+v", waves)
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	waves := []Wave{
+		{Name: "canary", Clusters: []string{"canary1"}, SoakTime: &metav1.Duration{Duration: time.Hour}},
+		{Name: "empty"},
+		{Name: "prod", Clusters: []string{"prod1", "prod2"}},
+	}
+	plan, created := Plan(nil, "d1", waves, true, nil, now)
+	if !created || plan.Waves[0].Phase != appv1alpha1.WaveProgressing || plan.Waves[2].Phase != appv1alpha1.WavePending {
+		t.Fatalf("expected a new plan starting with the canary wave, got %+v", plan)
+	}
+	if types := len(plan.Waves[0].Gates); types != 3 {
+		t.Errorf("expected the Available, Soak and Analysis gates, got %d gates", types)
+	}
+	if held := Held(plan); !reflect.DeepEqual(held, []string{"prod1", "prod2"}) {
+		t.Errorf("expected the prod clusters held, got %v", held)
+	}
+
+	progress := map[string]Progress{"canary1": {Updated: true}}
+	if started, wait := Advance(plan, progress, true, nil, now); len(started) > 0 || wait != 0 || plan.CurrentWave != 0 {
+		t.Errorf("expected the canary wave to wait for its cluster, got %v, %s", started, wait)
+	}
+	if !reflect.DeepEqual(plan.Waves[0].Updated, []string{"canary1"}) {
+		t.Errorf("expected canary1 updated, got %v", plan.Waves[0].Updated)
+	}
+	progress["canary1"] = Progress{Updated: true, Available: true}
+	if _, wait := Advance(plan, progress, true, nil, now.Add(10*time.Minute)); wait != time.Hour {
+		t.Errorf("expected to soak for an hour, got %s", wait)
+	}
+	if _, wait := Advance(plan, progress, true, nil, now.Add(40*time.Minute)); wait != 30*time.Minute {
+		t.Errorf("expected to soak for 30 more minutes, got %s", wait)
+	}
+	if _, wait := Advance(plan, progress, false, nil, now.Add(80*time.Minute)); wait != 0 || plan.CurrentWave != 0 {
+		t.Errorf("expected the canary wave to wait for the analysis")
+	}
+	started, _ := Advance(plan, progress, true, nil, now.Add(90*time.Minute))
+	if !reflect.DeepEqual(started, []string{"empty", "prod"}) || plan.CurrentWave != 2 {
+		t.Errorf("expected the empty wave to complete and the prod wave to start, got %v at wave %d", started, plan.CurrentWave)
+	}
+	if len(Held(plan)) != 0 {
+		t.Errorf("expected no cluster held during the last wave")
+	}
+
+	// the same digest goes on with the plan, with its clusters updated
+	waves[2].Clusters = []string{"prod1", "prod2", "prod3"}
+	next, created := Plan(plan, "d1", waves, true, nil, now.Add(2*time.Hour))
+	if created || next.CurrentWave != 2 || len(next.Waves[2].Clusters) != 3 {
+		t.Errorf("expected the plan to go on with prod3, got %+v", next)
+	}
+	progress["prod1"] = Progress{Updated: true, Available: true}
+	progress["prod2"] = Progress{Updated: true, Available: true}
+	progress["prod3"] = Progress{Updated: true, Available: true}
+	Advance(next, progress, true, nil, now.Add(2*time.Hour))
+	if !Completed(next) || next.Waves[2].CompletedAt == nil {
+		t.Errorf("expected the rollout to complete, got %+v", next)
+	}
+
+	// a new digest or new waves restart the rollout
+	if p, created := Plan(next, "d2", waves, true, nil, now); !created || p.CurrentWave != 0 {
+		t.Errorf("expected a new plan for a new digest")
+	}
+	if p, created := Plan(next, "d1", waves, false, nil, now); !created || len(p.Waves[0].Gates) != 2 {
+		t.Errorf("expected a new plan for new gates")
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	r := &appv1alpha1.Rollout{Waves: []appv1alpha1.RolloutWave{
+		{Name: "canary", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "canary"}}},
+		{Name: "prod", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}}},
+	}}
+	overrides := &appv1alpha1.RolloutOverrides{Deferrals: []appv1alpha1.ClusterDeferral{
+		{Cluster: "prod1", Wave: RemainingWave},
+		{Cluster: "prod2", Wave: "canary"},
+		{Cluster: "prod9", Wave: "prod"},
+		{Cluster: "canary1", Wave: "staging"},
+	}}
+	waves, ignored, err := Assign(r, overrides, clusters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(waves[1].Clusters, []string{"prod2"}) || !reflect.DeepEqual(waves[2].Clusters, []string{"dev1", "prod1"}) {
+		t.Errorf("expected prod1 moved to the remaining wave, got %+v", waves)
+	}
+	if len(ignored) != 3 {
+		t.Errorf("expected the earlier wave, unknown cluster and unknown wave ignored, got %v", ignored)
+	}
+
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	paused := sets.NewString("canary")
+	plan, _ := Plan(nil, "d1", waves, false, paused, now)
+	if plan.Waves[0].Phase != appv1alpha1.WavePaused || plan.Waves[0].StartedAt != nil {
+		t.Fatalf("expected the canary wave paused before starting, got %+v", plan.Waves[0])
+	}
+	if held := Held(plan); !reflect.DeepEqual(held, []string{"canary1", "prod2", "dev1", "prod1"}) {
+		t.Errorf("expected all the clusters held, got %v", held)
+	}
+	progress := map[string]Progress{"canary1": {Updated: true, Available: true}}
+	if started, _ := Advance(plan, progress, false, paused, now); len(started) > 0 || plan.CurrentWave != 0 {
+		t.Errorf("expected the paused wave not to start, got %v", started)
+	}
+
+	// resuming starts the canary wave, pausing the prod wave stops the rollout after it
+	paused = sets.NewString("prod")
+	started, _ := Advance(plan, progress, false, paused, now)
+	if !reflect.DeepEqual(started, []string{"canary"}) || plan.CurrentWave != 1 || plan.Waves[1].Phase != appv1alpha1.WavePaused {
+		t.Errorf("expected the canary wave to start and complete before the paused prod wave, got %v, %+v", started, plan)
+	}
+	progress["prod2"] = Progress{Updated: true, Available: true}
+	if Advance(plan, progress, false, paused, now); plan.CurrentWave != 1 {
+		t.Errorf("expected the paused prod wave not to complete")
+	}
+	if started, _ = Advance(plan, progress, false, nil, now); !reflect.DeepEqual(started, []string{"prod", RemainingWave}) {
+		t.Errorf("expected the prod wave to resume, got %v", started)
+	}
+}
+
+func TestAdvanceSkipped(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	waves := []Wave{{Name: "canary", Clusters: []string{"canary1", "canary2"}}, {Name: "prod", Clusters: []string{"prod1"}}}
+	plan, _ := Plan(nil, "d1", waves, false, nil, now)
+	progress := map[string]Progress{"canary1": {Updated: true}, "canary2": {Updated: true, Available: true}}
+	if Advance(plan, progress, false, nil, now); plan.CurrentWave != 0 {
+		t.Errorf("expected the canary wave to wait for canary1")
+	}
+	progress["canary1"] = Progress{Updated: true, Skipped: true}
+	if started, _ := Advance(plan, progress, false, nil, now); !reflect.DeepEqual(started, []string{"prod"}) {
+		t.Errorf("expected the prod wave to start past canary1, got %v", started)
+	}
+}
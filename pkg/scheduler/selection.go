@@ -0,0 +1,95 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scheduler narrows the clusters of a placement decision down to the ones a
+// bundle is distributed to
+package scheduler
+
+import (
+	"sort"
+	"strconv"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+const (
+	// CostAnnotation holds the cost of a cluster, lower is better
+	CostAnnotation = "cluster.open-cluster-management.io/cost"
+	// ScoreAnnotation holds the score of a cluster, higher is better
+	ScoreAnnotation = "cluster.open-cluster-management.io/score"
+)
+
+// Rank orders the clusters from best to worst according to the selection, by name
+// if the selection is nil
+func Rank(clusters []*clusterapiv1.ManagedCluster, sel *appv1alpha1.ClusterSelection) []*clusterapiv1.ManagedCluster {
+	type ranked struct {
+		cluster *clusterapiv1.ManagedCluster
+		value   float64
+		known   bool
+	}
+	highest, annotation := false, ""
+	if sel != nil {
+		highest = sel.Strategy == appv1alpha1.SelectHighestScore
+		annotation = sel.Annotation
+		if annotation == "" {
+			annotation = CostAnnotation
+			if highest {
+				annotation = ScoreAnnotation
+			}
+		}
+	}
+
+	candidates := make([]ranked, 0, len(clusters))
+	for _, c := range clusters {
+		r := ranked{cluster: c}
+		if annotation != "" {
+			if v, err := strconv.ParseFloat(c.Annotations[annotation], 64); err == nil {
+				r.value, r.known = v, true
+			}
+		}
+		candidates = append(candidates, r)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.known != b.known {
+			return a.known
+		}
+		if a.value != b.value {
+			if highest {
+				return a.value > b.value
+			}
+			return a.value < b.value
+		}
+		return a.cluster.Name < b.cluster.Name
+	})
+
+	result := make([]*clusterapiv1.ManagedCluster, 0, len(candidates))
+	for _, c := range candidates {
+		result = append(result, c.cluster)
+	}
+	return result
+}
+
+// Names returns the names of the clusters
+func Names(clusters []*clusterapiv1.ManagedCluster) []string {
+	names := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		names = append(names, c.Name)
+	}
+	return names
+}
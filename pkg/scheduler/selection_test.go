@@ -0,0 +1,86 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func cluster(name string, annotations map[string]string, labels map[string]string) *clusterapiv1.ManagedCluster {
+	return &clusterapiv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations, Labels: labels}}
+}
+
+func TestRank(t *testing.T) {
+	clusters := []*clusterapiv1.ManagedCluster{
+		cluster("a", map[string]string{CostAnnotation: "3", ScoreAnnotation: "10"}, nil),
+		cluster("b", map[string]string{CostAnnotation: "1"}, nil),
+		cluster("c", nil, nil),
+		cluster("d", map[string]string{CostAnnotation: "2", ScoreAnnotation: "50"}, nil),
+	}
+
+	ranked := Names(Rank(clusters, &appv1alpha1.ClusterSelection{Clusters: 2}))
+	if !reflect.DeepEqual(ranked, []string{"b", "d", "a", "c"}) {
+		t.Errorf("expected the cheapest clusters first and c without cost last, got %v", ranked)
+	}
+
+	ranked = Names(Rank(clusters, &appv1alpha1.ClusterSelection{Clusters: 3, Strategy: appv1alpha1.SelectHighestScore}))
+	if !reflect.DeepEqual(ranked, []string{"d", "a", "b", "c"}) {
+		t.Errorf("expected the best scored clusters d, a then unscored b and c, got %v", ranked)
+	}
+
+	ranked = Names(Rank(clusters, nil))
+	if !reflect.DeepEqual(ranked, []string{"a", "b", "c", "d"}) {
+		t.Errorf("expected clusters ordered by name without selection, got %v", ranked)
+	}
+}
+
+func TestSpread(t *testing.T) {
+	one, two := int32(1), int32(2)
+	clusters := []*clusterapiv1.ManagedCluster{
+		cluster("a", nil, map[string]string{"region": "us", "zone": "us-1"}),
+		cluster("b", nil, map[string]string{"region": "us", "zone": "us-1"}),
+		cluster("c", nil, map[string]string{"region": "us", "zone": "us-2"}),
+		cluster("d", nil, map[string]string{"region": "eu", "zone": "eu-1"}),
+		cluster("e", nil, nil),
+	}
+
+	kept, unsatisfied := Spread(clusters, []appv1alpha1.SpreadConstraint{
+		{TopologyKey: "zone", MaxClustersPerDomain: &one},
+		{TopologyKey: "region", MinDomains: &two},
+	}, 0)
+	if !reflect.DeepEqual(Names(kept), []string{"a", "c", "d", "e"}) || len(unsatisfied) != 0 {
+		t.Errorf("expected one cluster per zone, got %v, %v", Names(kept), unsatisfied)
+	}
+
+	three := int32(3)
+	_, unsatisfied = Spread(clusters, []appv1alpha1.SpreadConstraint{{TopologyKey: "region", MinDomains: &three}}, 0)
+	if len(unsatisfied) != 1 {
+		t.Errorf("expected the region constraint to be unsatisfiable")
+	}
+
+	// the best two clusters are in the same region, the eu one is preferred to cover two regions
+	kept, unsatisfied = Spread(clusters, []appv1alpha1.SpreadConstraint{{TopologyKey: "region", MinDomains: &two}}, 2)
+	if !reflect.DeepEqual(Names(kept), []string{"a", "d"}) || len(unsatisfied) != 0 {
+		t.Errorf("expected clusters in two regions, got %v, %v", Names(kept), unsatisfied)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// Spread selects up to limit clusters, unlimited if 0, in order as long as they do
+// not exceed the maximum number of clusters per domain of the constraints. Clusters in
+// domains not covered yet are selected first until the constraints requiring a minimum
+// number of domains are satisfied. It returns the selected clusters, in order, and the
+// constraints which cannot be satisfied.
+func Spread(clusters []*clusterapiv1.ManagedCluster, constraints []appv1alpha1.SpreadConstraint, limit int) ([]*clusterapiv1.ManagedCluster, []string) {
+	counts := make([]map[string]int32, len(constraints))
+	for i := range constraints {
+		counts[i] = map[string]int32{}
+	}
+	selected := map[string]bool{}
+	add := func(c *clusterapiv1.ManagedCluster) {
+		if selected[c.Name] || (limit > 0 && len(selected) >= limit) {
+			return
+		}
+		for i, sc := range constraints {
+			domain, ok := c.Labels[sc.TopologyKey]
+			if ok && sc.MaxClustersPerDomain != nil && counts[i][domain] >= *sc.MaxClustersPerDomain {
+				return
+			}
+		}
+		for i, sc := range constraints {
+			if domain, ok := c.Labels[sc.TopologyKey]; ok {
+				counts[i][domain]++
+			}
+		}
+		selected[c.Name] = true
+	}
+
+	// cover the required number of domains first
+	for i, sc := range constraints {
+		if sc.MinDomains == nil {
+			continue
+		}
+		for _, c := range clusters {
+			if int32(len(counts[i])) >= *sc.MinDomains {
+				break
+			}
+			if domain, ok := c.Labels[sc.TopologyKey]; ok && counts[i][domain] == 0 {
+				add(c)
+			}
+		}
+	}
+	for _, c := range clusters {
+		add(c)
+	}
+
+	kept := []*clusterapiv1.ManagedCluster{}
+	for _, c := range clusters {
+		if selected[c.Name] {
+			kept = append(kept, c)
+		}
+	}
+	unsatisfied := []string{}
+	for i, sc := range constraints {
+		if sc.MinDomains != nil && int32(len(counts[i])) < *sc.MinDomains {
+			unsatisfied = append(unsatisfied, fmt.Sprintf("%d %s domains required, %d available",
+				*sc.MinDomains, sc.TopologyKey, len(counts[i])))
+		}
+	}
+	return kept, unsatisfied
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// AWSSecretsManager reads secrets of AWS Secrets Manager with the credentials of the
+// default credential chain of the AWS SDK: the environment variables, the shared
+// configuration, the web identity token of the ServiceAccount (IRSA), or the IAM role
+// of the container or instance
+type AWSSecretsManager struct {
+	Client *secretsmanager.Client
+}
+
+// NewAWSSecretsManager returns the provider of AWS Secrets Manager, its requests timing
+// out after the timeout when not zero. The HTTP client is the one of the SDK, which
+// honors AWS_CA_BUNDLE.
+func NewAWSSecretsManager(ctx context.Context, cfg *appv1alpha1.AWSSecretsManagerProvider, timeout time.Duration) (*AWSSecretsManager, error) {
+	client := awshttp.NewBuildableClient()
+	if timeout > 0 {
+		client = client.WithTimeout(timeout)
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region), config.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the AWS configuration: %w", err)
+	}
+	return &AWSSecretsManager{Client: secretsmanager.NewFromConfig(awsConfig, func(o *secretsmanager.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})}, nil
+}
+
+// Lookup returns the secret string of the secret identified by the path, or the value
+// of the key of the secret string parsed as a JSON object
+func (a *AWSSecretsManager) Lookup(ctx context.Context, path, key string) (string, error) {
+	secret, err := a.Client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(path)})
+	if err != nil {
+		return "", err
+	}
+	if secret.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no secret string", path)
+	}
+	if key == "" {
+		return *secret.SecretString, nil
+	}
+	data := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(*secret.SecretString), &data); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %w", path, err)
+	}
+	return stringValue(data, key)
+}
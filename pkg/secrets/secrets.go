@@ -0,0 +1,225 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package secrets looks up the secret values of the bundles in external secret
+// backends
+package secrets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// DefaultRefreshInterval is the interval between two lookups of a secret value
+const DefaultRefreshInterval = 5 * time.Minute
+
+const (
+	// ProviderVault is the name of the Vault provider
+	ProviderVault = "vault"
+	// ProviderAWSSecretsManager is the name of the AWS Secrets Manager provider
+	ProviderAWSSecretsManager = "awsSecretsManager"
+)
+
+// Provider looks up secrets in an external backend
+type Provider interface {
+	// Lookup returns the value of the key of the secret at the path
+	Lookup(ctx context.Context, path, key string) (string, error)
+}
+
+// Reference is a key of a secret of a provider, looked up for the bundles of a namespace
+type Reference struct {
+	Namespace string
+	Provider  string
+	Path      string
+	Key       string
+}
+
+// NewReference returns the reference of the source of a secret value of a bundle of the
+// namespace, with its path rendered by the function
+func NewReference(namespace string, s appv1alpha1.SecretValueSource, render func(string) (string, error)) (Reference, error) {
+	var ref Reference
+	switch {
+	case s.Vault != nil:
+		ref = Reference{Namespace: namespace, Provider: ProviderVault, Path: s.Vault.Path, Key: s.Vault.Key}
+	case s.AWSSecretsManager != nil:
+		ref = Reference{Namespace: namespace, Provider: ProviderAWSSecretsManager, Path: s.AWSSecretsManager.SecretID, Key: s.AWSSecretsManager.Key}
+	default:
+		return ref, fmt.Errorf("no provider set")
+	}
+	path, err := render(ref.Path)
+	if err != nil {
+		return ref, fmt.Errorf("invalid path %s: %w", ref.Path, err)
+	}
+	ref.Path = path
+	return ref, nil
+}
+
+// Resolver looks up the secret values with the providers of the configuration, and
+// caches them until the refresh interval elapses
+type Resolver struct {
+	HTTP *http.Client
+	// Providers override the providers of the configuration, by name
+	Providers map[string]Provider
+
+	mu    sync.Mutex
+	cache map[Reference]cached
+	// aws is the AWS Secrets Manager provider of awsConfig, kept for the cache of its
+	// credentials
+	aws       *AWSSecretsManager
+	awsConfig appv1alpha1.AWSSecretsManagerProvider
+}
+
+type cached struct {
+	value   string
+	expires time.Time
+}
+
+// Resolve returns the value of the reference, when the configuration allows its namespace
+// to look it up
+func (r *Resolver) Resolve(ctx context.Context, cfg *appv1alpha1.SecretProviders, ref Reference) (string, error) {
+	if !Allowed(cfg, ref) {
+		return "", fmt.Errorf("namespace %s may not look up %s in %s", ref.Namespace, ref.Path, ref.Provider)
+	}
+	ref.Path, _ = CleanPath(ref.Path)
+	r.mu.Lock()
+	previous, found := r.cache[ref]
+	r.mu.Unlock()
+	now := time.Now()
+	if found && now.Before(previous.expires) {
+		return previous.value, nil
+	}
+	p, err := r.provider(ctx, cfg, ref.Provider)
+	if err != nil {
+		return "", err
+	}
+	value, err := p.Lookup(ctx, ref.Path, ref.Key)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up %s in %s: %w", ref.Path, ref.Provider, err)
+	}
+	interval := DefaultRefreshInterval
+	if cfg != nil && cfg.RefreshInterval != nil {
+		interval = cfg.RefreshInterval.Duration
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cache == nil {
+		r.cache = map[Reference]cached{}
+	}
+	for k, c := range r.cache {
+		if now.After(c.expires) {
+			delete(r.cache, k)
+		}
+	}
+	r.cache[ref] = cached{value: value, expires: now.Add(interval)}
+	return value, nil
+}
+
+// CleanPath returns the path cleaned of its empty and . segments and of its leading
+// slash, false for the paths with a .. segment or a %, ? or # character, which could
+// escape the path once in a URL
+func CleanPath(p string) (string, bool) {
+	if strings.ContainsAny(p, "%?#") {
+		return "", false
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return "", false
+		}
+	}
+	return strings.TrimPrefix(path.Clean("/"+p), "/"), true
+}
+
+// Allowed returns whether the cleaned path of the reference is under one of the prefixes
+// of its namespace, or is one of them, the prefixes matching whole segments. The paths which
+// cannot be cleaned are never allowed.
+func Allowed(cfg *appv1alpha1.SecretProviders, ref Reference) bool {
+	if cfg == nil {
+		return false
+	}
+	p, ok := CleanPath(ref.Path)
+	if !ok || p == "" {
+		return false
+	}
+	for _, n := range cfg.Namespaces {
+		if n.Name != ref.Namespace {
+			continue
+		}
+		for _, prefix := range n.PathPrefixes {
+			// a prefix ending with a slash allows the paths under it only
+			base := strings.Trim(prefix, "/")
+			if base != "" && (strings.HasPrefix(p, base+"/") || (p == base && !strings.HasSuffix(prefix, "/"))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Hash returns a hash of the values, by name, changed when any of them rotates. It is
+// truncated so that the values cannot be guessed from it.
+func Hash(values map[string]string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(values))
+	for n := range values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, n := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", n, values[n])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// provider returns the named provider
+func (r *Resolver) provider(ctx context.Context, cfg *appv1alpha1.SecretProviders, name string) (Provider, error) {
+	if p, ok := r.Providers[name]; ok {
+		return p, nil
+	}
+	client := r.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	switch {
+	case name == ProviderVault && cfg != nil && cfg.Vault != nil:
+		return NewVault(cfg.Vault, client), nil
+	case name == ProviderAWSSecretsManager && cfg != nil && cfg.AWSSecretsManager != nil:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.aws != nil && r.awsConfig == *cfg.AWSSecretsManager {
+			return r.aws, nil
+		}
+		a, err := NewAWSSecretsManager(ctx, cfg.AWSSecretsManager, client.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		r.aws, r.awsConfig = a, *cfg.AWSSecretsManager
+		return a, nil
+	}
+	return nil, fmt.Errorf("the %s secret provider is not configured", name)
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+type fakeProvider struct {
+	values  map[string]string
+	lookups int
+}
+
+func (p *fakeProvider) Lookup(ctx context.Context, path, key string) (string, error) {
+	p.lookups++
+	v, ok := p.values[path+"#"+key]
+	if !ok {
+		return "", fmt.Errorf("no secret %s", path)
+	}
+	return v, nil
+}
+
+func TestResolve(t *testing.T) {
+	p := &fakeProvider{values: map[string]string{"shop/db#password": "v1"}}
+	r := &Resolver{Providers: map[string]Provider{ProviderVault: p}}
+	cfg := &appv1alpha1.SecretProviders{
+		Namespaces: []appv1alpha1.SecretNamespace{
+			{Name: "shop", PathPrefixes: []string{"shop/"}},
+			{Name: "blog", PathPrefixes: []string{"blog/"}},
+		},
+		RefreshInterval: &metav1.Duration{Duration: time.Hour},
+	}
+	ref := Reference{Namespace: "shop", Provider: ProviderVault, Path: "shop/db", Key: "password"}
+
+	v, err := r.Resolve(context.TODO(), cfg, ref)
+	if err != nil || v != "v1" {
+		t.Fatalf("expected v1, got %q, %v", v, err)
+	}
+	p.values["shop/db#password"] = "v2"
+	if v, _ := r.Resolve(context.TODO(), cfg, ref); v != "v1" || p.lookups != 1 {
+		t.Errorf("expected the cached v1, got %q after %d lookups", v, p.lookups)
+	}
+
+	// expire the cached value
+	r.cache[ref] = cached{value: "v1", expires: time.Now().Add(-time.Second)}
+	v, err = r.Resolve(context.TODO(), cfg, ref)
+	if err != nil || v != "v2" {
+		t.Errorf("expected the rotated v2, got %q, %v", v, err)
+	}
+
+	// the value cached for a namespace is not served to the others
+	other := ref
+	other.Namespace = "blog"
+	if v, err := r.Resolve(context.TODO(), cfg, other); err == nil {
+		t.Errorf("expected the path of another namespace to be denied, got %q", v)
+	}
+
+	if _, err := r.Resolve(context.TODO(), cfg, Reference{Namespace: "shop", Provider: ProviderAWSSecretsManager, Path: "shop/db"}); err == nil {
+		t.Error("expected an unconfigured provider to fail")
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	cfg := &appv1alpha1.SecretProviders{Namespaces: []appv1alpha1.SecretNamespace{
+		{Name: "shop", PathPrefixes: []string{"secret/data/shop/", "/kv/shop/"}},
+		{Name: "blog", PathPrefixes: []string{""}},
+	}}
+	tests := []struct {
+		namespace, path string
+		expected        bool
+	}{
+		{namespace: "shop", path: "secret/data/shop/db", expected: true},
+		{namespace: "shop", path: "/secret/data/shop/db", expected: true},
+		{namespace: "shop", path: "kv/shop/db", expected: true},
+		{namespace: "shop", path: "secret/data/shop"},
+		{namespace: "shop", path: "secret/data/shop//./db", expected: true},
+		{namespace: "shop", path: "secret/data/shopping/db"},
+		{namespace: "shop", path: "secret/data/shop/../blog/db"},
+		{namespace: "shop", path: "secret/data/shop/%2e%2e/%2e%2e/blog/db"},
+		{namespace: "shop", path: "secret/data/blog/db?x=secret/data/shop/db"},
+		{namespace: "shop", path: "secret/data/blog/db#secret/data/shop/db"},
+		{namespace: "shop", path: "kv/shop"},
+		{namespace: "shop", path: "kv/shopping/db"},
+		{namespace: "blog", path: "secret/data/shop/db"},
+		{namespace: "blog", path: "blog/db"},
+		{namespace: "default", path: "secret/data/shop/db"},
+	}
+	for _, tt := range tests {
+		ref := Reference{Namespace: tt.namespace, Provider: ProviderVault, Path: tt.path}
+		if allowed := Allowed(cfg, ref); allowed != tt.expected {
+			t.Errorf("Allowed(%s, %s) = %v, expected %v", tt.namespace, tt.path, allowed, tt.expected)
+		}
+	}
+	if Allowed(nil, Reference{Namespace: "shop", Path: "secret/data/shop/db"}) {
+		t.Error("expected no path to be allowed without configuration")
+	}
+	exact := &appv1alpha1.SecretProviders{Namespaces: []appv1alpha1.SecretNamespace{
+		{Name: "shop", PathPrefixes: []string{"secret/data/shop"}},
+	}}
+	if !Allowed(exact, Reference{Namespace: "shop", Path: "secret/data/shop"}) {
+		t.Error("expected the path of a prefix to be allowed")
+	}
+	if Allowed(exact, Reference{Namespace: "shop", Path: "secret/data/shop-admin/db"}) {
+		t.Error("expected the prefix to match whole segments")
+	}
+}
+
+func TestHash(t *testing.T) {
+	if h := Hash(nil); h != "" {
+		t.Errorf("expected no hash without values, got %q", h)
+	}
+	h := Hash(map[string]string{"db": "v1", "api": "k1"})
+	if len(h) != 16 {
+		t.Errorf("expected a truncated hash, got %q", h)
+	}
+	if Hash(map[string]string{"api": "k1", "db": "v1"}) != h {
+		t.Error("expected the hash to not depend on the order of the values")
+	}
+	if Hash(map[string]string{"db": "v2", "api": "k1"}) == h {
+		t.Error("expected a rotation to change the hash")
+	}
+	if Hash(map[string]string{"db": "v1k1"}) == Hash(map[string]string{"db": "v1", "k1": ""}) {
+		t.Error("expected the names and values to be delimited")
+	}
+}
+
+func TestNewReference(t *testing.T) {
+	render := func(s string) (string, error) { return strings.ReplaceAll(s, "{{ .Name }}", "cluster1"), nil }
+	ref, err := NewReference("shop", appv1alpha1.SecretValueSource{
+		AWSSecretsManager: &appv1alpha1.AWSSecretReference{SecretID: "shop/{{ .Name }}/db", Key: "password"},
+	}, render)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Reference{Namespace: "shop", Provider: ProviderAWSSecretsManager, Path: "shop/cluster1/db", Key: "password"}); ref != expected {
+		t.Errorf("expected %v, got %v", expected, ref)
+	}
+	if _, err := NewReference("shop", appv1alpha1.SecretValueSource{}, render); err == nil {
+		t.Error("expected a source without provider to fail")
+	}
+}
+
+func TestVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/shop/db":
+			fmt.Fprint(w, `{"data":{"data":{"password":"kv2","port":5432},"metadata":{"version":3}}}`)
+		case "/v1/kv/shop/db":
+			fmt.Fprint(w, `{"data":{"password":"kv1"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	v := &Vault{Address: server.URL, Token: "s.token", HTTP: server.Client()}
+	tests := []struct {
+		path, key, expected string
+	}{
+		{path: "secret/data/shop/db", key: "password", expected: "kv2"},
+		{path: "secret/data/shop/db", key: "port", expected: "5432"},
+		{path: "kv/shop/db", key: "password", expected: "kv1"},
+		{path: "/kv/shop/db", key: "password", expected: "kv1"},
+	}
+	for _, tt := range tests {
+		value, err := v.Lookup(context.TODO(), tt.path, tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != tt.expected {
+			t.Errorf("Lookup(%s, %s) = %q, expected %q", tt.path, tt.key, value, tt.expected)
+		}
+	}
+	if _, err := v.Lookup(context.TODO(), "kv/shop/db", "user"); err == nil {
+		t.Error("expected a missing key to fail")
+	}
+	// the segments are escaped rather than interpreted by the server
+	if _, err := v.Lookup(context.TODO(), "kv/shop/db?x=1", "password"); err == nil {
+		t.Error("expected a query in the path to be escaped")
+	}
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_ = json.NewEncoder(w).Encode(map[string]string{"Name": body["SecretId"], "SecretString": `{"password":"aws"}`})
+	}))
+	defer server.Close()
+	a, err := NewAWSSecretsManager(context.TODO(), &appv1alpha1.AWSSecretsManagerProvider{Region: "eu-west-1", Endpoint: server.URL}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := a.Lookup(context.TODO(), "shop/db", "password"); err != nil || v != "aws" {
+		t.Errorf("expected the password, got %q, %v", v, err)
+	}
+	if v, err := a.Lookup(context.TODO(), "shop/db", ""); err != nil || v != `{"password":"aws"}` {
+		t.Errorf("expected the secret string, got %q, %v", v, err)
+	}
+}
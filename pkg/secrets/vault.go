@@ -0,0 +1,96 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// Vault reads secrets of a Vault server with the token of the VAULT_TOKEN environment
+// variable
+type Vault struct {
+	Address   string
+	Namespace string
+	Token     string
+	HTTP      *http.Client
+}
+
+// NewVault returns the provider of the Vault server
+func NewVault(cfg *appv1alpha1.VaultProvider, client *http.Client) *Vault {
+	return &Vault{Address: cfg.Address, Namespace: cfg.Namespace, Token: os.Getenv("VAULT_TOKEN"), HTTP: client}
+}
+
+// Lookup returns the value of the key of the secret at the path, read with the KV
+// version 1 or 2 engine. Each segment of the path is escaped in the URL.
+func (v *Vault) Lookup(ctx context.Context, path, key string) (string, error) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	u := strings.TrimSuffix(v.Address, "/") + "/v1/" + strings.Join(segments, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	resp, err := v.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s", resp.Status)
+	}
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("invalid vault secret: %w", err)
+	}
+	data := secret.Data
+	// the KV version 2 engine nests the data with its metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+	return stringValue(data, key)
+}
+
+// stringValue returns the value of the key, in JSON when not a string
+func stringValue(data map[string]interface{}, key string) (string, error) {
+	v, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("no key %s in the secret", key)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	out, err := json.Marshal(v)
+	return string(out), err
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package securitygate reviews the images of the bundles with an external scanner
+// before they are distributed
+package securitygate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// DefaultRecheckInterval is the interval between the reviews of unchanged images
+const DefaultRecheckInterval = time.Hour
+
+// Review is the payload posted to the scanner webhook
+type Review struct {
+	Namespace         string   `json:"namespace"`
+	Bundle            string   `json:"bundle"`
+	Generation        int64    `json:"generation"`
+	Images            []string `json:"images"`
+	SeverityThreshold string   `json:"severityThreshold,omitempty"`
+}
+
+// Result is the answer of the scanner webhook
+type Result struct {
+	Allowed  bool      `json:"allowed"`
+	Message  string    `json:"message,omitempty"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Finding is a vulnerability found by the scanner in an image
+type Finding struct {
+	Image    string `json:"image"`
+	ID       string `json:"id"`
+	Severity string `json:"severity"`
+}
+
+// Summary returns the message of the result, or the list of its findings
+func (r *Result) Summary() string {
+	if r.Message != "" || len(r.Findings) == 0 {
+		return r.Message
+	}
+	findings := []string{}
+	for _, f := range r.Findings {
+		findings = append(findings, fmt.Sprintf("%s %s in %s", f.Severity, f.ID, f.Image))
+	}
+	return strings.Join(findings, ", ")
+}
+
+// Gate posts reviews to the scanner webhook of the configuration, and caches the
+// results until the recheck interval elapses
+type Gate struct {
+	Client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+type cached struct {
+	result  *Result
+	expires time.Time
+}
+
+// Review returns the result of the review of the images by the scanner
+func (g *Gate) Review(ctx context.Context, cfg *appv1alpha1.SecurityGate, review *Review) (*Result, error) {
+	review.SeverityThreshold = cfg.SeverityThreshold
+	key := cfg.URL + "|" + cfg.SeverityThreshold + "|" + strings.Join(review.Images, ",")
+	g.mu.Lock()
+	if c, ok := g.cache[key]; ok && time.Now().Before(c.expires) {
+		g.mu.Unlock()
+		return c.result, nil
+	}
+	g.mu.Unlock()
+
+	result, err := g.post(ctx, cfg.URL, review)
+	if err != nil {
+		return nil, err
+	}
+	interval := DefaultRecheckInterval
+	if cfg.RecheckInterval != nil {
+		interval = cfg.RecheckInterval.Duration
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cache == nil {
+		g.cache = map[string]cached{}
+	}
+	now := time.Now()
+	for k, c := range g.cache {
+		if now.After(c.expires) {
+			delete(g.cache, k)
+		}
+	}
+	g.cache[key] = cached{result: result, expires: now.Add(interval)}
+	return result, nil
+}
+
+func (g *Gate) post(ctx context.Context, url string, review *Review) (*Result, error) {
+	data, err := json.Marshal(review)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scanner returned %s", resp.Status)
+	}
+	result := &Result{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("invalid scanner result: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package securitygate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func TestReview(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		review := &Review{}
+		if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.SeverityThreshold != "High" {
+			http.Error(w, "bad review", http.StatusBadRequest)
+			return
+		}
+		result := Result{Allowed: true}
+		for _, image := range review.Images {
+			if image == "ghcr.io/acme/web:v1" {
+				result = Result{Findings: []Finding{{Image: image, ID: "CVE-2022-0001", Severity: "Critical"}}}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+
+	g := &Gate{}
+	cfg := &appv1alpha1.SecurityGate{URL: server.URL, SeverityThreshold: "High"}
+	result, err := g.Review(context.TODO(), cfg, &Review{Bundle: "web", Images: []string{"ghcr.io/acme/web:v1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || result.Summary() != "Critical CVE-2022-0001 in ghcr.io/acme/web:v1" {
+		t.Errorf("expected the image to be denied, got %+v", result)
+	}
+	if _, err := g.Review(context.TODO(), cfg, &Review{Bundle: "web", Images: []string{"ghcr.io/acme/web:v1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the result to be cached, got %d calls", calls)
+	}
+	result, err = g.Review(context.TODO(), cfg, &Review{Bundle: "web", Images: []string{"ghcr.io/acme/web:v2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed {
+		t.Errorf("expected the image to be allowed, got %+v", result)
+	}
+
+	cfg.SeverityThreshold = "Low"
+	if _, err := g.Review(context.TODO(), cfg, &Review{Bundle: "web", Images: []string{"ghcr.io/acme/web:v2"}}); err == nil {
+		t.Error("expected a scanner error to fail")
+	}
+}
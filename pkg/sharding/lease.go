@@ -0,0 +1,166 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationclient "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/klog/v2"
+)
+
+// LeaseAssigner assigns a free shard to a replica by holding the lease of the shard
+type LeaseAssigner struct {
+	Client    coordinationclient.LeasesGetter
+	Namespace string
+	Prefix    string
+	Identity  string
+	Count     int
+
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+
+	now func() time.Time
+}
+
+func (a *LeaseAssigner) leaseName(id int) string {
+	return fmt.Sprintf("%s%d", a.Prefix, id)
+}
+
+func (a *LeaseAssigner) clock() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now()
+}
+
+// Acquire blocks until the lease of one of the shards is acquired or the context is
+// done, then keeps renewing the lease in the background until the context is done.
+// onLost is called if the lease cannot be renewed.
+func (a *LeaseAssigner) Acquire(ctx context.Context, onLost func()) (*Shard, error) {
+	for {
+		for id := 0; id < a.Count; id++ {
+			acquired, err := a.tryAcquire(ctx, id)
+			if err != nil {
+				klog.Errorf("Error acquiring lease %s: %v", a.leaseName(id), err)
+				continue
+			}
+			if acquired {
+				klog.Infof("Acquired lease %s for shard %d/%d", a.leaseName(id), id, a.Count)
+				go a.renew(ctx, id, onLost)
+				return &Shard{ID: id, Count: a.Count}, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(a.RetryPeriod):
+		}
+	}
+}
+
+// tryAcquire takes the lease of a shard if it is free, expired or already held
+func (a *LeaseAssigner) tryAcquire(ctx context.Context, id int) (bool, error) {
+	now := metav1.NewMicroTime(a.clock())
+	seconds := int32(a.LeaseDuration.Seconds())
+	leases := a.Client.Leases(a.Namespace)
+
+	lease, err := leases.Get(ctx, a.leaseName(id), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: a.leaseName(id), Namespace: a.Namespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &a.Identity,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	held := lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == a.Identity
+	if !held && !a.expired(lease) {
+		return false, nil
+	}
+	if !held {
+		lease.Spec.AcquireTime = &now
+		transitions := int32(0)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions + 1
+		}
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	lease.Spec.HolderIdentity = &a.Identity
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// expired returns true if the holder of the lease did not renew it in time
+func (a *LeaseAssigner) expired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" ||
+		lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	duration := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	return lease.Spec.RenewTime.Add(duration).Before(a.clock())
+}
+
+// renew renews the lease of the shard every retry period, calling onLost if it could
+// not be renewed within the renew deadline
+func (a *LeaseAssigner) renew(ctx context.Context, id int, onLost func()) {
+	renewed := a.clock()
+	ticker := time.NewTicker(a.RetryPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ok, err := a.tryAcquire(ctx, id)
+		if ok {
+			renewed = a.clock()
+			continue
+		}
+		if err != nil {
+			klog.Errorf("Error renewing lease %s: %v", a.leaseName(id), err)
+		}
+		if a.clock().Sub(renewed) > a.RenewDeadline {
+			klog.Errorf("Lost lease %s for shard %d/%d", a.leaseName(id), id, a.Count)
+			onLost()
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sharding splits the AppBundles between several controller replicas
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Shard identifies the subset of the bundles owned by a replica. A nil shard owns
+// all bundles.
+type Shard struct {
+	ID    int
+	Count int
+}
+
+// NewShard returns the shard id out of count, or nil when sharding is disabled
+func NewShard(id, count int) (*Shard, error) {
+	if count <= 1 {
+		return nil, nil
+	}
+	if id < 0 || id >= count {
+		return nil, fmt.Errorf("shard %d out of range [0, %d)", id, count)
+	}
+	return &Shard{ID: id, Count: count}, nil
+}
+
+// Of returns the shard of the bundle namespace/name out of count
+func Of(namespace, name string, count int) int {
+	h := fnv.New32a()
+	h.Write([]byte(namespace + "/" + name))
+	return int(h.Sum32() % uint32(count))
+}
+
+// Owns returns true if the bundle namespace/name belongs to the shard
+func (s *Shard) Owns(namespace, name string) bool {
+	if s == nil {
+		return true
+	}
+	return Of(namespace, name, s.Count) == s.ID
+}
+
+// Lead returns true if the replica of the shard runs the controllers which are not
+// sharded, the replica of the first shard. A nil shard runs them.
+func (s *Shard) Lead() bool {
+	return s == nil || s.ID == 0
+}
+
+// Predicate filters the events of the objects not belonging to the shard
+func (s *Shard) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return s.Owns(obj.GetNamespace(), obj.GetName())
+	})
+}
+
+// String returns the shard as id/count
+func (s *Shard) String() string {
+	if s == nil {
+		return "all"
+	}
+	return fmt.Sprintf("%d/%d", s.ID, s.Count)
+}
+
+// OrdinalFromHostname returns the ordinal of a StatefulSet pod from its hostname,
+// e.g. 2 for kealm-controller-manager-2
+func OrdinalFromHostname(hostname string) (int, error) {
+	i := strings.LastIndex(hostname, "-")
+	if i < 0 {
+		return 0, fmt.Errorf("hostname %q has no ordinal suffix", hostname)
+	}
+	ordinal, err := strconv.Atoi(hostname[i+1:])
+	if err != nil || ordinal < 0 {
+		return 0, fmt.Errorf("hostname %q has no ordinal suffix", hostname)
+	}
+	return ordinal, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOwns(t *testing.T) {
+	count := 3
+	owners := map[int]int{}
+	for i := 0; i < 300; i++ {
+		name := fmt.Sprintf("bundle-%d", i)
+		owned := 0
+		for id := 0; id < count; id++ {
+			s, err := NewShard(id, count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Owns("ns", name) {
+				owned++
+				owners[id]++
+			}
+		}
+		if owned != 1 {
+			t.Fatalf("bundle %s owned by %d shards", name, owned)
+		}
+	}
+	for id := 0; id < count; id++ {
+		if owners[id] == 0 {
+			t.Errorf("shard %d owns no bundle", id)
+		}
+	}
+
+	var all *Shard
+	if !all.Owns("ns", "any") {
+		t.Error("nil shard should own all bundles")
+	}
+	for id := 0; id < count; id++ {
+		s, _ := NewShard(id, count)
+		if s.Lead() != (id == 0) {
+			t.Errorf("expected only shard 0 to lead, shard %d leads: %t", id, s.Lead())
+		}
+	}
+	if !all.Lead() {
+		t.Error("nil shard should lead")
+	}
+	if _, err := NewShard(3, 3); err == nil {
+		t.Error("expected an error for an out of range shard")
+	}
+}
+
+func TestOrdinalFromHostname(t *testing.T) {
+	if o, err := OrdinalFromHostname("kealm-controller-manager-2"); err != nil || o != 2 {
+		t.Errorf("expected 2, got %d, %v", o, err)
+	}
+	if _, err := OrdinalFromHostname("kealm-controller-manager-7f9c6d"); err == nil {
+		t.Error("expected an error for a hostname without ordinal")
+	}
+}
+
+func TestLeaseAssigner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewSimpleClientset().CoordinationV1()
+	now := time.Unix(1000, 0)
+	assigner := func(identity string) *LeaseAssigner {
+		return &LeaseAssigner{
+			Client: client, Namespace: "kealm", Prefix: "kealm-shard-", Identity: identity, Count: 2,
+			LeaseDuration: 15 * time.Second, RenewDeadline: 10 * time.Second, RetryPeriod: time.Hour,
+			now: func() time.Time { return now },
+		}
+	}
+
+	a, err := assigner("a").Acquire(ctx, func() {})
+	if err != nil || a.ID != 0 {
+		t.Fatalf("expected shard 0, got %v, %v", a, err)
+	}
+	b, err := assigner("b").Acquire(ctx, func() {})
+	if err != nil || b.ID != 1 {
+		t.Fatalf("expected shard 1, got %v, %v", b, err)
+	}
+
+	// a third replica only gets a shard once a lease expires
+	c := assigner("c")
+	if ok, err := c.tryAcquire(ctx, 0); ok || err != nil {
+		t.Fatalf("expected lease held by a, got %v, %v", ok, err)
+	}
+	now = now.Add(time.Minute)
+	if ok, err := c.tryAcquire(ctx, 0); !ok || err != nil {
+		t.Fatalf("expected expired lease to be acquired, got %v, %v", ok, err)
+	}
+}
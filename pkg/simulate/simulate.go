@@ -0,0 +1,116 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulate evaluates hypothetical placement decisions and cluster label
+// changes, to report the clusters the bundles would gain or lose without applying
+// anything
+package simulate
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+)
+
+// Relabel returns a copy of the cluster with the labels set and the removed labels
+// deleted
+func Relabel(cluster *clusterapiv1.ManagedCluster, set map[string]string, removed []string) *clusterapiv1.ManagedCluster {
+	c := cluster.DeepCopy()
+	if c.Labels == nil {
+		c.Labels = map[string]string{}
+	}
+	for k, v := range set {
+		c.Labels[k] = v
+	}
+	for _, k := range removed {
+		delete(c.Labels, k)
+	}
+	return c
+}
+
+// Matches returns true if the cluster satisfies one of the predicates of the
+// placement, or the placement has none. The cluster sets bound to the namespace of
+// the placement are not evaluated.
+func Matches(placement *clusterapiv1alpha1.Placement, cluster *clusterapiv1.ManagedCluster) (bool, error) {
+	if len(placement.Spec.Predicates) == 0 {
+		return true, nil
+	}
+	claims := labels.Set{}
+	for _, claim := range cluster.Status.ClusterClaims {
+		claims[claim.Name] = claim.Value
+	}
+	for i, p := range placement.Spec.Predicates {
+		selector := p.RequiredClusterSelector
+		byLabels, err := metav1.LabelSelectorAsSelector(&selector.LabelSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid label selector of predicate %d of placement %s: %w", i, placement.Name, err)
+		}
+		byClaims, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: selector.ClaimSelector.MatchExpressions})
+		if err != nil {
+			return false, fmt.Errorf("invalid claim selector of predicate %d of placement %s: %w", i, placement.Name, err)
+		}
+		if byLabels.Matches(labels.Set(cluster.Labels)) && byClaims.Matches(claims) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Decide returns the decision of the placement once the changed clusters are
+// evaluated against its predicates: the decided clusters no longer matching are
+// removed, and the matching clusters are added while the decision has fewer clusters
+// than the number of clusters of the placement.
+func Decide(placement *clusterapiv1alpha1.Placement, decided []string, changed []*clusterapiv1.ManagedCluster) ([]string, error) {
+	removed := sets.NewString()
+	added := []string{}
+	current := sets.NewString(decided...)
+	for _, c := range changed {
+		matches, err := Matches(placement, c)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !matches && current.Has(c.Name):
+			removed.Insert(c.Name)
+		case matches && !current.Has(c.Name):
+			added = append(added, c.Name)
+		}
+	}
+	result := []string{}
+	for _, c := range decided {
+		if !removed.Has(c) {
+			result = append(result, c)
+		}
+	}
+	for _, c := range added {
+		if n := placement.Spec.NumberOfClusters; n != nil && len(result) >= int(*n) {
+			break
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// Diff returns the clusters gained and lost going from the current clusters to the
+// next ones, sorted
+func Diff(current, next []string) ([]string, []string) {
+	c, n := sets.NewString(current...), sets.NewString(next...)
+	return n.Difference(c).List(), c.Difference(n).List()
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulate
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+)
+
+func cluster(name string, labels map[string]string) *clusterapiv1.ManagedCluster {
+	return &clusterapiv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func placement(n *int32, matchLabels map[string]string) *clusterapiv1alpha1.Placement {
+	p := &clusterapiv1alpha1.Placement{ObjectMeta: metav1.ObjectMeta{Name: "prod"}}
+	p.Spec.NumberOfClusters = n
+	p.Spec.Predicates = []clusterapiv1alpha1.ClusterPredicate{{
+		RequiredClusterSelector: clusterapiv1alpha1.ClusterSelector{
+			LabelSelector: metav1.LabelSelector{MatchLabels: matchLabels},
+		},
+	}}
+	return p
+}
+
+func TestRelabel(t *testing.T) {
+	c := cluster("cluster1", map[string]string{"env": "dev", "region": "eu"})
+	relabeled := Relabel(c, map[string]string{"env": "prod"}, []string{"region"})
+	if !reflect.DeepEqual(relabeled.Labels, map[string]string{"env": "prod"}) {
+		t.Errorf("unexpected labels %v", relabeled.Labels)
+	}
+	if c.Labels["env"] != "dev" {
+		t.Error("expected the cluster to be left unchanged")
+	}
+}
+
+func TestDecide(t *testing.T) {
+	p := placement(nil, map[string]string{"env": "prod"})
+	changed := []*clusterapiv1.ManagedCluster{
+		cluster("cluster2", map[string]string{"env": "dev"}),
+		cluster("cluster4", map[string]string{"env": "prod"}),
+	}
+	decided, err := Decide(p, []string{"cluster1", "cluster2", "cluster3"}, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decided, []string{"cluster1", "cluster3", "cluster4"}) {
+		t.Errorf("unexpected decision %v", decided)
+	}
+
+	two := int32(2)
+	decided, err = Decide(placement(&two, map[string]string{"env": "prod"}), []string{"cluster1", "cluster3"}, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decided, []string{"cluster1", "cluster3"}) {
+		t.Errorf("expected the number of clusters to be respected, got %v", decided)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	gained, lost := Diff([]string{"cluster1", "cluster2"}, []string{"cluster3", "cluster1"})
+	if !reflect.DeepEqual(gained, []string{"cluster3"}) || !reflect.DeepEqual(lost, []string{"cluster2"}) {
+		t.Errorf("unexpected diff %v, %v", gained, lost)
+	}
+}
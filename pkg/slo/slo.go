@@ -0,0 +1,104 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package slo evaluates the rollout SLOs of the bundles: the fraction of the clusters
+// whose work becomes Available within a window of the write of its content
+package slo
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// Objective is the fraction of the clusters, at least 0 and lower than 1, whose work
+// must become Available within the window
+type Objective struct {
+	Target float64
+	Window time.Duration
+}
+
+// Cluster is the work of a bundle on a cluster, with the time its content was written
+type Cluster struct {
+	Name       string
+	Written    time.Time
+	Conditions []metav1.Condition
+}
+
+// Result counts the clusters which became Available within the window, the ones which
+// missed it, and the ones still within it
+type Result struct {
+	Good, Bad, Pending int
+	// Deadline is the end of the earliest window of the pending clusters, zero when
+	// none is pending
+	Deadline time.Time
+}
+
+// Evaluate classifies the clusters at the given time. A work staying Available across
+// the write of its content is good, the clusters whose write time is unknown are
+// skipped.
+func Evaluate(clusters []Cluster, o Objective, now time.Time) Result {
+	r := Result{}
+	for _, c := range clusters {
+		if c.Written.IsZero() {
+			continue
+		}
+		deadline := c.Written.Add(o.Window)
+		available := meta.FindStatusCondition(c.Conditions, workapiv1.WorkAvailable)
+		switch {
+		case available != nil && available.Status == metav1.ConditionTrue:
+			if available.LastTransitionTime.Time.After(deadline) {
+				r.Bad++
+			} else {
+				r.Good++
+			}
+		case now.After(deadline):
+			r.Bad++
+		default:
+			r.Pending++
+			if r.Deadline.IsZero() || deadline.Before(r.Deadline) {
+				r.Deadline = deadline
+			}
+		}
+	}
+	return r
+}
+
+// SLI returns the fraction of the decided clusters which became Available within the
+// window, 1 when none is decided
+func (r Result) SLI() float64 {
+	if r.Good+r.Bad == 0 {
+		return 1
+	}
+	return float64(r.Good) / float64(r.Good+r.Bad)
+}
+
+// BurnRate returns how fast the rollout consumes the error budget of the objective:
+// 1 when the clusters which missed the window exactly exhaust it. The target must be
+// lower than 1.
+func (r Result) BurnRate(o Objective) float64 {
+	return (1 - r.SLI()) / (1 - o.Target)
+}
+
+// Violated returns true once the clusters which missed the window exceed the error
+// budget of all the clusters, whatever the pending clusters do
+func (r Result) Violated(o Objective) bool {
+	total := r.Good + r.Bad + r.Pending
+	// tolerate the rounding of the budget, e.g. 5% of 20 clusters is 1
+	return float64(r.Bad) > (1-o.Target)*float64(total)+1e-9
+}
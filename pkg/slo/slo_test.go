@@ -0,0 +1,64 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slo
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func available(at time.Time) []metav1.Condition {
+	return []metav1.Condition{{Type: workapiv1.WorkAvailable, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(at)}}
+}
+
+func TestEvaluate(t *testing.T) {
+	written := time.Unix(10000, 0)
+	o := Objective{Target: 0.75, Window: 15 * time.Minute}
+	clusters := []Cluster{
+		{Name: "fast", Written: written, Conditions: available(written.Add(time.Minute))},
+		{Name: "unchanged", Written: written, Conditions: available(written.Add(-time.Hour))},
+		{Name: "slow", Written: written, Conditions: available(written.Add(20 * time.Minute))},
+		{Name: "pending", Written: written.Add(10 * time.Minute)},
+		{Name: "unknown"},
+	}
+	r := Evaluate(clusters, o, written.Add(16*time.Minute))
+	if r.Good != 2 || r.Bad != 1 || r.Pending != 1 {
+		t.Fatalf("got %d good, %d bad and %d pending clusters", r.Good, r.Bad, r.Pending)
+	}
+	if want := written.Add(25 * time.Minute); !r.Deadline.Equal(want) {
+		t.Errorf("got deadline %s, want %s", r.Deadline, want)
+	}
+	if sli := r.SLI(); sli < 0.66 || sli > 0.67 {
+		t.Errorf("got SLI %f", sli)
+	}
+	if burn := r.BurnRate(o); burn < 1.33 || burn > 1.34 {
+		t.Errorf("got burn rate %f", burn)
+	}
+	// 1 of 4 clusters missed the window, within the budget of 25%
+	if r.Violated(o) {
+		t.Error("expected the objective to be met")
+	}
+
+	// the pending cluster misses the window
+	r = Evaluate(clusters, o, written.Add(26*time.Minute))
+	if r.Bad != 2 || r.Pending != 0 || !r.Violated(o) {
+		t.Errorf("expected the objective to be violated, got %+v", r)
+	}
+}
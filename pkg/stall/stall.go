@@ -0,0 +1,84 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package stall detects the works stuck on the managed clusters: not Applied, or still
+// Progressing, long after their content is written, e.g. as the work agent of the
+// cluster is broken
+package stall
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// Work is the work of a bundle on a cluster, with the time its content was written.
+// Generation is the generation of the work written, 0 when unknown.
+type Work struct {
+	Written    time.Time
+	Generation int64
+	Conditions []metav1.Condition
+}
+
+// Check returns whether the work is stalled at the given time, and otherwise the delay
+// until it would be, 0 when it cannot stall: once Applied and not Progressing, or when
+// its write time is unknown. The timeout runs from the later of the write and of the
+// transition of the conditions unsettling the work, so that a work settled long ago
+// is given the timeout to settle again.
+func Check(w Work, timeout time.Duration, now time.Time) (bool, time.Duration) {
+	if w.Written.IsZero() || Settled(w) {
+		return false, 0
+	}
+	since := w.Written
+	if t := unsettledSince(w); t.After(since) {
+		since = t
+	}
+	deadline := since.Add(timeout)
+	if now.After(deadline) {
+		return true, 0
+	}
+	return false, deadline.Sub(now)
+}
+
+// Settled returns true when the work agent applied the generation written and the
+// work is no longer progressing
+func Settled(w Work) bool {
+	applied := meta.FindStatusCondition(w.Conditions, workapiv1.WorkApplied)
+	if applied == nil || applied.Status != metav1.ConditionTrue {
+		return false
+	}
+	// the agents not observing the generations are trusted
+	if applied.ObservedGeneration != 0 && applied.ObservedGeneration < w.Generation {
+		return false
+	}
+	return !meta.IsStatusConditionTrue(w.Conditions, workapiv1.WorkProgressing)
+}
+
+// unsettledSince returns when the conditions unsettling the work last transitioned:
+// Applied not true, or Progressing true
+func unsettledSince(w Work) time.Time {
+	var since time.Time
+	if applied := meta.FindStatusCondition(w.Conditions, workapiv1.WorkApplied); applied != nil && applied.Status != metav1.ConditionTrue {
+		since = applied.LastTransitionTime.Time
+	}
+	if progressing := meta.FindStatusCondition(w.Conditions, workapiv1.WorkProgressing); progressing != nil &&
+		progressing.Status == metav1.ConditionTrue && progressing.LastTransitionTime.After(since) {
+		since = progressing.LastTransitionTime.Time
+	}
+	return since
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stall
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	applied := func(generation int64) []metav1.Condition {
+		return []metav1.Condition{{Type: workapiv1.WorkApplied, Status: metav1.ConditionTrue, ObservedGeneration: generation}}
+	}
+	tests := []struct {
+		name    string
+		work    Work
+		stalled bool
+		recheck time.Duration
+	}{
+		{name: "unknown write", work: Work{}},
+		{name: "applied", work: Work{Written: now.Add(-time.Hour), Generation: 2, Conditions: applied(2)}},
+		{name: "generation not observed", work: Work{Written: now.Add(-time.Hour), Generation: 2, Conditions: applied(0)}},
+		{name: "previous generation", work: Work{Written: now.Add(-time.Hour), Generation: 3, Conditions: applied(2)}, stalled: true},
+		{name: "no condition", work: Work{Written: now.Add(-time.Hour)}, stalled: true},
+		{name: "progressing", work: Work{Written: now.Add(-time.Hour), Conditions: append(applied(0),
+			metav1.Condition{Type: workapiv1.WorkProgressing, Status: metav1.ConditionTrue})}, stalled: true},
+		{name: "within timeout", work: Work{Written: now.Add(-5 * time.Minute)}, recheck: 10 * time.Minute},
+		{name: "progressing again", work: Work{Written: now.Add(-48 * time.Hour), Conditions: append(applied(0),
+			metav1.Condition{Type: workapiv1.WorkProgressing, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-time.Minute))})},
+			recheck: 14 * time.Minute},
+		{name: "not applied again", work: Work{Written: now.Add(-48 * time.Hour), Conditions: []metav1.Condition{{
+			Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute))}}},
+			recheck: 5 * time.Minute},
+		{name: "not applied since long", work: Work{Written: now.Add(-48 * time.Hour), Conditions: []metav1.Condition{{
+			Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(-time.Hour))}}},
+			stalled: true},
+	}
+	for _, tt := range tests {
+		stalled, recheck := Check(tt.work, 15*time.Minute, now)
+		if stalled != tt.stalled || recheck != tt.recheck {
+			t.Errorf("%s: expected %t, %s, got %t, %s", tt.name, tt.stalled, tt.recheck, stalled, recheck)
+		}
+	}
+}
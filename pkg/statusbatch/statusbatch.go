@@ -0,0 +1,293 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statusbatch coalesces the status updates of the bundles, so that the details
+// of their clusters are written at most once per interval instead of on each reconcile
+package statusbatch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/metrics"
+)
+
+// flushTimeout bounds the write of a deferred status
+const flushTimeout = 30 * time.Second
+
+// Batcher writes the statuses of the bundles: the unchanged statuses are skipped, the
+// transitions written right away, and the other changes at most once per Interval per
+// bundle, the last one deferred until the interval elapses
+type Batcher struct {
+	// Interval is the minimum time between two writes of the status of a bundle
+	// without transition
+	Interval time.Duration
+	// Update writes the status of a bundle read in the current reconcile
+	Update func(ctx context.Context, bundle *appv1alpha1.AppBundle) error
+	// Patch writes a deferred status as a merge patch from the last written one, as
+	// the bundle may have changed since it was read
+	Patch func(ctx context.Context, bundle, written *appv1alpha1.AppBundle) error
+
+	mu      sync.Mutex
+	entries map[types.NamespacedName]*entry
+	now     func() time.Time
+}
+
+type entry struct {
+	// written is the bundle with the last status written, or read when none was
+	// written since the start
+	written   *appv1alpha1.AppBundle
+	writtenAt time.Time
+	// pending is the bundle with the deferred status, flushed by the timer
+	pending *appv1alpha1.AppBundle
+	timer   *time.Timer
+	// writing serializes the writes of the bundle
+	writing sync.Mutex
+}
+
+func (b *Batcher) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// entry returns the entry of a bundle, the caller holding the lock
+func (b *Batcher) entry(key types.NamespacedName) *entry {
+	if b.entries == nil {
+		b.entries = map[types.NamespacedName]*entry{}
+	}
+	e, ok := b.entries[key]
+	if !ok {
+		e = &entry{}
+		b.entries[key] = e
+	}
+	return e
+}
+
+// Restore sets the deferred status of the bundle, if any, on the bundle read at the
+// start of a reconcile, so that the reconcile carries on from the last status
+// computed. The status read is recorded as written when no write is known.
+//
+// The deferred status is dropped once the bundle changed since it was read, as it
+// would overwrite the status written meanwhile, the reconcile computing it again from
+// the status read.
+func (b *Batcher) Restore(bundle *appv1alpha1.AppBundle) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e := b.entry(types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name})
+	if e.pending != nil && e.pending.UID == bundle.UID && e.pending.ResourceVersion == bundle.ResourceVersion {
+		bundle.Status = *e.pending.Status.DeepCopy()
+		return
+	}
+	if e.pending != nil {
+		e.drop()
+		e.written = bundle.DeepCopy()
+		return
+	}
+	if e.written == nil || e.written.UID != bundle.UID {
+		e.written = bundle.DeepCopy()
+	}
+}
+
+// Write writes the status of the bundle, or defers it when it has no transition and
+// the status of the bundle was written less than Interval ago
+func (b *Batcher) Write(ctx context.Context, bundle *appv1alpha1.AppBundle) error {
+	key := types.NamespacedName{Namespace: bundle.Namespace, Name: bundle.Name}
+	b.mu.Lock()
+	e := b.entry(key)
+	written := e.written
+	if written != nil && written.UID != bundle.UID {
+		written = nil
+	}
+	if written != nil && equality.Semantic.DeepEqual(written.Status, bundle.Status) {
+		e.drop()
+		b.mu.Unlock()
+		metrics.StatusWrites.WithLabelValues("skipped").Inc()
+		return nil
+	}
+	now := b.clock()
+	due := e.writtenAt.Add(b.Interval)
+	if written != nil && bundle.DeletionTimestamp.IsZero() && !Transition(&written.Status, &bundle.Status) && now.Before(due) {
+		e.pending = bundle.DeepCopy()
+		if e.timer == nil {
+			e.timer = time.AfterFunc(due.Sub(now), func() { b.flush(key) })
+		}
+		b.mu.Unlock()
+		metrics.StatusWrites.WithLabelValues("deferred").Inc()
+		return nil
+	}
+	e.drop()
+	b.mu.Unlock()
+
+	e.writing.Lock()
+	defer e.writing.Unlock()
+	if err := b.Update(ctx, bundle); err != nil {
+		return err
+	}
+	metrics.StatusWrites.WithLabelValues("written").Inc()
+	b.recordWrite(e, bundle)
+	return nil
+}
+
+// flush writes the deferred status of a bundle. A failed write is retried after the
+// interval, unless a newer status is deferred or written meanwhile.
+func (b *Batcher) flush(key types.NamespacedName) {
+	b.mu.Lock()
+	e, ok := b.entries[key]
+	if !ok || e.pending == nil {
+		b.mu.Unlock()
+		return
+	}
+	pending, written, writtenAt := e.pending, e.written, e.writtenAt
+	e.pending, e.timer = nil, nil
+	b.mu.Unlock()
+
+	e.writing.Lock()
+	defer e.writing.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if err := b.Patch(ctx, pending, written); err != nil {
+		if apierrors.IsNotFound(err) {
+			return
+		}
+		klog.Errorf("Failed to write the deferred status of AppBundle %s, retrying: %v", key, err)
+		metrics.StatusWrites.WithLabelValues("failed").Inc()
+		b.retry(key, e, pending, writtenAt)
+		return
+	}
+	metrics.StatusWrites.WithLabelValues("flushed").Inc()
+	b.recordWrite(e, pending)
+}
+
+// retry defers the status of a failed write again, unless the bundle was forgotten, or
+// a newer status was deferred or written since the status was read
+func (b *Batcher) retry(key types.NamespacedName, e *entry, pending *appv1alpha1.AppBundle, writtenAt time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.entries[key] != e || e.pending != nil || !e.writtenAt.Equal(writtenAt) {
+		return
+	}
+	e.pending = pending
+	e.timer = time.AfterFunc(b.Interval, func() { b.flush(key) })
+}
+
+func (b *Batcher) recordWrite(e *entry, bundle *appv1alpha1.AppBundle) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e.written = bundle.DeepCopy()
+	e.writtenAt = b.clock()
+}
+
+// drop discards the deferred status, the caller holding the lock
+func (e *entry) drop() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	e.pending = nil
+}
+
+// Forget drops the state of a deleted bundle
+func (b *Batcher) Forget(key types.NamespacedName) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e, ok := b.entries[key]; ok {
+		e.drop()
+		delete(b.entries, key)
+	}
+}
+
+// Transition returns true when the status changes beyond the details of its clusters:
+// the status or the reason of a condition of the bundle or of one of its clusters, the
+// clusters listed, or the phases of the rollout plan
+func Transition(written, status *appv1alpha1.AppBundleStatus) bool {
+	if conditionsChanged(written.Conditions, status.Conditions) {
+		return true
+	}
+	if !clusterNames(written).Equal(clusterNames(status)) {
+		return true
+	}
+	for _, c := range status.Clusters {
+		for _, w := range written.Clusters {
+			if w.ClusterName == c.ClusterName {
+				if conditionsChanged(w.Conditions, c.Conditions) {
+					return true
+				}
+				break
+			}
+		}
+	}
+	if (written.Plan == nil) != (status.Plan == nil) {
+		return true
+	}
+	if written.Plan != nil {
+		if written.Plan.Digest != status.Plan.Digest || written.Plan.CurrentWave != status.Plan.CurrentWave ||
+			len(written.Plan.Waves) != len(status.Plan.Waves) {
+			return true
+		}
+		for i := range status.Plan.Waves {
+			if written.Plan.Waves[i].Phase != status.Plan.Waves[i].Phase {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// conditionsChanged returns true when a condition is added, removed, or changes status
+// or reason
+func conditionsChanged(written, conditions []metav1.Condition) bool {
+	if len(written) != len(conditions) {
+		return true
+	}
+	for _, c := range conditions {
+		found := false
+		for _, w := range written {
+			if w.Type == c.Type {
+				found = w.Status == c.Status && w.Reason == c.Reason
+				break
+			}
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
+
+func clusterNames(status *appv1alpha1.AppBundleStatus) sets.String {
+	names := sets.NewString()
+	for _, c := range status.Clusters {
+		names.Insert(c.ClusterName)
+	}
+	return names
+}
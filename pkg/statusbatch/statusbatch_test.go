@@ -0,0 +1,282 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusbatch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// recorder records the statuses written
+type recorder struct {
+	mu      sync.Mutex
+	updates []appv1alpha1.AppBundleStatus
+	patches []appv1alpha1.AppBundleStatus
+	bases   []appv1alpha1.AppBundleStatus
+	// failures is the number of patches failing next
+	failures int
+}
+
+func (r *recorder) update(_ context.Context, bundle *appv1alpha1.AppBundle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates = append(r.updates, *bundle.Status.DeepCopy())
+	return nil
+}
+
+func (r *recorder) patch(_ context.Context, bundle, written *appv1alpha1.AppBundle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("connection refused")
+	}
+	r.patches = append(r.patches, *bundle.Status.DeepCopy())
+	r.bases = append(r.bases, *written.Status.DeepCopy())
+	return nil
+}
+
+func (r *recorder) counts() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.updates), len(r.patches)
+}
+
+func bundleWith(clusters ...string) *appv1alpha1.AppBundle {
+	b := &appv1alpha1.AppBundle{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "shop", UID: "uid"}}
+	b.Status.Conditions = []v1.Condition{{Type: appv1alpha1.ConditionSynced, Status: v1.ConditionTrue, Reason: appv1alpha1.ReasonSynced}}
+	for _, c := range clusters {
+		b.Status.Clusters = append(b.Status.Clusters, appv1alpha1.ClusterStatus{ClusterName: c})
+	}
+	return b
+}
+
+func withApplied(b *appv1alpha1.AppBundle, cluster string) *appv1alpha1.AppBundle {
+	for i := range b.Status.Clusters {
+		if b.Status.Clusters[i].ClusterName == cluster {
+			b.Status.Clusters[i].Conditions = []v1.Condition{{Type: "Applied", Status: v1.ConditionTrue, Reason: "AppliedManifestWorkComplete"}}
+		}
+	}
+	return b
+}
+
+func withDigest(b *appv1alpha1.AppBundle, cluster string) *appv1alpha1.AppBundle {
+	for i := range b.Status.Clusters {
+		if b.Status.Clusters[i].ClusterName == cluster {
+			b.Status.Clusters[i].Digest = "sha256:" + cluster
+		}
+	}
+	return b
+}
+
+func TestBatcher(t *testing.T) {
+	r := &recorder{}
+	// the timers do not fire within the interval, the test flushing the statuses
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := &Batcher{Interval: time.Hour, Update: r.update, Patch: r.patch, now: func() time.Time { return now }}
+	key := types.NamespacedName{Namespace: "shop", Name: "web"}
+	ctx := context.TODO()
+
+	// the first change is written, the unchanged status skipped
+	read := bundleWith("cluster1", "cluster2")
+	b.Restore(read)
+	if err := b.Write(ctx, withDigest(bundleWith("cluster1", "cluster2"), "cluster1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Write(ctx, withDigest(bundleWith("cluster1", "cluster2"), "cluster1")); err != nil {
+		t.Fatal(err)
+	}
+	if updates, patches := r.counts(); updates != 1 || patches != 0 {
+		t.Fatalf("expected 1 update, got %d updates and %d patches", updates, patches)
+	}
+
+	// the next details of the clusters within the interval are deferred and coalesced
+	next := withDigest(withDigest(bundleWith("cluster1", "cluster2"), "cluster1"), "cluster2")
+	if err := b.Write(ctx, next); err != nil {
+		t.Fatal(err)
+	}
+	if updates, _ := r.counts(); updates != 1 {
+		t.Fatalf("expected the change to be deferred, got %d updates", updates)
+	}
+	// a reconcile reading the bundle carries on from the deferred status
+	reread := bundleWith("cluster1", "cluster2")
+	b.Restore(reread)
+	if reread.Status.Clusters[1].Digest == "" {
+		t.Errorf("expected the deferred status to be restored, got %+v", reread.Status)
+	}
+	next.Status.ManifestBytes = 2048
+	if err := b.Write(ctx, next); err != nil {
+		t.Fatal(err)
+	}
+	// a failed flush defers the status again
+	r.failures = 1
+	b.flush(key)
+	if _, patches := r.counts(); patches != 0 || b.entries[key].pending == nil || b.entries[key].timer == nil {
+		t.Fatalf("expected the failed status to be deferred again, got %d patches", patches)
+	}
+	now = now.Add(time.Hour)
+	b.flush(key)
+	updates, patches := r.counts()
+	if updates != 1 || patches != 1 {
+		t.Fatalf("expected 1 update and 1 flushed patch, got %d updates and %d patches", updates, patches)
+	}
+	if r.patches[0].ManifestBytes != 2048 || r.bases[0].Clusters[1].Digest != "" {
+		t.Errorf("expected the last status patched from the written one, got %+v from %+v", r.patches[0], r.bases[0])
+	}
+
+	// transitions are written right away
+	if err := b.Write(ctx, withDigest(bundleWith("cluster1"), "cluster1")); err != nil {
+		t.Fatal(err)
+	}
+	failed := bundleWith("cluster1")
+	failed.Status.Conditions[0].Status, failed.Status.Conditions[0].Reason = v1.ConditionFalse, "RenderFailed"
+	if err := b.Write(ctx, failed); err != nil {
+		t.Fatal(err)
+	}
+	if updates, _ := r.counts(); updates != 3 {
+		t.Errorf("expected the transitions to be written, got %d updates", updates)
+	}
+
+	// a failed flush is not retried once a newer status is written
+	now = now.Add(time.Hour)
+	if err := b.Write(ctx, withDigest(bundleWith("cluster1"), "cluster1")); err != nil {
+		t.Fatal(err)
+	}
+	stale := withDigest(bundleWith("cluster1"), "cluster1")
+	stale.Status.ManifestBytes = 1024
+	if err := b.Write(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+	b.mu.Lock()
+	e := b.entries[key]
+	pending, writtenAt := e.pending, e.writtenAt
+	e.drop()
+	b.mu.Unlock()
+	now = now.Add(time.Hour)
+	newer := withDigest(bundleWith("cluster1"), "cluster1")
+	newer.Status.ManifestBytes = 512
+	if err := b.Write(ctx, newer); err != nil {
+		t.Fatal(err)
+	}
+	b.retry(key, e, pending, writtenAt)
+	if e.pending != nil {
+		t.Errorf("expected the stale status to not be retried, got %+v", e.pending.Status)
+	}
+
+	// a deferred status is dropped when the bundle is forgotten
+	now = now.Add(time.Hour)
+	if err := b.Write(ctx, withDigest(bundleWith("cluster1"), "cluster1")); err != nil {
+		t.Fatal(err)
+	}
+	deferred := withDigest(bundleWith("cluster1"), "cluster1")
+	deferred.Status.ManifestBytes = 4096
+	if err := b.Write(ctx, deferred); err != nil {
+		t.Fatal(err)
+	}
+	b.Forget(key)
+	b.flush(key)
+	if updates, patches := r.counts(); updates != 6 || patches != 1 {
+		t.Errorf("expected the deferred status to be dropped, got %d updates and %d patches", updates, patches)
+	}
+}
+
+func TestRestoreChanged(t *testing.T) {
+	r := &recorder{}
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := &Batcher{Interval: time.Hour, Update: r.update, Patch: r.patch, now: func() time.Time { return now }}
+	ctx := context.TODO()
+
+	read := bundleWith("cluster1")
+	read.ResourceVersion = "1"
+	b.Restore(read)
+	if err := b.Write(ctx, withDigest(read.DeepCopy(), "cluster1")); err != nil {
+		t.Fatal(err)
+	}
+	deferred := withDigest(read.DeepCopy(), "cluster1")
+	deferred.Status.ManifestBytes = 2048
+	if err := b.Write(ctx, deferred); err != nil {
+		t.Fatal(err)
+	}
+
+	// the status was written by another writer since the deferred status was read
+	reread := withDigest(bundleWith("cluster1"), "cluster1")
+	reread.ResourceVersion = "2"
+	reread.Status.ManifestBytes = 1024
+	b.Restore(reread)
+	if reread.Status.ManifestBytes != 1024 {
+		t.Errorf("expected the status read to be kept, got %+v", reread.Status)
+	}
+	e := b.entries[types.NamespacedName{Namespace: "shop", Name: "web"}]
+	if e.pending != nil || e.timer != nil {
+		t.Errorf("expected the deferred status to be dropped, got %+v", e.pending)
+	}
+	// the status read is the base of the next write
+	if err := b.Write(ctx, reread); err != nil {
+		t.Fatal(err)
+	}
+	if updates, patches := r.counts(); updates != 1 || patches != 0 {
+		t.Errorf("expected the status read to be skipped, got %d updates and %d patches", updates, patches)
+	}
+}
+
+func TestTransition(t *testing.T) {
+	applied := withApplied(bundleWith("cluster1", "cluster2"), "cluster1")
+	failed := bundleWith("cluster1", "cluster2")
+	failed.Status.Conditions[0].Status, failed.Status.Conditions[0].Reason = v1.ConditionFalse, "RenderFailed"
+	message := bundleWith("cluster1", "cluster2")
+	message.Status.Conditions[0].Message = "distributed to 2 clusters"
+	digest := withDigest(bundleWith("cluster1", "cluster2"), "cluster1")
+	clusterFailed := withApplied(bundleWith("cluster1", "cluster2"), "cluster1")
+	clusterFailed.Status.Clusters[0].Conditions[0].Status = v1.ConditionFalse
+	clusterMessage := withApplied(bundleWith("cluster1", "cluster2"), "cluster1")
+	clusterMessage.Status.Clusters[0].Conditions[0].Message = "applied"
+	planned := bundleWith("cluster1", "cluster2")
+	planned.Status.Plan = &appv1alpha1.RolloutPlan{Digest: "abc"}
+
+	tests := []struct {
+		name    string
+		written *appv1alpha1.AppBundle
+		status  *appv1alpha1.AppBundle
+		want    bool
+	}{
+		{"unchanged", bundleWith("cluster1", "cluster2"), bundleWith("cluster1", "cluster2"), false},
+		{"cluster digest", bundleWith("cluster1", "cluster2"), digest, false},
+		{"cluster condition added", bundleWith("cluster1", "cluster2"), applied, true},
+		{"condition message", bundleWith("cluster1", "cluster2"), message, false},
+		{"condition status", bundleWith("cluster1", "cluster2"), failed, true},
+		{"cluster removed", bundleWith("cluster1", "cluster2"), bundleWith("cluster1"), true},
+		{"cluster condition status", withApplied(bundleWith("cluster1", "cluster2"), "cluster1"), clusterFailed, true},
+		{"cluster condition message", withApplied(bundleWith("cluster1", "cluster2"), "cluster1"), clusterMessage, false},
+		{"cluster added", bundleWith("cluster1", "cluster2"), bundleWith("cluster1", "cluster2", "cluster3"), true},
+		{"plan", bundleWith("cluster1", "cluster2"), planned, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Transition(&tt.written.Status, &tt.status.Status); got != tt.want {
+				t.Errorf("Transition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
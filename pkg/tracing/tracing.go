@@ -0,0 +1,106 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing sets up the OpenTelemetry tracing of the reconciles, exported to a
+// collector over OTLP/HTTP, and propagates their trace context in the W3C traceparent
+// format so that the delivery of a work can be traced from the hub to the clusters
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/rest"
+)
+
+// TracesPath is the path of the OTLP/HTTP traces endpoint of the collectors
+const TracesPath = "/v1/traces"
+
+// traceparentHeader is the header of the W3C trace context holding the traceparent
+const traceparentHeader = "traceparent"
+
+// NewProvider returns the provider of the tracers of the service, exporting the spans
+// to the collector at the endpoint, its base URL, e.g. http://otel-collector:4318. The
+// ratio of the traces started by the service which are sampled is between 0 and 1,
+// the traces of a remote parent keeping its decision.
+func NewProvider(ctx context.Context, endpoint, service string, sampleRatio float64) (*sdktrace.TracerProvider, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(path.Join("/", u.Path, TracesPath)),
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(service))),
+	), nil
+}
+
+// WrapConfig wraps the transport of the rest config so that its requests are spans of
+// the current trace, their trace context being propagated to the API server
+func WrapConfig(cfg *rest.Config) *rest.Config {
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return otelhttp.NewTransport(rt, otelhttp.WithPropagators(propagation.TraceContext{}))
+	})
+	return cfg
+}
+
+// Traceparent returns the current span of the context in the W3C traceparent format,
+// empty without a valid span
+func Traceparent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(traceparentHeader)
+}
+
+// RecordError records the error on the span and sets its status to failed
+func RecordError(span trace.Span, err error) {
+	if span == nil || err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// End ends the span, failed if err is not nil. A nil span is ignored.
+func End(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	RecordError(span, err)
+	span.End()
+}
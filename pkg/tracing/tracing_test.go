@@ -0,0 +1,126 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/rest"
+)
+
+func TestTraceparent(t *testing.T) {
+	if tp := Traceparent(context.TODO()); tp != "" {
+		t.Errorf("expected no traceparent without span, got %s", tp)
+	}
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.TODO(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+	if tp, expected := Traceparent(ctx), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; tp != expected {
+		t.Errorf("expected %s, got %s", expected, tp)
+	}
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("kealm")
+	ctx, root := tracer.Start(context.TODO(), "AppBundle.Reconcile")
+	_, child := tracer.Start(ctx, "AppBundle.Apply")
+	End(child, errors.New("conflict"))
+	End(root, nil)
+	End(nil, errors.New("ignored"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name() != "AppBundle.Apply" || spans[0].Status().Code != codes.Error || len(spans[0].Events()) != 1 ||
+		spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Errorf("expected the failed child span, got %s with %+v", spans[0].Name(), spans[0].Status())
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Errorf("expected the root span not failed, got %+v", spans[1].Status())
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	exported := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case exported <- r.URL.Path:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	if _, err := NewProvider(context.TODO(), "otel-collector", "kealm", 1); err == nil {
+		t.Error("expected an endpoint without host to be rejected")
+	}
+	provider, err := NewProvider(context.TODO(), srv.URL+"/otlp", "kealm", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := provider.Tracer("kealm").Start(context.TODO(), "AppBundle.Reconcile")
+	span.End()
+	if err := provider.Shutdown(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case path := <-exported:
+		if path != "/otlp"+TracesPath {
+			t.Errorf("expected the spans exported to /otlp%s, got %s", TracesPath, path)
+		}
+	default:
+		t.Error("expected the spans to be exported on shutdown")
+	}
+}
+
+func TestWrapConfig(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	}))
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("kealm")
+	ctx, span := tracer.Start(context.TODO(), "AppBundle.Reconcile")
+	defer span.End()
+	cfg := WrapConfig(&rest.Config{Host: srv.URL})
+	rt, err := rest.TransportFor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if tp := (<-headers).Get("traceparent"); tp == "" {
+		t.Error("expected the trace context to be propagated")
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracker
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// subscriberBuffer is the number of transitions buffered for a subscriber, beyond which
+// the transitions are dropped rather than blocking the informer
+const subscriberBuffer = 256
+
+// Hub fans out the transitions of the bundles to its subscribers. It is registered as
+// an event handler of the AppBundle informer.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]bool
+}
+
+type subscriber struct {
+	namespace, name string
+	ch              chan Transition
+}
+
+var _ cache.ResourceEventHandler = &Hub{}
+
+// NewHub returns a hub without subscribers
+func NewHub() *Hub {
+	return &Hub{subscribers: map[*subscriber]bool{}}
+}
+
+// Subscribe returns the transitions of the bundle namespace/name, of all the bundles of
+// the namespace when name is empty, or of all the bundles when both are empty. The
+// channel is closed when the context is done.
+func (h *Hub) Subscribe(ctx context.Context, namespace, name string) <-chan Transition {
+	s := &subscriber{namespace: namespace, name: name, ch: make(chan Transition, subscriberBuffer)}
+	h.mu.Lock()
+	h.subscribers[s] = true
+	h.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers, s)
+		close(s.ch)
+	}()
+	return s.ch
+}
+
+// OnAdd ignores the bundles listed, their current status not being a transition
+func (h *Hub) OnAdd(obj interface{}) {}
+
+// OnUpdate publishes the transitions of an updated bundle
+func (h *Hub) OnUpdate(oldObj, newObj interface{}) {
+	old, ok := oldObj.(*appv1alpha1.AppBundle)
+	if !ok {
+		return
+	}
+	if bundle, ok := newObj.(*appv1alpha1.AppBundle); ok {
+		h.publish(Diff(old, bundle))
+	}
+}
+
+// OnDelete publishes the clusters of a deleted bundle as removed
+func (h *Hub) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if bundle, ok := obj.(*appv1alpha1.AppBundle); ok {
+		deleted := bundle.DeepCopy()
+		deleted.Status.Clusters = nil
+		h.publish(Diff(bundle, deleted))
+	}
+}
+
+func (h *Hub) publish(transitions []Transition) {
+	if len(transitions) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subscribers {
+		for _, t := range transitions {
+			if !s.matches(t) {
+				continue
+			}
+			select {
+			case s.ch <- t:
+			default:
+				klog.Warningf("Dropping transition of cluster %s of AppBundle %s/%s, the subscriber is too slow",
+					t.Cluster, t.Namespace, t.Bundle)
+			}
+		}
+	}
+}
+
+func (s *subscriber) matches(t Transition) bool {
+	return (s.namespace == "" || s.namespace == t.Namespace) && (s.name == "" || s.name == t.Bundle)
+}
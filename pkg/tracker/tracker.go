@@ -0,0 +1,129 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracker streams the transitions of the clusters of the bundles, read from
+// the watch of the AppBundles instead of polling their status
+package tracker
+
+import (
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// Transition is a change of a condition of a cluster of a bundle, or a cluster leaving
+// the bundle
+type Transition struct {
+	Namespace string `json:"namespace"`
+	Bundle    string `json:"bundle"`
+	Cluster   string `json:"cluster"`
+	// Type of the condition, empty when the cluster left the bundle
+	Type string `json:"type,omitempty"`
+	// Previous status of the condition, empty when the condition is new
+	Previous v1.ConditionStatus `json:"previous,omitempty"`
+	// Status of the condition
+	Status  v1.ConditionStatus `json:"status,omitempty"`
+	Reason  string             `json:"reason,omitempty"`
+	Message string             `json:"message,omitempty"`
+	// Removed is true when the cluster left the bundle
+	Removed bool      `json:"removed,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// EventType returns Warning when the transition reports a failure of the cluster: its
+// work no longer Applied or Available, or Degraded, and Normal otherwise
+func (t Transition) EventType() string {
+	switch {
+	case t.Type == workapiv1.WorkApplied || t.Type == workapiv1.WorkAvailable:
+		if t.Status == v1.ConditionFalse {
+			return corev1.EventTypeWarning
+		}
+	case t.Type == workapiv1.WorkDegraded:
+		if t.Status == v1.ConditionTrue {
+			return corev1.EventTypeWarning
+		}
+	}
+	return corev1.EventTypeNormal
+}
+
+// Diff returns the transitions of the clusters between two versions of a bundle: the
+// conditions of the clusters changing status or reason, the conditions of the clusters
+// joining the bundle, and the clusters leaving it, sorted by cluster and type. The old
+// bundle is nil for a new bundle.
+func Diff(old, new *appv1alpha1.AppBundle) []Transition {
+	previous := map[string]appv1alpha1.ClusterStatus{}
+	if old != nil {
+		for _, c := range old.Status.Clusters {
+			previous[c.ClusterName] = c
+		}
+	}
+	now := time.Now()
+	transitions := []Transition{}
+	current := map[string]bool{}
+	for _, c := range new.Status.Clusters {
+		current[c.ClusterName] = true
+		conditions := map[string]v1.Condition{}
+		for _, cond := range previous[c.ClusterName].Conditions {
+			conditions[cond.Type] = cond
+		}
+		for _, cond := range c.Conditions {
+			prev, ok := conditions[cond.Type]
+			if ok && prev.Status == cond.Status && prev.Reason == cond.Reason {
+				continue
+			}
+			t := Transition{
+				Namespace: new.Namespace,
+				Bundle:    new.Name,
+				Cluster:   c.ClusterName,
+				Type:      cond.Type,
+				Status:    cond.Status,
+				Reason:    cond.Reason,
+				Message:   cond.Message,
+				Time:      cond.LastTransitionTime.Time,
+			}
+			if ok {
+				t.Previous = prev.Status
+			}
+			if t.Time.IsZero() || (ok && prev.Status == cond.Status) {
+				t.Time = now
+			}
+			transitions = append(transitions, t)
+		}
+	}
+	for name := range previous {
+		if !current[name] {
+			transitions = append(transitions, Transition{
+				Namespace: new.Namespace,
+				Bundle:    new.Name,
+				Cluster:   name,
+				Removed:   true,
+				Time:      now,
+			})
+		}
+	}
+	sort.SliceStable(transitions, func(i, j int) bool {
+		if transitions[i].Cluster != transitions[j].Cluster {
+			return transitions[i].Cluster < transitions[j].Cluster
+		}
+		return transitions[i].Type < transitions[j].Type
+	})
+	return transitions
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+func bundle(name string, clusters ...appv1alpha1.ClusterStatus) *appv1alpha1.AppBundle {
+	return &appv1alpha1.AppBundle{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "shop", UID: types.UID("uid-" + name)},
+		Status:     appv1alpha1.AppBundleStatus{Clusters: clusters},
+	}
+}
+
+func cluster(name string, conditions ...v1.Condition) appv1alpha1.ClusterStatus {
+	return appv1alpha1.ClusterStatus{ClusterName: name, Conditions: conditions}
+}
+
+func condition(t string, status v1.ConditionStatus, reason string) v1.Condition {
+	return v1.Condition{Type: t, Status: status, Reason: reason}
+}
+
+type change struct {
+	cluster, typ     string
+	previous, status v1.ConditionStatus
+	removed          bool
+}
+
+func changes(transitions []Transition) []change {
+	out := []change{}
+	for _, t := range transitions {
+		out = append(out, change{cluster: t.Cluster, typ: t.Type, previous: t.Previous, status: t.Status, removed: t.Removed})
+	}
+	return out
+}
+
+func equal(a, b []change) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDiff(t *testing.T) {
+	old := bundle("web",
+		cluster("cluster1", condition(workapiv1.WorkApplied, v1.ConditionTrue, "AppliedManifestWorkComplete"),
+			condition(workapiv1.WorkAvailable, v1.ConditionTrue, "ResourcesAvailable")),
+		cluster("cluster2", condition(workapiv1.WorkApplied, v1.ConditionTrue, "AppliedManifestWorkComplete")))
+	new := bundle("web",
+		cluster("cluster3", condition(workapiv1.WorkApplied, v1.ConditionFalse, "AppliedManifestWorkFailed")),
+		cluster("cluster1", condition(workapiv1.WorkApplied, v1.ConditionTrue, "AppliedManifestWorkComplete"),
+			condition(workapiv1.WorkAvailable, v1.ConditionFalse, "ResourcesNotAvailable")))
+
+	expected := []change{
+		{cluster: "cluster1", typ: workapiv1.WorkAvailable, previous: v1.ConditionTrue, status: v1.ConditionFalse},
+		{cluster: "cluster2", removed: true},
+		{cluster: "cluster3", typ: workapiv1.WorkApplied, status: v1.ConditionFalse},
+	}
+	if got := changes(Diff(old, new)); !equal(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	if got := Diff(new, new.DeepCopy()); len(got) != 0 {
+		t.Errorf("expected no transition of an unchanged status, got %+v", got)
+	}
+	if got := changes(Diff(nil, bundle("web", cluster("cluster1", condition(workapiv1.WorkApplied, v1.ConditionTrue, ""))))); len(got) != 1 {
+		t.Errorf("expected the conditions of a new bundle, got %+v", got)
+	}
+}
+
+func TestEventType(t *testing.T) {
+	tests := []struct {
+		transition Transition
+		want       string
+	}{
+		{Transition{Type: workapiv1.WorkApplied, Status: v1.ConditionFalse}, corev1.EventTypeWarning},
+		{Transition{Type: workapiv1.WorkAvailable, Status: v1.ConditionFalse}, corev1.EventTypeWarning},
+		{Transition{Type: workapiv1.WorkAvailable, Status: v1.ConditionTrue}, corev1.EventTypeNormal},
+		{Transition{Type: workapiv1.WorkDegraded, Status: v1.ConditionTrue}, corev1.EventTypeWarning},
+		{Transition{Type: workapiv1.WorkProgressing, Status: v1.ConditionFalse}, corev1.EventTypeNormal},
+		{Transition{Removed: true}, corev1.EventTypeNormal},
+	}
+	for _, tt := range tests {
+		if got := tt.transition.EventType(); got != tt.want {
+			t.Errorf("EventType(%+v) = %s, want %s", tt.transition, got, tt.want)
+		}
+	}
+}
+
+func receive(t *testing.T, ch <-chan Transition) Transition {
+	t.Helper()
+	select {
+	case tr, ok := <-ch:
+		if !ok {
+			t.Fatal("expected a transition, the stream is closed")
+		}
+		return tr
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a transition")
+	}
+	return Transition{}
+}
+
+func TestHub(t *testing.T) {
+	h := NewHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	all := h.Subscribe(ctx, "", "")
+	web := h.Subscribe(ctx, "shop", "web")
+
+	old := bundle("web", cluster("cluster1", condition(workapiv1.WorkApplied, v1.ConditionTrue, "")))
+	h.OnAdd(old)
+	h.OnUpdate(bundle("api"), bundle("api", cluster("cluster1", condition(workapiv1.WorkApplied, v1.ConditionTrue, ""))))
+	h.OnUpdate(old, bundle("web", cluster("cluster1", condition(workapiv1.WorkApplied, v1.ConditionFalse, "Failed"))))
+	h.OnDelete(cache.DeletedFinalStateUnknown{Obj: old})
+
+	if tr := receive(t, all); tr.Bundle != "api" {
+		t.Errorf("expected the transition of api first, got %+v", tr)
+	}
+	if tr := receive(t, web); tr.Bundle != "web" || tr.Status != v1.ConditionFalse {
+		t.Errorf("expected the failure of web, got %+v", tr)
+	}
+	if tr := receive(t, web); !tr.Removed {
+		t.Errorf("expected the cluster of the deleted bundle removed, got %+v", tr)
+	}
+
+	cancel()
+	for range web {
+	}
+}
+
+func TestWatch(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := appv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	b := bundle("web", cluster("cluster1", condition(workapiv1.WorkApplied, v1.ConditionTrue, "")))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(b).Build()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.Get(ctx, types.NamespacedName{Namespace: b.Namespace, Name: b.Name}, b); err != nil {
+		t.Fatal(err)
+	}
+
+	transitions, errs, err := Watch(ctx, c, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fake client does not replay the events from the resource version, give the
+	// watch the time to start
+	time.Sleep(200 * time.Millisecond)
+	updated := b.DeepCopy()
+	updated.Status.Clusters = append(updated.Status.Clusters, cluster("cluster2", condition(workapiv1.WorkApplied, v1.ConditionTrue, "")))
+	if err := c.Update(ctx, updated); err != nil {
+		t.Fatal(err)
+	}
+	if tr := receive(t, transitions); tr.Cluster != "cluster2" || tr.Type != workapiv1.WorkApplied {
+		t.Errorf("expected cluster2 applied, got %+v", tr)
+	}
+	if err := c.Delete(ctx, updated); err != nil {
+		t.Fatal(err)
+	}
+	removed := map[string]bool{}
+	for tr := range transitions {
+		removed[tr.Cluster] = tr.Removed
+	}
+	if !removed["cluster1"] || !removed["cluster2"] {
+		t.Errorf("expected the clusters removed with the bundle, got %v", removed)
+	}
+	if err, ok := <-errs; ok {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
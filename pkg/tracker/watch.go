@@ -0,0 +1,108 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracker
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// Watch streams the transitions of the bundle after the version read, watching the
+// bundle from its resource version and resuming the watch when it expires. The channel
+// is closed when the bundle is deleted, after its clusters are reported removed, or
+// when the context is done; an error is reported on errs, closed with the transitions.
+func Watch(ctx context.Context, c client.WithWatch, bundle *appv1alpha1.AppBundle) (<-chan Transition, <-chan error, error) {
+	selector := fields.OneTermEqualSelector("metadata.name", bundle.Name).String()
+	watcher, err := watchtools.NewRetryWatcher(bundle.ResourceVersion, &cache.ListWatch{
+		WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return c.Watch(ctx, &appv1alpha1.AppBundleList{}, client.InNamespace(bundle.Namespace), &client.ListOptions{Raw: &options})
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transitions, errs := make(chan Transition), make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(transitions)
+		defer watcher.Stop()
+		last := bundle.DeepCopy()
+		send := func(ts []Transition) bool {
+			for _, t := range ts {
+				select {
+				case transitions <- t:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					errs <- fmt.Errorf("the watch of AppBundle %s/%s ended", bundle.Namespace, bundle.Name)
+					return
+				}
+				switch event.Type {
+				case watch.Error:
+					errs <- fmt.Errorf("watching AppBundle %s/%s: %v", bundle.Namespace, bundle.Name, apiStatus(event.Object))
+					return
+				case watch.Added, watch.Modified:
+					current, ok := event.Object.(*appv1alpha1.AppBundle)
+					if !ok || current.Name != bundle.Name || current.UID != last.UID {
+						continue
+					}
+					if !send(Diff(last, current)) {
+						return
+					}
+					last = current
+				case watch.Deleted:
+					current, ok := event.Object.(*appv1alpha1.AppBundle)
+					if !ok || current.Name != bundle.Name || current.UID != last.UID {
+						continue
+					}
+					deleted := last.DeepCopy()
+					deleted.Status.Clusters = nil
+					send(Diff(last, deleted))
+					return
+				}
+			}
+		}
+	}()
+	return transitions, errs, nil
+}
+
+func apiStatus(obj interface{}) interface{} {
+	if status, ok := obj.(*v1.Status); ok {
+		return status.Message
+	}
+	return obj
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package works creates the ManifestWorks of the bundles idempotently, so that a
+// reconcile racing with a retried or concurrent one converges instead of failing.
+package works
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workv1 "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// Create creates the work with the writer. When another writer created it first, the
+// existing work is read with the reader and returned, for the caller to update it
+// instead. It returns nil once the work is created.
+func Create(ctx context.Context, writer, reader workv1.WorkV1Interface, work *workapiv1.ManifestWork) (*workapiv1.ManifestWork, error) {
+	_, err := writer.ManifestWorks(work.Namespace).Create(ctx, work, v1.CreateOptions{})
+	if err == nil {
+		return nil, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	existing, err := reader.ManifestWorks(work.Namespace).Get(ctx, work.Name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// deleted in between, let the next reconcile create it again
+		return nil, apierrors.NewConflict(workapiv1.Resource("manifestworks"), work.Name, err)
+	}
+	return existing, err
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package works
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	workfake "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func work(annotation string) *workapiv1.ManifestWork {
+	return &workapiv1.ManifestWork{
+		ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "cluster1", Annotations: map[string]string{"digest": annotation}},
+	}
+}
+
+func TestCreate(t *testing.T) {
+	client := workfake.NewSimpleClientset()
+	existing, err := Create(context.TODO(), client.WorkV1(), client.WorkV1(), work("new"))
+	if err != nil || existing != nil {
+		t.Fatalf("expected the work to be created, got %v, %v", existing, err)
+	}
+	if _, err := client.WorkV1().ManifestWorks("cluster1").Get(context.TODO(), "web", v1.GetOptions{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateRace(t *testing.T) {
+	// another reconcile created the work since it was read
+	client := workfake.NewSimpleClientset(work("old"))
+	existing, err := Create(context.TODO(), client.WorkV1(), client.WorkV1(), work("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existing == nil || existing.Annotations["digest"] != "old" {
+		t.Errorf("expected the existing work to be returned, got %v", existing)
+	}
+
+	// and deleted it before it is read again
+	reader := workfake.NewSimpleClientset()
+	if _, err := Create(context.TODO(), client.WorkV1(), reader.WorkV1(), work("new")); !apierrors.IsConflict(err) {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+}
+
+func TestCreateFailure(t *testing.T) {
+	client := workfake.NewSimpleClientset()
+	client.PrependReactor("create", "manifestworks", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewServiceUnavailable("etcd leader changed")
+	})
+	if _, err := Create(context.TODO(), client.WorkV1(), client.WorkV1(), work("new")); !apierrors.IsServiceUnavailable(err) {
+		t.Errorf("expected the error of the creation, got %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package writeback
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maxFileSize bounds the size of the files read from Git hosts
+const maxFileSize = 16 << 20
+
+// host is the kind of API of a Git host
+type host int
+
+const (
+	github host = iota
+	gitlab
+	gitea
+)
+
+// Writer commits files to Git repositories through the API of their GitHub, GitLab or
+// Gitea host
+type Writer struct {
+	HTTP *http.Client
+}
+
+// repository is a repository on a Git host
+type repository struct {
+	host host
+	// api is the URL of the API of the host
+	api *url.URL
+	// project is the owner/name path of the repository
+	project string
+}
+
+// parseRepository returns the repository of an HTTP URL
+func parseRepository(repo string) (*repository, error) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL %s: %w", repo, err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("repository URL %s is not an HTTP URL", repo)
+	}
+	r := &repository{project: strings.TrimPrefix(u.Path, "/"), api: &url.URL{Scheme: u.Scheme, Host: u.Host}}
+	switch {
+	case u.Host == "github.com":
+		r.host = github
+		r.api.Host = "api.github.com"
+	case strings.Contains(u.Host, "gitlab"):
+		r.host = gitlab
+	default:
+		r.host = gitea
+	}
+	return r, nil
+}
+
+// FileURL returns the URL of the API reading and writing a file of a Git repository
+// on GitHub, GitLab or Gitea, e.g. https://api.github.com/repos/acme/fleet/contents/shop/state.yaml
+func FileURL(repo, file string) (string, error) {
+	r, err := parseRepository(repo)
+	if err != nil {
+		return "", err
+	}
+	return r.fileURL(file).String(), nil
+}
+
+func (r *repository) fileURL(file string) *url.URL {
+	u := *r.api
+	file = strings.Trim(file, "/")
+	switch r.host {
+	case github:
+		u.Path = fmt.Sprintf("/repos/%s/contents/%s", r.project, file)
+	case gitlab:
+		// the project and file are identified by their escaped full path
+		u.Path = fmt.Sprintf("/api/v4/projects/%s/repository/files/%s", r.project, file)
+		u.RawPath = fmt.Sprintf("/api/v4/projects/%s/repository/files/%s", url.PathEscape(r.project), url.PathEscape(file))
+	default:
+		u.Path = fmt.Sprintf("/api/v1/repos/%s/contents/%s", r.project, file)
+	}
+	return &u
+}
+
+// Write commits the files that differ from the ones of the branch, one commit per file
+// with the message, and returns the paths of the written files
+func (w *Writer) Write(ctx context.Context, repo, branch, token string, files map[string][]byte, message string) ([]string, error) {
+	r, err := parseRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	written := []string{}
+	for _, p := range paths {
+		content, sha, found, err := w.read(ctx, r, branch, token, p)
+		if err != nil {
+			return written, err
+		}
+		if found && bytes.Equal(content, files[p]) {
+			continue
+		}
+		if err := w.write(ctx, r, branch, token, p, files[p], sha, found, message); err != nil {
+			return written, err
+		}
+		written = append(written, p)
+	}
+	return written, nil
+}
+
+// file is a file read from the API of GitHub, GitLab or Gitea
+type file struct {
+	Content string `json:"content"`
+	// SHA is the blob SHA GitHub and Gitea require to update the file
+	SHA string `json:"sha"`
+}
+
+// read returns the content of the file on the branch, and its blob SHA
+func (w *Writer) read(ctx context.Context, r *repository, branch, token, p string) ([]byte, string, bool, error) {
+	u := r.fileURL(p)
+	u.RawQuery = url.Values{"ref": {branch}}.Encode()
+	resp, err := w.do(ctx, r, http.MethodGet, u.String(), token, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false, fmt.Errorf("failed to read %s: %s", u.Redacted(), resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize+1))
+	if err != nil {
+		return nil, "", false, err
+	}
+	if len(body) > maxFileSize {
+		return nil, "", false, fmt.Errorf("%s is larger than %d bytes", u.Redacted(), maxFileSize)
+	}
+	var f file
+	if err := json.Unmarshal(body, &f); err != nil {
+		return nil, "", false, fmt.Errorf("failed to parse %s: %w", u.Redacted(), err)
+	}
+	// the content is base64 encoded, GitHub wrapping it in lines
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(f.Content, "\n", ""))
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to decode %s: %w", u.Redacted(), err)
+	}
+	return content, f.SHA, true, nil
+}
+
+// write creates or updates the file on the branch
+func (w *Writer) write(ctx context.Context, r *repository, branch, token, p string, content []byte, sha string, exists bool, message string) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	var body map[string]interface{}
+	method := http.MethodPut
+	switch r.host {
+	case gitlab:
+		body = map[string]interface{}{"branch": branch, "content": encoded, "encoding": "base64", "commit_message": message}
+		if !exists {
+			method = http.MethodPost
+		}
+	default:
+		body = map[string]interface{}{"branch": branch, "content": encoded, "message": message}
+		if exists {
+			body["sha"] = sha
+		} else if r.host == gitea {
+			method = http.MethodPost
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	u := r.fileURL(p).String()
+	resp, err := w.do(ctx, r, method, u, token, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to write %s: %s", u, resp.Status)
+	}
+	return nil
+}
+
+func (w *Writer) do(ctx context.Context, r *repository, method, u, token string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		if r.host == gitlab {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		} else {
+			req.Header.Set("Authorization", "token "+token)
+		}
+	}
+	client := w.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
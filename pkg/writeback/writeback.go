@@ -0,0 +1,116 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package writeback records the state resolved for the clusters of a bundle in Git
+package writeback
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// StateFile is the name of the file listing the state of the clusters of a bundle
+const StateFile = "state.yaml"
+
+// State is the state resolved for the clusters of a bundle
+type State struct {
+	Bundle     string    `json:"bundle"`
+	Generation int64     `json:"generation"`
+	Clusters   []Cluster `json:"clusters"`
+}
+
+// Cluster is the state resolved for a cluster
+type Cluster struct {
+	Name string `json:"name"`
+	// Digest of the manifests distributed to the cluster
+	Digest string   `json:"digest"`
+	Images []string `json:"images,omitempty"`
+	// Manifests rendered for the cluster, written to a file of their own
+	Manifests []workapiv1.Manifest `json:"-"`
+}
+
+// Digest returns the digest identifying the state, from the generation and the digests
+// of the clusters
+func (s State) Digest() string {
+	clusters := append([]Cluster{}, s.Clusters...)
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%d\n", s.Bundle, s.Generation)
+	for _, c := range clusters {
+		fmt.Fprintf(h, "%s=%s\n", c.Name, c.Digest)
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
+
+// Files returns the files recording the state in the directory: the state file with
+// the digest and images of each cluster and, with manifests, a clusters/CLUSTER.yaml
+// file per cluster with its manifests
+func Files(s State, dir string, withManifests bool) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	clusters := []Cluster{}
+	for _, c := range s.Clusters {
+		images := sets.NewString()
+		for _, m := range c.Manifests {
+			u, err := manifests.ToUnstructured(m)
+			if err != nil {
+				return nil, err
+			}
+			for _, container := range manifests.Containers(u) {
+				if image, ok := container["image"].(string); ok && image != "" {
+					images.Insert(image)
+				}
+			}
+		}
+		c.Images = images.List()
+		clusters = append(clusters, c)
+		if !withManifests {
+			continue
+		}
+		var b bytes.Buffer
+		for i, m := range c.Manifests {
+			u, err := manifests.ToUnstructured(m)
+			if err != nil {
+				return nil, err
+			}
+			out, err := yaml.Marshal(u.Object)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				b.WriteString("---\n")
+			}
+			b.Write(out)
+		}
+		files[path.Join(dir, "clusters", c.Name+".yaml")] = b.Bytes()
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	s.Clusters = clusters
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	files[path.Join(dir, StateFile)] = out
+	return files, nil
+}
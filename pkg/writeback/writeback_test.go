@@ -0,0 +1,159 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package writeback
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+func TestFiles(t *testing.T) {
+	ms, err := manifests.ParseYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: quay.io/acme/web:v2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := State{Bundle: "shop/web", Generation: 3, Clusters: []Cluster{
+		{Name: "cluster2", Digest: "sha256:2", Manifests: ms},
+		{Name: "cluster1", Digest: "sha256:1", Manifests: ms},
+	}}
+	files, err := Files(s, "shop/web", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected the state file only, got %d files", len(files))
+	}
+	var written State
+	if err := yaml.Unmarshal(files["shop/web/state.yaml"], &written); err != nil {
+		t.Fatal(err)
+	}
+	expected := State{Bundle: "shop/web", Generation: 3, Clusters: []Cluster{
+		{Name: "cluster1", Digest: "sha256:1", Images: []string{"quay.io/acme/web:v2"}},
+		{Name: "cluster2", Digest: "sha256:2", Images: []string{"quay.io/acme/web:v2"}},
+	}}
+	if !reflect.DeepEqual(written, expected) {
+		t.Errorf("expected state %v, got %v", expected, written)
+	}
+
+	files, err = Files(s, "shop/web", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := string(files["shop/web/clusters/cluster1.yaml"]); !strings.Contains(content, "image: quay.io/acme/web:v2") {
+		t.Errorf("expected the manifests of cluster1, got %q", content)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	s := State{Bundle: "shop/web", Generation: 3, Clusters: []Cluster{{Name: "cluster1", Digest: "sha256:1"}, {Name: "cluster2", Digest: "sha256:2"}}}
+	reordered := State{Bundle: "shop/web", Generation: 3, Clusters: []Cluster{s.Clusters[1], s.Clusters[0]}}
+	if s.Digest() != reordered.Digest() {
+		t.Error("expected the digest to be independent of the order of the clusters")
+	}
+	changed := State{Bundle: "shop/web", Generation: 3, Clusters: []Cluster{s.Clusters[0]}}
+	if s.Digest() == changed.Digest() {
+		t.Error("expected the digest to change with the clusters")
+	}
+}
+
+func TestFileURL(t *testing.T) {
+	tests := []struct {
+		repo     string
+		expected string
+	}{
+		{repo: "https://github.com/acme/fleet.git", expected: "https://api.github.com/repos/acme/fleet/contents/shop/state.yaml"},
+		{repo: "https://gitlab.com/acme/infra/fleet", expected: "https://gitlab.com/api/v4/projects/acme%2Finfra%2Ffleet/repository/files/shop%2Fstate.yaml"},
+		{repo: "https://git.acme.com/acme/fleet", expected: "https://git.acme.com/api/v1/repos/acme/fleet/contents/shop/state.yaml"},
+	}
+	for _, tt := range tests {
+		u, err := FileURL(tt.repo, "shop/state.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u != tt.expected {
+			t.Errorf("FileURL(%s) = %s, expected %s", tt.repo, u, tt.expected)
+		}
+	}
+	if _, err := FileURL("git@github.com:acme/fleet.git", "state.yaml"); err == nil {
+		t.Error("expected SSH URLs to be rejected")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	stored := map[string]string{"/api/v1/repos/acme/fleet/contents/shop/unchanged.yaml": "same"}
+	methods := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			content, ok := stored[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(file{Content: base64.StdEncoding.EncodeToString([]byte(content)), SHA: "abc"})
+		default:
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["branch"] != "state" || body["message"] != "Record shop/web" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			methods[r.URL.Path] = r.Method
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	w := &Writer{HTTP: server.Client()}
+	written, err := w.Write(context.TODO(), server.URL+"/acme/fleet", "state", "secret", map[string][]byte{
+		"shop/unchanged.yaml": []byte("same"),
+		"shop/state.yaml":     []byte("generation: 3\n"),
+	}, "Record shop/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, []string{"shop/state.yaml"}) {
+		t.Errorf("expected the changed file only to be written, got %v", written)
+	}
+	if m := methods["/api/v1/repos/acme/fleet/contents/shop/state.yaml"]; m != http.MethodPost {
+		t.Errorf("expected the new file to be created with POST, got %s", m)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/audit"
+)
+
+// AttributeAppBundlePath is the path the AppBundle attribution webhook is served on
+const AttributeAppBundlePath = "/attribute-app-open-cluster-management-io-v1alpha1-appbundle"
+
+//+kubebuilder:webhook:path=/attribute-app-open-cluster-management-io-v1alpha1-appbundle,mutating=true,failurePolicy=fail,sideEffects=None,groups=app.open-cluster-management.io,resources=appbundles,verbs=create;update,versions=v1alpha1,name=aappbundle.kb.io,admissionReviewVersions=v1
+
+// AppBundleAttributor records on AppBundles the user who last changed their spec.
+// The user is taken from the admission request, so it names the actual user or
+// service account rather than the field manager of the client it used. The webhook
+// fails closed, so that no bundle is written without it setting the annotation.
+type AppBundleAttributor struct {
+	decoder *admission.Decoder
+}
+
+// SetupWithManager registers the attributor with the webhook server of the Manager.
+func (a *AppBundleAttributor) SetupWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(AttributeAppBundlePath, &webhook.Admission{Handler: a})
+	return nil
+}
+
+// Handle sets the last modified by annotation to the requesting user when the bundle
+// is created or its spec changes, and otherwise keeps the previous value so that the
+// annotation cannot be forged
+func (a *AppBundleAttributor) Handle(ctx context.Context, req admission.Request) admission.Response {
+	bundle := &appv1alpha1.AppBundle{}
+	if err := a.decoder.Decode(req, bundle); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	user, set := req.UserInfo.Username, true
+	if req.Operation == admissionv1.Update {
+		old := &appv1alpha1.AppBundle{}
+		if err := a.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if equality.Semantic.DeepEqual(old.Spec, bundle.Spec) {
+			user, set = old.Annotations[audit.ModifiedByAnnotation]
+		}
+	}
+	if current, ok := bundle.Annotations[audit.ModifiedByAnnotation]; ok == set && current == user {
+		return admission.Allowed("")
+	}
+	if set {
+		if bundle.Annotations == nil {
+			bundle.Annotations = map[string]string{}
+		}
+		bundle.Annotations[audit.ModifiedByAnnotation] = user
+	} else {
+		delete(bundle.Annotations, audit.ModifiedByAnnotation)
+	}
+	raw, err := json.Marshal(bundle)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, raw)
+}
+
+// InjectDecoder injects the decoder.
+func (a *AppBundleAttributor) InjectDecoder(d *admission.Decoder) error {
+	a.decoder = d
+	return nil
+}
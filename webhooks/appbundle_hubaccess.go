@@ -0,0 +1,97 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+)
+
+// ValidateHubAccessPath is the path the AppBundle hub access webhook is served on
+const ValidateHubAccessPath = "/validate-app-open-cluster-management-io-v1alpha1-appbundle-hubaccess"
+
+//+kubebuilder:webhook:path=/validate-app-open-cluster-management-io-v1alpha1-appbundle-hubaccess,mutating=false,failurePolicy=fail,sideEffects=None,groups=app.open-cluster-management.io,resources=appbundles,verbs=create;update,versions=v1alpha1,name=vappbundlehubaccess.kb.io,admissionReviewVersions=v1
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// HubAccessValidator denies the AppBundles whose hub access binds a Role the requesting
+// user may not bind, as the controller binds the Role with its own permissions. It
+// fails closed, unlike the other webhooks of the bundles.
+type HubAccessValidator struct {
+	Client client.Client
+
+	decoder *admission.Decoder
+}
+
+// SetupWithManager registers the validator with the webhook server of the Manager.
+func (v *HubAccessValidator) SetupWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(ValidateHubAccessPath, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle admits the bundles without hub access, and the bundles with a hub access when
+// a SubjectAccessReview allows the user to bind its Role in the bundle namespace
+func (v *HubAccessValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	bundle := &appv1alpha1.AppBundle{}
+	if err := v.decoder.Decode(req, bundle); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	access := bundle.Spec.HubAccess
+	if access == nil {
+		return admission.Allowed("")
+	}
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, values := range req.UserInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(values)
+	}
+	review := &authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		User:   req.UserInfo.Username,
+		Groups: req.UserInfo.Groups,
+		UID:    req.UserInfo.UID,
+		Extra:  extra,
+		ResourceAttributes: &authorizationv1.ResourceAttributes{
+			Namespace: req.Namespace,
+			Verb:      "bind",
+			Group:     rbacv1.GroupName,
+			Resource:  "roles",
+			Name:      access.Role,
+		},
+	}}
+	if err := v.Client.Create(ctx, review); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !review.Status.Allowed {
+		return admission.Denied(fmt.Sprintf("user %s may not bind Role %s of namespace %s granted by the hub access",
+			req.UserInfo.Username, access.Role, req.Namespace))
+	}
+	return admission.Allowed("")
+}
+
+// InjectDecoder injects the decoder.
+func (v *HubAccessValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
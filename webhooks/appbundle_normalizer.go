@@ -0,0 +1,78 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/pkg/manifests"
+)
+
+// NormalizeAppBundlePath is the path the AppBundle mutating webhook is served on
+const NormalizeAppBundlePath = "/mutate-app-open-cluster-management-io-v1alpha1-appbundle"
+
+//+kubebuilder:webhook:path=/mutate-app-open-cluster-management-io-v1alpha1-appbundle,mutating=true,failurePolicy=ignore,sideEffects=None,groups=app.open-cluster-management.io,resources=appbundles,verbs=create;update,versions=v1alpha1,name=mappbundle.kb.io,admissionReviewVersions=v1
+
+// AppBundleNormalizer normalizes the inline manifests of AppBundles on create and
+// update. Mutating webhooks run before the schema validation, so strings of YAML
+// documents and JSON arrays pasted in a single manifest are split into resources
+// before the schema rejects them.
+type AppBundleNormalizer struct {
+	decoder *admission.Decoder
+}
+
+// SetupWithManager registers the normalizer with the webhook server of the Manager.
+func (n *AppBundleNormalizer) SetupWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(NormalizeAppBundlePath, &webhook.Admission{Handler: n})
+	return nil
+}
+
+// Handle patches the bundle with one manifest per resource, and denies it when a
+// manifest cannot be parsed, as it would otherwise fail on the managed clusters
+func (n *AppBundleNormalizer) Handle(ctx context.Context, req admission.Request) admission.Response {
+	bundle := &appv1alpha1.AppBundle{}
+	if err := n.decoder.Decode(req, bundle); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	ms, err := manifests.Normalize(bundle.Spec.Workload.Manifests)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	if equality.Semantic.DeepEqual(ms, bundle.Spec.Workload.Manifests) {
+		return admission.Allowed("")
+	}
+	bundle.Spec.Workload.Manifests = ms
+	raw, err := json.Marshal(bundle)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, raw)
+}
+
+// InjectDecoder injects the decoder.
+func (n *AppBundleNormalizer) InjectDecoder(d *admission.Decoder) error {
+	n.decoder = d
+	return nil
+}
@@ -0,0 +1,486 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appv1alpha1 "github.com/pdettori/kealm/api/v1alpha1"
+	"github.com/pdettori/kealm/controllers"
+	"github.com/pdettori/kealm/pkg/config"
+	"github.com/pdettori/kealm/pkg/lint"
+	"github.com/pdettori/kealm/pkg/manifests"
+	"github.com/pdettori/kealm/pkg/quota"
+	"github.com/pdettori/kealm/pkg/rollout"
+	clusterlisterv1alpha1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1alpha1"
+)
+
+// ValidateAppBundlePath is the path the AppBundle validating webhook is served on
+const ValidateAppBundlePath = "/validate-app-open-cluster-management-io-v1alpha1-appbundle"
+
+//+kubebuilder:webhook:path=/validate-app-open-cluster-management-io-v1alpha1-appbundle,mutating=false,failurePolicy=ignore,sideEffects=None,groups=app.open-cluster-management.io,resources=appbundles,verbs=create;update,versions=v1alpha1,name=vappbundle.kb.io,admissionReviewVersions=v1
+
+// AppBundleValidator validates AppBundles on create and update
+type AppBundleValidator struct {
+	Client          client.Reader
+	PlacementLister clusterlisterv1alpha1.PlacementLister
+	Config          *config.Store
+
+	decoder *admission.Decoder
+}
+
+// SetupWithManager registers the validator with the webhook server of the Manager.
+func (v *AppBundleValidator) SetupWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(ValidateAppBundlePath, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle admits the bundle, warning the user when the referenced placement does not
+// exist or cannot be satisfied. Bundles setting a namespace on cluster-scoped resources,
+// or a target namespace without effect, or exceeding the quota of their namespace, are
+// denied. The findings of the lint rules
+// are returned as warnings, or deny the bundle, depending on their severity. Only the users of the override groups of the security
+// gate may override it.
+func (v *AppBundleValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	bundle := &appv1alpha1.AppBundle{}
+	if err := v.decoder.Decode(req, bundle); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if override, ok := bundle.Annotations[controllers.SecurityGateOverrideAnnotation]; ok {
+		old := &appv1alpha1.AppBundle{}
+		if len(req.OldObject.Raw) > 0 {
+			if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+				return admission.Errored(http.StatusBadRequest, err)
+			}
+		}
+		previous, existed := old.Annotations[controllers.SecurityGateOverrideAnnotation]
+		if (!existed || previous != override) && !v.mayOverride(req.UserInfo.Groups) {
+			return admission.Denied("only the override groups of the security gate may set the " +
+				controllers.SecurityGateOverrideAnnotation + " annotation")
+		}
+	}
+
+	if err := validateScopes(bundle); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := validateScaling(bundle.Spec.Scaling); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := validateRequirements(bundle.Spec.Requires); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := validateAntiAffinity(bundle.Spec.AntiAffinity); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := validateComponents(bundle); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := validateBlueGreen(bundle.Spec.BlueGreen); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := validateRollout(bundle.Spec.Rollout); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := validateRolloutOverrides(bundle.Spec.Rollout, bundle.Spec.RolloutOverrides); err != nil {
+		return admission.Denied(err.Error())
+	}
+	for _, cert := range bundle.Spec.Certificates {
+		if cert.Namespace == "" && bundle.Spec.TargetNamespace == "" {
+			return admission.Denied(fmt.Sprintf("the namespace of certificate %s must be set when the bundle has no target namespace", cert.Name))
+		}
+	}
+	if a := bundle.Spec.HubAccess; a != nil && a.Namespace == "" && bundle.Spec.TargetNamespace == "" {
+		return admission.Denied("the namespace of the hub access must be set when the bundle has no target namespace")
+	}
+	if err := validateSecretValues(bundle); err != nil {
+		return admission.Denied(err.Error())
+	}
+	if exceeded, err := v.checkQuota(ctx, req, bundle); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	} else if len(exceeded) > 0 {
+		return admission.Denied("the quota of the namespace is exceeded: " + strings.Join(exceeded, "; "))
+	}
+
+	warnings, err := v.lint(bundle)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	if _, ok := bundle.GetLabels()[controllers.PlacementLabel]; ok && bundle.Spec.PlacementPolicy != "" {
+		return admission.Denied("the placement policy cannot be combined with the " + controllers.PlacementLabel + " label")
+	}
+	// the managed placement is created when the bundle is reconciled
+	if placement, ok := controllers.PlacementName(bundle); ok && placement != controllers.AllClustersPlacement {
+		status, _, message, err := controllers.CheckPlacement(v.PlacementLister, req.Namespace, placement)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if status != v1.ConditionTrue {
+			warnings = append(warnings, message)
+		}
+	}
+
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// checkQuota returns the limits of the quota of the namespace exceeded by a new bundle,
+// or by the inline manifests of the bundle. The clusters are checked when reconciling,
+// once the placement is resolved.
+func (v *AppBundleValidator) checkQuota(ctx context.Context, req admission.Request, bundle *appv1alpha1.AppBundle) ([]string, error) {
+	if v.Client == nil {
+		return nil, nil
+	}
+	tenant, err := controllers.TenantOf(ctx, v.Client, req.Namespace)
+	if err != nil || tenant == nil || tenant.Spec.Quota == nil {
+		return nil, err
+	}
+	var bundles appv1alpha1.AppBundleList
+	if err := v.Client.List(ctx, &bundles, client.InNamespace(req.Namespace)); err != nil {
+		return nil, err
+	}
+	others := []appv1alpha1.AppBundle{}
+	for _, b := range bundles.Items {
+		if b.Name != bundle.Name {
+			others = append(others, b)
+		}
+	}
+	usage, _ := quota.Usage(others)
+	q := *tenant.Spec.Quota
+	q.MaxClusters = nil
+	if req.Operation == admissionv1.Create {
+		usage.Bundles++
+	} else {
+		q.MaxBundles = nil
+	}
+	ms, err := manifests.Normalize(bundle.Spec.Workload.Manifests)
+	if err != nil {
+		return nil, err
+	}
+	usage.ManifestBytes += quota.Size(ms)
+	return quota.Check(&q, usage), nil
+}
+
+// validateScopes checks the scopes of the inline manifests. The target namespace is
+// only checked when the bundle has no workload references, as their manifests are
+// read when reconciling.
+func validateScopes(bundle *appv1alpha1.AppBundle) error {
+	ms, err := manifests.Normalize(bundle.Spec.Workload.Manifests)
+	if err != nil {
+		return err
+	}
+	if err := manifests.ValidateScopes(ms); err != nil {
+		return err
+	}
+	if bundle.Spec.TargetNamespace == "" || len(bundle.Spec.WorkloadRefs) > 0 {
+		return nil
+	}
+	_, err = manifests.SetNamespace(ms, bundle.Spec.TargetNamespace)
+	return err
+}
+
+// validateScaling checks that the scaling rules have valid cluster selectors, and set
+// either replicas or a positive multiplier
+func validateScaling(s *appv1alpha1.Scaling) error {
+	if s == nil {
+		return nil
+	}
+	for i, rule := range s.Rules {
+		if _, err := v1.LabelSelectorAsSelector(&rule.ClusterSelector); err != nil {
+			return fmt.Errorf("invalid cluster selector of scaling rule %d: %w", i, err)
+		}
+		if (rule.Replicas == nil) == (rule.Multiplier == nil) {
+			return fmt.Errorf("scaling rule %d must set either replicas or multiplier", i)
+		}
+		if rule.Multiplier != nil && rule.Multiplier.Sign() < 0 {
+			return fmt.Errorf("multiplier of scaling rule %d must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// validateRequirements checks that the requirements set either a name or a valid
+// selector
+func validateRequirements(requires []appv1alpha1.BundleRequirement) error {
+	for i, req := range requires {
+		if (req.Name == "") == (req.Selector == nil) {
+			return fmt.Errorf("requirement %d must set either name or selector", i)
+		}
+		if req.Selector != nil {
+			if _, err := v1.LabelSelectorAsSelector(req.Selector); err != nil {
+				return fmt.Errorf("invalid selector of requirement %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateAntiAffinity(antiAffinity []appv1alpha1.BundleAntiAffinity) error {
+	for i, a := range antiAffinity {
+		if (a.Name == "") == (a.Selector == nil) {
+			return fmt.Errorf("anti-affinity %d must set either name or selector", i)
+		}
+		if a.Selector != nil {
+			if _, err := v1.LabelSelectorAsSelector(a.Selector); err != nil {
+				return fmt.Errorf("invalid selector of anti-affinity %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBlueGreen checks that a blue/green bundle sets either a switch or a mesh, with
+// increasing steps below 100
+func validateBlueGreen(bg *appv1alpha1.BlueGreen) error {
+	if bg == nil {
+		return nil
+	}
+	hasSwitch := len(bg.Switch.Raw) > 0 || bg.Switch.Object != nil
+	if hasSwitch == (bg.Mesh != nil) {
+		return fmt.Errorf("blueGreen must set either switch or mesh")
+	}
+	if bg.Mesh == nil {
+		return nil
+	}
+	previous := int32(0)
+	for _, w := range bg.Mesh.Steps {
+		if w <= previous || w >= 100 {
+			return fmt.Errorf("the mesh steps must be increasing percentages between 1 and 99")
+		}
+		previous = w
+	}
+	return nil
+}
+
+// validateRollout checks that the waves have unique names, other than the name of the
+// remaining wave, and valid selectors
+func validateRollout(r *appv1alpha1.Rollout) error {
+	if r == nil {
+		return nil
+	}
+	if len(r.Waves) == 0 {
+		return fmt.Errorf("the rollout must have waves")
+	}
+	names := map[string]bool{}
+	for _, w := range r.Waves {
+		if w.Name == "" || w.Name == rollout.RemainingWave || names[w.Name] {
+			return fmt.Errorf("the waves must have unique names other than %s", rollout.RemainingWave)
+		}
+		names[w.Name] = true
+		if w.ClusterSelector != nil {
+			if _, err := v1.LabelSelectorAsSelector(w.ClusterSelector); err != nil {
+				return fmt.Errorf("invalid cluster selector of wave %s: %w", w.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRolloutOverrides checks that the overrides name waves of the rollout, and
+// defer each cluster once. Whether the clusters move to a later wave depends on their
+// labels, checked when reconciling.
+func validateRolloutOverrides(r *appv1alpha1.Rollout, o *appv1alpha1.RolloutOverrides) error {
+	if o == nil {
+		return nil
+	}
+	if r == nil {
+		return fmt.Errorf("the rollout overrides require a rollout")
+	}
+	waves := map[string]bool{rollout.RemainingWave: true}
+	for _, w := range r.Waves {
+		waves[w.Name] = true
+	}
+	for _, name := range o.PausedWaves {
+		if !waves[name] {
+			return fmt.Errorf("paused wave %s is not a wave of the rollout", name)
+		}
+	}
+	deferred := map[string]bool{}
+	for _, d := range o.Deferrals {
+		if d.Cluster == "" || deferred[d.Cluster] {
+			return fmt.Errorf("the deferrals must name each cluster once")
+		}
+		deferred[d.Cluster] = true
+		if !waves[d.Wave] {
+			return fmt.Errorf("wave %s of cluster %s is not a wave of the rollout", d.Wave, d.Cluster)
+		}
+	}
+	return nil
+}
+
+// validateComponents checks that the components have unique names, depend on other
+// components of the bundle without cycles, that their inline manifests have valid
+// scopes and that only the Crossplane components have outputs
+func validateComponents(bundle *appv1alpha1.AppBundle) error {
+	dependencies := map[string][]string{}
+	for i, c := range bundle.Spec.Components {
+		if c.Name == "" {
+			return fmt.Errorf("component %d must have a name", i)
+		}
+		if _, ok := dependencies[c.Name]; ok {
+			return fmt.Errorf("duplicate component %s", c.Name)
+		}
+		dependencies[c.Name] = c.DependsOn
+	}
+	for _, c := range bundle.Spec.Components {
+		for _, dep := range c.DependsOn {
+			if _, ok := dependencies[dep]; !ok {
+				return fmt.Errorf("component %s depends on unknown component %s", c.Name, dep)
+			}
+		}
+		if dependsOn(dependencies, c.Name, c.Name, map[string]bool{}) {
+			return fmt.Errorf("component %s depends on itself", c.Name)
+		}
+		if err := validateComponentType(c); err != nil {
+			return err
+		}
+		ms, err := manifests.Normalize(c.Manifests)
+		if err != nil {
+			return fmt.Errorf("invalid manifests of component %s: %w", c.Name, err)
+		}
+		if err := manifests.ValidateScopes(ms); err != nil {
+			return fmt.Errorf("invalid manifests of component %s: %w", c.Name, err)
+		}
+		namespace := c.TargetNamespace
+		if namespace == "" {
+			namespace = bundle.Spec.TargetNamespace
+		}
+		if namespace == "" || len(c.WorkloadRefs) > 0 || c.Type == appv1alpha1.ComponentCrossplane {
+			continue
+		}
+		if _, err := manifests.SetNamespace(ms, namespace); err != nil {
+			return fmt.Errorf("invalid manifests of component %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateSecretValues checks that the secret values have unique names and a single
+// provider, and are referenced by cluster templates
+func validateSecretValues(bundle *appv1alpha1.AppBundle) error {
+	if len(bundle.Spec.SecretValues) > 0 && !bundle.Spec.ClusterTemplating {
+		return fmt.Errorf("the secret values are only available with cluster templating")
+	}
+	names := map[string]bool{}
+	for _, s := range bundle.Spec.SecretValues {
+		if names[s.Name] {
+			return fmt.Errorf("duplicate secret value %s", s.Name)
+		}
+		names[s.Name] = true
+		if (s.ValueFrom.Vault == nil) == (s.ValueFrom.AWSSecretsManager == nil) {
+			return fmt.Errorf("secret value %s must be looked up in exactly one provider", s.Name)
+		}
+	}
+	return nil
+}
+
+// validateComponentType checks that the Crossplane components, applied on the hub, are
+// not constrained like the distributed ones, and that their outputs read one value
+func validateComponentType(c appv1alpha1.Component) error {
+	if c.Type != appv1alpha1.ComponentCrossplane {
+		if len(c.Outputs) > 0 {
+			return fmt.Errorf("component %s has outputs but is not a Crossplane component", c.Name)
+		}
+		return nil
+	}
+	if len(c.DependsOn) > 0 || c.OS != nil || c.TargetNamespace != "" {
+		return fmt.Errorf("Crossplane component %s is applied in the namespace of the bundle and cannot have dependencies, an OS or a target namespace", c.Name)
+	}
+	names := map[string]bool{}
+	for _, o := range c.Outputs {
+		if names[o.Name] {
+			return fmt.Errorf("duplicate output %s of component %s", o.Name, c.Name)
+		}
+		names[o.Name] = true
+		if (o.FieldPath == "") == (o.ConnectionSecretKey == "") {
+			return fmt.Errorf("output %s of component %s must set either a field path or a connection secret key", o.Name, c.Name)
+		}
+	}
+	return nil
+}
+
+// dependsOn returns true if the component depends on the target, directly or through
+// the components it depends on
+func dependsOn(dependencies map[string][]string, component, target string, visited map[string]bool) bool {
+	if visited[component] {
+		return false
+	}
+	visited[component] = true
+	for _, dep := range dependencies[component] {
+		if dep == target || dependsOn(dependencies, dep, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// lint returns the warnings of the lint rules for the inline manifests, and an error
+// listing the findings of the denying rules
+func (v *AppBundleValidator) lint(bundle *appv1alpha1.AppBundle) ([]string, error) {
+	var rules []appv1alpha1.LintRule
+	if v.Config != nil {
+		rules = v.Config.Get().LintRules
+	}
+	ms, err := manifests.Normalize(bundle.Spec.Workload.Manifests)
+	if err != nil {
+		return nil, err
+	}
+	findings, err := lint.Lint(ms, rules)
+	if err != nil {
+		return nil, err
+	}
+	denied, warned := lint.Split(findings)
+	warnings := []string{}
+	for _, f := range warned {
+		warnings = append(warnings, f.String())
+	}
+	if len(denied) > 0 {
+		messages := []string{}
+		for _, f := range denied {
+			messages = append(messages, f.String())
+		}
+		return nil, fmt.Errorf("lint failed: %s", strings.Join(messages, "; "))
+	}
+	return warnings, nil
+}
+
+// mayOverride returns true if one of the groups may override the security gate
+func (v *AppBundleValidator) mayOverride(groups []string) bool {
+	if v.Config == nil {
+		return false
+	}
+	cfg := v.Config.Get()
+	if cfg.SecurityGate == nil {
+		return false
+	}
+	return sets.NewString(cfg.SecurityGate.OverrideGroups...).HasAny(groups...)
+}
+
+// InjectDecoder injects the decoder.
+func (v *AppBundleValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}